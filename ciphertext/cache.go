@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/services/kms"
 	"bitbucket.di2e.net/dime/object-drive-server/util"
 
 	"crypto/sha256"
@@ -56,6 +57,12 @@ type CiphertextCacheData struct {
 	Logger *zap.Logger
 	// MasterKey is the secret passphrase used in scrambling keys
 	MasterKey string
+	// PreviousMasterKey is the passphrase being rotated away from, accepted until rotation completes
+	PreviousMasterKey string
+	// deduplicate indicates that identical content is stored only once
+	deduplicate bool
+	// keyID identifies the key of the key provider that wraps the master key
+	keyID string
 }
 
 // NewCiphertextCacheRaw is a cache that goes off to PermanentStorage.
@@ -97,8 +104,11 @@ func NewCiphertextCacheRaw(
 		ChunkSize:              conf.ChunkSize * 1024 * 1024,
 		Logger:                 logger,
 		MasterKey:              conf.MasterKey,
+		PreviousMasterKey:      conf.PreviousMasterKey,
 		fileLimit:              conf.FileLimit,
 		fileSleep:              time.Duration(conf.FileSleep) * time.Millisecond,
+		deduplicate:            conf.Deduplicate,
+		keyID:                  conf.KeyID,
 	}
 	CacheMustExist(d, logger)
 
@@ -106,9 +116,138 @@ func NewCiphertextCacheRaw(
 		zap.String("mount", conf.Root),
 		zap.String("location", d.CacheLocationString),
 	)
+	if keyProvider != nil {
+		if err := d.loadDataKey(keyProvider); err != nil {
+			return d, err
+		}
+	}
 	return d, d.masterKeyCheck()
 }
 
+// loadDataKey replaces the configured master key with the data key kept
+// wrapped in PermanentStorage, creating it on first use. A master key that
+// was configured before a key provider was is wrapped as the data key, so
+// that existing file keys remain readable. The data key is wrapped again on
+// every start, so that it moves to the current version of a rotated key.
+func (d *CiphertextCacheData) loadDataKey(p kms.KeyProvider) *util.Loggable {
+	rName := FileId("datakey")
+	have, err := d.haveCanary(rName)
+	if err != nil && err.Msg != PermanentStorageNotFoundErrorString {
+		return err
+	}
+	if have == "" {
+		created, err := d.createDataKey(p, rName)
+		if err != nil || created {
+			return err
+		}
+		// Another instance starting at the same time created it first
+		d.Logger.Info("ciphertextcache data key was created by another instance", zap.String("keyID", d.keyID))
+		if have, err = d.haveCanary(rName); err != nil {
+			return err
+		}
+	}
+	unhexed, e := hex.DecodeString(strings.TrimSpace(have))
+	if e != nil {
+		return util.NewLoggable("ciphertextcache data key decode fail", e)
+	}
+	dataKey, wrapped, e := kms.Rewrap(p, d.keyID, unhexed)
+	if e != nil {
+		return util.NewLoggable("ciphertextcache data key rewrap fail", e, zap.String("keyID", d.keyID))
+	}
+	if d.MasterKey != "" && d.MasterKey != string(dataKey) {
+		// A data key that the canary does not name, when it names the
+		// configured master key, was created by a start without the master
+		// key configured, and nothing was stored with it
+		canary, err := d.haveCanary(FileId("canary"))
+		if err != nil && err.Msg != PermanentStorageNotFoundErrorString {
+			return err
+		}
+		if canary != "" && canary != hashMasterKey(string(dataKey)) && canary == hashMasterKey(d.MasterKey) {
+			d.Logger.Warn("ciphertextcache wrapped data key is replaced by configured master key named by canary", zap.String("keyID", d.keyID))
+			dataKey = []byte(d.MasterKey)
+			if wrapped, e = p.Wrap(d.keyID, dataKey); e != nil {
+				return util.NewLoggable("ciphertextcache data key wrap fail", e, zap.String("keyID", d.keyID))
+			}
+		} else {
+			d.Logger.Warn("ciphertextcache configured master key is ignored in favor of wrapped data key", zap.String("keyID", d.keyID))
+		}
+	}
+	return d.setDataKey(rName, dataKey, wrapped)
+}
+
+// createDataKey wraps the master key that content is stored with as the data
+// key, or a new key if no content has been stored, and stores it unless
+// another instance already has, reporting whether it was stored. The key is
+// checked against the canary before anything is written, so that a missing or
+// wrong master key is refused rather than replaced by a key that nothing was
+// stored with.
+func (d *CiphertextCacheData) createDataKey(p kms.KeyProvider, rName FileId) (bool, *util.Loggable) {
+	canary, err := d.haveCanary(FileId("canary"))
+	if err != nil && err.Msg != PermanentStorageNotFoundErrorString {
+		return false, err
+	}
+	var dataKey []byte
+	switch {
+	case canary != "" && d.MasterKey == "":
+		return false, util.NewLoggable("ciphertextcache data key missing", nil,
+			zap.String("detail",
+				"Content is stored with a master key that is not configured. Set OD_ENCRYPT_MASTERKEY to the master key used before the key provider was configured, so that it is wrapped as the data key.",
+			),
+			zap.String("keyID", d.keyID),
+		)
+	case canary != "" && canary != hashMasterKey(d.MasterKey) &&
+		(d.PreviousMasterKey == "" || canary != hashMasterKey(d.PreviousMasterKey)):
+		return false, util.NewLoggable("ciphertextcache canary mismatch", nil,
+			zap.String("detail",
+				"The configured master key is not the one content is stored with, so it is not wrapped as the data key. Check your configuration settings.",
+			),
+			zap.String("haveCanary", canary),
+			zap.String("expectCanary", hashMasterKey(d.MasterKey)),
+		)
+	case d.MasterKey != "":
+		d.Logger.Info("ciphertextcache master key is being wrapped as data key", zap.String("keyID", d.keyID))
+		dataKey = []byte(d.MasterKey)
+	default:
+		d.Logger.Info("ciphertextcache data key is being created", zap.String("keyID", d.keyID))
+		newKey, e := kms.NewDataKey()
+		if e != nil {
+			return false, util.NewLoggable("ciphertextcache data key create fail", e)
+		}
+		dataKey = newKey
+	}
+	wrapped, e := p.Wrap(d.keyID, dataKey)
+	if e != nil {
+		return false, util.NewLoggable("ciphertextcache data key wrap fail", e, zap.String("keyID", d.keyID))
+	}
+	if d.PermanentStorage == nil {
+		// A single instance, so there is no other to race with
+		return true, d.setDataKey(rName, dataKey, wrapped)
+	}
+	key := toKey(string(d.Resolve(NewFileName(rName, ""))))
+	created, e := d.PermanentStorage.UploadIfAbsent(strings.NewReader(hex.EncodeToString(wrapped)), key)
+	if e != nil {
+		return false, util.NewLoggable("ciphertextcache data key store fail", e, zap.String("key", *key))
+	}
+	if created {
+		d.useDataKey(dataKey)
+	}
+	return created, nil
+}
+
+// setDataKey uses a data key as the master key, and writes it back wrapped
+func (d *CiphertextCacheData) setDataKey(rName FileId, dataKey, wrapped []byte) *util.Loggable {
+	d.useDataKey(dataKey)
+	return d.expectCanary(rName, hex.EncodeToString(wrapped))
+}
+
+// useDataKey uses a data key as the master key
+func (d *CiphertextCacheData) useDataKey(dataKey []byte) {
+	d.MasterKey = string(dataKey)
+	if d.PreviousMasterKey == d.MasterKey {
+		d.PreviousMasterKey = ""
+	}
+}
+
 // Delete the local cache
 func (d *CiphertextCacheData) Delete() error {
 	return d.Files().RemoveAll(FileNameCached(d.CacheLocationString))
@@ -170,8 +309,7 @@ func (d *CiphertextCacheData) expectCanary(rName FileId, expected string) *util.
 // masterKeyCheck will advise the caller to panic if the masterKey being used is definitely wrong
 func (d *CiphertextCacheData) masterKeyCheck() *util.Loggable {
 	// The expected value is a hex hash of our key stored in a canary file
-	hashedKeyBytes := sha256.Sum256([]byte(d.MasterKey))
-	expected := hex.EncodeToString(hashedKeyBytes[:])
+	expected := hashMasterKey(d.MasterKey)
 	// The canary file
 	rName := FileId("canary")
 	have, err := d.haveCanary(rName)
@@ -190,6 +328,12 @@ func (d *CiphertextCacheData) masterKeyCheck() *util.Loggable {
 		return err
 	}
 
+	// While rotating, the canary still names the previous key until rotation completes
+	if d.PreviousMasterKey != "" && have == hashMasterKey(d.PreviousMasterKey) {
+		d.Logger.Info("ciphertextcache canary matches previous master key, rotation in progress")
+		return nil
+	}
+
 	// Fail if we don't have what we expected and we have something specific
 	if have != expected {
 		// If we are going to fail to come up, delete the cached key, as it's invalid.
@@ -210,12 +354,55 @@ func (d *CiphertextCacheData) masterKeyCheck() *util.Loggable {
 	return nil
 }
 
+// hashMasterKey is the canary value for a master key
+func hashMasterKey(masterKey string) string {
+	hashedKeyBytes := sha256.Sum256([]byte(masterKey))
+	return hex.EncodeToString(hashedKeyBytes[:])
+}
+
+// CompleteKeyRotation replaces the canary for the previous master key with
+// the canary for the current one, so that instances still configured with
+// only the previous key refuse to start.
+func (d *CiphertextCacheData) CompleteKeyRotation() *util.Loggable {
+	if d.PreviousMasterKey == "" {
+		return nil
+	}
+	d.Logger.Info("ciphertextcache canary is being set for rotated master key")
+	return d.expectCanary(FileId("canary"), hashMasterKey(d.MasterKey))
+}
+
+// GetPreviousMasterKey is the key being rotated away from, or empty if this
+// cache is not rotating its master key
+func (d *CiphertextCacheData) GetPreviousMasterKey() string {
+	return d.PreviousMasterKey
+}
+
 // GetMasterKey is the key for this cache - no more system global masterkey
 // This means that in order to have a key, you need to have an object that it refers to
 func (d *CiphertextCacheData) GetMasterKey() string {
 	return d.MasterKey
 }
 
+// Deduplicates indicates whether identical content stored in this cache is
+// stored only once
+func (d *CiphertextCacheData) Deduplicates() bool {
+	return d.deduplicate
+}
+
+// Purge removes a file from the cache and from PermanentStorage. It must only
+// be called once no object refers to the file.
+func (d *CiphertextCacheData) Purge(rName FileId) error {
+	for _, ext := range []string{FileStateUploaded, FileStateCached} {
+		if err := d.Files().Remove(d.Resolve(NewFileName(rName, ext))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if d.PermanentStorage == nil {
+		return nil
+	}
+	return d.PermanentStorage.Delete(toKey(string(d.Resolve(NewFileName(rName, "")))))
+}
+
 // Resolve a name to somewhere in the cache, given the rName
 func (d *CiphertextCacheData) Resolve(fName FileName) FileNameCached {
 	return FileNameCached(filepath.Join(d.CacheLocationString, string(fName)))
@@ -2,12 +2,14 @@ package ciphertext_test
 
 import (
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
 	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/services/kms"
 	"bitbucket.di2e.net/dime/object-drive-server/util"
 )
 
@@ -168,3 +170,143 @@ func TestCacheCreateWrongKey(t *testing.T) {
 	t.Logf("correct key correctly brought up existing purged cache")
 
 }
+
+//
+// Rotate the master key of a (local) cache.
+// During rotation both keys are accepted, afterwards only the new key.
+//
+func TestCacheRotateKey(t *testing.T) {
+	logger := config.RootLogger
+	testRoot, err := ioutil.TempDir("", "rotatekey")
+	if err != nil {
+		t.Fatalf("unable to create test root: %v", err)
+	}
+	defer os.RemoveAll(testRoot)
+	_, zone, conf, dbID := cacheParams(testRoot, "partition0")
+	oldKey := conf.MasterKey
+	newKey := "rotatedTestKey"
+
+	d, loggableErr := ciphertext.NewLocalCiphertextCache(logger, zone, conf, dbID)
+	if loggableErr != nil {
+		loggableErr.ToError(logger)
+		t.Fatalf("unable to create cache")
+	}
+
+	// Bring up a rotating cache alongside one still using only the old key
+	conf.MasterKey = newKey
+	conf.PreviousMasterKey = oldKey
+	d, loggableErr = ciphertext.NewLocalCiphertextCache(logger, zone, conf, dbID)
+	if loggableErr != nil {
+		loggableErr.ToError(logger)
+		t.Fatalf("rotating cache should accept the canary of the previous key")
+	}
+	if d.GetPreviousMasterKey() != oldKey {
+		t.Fatalf("rotating cache did not report the previous key")
+	}
+
+	// Once rotation completes, only the new key is accepted
+	if loggableErr = d.CompleteKeyRotation(); loggableErr != nil {
+		loggableErr.ToError(logger)
+		t.Fatalf("unable to complete rotation")
+	}
+	conf.MasterKey = oldKey
+	conf.PreviousMasterKey = ""
+	if _, loggableErr = ciphertext.NewLocalCiphertextCache(logger, zone, conf, dbID); loggableErr == nil {
+		t.Fatalf("previous key should be rejected after rotation completes")
+	}
+	conf.MasterKey = newKey
+	if _, loggableErr = ciphertext.NewLocalCiphertextCache(logger, zone, conf, dbID); loggableErr != nil {
+		loggableErr.ToError(logger)
+		t.Fatalf("new key should be accepted after rotation completes")
+	}
+}
+
+func TestCacheKeyProvider(t *testing.T) {
+	logger := config.RootLogger
+	testRoot, err := ioutil.TempDir("", "keyprovider")
+	if err != nil {
+		t.Fatalf("unable to create test root: %v", err)
+	}
+	defer os.RemoveAll(testRoot)
+	_, zone, conf, dbID := cacheParams(testRoot, "partition0")
+	configuredKey := conf.MasterKey
+	conf.KeyID = "testzone"
+
+	keyring, err := kms.NewKeyring(filepath.Join(testRoot, "keyring.json"))
+	if err != nil {
+		t.Fatalf("unable to create keyring: %v", err)
+	}
+	ciphertext.SetKeyProvider(keyring)
+	defer ciphertext.SetKeyProvider(nil)
+
+	// The configured master key is wrapped as the data key on first use
+	d, loggableErr := ciphertext.NewLocalCiphertextCache(logger, zone, conf, dbID)
+	if loggableErr != nil {
+		loggableErr.ToError(logger)
+		t.Fatalf("unable to create cache")
+	}
+	if d.GetMasterKey() != configuredKey {
+		t.Fatalf("configured master key was not kept as the data key")
+	}
+
+	// Once wrapped, the data key is used even without a configured master key,
+	// and survives rotation of the key that wraps it
+	if err := keyring.Rotate(conf.KeyID); err != nil {
+		t.Fatalf("unable to rotate key: %v", err)
+	}
+	conf.MasterKey = ""
+	d, loggableErr = ciphertext.NewLocalCiphertextCache(logger, zone, conf, dbID)
+	if loggableErr != nil {
+		loggableErr.ToError(logger)
+		t.Fatalf("unable to create cache from wrapped data key")
+	}
+	if d.GetMasterKey() != configuredKey {
+		t.Fatalf("wrapped data key was not unwrapped")
+	}
+}
+
+func TestCacheKeyProviderChecksCanary(t *testing.T) {
+	logger := config.RootLogger
+	testRoot, err := ioutil.TempDir("", "keyprovidercanary")
+	if err != nil {
+		t.Fatalf("unable to create test root: %v", err)
+	}
+	defer os.RemoveAll(testRoot)
+	_, zone, conf, dbID := cacheParams(testRoot, "partition0")
+	configuredKey := conf.MasterKey
+	conf.KeyID = "testzone"
+
+	// Content is stored with the master key before a key provider is used
+	if _, loggableErr := ciphertext.NewLocalCiphertextCache(logger, zone, conf, dbID); loggableErr != nil {
+		loggableErr.ToError(logger)
+		t.Fatalf("unable to create cache")
+	}
+
+	keyring, err := kms.NewKeyring(filepath.Join(testRoot, "keyring.json"))
+	if err != nil {
+		t.Fatalf("unable to create keyring: %v", err)
+	}
+	ciphertext.SetKeyProvider(keyring)
+	defer ciphertext.SetKeyProvider(nil)
+
+	// Without the master key, or with the wrong one, no data key is created
+	conf.MasterKey = ""
+	if _, loggableErr := ciphertext.NewLocalCiphertextCache(logger, zone, conf, dbID); loggableErr == nil {
+		t.Fatalf("expected cache without the master key to be refused")
+	}
+	conf.MasterKey = "wrongkey"
+	if _, loggableErr := ciphertext.NewLocalCiphertextCache(logger, zone, conf, dbID); loggableErr == nil {
+		t.Fatalf("expected cache with the wrong master key to be refused")
+	}
+
+	// So the master key is still wrapped as the data key once configured
+	conf.MasterKey = configuredKey
+	d, loggableErr := ciphertext.NewLocalCiphertextCache(logger, zone, conf, dbID)
+	if loggableErr != nil {
+		loggableErr.ToError(logger)
+		t.Fatalf("unable to create cache with the master key")
+	}
+	if d.GetMasterKey() != configuredKey {
+		t.Fatalf("configured master key was not kept as the data key")
+	}
+}
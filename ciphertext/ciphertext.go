@@ -37,11 +37,14 @@ type PeerMapData struct {
 	CertKey string
 }
 
+// randomNameSize is the number of random bytes in the name of each file
+// NOTE: This length affects the rname a.k.a. contentConnector length when in hexadecimal format.  If this is altered
+// the API request for the Ciphertext route regular expression may need updated.
+const randomNameSize = 26
+
 // CreateRandomName gives each file a random name
 func CreateRandomName() string {
-	// NOTE: This length affects the rname a.k.a. contentConnector length when in hexadecimal format.  If this is altered
-	// the API request for the Ciphertext route regular expression may need updated.
-	key := make([]byte, 26)
+	key := make([]byte, randomNameSize)
 	rand.Read(key)
 	return hex.EncodeToString(key)
 }
@@ -149,6 +152,21 @@ func UseLocalFile(logger *zap.Logger, d CiphertextCache, rName FileId, cipherSta
 	return cipherFile, length, nil
 }
 
+// IsAwaitingUpload reports whether a file that is not in permanent storage is
+// held by the cache of this instance waiting to be uploaded, or by the cache
+// of a peer
+func IsAwaitingUpload(logger *zap.Logger, d CiphertextCache, rName FileId) bool {
+	if _, err := d.Files().Stat(d.Resolve(NewFileName(rName, FileStateUploaded))); err == nil {
+		return true
+	}
+	peerFile, err := useP2PFile(logger, d.GetCiphertextCacheZone(), rName, 0)
+	if err != nil || peerFile == nil {
+		return false
+	}
+	peerFile.Close()
+	return true
+}
+
 // useP2PFile is similar to useLocalFile, except it searches peer caches for our ciphertext.
 // It is better to do this than it is to stall while the file moves to S3.
 //
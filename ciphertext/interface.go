@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/services/kms"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
 
 	"go.uber.org/zap"
 )
@@ -55,8 +57,16 @@ type CiphertextCache interface {
 	BackgroundRecache(rName FileId, totalLength int64)
 	// GetMasterKey is the key for this cache
 	GetMasterKey() string
+	// GetPreviousMasterKey is the key being rotated away from, if any
+	GetPreviousMasterKey() string
+	// CompleteKeyRotation marks the rotation away from the previous key as done
+	CompleteKeyRotation() *util.Loggable
 	// Delete the local cache
 	Delete() error
+	// Deduplicates indicates whether identical content stored in this cache is stored only once
+	Deduplicates() bool
+	// Purge removes a file from the cache and from PermanentStorage once nothing refers to it
+	Purge(rName FileId) error
 }
 
 // ciphertextCaches is the named set of local caches that are bound to a remote bucket (S3 or possibly something else)
@@ -65,11 +75,14 @@ var ciphertextCaches = make(map[CiphertextCacheZone]CiphertextCache)
 
 // FindCiphertextCacheByObject gets us a drain provider that corresponds with the object
 //
-// This implementation ASSUMES that main.go is setting us up with a provider per zone
+// This implementation ASSUMES that main.go is setting us up with a provider per zone.
+// The zone is recorded in the content connector of the object when it is created,
+// so that it does not move if properties used to route it later change.
 func FindCiphertextCacheByObject(obj *models.ODObject) CiphertextCache {
-	// When we have an API token, and a way to configure multiple providers, we simply pick a provider as a function object's properties (already tested to work)
-	// For now, every object is getting default, but we can't change this without getting unique configs per CiphertextCache
-	return FindCiphertextCache(S3_DEFAULT_CIPHERTEXT_CACHE)
+	if obj == nil {
+		return FindCiphertextCache(S3_DEFAULT_CIPHERTEXT_CACHE)
+	}
+	return findCiphertextCacheByZone(ContentConnectorZone(obj.ContentConnector.String))
 }
 
 // FindCiphertextCache gets us a drain provider by zone.  We ONLY use this to construct drain providers.  Ask for it by object otherwise.
@@ -99,13 +112,28 @@ func SetCiphertextCache(zone CiphertextCacheZone, dp CiphertextCache) {
 	ciphertextCaches[zone] = dp
 }
 
+// keyProvider, if set, wraps the data keys that replace configured master keys
+var keyProvider kms.KeyProvider
+
+// SetKeyProvider sets the provider of keys that wrap the master key of each cache created after it.
+// ONLY do this in single-threaded main setup, as with SetCiphertextCache
+func SetKeyProvider(p kms.KeyProvider) {
+	keyProvider = p
+}
+
 // PermanentStorage is a generic type for mocking out or replacing S3
 type PermanentStorage interface {
 	Upload(fIn io.ReadSeeker, key *string) error
+	//UploadIfAbsent uploads the file only if nothing is stored at the key, reporting whether it was uploaded
+	UploadIfAbsent(fIn io.ReadSeeker, key *string) (bool, error)
 	//Download returns a sentinel error PermanentStorageNotFoundErrorString when key not found - maybe not a real error
 	Download(fOut io.WriterAt, key *string) (int64, error)
 	//GetStream returns a sentinel error PermanentStorageNotFoundErrorString when key not found - maybe not a real error
 	GetStream(key *string, begin, end int64) (io.ReadCloser, error)
+	//Delete removes the file, and is not an error if the key is not found
+	Delete(key *string) error
+	//List calls fn with each key beneath the prefix and when it was written, stopping at the first error from fn
+	List(prefix string, fn func(key string, modified time.Time) error) error
 	GetName() *string
 }
 
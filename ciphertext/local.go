@@ -4,6 +4,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"bitbucket.di2e.net/dime/object-drive-server/config"
 	"bitbucket.di2e.net/dime/object-drive-server/util"
@@ -41,6 +42,51 @@ func (s *PermanentStorageLocalData) Upload(fIn io.ReadSeeker, key *string) error
 	return err
 }
 
+// UploadIfAbsent uploads a file into PermanentStorage unless the key exists
+func (s *PermanentStorageLocalData) UploadIfAbsent(fIn io.ReadSeeker, key *string) (bool, error) {
+	fName := s.Location + "/" + *key
+	fOut, err := os.OpenFile(fName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer fOut.Close()
+	_, err = io.Copy(fOut, fIn)
+	return err == nil, err
+}
+
+// Delete from PermanentStorage
+func (s *PermanentStorageLocalData) Delete(key *string) error {
+	err := os.Remove(s.Location + "/" + *key)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// List the keys in PermanentStorage beneath the prefix
+func (s *PermanentStorageLocalData) List(prefix string, fn func(key string, modified time.Time) error) error {
+	err := filepath.Walk(filepath.Join(s.Location, prefix), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Location, path)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), info.ModTime())
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
 // Download from PermanentStorage io.WriteAt is used because there is some parallel download stuff going on with S3
 func (s *PermanentStorageLocalData) Download(fOut io.WriterAt, key *string) (int64, error) {
 	fName := s.Location + "/" + *key
@@ -0,0 +1,235 @@
+package ciphertext
+
+import (
+	"encoding/hex"
+	"errors"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"go.uber.org/zap"
+)
+
+// orphanBatchSize is the number of files in permanent storage checked for
+// references at a time
+const orphanBatchSize = 500
+
+// orphanMarkerSuffix names the file stored beside a content file when it is
+// first found orphaned, so that the grace period runs from then
+const orphanMarkerSuffix = ".orphaned"
+
+// OrphanOpts selects how files that nothing refers to are collected
+type OrphanOpts struct {
+	// GracePeriod is how long a file must have been in permanent storage
+	// before it is checked, so that content being stored while its object is
+	// created is not mistaken for an orphan, and then how long it must have
+	// been orphaned before it is deleted, so that content released while in
+	// use, such as by re-encryption, is not deleted as soon as it is released
+	GracePeriod time.Duration
+	// DryRun reports orphaned files without marking or deleting them
+	DryRun bool
+	// Progress, if set, is called after each batch of files is checked, and
+	// stops the collection if it returns an error
+	Progress func(OrphanReport) error
+}
+
+// Orphan is a file in permanent storage that nothing refers to
+type Orphan struct {
+	Key              string
+	ContentConnector string
+	Modified         time.Time
+}
+
+// OrphanReport counts the files found in permanent storage for a zone
+type OrphanReport struct {
+	// Listed is the number of content files in permanent storage
+	Listed int64
+	// Recent is the number of files not checked as they are within the grace period
+	Recent int64
+	// Orphaned is the number of files that nothing refers to
+	Orphaned int64
+	// Marked is the number of orphaned files first found orphaned
+	Marked int64
+	// Deleted is the number of files deleted, having been orphaned for the
+	// grace period
+	Deleted int64
+}
+
+// ReferencedFunc returns those of the content connectors given that an
+// object, a revision, or deduplicated content refers to
+type ReferencedFunc func(contentConnectors []string) (map[string]bool, error)
+
+// OrphanZone is the permanent storage of a zone in which orphans are collected
+type OrphanZone struct {
+	Zone             CiphertextCacheZone
+	PermanentStorage PermanentStorage
+	// Location is the path beneath which the content files of the zone are
+	// stored, as for the cache of the zone
+	Location string
+	// Purge, if set, deletes a content file along with any copy of it cached
+	// locally, in place of deleting it from permanent storage
+	Purge func(rName FileId) error
+}
+
+// CacheOrphanZone collects the orphans of the zone of a cache
+func CacheOrphanZone(d CiphertextCache) OrphanZone {
+	return OrphanZone{
+		Zone:             d.GetCiphertextCacheZone(),
+		PermanentStorage: d.GetPermanentStorage(),
+		Location:         string(d.Resolve("")),
+		Purge:            d.Purge,
+	}
+}
+
+// NewOrphanZone collects the orphans of a zone from its permanent storage
+// alone, without opening a cache for it
+func NewOrphanZone(zone CiphertextCacheZone, conf config.DiskCacheOpts, dbID string) OrphanZone {
+	logger := config.RootLogger.With(zap.String("session", "CiphertextCache"))
+	return OrphanZone{
+		Zone:             zone,
+		PermanentStorage: NewPermanentStorage(conf, logger),
+		Location:         filepath.Join(conf.Partition, dbID),
+	}
+}
+
+func (z OrphanZone) key(rName FileId) string {
+	return filepath.Join(z.Location, string(rName))
+}
+
+func (z OrphanZone) purge(rName FileId) error {
+	if z.Purge != nil {
+		return z.Purge(rName)
+	}
+	return z.PermanentStorage.Delete(toKey(z.key(rName)))
+}
+
+// orphanCandidate is a content file, or the marker of one, to check for
+// references
+type orphanCandidate struct {
+	Orphan
+	rName  FileId
+	marker bool
+}
+
+// CollectOrphans lists the content files of a zone in permanent storage, and
+// reports each that nothing refers to. Unless this is a dry run, a marker is
+// stored beside an orphaned file when it is first found, and the file is
+// deleted once it has been orphaned for the grace period, if nothing has
+// referred to it since. Files other than content, such as the canary, are left
+// alone.
+func CollectOrphans(z OrphanZone, opts OrphanOpts, referenced ReferencedFunc, found func(Orphan)) (OrphanReport, error) {
+	var report OrphanReport
+	ps := z.PermanentStorage
+	if ps == nil {
+		return report, errors.New("zone has no permanent storage")
+	}
+	cutoff := time.Now().Add(-opts.GracePeriod)
+
+	var batch []orphanCandidate
+	check := func() error {
+		connectors := make([]string, len(batch))
+		for i, candidate := range batch {
+			connectors[i] = candidate.ContentConnector
+		}
+		refs, err := referenced(connectors)
+		if err != nil {
+			return err
+		}
+		for _, candidate := range batch {
+			if candidate.marker {
+				if err := z.collectMarked(candidate, refs[candidate.ContentConnector], cutoff, opts, referenced, &report); err != nil {
+					return err
+				}
+				continue
+			}
+			if refs[candidate.ContentConnector] {
+				continue
+			}
+			report.Orphaned++
+			found(candidate.Orphan)
+			if opts.DryRun {
+				continue
+			}
+			marked, err := ps.UploadIfAbsent(strings.NewReader(time.Now().UTC().Format(time.RFC3339)), toKey(candidate.Key+orphanMarkerSuffix))
+			if err != nil {
+				return err
+			}
+			if marked {
+				report.Marked++
+			}
+		}
+		batch = batch[:0]
+		if opts.Progress != nil {
+			return opts.Progress(report)
+		}
+		return nil
+	}
+	err := ps.List(z.Location, func(key string, modified time.Time) error {
+		name := path.Base(key)
+		marker := strings.HasSuffix(name, orphanMarkerSuffix)
+		rName := FileId(strings.TrimSuffix(name, orphanMarkerSuffix))
+		if !isContentName(rName) || strings.TrimSuffix(key, orphanMarkerSuffix) != z.key(rName) {
+			return nil
+		}
+		if !marker {
+			report.Listed++
+			if modified.After(cutoff) {
+				report.Recent++
+				return nil
+			}
+		}
+		candidate := orphanCandidate{
+			Orphan: Orphan{Key: z.key(rName), ContentConnector: ZoneContentConnector(z.Zone, rName), Modified: modified},
+			rName:  rName,
+			marker: marker,
+		}
+		batch = append(batch, candidate)
+		if len(batch) < orphanBatchSize {
+			return nil
+		}
+		return check()
+	})
+	if err == nil && len(batch) > 0 {
+		err = check()
+	}
+	return report, err
+}
+
+// collectMarked deletes a file that was marked as orphaned before the cutoff,
+// checking again that nothing refers to it just before it is deleted, as it
+// may have been reused since its batch was checked. The marker is removed once
+// the file is deleted, or if the file is referred to again.
+func (z OrphanZone) collectMarked(candidate orphanCandidate, isReferenced bool, cutoff time.Time, opts OrphanOpts, referenced ReferencedFunc, report *OrphanReport) error {
+	if opts.DryRun {
+		return nil
+	}
+	markerKey := toKey(candidate.Key + orphanMarkerSuffix)
+	if !isReferenced && candidate.Modified.After(cutoff) {
+		return nil
+	}
+	if !isReferenced {
+		refs, err := referenced([]string{candidate.ContentConnector})
+		if err != nil {
+			return err
+		}
+		isReferenced = refs[candidate.ContentConnector]
+	}
+	if !isReferenced {
+		if err := z.purge(candidate.rName); err != nil {
+			return err
+		}
+		report.Deleted++
+	}
+	return z.PermanentStorage.Delete(markerKey)
+}
+
+// isContentName reports whether a file is named as content is, by CreateRandomName
+func isContentName(rName FileId) bool {
+	if len(rName) != hex.EncodedLen(randomNameSize) {
+		return false
+	}
+	_, err := hex.DecodeString(string(rName))
+	return err == nil
+}
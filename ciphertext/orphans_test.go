@@ -0,0 +1,116 @@
+package ciphertext_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+)
+
+func TestCollectOrphans(t *testing.T) {
+	logger := config.RootLogger
+	testRoot, err := ioutil.TempDir("", "orphans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testRoot)
+	_, zone, conf, dbID := cacheParams(testRoot, "partition0")
+	d, loggableErr := ciphertext.NewLocalCiphertextCache(logger, zone, conf, dbID)
+	if loggableErr != nil {
+		t.Fatalf("unable to create cache: %s", loggableErr.Msg)
+	}
+
+	// Store one content file that is referred to and others that are not
+	permanent := filepath.Join(testRoot, "permanent")
+	age := func(key string) {
+		past := time.Now().Add(-2 * time.Hour)
+		if err := os.Chtimes(filepath.Join(permanent, key), past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store := func() ciphertext.FileId {
+		rName := ciphertext.FileId(ciphertext.CreateRandomName())
+		key := string(d.Resolve(ciphertext.NewFileName(rName, "")))
+		if err := d.GetPermanentStorage().Upload(bytes.NewReader([]byte("content")), &key); err != nil {
+			t.Fatal(err)
+		}
+		return rName
+	}
+	kept := ciphertext.ZoneContentConnector(zone, store())
+	orphaned := store()
+	reused := ciphertext.ZoneContentConnector(zone, store())
+	raced := ciphertext.ZoneContentConnector(zone, store())
+	isReused := false
+	referenced := func(contentConnectors []string) (map[string]bool, error) {
+		refs := map[string]bool{kept: true, reused: isReused}
+		// Referred to after its batch was checked
+		if len(contentConnectors) == 1 {
+			refs[raced] = true
+		}
+		return refs, nil
+	}
+	var found []ciphertext.Orphan
+	collect := func(opts ciphertext.OrphanOpts) ciphertext.OrphanReport {
+		found = nil
+		report, err := ciphertext.CollectOrphans(ciphertext.CacheOrphanZone(d), opts, referenced, func(o ciphertext.Orphan) {
+			found = append(found, o)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+	ageAll := func() {
+		err := d.GetPermanentStorage().List(string(d.Resolve("")), func(key string, modified time.Time) error {
+			age(key)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Files within the grace period are not checked
+	report := collect(ciphertext.OrphanOpts{GracePeriod: time.Hour})
+	if report.Listed != 4 || report.Recent != 4 || report.Orphaned != 0 {
+		t.Errorf("expected all files to be recent, got %+v", report)
+	}
+
+	// A dry run reports the orphans without marking them. The canary is not
+	// content, so is not listed.
+	ageAll()
+	report = collect(ciphertext.OrphanOpts{GracePeriod: time.Hour, DryRun: true})
+	if report.Listed != 4 || report.Orphaned != 3 || report.Marked != 0 || report.Deleted != 0 {
+		t.Errorf("expected three orphans to be reported, got %+v", report)
+	}
+	if len(found) != 3 {
+		t.Errorf("expected %s, %s and %s to be found orphaned, got %+v", orphaned, reused, raced, found)
+	}
+
+	// Orphans are marked, and not deleted until marked for the grace period
+	report = collect(ciphertext.OrphanOpts{GracePeriod: time.Hour})
+	if report.Orphaned != 3 || report.Marked != 3 || report.Deleted != 0 {
+		t.Errorf("expected three orphans to be marked, got %+v", report)
+	}
+	report = collect(ciphertext.OrphanOpts{GracePeriod: time.Hour})
+	if report.Orphaned != 3 || report.Marked != 0 || report.Deleted != 0 {
+		t.Errorf("expected orphans marked within the grace period to remain, got %+v", report)
+	}
+
+	// Only the orphan that is still not referred to when it is deleted is deleted
+	ageAll()
+	isReused = true
+	report = collect(ciphertext.OrphanOpts{GracePeriod: time.Hour})
+	if report.Orphaned != 2 || report.Deleted != 1 {
+		t.Errorf("expected one orphan to be deleted, got %+v", report)
+	}
+	report = collect(ciphertext.OrphanOpts{GracePeriod: time.Hour})
+	if report.Listed != 3 || report.Orphaned != 1 || report.Marked != 1 || report.Deleted != 0 {
+		t.Errorf("expected only the orphan referred to while deleting to be marked again, got %+v", report)
+	}
+}
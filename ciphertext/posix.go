@@ -0,0 +1,429 @@
+package ciphertext
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karlseguin/ccache"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+const (
+	// checksumExtension is appended to the name of a file in permanent storage
+	// for the sidecar holding the hex encoded sha256 of its contents
+	checksumExtension = ".sha256"
+	// PermanentStorageChecksumErrorString is returned when the contents of a file
+	// in permanent storage no longer match the checksum recorded when written
+	PermanentStorageChecksumErrorString = "checksum mismatch in permanent storage"
+	// verifiedCacheSize bounds the number of files whose verification is
+	// remembered
+	verifiedCacheSize = 10000
+	// verifiedCacheTTL is how long the verification of a file is remembered
+	verifiedCacheTTL = 24 * time.Hour
+	// checksumWait is how long UploadIfAbsent waits for the checksum of a file
+	// that another upload has just put in place. A file still without one is
+	// left from an upload that did not complete, and is replaced.
+	checksumWait = 10 * time.Second
+)
+
+// PermanentStorageFilesystemData writes back permanently to a plain filesystem,
+// such as a POSIX or NFS mount shared between instances. Unlike
+// PermanentStorageLocalData, writes are durable: files are written to a
+// temporary name, synced, and then renamed into place, followed by a checksum
+// sidecar that is verified when the file is read. Files are stored exactly as
+// with the other kinds of PermanentStorage, so that they can be moved between
+// them. The sidecar is put in place last, so a file without one was not
+// completely written and is not found.
+type PermanentStorageFilesystemData struct {
+	// Root is the directory on the mount under which keys are stored
+	Root string
+	// verified remembers the files whose checksum has been checked, and the
+	// outcome, so that ranged reads of the same file do not each rehash it
+	verified *ccache.Cache
+	// verifying holds the files being verified, closing the channel of a file
+	// once it has been verified
+	verifying     map[string]chan struct{}
+	verifyingLock sync.Mutex
+}
+
+// verifiedStamp identifies the version of a file and checksum that was
+// verified, and whether they matched
+type verifiedStamp struct {
+	size     int64
+	modTime  time.Time
+	checksum string
+	ok       bool
+}
+
+// NewPermanentStorageFilesystemData creates a place to write in to a mounted filesystem
+func NewPermanentStorageFilesystemData(root string) PermanentStorage {
+	return &PermanentStorageFilesystemData{
+		Root:      root,
+		verified:  ccache.New(ccache.Configure().MaxSize(verifiedCacheSize)),
+		verifying: make(map[string]chan struct{}),
+	}
+}
+
+// GetName returns a name that the permanent storage uses to identify its collection
+func (s *PermanentStorageFilesystemData) GetName() *string {
+	return &s.Root
+}
+
+// resolve maps a key onto a sharded path beneath the root. Keys are of the form
+// partition/dbID/rname, and the rname is spread across two levels of
+// directories named for its leading characters so that no single directory
+// grows too large.
+func (s *PermanentStorageFilesystemData) resolve(key *string) string {
+	dir, name := filepath.Split(filepath.Clean("/" + *key))
+	if len(name) >= 4 {
+		dir = filepath.Join(dir, name[0:2], name[2:4])
+	}
+	return filepath.Join(s.Root, dir, name)
+}
+
+// Upload a file into PermanentStorage
+func (s *PermanentStorageFilesystemData) Upload(fIn io.ReadSeeker, key *string) error {
+	fName := s.resolve(key)
+	dir := filepath.Dir(fName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	hasher := sha256.New()
+	tmpName, err := writeTempSynced(dir, filepath.Base(fName), io.TeeReader(fIn, hasher))
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, fName); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return s.putChecksum(fName, hex.EncodeToString(hasher.Sum(nil)))
+}
+
+// UploadIfAbsent uploads a file into PermanentStorage unless the key exists.
+// The file is linked into place, which fails if the key exists, rather than
+// renamed over it.
+func (s *PermanentStorageFilesystemData) UploadIfAbsent(fIn io.ReadSeeker, key *string) (bool, error) {
+	fName := s.resolve(key)
+	dir := filepath.Dir(fName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return false, err
+	}
+	hasher := sha256.New()
+	tmpName, err := writeTempSynced(dir, filepath.Base(fName), io.TeeReader(fIn, hasher))
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmpName)
+	if err := os.Link(tmpName, fName); err != nil {
+		if !os.IsExist(err) {
+			return false, err
+		}
+		// Stored by another upload, once its checksum is in place
+		if complete, err := waitForChecksum(fName); complete || err != nil {
+			return false, err
+		}
+		if err := os.Rename(tmpName, fName); err != nil {
+			return false, err
+		}
+	}
+	return true, s.putChecksum(fName, hex.EncodeToString(hasher.Sum(nil)))
+}
+
+// Delete from PermanentStorage. The checksum is removed first, so that a file
+// being deleted is no longer found.
+func (s *PermanentStorageFilesystemData) Delete(key *string) error {
+	fName := s.resolve(key)
+	if err := os.Remove(fName + checksumExtension); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(fName); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	s.verified.Delete(fName)
+	return syncDir(filepath.Dir(fName))
+}
+
+// putChecksum writes the checksum sidecar of a file that has been put in
+// place, marking it complete
+func (s *PermanentStorageFilesystemData) putChecksum(fName string, checksum string) error {
+	dir := filepath.Dir(fName)
+	tmpName, err := writeTempSynced(dir, filepath.Base(fName)+checksumExtension, strings.NewReader(checksum))
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, fName+checksumExtension); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	s.verified.Delete(fName)
+	return syncDir(dir)
+}
+
+// waitForChecksum reports whether the checksum of a file appears within
+// checksumWait
+func waitForChecksum(fName string) (bool, error) {
+	for waited := time.Duration(0); ; waited += 10 * time.Millisecond {
+		_, err := os.Stat(fName + checksumExtension)
+		if err == nil {
+			return true, nil
+		}
+		if !os.IsNotExist(err) {
+			return false, err
+		}
+		if waited >= checksumWait {
+			return false, nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// writeTempSynced writes the contents of r to a new temporary file in dir, and
+// syncs it to stable storage before returning its name.
+func writeTempSynced(dir string, prefix string, r io.Reader) (string, error) {
+	fOut, err := ioutil.TempFile(dir, "."+prefix+".tmp")
+	if err != nil {
+		return "", err
+	}
+	name := fOut.Name()
+	if _, err = io.Copy(fOut, r); err == nil {
+		err = fOut.Sync()
+	}
+	if closeErr := fOut.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
+
+// syncDir flushes the directory entry so that a rename survives a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// List the keys in PermanentStorage beneath the prefix. Files still being
+// written, and checksum sidecars, are not keys.
+func (s *PermanentStorageFilesystemData) List(prefix string, fn func(key string, modified time.Time) error) error {
+	root := filepath.Join(s.Root, filepath.Clean("/"+prefix))
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, checksumExtension) {
+			return nil
+		}
+		// Files of four or more characters are sharded beneath the directory of their key
+		rel, err := filepath.Rel(s.Root, path)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Dir(rel)
+		if len(name) >= 4 {
+			dir = filepath.Dir(filepath.Dir(dir))
+		}
+		return fn(filepath.ToSlash(filepath.Join(dir, name)), info.ModTime())
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Download from PermanentStorage, verifying the contents against the checksum recorded on upload
+func (s *PermanentStorageFilesystemData) Download(fOut io.WriterAt, key *string) (int64, error) {
+	fName := s.resolve(key)
+	fIn, err := openStored(fName, key)
+	if err != nil {
+		return 0, err
+	}
+	defer fIn.Close()
+	expected, err := readChecksum(fName, key)
+	if err != nil {
+		return 0, err
+	}
+	hasher := sha256.New()
+	at, err := io.Copy(&offsetWriter{w: fOut}, io.TeeReader(fIn, hasher))
+	if err != nil {
+		return at, err
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != expected {
+		return at, util.NewLoggable(PermanentStorageChecksumErrorString, nil, zap.String("key", *key))
+	}
+	return at, nil
+}
+
+// GetStream from PermanentStorage. A file that has not yet been verified
+// against its checksum is hashed in full before any range of it is returned,
+// and the outcome is remembered for the version of the file and checksum, so
+// that later ranges are returned without hashing it again. A file found not to
+// match is refused.
+func (s *PermanentStorageFilesystemData) GetStream(key *string, begin, end int64) (io.ReadCloser, error) {
+	fName := s.resolve(key)
+	fIn, err := openStored(fName, key)
+	if err != nil {
+		return nil, err
+	}
+	stamp, err := s.verify(fIn, fName, *key)
+	if err != nil {
+		fIn.Close()
+		return nil, err
+	}
+	if !stamp.ok {
+		fIn.Close()
+		return nil, util.NewLoggable(PermanentStorageChecksumErrorString, nil, zap.String("key", *key))
+	}
+	if _, err := fIn.Seek(begin, io.SeekStart); err != nil {
+		fIn.Close()
+		return nil, err
+	}
+	// end is inclusive, as with an http range, unless it precedes begin
+	if begin <= end {
+		return &limitedFile{Reader: io.LimitReader(fIn, end-begin+1), File: fIn}, nil
+	}
+	return fIn, nil
+}
+
+// openStored opens a file in permanent storage, reporting a missing file with
+// the sentinel
+func openStored(fName string, key *string) (*os.File, error) {
+	fIn, err := os.Open(fName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, util.NewLoggable(PermanentStorageNotFoundErrorString, err, zap.String("key", *key))
+		}
+		return nil, err
+	}
+	return fIn, nil
+}
+
+// verification returns the outcome of verifying this version of the file and
+// checksum, if they have been verified
+func (s *PermanentStorageFilesystemData) verification(fName string, info os.FileInfo, checksum string) (verifiedStamp, bool) {
+	item := s.verified.Get(fName)
+	if item == nil || item.Expired() {
+		return verifiedStamp{}, false
+	}
+	stamp := item.Value().(verifiedStamp)
+	if stamp.size != info.Size() || !stamp.modTime.Equal(info.ModTime()) || stamp.checksum != checksum {
+		return verifiedStamp{}, false
+	}
+	return stamp, true
+}
+
+// verify returns the outcome of verifying the open file against its checksum,
+// hashing it unless this version of it has already been verified. Concurrent
+// reads of a file wait for a single verification of it.
+func (s *PermanentStorageFilesystemData) verify(fIn *os.File, fName string, key string) (verifiedStamp, error) {
+	for {
+		expected, err := readChecksum(fName, &key)
+		if err != nil {
+			return verifiedStamp{}, err
+		}
+		info, err := fIn.Stat()
+		if err != nil {
+			return verifiedStamp{}, err
+		}
+		if stamp, known := s.verification(fName, info, expected); known {
+			return stamp, nil
+		}
+		s.verifyingLock.Lock()
+		done, busy := s.verifying[fName]
+		if !busy {
+			done = make(chan struct{})
+			s.verifying[fName] = done
+		}
+		s.verifyingLock.Unlock()
+		if busy {
+			// Verified by another read, which may have been of another version
+			<-done
+			continue
+		}
+		stamp, err := verifyStored(fIn, info, expected)
+		if err == nil {
+			if !stamp.ok {
+				config.RootLogger.Error("permanent storage checksum mismatch", zap.String("key", key))
+			}
+			s.verified.Set(fName, stamp, verifiedCacheTTL)
+		}
+		s.verifyingLock.Lock()
+		delete(s.verifying, fName)
+		close(done)
+		s.verifyingLock.Unlock()
+		return stamp, err
+	}
+}
+
+// verifyStored hashes an open file in permanent storage and compares it with
+// its checksum
+func verifyStored(fIn *os.File, info os.FileInfo, expected string) (verifiedStamp, error) {
+	stamp := verifiedStamp{size: info.Size(), modTime: info.ModTime(), checksum: expected}
+	if _, err := fIn.Seek(0, io.SeekStart); err != nil {
+		return stamp, err
+	}
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, fIn); err != nil {
+		return stamp, err
+	}
+	stamp.ok = hex.EncodeToString(hasher.Sum(nil)) == expected
+	return stamp, nil
+}
+
+// readChecksum returns the checksum recorded for the file. A missing sidecar
+// means the file was never completely written, so it is not found.
+func readChecksum(fName string, key *string) (string, error) {
+	checksum, err := ioutil.ReadFile(fName + checksumExtension)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", util.NewLoggable(PermanentStorageNotFoundErrorString, err, zap.String("key", *key))
+		}
+		return "", err
+	}
+	expected := strings.TrimSpace(string(checksum))
+	if _, err := hex.DecodeString(expected); err != nil || len(expected) != sha256.Size*2 {
+		return "", util.NewLoggable(PermanentStorageChecksumErrorString, errors.New("malformed checksum"), zap.String("key", *key))
+	}
+	return expected, nil
+}
+
+// offsetWriter adapts an io.WriterAt to sequential writes
+type offsetWriter struct {
+	w  io.WriterAt
+	at int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.WriteAt(p, o.at)
+	o.at += int64(n)
+	return n, err
+}
+
+// limitedFile closes the underlying file of a range read
+type limitedFile struct {
+	io.Reader
+	File *os.File
+}
+
+// Close the underlying file
+func (l *limitedFile) Close() error {
+	return l.File.Close()
+}
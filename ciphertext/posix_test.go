@@ -0,0 +1,139 @@
+package ciphertext_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+)
+
+func TestPermanentStorageFilesystem(t *testing.T) {
+	root, err := ioutil.TempDir("", "permanent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	storage := ciphertext.NewPermanentStorageFilesystemData(root)
+	key := "cache/dbtest/abcdef0123456789"
+	content := []byte("the quick brown fox jumps over the lazy dog")
+	if err := storage.Upload(bytes.NewReader(content), &key); err != nil {
+		t.Fatal(err)
+	}
+
+	// stored as is sharded beneath the root, beside a sidecar with its checksum
+	stored := filepath.Join(root, "cache", "dbtest", "ab", "cd", "abcdef0123456789")
+	raw, err := ioutil.ReadFile(stored)
+	if err != nil {
+		t.Fatalf("file not stored at sharded location: %v", err)
+	}
+	if !bytes.Equal(raw, content) {
+		t.Errorf("file not stored as uploaded: %q", raw)
+	}
+	sum := sha256.Sum256(content)
+	checksum, err := ioutil.ReadFile(stored + ".sha256")
+	if err != nil {
+		t.Fatalf("checksum sidecar not stored: %v", err)
+	}
+	if string(checksum) != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected checksum %s", checksum)
+	}
+
+	// present keys are not uploaded again
+	if uploaded, err := storage.UploadIfAbsent(bytes.NewReader([]byte("other content")), &key); err != nil || uploaded {
+		t.Errorf("expected existing key to be left as it is, got %v %v", uploaded, err)
+	}
+
+	// listed by key, without the sidecar
+	var listed []string
+	if err := storage.List("cache/dbtest", func(k string, modified time.Time) error {
+		listed = append(listed, k)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0] != key {
+		t.Errorf("expected %s to be listed, got %v", key, listed)
+	}
+
+	// whole file download
+	buf := &aws.WriteAtBuffer{}
+	n, err := storage.Download(buf, &key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(content)) || !bytes.Equal(buf.Bytes(), content) {
+		t.Errorf("downloaded content does not match uploaded content")
+	}
+
+	// inclusive range
+	stream, err := storage.GetStream(&key, 4, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part, _ := ioutil.ReadAll(stream)
+	stream.Close()
+	if string(part) != "quick" {
+		t.Errorf("expected range to be quick, got %s", string(part))
+	}
+
+	// missing keys are reported with the sentinel
+	missing := "cache/dbtest/0000000000000000"
+	if _, err := storage.Download(&aws.WriteAtBuffer{}, &missing); err == nil || err.Error() != ciphertext.PermanentStorageNotFoundErrorString {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	// a changed checksum is verified again, although the file is unchanged
+	if err := ioutil.WriteFile(stored+".sha256", []byte(hex.EncodeToString(make([]byte, sha256.Size))), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.GetStream(&key, 4, 8); err == nil || err.Error() != ciphertext.PermanentStorageChecksumErrorString {
+		t.Errorf("expected checksum error on stream after checksum changed, got %v", err)
+	}
+	if err := ioutil.WriteFile(stored+".sha256", checksum, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// corruption is detected
+	corrupt := append([]byte{}, raw...)
+	corrupt[0] = 'T'
+	if err := ioutil.WriteFile(stored, corrupt, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.Download(&aws.WriteAtBuffer{}, &key); err == nil || err.Error() != ciphertext.PermanentStorageChecksumErrorString {
+		t.Errorf("expected checksum error on download, got %v", err)
+	}
+	// A file not yet verified is verified before any range of it is returned
+	if _, err := storage.GetStream(&key, 4, 8); err == nil || err.Error() != ciphertext.PermanentStorageChecksumErrorString {
+		t.Errorf("expected checksum error on stream, got %v", err)
+	}
+
+	// a file without its checksum was not completely written
+	if err := os.Remove(stored + ".sha256"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.GetStream(&key, 4, 8); err == nil || err.Error() != ciphertext.PermanentStorageNotFoundErrorString {
+		t.Errorf("expected not found error without checksum, got %v", err)
+	}
+
+	// deleted with its checksum
+	if err := storage.Upload(bytes.NewReader(content), &key); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Delete(&key); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{stored, stored + ".sha256"} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be deleted, got %v", name, err)
+		}
+	}
+}
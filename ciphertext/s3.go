@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"net/http"
 	"strings"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"bitbucket.di2e.net/dime/object-drive-server/util"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -59,6 +61,52 @@ func (s *PermanentStorageData) Upload(fIn io.ReadSeeker, key *string) error {
 	return err
 }
 
+// UploadIfAbsent uploads a file into S3 unless the key exists, by making the
+// put conditional on there being no object at the key
+func (s *PermanentStorageData) UploadIfAbsent(fIn io.ReadSeeker, key *string) (bool, error) {
+	req, _ := s.S3.PutObjectRequest(&s3.PutObjectInput{Body: fIn, Bucket: s.Bucket, Key: key})
+	req.HTTPRequest.Header.Set("If-None-Match", "*")
+	err := req.Send()
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		switch reqErr.StatusCode() {
+		case http.StatusPreconditionFailed, http.StatusConflict:
+			// Stored already, or being stored by another conditional put
+			return false, nil
+		}
+	}
+	return err == nil, err
+}
+
+// Delete from S3
+func (s *PermanentStorageData) Delete(key *string) error {
+	_, err := s.S3.DeleteObject(&s3.DeleteObjectInput{Bucket: s.Bucket, Key: key})
+	if err != nil && strings.Contains(err.Error(), "reduce your request rate") {
+		// backoff
+		s3Backoff()
+		// retry
+		_, err = s.S3.DeleteObject(&s3.DeleteObjectInput{Bucket: s.Bucket, Key: key})
+	}
+	return err
+}
+
+// List the keys in S3 beneath the prefix
+func (s *PermanentStorageData) List(prefix string, fn func(key string, modified time.Time) error) error {
+	var fnErr error
+	input := &s3.ListObjectsV2Input{Bucket: s.Bucket, Prefix: aws.String(strings.TrimSuffix(prefix, "/") + "/")}
+	err := s.S3.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			if fnErr = fn(aws.StringValue(obj.Key), aws.TimeValue(obj.LastModified)); fnErr != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
 // Download from S3
 func (s *PermanentStorageData) Download(fOut io.WriterAt, key *string) (int64, error) {
 	downloader := s3manager.NewDownloader(s.AWSSession)
@@ -106,20 +154,11 @@ func (s *PermanentStorageData) GetStream(key *string, begin, end int64) (io.Read
 	return out.Body, nil
 }
 
-// NewDiskCache sets up a drain with default parameters overridden by environment variables
+// NewDiskCache sets up a drain with default parameters overridden by environment variables.
+// Files are persisted to S3 unless conf.PermanentStorage selects a filesystem mount.
 func NewDiskCache(zone CiphertextCacheZone, conf config.DiskCacheOpts, dbID string) (*CiphertextCacheData, *util.Loggable) {
 	logger := config.RootLogger.With(zap.String("session", "CiphertextCache"))
-
-	s3Config := config.NewS3Config()
-	sess := amazon.NewAWSSession(s3Config.AWSConfig, logger, "S3 ciphertextcache")
-
-	//Assign permanent storage if we have a bucket name
-	var permanentStorage PermanentStorage
-	if config.DefaultBucket != "" {
-		permanentStorage = NewPermanentStorageData(sess, &config.DefaultBucket)
-	} else {
-		logger.Info("permanentstorage is empty because there is no bucket name")
-	}
+	permanentStorage := NewPermanentStorage(conf, logger)
 
 	d, err := NewCiphertextCacheRaw(zone, &conf, dbID, logger, permanentStorage)
 
@@ -127,6 +166,33 @@ func NewDiskCache(zone CiphertextCacheZone, conf config.DiskCacheOpts, dbID stri
 	return d, err
 }
 
+// NewPermanentStorage opens the permanent storage selected by conf, which is
+// S3 unless conf.PermanentStorage selects a filesystem mount. It is nil if
+// there is no bucket name for S3.
+func NewPermanentStorage(conf config.DiskCacheOpts, logger *zap.Logger) PermanentStorage {
+	var permanentStorage PermanentStorage
+	switch conf.PermanentStorage {
+	case config.PermanentStoragePOSIX:
+		permanentStorage = NewPermanentStorageFilesystemData(conf.PermanentStorageRoot)
+		logger.Info("permanentstorage is a filesystem mount", zap.String("location", conf.PermanentStorageRoot))
+	default:
+		s3Config := config.NewS3Config()
+		sess := amazon.NewAWSSession(s3Config.AWSConfig, logger, "S3 ciphertextcache")
+
+		//Assign permanent storage if we have a bucket name
+		bucket := conf.Bucket
+		if bucket == "" {
+			bucket = config.DefaultBucket
+		}
+		if bucket != "" {
+			permanentStorage = NewPermanentStorageData(sess, &bucket)
+		} else {
+			logger.Info("permanentstorage is empty because there is no bucket name")
+		}
+	}
+	return permanentStorage
+}
+
 // TestS3Connection can be run to inspect the environment for configured S3
 // bucket names, and verify that those buckets are writable with our credentials.
 func TestS3Connection(sess *session.Session) bool {
@@ -0,0 +1,151 @@
+package ciphertext
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+)
+
+// StorageZonePropertyName is the object property that may be given when an
+// object is created to select the zone its content is stored in.
+const StorageZonePropertyName = "storageZone"
+
+// zoneSeparator separates the zone from the random name in the content
+// connector of objects stored outside the default zone. Objects in the default
+// zone have just the random name, as they always have.
+const zoneSeparator = ":"
+
+// CiphertextCacheRoute routes objects of the listed types, or owned by the
+// listed groups, to a zone.
+type CiphertextCacheRoute struct {
+	Zone        CiphertextCacheZone
+	TypeNames   []string
+	OwnerGroups []string
+}
+
+// ciphertextCacheRoutes is set up in main alongside ciphertextCaches, and
+// never edited after that
+var ciphertextCacheRoutes []CiphertextCacheRoute
+
+// NewCiphertextCacheRoutes creates the routes for the configured zones
+func NewCiphertextCacheRoutes(zones []config.CacheZoneOpts) []CiphertextCacheRoute {
+	var routes []CiphertextCacheRoute
+	for _, zone := range zones {
+		routes = append(routes, CiphertextCacheRoute{
+			Zone:        CiphertextCacheZone(zone.Name),
+			TypeNames:   zone.TypeNames,
+			OwnerGroups: zone.OwnerGroups,
+		})
+	}
+	return routes
+}
+
+// SetCiphertextCacheRoutes sets the routes used to select a zone for new objects.
+// ONLY do this in single-threaded main setup, as with SetCiphertextCache.
+func SetCiphertextCacheRoutes(routes []CiphertextCacheRoute) {
+	ciphertextCacheRoutes = routes
+}
+
+// FindCiphertextCacheZoneForObject selects the zone that the content of a new
+// object is to be stored in. The routes are applied first, in the order
+// configured, so that a routed object cannot be moved out of its zone. Only an
+// object that no route matches may select a zone with a storageZone property,
+// and only a zone that has no routes of its own, so that content cannot be put
+// into a zone reserved for particular types or groups. An error is returned if
+// the storageZone property names a zone that does not exist or is routed.
+func FindCiphertextCacheZoneForObject(obj *models.ODObject) (CiphertextCacheZone, error) {
+	ownerGroup := ""
+	if strings.HasPrefix(obj.OwnedBy.String, "group/") {
+		ownerGroup = models.NewODAcmGranteeFromResourceName(obj.OwnedBy.String).Grantee
+	}
+	for _, route := range ciphertextCacheRoutes {
+		for _, typeName := range route.TypeNames {
+			if strings.EqualFold(typeName, obj.TypeName.String) {
+				return route.Zone, nil
+			}
+		}
+		if len(ownerGroup) == 0 {
+			continue
+		}
+		for _, group := range route.OwnerGroups {
+			if strings.EqualFold(group, obj.OwnedBy.String) || strings.EqualFold(models.AACFlatten(group), ownerGroup) {
+				return route.Zone, nil
+			}
+		}
+	}
+	for _, property := range obj.Properties {
+		if property.Name != StorageZonePropertyName || len(property.Value.String) == 0 {
+			continue
+		}
+		for zone := range ciphertextCaches {
+			if !strings.EqualFold(string(zone), property.Value.String) {
+				continue
+			}
+			if isRoutedZone(zone) {
+				return "", fmt.Errorf("%s %s is reserved for the types and groups routed to it", StorageZonePropertyName, property.Value.String)
+			}
+			return zone, nil
+		}
+		return "", fmt.Errorf("%s %s does not exist", StorageZonePropertyName, property.Value.String)
+	}
+	return S3_DEFAULT_CIPHERTEXT_CACHE, nil
+}
+
+// isRoutedZone reports whether any types or groups are routed to the zone
+func isRoutedZone(zone CiphertextCacheZone) bool {
+	for _, route := range ciphertextCacheRoutes {
+		if route.Zone == zone && (len(route.TypeNames) > 0 || len(route.OwnerGroups) > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewContentConnector creates a content connector for new content stored in
+// the zone.
+func NewContentConnector(zone CiphertextCacheZone) string {
+	return ZoneContentConnector(zone, FileId(CreateRandomName()))
+}
+
+// ZoneContentConnector is the content connector of a file stored in the zone
+func ZoneContentConnector(zone CiphertextCacheZone, rName FileId) string {
+	if zone == S3_DEFAULT_CIPHERTEXT_CACHE || len(zone) == 0 {
+		return string(rName)
+	}
+	return string(zone) + zoneSeparator + string(rName)
+}
+
+// ContentConnectorZone returns the zone that content is stored in
+func ContentConnectorZone(contentConnector string) CiphertextCacheZone {
+	if idx := strings.Index(contentConnector, zoneSeparator); idx > 0 {
+		return CiphertextCacheZone(contentConnector[:idx])
+	}
+	return S3_DEFAULT_CIPHERTEXT_CACHE
+}
+
+// ContentConnectorFileId returns the random name of the content, by which it
+// is known within its zone
+func ContentConnectorFileId(contentConnector string) FileId {
+	if idx := strings.Index(contentConnector, zoneSeparator); idx > 0 {
+		return FileId(contentConnector[idx+len(zoneSeparator):])
+	}
+	return FileId(contentConnector)
+}
+
+// findCiphertextCacheByZone returns the cache for the zone, falling back to
+// the default zone if the zone is no longer configured.
+func findCiphertextCacheByZone(zone CiphertextCacheZone) CiphertextCache {
+	dp := FindCiphertextCache(zone)
+	if dp == nil && zone != S3_DEFAULT_CIPHERTEXT_CACHE {
+		config.RootLogger.Error(
+			"ciphertext cache zone is not configured. check OD_CACHE_ZONES",
+			zap.String("zone", string(zone)),
+		)
+		dp = FindCiphertextCache(S3_DEFAULT_CIPHERTEXT_CACHE)
+	}
+	return dp
+}
@@ -0,0 +1,116 @@
+package ciphertext_test
+
+import (
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+)
+
+func TestContentConnectorZone(t *testing.T) {
+	cc := ciphertext.NewContentConnector(ciphertext.CiphertextCacheZone("sensitive"))
+	if zone := ciphertext.ContentConnectorZone(cc); zone != "sensitive" {
+		t.Errorf("expected zone sensitive, got %s", zone)
+	}
+	rName := ciphertext.ContentConnectorFileId(cc)
+	if len(rName) == 0 || string(rName) == cc {
+		t.Errorf("expected random name without zone, got %s", rName)
+	}
+
+	// content in the default zone is named as it always has been
+	cc = ciphertext.NewContentConnector(ciphertext.S3_DEFAULT_CIPHERTEXT_CACHE)
+	if zone := ciphertext.ContentConnectorZone(cc); zone != ciphertext.S3_DEFAULT_CIPHERTEXT_CACHE {
+		t.Errorf("expected default zone, got %s", zone)
+	}
+	if rName := ciphertext.ContentConnectorFileId(cc); string(rName) != cc {
+		t.Errorf("expected content connector to be the random name, got %s", rName)
+	}
+}
+
+func TestFindCiphertextCacheZoneForObject(t *testing.T) {
+	ciphertext.SetCiphertextCache("sensitive", nil)
+	ciphertext.SetCiphertextCache("archive", nil)
+	ciphertext.SetCiphertextCache("shared", nil)
+	ciphertext.SetCiphertextCacheRoutes(ciphertext.NewCiphertextCacheRoutes([]config.CacheZoneOpts{
+		{Name: "sensitive", OwnerGroups: []string{"group/dctc/DCTC/ODrive_G1/ODrive G1"}},
+		{Name: "archive", TypeNames: []string{"Archive"}},
+	}))
+	defer ciphertext.SetCiphertextCacheRoutes(nil)
+
+	cases := []struct {
+		name     string
+		obj      models.ODObject
+		expected ciphertext.CiphertextCacheZone
+	}{
+		{
+			name:     "no route",
+			obj:      models.ODObject{TypeName: models.ToNullString("File"), OwnedBy: models.ToNullString("user/cn=test tester01")},
+			expected: ciphertext.S3_DEFAULT_CIPHERTEXT_CACHE,
+		},
+		{
+			name:     "type name",
+			obj:      models.ODObject{TypeName: models.ToNullString("archive"), OwnedBy: models.ToNullString("user/cn=test tester01")},
+			expected: "archive",
+		},
+		{
+			name:     "owner group",
+			obj:      models.ODObject{TypeName: models.ToNullString("File"), OwnedBy: models.ToNullString("group/dctc/DCTC/ODrive_G1/ODrive G1")},
+			expected: "sensitive",
+		},
+		{
+			name: "storageZone property",
+			obj: models.ODObject{
+				TypeName:   models.ToNullString("File"),
+				OwnedBy:    models.ToNullString("user/cn=test tester01"),
+				Properties: []models.ODObjectPropertyEx{{Name: ciphertext.StorageZonePropertyName, Value: models.ToNullString("Shared")}},
+			},
+			expected: "shared",
+		},
+		{
+			name: "type name overrides storageZone property",
+			obj: models.ODObject{
+				TypeName:   models.ToNullString("Archive"),
+				OwnedBy:    models.ToNullString("user/cn=test tester01"),
+				Properties: []models.ODObjectPropertyEx{{Name: ciphertext.StorageZonePropertyName, Value: models.ToNullString("Shared")}},
+			},
+			expected: "archive",
+		},
+		{
+			name: "owner group overrides storageZone property",
+			obj: models.ODObject{
+				TypeName:   models.ToNullString("File"),
+				OwnedBy:    models.ToNullString("group/dctc/DCTC/ODrive_G1/ODrive G1"),
+				Properties: []models.ODObjectPropertyEx{{Name: ciphertext.StorageZonePropertyName, Value: models.ToNullString(string(ciphertext.S3_DEFAULT_CIPHERTEXT_CACHE))}},
+			},
+			expected: "sensitive",
+		},
+	}
+	for _, c := range cases {
+		zone, err := ciphertext.FindCiphertextCacheZoneForObject(&c.obj)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if zone != c.expected {
+			t.Errorf("%s: expected zone %s, got %s", c.name, c.expected, zone)
+		}
+	}
+
+	unknown := models.ODObject{
+		Properties: []models.ODObjectPropertyEx{{Name: ciphertext.StorageZonePropertyName, Value: models.ToNullString("nowhere")}},
+	}
+	if _, err := ciphertext.FindCiphertextCacheZoneForObject(&unknown); err == nil {
+		t.Errorf("expected an error for a storageZone that does not exist")
+	}
+
+	// A zone reserved by its routes cannot be selected by other objects
+	routed := models.ODObject{
+		TypeName:   models.ToNullString("File"),
+		OwnedBy:    models.ToNullString("user/cn=test tester01"),
+		Properties: []models.ODObjectPropertyEx{{Name: ciphertext.StorageZonePropertyName, Value: models.ToNullString("Sensitive")}},
+	}
+	if _, err := ciphertext.FindCiphertextCacheZoneForObject(&routed); err == nil {
+		t.Errorf("expected an error for a storageZone that is routed")
+	}
+}
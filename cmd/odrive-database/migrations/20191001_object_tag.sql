@@ -0,0 +1,192 @@
+-- +migrate Up
+
+-- Restores object_tag so that tags can be associated to objects. The table and its triggers
+-- were dropped as part of the 20170630 combined migration while they were not yet in use.
+
+INSERT INTO migration_status SET description = '20191001_object_tag creating table object_tag';
+CREATE TABLE IF NOT EXISTS object_tag
+(
+  id binary(16) not null default 0
+  ,createdDate timestamp(6) null
+  ,createdBy varchar(255) not null
+  ,modifiedDate timestamp(6) null
+  ,modifiedBy varchar(255) null
+  ,isDeleted boolean null
+  ,deletedDate timestamp(6) null
+  ,deletedBy varchar(255) null
+  ,changeCount int null
+  ,changeToken varchar(60) null
+  ,objectId binary(16) null
+  ,name varchar(255) not null
+  ,CONSTRAINT pk_object_tag PRIMARY KEY (id)
+  ,INDEX ix_isDeleted (isDeleted)
+  ,INDEX ix_objectId (objectId)
+  ,INDEX ix_name (name)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+CREATE TABLE IF NOT EXISTS a_object_tag
+(
+  a_id int not null auto_increment
+  ,id binary(16) not null
+  ,createdDate timestamp(6) null
+  ,createdBy varchar(255) not null
+  ,modifiedDate timestamp(6) null
+  ,modifiedBy varchar(255) null
+  ,isDeleted boolean null
+  ,deletedDate timestamp(6) null
+  ,deletedBy varchar(255) null
+  ,changeCount int null
+  ,changeToken varchar(60) null
+  ,objectId binary(16) null
+  ,name varchar(255) not null
+  ,CONSTRAINT pk_a_object_tag PRIMARY KEY (a_id)
+  ,INDEX ix_id (id)
+  ,INDEX ix_modifiedDate (modifiedDate)
+  ,INDEX ix_changeCount (changeCount)
+  ,INDEX ix_objectId (objectId)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+
+INSERT INTO migration_status SET description = '20191001_object_tag creating triggers for object_tag';
+DROP TRIGGER IF EXISTS ti_object_tag;
+-- +migrate StatementBegin
+CREATE TRIGGER ti_object_tag
+BEFORE INSERT ON object_tag FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default '';
+	DECLARE thisTableName varchar(128) default 'object_tag';
+	# Rules
+	# objectId must be specified
+	IF NEW.objectId IS NULL THEN
+		SET error_msg := concat(error_msg, 'Field objectId required ');
+	END IF;
+	# name must be specified
+	IF NEW.name IS NULL OR NEW.name = '' THEN
+		SET error_msg := concat(error_msg, 'Field name required ');
+	END IF;
+	IF error_msg <> '' THEN
+		SET error_msg := concat(error_msg, 'when inserting record into ', thisTableName);
+		signal sqlstate '45000' set message_text = error_msg;
+	END IF;
+	# Force values on create
+	SET NEW.id := ordered_uuid(UUID());
+	SET NEW.createdDate := current_timestamp(6);
+	SET NEW.modifiedDate := current_timestamp(6);
+	SET NEW.modifiedBy := NEW.createdBy;
+	SET NEW.isDeleted := 0;
+	SET NEW.deletedDate := NULL;
+	SET NEW.deletedBy := NULL;
+	SET NEW.changeCount := 0;
+	# Standard change token formula
+	SET NEW.changeToken := md5(CONCAT(CAST(NEW.id AS CHAR),':',CAST(NEW.changeCount AS CHAR),':',CAST(NEW.createdDate AS CHAR)));
+	# Archive table
+	INSERT INTO a_object_tag SET
+		id = NEW.id
+		,createdDate = NEW.createdDate
+		,createdBy = NEW.createdBy
+		,modifiedDate = NEW.modifiedDate
+		,modifiedBy = NEW.modifiedBy
+		,isDeleted = NEW.isDeleted
+		,deletedDate = NEW.deletedDate
+		,deletedBy = NEW.deletedBy
+		,changeCount = NEW.changeCount
+		,changeToken = NEW.changeToken
+		,objectId = NEW.objectId
+		,name = NEW.name;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS tu_object_tag;
+-- +migrate StatementBegin
+CREATE TRIGGER tu_object_tag
+BEFORE UPDATE ON object_tag FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default '';
+	DECLARE thisTableName varchar(128) default 'object_tag';
+	# Rules
+	# only deletes are allowed.
+	# id cannot be changed
+	IF (NEW.id <> OLD.id) AND length(error_msg) < 83 THEN
+		SET error_msg := concat(error_msg, 'Unable to set id ');
+	END IF;
+	# createdDate cannot be changed
+	IF (NEW.createdDate <> OLD.createdDate) AND length(error_msg) < 76 THEN
+		SET error_msg := concat(error_msg, 'Unable to set createdDate ');
+	END IF;
+	# createdBy cannot be changed
+	IF (NEW.createdBy <> OLD.createdBy) AND length(error_msg) < 76 THEN
+		SET error_msg := concat(error_msg, 'Unable to set createdBy ');
+	END IF;
+	# objectId cannot be changed
+	IF (NEW.objectId <> OLD.objectId) AND length(error_msg) < 76 THEN
+		SET error_msg := concat(error_msg, 'Unable to set objectId ');
+	END IF;
+	# name cannot be changed
+	IF (NEW.name <> OLD.name) AND length(error_msg) < 81 THEN
+		SET error_msg := concat(error_msg, 'Unable to set name ');
+	END IF;
+	IF length(error_msg) > 0 THEN
+		SET error_msg := concat(error_msg, 'when updating record');
+		signal sqlstate '45000' set message_text = error_msg;
+	END IF;
+	# Force values on modify
+	# The only modification allowed is to mark as deleted...
+	SET NEW.modifiedDate := current_timestamp(6);
+	IF NEW.modifiedBy IS NULL OR NEW.modifiedBy = '' THEN
+		SET NEW.modifiedBy := NEW.deletedBy;
+	END IF;
+	SET NEW.isDeleted = 1;
+	SET NEW.deletedDate := current_timestamp(6);
+	IF NEW.deletedBy IS NULL OR NEW.deletedBy = '' THEN
+		SET NEW.deletedBy := NEW.modifiedBy;
+	END IF;
+	SET NEW.changeCount := OLD.changeCount + 1;
+	# Standard change token formula
+	SET NEW.changeToken := md5(CONCAT(CAST(OLD.id AS CHAR),':',CAST(NEW.changeCount AS CHAR),':',CAST(NEW.modifiedDate AS CHAR)));
+	# Archive table
+	INSERT INTO a_object_tag SET
+		id = NEW.id
+		,createdDate = NEW.createdDate
+		,createdBy = NEW.createdBy
+		,modifiedDate = NEW.modifiedDate
+		,modifiedBy = NEW.modifiedBy
+		,isDeleted = NEW.isDeleted
+		,deletedDate = NEW.deletedDate
+		,deletedBy = NEW.deletedBy
+		,changeCount = NEW.changeCount
+		,changeToken = NEW.changeToken
+		,objectId = NEW.objectId
+		,name = NEW.name;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS td_object_tag;
+-- +migrate StatementBegin
+CREATE TRIGGER td_object_tag
+BEFORE DELETE ON object_tag FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default 'Deleting records are not allowed. Use isDeleted, deletedDate, and deletedBy';
+	signal sqlstate '45000' set message_text = error_msg;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS td_a_object_tag;
+-- +migrate StatementBegin
+CREATE TRIGGER td_a_object_tag
+BEFORE DELETE ON a_object_tag FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default 'Deleting records are not allowed on archive tables.';
+	signal sqlstate '45000' set message_text = error_msg;
+END;
+-- +migrate StatementEnd
+
+INSERT INTO migration_status SET description = '20191001_object_tag setting schemaversion to 20191001';
+update dbstate set schemaVersion = '20191001' where schemaVersion <> '20191001';
+
+-- +migrate Down
+
+DROP TRIGGER IF EXISTS ti_object_tag;
+DROP TRIGGER IF EXISTS tu_object_tag;
+DROP TRIGGER IF EXISTS td_object_tag;
+DROP TRIGGER IF EXISTS td_a_object_tag;
+DROP TABLE IF EXISTS a_object_tag;
+DROP TABLE IF EXISTS object_tag;
+
+update dbstate set schemaVersion = '20190225' where schemaVersion <> '20190225';
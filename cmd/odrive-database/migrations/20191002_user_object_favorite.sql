@@ -0,0 +1,176 @@
+-- +migrate Up
+
+-- Restores user_object_favorite so that users can mark objects as favorites. The table and its
+-- triggers were dropped as part of the 20170630 combined migration while they were not yet in use.
+
+INSERT INTO migration_status SET description = '20191002_user_object_favorite creating table user_object_favorite';
+CREATE TABLE IF NOT EXISTS user_object_favorite
+(
+  id binary(16) not null default 0
+  ,createdDate timestamp(6) null
+  ,createdBy varchar(255) not null
+  ,modifiedDate timestamp(6) null
+  ,modifiedBy varchar(255) null
+  ,isDeleted boolean null
+  ,deletedDate timestamp(6) null
+  ,deletedBy varchar(255) null
+  ,objectId binary(16) not null
+  ,CONSTRAINT pk_user_object_favorite PRIMARY KEY (id)
+  ,INDEX ix_createdBy (createdBy)
+  ,INDEX ix_isDeleted (isDeleted)
+  ,INDEX ix_objectId (objectId)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+CREATE TABLE IF NOT EXISTS a_user_object_favorite
+(
+  a_id int not null auto_increment
+  ,id binary(16) not null
+  ,createdDate timestamp(6) null
+  ,createdBy varchar(255) not null
+  ,modifiedDate timestamp(6) null
+  ,modifiedBy varchar(255) null
+  ,isDeleted boolean null
+  ,deletedDate timestamp(6) null
+  ,deletedBy varchar(255) null
+  ,objectId binary(16) not null
+  ,CONSTRAINT pk_a_user_object_favorite PRIMARY KEY (a_id)
+  ,INDEX ix_id (id)
+  ,INDEX ix_createdBy (createdBy)
+  ,INDEX ix_isDeleted (isDeleted)
+  ,INDEX ix_objectId (objectId)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+
+INSERT INTO migration_status SET description = '20191002_user_object_favorite creating triggers for user_object_favorite';
+DROP TRIGGER IF EXISTS ti_user_object_favorite;
+-- +migrate StatementBegin
+CREATE TRIGGER ti_user_object_favorite
+BEFORE INSERT ON user_object_favorite FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default '';
+	DECLARE thisTableName varchar(128) default 'user_object_favorite';
+	DECLARE count_favorite int default 0;
+	# Rules
+	# objectId must be specified
+	IF NEW.objectId IS NULL OR NEW.objectId = '' THEN
+		SET error_msg := concat(error_msg, 'Field objectId required ');
+	END IF;
+	# objectId must be unique for the creator
+	SELECT COUNT(*) FROM user_object_favorite WHERE createdBy = NEW.createdBy AND objectId = NEW.objectId AND isDeleted = 0 INTO count_favorite;
+	IF count_favorite > 0 THEN
+		SET error_msg := concat(error_msg, 'Field objectId must be unique ');
+	END IF;
+	IF error_msg <> '' THEN
+		SET error_msg := concat(error_msg, 'when inserting record into ', thisTableName);
+		signal sqlstate '45000' set message_text = error_msg;
+	END IF;
+	# Force values on create
+	SET NEW.id := ordered_uuid(UUID());
+	SET NEW.createdDate := current_timestamp(6);
+	SET NEW.modifiedDate := current_timestamp(6);
+	SET NEW.modifiedBy := NEW.createdBy;
+	SET NEW.isDeleted := 0;
+	SET NEW.deletedDate := NULL;
+	SET NEW.deletedBy := NULL;
+	# Archive table
+	INSERT INTO a_user_object_favorite SET
+		id = NEW.id
+		,createdDate = NEW.createdDate
+		,createdBy = NEW.createdBy
+		,modifiedDate = NEW.modifiedDate
+		,modifiedBy = NEW.modifiedBy
+		,isDeleted = NEW.isDeleted
+		,deletedDate = NEW.deletedDate
+		,deletedBy = NEW.deletedBy
+		,objectId = NEW.objectId;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS tu_user_object_favorite;
+-- +migrate StatementBegin
+CREATE TRIGGER tu_user_object_favorite
+BEFORE UPDATE ON user_object_favorite FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default '';
+	DECLARE thisTableName varchar(128) default 'user_object_favorite';
+	# Rules
+	# only deletes are allowed.
+	# id cannot be changed
+	IF (NEW.id <> OLD.id) AND length(error_msg) < 83 THEN
+		SET error_msg := concat(error_msg, 'Unable to set id ');
+	END IF;
+	# createdDate cannot be changed
+	IF (NEW.createdDate <> OLD.createdDate) AND length(error_msg) < 74 THEN
+		SET error_msg := concat(error_msg, 'Unable to set createdDate ');
+	END IF;
+	# createdBy cannot be changed
+	IF (NEW.createdBy <> OLD.createdBy) AND length(error_msg) < 76 THEN
+		SET error_msg := concat(error_msg, 'Unable to set createdBy ');
+	END IF;
+	# objectId cannot be changed
+	IF (NEW.objectId <> OLD.objectId) AND length(error_msg) < 77 THEN
+		SET error_msg := concat(error_msg, 'Unable to set objectId ');
+	END IF;
+	# deletedBy must be set
+	IF (NEW.deletedBy IS NULL OR NEW.deletedBy = '') AND (NEW.modifiedBy IS NULL OR NEW.modifiedBy = '') AND length(error_msg) < 75 THEN
+		SET error_msg := concat(error_msg, 'Field deletedBy or modifiedBy required ');
+	END IF;
+	IF length(error_msg) > 0 THEN
+		SET error_msg := concat(error_msg, 'when updating record');
+		signal sqlstate '45000' set message_text = error_msg;
+	END IF;
+	# Force values on modify
+	# The only modification allowed is to mark as deleted...
+	SET NEW.modifiedDate := current_timestamp(6);
+	IF NEW.modifiedBy IS NULL OR NEW.modifiedBy = '' THEN
+		SET NEW.modifiedBy := NEW.deletedBy;
+	END IF;
+	SET NEW.isDeleted = 1;
+	SET NEW.deletedDate := current_timestamp(6);
+	IF NEW.deletedBy IS NULL OR NEW.deletedBy = '' THEN
+		SET NEW.deletedBy := NEW.modifiedBy;
+	END IF;
+	# Archive table
+	INSERT INTO a_user_object_favorite SET
+		id = NEW.id
+		,createdDate = NEW.createdDate
+		,createdBy = NEW.createdBy
+		,modifiedDate = NEW.modifiedDate
+		,modifiedBy = NEW.modifiedBy
+		,isDeleted = NEW.isDeleted
+		,deletedDate = NEW.deletedDate
+		,deletedBy = NEW.deletedBy
+		,objectId = NEW.objectId;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS td_user_object_favorite;
+-- +migrate StatementBegin
+CREATE TRIGGER td_user_object_favorite
+BEFORE DELETE ON user_object_favorite FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default 'Deleting records are not allowed. Use isDeleted, deletedDate, and deletedBy';
+	signal sqlstate '45000' set message_text = error_msg;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS td_a_user_object_favorite;
+-- +migrate StatementBegin
+CREATE TRIGGER td_a_user_object_favorite
+BEFORE DELETE ON a_user_object_favorite FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default 'Deleting records are not allowed on archive tables.';
+	signal sqlstate '45000' set message_text = error_msg;
+END;
+-- +migrate StatementEnd
+
+INSERT INTO migration_status SET description = '20191002_user_object_favorite setting schemaversion to 20191002';
+update dbstate set schemaVersion = '20191002' where schemaVersion <> '20191002';
+
+-- +migrate Down
+
+DROP TRIGGER IF EXISTS ti_user_object_favorite;
+DROP TRIGGER IF EXISTS tu_user_object_favorite;
+DROP TRIGGER IF EXISTS td_user_object_favorite;
+DROP TRIGGER IF EXISTS td_a_user_object_favorite;
+DROP TABLE IF EXISTS a_user_object_favorite;
+DROP TABLE IF EXISTS user_object_favorite;
+
+update dbstate set schemaVersion = '20191001' where schemaVersion <> '20191001';
@@ -0,0 +1,202 @@
+-- +migrate Up
+
+-- Restores user_object_subscription so that users can be notified of changes to objects. The table
+-- and its triggers were dropped as part of the 20170630 combined migration while they were not yet
+-- in use. A callbackUrl is now tracked for delivery of notifications.
+
+INSERT INTO migration_status SET description = '20191003_user_object_subscription creating table user_object_subscription';
+CREATE TABLE IF NOT EXISTS user_object_subscription
+(
+  id binary(16) not null default 0
+  ,createdDate timestamp(6) null
+  ,createdBy varchar(255) not null
+  ,modifiedDate timestamp(6) null
+  ,modifiedBy varchar(255) null
+  ,isDeleted boolean null
+  ,deletedDate timestamp(6) null
+  ,deletedBy varchar(255) null
+  ,objectId binary(16) not null
+  ,onCreate boolean null
+  ,onUpdate boolean null
+  ,onDelete boolean null
+  ,recursive boolean null
+  ,callbackUrl varchar(2048) not null
+  ,CONSTRAINT pk_user_object_subscription PRIMARY KEY (id)
+  ,INDEX ix_createdBy (createdBy)
+  ,INDEX ix_isDeleted (isDeleted)
+  ,INDEX ix_objectId (objectId)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+CREATE TABLE IF NOT EXISTS a_user_object_subscription
+(
+  a_id int not null auto_increment
+  ,id binary(16) not null
+  ,createdDate timestamp(6) null
+  ,createdBy varchar(255) not null
+  ,modifiedDate timestamp(6) null
+  ,modifiedBy varchar(255) null
+  ,isDeleted boolean null
+  ,deletedDate timestamp(6) null
+  ,deletedBy varchar(255) null
+  ,objectId binary(16) not null
+  ,onCreate boolean null
+  ,onUpdate boolean null
+  ,onDelete boolean null
+  ,recursive boolean null
+  ,callbackUrl varchar(2048) not null
+  ,CONSTRAINT pk_a_user_object_subscription PRIMARY KEY (a_id)
+  ,INDEX ix_id (id)
+  ,INDEX ix_createdBy (createdBy)
+  ,INDEX ix_isDeleted (isDeleted)
+  ,INDEX ix_objectId (objectId)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+
+INSERT INTO migration_status SET description = '20191003_user_object_subscription creating triggers for user_object_subscription';
+DROP TRIGGER IF EXISTS ti_user_object_subscription;
+-- +migrate StatementBegin
+CREATE TRIGGER ti_user_object_subscription
+BEFORE INSERT ON user_object_subscription FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default '';
+	DECLARE thisTableName varchar(128) default 'user_object_subscription';
+	# Rules
+	# objectId must be specified
+	IF NEW.objectId IS NULL OR NEW.objectId = '' THEN
+		SET error_msg := concat(error_msg, 'Field objectId required ');
+	END IF;
+	# callbackUrl must be specified
+	IF NEW.callbackUrl IS NULL OR NEW.callbackUrl = '' THEN
+		SET error_msg := concat(error_msg, 'Field callbackUrl required ');
+	END IF;
+	IF error_msg <> '' THEN
+		SET error_msg := concat(error_msg, 'when inserting record into ', thisTableName);
+		signal sqlstate '45000' set message_text = error_msg;
+	END IF;
+	# Force values on create
+	SET NEW.id := ordered_uuid(UUID());
+	SET NEW.createdDate := current_timestamp(6);
+	SET NEW.modifiedDate := current_timestamp(6);
+	SET NEW.modifiedBy := NEW.createdBy;
+	SET NEW.isDeleted := 0;
+	SET NEW.deletedDate := NULL;
+	SET NEW.deletedBy := NULL;
+	# Archive table
+	INSERT INTO a_user_object_subscription SET
+		id = NEW.id
+		,createdDate = NEW.createdDate
+		,createdBy = NEW.createdBy
+		,modifiedDate = NEW.modifiedDate
+		,modifiedBy = NEW.modifiedBy
+		,isDeleted = NEW.isDeleted
+		,deletedDate = NEW.deletedDate
+		,deletedBy = NEW.deletedBy
+		,objectId = NEW.objectId
+		,onCreate = NEW.onCreate
+		,onUpdate = NEW.onUpdate
+		,onDelete = NEW.onDelete
+		,recursive = NEW.recursive
+		,callbackUrl = NEW.callbackUrl;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS tu_user_object_subscription;
+-- +migrate StatementBegin
+CREATE TRIGGER tu_user_object_subscription
+BEFORE UPDATE ON user_object_subscription FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default '';
+	DECLARE thisTableName varchar(128) default 'user_object_subscription';
+	# Rules
+	# deleted records cannot be changed
+	IF (OLD.isDeleted = 1) AND length(error_msg) < 73 THEN
+		SET error_msg := concat(error_msg, 'Unable to change deleted record ');
+	END IF;
+	# id cannot be changed
+	IF (NEW.id <> OLD.id) AND length(error_msg) < 83 THEN
+		SET error_msg := concat(error_msg, 'Unable to set id ');
+	END IF;
+	# createdDate cannot be changed
+	IF (NEW.createdDate <> OLD.createdDate) AND length(error_msg) < 74 THEN
+		SET error_msg := concat(error_msg, 'Unable to set createdDate ');
+	END IF;
+	# createdBy cannot be changed
+	IF (NEW.createdBy <> OLD.createdBy) AND length(error_msg) < 76 THEN
+		SET error_msg := concat(error_msg, 'Unable to set createdBy ');
+	END IF;
+	# objectId cannot be changed
+	IF (NEW.objectId <> OLD.objectId) AND length(error_msg) < 77 THEN
+		SET error_msg := concat(error_msg, 'Unable to set objectId ');
+	END IF;
+	# callbackUrl must be specified
+	IF (NEW.callbackUrl IS NULL OR NEW.callbackUrl = '') AND length(error_msg) < 73 THEN
+		SET error_msg := concat(error_msg, 'Field callbackUrl required ');
+	END IF;
+	# modifiedBy must be set
+	IF (NEW.modifiedBy IS NULL OR NEW.modifiedBy = '') AND length(error_msg) < 76 THEN
+		SET error_msg := concat(error_msg, 'Field modifiedBy required ');
+	END IF;
+	IF length(error_msg) > 0 THEN
+		SET error_msg := concat(error_msg, 'when updating record');
+		signal sqlstate '45000' set message_text = error_msg;
+	END IF;
+	# Force values on modify
+	SET NEW.modifiedDate := current_timestamp(6);
+	IF NEW.isDeleted = 1 THEN
+		SET NEW.deletedDate := current_timestamp(6);
+		IF NEW.deletedBy IS NULL OR NEW.deletedBy = '' THEN
+			SET NEW.deletedBy := NEW.modifiedBy;
+		END IF;
+	ELSE
+		SET NEW.deletedDate := NULL;
+		SET NEW.deletedBy := NULL;
+	END IF;
+	# Archive table
+	INSERT INTO a_user_object_subscription SET
+		id = NEW.id
+		,createdDate = NEW.createdDate
+		,createdBy = NEW.createdBy
+		,modifiedDate = NEW.modifiedDate
+		,modifiedBy = NEW.modifiedBy
+		,isDeleted = NEW.isDeleted
+		,deletedDate = NEW.deletedDate
+		,deletedBy = NEW.deletedBy
+		,objectId = NEW.objectId
+		,onCreate = NEW.onCreate
+		,onUpdate = NEW.onUpdate
+		,onDelete = NEW.onDelete
+		,recursive = NEW.recursive
+		,callbackUrl = NEW.callbackUrl;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS td_user_object_subscription;
+-- +migrate StatementBegin
+CREATE TRIGGER td_user_object_subscription
+BEFORE DELETE ON user_object_subscription FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default 'Deleting records are not allowed. Use isDeleted, deletedDate, and deletedBy';
+	signal sqlstate '45000' set message_text = error_msg;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS td_a_user_object_subscription;
+-- +migrate StatementBegin
+CREATE TRIGGER td_a_user_object_subscription
+BEFORE DELETE ON a_user_object_subscription FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default 'Deleting records are not allowed on archive tables.';
+	signal sqlstate '45000' set message_text = error_msg;
+END;
+-- +migrate StatementEnd
+
+INSERT INTO migration_status SET description = '20191003_user_object_subscription setting schemaversion to 20191003';
+update dbstate set schemaVersion = '20191003' where schemaVersion <> '20191003';
+
+-- +migrate Down
+
+DROP TRIGGER IF EXISTS ti_user_object_subscription;
+DROP TRIGGER IF EXISTS tu_user_object_subscription;
+DROP TRIGGER IF EXISTS td_user_object_subscription;
+DROP TRIGGER IF EXISTS td_a_user_object_subscription;
+DROP TABLE IF EXISTS a_user_object_subscription;
+DROP TABLE IF EXISTS user_object_subscription;
+
+update dbstate set schemaVersion = '20191002' where schemaVersion <> '20191002';
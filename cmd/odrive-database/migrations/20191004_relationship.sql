@@ -0,0 +1,244 @@
+-- +migrate Up
+
+-- Restores relationship so that objects can be linked to each other. The table and its triggers were
+-- dropped as part of the 20170630 combined migration while they were not yet in use. Relationships are
+-- now typed, with relationshipType describing how the source relates to the target.
+
+INSERT INTO migration_status SET description = '20191004_relationship creating table relationship';
+CREATE TABLE IF NOT EXISTS relationship
+(
+  id binary(16) not null default 0
+  ,createdDate timestamp(6) null
+  ,createdBy varchar(255) not null
+  ,modifiedDate timestamp(6) null
+  ,modifiedBy varchar(255) null
+  ,isDeleted boolean null
+  ,deletedDate timestamp(6) null
+  ,deletedBy varchar(255) null
+  ,changeCount int null
+  ,changeToken varchar(60) null
+  ,sourceId binary(16) not null
+  ,targetId binary(16) not null
+  ,relationshipType varchar(255) not null
+  ,description varchar(10240) null
+  ,classificationPM varchar(200) null
+  ,CONSTRAINT pk_relationship PRIMARY KEY (id)
+  ,INDEX ix_isDeleted (isDeleted)
+  ,INDEX ix_sourceId (sourceId)
+  ,INDEX ix_targetId (targetId)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+CREATE TABLE IF NOT EXISTS a_relationship
+(
+  a_id int not null auto_increment
+  ,id binary(16) not null
+  ,createdDate timestamp(6) null
+  ,createdBy varchar(255) not null
+  ,modifiedDate timestamp(6) null
+  ,modifiedBy varchar(255) null
+  ,isDeleted boolean null
+  ,deletedDate timestamp(6) null
+  ,deletedBy varchar(255) null
+  ,changeCount int null
+  ,changeToken varchar(60) null
+  ,sourceId binary(16) not null
+  ,targetId binary(16) not null
+  ,relationshipType varchar(255) not null
+  ,description varchar(10240) null
+  ,classificationPM varchar(200) null
+  ,CONSTRAINT pk_a_relationship PRIMARY KEY (a_id)
+  ,INDEX ix_id (id)
+  ,INDEX ix_modifiedDate (modifiedDate)
+  ,INDEX ix_isDeleted (isDeleted)
+  ,INDEX ix_changeCount (changeCount)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+
+INSERT INTO migration_status SET description = '20191004_relationship creating triggers for relationship';
+DROP TRIGGER IF EXISTS ti_relationship;
+-- +migrate StatementBegin
+CREATE TRIGGER ti_relationship
+BEFORE INSERT ON relationship FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default '';
+	DECLARE thisTableName varchar(128) default 'relationship';
+	DECLARE count_object int default 0;
+	DECLARE count_relationship int default 0;
+	# Rules
+	# sourceId must be specified and exist
+	SELECT COUNT(*) FROM object WHERE isDeleted = 0 AND id = NEW.sourceId INTO count_object;
+	IF count_object = 0 THEN
+		SET error_msg := concat(error_msg, 'Field sourceId required ');
+	END IF;
+	# targetId must be specified and exist
+	SELECT COUNT(*) FROM object WHERE isDeleted = 0 AND id = NEW.targetId INTO count_object;
+	IF count_object = 0 THEN
+		SET error_msg := concat(error_msg, 'Field targetId required ');
+	END IF;
+	# sourceId and targetId must differ
+	IF NEW.sourceId = NEW.targetId THEN
+		SET error_msg := concat(error_msg, 'Field targetId must differ from sourceId ');
+	END IF;
+	# relationshipType must be specified
+	IF NEW.relationshipType IS NULL OR NEW.relationshipType = '' THEN
+		SET error_msg := concat(error_msg, 'Field relationshipType required ');
+	END IF;
+	# relationship must be unique for the source, target and type
+	SELECT COUNT(*) FROM relationship WHERE isDeleted = 0 AND sourceId = NEW.sourceId AND targetId = NEW.targetId AND relationshipType = NEW.relationshipType INTO count_relationship;
+	IF count_relationship > 0 THEN
+		SET error_msg := concat(error_msg, 'Relationship must be unique ');
+	END IF;
+	IF error_msg <> '' THEN
+		SET error_msg := concat(error_msg, 'when inserting record into ', thisTableName);
+		signal sqlstate '45000' set message_text = error_msg;
+	END IF;
+	# Force values on create
+	SET NEW.id := ordered_uuid(UUID());
+	SET NEW.createdDate := current_timestamp(6);
+	SET NEW.modifiedDate := current_timestamp(6);
+	SET NEW.modifiedBy := NEW.createdBy;
+	SET NEW.isDeleted := 0;
+	SET NEW.deletedDate := NULL;
+	SET NEW.deletedBy := NULL;
+	SET NEW.changeCount := 0;
+	# Standard change token formula
+	SET NEW.changeToken := md5(CONCAT(CAST(NEW.id AS CHAR),':',CAST(NEW.changeCount AS CHAR),':',CAST(NEW.modifiedDate AS CHAR)));
+	# Archive table
+	INSERT INTO a_relationship SET
+		id = NEW.id
+		,createdDate = NEW.createdDate
+		,createdBy = NEW.createdBy
+		,modifiedDate = NEW.modifiedDate
+		,modifiedBy = NEW.modifiedBy
+		,isDeleted = NEW.isDeleted
+		,deletedDate = NEW.deletedDate
+		,deletedBy = NEW.deletedBy
+		,changeCount = NEW.changeCount
+		,changeToken = NEW.changeToken
+		,sourceId = NEW.sourceId
+		,targetId = NEW.targetId
+		,relationshipType = NEW.relationshipType
+		,description = NEW.description
+		,classificationPM = NEW.classificationPM;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS tu_relationship;
+-- +migrate StatementBegin
+CREATE TRIGGER tu_relationship
+BEFORE UPDATE ON relationship FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default '';
+	DECLARE thisTableName varchar(128) default 'relationship';
+	# Rules
+	# deleted records cannot be changed
+	IF (OLD.isDeleted = 1) AND length(error_msg) < 79 THEN
+		SET error_msg := concat(error_msg, 'Record is deleted ');
+	END IF;
+	# id cannot be changed
+	IF (NEW.id <> OLD.id) AND length(error_msg) < 83 THEN
+		SET error_msg := concat(error_msg, 'Unable to set id ');
+	END IF;
+	# createdDate cannot be changed
+	IF (NEW.createdDate <> OLD.createdDate) AND length(error_msg) < 74 THEN
+		SET error_msg := concat(error_msg, 'Unable to set createdDate ');
+	END IF;
+	# createdBy cannot be changed
+	IF (NEW.createdBy <> OLD.createdBy) AND length(error_msg) < 76 THEN
+		SET error_msg := concat(error_msg, 'Unable to set createdBy ');
+	END IF;
+	# modifiedBy must be set
+	IF (NEW.modifiedBy IS NULL OR NEW.modifiedBy = '') AND length(error_msg) < 74 THEN
+		SET error_msg := concat(error_msg, 'Field modifiedBy required ');
+	END IF;
+	# changeCount cannot be changed
+	IF (NEW.changeCount <> OLD.changeCount) AND length(error_msg) < 74 THEN
+		SET error_msg := concat(error_msg, 'Unable to set changeCount ');
+	END IF;
+	# changeToken must be given and match the record
+	IF (NEW.changeToken IS NULL OR NEW.changeToken = '') and length(error_msg) < 73 THEN
+		SET error_msg := concat(error_msg, 'Field changeToken required ');
+	END IF;
+	IF (NEW.changeToken <> OLD.changeToken) and length(error_msg) < 71 THEN
+		SET error_msg := concat(error_msg, 'Field changeToken must match ');
+	END IF;
+	# sourceId cannot be changed
+	IF (NEW.sourceId <> OLD.sourceId) AND length(error_msg) < 77 THEN
+		SET error_msg := concat(error_msg, 'Unable to set sourceId ');
+	END IF;
+	# targetId cannot be changed
+	IF (NEW.targetId <> OLD.targetId) AND length(error_msg) < 77 THEN
+		SET error_msg := concat(error_msg, 'Unable to set targetId ');
+	END IF;
+	# relationshipType cannot be changed
+	IF (NEW.relationshipType <> OLD.relationshipType) AND length(error_msg) < 69 THEN
+		SET error_msg := concat(error_msg, 'Unable to set relationshipType ');
+	END IF;
+	IF length(error_msg) > 0 THEN
+		SET error_msg := concat(error_msg, 'when updating record');
+		signal sqlstate '45000' set message_text = error_msg;
+	END IF;
+	# Force values on modify
+	SET NEW.modifiedDate := current_timestamp(6);
+	IF (NEW.isDeleted <> OLD.isDeleted) THEN
+		IF (NEW.isDeleted = 1) THEN
+			SET NEW.deletedDate := current_timestamp(6);
+			SET NEW.deletedBy := NEW.modifiedBy;
+		ELSE
+			SET NEW.deletedDate := NULL;
+			SET NEW.deletedBy := NULL;
+		END IF;
+	END IF;
+	SET NEW.changeCount := OLD.changeCount + 1;
+	# Standard change token formula
+	SET NEW.changeToken := md5(CONCAT(CAST(OLD.id AS CHAR),':',CAST(NEW.changeCount AS CHAR),':',CAST(NEW.modifiedDate AS CHAR)));
+	# Archive table
+	INSERT INTO a_relationship SET
+		id = NEW.id
+		,createdDate = NEW.createdDate
+		,createdBy = NEW.createdBy
+		,modifiedDate = NEW.modifiedDate
+		,modifiedBy = NEW.modifiedBy
+		,isDeleted = NEW.isDeleted
+		,deletedDate = NEW.deletedDate
+		,deletedBy = NEW.deletedBy
+		,changeCount = NEW.changeCount
+		,changeToken = NEW.changeToken
+		,sourceId = NEW.sourceId
+		,targetId = NEW.targetId
+		,relationshipType = NEW.relationshipType
+		,description = NEW.description
+		,classificationPM = NEW.classificationPM;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS td_relationship;
+-- +migrate StatementBegin
+CREATE TRIGGER td_relationship
+BEFORE DELETE ON relationship FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default 'Deleting records are not allowed. Use isDeleted, deletedDate, and deletedBy';
+	signal sqlstate '45000' set message_text = error_msg;
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS td_a_relationship;
+-- +migrate StatementBegin
+CREATE TRIGGER td_a_relationship
+BEFORE DELETE ON a_relationship FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default 'Deleting records are not allowed on archive tables.';
+	signal sqlstate '45000' set message_text = error_msg;
+END;
+-- +migrate StatementEnd
+
+INSERT INTO migration_status SET description = '20191004_relationship setting schemaversion to 20191004';
+update dbstate set schemaVersion = '20191004' where schemaVersion <> '20191004';
+
+-- +migrate Down
+
+DROP TRIGGER IF EXISTS ti_relationship;
+DROP TRIGGER IF EXISTS tu_relationship;
+DROP TRIGGER IF EXISTS td_relationship;
+DROP TRIGGER IF EXISTS td_a_relationship;
+DROP TABLE IF EXISTS a_relationship;
+DROP TABLE IF EXISTS relationship;
+
+update dbstate set schemaVersion = '20191003' where schemaVersion <> '20191003';
@@ -0,0 +1,97 @@
+-- +migrate Up
+
+-- Extends object_type_property so that object types may declare the properties of their objects. Each
+-- definition links the type to a property whose name is the property name and whose value is the
+-- default. The value type, whether the property is required, and the allowed values for enumerations
+-- are recorded on the link. The foreign key for typeId previously referenced the wrong column and is
+-- recreated.
+
+SET FOREIGN_KEY_CHECKS=0;
+
+INSERT INTO migration_status SET description = '20191005_object_type_property altering table object_type_property';
+DROP PROCEDURE IF EXISTS sp_Patch_20191005_object_type_property;
+-- +migrate StatementBegin
+CREATE PROCEDURE sp_Patch_20191005_object_type_property()
+BEGIN
+    IF EXISTS (select null from information_schema.table_constraints where table_name = 'object_type_property' and binary constraint_name = 'fk_object_type_property_typeid') THEN
+        INSERT INTO migration_status SET description = '20191005_object_type_property drop fk_object_type_property_typeid';
+        ALTER TABLE object_type_property DROP FOREIGN KEY fk_object_type_property_typeid;
+    END IF;
+    IF EXISTS (select null from information_schema.statistics where table_name = 'object_type_property' and binary index_name = 'fk_object_type_property_typeid') THEN
+        INSERT INTO migration_status SET description = '20191005_object_type_property drop index fk_object_type_property_typeid';
+        ALTER TABLE object_type_property DROP INDEX fk_object_type_property_typeid;
+    END IF;
+    INSERT INTO migration_status SET description = '20191005_object_type_property creating fk_object_type_property_typeid';
+    ALTER TABLE object_type_property ADD CONSTRAINT fk_object_type_property_typeid FOREIGN KEY (typeid) REFERENCES object_type(id);
+    IF NOT EXISTS (select null from information_schema.columns where table_name = 'object_type_property' and column_name = 'valueType') THEN
+        INSERT INTO migration_status SET description = '20191005_object_type_property adding valueType, isRequired, allowedValues';
+        ALTER TABLE object_type_property ADD COLUMN valueType varchar(10) not null default 'string';
+        ALTER TABLE object_type_property ADD COLUMN isRequired boolean not null default 0;
+        ALTER TABLE object_type_property ADD COLUMN allowedValues varchar(10240) null;
+    END IF;
+END;
+-- +migrate StatementEnd
+CALL sp_Patch_20191005_object_type_property();
+SET FOREIGN_KEY_CHECKS=1;
+DROP PROCEDURE IF EXISTS sp_Patch_20191005_object_type_property;
+
+INSERT INTO migration_status SET description = '20191005_object_type_property recreating ti_object_type_property';
+DROP TRIGGER IF EXISTS ti_object_type_property;
+-- +migrate StatementBegin
+CREATE TRIGGER ti_object_type_property
+BEFORE INSERT ON object_type_property FOR EACH ROW
+BEGIN
+	DECLARE error_msg varchar(128) default '';
+	DECLARE thisTableName varchar(128) default 'object_type_property';
+	# Rules
+	# valueType must be one of the supported types
+	IF NEW.valueType IS NULL OR NEW.valueType NOT IN ('string', 'int', 'date', 'enum') THEN
+		SET error_msg := concat(error_msg, 'Field valueType invalid ');
+	END IF;
+	# allowedValues must be given for enum
+	IF NEW.valueType = 'enum' AND (NEW.allowedValues IS NULL OR NEW.allowedValues = '') THEN
+		SET error_msg := concat(error_msg, 'Field allowedValues required ');
+	END IF;
+	IF error_msg <> '' THEN
+		SET error_msg := concat(error_msg, 'when inserting record into ', thisTableName);
+		signal sqlstate '45000' set message_text = error_msg;
+	END IF;
+	# Force values on create
+	SET NEW.id := ordered_uuid(UUID());
+	SET NEW.createdDate := current_timestamp(6);
+	SET NEW.modifiedDate := current_timestamp(6);
+	SET NEW.modifiedBy := NEW.createdBy;
+	SET NEW.isDeleted := 0;
+	SET NEW.deletedDate := NULL;
+	SET NEW.deletedBy := NULL;
+	# No archive table
+END;
+-- +migrate StatementEnd
+
+INSERT INTO migration_status SET description = '20191005_object_type_property setting schemaversion to 20191005';
+update dbstate set schemaVersion = '20191005' where schemaVersion <> '20191005';
+
+-- +migrate Down
+
+DROP TRIGGER IF EXISTS ti_object_type_property;
+-- +migrate StatementBegin
+CREATE TRIGGER ti_object_type_property
+BEFORE INSERT ON object_type_property FOR EACH ROW
+BEGIN
+	DECLARE thisTableName varchar(128) default 'object_type_property';
+	# Force values on create
+	SET NEW.id := ordered_uuid(UUID());
+	SET NEW.createdDate := current_timestamp(6);
+	SET NEW.modifiedDate := current_timestamp(6);
+	SET NEW.modifiedBy := NEW.createdBy;
+	SET NEW.isDeleted := 0;
+	SET NEW.deletedDate := NULL;
+	SET NEW.deletedBy := NULL;
+	# No archive table
+END;
+-- +migrate StatementEnd
+ALTER TABLE object_type_property DROP COLUMN valueType;
+ALTER TABLE object_type_property DROP COLUMN isRequired;
+ALTER TABLE object_type_property DROP COLUMN allowedValues;
+
+update dbstate set schemaVersion = '20191004' where schemaVersion <> '20191004';
@@ -0,0 +1,44 @@
+-- +migrate Up
+
+-- Adds content_dedup and content_reference so that identical content uploaded with the same ACM into
+-- a cache zone that deduplicates is stored once. Each object that refers to deduplicated content in
+-- any of its revisions has a reference to it. When the last reference is removed by expunging, the
+-- content is marked released so that it may be removed from storage.
+
+INSERT INTO migration_status SET description = '20191006_content_dedup creating table content_dedup';
+CREATE TABLE IF NOT EXISTS content_dedup
+(
+  contentConnector varchar(255) not null
+  ,createdDate timestamp(6) not null default current_timestamp(6)
+  ,zone varchar(255) not null default ''
+  ,acmId int unsigned not null
+  ,contentHash binary(32) not null
+  ,contentSize bigint not null
+  ,encryptIV binary(16) not null
+  ,releasedDate timestamp(6) null
+  ,CONSTRAINT pk_content_dedup PRIMARY KEY (contentConnector)
+  ,INDEX ix_contentHash (contentHash, contentSize, acmId)
+  ,INDEX ix_releasedDate (releasedDate)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+
+INSERT INTO migration_status SET description = '20191006_content_dedup creating table content_reference';
+CREATE TABLE IF NOT EXISTS content_reference
+(
+  objectId binary(16) not null
+  ,contentConnector varchar(255) not null
+  ,createdDate timestamp(6) not null default current_timestamp(6)
+  ,CONSTRAINT pk_content_reference PRIMARY KEY (objectId, contentConnector)
+  ,INDEX ix_contentConnector (contentConnector)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+
+INSERT INTO migration_status SET description = '20191006_content_dedup setting schemaversion to 20191006';
+update dbstate set schemaVersion = '20191006' where schemaVersion <> '20191006';
+
+-- +migrate Down
+
+DROP TABLE IF EXISTS content_reference;
+DROP TABLE IF EXISTS content_dedup;
+
+update dbstate set schemaVersion = '20191005' where schemaVersion <> '20191005';
@@ -0,0 +1,56 @@
+-- +migrate Up
+
+-- Adds job and job_error so that long running operations may be performed in the background and
+-- report their progress. A job is claimed by the server instance running it, which renews its
+-- heartbeat as it records progress. A job whose heartbeat has lapsed is resumed by any instance
+-- from the number of items already processed.
+
+INSERT INTO migration_status SET description = '20191007_job creating table job';
+CREATE TABLE IF NOT EXISTS job
+(
+  id binary(16) not null
+  ,createdDate timestamp(6) not null default current_timestamp(6)
+  ,createdBy varchar(255) not null
+  ,modifiedDate timestamp(6) not null default current_timestamp(6)
+  ,jobType varchar(50) not null
+  ,state varchar(20) not null
+  ,request mediumtext not null
+  ,context mediumtext not null
+  ,total int not null default 0
+  ,processed int not null default 0
+  ,succeeded int not null default 0
+  ,failed int not null default 0
+  ,error text null
+  ,claimedBy varchar(255) null
+  ,heartbeatDate timestamp(6) null
+  ,finishedDate timestamp(6) null
+  ,CONSTRAINT pk_job PRIMARY KEY (id)
+  ,INDEX ix_createdBy (createdBy)
+  ,INDEX ix_state (state, heartbeatDate)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+
+INSERT INTO migration_status SET description = '20191007_job creating table job_error';
+CREATE TABLE IF NOT EXISTS job_error
+(
+  id int unsigned not null auto_increment
+  ,jobId binary(16) not null
+  ,createdDate timestamp(6) not null default current_timestamp(6)
+  ,objectId varchar(255) not null
+  ,code int not null
+  ,error text null
+  ,msg text null
+  ,CONSTRAINT pk_job_error PRIMARY KEY (id)
+  ,INDEX ix_jobId (jobId, id)
+) DEFAULT CHARACTER SET utf8 COLLATE utf8_unicode_ci
+;
+
+INSERT INTO migration_status SET description = '20191007_job setting schemaversion to 20191007';
+update dbstate set schemaVersion = '20191007' where schemaVersion <> '20191007';
+
+-- +migrate Down
+
+DROP TABLE IF EXISTS job_error;
+DROP TABLE IF EXISTS job;
+
+update dbstate set schemaVersion = '20191006' where schemaVersion <> '20191006';
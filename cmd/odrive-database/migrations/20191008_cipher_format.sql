@@ -0,0 +1,626 @@
+-- +migrate Up
+
+-- Adds cipherFormat to object, a_object and content_dedup to record the format each content stream
+-- is encrypted in at rest. Existing content is AES-CTR (0). New content is sealed in chunks with
+-- AES-GCM (1) so that tampering is detected, and existing content may be re-encrypted to it in the
+-- background. A session re-encrypting content sets @odrive_reencrypting so that the update of object
+-- is not recorded as a revision.
+
+INSERT INTO migration_status SET description = '20191008_cipher_format adding cipherFormat to object';
+ALTER TABLE object ADD COLUMN cipherFormat tinyint not null default 0 AFTER encryptIV;
+INSERT INTO migration_status SET description = '20191008_cipher_format adding cipherFormat to a_object';
+ALTER TABLE a_object ADD COLUMN cipherFormat tinyint not null default 0 AFTER encryptIV;
+INSERT INTO migration_status SET description = '20191008_cipher_format adding cipherFormat to content_dedup';
+ALTER TABLE content_dedup ADD COLUMN cipherFormat tinyint not null default 0 AFTER encryptIV;
+INSERT INTO migration_status SET description = '20191008_cipher_format adding index on a_object cipherFormat';
+CREATE INDEX ix_cipherFormat ON a_object (cipherFormat, contentConnector(255));
+
+INSERT INTO migration_status SET description = '20191008_cipher_format recreating triggers on object';
+DROP TRIGGER IF EXISTS ti_object;
+-- +migrate StatementBegin
+CREATE TRIGGER ti_object
+BEFORE INSERT ON object FOR EACH ROW
+BEGIN
+    DECLARE error_msg varchar(128) default '';
+    DECLARE thisTableName varchar(128) default 'object';
+    DECLARE type_contentConnector varchar(2000) default '';
+
+    # Rules
+    # Type must be specified
+    IF NOT EXISTS (select null from object_type where isdeleted = 0 and id = new.typeid) THEN
+        SET error_msg := concat(error_msg, 'Field typeId required ');
+    END IF;
+    # US Persons Data is NULLed if empty (will change to Unknown)
+    IF NEW.containsUSPersonsData IS NOT NULL AND NEW.containsUSPersonsData = '' THEN
+        SET NEW.containsUSPersonsData := NULL;
+    END IF;
+    # FOIA Exempt is NULLed if empty (will change to Unknown)
+    IF NEW.exemptFromFOIA IS NOT NULL AND NEW.exemptFromFOIA = '' THEN
+        SET NEW.exemptFromFOIA := NULL;
+    END IF;
+    # ParentId must be valid if specified
+    IF NEW.parentId IS NOT NULL AND NEW.parentId = '' THEN
+        SET NEW.parentId := NULL;
+    END IF;
+    IF NEW.parentId IS NOT NULL THEN
+        IF NOT EXISTS (select null from object where isdeleted = 0 and id = new.parentid) THEN
+            SET error_msg := concat(error_msg, 'Field parentId must be valid ');
+        END IF;
+    END IF;
+    IF error_msg <> '' THEN
+        SET error_msg := concat(error_msg, 'when inserting record into ', thisTableName);
+        signal sqlstate '45000' set message_text = error_msg;
+    END IF;
+
+    # Force values on create
+    SET NEW.id := ordered_uuid(UUID());
+    SET NEW.createdDate := current_timestamp(6);
+    SET NEW.modifiedDate := current_timestamp(6);
+    SET NEW.modifiedBy := NEW.createdBy;
+    SET NEW.isDeleted := 0;
+    SET NEW.deletedDate := NULL;
+    SET NEW.deletedBy := NULL;
+    SET NEW.isAncestorDeleted := 0;
+    SET NEW.isExpunged := 0;
+    SET NEW.expungedDate := NULL;
+    SET NEW.expungedBy := NULL;
+    # Assign Owner if not set
+    IF NEW.ownedBy IS NULL OR NEW.ownedBy = '' THEN
+        SET NEW.ownedBy := concat('user/', NEW.createdBy);
+    END IF;
+    SET NEW.ownedBy := calcResourceString(NEW.ownedBy);
+    SET NEW.ownedByID := calcGranteeIDFromResourceString(NEW.ownedBy);
+    SET NEW.changeCount := 0;
+    # Standard change token formula
+    SET NEW.changeToken := md5(CONCAT(CAST(NEW.id AS CHAR),':',CAST(NEW.changeCount AS CHAR),':',CAST(NEW.modifiedDate AS CHAR)));
+    # Assign contentConnector if not set
+    IF NEW.contentConnector IS NULL OR NEW.contentConnector = '' THEN
+        SELECT contentConnector FROM object_type WHERE isDeleted = 0 AND id = NEW.typeId INTO type_contentConnector;
+        SET NEW.contentConnector := type_contentConnector;
+    END IF;
+    # Assign US Persons Data if not set
+    IF NEW.containsUSPersonsData IS NULL THEN
+        SET NEW.containsUSPersonsData := 'Unknown';
+    END IF;
+    # Assign FOIA Exempt status if not set
+    IF NEW.exemptFromFOIA IS NULL THEN
+        SET NEW.exemptFromFOIA := 'Unknown';
+    END IF;
+
+
+    # Archive table
+    INSERT INTO
+        a_object
+    (
+        id
+        ,createdDate
+        ,createdBy
+        ,modifiedDate
+        ,modifiedBy
+        ,isDeleted
+        ,deletedDate
+        ,deletedBy
+        ,isAncestorDeleted
+        ,isExpunged
+        ,expungedDate
+        ,expungedBy
+        ,changeCount
+        ,changeToken
+        ,ownedBy
+        ,typeId
+        ,name
+        ,description
+        ,parentId
+        ,contentConnector
+        ,rawAcm
+        ,contentType
+        ,contentSize
+        ,contentHash
+        ,encryptIV
+        ,cipherFormat
+        ,containsUSPersonsData
+        ,exemptFromFOIA
+        ,acmId
+        ,ownedById
+    ) values (
+        NEW.id
+        ,NEW.createdDate
+        ,NEW.createdBy
+        ,NEW.modifiedDate
+        ,NEW.modifiedBy
+        ,NEW.isDeleted
+        ,NEW.deletedDate
+        ,NEW.deletedBy
+        ,NEW.isAncestorDeleted
+        ,NEW.isExpunged
+        ,NEW.expungedDate
+        ,NEW.expungedBy
+        ,NEW.changeCount
+        ,NEW.changeToken
+        ,NEW.ownedBy
+        ,NEW.typeId
+        ,NEW.name
+        ,NEW.description
+        ,NEW.parentId
+        ,NEW.contentConnector
+        ,NEW.rawAcm
+        ,NEW.contentType
+        ,NEW.contentSize
+        ,NEW.contentHash
+        ,NEW.encryptIV
+        ,NEW.cipherFormat
+        ,NEW.containsUSPersonsData
+        ,NEW.exemptFromFOIA
+        ,NEW.acmId
+        ,NEW.ownedById
+    );
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS tu_object;
+-- +migrate StatementBegin
+CREATE TRIGGER tu_object
+BEFORE UPDATE ON object FOR EACH ROW
+tu_object_body: BEGIN
+    DECLARE error_msg varchar(128) default '';
+    DECLARE thisTableName varchar(128) default 'object';
+    DECLARE type_contentConnector varchar(2000) default '';
+
+    # Re-encrypting content at rest changes only where and how it is stored, which is not a revision
+    IF @odrive_reencrypting = 1 THEN
+        LEAVE tu_object_body;
+    END IF;
+
+    # Rules
+    # id cannot be changed
+    IF (NEW.id <> OLD.id) AND length(error_msg) < 83 THEN
+        SET error_msg := concat(error_msg, 'Unable to set id ');
+    END IF;
+    # createdDate cannot be changed
+    IF (NEW.createdDate <> OLD.createdDate) AND length(error_msg) < 74 THEN
+        SET error_msg := concat(error_msg, 'Unable to set createdDate ');
+    END IF;
+    # createdBy cannot be changed
+    IF (NEW.createdBy <> OLD.createdBy) AND length(error_msg) < 76 THEN
+        SET error_msg := concat(error_msg, 'Unable to set createdBy ');
+    END IF;
+    # changeCount cannot be changed
+    IF (NEW.changeCount <> OLD.changeCount) AND length(error_msg) < 74 THEN
+        SET error_msg := concat(error_msg, 'Unable to set changeCount ');
+    END IF;
+    # changeToken must be given and match the record
+    IF (NEW.changeToken IS NULL OR NEW.changeToken = '') and length(error_msg) < 73 THEN
+        SET error_msg := concat(error_msg, 'Field changeToken required ');
+    END IF;
+    IF (NEW.changeToken <> OLD.changeToken) and length(error_msg) < 71 THEN
+        SET error_msg := concat(error_msg, 'Field changeToken must match ');
+    END IF;
+    # TypeId must be specified
+    IF NOT EXISTS (select null from object_type where isdeleted = 0 and id = new.typeid) THEN
+        SET error_msg := concat(error_msg, 'Field typeId required ');
+    END IF;
+    # US Persons Data is set to old value if null/empty
+    IF NEW.containsUSPersonsData IS NULL OR NEW.containsUSPersonsData = '' THEN
+        SET NEW.containsUSPersonsData := OLD.containsUSPersonsData;
+    END IF;
+    # FOIA Exempt is set to old value if null/empty
+    IF NEW.exemptFromFOIA IS NULL OR NEW.exemptFromFOIA = '' THEN
+        SET NEW.exemptFromFOIA := OLD.exemptFromFOIA;
+    END IF;    
+    # ParentId must be valid if specified
+    IF NEW.parentId IS NOT NULL AND LENGTH(NEW.parentId) = 0 THEN
+        SET NEW.parentId := NULL;
+    END IF;
+    IF NEW.parentId IS NOT NULL THEN
+        IF NOT EXISTS (select null from object where (isDeleted = 0 or (NEW.IsDeleted <> OLD.IsDeleted)) AND id = NEW.parentId) THEN
+            SET error_msg := concat(error_msg, 'Field parentId must be valid ');
+        END IF;
+    END IF;    
+    IF length(error_msg) > 0 THEN
+        SET error_msg := concat(error_msg, 'when updating record');
+        signal sqlstate '45000' set message_text = error_msg;
+    END IF;
+
+    # Force values on modify
+    SET NEW.modifiedDate := current_timestamp(6);
+    IF (NEW.isDeleted <> OLD.isDeleted) THEN
+        IF  (NEW.IsDeleted = 1) THEN
+            SET NEW.deletedDate := current_timestamp(6);
+            SET NEW.deletedBy := NEW.modifiedBy;
+        ELSE
+            SET NEW.deletedDate := NULL;
+            SET NEW.deletedBy := NULL;
+        END IF;                
+    END IF;
+    SET NEW.changeCount := OLD.changeCount + 1;
+    # Standard change token formula
+    SET NEW.changeToken := md5(CONCAT(CAST(OLD.id AS CHAR),':',CAST(NEW.changeCount AS CHAR),':',CAST(NEW.modifiedDate AS CHAR)));
+    # Assign Owner if not set
+    IF NEW.ownedBy IS NULL OR NEW.ownedBy = '' THEN
+        SET NEW.ownedBy := concat('user/', NEW.createdBy);
+    END IF;
+    SET NEW.ownedBy := calcResourceString(NEW.ownedBy);
+    # ownedByID derived from ownedBy
+    SET NEW.ownedByID := calcGranteeIDFromResourceString(NEW.ownedBy);
+    # Assign US Persons Data if not set
+    IF NEW.containsUSPersonsData IS NULL THEN
+        SET NEW.containsUSPersonsData = 'Unknown';
+    END IF;
+    # Assign FOIA Exempt status if not set
+    IF NEW.exemptFromFOIA IS NULL THEN
+        SET NEW.exemptFromFOIA = 'Unknown';
+    END IF;
+
+    # Archive table
+    INSERT INTO
+        a_object
+    (
+        id
+        ,createdDate
+        ,createdBy
+        ,modifiedDate
+        ,modifiedBy
+        ,isDeleted
+        ,deletedDate
+        ,deletedBy
+        ,isAncestorDeleted
+        ,isExpunged
+        ,expungedDate
+        ,expungedBy
+        ,changeCount
+        ,changeToken
+        ,ownedBy
+        ,typeId
+        ,name
+        ,description
+        ,parentId
+        ,contentConnector
+        ,rawAcm
+        ,contentType
+        ,contentSize
+        ,contentHash
+        ,encryptIV
+        ,cipherFormat
+        ,containsUSPersonsData
+        ,exemptFromFOIA
+        ,acmId
+        ,ownedById
+    ) values (
+        NEW.id
+        ,NEW.createdDate
+        ,NEW.createdBy
+        ,NEW.modifiedDate
+        ,NEW.modifiedBy
+        ,NEW.isDeleted
+        ,NEW.deletedDate
+        ,NEW.deletedBy
+        ,NEW.isAncestorDeleted
+        ,NEW.isExpunged
+        ,NEW.expungedDate
+        ,NEW.expungedBy
+        ,NEW.changeCount
+        ,NEW.changeToken
+        ,NEW.ownedBy
+        ,NEW.typeId
+        ,NEW.name
+        ,NEW.description
+        ,NEW.parentId
+        ,NEW.contentConnector
+        ,NEW.rawAcm
+        ,NEW.contentType
+        ,NEW.contentSize
+        ,NEW.contentHash
+        ,NEW.encryptIV
+        ,NEW.cipherFormat
+        ,NEW.containsUSPersonsData
+        ,NEW.exemptFromFOIA
+        ,NEW.acmId
+        ,NEW.ownedById
+    );
+END;
+-- +migrate StatementEnd
+
+INSERT INTO migration_status SET description = '20191008_cipher_format setting schemaversion to 20191008';
+update dbstate set schemaVersion = '20191008' where schemaVersion <> '20191008';
+
+-- +migrate Down
+
+DROP TRIGGER IF EXISTS ti_object;
+-- +migrate StatementBegin
+CREATE TRIGGER ti_object
+BEFORE INSERT ON object FOR EACH ROW
+BEGIN
+    DECLARE error_msg varchar(128) default '';
+    DECLARE thisTableName varchar(128) default 'object';
+    DECLARE type_contentConnector varchar(2000) default '';
+
+    # Rules
+    # Type must be specified
+    IF NOT EXISTS (select null from object_type where isdeleted = 0 and id = new.typeid) THEN
+        SET error_msg := concat(error_msg, 'Field typeId required ');
+    END IF;
+    # US Persons Data is NULLed if empty (will change to Unknown)
+    IF NEW.containsUSPersonsData IS NOT NULL AND NEW.containsUSPersonsData = '' THEN
+        SET NEW.containsUSPersonsData := NULL;
+    END IF;
+    # FOIA Exempt is NULLed if empty (will change to Unknown)
+    IF NEW.exemptFromFOIA IS NOT NULL AND NEW.exemptFromFOIA = '' THEN
+        SET NEW.exemptFromFOIA := NULL;
+    END IF;
+    # ParentId must be valid if specified
+    IF NEW.parentId IS NOT NULL AND NEW.parentId = '' THEN
+        SET NEW.parentId := NULL;
+    END IF;
+    IF NEW.parentId IS NOT NULL THEN
+        IF NOT EXISTS (select null from object where isdeleted = 0 and id = new.parentid) THEN
+            SET error_msg := concat(error_msg, 'Field parentId must be valid ');
+        END IF;
+    END IF;
+    IF error_msg <> '' THEN
+        SET error_msg := concat(error_msg, 'when inserting record into ', thisTableName);
+        signal sqlstate '45000' set message_text = error_msg;
+    END IF;
+
+    # Force values on create
+    SET NEW.id := ordered_uuid(UUID());
+    SET NEW.createdDate := current_timestamp(6);
+    SET NEW.modifiedDate := current_timestamp(6);
+    SET NEW.modifiedBy := NEW.createdBy;
+    SET NEW.isDeleted := 0;
+    SET NEW.deletedDate := NULL;
+    SET NEW.deletedBy := NULL;
+    SET NEW.isAncestorDeleted := 0;
+    SET NEW.isExpunged := 0;
+    SET NEW.expungedDate := NULL;
+    SET NEW.expungedBy := NULL;
+    # Assign Owner if not set
+    IF NEW.ownedBy IS NULL OR NEW.ownedBy = '' THEN
+        SET NEW.ownedBy := concat('user/', NEW.createdBy);
+    END IF;
+    SET NEW.ownedBy := calcResourceString(NEW.ownedBy);
+    SET NEW.ownedByID := calcGranteeIDFromResourceString(NEW.ownedBy);
+    SET NEW.changeCount := 0;
+    # Standard change token formula
+    SET NEW.changeToken := md5(CONCAT(CAST(NEW.id AS CHAR),':',CAST(NEW.changeCount AS CHAR),':',CAST(NEW.modifiedDate AS CHAR)));
+    # Assign contentConnector if not set
+    IF NEW.contentConnector IS NULL OR NEW.contentConnector = '' THEN
+        SELECT contentConnector FROM object_type WHERE isDeleted = 0 AND id = NEW.typeId INTO type_contentConnector;
+        SET NEW.contentConnector := type_contentConnector;
+    END IF;
+    # Assign US Persons Data if not set
+    IF NEW.containsUSPersonsData IS NULL THEN
+        SET NEW.containsUSPersonsData := 'Unknown';
+    END IF;
+    # Assign FOIA Exempt status if not set
+    IF NEW.exemptFromFOIA IS NULL THEN
+        SET NEW.exemptFromFOIA := 'Unknown';
+    END IF;
+
+
+    # Archive table
+    INSERT INTO
+        a_object
+    (
+        id
+        ,createdDate
+        ,createdBy
+        ,modifiedDate
+        ,modifiedBy
+        ,isDeleted
+        ,deletedDate
+        ,deletedBy
+        ,isAncestorDeleted
+        ,isExpunged
+        ,expungedDate
+        ,expungedBy
+        ,changeCount
+        ,changeToken
+        ,ownedBy
+        ,typeId
+        ,name
+        ,description
+        ,parentId
+        ,contentConnector
+        ,rawAcm
+        ,contentType
+        ,contentSize
+        ,contentHash
+        ,encryptIV
+        ,containsUSPersonsData
+        ,exemptFromFOIA
+        ,acmId
+        ,ownedById
+    ) values (
+        NEW.id
+        ,NEW.createdDate
+        ,NEW.createdBy
+        ,NEW.modifiedDate
+        ,NEW.modifiedBy
+        ,NEW.isDeleted
+        ,NEW.deletedDate
+        ,NEW.deletedBy
+        ,NEW.isAncestorDeleted
+        ,NEW.isExpunged
+        ,NEW.expungedDate
+        ,NEW.expungedBy
+        ,NEW.changeCount
+        ,NEW.changeToken
+        ,NEW.ownedBy
+        ,NEW.typeId
+        ,NEW.name
+        ,NEW.description
+        ,NEW.parentId
+        ,NEW.contentConnector
+        ,NEW.rawAcm
+        ,NEW.contentType
+        ,NEW.contentSize
+        ,NEW.contentHash
+        ,NEW.encryptIV
+        ,NEW.containsUSPersonsData
+        ,NEW.exemptFromFOIA
+        ,NEW.acmId
+        ,NEW.ownedById
+    );
+END;
+-- +migrate StatementEnd
+DROP TRIGGER IF EXISTS tu_object;
+-- +migrate StatementBegin
+CREATE TRIGGER tu_object
+BEFORE UPDATE ON object FOR EACH ROW
+BEGIN
+    DECLARE error_msg varchar(128) default '';
+    DECLARE thisTableName varchar(128) default 'object';
+    DECLARE type_contentConnector varchar(2000) default '';
+
+    # Rules
+    # id cannot be changed
+    IF (NEW.id <> OLD.id) AND length(error_msg) < 83 THEN
+        SET error_msg := concat(error_msg, 'Unable to set id ');
+    END IF;
+    # createdDate cannot be changed
+    IF (NEW.createdDate <> OLD.createdDate) AND length(error_msg) < 74 THEN
+        SET error_msg := concat(error_msg, 'Unable to set createdDate ');
+    END IF;
+    # createdBy cannot be changed
+    IF (NEW.createdBy <> OLD.createdBy) AND length(error_msg) < 76 THEN
+        SET error_msg := concat(error_msg, 'Unable to set createdBy ');
+    END IF;
+    # changeCount cannot be changed
+    IF (NEW.changeCount <> OLD.changeCount) AND length(error_msg) < 74 THEN
+        SET error_msg := concat(error_msg, 'Unable to set changeCount ');
+    END IF;
+    # changeToken must be given and match the record
+    IF (NEW.changeToken IS NULL OR NEW.changeToken = '') and length(error_msg) < 73 THEN
+        SET error_msg := concat(error_msg, 'Field changeToken required ');
+    END IF;
+    IF (NEW.changeToken <> OLD.changeToken) and length(error_msg) < 71 THEN
+        SET error_msg := concat(error_msg, 'Field changeToken must match ');
+    END IF;
+    # TypeId must be specified
+    IF NOT EXISTS (select null from object_type where isdeleted = 0 and id = new.typeid) THEN
+        SET error_msg := concat(error_msg, 'Field typeId required ');
+    END IF;
+    # US Persons Data is set to old value if null/empty
+    IF NEW.containsUSPersonsData IS NULL OR NEW.containsUSPersonsData = '' THEN
+        SET NEW.containsUSPersonsData := OLD.containsUSPersonsData;
+    END IF;
+    # FOIA Exempt is set to old value if null/empty
+    IF NEW.exemptFromFOIA IS NULL OR NEW.exemptFromFOIA = '' THEN
+        SET NEW.exemptFromFOIA := OLD.exemptFromFOIA;
+    END IF;    
+    # ParentId must be valid if specified
+    IF NEW.parentId IS NOT NULL AND LENGTH(NEW.parentId) = 0 THEN
+        SET NEW.parentId := NULL;
+    END IF;
+    IF NEW.parentId IS NOT NULL THEN
+        IF NOT EXISTS (select null from object where (isDeleted = 0 or (NEW.IsDeleted <> OLD.IsDeleted)) AND id = NEW.parentId) THEN
+            SET error_msg := concat(error_msg, 'Field parentId must be valid ');
+        END IF;
+    END IF;    
+    IF length(error_msg) > 0 THEN
+        SET error_msg := concat(error_msg, 'when updating record');
+        signal sqlstate '45000' set message_text = error_msg;
+    END IF;
+
+    # Force values on modify
+    SET NEW.modifiedDate := current_timestamp(6);
+    IF (NEW.isDeleted <> OLD.isDeleted) THEN
+        IF  (NEW.IsDeleted = 1) THEN
+            SET NEW.deletedDate := current_timestamp(6);
+            SET NEW.deletedBy := NEW.modifiedBy;
+        ELSE
+            SET NEW.deletedDate := NULL;
+            SET NEW.deletedBy := NULL;
+        END IF;                
+    END IF;
+    SET NEW.changeCount := OLD.changeCount + 1;
+    # Standard change token formula
+    SET NEW.changeToken := md5(CONCAT(CAST(OLD.id AS CHAR),':',CAST(NEW.changeCount AS CHAR),':',CAST(NEW.modifiedDate AS CHAR)));
+    # Assign Owner if not set
+    IF NEW.ownedBy IS NULL OR NEW.ownedBy = '' THEN
+        SET NEW.ownedBy := concat('user/', NEW.createdBy);
+    END IF;
+    SET NEW.ownedBy := calcResourceString(NEW.ownedBy);
+    # ownedByID derived from ownedBy
+    SET NEW.ownedByID := calcGranteeIDFromResourceString(NEW.ownedBy);
+    # Assign US Persons Data if not set
+    IF NEW.containsUSPersonsData IS NULL THEN
+        SET NEW.containsUSPersonsData = 'Unknown';
+    END IF;
+    # Assign FOIA Exempt status if not set
+    IF NEW.exemptFromFOIA IS NULL THEN
+        SET NEW.exemptFromFOIA = 'Unknown';
+    END IF;
+
+    # Archive table
+    INSERT INTO
+        a_object
+    (
+        id
+        ,createdDate
+        ,createdBy
+        ,modifiedDate
+        ,modifiedBy
+        ,isDeleted
+        ,deletedDate
+        ,deletedBy
+        ,isAncestorDeleted
+        ,isExpunged
+        ,expungedDate
+        ,expungedBy
+        ,changeCount
+        ,changeToken
+        ,ownedBy
+        ,typeId
+        ,name
+        ,description
+        ,parentId
+        ,contentConnector
+        ,rawAcm
+        ,contentType
+        ,contentSize
+        ,contentHash
+        ,encryptIV
+        ,containsUSPersonsData
+        ,exemptFromFOIA
+        ,acmId
+        ,ownedById
+    ) values (
+        NEW.id
+        ,NEW.createdDate
+        ,NEW.createdBy
+        ,NEW.modifiedDate
+        ,NEW.modifiedBy
+        ,NEW.isDeleted
+        ,NEW.deletedDate
+        ,NEW.deletedBy
+        ,NEW.isAncestorDeleted
+        ,NEW.isExpunged
+        ,NEW.expungedDate
+        ,NEW.expungedBy
+        ,NEW.changeCount
+        ,NEW.changeToken
+        ,NEW.ownedBy
+        ,NEW.typeId
+        ,NEW.name
+        ,NEW.description
+        ,NEW.parentId
+        ,NEW.contentConnector
+        ,NEW.rawAcm
+        ,NEW.contentType
+        ,NEW.contentSize
+        ,NEW.contentHash
+        ,NEW.encryptIV
+        ,NEW.containsUSPersonsData
+        ,NEW.exemptFromFOIA
+        ,NEW.acmId
+        ,NEW.ownedById
+    );
+END;
+-- +migrate StatementEnd
+
+DROP INDEX ix_cipherFormat ON a_object;
+ALTER TABLE content_dedup DROP COLUMN cipherFormat;
+ALTER TABLE a_object DROP COLUMN cipherFormat;
+ALTER TABLE object DROP COLUMN cipherFormat;
+
+update dbstate set schemaVersion = '20191007' where schemaVersion <> '20191007';
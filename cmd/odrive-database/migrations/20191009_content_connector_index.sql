@@ -0,0 +1,19 @@
+-- +migrate Up
+
+-- Adds indexes on contentConnector to object and a_object so that content found in permanent storage
+-- can be checked for objects or revisions that refer to it before it is removed as orphaned.
+
+INSERT INTO migration_status SET description = '20191009_content_connector_index adding index on object contentConnector';
+CREATE INDEX ix_contentConnector ON object (contentConnector(255));
+INSERT INTO migration_status SET description = '20191009_content_connector_index adding index on a_object contentConnector';
+CREATE INDEX ix_contentConnector ON a_object (contentConnector(255));
+
+INSERT INTO migration_status SET description = '20191009_content_connector_index setting schemaversion to 20191009';
+update dbstate set schemaVersion = '20191009' where schemaVersion <> '20191009';
+
+-- +migrate Down
+
+DROP INDEX ix_contentConnector ON a_object;
+DROP INDEX ix_contentConnector ON object;
+
+update dbstate set schemaVersion = '20191008' where schemaVersion <> '20191008';
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/services/kms"
+)
+
+// keyRotateRoutine makes a new version of a key current in the key provider
+// configured by OD_ENCRYPT_KEYPROVIDER. Servers wrap their data keys with the
+// new version when they next start.
+func keyRotateRoutine(keyID string) {
+	p, err := kms.NewKeyProvider(config.NewKeyProviderOpts(config.KeyProviderOpts{}))
+	if err != nil {
+		log.Printf("Unable to configure key provider: %v", err)
+		os.Exit(1)
+	}
+	if p == nil {
+		log.Printf("Set %s to rotate keys", config.OD_ENCRYPT_KEYPROVIDER)
+		os.Exit(1)
+	}
+	if err := p.Rotate(keyID); err != nil {
+		log.Printf("Unable to rotate key %s: %v", keyID, err)
+		os.Exit(1)
+	}
+	log.Printf("Rotated key %s", keyID)
+}
+
+// kmsServeRoutine serves the keys of a keyring over HTTP, as a stand-in for a
+// key management service
+func kmsServeRoutine(keyring, addr string) {
+	k, err := kms.NewKeyring(keyring)
+	if err != nil {
+		log.Printf("Unable to open keyring %s: %v", keyring, err)
+		os.Exit(1)
+	}
+	log.Printf("Serving keyring %s on %s", keyring, addr)
+	log.Fatal(http.ListenAndServe(addr, kms.NewHandler(k)))
+}
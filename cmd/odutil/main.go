@@ -17,11 +17,20 @@ var (
 	key             = flag.String("key", "", "Key up on S3 to write. defaults to the value passed to input")
 	accessKeyID     = flag.String("accesskeyid", "", "Access Key ID for using S3")
 	secretAccessKey = flag.String("secretaccesskey", "", "Secret Access Key for using S3")
+	keyID           = flag.String("keyid", "odrive", "Key id to rotate in the key provider")
+	keyring         = flag.String("keyring", "keyring.json", "Keyring file to serve")
+	addr            = flag.String("addr", ":8443", "Address to serve the keyring on")
+	confPath        = flag.String("conf", "odrive.yml", "Server configuration file giving the database and storage to reconcile")
+	graceHours      = flag.Int64("grace", 168, "Hours a file must have been in permanent storage before it is checked, and then have been found orphaned before it is deleted")
+	dryRun          = flag.Bool("dryrun", true, "Report orphans without marking or deleting them")
 )
 
 const (
-	upload   = "upload"
-	download = "download"
+	upload    = "upload"
+	download  = "download"
+	keyRotate = "keyrotate"
+	kmsServe  = "kmsserve"
+	orphans   = "orphans"
 )
 
 func main() {
@@ -44,6 +53,12 @@ func main() {
 		uploadRoutine(*input, *bucket, *key)
 	case download:
 		downloadRoutine(*input, *bucket, *key)
+	case keyRotate:
+		keyRotateRoutine(*keyID)
+	case kmsServe:
+		kmsServeRoutine(*keyring, *addr)
+	case orphans:
+		orphansRoutine(*confPath, *graceHours, *dryRun)
 	default:
 		fmt.Println("Unrecognized command:", *cmd)
 	}
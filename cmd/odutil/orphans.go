@@ -0,0 +1,54 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+)
+
+// orphansRoutine reconciles the permanent storage of each zone configured for
+// the server against the database, reporting files that no object or revision
+// refers to, and deleting them unless this is a dry run
+func orphansRoutine(confPath string, graceHours int64, dryRun bool) {
+	conf := config.NewAppConfiguration(config.ValueOpts{Conf: confPath})
+	d, dbID, err := dao.NewDataAccessLayer(conf.DatabaseConnection, dao.WithLogger(config.RootLogger))
+	if err != nil {
+		log.Printf("Unable to connect to the database: %v", err)
+		os.Exit(1)
+	}
+	zones := []ciphertext.CiphertextCacheZone{ciphertext.S3_DEFAULT_CIPHERTEXT_CACHE}
+	zoneConfs := []config.DiskCacheOpts{conf.CacheSettings}
+	for _, zoneConf := range conf.CacheSettings.Zones {
+		zones = append(zones, ciphertext.CiphertextCacheZone(zoneConf.Name))
+		zoneConfs = append(zoneConfs, conf.CacheSettings.ForZone(zoneConf))
+	}
+	opts := ciphertext.OrphanOpts{
+		GracePeriod: time.Duration(graceHours) * time.Hour,
+		DryRun:      dryRun,
+	}
+	failed := false
+	for i, zone := range zones {
+		// Only the permanent storage of the zone is opened, as a cache would
+		// check its keys and drain and purge files
+		z := ciphertext.NewOrphanZone(zone, zoneConfs[i], dbID)
+		report, err := ciphertext.CollectOrphans(z, opts, dao.ReferencedContentConnectors(d), func(orphan ciphertext.Orphan) {
+			log.Printf("orphan %s modified %s", orphan.Key, orphan.Modified.Format(time.RFC3339))
+		})
+		log.Printf("zone %s: %d listed, %d within the grace period, %d orphaned, %d newly marked, %d deleted",
+			zone, report.Listed, report.Recent, report.Orphaned, report.Marked, report.Deleted)
+		if err != nil {
+			log.Printf("Unable to reconcile zone %s: %v", zone, err)
+			failed = true
+		}
+	}
+	if dryRun {
+		log.Printf("Dry run: no files were marked or deleted. Run with -dryrun=false to mark orphans, and delete those marked longer than the grace period ago.")
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -44,6 +46,10 @@ type AppConfiguration struct {
 	ZK                  ZKSettings                  `yaml:"zk"`
 	EventQueue          EventQueueConfiguration     `yaml:"event_queue"`
 	UserAOCacheSettings UserAOCacheConfiguration    `yaml:"useraocache"`
+	WebhookSettings     WebhookConfiguration        `yaml:"webhook"`
+	FullTextSettings    FullTextConfiguration       `yaml:"fulltext"`
+	WebDAVSettings      WebDAVConfiguration         `yaml:"webdav"`
+	S3GatewaySettings   S3GatewayConfiguration      `yaml:"s3gateway"`
 }
 
 // AACConfiguration holds data required for an AAC client. Host and port are often
@@ -197,11 +203,148 @@ type DiskCacheOpts struct {
 	// MasterKey is the master encryption key. This must be kept safe. Losing this
 	// key will make encrypted data unrecoverable.
 	MasterKey string `yaml:"masterkey"`
+	// PreviousMasterKey is the master encryption key being rotated away from.
+	// While set, keys wrapped with either key are accepted, and permissions are
+	// rewrapped with MasterKey in the background.
+	PreviousMasterKey string `yaml:"previous_masterkey"`
+	// KeyProvider, if set, holds the key that the master key is wrapped with,
+	// so that the master key need not be configured.
+	KeyProvider KeyProviderOpts `yaml:"key_provider"`
+	// KeyID identifies the key of the KeyProvider that the master key is
+	// wrapped with.
+	KeyID string `yaml:"key_id"`
 	// ChunkSize specifies a memory block size to send to S3 when durably persisting
 	// cached files.
 	ChunkSize int64 `yaml:"chunk_size"`
+	// PermanentStorage selects where cached files are durably persisted. Either
+	// s3, the default, or posix for a filesystem mount shared between instances.
+	PermanentStorage string `yaml:"permanent_storage"`
+	// PermanentStorageRoot is the directory on the mount that files are persisted
+	// beneath when PermanentStorage is posix.
+	PermanentStorageRoot string `yaml:"permanent_storage_root"`
+	// Deduplicate enables storing identical content only once when uploaded
+	// into the same ACM, reusing the ciphertext already stored.
+	Deduplicate bool `yaml:"deduplicate"`
+	// Reencrypt enables re-encrypting content stored with AES-CTR into the
+	// authenticated format used for new content, in the background.
+	Reencrypt bool `yaml:"reencrypt"`
+	// OrphanCollect enables a daily job that deletes files in permanent storage
+	// that no object or revision refers to.
+	OrphanCollect bool `yaml:"orphan_collect"`
+	// OrphanGrace is the number of hours a file must have been in permanent
+	// storage before it may be collected as an orphan.
+	OrphanGrace int64 `yaml:"orphan_grace"`
+	// OrphanDryRun reports orphaned files found by the collection job without
+	// deleting them.
+	OrphanDryRun bool `yaml:"orphan_dryrun"`
+	// Bucket is the S3 bucket files are persisted to, set by ForZone for zones
+	// with their own bucket. If empty, the bucket given by OD_AWS_S3_BUCKET is used.
+	Bucket string `yaml:"-"`
+	// Zones are additional caches, each with their own permanent storage and
+	// master key, that objects are routed to when created.
+	Zones []CacheZoneOpts `yaml:"zones"`
 }
 
+// CacheZoneOpts describes an additional ciphertext cache zone and the objects
+// routed to it. An object is routed to a zone when its storageZone property
+// names the zone, or its type or owning group is listed for the zone.
+type CacheZoneOpts struct {
+	// Name identifies the zone. It may contain only letters, digits and
+	// underscores.
+	Name string `yaml:"name"`
+	// Bucket is the S3 bucket files in the zone are persisted to. If empty,
+	// the default bucket is used with a key prefix for the zone.
+	Bucket string `yaml:"bucket"`
+	// PermanentStorage is either s3 or posix, as for DiskCacheOpts.
+	PermanentStorage string `yaml:"permanent_storage"`
+	// PermanentStorageRoot is the directory on the mount that files are persisted
+	// beneath when PermanentStorage is posix.
+	PermanentStorageRoot string `yaml:"permanent_storage_root"`
+	// MasterKey is the master encryption key for files in the zone.
+	MasterKey string `yaml:"masterkey"`
+	// PreviousMasterKey is the master encryption key of the zone being rotated
+	// away from, as for DiskCacheOpts.
+	PreviousMasterKey string `yaml:"previous_masterkey"`
+	// KeyID identifies the key of the KeyProvider that the master key of the
+	// zone is wrapped with. The default is the name of the zone.
+	KeyID string `yaml:"key_id"`
+	// Deduplicate enables storing identical content only once within the zone,
+	// as for DiskCacheOpts.
+	Deduplicate bool `yaml:"deduplicate"`
+	// TypeNames lists the object types routed to the zone.
+	TypeNames []string `yaml:"type_names"`
+	// OwnerGroups lists the groups whose objects are routed to the zone.
+	OwnerGroups []string `yaml:"owner_groups"`
+}
+
+// KeyProviderOpts selects the provider of keys that master keys are wrapped
+// with, and how to reach it.
+type KeyProviderOpts struct {
+	// Type is either keyring, for a local keyring file, or kms, for a key
+	// management service reached over HTTP. If empty, master keys are
+	// configured directly.
+	Type string `yaml:"type"`
+	// Keyring is the path of the keyring file when Type is keyring.
+	Keyring string `yaml:"keyring"`
+	// URL is the base URL of the key management service when Type is kms.
+	URL string `yaml:"url"`
+	// Trust is the path to the CA trust for the key management service.
+	Trust string `yaml:"trust"`
+	// Cert is the path to the client certificate for the key management service.
+	Cert string `yaml:"cert"`
+	// Key is the path to the client private key for the key management service.
+	Key string `yaml:"key"`
+}
+
+// Values for KeyProviderOpts.Type
+const (
+	KeyProviderKeyring = "keyring"
+	KeyProviderKMS     = "kms"
+)
+
+// ForZone returns the options for the cache of a zone. The zone shares the
+// local cache settings, but is kept in its own partition.
+func (opts DiskCacheOpts) ForZone(zone CacheZoneOpts) DiskCacheOpts {
+	zoneOpts := opts
+	zoneOpts.Partition = filepath.Join(opts.Partition, zone.Name)
+	zoneOpts.Bucket = zone.Bucket
+	zoneOpts.PermanentStorage = zone.PermanentStorage
+	zoneOpts.PermanentStorageRoot = zone.PermanentStorageRoot
+	zoneOpts.MasterKey = zone.MasterKey
+	zoneOpts.PreviousMasterKey = zone.PreviousMasterKey
+	zoneOpts.KeyID = zone.KeyID
+	zoneOpts.Deduplicate = zone.Deduplicate
+	zoneOpts.Zones = nil
+	return zoneOpts
+}
+
+// CacheZoneEnv is the name of the environment variable for a setting of a
+// zone listed in OD_CACHE_ZONES, such as OD_CACHE_ZONE_SENSITIVE_BUCKET.
+func CacheZoneEnv(zone, setting string) string {
+	return fmt.Sprintf("OD_CACHE_ZONE_%s_%s", strings.ToUpper(zone), setting)
+}
+
+// Settings of a zone that may be given with CacheZoneEnv
+const (
+	CacheZoneBucket               = "BUCKET"
+	CacheZoneDeduplicate          = "DEDUPLICATE"
+	CacheZoneKeyID                = "KEYID"
+	CacheZoneMasterKey            = "MASTERKEY"
+	CacheZoneOwnerGroups          = "OWNERGROUPS"
+	CacheZonePreviousMasterKey    = "MASTERKEY_PREVIOUS"
+	CacheZonePermanentStorage     = "PERMANENTSTORAGE"
+	CacheZonePermanentStorageRoot = "PERMANENTSTORAGE_ROOT"
+	CacheZoneTypeNames            = "TYPENAMES"
+)
+
+var cacheZoneNameRx = regexp.MustCompile("^[0-9a-zA-Z_]+$")
+
+// Values for DiskCacheOpts.PermanentStorage
+const (
+	PermanentStorageS3    = "s3"
+	PermanentStoragePOSIX = "posix"
+)
+
 // ServerSettingsConfiguration holds the attributes needed for
 // setting up an AppServer listener.
 type ServerSettingsConfiguration struct {
@@ -234,6 +377,9 @@ type ServerSettingsConfiguration struct {
 	// outside the network. This configuration option must be specified in YAML
 	// or on the command line.
 	ACLImpersonationWhitelist []string `yaml:"acl_whitelist"`
+	// TypeAdmins is a list of Distinguished Names of users permitted to
+	// manage the property definitions of object types.
+	TypeAdmins []string `yaml:"type_admins"`
 	// PathToStaticFiles is a location on disk where static assets are stored.
 	PathToStaticFiles string `yaml:"static_root"`
 	// PathToTemplateFiles is a location on disk where Go templates are stored.
@@ -285,6 +431,19 @@ type ServerSettingsConfiguration struct {
 	HeaderSessionIDName string `yaml:"header_sessionid_name"`
 	// MaxPageSize is the maximum number of results per page allowed for list/search operations
 	MaxPageSize int64 `yaml:"max_page_size"`
+	// ImportMaxEntries is the maximum number of entries in an archive that may be imported
+	ImportMaxEntries int64 `yaml:"import_max_entries"`
+	// ImportMaxSize is the maximum number of bytes of an archive, and of the files expanded
+	// from it, that may be imported
+	ImportMaxSize int64 `yaml:"import_max_size"`
+	// JobWorkers is the number of background jobs this instance runs at a time
+	JobWorkers int64 `yaml:"job_workers"`
+	// JobLease is the number of seconds a running job may go without recording progress
+	// before another instance may claim it
+	JobLease int64 `yaml:"job_lease"`
+	// ScrubRate is the number of content streams per minute that this instance
+	// verifies against their recorded hash, or 0 to not verify content
+	ScrubRate int64 `yaml:"scrub_rate"`
 }
 
 // UserAOCacheConfiguration holds configuration for managing user ao cache rebuilds
@@ -298,6 +457,76 @@ type UserAOCacheConfiguration struct {
 	LruTime int64 `yaml:"lru_time"`
 }
 
+// FullTextConfiguration holds configuration for indexing the text of object
+// content streams so that objects may be found by their content.
+type FullTextConfiguration struct {
+	// Root is the directory in which the index is stored. Content is not
+	// indexed if it is not set.
+	Root string `yaml:"root"`
+	// MaxSize is the maximum number of bytes of a content stream from which
+	// text is extracted
+	MaxSize int64 `yaml:"max_size"`
+	// QueueSize is the number of objects that may be waiting to be indexed
+	// before further changes are not indexed
+	QueueSize int64 `yaml:"queue_size"`
+	// Workers is the number of objects that may be indexed concurrently
+	Workers int64 `yaml:"workers"`
+}
+
+// S3GatewayConfiguration holds configuration for the listener implementing a
+// subset of the S3 REST API, through which tools that only speak S3 may access
+// objects with buckets for the roots of the user and their groups.
+type S3GatewayConfiguration struct {
+	// ListenPort is the port the gateway listens on with the TLS settings of
+	// the server. The gateway is not started if it is not set.
+	ListenPort string `yaml:"port"`
+	// ACM is the ACM assigned to objects created through the gateway, as S3
+	// clients have no means of providing one
+	ACM string `yaml:"acm"`
+	// UserBucket is the name of the bucket holding the objects owned by the
+	// caller. Any other bucket is the group of that name.
+	UserBucket string `yaml:"user_bucket"`
+}
+
+// WebDAVConfiguration holds configuration for the WebDAV front end, through
+// which objects may be accessed by their path as a network drive.
+type WebDAVConfiguration struct {
+	// Prefix is the path under which WebDAV requests are served, such as /dav.
+	// WebDAV is not served if it is not set.
+	Prefix string `yaml:"prefix"`
+	// ACM is the ACM assigned to files and folders created through WebDAV,
+	// as WebDAV clients have no means of providing one
+	ACM string `yaml:"acm"`
+	// LockTimeout is the maximum duration, in seconds, that a WebDAV lock is
+	// held without being refreshed
+	LockTimeout int64 `yaml:"lock_timeout"`
+}
+
+// WebhookConfiguration holds configuration for delivering notifications to
+// callback URLs registered by subscriptions to objects.
+type WebhookConfiguration struct {
+	// AllowedCallbacks are the hosts, or URL prefixes including the scheme,
+	// that subscriptions may have notifications posted to. If empty, any host
+	// is allowed.
+	AllowedCallbacks []string `yaml:"allowed_callbacks"`
+	// AllowPrivate permits callbacks that resolve to loopback, private or
+	// link-local addresses
+	AllowPrivate bool `yaml:"allow_private"`
+	// QueueSize is the number of notifications that may be waiting for
+	// delivery before further notifications are dead lettered
+	QueueSize int64 `yaml:"queue_size"`
+	// Retries is the number of additional attempts made to deliver a
+	// notification after the first attempt fails
+	Retries int64 `yaml:"retries"`
+	// RetryDelay is the initial duration, in seconds, to wait before retrying
+	// delivery. The delay doubles for each subsequent attempt.
+	RetryDelay int64 `yaml:"retry_delay"`
+	// Timeout is the maximum duration, in seconds, for a single delivery attempt
+	Timeout int64 `yaml:"timeout"`
+	// Workers is the number of notifications that may be delivered concurrently
+	Workers int64 `yaml:"workers"`
+}
+
 // ZKSettings holds the data required to communicate with default Zookeeper.
 type ZKSettings struct {
 	// The IP address of our server, as reported to Zookeeper. If configured,
@@ -343,6 +572,14 @@ func NewAppConfiguration(opts ValueOpts) AppConfiguration {
 	confFile.EventQueue = eventQueue
 	useraocacheSettings := newUserAOCacheSettingsFromEnv(confFile, opts)
 	confFile.UserAOCacheSettings = useraocacheSettings
+	webhookSettings := newWebhookSettingsFromEnv(confFile, opts)
+	confFile.WebhookSettings = webhookSettings
+	fullTextSettings := newFullTextSettingsFromEnv(confFile, opts)
+	confFile.FullTextSettings = fullTextSettings
+	webDAVSettings := newWebDAVSettingsFromEnv(confFile, opts)
+	confFile.WebDAVSettings = webDAVSettings
+	s3GatewaySettings := newS3GatewaySettingsFromEnv(confFile, opts)
+	confFile.S3GatewaySettings = s3GatewaySettings
 
 	appConf := AppConfiguration{
 		AACSettings:         aacSettings,
@@ -352,6 +589,10 @@ func NewAppConfiguration(opts ValueOpts) AppConfiguration {
 		ServerSettings:      serverSettings,
 		ZK:                  zkSettings,
 		UserAOCacheSettings: useraocacheSettings,
+		WebhookSettings:     webhookSettings,
+		FullTextSettings:    fullTextSettings,
+		WebDAVSettings:      webDAVSettings,
+		S3GatewaySettings:   s3GatewaySettings,
 	}
 
 	setEnvironmentFromConfiguration(appConf)
@@ -533,10 +774,21 @@ func newDiskCacheOpts(confFile AppConfiguration, opts ValueOpts) DiskCacheOpts {
 		)
 		os.Exit(1)
 	}
+	previousMasterKey, err := MaybeDecrypt(cascade(OD_ENCRYPT_MASTERKEY_PREVIOUS, confFile.CacheSettings.PreviousMasterKey, ""))
+	if err != nil {
+		log.Printf("The previous master encryption key was encoded with ENC{...}, but it will not decode properly: %v", err)
+		os.Exit(1)
+	}
+	keyProvider := NewKeyProviderOpts(confFile.CacheSettings.KeyProvider)
 	if !confFile.ServerSettings.EncryptEnabled {
 		masterKey = ""
-	} else if masterKey == "" {
-		log.Fatal("You must set master encryption key with OD_ENCRYPT_MASTERKEY to start the service when encryption is enabled")
+		previousMasterKey = ""
+		keyProvider = KeyProviderOpts{}
+	} else if masterKey == "" && keyProvider.Type == "" {
+		log.Fatal("You must set master encryption key with OD_ENCRYPT_MASTERKEY or a key provider with OD_ENCRYPT_KEYPROVIDER to start the service when encryption is enabled")
+	}
+	if previousMasterKey == masterKey {
+		previousMasterKey = ""
 	}
 	settings := DiskCacheOpts{
 		Root:                 cascade(OD_CACHE_ROOT, confFile.CacheSettings.Root, "."),
@@ -546,10 +798,27 @@ func newDiskCacheOpts(confFile AppConfiguration, opts ValueOpts) DiskCacheOpts {
 		EvictAge:             cascadeInt(OD_CACHE_EVICTAGE, confFile.CacheSettings.EvictAge, 300),
 		WalkSleep:            cascadeInt(OD_CACHE_WALKSLEEP, confFile.CacheSettings.WalkSleep, 30),
 		MasterKey:            masterKey,
+		PreviousMasterKey:    previousMasterKey,
+		KeyProvider:          keyProvider,
+		KeyID:                cascade(OD_ENCRYPT_KEYID, confFile.CacheSettings.KeyID, "odrive"),
 		ChunkSize:            cascadeInt(OD_AWS_S3_FETCH_MB, confFile.CacheSettings.ChunkSize, 16),
 		FileLimit:            cascadeInt(OD_CACHE_FILELIMIT, confFile.CacheSettings.FileLimit, 0),
 		FileSleep:            cascadeInt(OD_CACHE_FILESLEEP, confFile.CacheSettings.FileSleep, 0),
+		PermanentStorage:     strings.ToLower(cascade(OD_CACHE_PERMANENTSTORAGE, confFile.CacheSettings.PermanentStorage, PermanentStorageS3)),
+		PermanentStorageRoot: cascade(OD_CACHE_PERMANENTSTORAGE_ROOT, confFile.CacheSettings.PermanentStorageRoot, ""),
+		Deduplicate:          CascadeBoolFromString(OD_CACHE_DEDUPLICATE, strconv.FormatBool(confFile.CacheSettings.Deduplicate), false),
+		Reencrypt:            CascadeBoolFromString(OD_ENCRYPT_REENCRYPT, strconv.FormatBool(confFile.CacheSettings.Reencrypt), false),
+		OrphanCollect:        CascadeBoolFromString(OD_CACHE_ORPHAN_COLLECT, strconv.FormatBool(confFile.CacheSettings.OrphanCollect), false),
+		OrphanGrace:          cascadeInt(OD_CACHE_ORPHAN_GRACE, confFile.CacheSettings.OrphanGrace, 168),
+		OrphanDryRun:         CascadeBoolFromString(OD_CACHE_ORPHAN_DRYRUN, strconv.FormatBool(confFile.CacheSettings.OrphanDryRun), false),
 	}
+	if settings.PermanentStorage != PermanentStorageS3 && settings.PermanentStorage != PermanentStoragePOSIX {
+		log.Fatalf("%s must be either %s or %s", OD_CACHE_PERMANENTSTORAGE, PermanentStorageS3, PermanentStoragePOSIX)
+	}
+	if settings.PermanentStorage == PermanentStoragePOSIX && settings.PermanentStorageRoot == "" {
+		log.Fatalf("You must set %s when %s is %s", OD_CACHE_PERMANENTSTORAGE_ROOT, OD_CACHE_PERMANENTSTORAGE, PermanentStoragePOSIX)
+	}
+	settings.Zones = newCacheZoneOpts(confFile, confFile.ServerSettings.EncryptEnabled, keyProvider.Type != "")
 	// Permit inputs as whole number percentages, and constrain by simple sanity checks
 	if settings.LowThresholdPercent > 1 {
 		settings.LowThresholdPercent = settings.LowThresholdPercent / 100.0
@@ -566,6 +835,96 @@ func newDiskCacheOpts(confFile AppConfiguration, opts ValueOpts) DiskCacheOpts {
 	return settings
 }
 
+// NewKeyProviderOpts reads the environment to select the provider of keys that
+// master keys are wrapped with, falling back to the options given.
+func NewKeyProviderOpts(fromFile KeyProviderOpts) KeyProviderOpts {
+	opts := KeyProviderOpts{
+		Type:    strings.ToLower(cascade(OD_ENCRYPT_KEYPROVIDER, fromFile.Type, "")),
+		Keyring: cascade(OD_ENCRYPT_KEYRING, fromFile.Keyring, ""),
+		URL:     cascade(OD_ENCRYPT_KMS_URL, fromFile.URL, ""),
+		Trust:   cascade(OD_ENCRYPT_KMS_TRUST, fromFile.Trust, ""),
+		Cert:    cascade(OD_ENCRYPT_KMS_CERT, fromFile.Cert, ""),
+		Key:     cascade(OD_ENCRYPT_KMS_KEY, fromFile.Key, ""),
+	}
+	switch opts.Type {
+	case "":
+	case KeyProviderKeyring:
+		if opts.Keyring == "" {
+			log.Fatalf("You must set %s when %s is %s", OD_ENCRYPT_KEYRING, OD_ENCRYPT_KEYPROVIDER, KeyProviderKeyring)
+		}
+	case KeyProviderKMS:
+		if opts.URL == "" {
+			log.Fatalf("You must set %s when %s is %s", OD_ENCRYPT_KMS_URL, OD_ENCRYPT_KEYPROVIDER, KeyProviderKMS)
+		}
+	default:
+		log.Fatalf("%s must be either %s or %s", OD_ENCRYPT_KEYPROVIDER, KeyProviderKeyring, KeyProviderKMS)
+	}
+	return opts
+}
+
+// newCacheZoneOpts reads the zones named in OD_CACHE_ZONES from the
+// environment, falling back to zones of the same name in the configuration
+// file. If OD_CACHE_ZONES is not set, the zones in the file are used.
+func newCacheZoneOpts(confFile AppConfiguration, encryptEnabled bool, keyProvided bool) []CacheZoneOpts {
+	fromFile := make(map[string]CacheZoneOpts)
+	var names []string
+	for _, zone := range confFile.CacheSettings.Zones {
+		fromFile[strings.ToUpper(zone.Name)] = zone
+		names = append(names, zone.Name)
+	}
+	names = CascadeStringSlice(OD_CACHE_ZONES, names, nil)
+	var zones []CacheZoneOpts
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !cacheZoneNameRx.MatchString(name) {
+			log.Fatalf("Cache zone name %q may contain only letters, digits and underscores", name)
+		}
+		if seen[strings.ToUpper(name)] || strings.ToUpper(name) == "S3_DEFAULT" {
+			log.Fatalf("Cache zone name %q must be unique and not S3_DEFAULT", name)
+		}
+		seen[strings.ToUpper(name)] = true
+		file := fromFile[strings.ToUpper(name)]
+		masterKey, err := MaybeDecrypt(cascade(CacheZoneEnv(name, CacheZoneMasterKey), file.MasterKey, ""))
+		if err != nil {
+			log.Fatalf("The master encryption key for cache zone %s was encoded with ENC{...}, but it will not decode properly: %v", name, err)
+		}
+		previousMasterKey, err := MaybeDecrypt(cascade(CacheZoneEnv(name, CacheZonePreviousMasterKey), file.PreviousMasterKey, ""))
+		if err != nil {
+			log.Fatalf("The previous master encryption key for cache zone %s was encoded with ENC{...}, but it will not decode properly: %v", name, err)
+		}
+		if !encryptEnabled {
+			masterKey = ""
+			previousMasterKey = ""
+		} else if masterKey == "" && !keyProvided {
+			log.Fatalf("You must set %s to start the service when encryption is enabled", CacheZoneEnv(name, CacheZoneMasterKey))
+		}
+		if previousMasterKey == masterKey {
+			previousMasterKey = ""
+		}
+		zone := CacheZoneOpts{
+			Name:                 name,
+			Bucket:               cascade(CacheZoneEnv(name, CacheZoneBucket), file.Bucket, ""),
+			PermanentStorage:     strings.ToLower(cascade(CacheZoneEnv(name, CacheZonePermanentStorage), file.PermanentStorage, PermanentStorageS3)),
+			PermanentStorageRoot: cascade(CacheZoneEnv(name, CacheZonePermanentStorageRoot), file.PermanentStorageRoot, ""),
+			MasterKey:            masterKey,
+			PreviousMasterKey:    previousMasterKey,
+			KeyID:                cascade(CacheZoneEnv(name, CacheZoneKeyID), file.KeyID, name),
+			Deduplicate:          CascadeBoolFromString(CacheZoneEnv(name, CacheZoneDeduplicate), strconv.FormatBool(file.Deduplicate), false),
+			TypeNames:            CascadeStringSlice(CacheZoneEnv(name, CacheZoneTypeNames), file.TypeNames, nil),
+			OwnerGroups:          CascadeStringSlice(CacheZoneEnv(name, CacheZoneOwnerGroups), file.OwnerGroups, nil),
+		}
+		if zone.PermanentStorage != PermanentStorageS3 && zone.PermanentStorage != PermanentStoragePOSIX {
+			log.Fatalf("%s must be either %s or %s", CacheZoneEnv(name, CacheZonePermanentStorage), PermanentStorageS3, PermanentStoragePOSIX)
+		}
+		if zone.PermanentStorage == PermanentStoragePOSIX && zone.PermanentStorageRoot == "" {
+			log.Fatalf("You must set %s when %s is %s", CacheZoneEnv(name, CacheZonePermanentStorageRoot), CacheZoneEnv(name, CacheZonePermanentStorage), PermanentStoragePOSIX)
+		}
+		zones = append(zones, zone)
+	}
+	return zones
+}
+
 // newServerSettingsFromEnv inspects the environment and returns a ServerSettingsConfiguration.
 func newServerSettingsFromEnv(confFile AppConfiguration, opts ValueOpts) ServerSettingsConfiguration {
 
@@ -599,6 +958,8 @@ func newServerSettingsFromEnv(confFile AppConfiguration, opts ValueOpts) ServerS
 
 	// Use cli options, environment, or configuration file for the ACL whitelist (whichever has values first is used)
 	settings.ACLImpersonationWhitelist = selectNonEmptyStringSlice(opts.Whitelist, getEnvSliceFromPrefix(OD_SERVER_ACL_WHITELIST), confFile.ServerSettings.ACLImpersonationWhitelist)
+	// Use environment, or configuration file for the type administrators (whichever has values first is used)
+	settings.TypeAdmins = selectNonEmptyStringSlice(getEnvSliceFromPrefix(OD_SERVER_TYPE_ADMIN), confFile.ServerSettings.TypeAdmins)
 	// Command line argument, if given, supersedes environment, configuration file, and default for static root and templates
 	if len(opts.StaticRootPath) > 0 {
 		settings.PathToStaticFiles = opts.StaticRootPath
@@ -622,6 +983,15 @@ func newServerSettingsFromEnv(confFile AppConfiguration, opts ValueOpts) ServerS
 	// Max page size limiter added in 1.0.23
 	settings.MaxPageSize = cascadeInt(OD_SERVER_MAXPAGESIZE, confFile.ServerSettings.MaxPageSize, 100)
 
+	// Limits on archives imported, against archives that expand beyond reason
+	settings.ImportMaxEntries = cascadeInt(OD_SERVER_IMPORT_MAX_ENTRIES, confFile.ServerSettings.ImportMaxEntries, 10000)
+	settings.ImportMaxSize = cascadeInt(OD_SERVER_IMPORT_MAX_SIZE, confFile.ServerSettings.ImportMaxSize, 10737418240)
+
+	// Background jobs, claimed from the database by each instance
+	settings.JobWorkers = cascadeInt(OD_SERVER_JOB_WORKERS, confFile.ServerSettings.JobWorkers, 2)
+	settings.JobLease = cascadeInt(OD_SERVER_JOB_LEASE, confFile.ServerSettings.JobLease, 120)
+	settings.ScrubRate = cascadeInt(OD_SERVER_SCRUB_RATE, confFile.ServerSettings.ScrubRate, 0)
+
 	return settings
 }
 
@@ -634,6 +1004,51 @@ func newUserAOCacheSettingsFromEnv(confFile AppConfiguration, opts ValueOpts) Us
 	return settings
 }
 
+func newFullTextSettingsFromEnv(confFile AppConfiguration, opts ValueOpts) FullTextConfiguration {
+	var settings FullTextConfiguration
+
+	settings.Root = cascade(OD_FULLTEXT_ROOT, confFile.FullTextSettings.Root, "")
+	settings.MaxSize = cascadeInt(OD_FULLTEXT_MAX_SIZE, confFile.FullTextSettings.MaxSize, 10485760)
+	settings.QueueSize = cascadeInt(OD_FULLTEXT_QUEUE_SIZE, confFile.FullTextSettings.QueueSize, 1000)
+	settings.Workers = cascadeInt(OD_FULLTEXT_WORKERS, confFile.FullTextSettings.Workers, 2)
+
+	return settings
+}
+
+func newS3GatewaySettingsFromEnv(confFile AppConfiguration, opts ValueOpts) S3GatewayConfiguration {
+	var settings S3GatewayConfiguration
+
+	settings.ListenPort = cascade(OD_S3GATEWAY_PORT, confFile.S3GatewaySettings.ListenPort, "")
+	settings.ACM = cascade(OD_S3GATEWAY_ACM, confFile.S3GatewaySettings.ACM, `{"version":"2.1.0","classif":"U","portion":"U","banner":"UNCLASSIFIED","dissem_countries":["USA"]}`)
+	settings.UserBucket = cascade(OD_S3GATEWAY_USER_BUCKET, confFile.S3GatewaySettings.UserBucket, "user")
+
+	return settings
+}
+
+func newWebDAVSettingsFromEnv(confFile AppConfiguration, opts ValueOpts) WebDAVConfiguration {
+	var settings WebDAVConfiguration
+
+	settings.Prefix = strings.TrimRight(cascade(OD_WEBDAV_PREFIX, confFile.WebDAVSettings.Prefix, ""), "/")
+	settings.ACM = cascade(OD_WEBDAV_ACM, confFile.WebDAVSettings.ACM, `{"version":"2.1.0","classif":"U","portion":"U","banner":"UNCLASSIFIED","dissem_countries":["USA"]}`)
+	settings.LockTimeout = cascadeInt(OD_WEBDAV_LOCK_TIMEOUT, confFile.WebDAVSettings.LockTimeout, 3600)
+
+	return settings
+}
+
+func newWebhookSettingsFromEnv(confFile AppConfiguration, opts ValueOpts) WebhookConfiguration {
+	var settings WebhookConfiguration
+
+	settings.AllowedCallbacks = CascadeStringSlice(OD_WEBHOOK_ALLOWED_CALLBACKS, confFile.WebhookSettings.AllowedCallbacks, empty)
+	settings.AllowPrivate = CascadeBoolFromString(OD_WEBHOOK_ALLOW_PRIVATE, strconv.FormatBool(confFile.WebhookSettings.AllowPrivate), false)
+	settings.QueueSize = cascadeInt(OD_WEBHOOK_QUEUE_SIZE, confFile.WebhookSettings.QueueSize, 1000)
+	settings.Retries = cascadeInt(OD_WEBHOOK_RETRIES, confFile.WebhookSettings.Retries, 3)
+	settings.RetryDelay = cascadeInt(OD_WEBHOOK_RETRY_DELAY, confFile.WebhookSettings.RetryDelay, 5)
+	settings.Timeout = cascadeInt(OD_WEBHOOK_TIMEOUT, confFile.WebhookSettings.Timeout, 10)
+	settings.Workers = cascadeInt(OD_WEBHOOK_WORKERS, confFile.WebhookSettings.Workers, 4)
+
+	return settings
+}
+
 //NewEncryptableFunctions creates the set of function that can have optional encryption
 func NewEncryptableFunctions(encryptEnabled bool) EncryptableFunctions {
 	if encryptEnabled {
@@ -641,12 +1056,18 @@ func NewEncryptableFunctions(encryptEnabled bool) EncryptableFunctions {
 			EncryptionStateBanner:  EncryptionBannerTrue,
 			EncryptionStateHeader:  EncryptionHeaderTrue,
 			DoCipherByReaderWriter: odrivecrypto.DoCipherByReaderWriter,
+			DoSealByReaderWriter:   odrivecrypto.DoSealByReaderWriter,
+			DoOpenByReaderWriter:   odrivecrypto.DoOpenByReaderWriter,
+			CipherFormat:           odrivecrypto.CipherFormatGCM,
 		}
 	} else {
 		return EncryptableFunctions{
 			EncryptionStateBanner:  EncryptionBannerFalse,
 			EncryptionStateHeader:  EncryptionHeaderFalse,
 			DoCipherByReaderWriter: odrivecrypto.DoNocipherByReaderWriter,
+			DoSealByReaderWriter:   odrivecrypto.DoNocipherByReaderWriter,
+			DoOpenByReaderWriter:   odrivecrypto.DoNocipherByReaderWriter,
+			CipherFormat:           odrivecrypto.CipherFormatCTR,
 		}
 	}
 }
@@ -1086,14 +1507,34 @@ func setEnvironmentFromConfiguration(conf AppConfiguration) {
 	// os.Setenv(OD_AWS_SQS_ENDPOINT,
 	// os.Setenv(OD_AWS_SQS_INTERVAL,
 	// os.Setenv(OD_AWS_SQS_NAME,
+	os.Setenv(OD_CACHE_DEDUPLICATE, strconv.FormatBool(conf.CacheSettings.Deduplicate))
 	os.Setenv(OD_CACHE_EVICTAGE, strconv.FormatInt(conf.CacheSettings.EvictAge, 10))
 	os.Setenv(OD_CACHE_FILELIMIT, strconv.FormatInt(conf.CacheSettings.FileLimit, 10))
 	os.Setenv(OD_CACHE_FILESLEEP, strconv.FormatInt(conf.CacheSettings.FileSleep, 10))
 	os.Setenv(OD_CACHE_HIGHTHRESHOLDPERCENT, fmt.Sprintf("%v", conf.CacheSettings.HighThresholdPercent))
 	os.Setenv(OD_CACHE_LOWTHRESHOLDPERCENT, fmt.Sprintf("%v", conf.CacheSettings.LowThresholdPercent))
+	os.Setenv(OD_CACHE_ORPHAN_COLLECT, strconv.FormatBool(conf.CacheSettings.OrphanCollect))
+	os.Setenv(OD_CACHE_ORPHAN_DRYRUN, strconv.FormatBool(conf.CacheSettings.OrphanDryRun))
+	os.Setenv(OD_CACHE_ORPHAN_GRACE, strconv.FormatInt(conf.CacheSettings.OrphanGrace, 10))
 	os.Setenv(OD_CACHE_PARTITION, conf.CacheSettings.Partition)
+	os.Setenv(OD_CACHE_PERMANENTSTORAGE, conf.CacheSettings.PermanentStorage)
+	os.Setenv(OD_CACHE_PERMANENTSTORAGE_ROOT, conf.CacheSettings.PermanentStorageRoot)
 	os.Setenv(OD_CACHE_ROOT, conf.CacheSettings.Root)
 	os.Setenv(OD_CACHE_WALKSLEEP, strconv.FormatInt(conf.CacheSettings.WalkSleep, 10))
+	var zoneNames []string
+	for _, zone := range conf.CacheSettings.Zones {
+		zoneNames = append(zoneNames, zone.Name)
+		os.Setenv(CacheZoneEnv(zone.Name, CacheZoneBucket), zone.Bucket)
+		os.Setenv(CacheZoneEnv(zone.Name, CacheZoneDeduplicate), strconv.FormatBool(zone.Deduplicate))
+		os.Setenv(CacheZoneEnv(zone.Name, CacheZoneKeyID), zone.KeyID)
+		os.Setenv(CacheZoneEnv(zone.Name, CacheZoneMasterKey), zone.MasterKey)
+		os.Setenv(CacheZoneEnv(zone.Name, CacheZoneOwnerGroups), strings.Join(zone.OwnerGroups, ","))
+		os.Setenv(CacheZoneEnv(zone.Name, CacheZonePermanentStorage), zone.PermanentStorage)
+		os.Setenv(CacheZoneEnv(zone.Name, CacheZonePermanentStorageRoot), zone.PermanentStorageRoot)
+		os.Setenv(CacheZoneEnv(zone.Name, CacheZonePreviousMasterKey), zone.PreviousMasterKey)
+		os.Setenv(CacheZoneEnv(zone.Name, CacheZoneTypeNames), strings.Join(zone.TypeNames, ","))
+	}
+	os.Setenv(OD_CACHE_ZONES, strings.Join(zoneNames, ","))
 	os.Setenv(OD_DB_ACMGRANTEECACHE_LRU_TIME, strconv.FormatInt(conf.DatabaseConnection.AcmGranteeCacheLruTime, 10))
 	os.Setenv(OD_DB_CA, conf.DatabaseConnection.CAPath)
 	os.Setenv(OD_DB_CERT, conf.DatabaseConnection.ClientCert)
@@ -1114,7 +1555,16 @@ func setEnvironmentFromConfiguration(conf AppConfiguration) {
 	os.Setenv(OD_DB_USE_TLS, strconv.FormatBool(conf.DatabaseConnection.UseTLS))
 	os.Setenv(OD_DB_USERNAME, conf.DatabaseConnection.Username)
 	os.Setenv(OD_ENCRYPT_ENABLED, strconv.FormatBool(conf.ServerSettings.EncryptEnabled))
+	os.Setenv(OD_ENCRYPT_KEYID, conf.CacheSettings.KeyID)
+	os.Setenv(OD_ENCRYPT_KEYPROVIDER, conf.CacheSettings.KeyProvider.Type)
+	os.Setenv(OD_ENCRYPT_KEYRING, conf.CacheSettings.KeyProvider.Keyring)
+	os.Setenv(OD_ENCRYPT_KMS_CERT, conf.CacheSettings.KeyProvider.Cert)
+	os.Setenv(OD_ENCRYPT_KMS_KEY, conf.CacheSettings.KeyProvider.Key)
+	os.Setenv(OD_ENCRYPT_KMS_TRUST, conf.CacheSettings.KeyProvider.Trust)
+	os.Setenv(OD_ENCRYPT_KMS_URL, conf.CacheSettings.KeyProvider.URL)
 	os.Setenv(OD_ENCRYPT_MASTERKEY, conf.CacheSettings.MasterKey)
+	os.Setenv(OD_ENCRYPT_MASTERKEY_PREVIOUS, conf.CacheSettings.PreviousMasterKey)
+	os.Setenv(OD_ENCRYPT_REENCRYPT, strconv.FormatBool(conf.CacheSettings.Reencrypt))
 	os.Setenv(OD_EVENT_KAFKA_ADDRS, strings.Join(conf.EventQueue.KafkaAddrs, ","))
 	os.Setenv(OD_EVENT_PUBLISH_FAILURE_ACTIONS, strings.Join(conf.EventQueue.PublishFailureActions, ","))
 	os.Setenv(OD_EVENT_PUBLISH_SUCCESS_ACTIONS, strings.Join(conf.EventQueue.PublishSuccessActions, ","))
@@ -1122,6 +1572,10 @@ func setEnvironmentFromConfiguration(conf AppConfiguration) {
 	os.Setenv(OD_EVENT_ZK_ADDRS, strings.Join(conf.EventQueue.ZKAddrs, ","))
 	// os.Setenv(OD_EXTERNAL_HOST,
 	// os.Setenv(OD_EXTERNAL_PORT,
+	os.Setenv(OD_FULLTEXT_MAX_SIZE, strconv.FormatInt(conf.FullTextSettings.MaxSize, 10))
+	os.Setenv(OD_FULLTEXT_QUEUE_SIZE, strconv.FormatInt(conf.FullTextSettings.QueueSize, 10))
+	os.Setenv(OD_FULLTEXT_ROOT, conf.FullTextSettings.Root)
+	os.Setenv(OD_FULLTEXT_WORKERS, strconv.FormatInt(conf.FullTextSettings.Workers, 10))
 	os.Setenv(OD_HEADER_BANNER_ENABLED, strconv.FormatBool(conf.ServerSettings.HeaderBannerEnabled))
 	os.Setenv(OD_HEADER_BANNER_NAME, conf.ServerSettings.HeaderBannerName)
 	os.Setenv(OD_HEADER_SERVER_ENABLED, strconv.FormatBool(conf.ServerSettings.HeaderServerEnabled))
@@ -1135,6 +1589,9 @@ func setEnvironmentFromConfiguration(conf AppConfiguration) {
 	os.Setenv(OD_PEER_ENABLED, strconv.FormatBool(conf.ServerSettings.PeerEnabled))
 	// os.Setenv(OD_PEER_SIGNIFIER,
 	// os.Setenv(OD_PEER_INSECURE_SKIP_VERIFY,
+	os.Setenv(OD_S3GATEWAY_ACM, conf.S3GatewaySettings.ACM)
+	os.Setenv(OD_S3GATEWAY_PORT, conf.S3GatewaySettings.ListenPort)
+	os.Setenv(OD_S3GATEWAY_USER_BUCKET, conf.S3GatewaySettings.UserBucket)
 	for idx, val := range conf.ServerSettings.ACLImpersonationWhitelist {
 		os.Setenv(fmt.Sprintf("%s%d", OD_SERVER_ACL_WHITELIST, idx), val)
 	}
@@ -1142,11 +1599,19 @@ func setEnvironmentFromConfiguration(conf AppConfiguration) {
 	os.Setenv(OD_SERVER_CA, conf.ServerSettings.CAPath)
 	os.Setenv(OD_SERVER_CERT, conf.ServerSettings.ServerCertChain)
 	os.Setenv(OD_SERVER_CIPHERS, strings.Join(conf.ServerSettings.CipherSuites, ","))
+	os.Setenv(OD_SERVER_IMPORT_MAX_ENTRIES, strconv.FormatInt(conf.ServerSettings.ImportMaxEntries, 10))
+	os.Setenv(OD_SERVER_IMPORT_MAX_SIZE, strconv.FormatInt(conf.ServerSettings.ImportMaxSize, 10))
+	os.Setenv(OD_SERVER_JOB_LEASE, strconv.FormatInt(conf.ServerSettings.JobLease, 10))
+	os.Setenv(OD_SERVER_JOB_WORKERS, strconv.FormatInt(conf.ServerSettings.JobWorkers, 10))
+	os.Setenv(OD_SERVER_SCRUB_RATE, strconv.FormatInt(conf.ServerSettings.ScrubRate, 10))
 	os.Setenv(OD_SERVER_KEY, conf.ServerSettings.ServerKey)
 	os.Setenv(OD_SERVER_MAXPAGESIZE, strconv.FormatInt(conf.ServerSettings.MaxPageSize, 10))
 	os.Setenv(OD_SERVER_PORT, conf.ServerSettings.ListenPort)
 	os.Setenv(OD_SERVER_STATIC_ROOT, conf.ServerSettings.PathToStaticFiles)
 	os.Setenv(OD_SERVER_TEMPLATE_ROOT, conf.ServerSettings.PathToTemplateFiles)
+	for idx, val := range conf.ServerSettings.TypeAdmins {
+		os.Setenv(fmt.Sprintf("%s%d", OD_SERVER_TYPE_ADMIN, idx), val)
+	}
 	os.Setenv(OD_SERVER_TIMEOUT_IDLE, strconv.FormatInt(conf.ServerSettings.IdleTimeout, 10))
 	os.Setenv(OD_SERVER_TIMEOUT_READ, strconv.FormatInt(conf.ServerSettings.ReadTimeout, 10))
 	os.Setenv(OD_SERVER_TIMEOUT_READHEADER, strconv.FormatInt(conf.ServerSettings.ReadHeaderTimeout, 10))
@@ -1155,6 +1620,16 @@ func setEnvironmentFromConfiguration(conf AppConfiguration) {
 	// os.Setenv(OD_TOKENJAR_PASSWORD,
 	os.Setenv(OD_USERAOCACHE_LRU_TIME, strconv.FormatInt(conf.UserAOCacheSettings.LruTime, 10))
 	os.Setenv(OD_USERAOCACHE_TIMEOUT, strconv.FormatInt(conf.UserAOCacheSettings.UserAOCacheTimeout, 10))
+	os.Setenv(OD_WEBDAV_ACM, conf.WebDAVSettings.ACM)
+	os.Setenv(OD_WEBDAV_LOCK_TIMEOUT, strconv.FormatInt(conf.WebDAVSettings.LockTimeout, 10))
+	os.Setenv(OD_WEBDAV_PREFIX, conf.WebDAVSettings.Prefix)
+	os.Setenv(OD_WEBHOOK_ALLOWED_CALLBACKS, strings.Join(conf.WebhookSettings.AllowedCallbacks, ","))
+	os.Setenv(OD_WEBHOOK_ALLOW_PRIVATE, strconv.FormatBool(conf.WebhookSettings.AllowPrivate))
+	os.Setenv(OD_WEBHOOK_QUEUE_SIZE, strconv.FormatInt(conf.WebhookSettings.QueueSize, 10))
+	os.Setenv(OD_WEBHOOK_RETRIES, strconv.FormatInt(conf.WebhookSettings.Retries, 10))
+	os.Setenv(OD_WEBHOOK_RETRY_DELAY, strconv.FormatInt(conf.WebhookSettings.RetryDelay, 10))
+	os.Setenv(OD_WEBHOOK_TIMEOUT, strconv.FormatInt(conf.WebhookSettings.Timeout, 10))
+	os.Setenv(OD_WEBHOOK_WORKERS, strconv.FormatInt(conf.WebhookSettings.Workers, 10))
 	os.Setenv(OD_ZK_AAC, conf.AACSettings.AACAnnouncementPoint)
 	os.Setenv(OD_ZK_ANNOUNCE, conf.ZK.AnnouncementPoint)
 	os.Setenv(OD_ZK_MYIP, conf.ZK.IP)
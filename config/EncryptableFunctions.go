@@ -29,6 +29,22 @@ type EncryptableFunctions struct {
 	EncryptionStateBanner  EncryptionStateBanner
 	EncryptionStateHeader  EncryptionStateHeader
 	DoCipherByReaderWriter DoCipherByReaderWriter
+	// DoSealByReaderWriter stores new content in CipherFormat
+	DoSealByReaderWriter DoCipherByReaderWriter
+	// DoOpenByReaderWriter reads content stored by DoSealByReaderWriter
+	DoOpenByReaderWriter DoCipherByReaderWriter
+	// CipherFormat is the format of content stored by DoSealByReaderWriter
+	CipherFormat int
+}
+
+// DecipherFor is the function that reads content stored in a format, as
+// recorded with its object. Content stored before the format was recorded is
+// read as AES-CTR.
+func (f EncryptableFunctions) DecipherFor(format int) DoCipherByReaderWriter {
+	if format == odrivecrypto.CipherFormatGCM {
+		return f.DoOpenByReaderWriter
+	}
+	return f.DoCipherByReaderWriter
 }
 
 func EncryptionHeaderFalse() (key, value string) {
@@ -90,6 +90,22 @@ func TestParseAppConfigurationFromConfigFile(t *testing.T) {
 	if conf.ServerSettings.EncryptEnabled {
 		t.Errorf("expected encrypt_enabled to be false: %v", conf.ServerSettings.EncryptEnabled)
 	}
+	if len(conf.CacheSettings.Zones) != 1 || conf.CacheSettings.Zones[0].Name != "sensitive" {
+		t.Errorf("expected a single cache zone named sensitive, got: %v", conf.CacheSettings.Zones)
+	} else if conf.CacheSettings.Zones[0].TypeNames[0] != "foo" || conf.CacheSettings.Zones[0].OwnerGroups[0] != "baz" {
+		t.Errorf("expected cache zone routes for type foo and group baz, got: %v", conf.CacheSettings.Zones[0])
+	} else if !conf.CacheSettings.Zones[0].Deduplicate {
+		t.Errorf("expected cache zone sensitive to deduplicate")
+	}
+	if conf.FullTextSettings.Root != "/var/lib/odrive/fulltext" || conf.FullTextSettings.MaxSize != 1048576 {
+		t.Errorf("expected full text index settings, got: %v", conf.FullTextSettings)
+	}
+	if conf.WebDAVSettings.Prefix != "/dav/" || conf.WebDAVSettings.LockTimeout != 600 {
+		t.Errorf("expected webdav settings, got: %v", conf.WebDAVSettings)
+	}
+	if conf.S3GatewaySettings.ListenPort != "9000" || conf.S3GatewaySettings.UserBucket != "mine" {
+		t.Errorf("expected s3 gateway settings, got: %v", conf.S3GatewaySettings)
+	}
 
 }
 
@@ -35,14 +35,21 @@ const (
 	OD_AWS_SQS_ENDPOINT              = "OD_AWS_SQS_ENDPOINT"
 	OD_AWS_SQS_INTERVAL              = "OD_AWS_SQS_INTERVAL"
 	OD_AWS_SQS_NAME                  = "OD_AWS_SQS_NAME"
+	OD_CACHE_DEDUPLICATE             = "OD_CACHE_DEDUPLICATE"
 	OD_CACHE_EVICTAGE                = "OD_CACHE_EVICTAGE"
 	OD_CACHE_FILELIMIT               = "OD_CACHE_FILELIMIT"
 	OD_CACHE_FILESLEEP               = "OD_CACHE_FILESLEEP"
 	OD_CACHE_HIGHTHRESHOLDPERCENT    = "OD_CACHE_HIGHTHRESHOLDPERCENT"
 	OD_CACHE_LOWTHRESHOLDPERCENT     = "OD_CACHE_LOWTHRESHOLDPERCENT"
+	OD_CACHE_ORPHAN_COLLECT          = "OD_CACHE_ORPHAN_COLLECT"
+	OD_CACHE_ORPHAN_DRYRUN           = "OD_CACHE_ORPHAN_DRYRUN"
+	OD_CACHE_ORPHAN_GRACE            = "OD_CACHE_ORPHAN_GRACE"
 	OD_CACHE_PARTITION               = "OD_CACHE_PARTITION"
+	OD_CACHE_PERMANENTSTORAGE        = "OD_CACHE_PERMANENTSTORAGE"
+	OD_CACHE_PERMANENTSTORAGE_ROOT   = "OD_CACHE_PERMANENTSTORAGE_ROOT"
 	OD_CACHE_ROOT                    = "OD_CACHE_ROOT"
 	OD_CACHE_WALKSLEEP               = "OD_CACHE_WALKSLEEP"
+	OD_CACHE_ZONES                   = "OD_CACHE_ZONES"
 	OD_DB_ACMGRANTEECACHE_LRU_TIME   = "OD_DB_ACMGRANTEECACHE_LRU_TIME"
 	OD_DB_CA                         = "OD_DB_CA"
 	OD_DB_CERT                       = "OD_DB_CERT"
@@ -64,7 +71,16 @@ const (
 	OD_DB_USE_TLS                    = "OD_DB_USE_TLS"
 	OD_DB_USERNAME                   = "OD_DB_USERNAME"
 	OD_ENCRYPT_ENABLED               = "OD_ENCRYPT_ENABLED"
+	OD_ENCRYPT_KEYID                 = "OD_ENCRYPT_KEYID"
+	OD_ENCRYPT_KEYPROVIDER           = "OD_ENCRYPT_KEYPROVIDER"
+	OD_ENCRYPT_KEYRING               = "OD_ENCRYPT_KEYRING"
+	OD_ENCRYPT_KMS_CERT              = "OD_ENCRYPT_KMS_CERT"
+	OD_ENCRYPT_KMS_KEY               = "OD_ENCRYPT_KMS_KEY"
+	OD_ENCRYPT_KMS_TRUST             = "OD_ENCRYPT_KMS_TRUST"
+	OD_ENCRYPT_KMS_URL               = "OD_ENCRYPT_KMS_URL"
 	OD_ENCRYPT_MASTERKEY             = "OD_ENCRYPT_MASTERKEY"
+	OD_ENCRYPT_MASTERKEY_PREVIOUS    = "OD_ENCRYPT_MASTERKEY_PREVIOUS"
+	OD_ENCRYPT_REENCRYPT             = "OD_ENCRYPT_REENCRYPT"
 	OD_EVENT_KAFKA_ADDRS             = "OD_EVENT_KAFKA_ADDRS"
 	OD_EVENT_PUBLISH_FAILURE_ACTIONS = "OD_EVENT_PUBLISH_FAILURE_ACTIONS"
 	OD_EVENT_PUBLISH_SUCCESS_ACTIONS = "OD_EVENT_PUBLISH_SUCCESS_ACTIONS"
@@ -72,6 +88,10 @@ const (
 	OD_EVENT_ZK_ADDRS                = "OD_EVENT_ZK_ADDRS"
 	OD_EXTERNAL_HOST                 = "OD_EXTERNAL_HOST"
 	OD_EXTERNAL_PORT                 = "OD_EXTERNAL_PORT"
+	OD_FULLTEXT_MAX_SIZE             = "OD_FULLTEXT_MAX_SIZE"
+	OD_FULLTEXT_QUEUE_SIZE           = "OD_FULLTEXT_QUEUE_SIZE"
+	OD_FULLTEXT_ROOT                 = "OD_FULLTEXT_ROOT"
+	OD_FULLTEXT_WORKERS              = "OD_FULLTEXT_WORKERS"
 	OD_HEADER_BANNER_ENABLED         = "OD_HEADER_BANNER_ENABLED"
 	OD_HEADER_BANNER_NAME            = "OD_HEADER_BANNER_NAME"
 	OD_HEADER_SERVER_ENABLED         = "OD_HEADER_SERVER_ENABLED"
@@ -85,16 +105,25 @@ const (
 	OD_PEER_ENABLED                  = "OD_PEER_ENABLED"
 	OD_PEER_INSECURE_SKIP_VERIFY     = "OD_PEER_INSECURE_SKIP_VERIFY"
 	OD_PEER_SIGNIFIER                = "OD_PEER_SIGNIFIER"
+	OD_S3GATEWAY_ACM                 = "OD_S3GATEWAY_ACM"
+	OD_S3GATEWAY_PORT                = "OD_S3GATEWAY_PORT"
+	OD_S3GATEWAY_USER_BUCKET         = "OD_S3GATEWAY_USER_BUCKET"
 	OD_SERVER_ACL_WHITELIST          = "OD_SERVER_ACL_WHITELIST"
 	OD_SERVER_BINDADDRESS            = "OD_SERVER_BINDADDRESS"
 	OD_SERVER_CA                     = "OD_SERVER_CA"
 	OD_SERVER_CERT                   = "OD_SERVER_CERT"
 	OD_SERVER_CIPHERS                = "OD_SERVER_CIPHERS"
+	OD_SERVER_IMPORT_MAX_ENTRIES     = "OD_SERVER_IMPORT_MAX_ENTRIES"
+	OD_SERVER_IMPORT_MAX_SIZE        = "OD_SERVER_IMPORT_MAX_SIZE"
+	OD_SERVER_JOB_LEASE              = "OD_SERVER_JOB_LEASE"
+	OD_SERVER_JOB_WORKERS            = "OD_SERVER_JOB_WORKERS"
 	OD_SERVER_KEY                    = "OD_SERVER_KEY"
 	OD_SERVER_MAXPAGESIZE            = "OD_SERVER_MAXPAGESIZE"
 	OD_SERVER_PORT                   = "OD_SERVER_PORT"
+	OD_SERVER_SCRUB_RATE             = "OD_SERVER_SCRUB_RATE"
 	OD_SERVER_STATIC_ROOT            = "OD_SERVER_STATIC_ROOT"
 	OD_SERVER_TEMPLATE_ROOT          = "OD_SERVER_TEMPLATE_ROOT"
+	OD_SERVER_TYPE_ADMIN             = "OD_SERVER_TYPE_ADMIN"
 	OD_SERVER_TIMEOUT_IDLE           = "OD_SERVER_TIMEOUT_IDLE"
 	OD_SERVER_TIMEOUT_READ           = "OD_SERVER_TIMEOUT_READ"
 	OD_SERVER_TIMEOUT_READHEADER     = "OD_SERVER_TIMEOUT_READHEADER"
@@ -103,6 +132,16 @@ const (
 	OD_TOKENJAR_PASSWORD             = "OD_TOKENJAR_PASSWORD"
 	OD_USERAOCACHE_LRU_TIME          = "OD_USERAOCACHE_LRU_TIME"
 	OD_USERAOCACHE_TIMEOUT           = "OD_USERAOCACHE_TIMEOUT"
+	OD_WEBDAV_ACM                    = "OD_WEBDAV_ACM"
+	OD_WEBDAV_LOCK_TIMEOUT           = "OD_WEBDAV_LOCK_TIMEOUT"
+	OD_WEBDAV_PREFIX                 = "OD_WEBDAV_PREFIX"
+	OD_WEBHOOK_ALLOWED_CALLBACKS     = "OD_WEBHOOK_ALLOWED_CALLBACKS"
+	OD_WEBHOOK_ALLOW_PRIVATE         = "OD_WEBHOOK_ALLOW_PRIVATE"
+	OD_WEBHOOK_QUEUE_SIZE            = "OD_WEBHOOK_QUEUE_SIZE"
+	OD_WEBHOOK_RETRIES               = "OD_WEBHOOK_RETRIES"
+	OD_WEBHOOK_RETRY_DELAY           = "OD_WEBHOOK_RETRY_DELAY"
+	OD_WEBHOOK_TIMEOUT               = "OD_WEBHOOK_TIMEOUT"
+	OD_WEBHOOK_WORKERS               = "OD_WEBHOOK_WORKERS"
 	OD_ZK_AAC                        = "OD_ZK_AAC"
 	OD_ZK_ANNOUNCE                   = "OD_ZK_ANNOUNCE"
 	OD_ZK_MYIP                       = "OD_ZK_MYIP"
@@ -142,14 +181,21 @@ var Vars = []string{OD_AAC_CA,
 	OD_AWS_SQS_ENDPOINT,
 	OD_AWS_SQS_INTERVAL,
 	OD_AWS_SQS_NAME,
+	OD_CACHE_DEDUPLICATE,
 	OD_CACHE_EVICTAGE,
 	OD_CACHE_FILELIMIT,
 	OD_CACHE_FILESLEEP,
 	OD_CACHE_HIGHTHRESHOLDPERCENT,
 	OD_CACHE_LOWTHRESHOLDPERCENT,
+	OD_CACHE_ORPHAN_COLLECT,
+	OD_CACHE_ORPHAN_DRYRUN,
+	OD_CACHE_ORPHAN_GRACE,
 	OD_CACHE_PARTITION,
+	OD_CACHE_PERMANENTSTORAGE,
+	OD_CACHE_PERMANENTSTORAGE_ROOT,
 	OD_CACHE_ROOT,
 	OD_CACHE_WALKSLEEP,
+	OD_CACHE_ZONES,
 	OD_DB_ACMGRANTEECACHE_LRU_TIME,
 	OD_DB_CA,
 	OD_DB_CERT,
@@ -171,7 +217,16 @@ var Vars = []string{OD_AAC_CA,
 	OD_DB_USE_TLS,
 	OD_DB_USERNAME,
 	OD_ENCRYPT_ENABLED,
+	OD_ENCRYPT_KEYID,
+	OD_ENCRYPT_KEYPROVIDER,
+	OD_ENCRYPT_KEYRING,
+	OD_ENCRYPT_KMS_CERT,
+	OD_ENCRYPT_KMS_KEY,
+	OD_ENCRYPT_KMS_TRUST,
+	OD_ENCRYPT_KMS_URL,
 	OD_ENCRYPT_MASTERKEY,
+	OD_ENCRYPT_MASTERKEY_PREVIOUS,
+	OD_ENCRYPT_REENCRYPT,
 	OD_EVENT_KAFKA_ADDRS,
 	OD_EVENT_PUBLISH_FAILURE_ACTIONS,
 	OD_EVENT_PUBLISH_SUCCESS_ACTIONS,
@@ -179,6 +234,10 @@ var Vars = []string{OD_AAC_CA,
 	OD_EVENT_ZK_ADDRS,
 	OD_EXTERNAL_HOST,
 	OD_EXTERNAL_PORT,
+	OD_FULLTEXT_MAX_SIZE,
+	OD_FULLTEXT_QUEUE_SIZE,
+	OD_FULLTEXT_ROOT,
+	OD_FULLTEXT_WORKERS,
 	OD_HEADER_BANNER_ENABLED,
 	OD_HEADER_BANNER_NAME,
 	OD_HEADER_SERVER_ENABLED,
@@ -192,16 +251,25 @@ var Vars = []string{OD_AAC_CA,
 	OD_PEER_ENABLED,
 	OD_PEER_SIGNIFIER,
 	OD_PEER_INSECURE_SKIP_VERIFY,
+	OD_S3GATEWAY_ACM,
+	OD_S3GATEWAY_PORT,
+	OD_S3GATEWAY_USER_BUCKET,
 	OD_SERVER_ACL_WHITELIST,
 	OD_SERVER_BINDADDRESS,
 	OD_SERVER_CA,
 	OD_SERVER_CERT,
 	OD_SERVER_CIPHERS,
+	OD_SERVER_IMPORT_MAX_ENTRIES,
+	OD_SERVER_IMPORT_MAX_SIZE,
+	OD_SERVER_JOB_LEASE,
+	OD_SERVER_JOB_WORKERS,
 	OD_SERVER_KEY,
 	OD_SERVER_MAXPAGESIZE,
 	OD_SERVER_PORT,
+	OD_SERVER_SCRUB_RATE,
 	OD_SERVER_STATIC_ROOT,
 	OD_SERVER_TEMPLATE_ROOT,
+	OD_SERVER_TYPE_ADMIN,
 	OD_SERVER_TIMEOUT_IDLE,
 	OD_SERVER_TIMEOUT_READ,
 	OD_SERVER_TIMEOUT_READHEADER,
@@ -210,6 +278,16 @@ var Vars = []string{OD_AAC_CA,
 	OD_TOKENJAR_PASSWORD,
 	OD_USERAOCACHE_LRU_TIME,
 	OD_USERAOCACHE_TIMEOUT,
+	OD_WEBDAV_ACM,
+	OD_WEBDAV_LOCK_TIMEOUT,
+	OD_WEBDAV_PREFIX,
+	OD_WEBHOOK_ALLOWED_CALLBACKS,
+	OD_WEBHOOK_ALLOW_PRIVATE,
+	OD_WEBHOOK_QUEUE_SIZE,
+	OD_WEBHOOK_RETRIES,
+	OD_WEBHOOK_RETRY_DELAY,
+	OD_WEBHOOK_TIMEOUT,
+	OD_WEBHOOK_WORKERS,
 	OD_ZK_AAC,
 	OD_ZK_ANNOUNCE,
 	OD_ZK_MYIP,
@@ -227,6 +305,7 @@ func PrintODEnvironment() {
 		OD_AWS_ACCESS_KEY_ID,
 		OD_AWS_SECRET_ACCESS_KEY,
 		OD_ENCRYPT_MASTERKEY,
+		OD_ENCRYPT_MASTERKEY_PREVIOUS,
 		OD_DB_PASSWORD,
 	}
 	redact := func(envVar, value string) string {
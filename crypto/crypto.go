@@ -188,3 +188,45 @@ func rangeCopy(dst io.Writer, src io.Reader, byteRange *ByteRange) (int64, error
 	rangeDiff := byteRange.Stop - byteRange.Start + 1
 	return io.CopyN(dst, src, rangeDiff)
 }
+
+// cipherReaderAt applies the cipher to ciphertext read at any offset
+type cipherReaderAt struct {
+	block cipher.Block
+	iv    []byte
+	r     io.ReaderAt
+}
+
+// NewCipherReaderAt allows ciphertext written by DoCipherByReaderWriter to be
+// read from any offset, as the counter of each block follows from the offset.
+// This serves readers that need random access, such as for zip archives.
+func NewCipherReaderAt(r io.ReaderAt, key []byte, iv []byte) (io.ReaderAt, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &cipherReaderAt{block: block, iv: iv, r: r}, nil
+}
+
+// ReadAt reads the plaintext at the offset
+func (c *cipherReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	if n <= 0 {
+		return n, err
+	}
+	// Advance the counter by the blocks preceding the offset, carrying as
+	// the stream of the cipher does
+	counter := make([]byte, len(c.iv))
+	copy(counter, c.iv)
+	carry := uint64(off / aes.BlockSize)
+	for i := len(counter) - 1; i >= 0 && carry > 0; i-- {
+		sum := uint64(counter[i]) + (carry & 0xff)
+		counter[i] = byte(sum)
+		carry = (carry >> 8) + (sum >> 8)
+	}
+	stream := cipher.NewCTR(c.block, counter)
+	// Discard the key stream preceding the offset within its block
+	skip := make([]byte, off%aes.BlockSize)
+	stream.XORKeyStream(skip, skip)
+	stream.XORKeyStream(p[:n], p[:n])
+	return n, err
+}
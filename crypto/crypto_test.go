@@ -161,3 +161,115 @@ func basicCipherRaw(t *testing.T, data []byte, ciphertextName string, byteRange
 		t.Errorf("Recovered data not the same for range:%d-%d", byteRange.Start, byteRange.Stop)
 	}
 }
+
+func TestCipherReaderAt(t *testing.T) {
+	logger := config.RootLogger
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	key := crypto.CreateKey()
+	iv := crypto.CreateIV()
+	// A counter about to carry into the bytes before it
+	iv[len(iv)-1] = 0xfe
+	iv[len(iv)-2] = 0xff
+	var ciphertext bytes.Buffer
+	if _, _, err := crypto.DoCipherByReaderWriter(logger, bytes.NewReader(data), &ciphertext, key, iv, "reader at", nil); err != nil {
+		t.Fatalf("unable to encrypt: %v", err)
+	}
+	r, err := crypto.NewCipherReaderAt(bytes.NewReader(ciphertext.Bytes()), key, iv)
+	if err != nil {
+		t.Fatalf("unable to create reader: %v", err)
+	}
+	for _, off := range []int{0, 1, 15, 16, 17, 31, 32, 500, 999} {
+		p := make([]byte, 40)
+		n, _ := r.ReadAt(p, int64(off))
+		expected := data[off:]
+		if len(expected) > len(p) {
+			expected = expected[:len(p)]
+		}
+		if !bytes.Equal(p[:n], expected) {
+			t.Errorf("wrong plaintext at offset %d", off)
+		}
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	logger := config.RootLogger
+	key := crypto.CreateKey()
+	iv := crypto.CreateIV()
+	for _, size := range []int{0, 1, crypto.GCMChunkSize - 1, crypto.GCMChunkSize, 2*crypto.GCMChunkSize + 100} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		var sealed bytes.Buffer
+		checksum, length, err := crypto.DoSealByReaderWriter(logger, bytes.NewReader(data), &sealed, key, iv, "seal", nil)
+		if err != nil {
+			t.Fatalf("unable to seal %d bytes: %v", size, err)
+		}
+		if length != int64(size) || int64(sealed.Len()) != crypto.CiphertextSize(crypto.CipherFormatGCM, int64(size)) {
+			t.Errorf("wrong length for %d bytes: %d sealed as %d", size, length, sealed.Len())
+		}
+		var opened bytes.Buffer
+		openChecksum, _, err := crypto.DoOpenByReaderWriter(logger, bytes.NewReader(sealed.Bytes()), &opened, key, iv, "open", nil)
+		if err != nil {
+			t.Fatalf("unable to open %d bytes: %v", size, err)
+		}
+		if !bytes.Equal(opened.Bytes(), data) || !bytes.Equal(openChecksum, checksum) {
+			t.Errorf("recovered data not the same for %d bytes", size)
+		}
+	}
+}
+
+func TestOpenRange(t *testing.T) {
+	logger := config.RootLogger
+	key := crypto.CreateKey()
+	iv := crypto.CreateIV()
+	data := make([]byte, 3*crypto.GCMChunkSize+10)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	var sealed bytes.Buffer
+	if _, _, err := crypto.DoSealByReaderWriter(logger, bytes.NewReader(data), &sealed, key, iv, "seal", nil); err != nil {
+		t.Fatalf("unable to seal: %v", err)
+	}
+	for _, r := range [][2]int64{{0, 10}, {crypto.GCMChunkSize - 5, crypto.GCMChunkSize + 5}, {2*crypto.GCMChunkSize + 1, -1}, {int64(len(data) - 1), -1}} {
+		byteRange := &crypto.ByteRange{Start: r[0], Stop: r[1]}
+		cipherStartAt, chunkIV := crypto.AdjustSealedIV(iv, byteRange)
+		var opened bytes.Buffer
+		if _, _, err := crypto.DoOpenByReaderWriter(logger, bytes.NewReader(sealed.Bytes()[cipherStartAt:]), &opened, key, chunkIV, "open range", byteRange); err != nil {
+			t.Fatalf("unable to open range %d-%d: %v", r[0], r[1], err)
+		}
+		expected := data[r[0]:]
+		if r[1] != -1 {
+			expected = data[r[0] : r[1]+1]
+		}
+		if !bytes.Equal(opened.Bytes(), expected) {
+			t.Errorf("recovered data not the same for range %d-%d", r[0], r[1])
+		}
+	}
+}
+
+func TestOpenTampered(t *testing.T) {
+	logger := config.RootLogger
+	key := crypto.CreateKey()
+	iv := crypto.CreateIV()
+	data := make([]byte, 2*crypto.GCMChunkSize+10)
+	var sealed bytes.Buffer
+	if _, _, err := crypto.DoSealByReaderWriter(logger, bytes.NewReader(data), &sealed, key, iv, "seal", nil); err != nil {
+		t.Fatalf("unable to seal: %v", err)
+	}
+
+	flipped := append([]byte(nil), sealed.Bytes()...)
+	flipped[crypto.GCMChunkSize+100] ^= 1
+	if _, _, err := crypto.DoOpenByReaderWriter(logger, bytes.NewReader(flipped), ioutil.Discard, key, iv, "flipped", nil); err != crypto.ErrCiphertextTampered {
+		t.Errorf("flipped bit not detected: %v", err)
+	}
+
+	// Whole chunks dropped from the end leave a chunk not marked last
+	truncated := sealed.Bytes()[:crypto.CiphertextSize(crypto.CipherFormatGCM, crypto.GCMChunkSize)]
+	if _, _, err := crypto.DoOpenByReaderWriter(logger, bytes.NewReader(truncated), ioutil.Discard, key, iv, "truncated", nil); err != crypto.ErrCiphertextTampered {
+		t.Errorf("truncation not detected: %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package crypto
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"go.uber.org/zap"
+)
+
+// Formats of content at rest, recorded with each object
+const (
+	// CipherFormatCTR is AES-CTR as applied by DoCipherByReaderWriter, which
+	// does not detect tampering. Content stored while encryption at rest is
+	// disabled is recorded in this format, as its offsets are the same.
+	CipherFormatCTR = 0
+	// CipherFormatGCM is AES-GCM applied to each chunk of GCMChunkSize, as
+	// applied by DoSealByReaderWriter. Each chunk carries its own tag, so that
+	// any range may be decrypted and authenticated without the content before it.
+	CipherFormatGCM = 1
+)
+
+// GCMChunkSize is the size of the plaintext sealed in each chunk. The last
+// chunk may be shorter, and is empty only for empty content.
+const GCMChunkSize = 64 * 1024
+
+// gcmTagSize is the size of the tag following the ciphertext of each chunk
+const gcmTagSize = 16
+
+// gcmCounterAt is the offset in the iv of the counter of chunks. The nonce of
+// a chunk is the iv up to the counter, the counter, and a byte marking the
+// last chunk, so that chunks may not be reordered or truncated unnoticed.
+const gcmCounterAt = 7
+
+// ErrCiphertextTampered is returned when sealed content fails authentication
+var ErrCiphertextTampered = errors.New("ciphertext failed authentication")
+
+// CiphertextSize is the size at rest of content of a size in a format
+func CiphertextSize(format int, size int64) int64 {
+	if format != CipherFormatGCM {
+		return size
+	}
+	chunks := (size + GCMChunkSize - 1) / GCMChunkSize
+	if chunks == 0 {
+		chunks = 1
+	}
+	return size + chunks*gcmTagSize
+}
+
+// AdjustSealedIV locates the chunk of sealed content containing the start of
+// the byte range, returning the offset of the chunk at rest and the iv to open
+// from it. As with CTR, the byte range is adjusted to be relative to the chunk.
+func AdjustSealedIV(originalIV []byte, byteRange *ByteRange) (int64, []byte) {
+	iv := make([]byte, len(originalIV))
+	copy(iv, originalIV)
+	if byteRange == nil {
+		return 0, iv
+	}
+	chunks := byteRange.Start / GCMChunkSize
+	counter := binary.BigEndian.Uint32(iv[gcmCounterAt:])
+	binary.BigEndian.PutUint32(iv[gcmCounterAt:], counter+uint32(chunks))
+	byteRange.Start -= chunks * GCMChunkSize
+	if byteRange.Stop != -1 {
+		byteRange.Stop -= chunks * GCMChunkSize
+	}
+	return chunks * (GCMChunkSize + gcmTagSize), iv
+}
+
+// gcmNonce is the nonce of a chunk
+func gcmNonce(iv []byte, chunk uint32, last bool) []byte {
+	nonce := make([]byte, gcmCounterAt+5)
+	copy(nonce, iv[:gcmCounterAt])
+	binary.BigEndian.PutUint32(nonce[gcmCounterAt:], binary.BigEndian.Uint32(iv[gcmCounterAt:])+chunk)
+	if last {
+		nonce[gcmCounterAt+4] = 1
+	}
+	return nonce
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkReader seals or opens its source a chunk at a time, hashing the plaintext
+type chunkReader struct {
+	aead cipher.AEAD
+	iv   []byte
+	r    *bufio.Reader
+	H    hash.Hash
+	Size int64
+	// in holds a chunk read from the source, which is sealed or opened in
+	// place, and out the unread part of the result
+	in    []byte
+	out   []byte
+	chunk uint32
+	seal  bool
+	done  bool
+}
+
+func newChunkReader(aead cipher.AEAD, iv []byte, r io.Reader, seal bool) *chunkReader {
+	inSize := GCMChunkSize + gcmTagSize
+	if seal {
+		inSize = GCMChunkSize
+	}
+	return &chunkReader{
+		aead: aead,
+		iv:   iv,
+		r:    bufio.NewReader(r),
+		H:    sha256.New(),
+		in:   make([]byte, inSize, GCMChunkSize+gcmTagSize),
+		seal: seal,
+	}
+}
+
+// Read returns the sealed or opened content
+func (c *chunkReader) Read(dst []byte) (int, error) {
+	for len(c.out) == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		if err := c.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(dst, c.out)
+	c.out = c.out[n:]
+	return n, nil
+}
+
+// next seals or opens the next chunk. A chunk is last if the source ends
+// with it.
+func (c *chunkReader) next() error {
+	n, err := io.ReadFull(c.r, c.in)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	last := n < len(c.in)
+	if !last {
+		if _, err := c.r.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+	nonce := gcmNonce(c.iv, c.chunk, last)
+	if c.seal {
+		c.H.Write(c.in[:n])
+		c.Size += int64(n)
+		c.out = c.aead.Seal(c.in[:0], nonce, c.in[:n], nil)
+	} else {
+		plain, err := c.aead.Open(c.in[:0], nonce, c.in[:n], nil)
+		if err != nil {
+			return ErrCiphertextTampered
+		}
+		c.H.Write(plain)
+		c.Size += int64(len(plain))
+		c.out = plain
+	}
+	c.chunk++
+	c.done = last
+	return nil
+}
+
+// DoSealByReaderWriter seals the content read from io.Reader in chunks with
+// AES-GCM, writing to the io.Writer. The checksum and length returned are of
+// the plaintext read.
+func DoSealByReaderWriter(
+	logger *zap.Logger,
+	inFile io.Reader,
+	outFile io.Writer,
+	key []byte,
+	iv []byte,
+	description string,
+	byteRange *ByteRange,
+) (checksum []byte, length int64, err error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		logger.Error("unable to use cipher", zap.String("description", description), zap.Error(err))
+		return nil, 0, err
+	}
+	reader := newChunkReader(aead, iv, inFile, true)
+	_, err = rangeCopy(outFile, reader, byteRange)
+	return reader.H.Sum(nil), reader.Size, err
+}
+
+// DoOpenByReaderWriter opens content sealed by DoSealByReaderWriter, read
+// from io.Reader from the start of a chunk, and writes the byte range of the
+// plaintext to the io.Writer. Returns ErrCiphertextTampered if a chunk fails
+// authentication, which may be after part of the plaintext is written.
+func DoOpenByReaderWriter(
+	logger *zap.Logger,
+	inFile io.Reader,
+	outFile io.Writer,
+	key []byte,
+	iv []byte,
+	description string,
+	byteRange *ByteRange,
+) (checksum []byte, length int64, err error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		logger.Error("unable to use cipher", zap.String("description", description), zap.Error(err))
+		return nil, 0, err
+	}
+	reader := newChunkReader(aead, iv, inFile, false)
+	length, err = rangeCopy(outFile, reader, byteRange)
+	if err == ErrCiphertextTampered {
+		logger.Error("ciphertext failed authentication", zap.String("description", description))
+	}
+	return reader.H.Sum(nil), length, err
+}
@@ -0,0 +1,265 @@
+package dao
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded, or was issued
+// for a listing sorted differently than the request it is given with
+var ErrInvalidCursor = errors.New("cursor is not valid for this request")
+
+// Cursor marks the position in a listing following the last object of a
+// page, by the values of the sort keys of that object and its ID. Listing from
+// a cursor returns the objects that sort after it, so that pages remain
+// consistent while objects are added, and later pages are as fast to retrieve
+// as the first.
+type Cursor struct {
+	// Keys are the database fields of the sort keys of the listing
+	Keys []string `json:"k"`
+	// Values are the values of the sort keys of the last object, with nil
+	// representing null
+	Values []*string `json:"v"`
+	// ID is the identifier of the last object, in hexadecimal
+	ID string `json:"id"`
+}
+
+// sortKey is a database field that results are sorted on
+type sortKey struct {
+	dbField   string
+	ascending bool
+}
+
+// getSortKeys returns the sort keys of the paging request, or the default sort
+// by modified date if it has none that are recognized. The object ID is always
+// the final sort key, ensuring a consistent order for objects with equal
+// values.
+func getSortKeys(pagingRequest PagingRequest) []sortKey {
+	var keys []sortKey
+	for _, sortSetting := range pagingRequest.SortSettings {
+		dbField := getDBFieldFromPagingRequestField(sortSetting.SortField)
+		if len(dbField) == 0 {
+			// skip this unrecognized/unhandled field
+			continue
+		}
+		key := sortKey{dbField: dbField, ascending: sortSetting.SortAscending}
+		if dbField == "o.id" {
+			// The ID is unique, so any later sort keys have no effect
+			return append(keys, key)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		keys = append(keys, sortKey{dbField: "o.modifieddate", ascending: false})
+	}
+	return append(keys, sortKey{dbField: "o.id", ascending: keys[len(keys)-1].ascending})
+}
+
+// DecodeCursor decodes a cursor issued in the nextCursor of a listing,
+// ensuring it was issued for a listing sorted the same as the paging request.
+func DecodeCursor(token string, pagingRequest PagingRequest) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	keys := getSortKeys(pagingRequest)
+	if len(cursor.Keys) != len(keys)-1 || len(cursor.Values) != len(cursor.Keys) {
+		return nil, ErrInvalidCursor
+	}
+	for i, key := range cursor.Keys {
+		if key != keys[i].dbField {
+			return nil, ErrInvalidCursor
+		}
+	}
+	if _, err := hex.DecodeString(cursor.ID); err != nil || len(cursor.ID) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	for i, value := range cursor.Values {
+		if value == nil {
+			continue
+		}
+		if _, err := cursorArg(cursor.Keys[i], *value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return &cursor, nil
+}
+
+// cursorValue returns the value of a sort key of an object as held in a
+// cursor, or nil if it is null
+func cursorValue(object models.ODObject, dbField string) *string {
+	var value string
+	switch dbField {
+	case "o.changecount":
+		value = strconv.Itoa(object.ChangeCount)
+	case "o.createdby":
+		value = object.CreatedBy
+	case "o.createddate":
+		value = object.CreatedDate.UTC().Format(time.RFC3339Nano)
+	case "o.containsuspersonsdata":
+		value = object.ContainsUSPersonsData
+	case "o.contentsize":
+		if !object.ContentSize.Valid {
+			return nil
+		}
+		value = strconv.FormatInt(object.ContentSize.Int64, 10)
+	case "o.contenttype":
+		if !object.ContentType.Valid {
+			return nil
+		}
+		value = object.ContentType.String
+	case "o.description":
+		if !object.Description.Valid {
+			return nil
+		}
+		value = object.Description.String
+	case "o.exemptfromfoia":
+		value = object.ExemptFromFOIA
+	case "o.modifiedby":
+		value = object.ModifiedBy
+	case "o.modifieddate":
+		value = object.ModifiedDate.UTC().Format(time.RFC3339Nano)
+	case "o.name":
+		value = object.Name
+	case "o.ownedby":
+		if !object.OwnedBy.Valid {
+			return nil
+		}
+		value = object.OwnedBy.String
+	case "ot.name":
+		if !object.TypeName.Valid {
+			return nil
+		}
+		value = object.TypeName.String
+	case "o.parentid":
+		if len(object.ParentID) == 0 {
+			return nil
+		}
+		value = hex.EncodeToString(object.ParentID)
+	}
+	return &value
+}
+
+// cursorArg converts the value of a sort key held in a cursor to the argument
+// compared with the database field
+func cursorArg(dbField string, value string) (interface{}, error) {
+	switch dbField {
+	case "o.changecount", "o.contentsize":
+		return strconv.ParseInt(value, 10, 64)
+	case "o.createddate", "o.modifieddate":
+		return time.Parse(time.RFC3339Nano, value)
+	case "o.parentid", "o.id":
+		return hex.DecodeString(value)
+	}
+	return value, nil
+}
+
+// encodeCursor returns a cursor following the object in a listing sorted by
+// the sort keys
+func encodeCursor(object models.ODObject, keys []sortKey) string {
+	cursor := Cursor{ID: hex.EncodeToString(object.ID)}
+	for _, key := range keys[:len(keys)-1] {
+		cursor.Keys = append(cursor.Keys, key.dbField)
+		cursor.Values = append(cursor.Values, cursorValue(object, key.dbField))
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// buildFilterForCursor builds the where clause portion matching the objects
+// that sort after the cursor. For sort keys k1..kn, these are the objects
+// having k1 after the cursor, or k1 equal and k2 after, and so on. Null values
+// sort first in ascending order, as in MySQL.
+func buildFilterForCursor(pagingRequest PagingRequest) (string, []interface{}) {
+	cursor := pagingRequest.Cursor
+	if cursor == nil {
+		return "", nil
+	}
+	keys := getSortKeys(pagingRequest)
+	values := append(append([]*string{}, cursor.Values...), &cursor.ID)
+	var alternatives []string
+	var args []interface{}
+	equal := ``
+	var equalArgs []interface{}
+	for i, key := range keys {
+		after, afterArgs := buildCursorComparison(key, values[i], true)
+		if len(after) > 0 {
+			alternatives = append(alternatives, `(`+equal+after+`)`)
+			args = append(append(args, equalArgs...), afterArgs...)
+		}
+		same, sameArgs := buildCursorComparison(key, values[i], false)
+		equal += same + ` and `
+		equalArgs = append(equalArgs, sameArgs...)
+	}
+	if len(alternatives) == 0 {
+		return ` and 1 = 0`, nil
+	}
+	return ` and (` + strings.Join(alternatives, ` or `) + `)`, args
+}
+
+// buildCursorComparison builds the condition for a sort key to sort after the
+// value, or to equal it. An empty condition never matches.
+func buildCursorComparison(key sortKey, value *string, after bool) (string, []interface{}) {
+	if value == nil {
+		switch {
+		case !after:
+			return key.dbField + ` is null`, nil
+		case key.ascending:
+			return key.dbField + ` is not null`, nil
+		default:
+			return ``, nil
+		}
+	}
+	arg, _ := cursorArg(key.dbField, *value)
+	switch {
+	case !after:
+		return key.dbField + ` = ?`, []interface{}{arg}
+	case key.ascending:
+		return key.dbField + ` > ?`, []interface{}{arg}
+	default:
+		return `(` + key.dbField + ` < ? or ` + key.dbField + ` is null)`, []interface{}{arg}
+	}
+}
+
+// setNextCursor sets the cursor following the last object of a page of
+// results, if there may be more results. This must be called before results
+// are post processed.
+func setNextCursor(response *models.ODObjectResultset, pagingRequest PagingRequest) {
+	if len(response.Objects) == 0 || len(response.Objects) < GetSanitizedPageSize(pagingRequest.PageSize) {
+		return
+	}
+	// Totals count the whole listing, so objects before a cursor cannot be
+	// told apart from those after it, and a full page from a cursor may be
+	// followed by more
+	if pagingRequest.Cursor == nil {
+		seen := len(response.Objects) + GetOffset(pagingRequest.PageNumber, pagingRequest.PageSize)
+		if seen >= response.TotalRows {
+			return
+		}
+	}
+	response.NextCursor = encodeCursor(response.Objects[len(response.Objects)-1], getSortKeys(pagingRequest))
+}
+
+// queryRowCountWithoutCursor returns the query counting the rows of a listing,
+// and its arguments, without the condition on the cursor, so that totals are of
+// the whole listing rather than the rows following the cursor. The arguments
+// of the cursor are the last of those for the listing.
+func queryRowCountWithoutCursor(query string, args []interface{}, pagingRequest PagingRequest) (string, []interface{}) {
+	cursorFilter, cursorArgs := buildFilterForCursor(pagingRequest)
+	if len(cursorFilter) == 0 {
+		return queryRowCount(query), args
+	}
+	query = strings.Replace(query, cursorFilter, ``, 1)
+	return queryRowCount(query), args[:len(args)-len(cursorArgs)]
+}
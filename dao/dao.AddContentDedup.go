@@ -0,0 +1,128 @@
+package dao
+
+import (
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// AddContentDedup records the content of the object, newly stored in the
+// zone, so that objects later created with the same content and ACM may share
+// it. The content is only found once an object referring to it is stored.
+func (dao *DataAccessLayer) AddContentDedup(object models.ODObject, zone string) error {
+	defer util.Time("AddContentDedup")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return err
+	}
+	err = addContentDedupInTransaction(tx, dao, object, zone)
+	if err != nil {
+		dao.GetLogger().Error("Error in AddContentDedup", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return err
+}
+
+func addContentDedupInTransaction(tx *sqlx.Tx, dao *DataAccessLayer, object models.ODObject, zone string) error {
+	if len(object.ContentConnector.String) == 0 {
+		return errors.New("content connector was not specified for content being deduplicated")
+	}
+	if len(object.ContentHash) == 0 || !object.ContentSize.Valid {
+		return errors.New("content hash and size must be specified for content being deduplicated")
+	}
+	acmID, err := getAcm2IDForRawAcmInTransaction(tx, dao, object.RawAcm.String, true)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+    insert content_dedup set 
+        contentConnector = ?
+        ,zone = ?
+        ,acmId = ?
+        ,contentHash = ?
+        ,contentSize = ?
+        ,encryptIV = ?
+        ,cipherFormat = ?`,
+		object.ContentConnector.String, zone, acmID, object.ContentHash, object.ContentSize.Int64, object.EncryptIV, object.CipherFormat)
+	return err
+}
+
+// addContentReferenceInTransaction records that the object refers to its
+// current content, if the content is deduplicated. The content is locked so
+// that it cannot be released while the reference is added. If the object
+// shares content that has already been released, or removed since, it must
+// not refer to it, and ErrContentReleased is returned.
+func addContentReferenceInTransaction(tx *sqlx.Tx, object models.ODObject, sharesContent bool) error {
+	if len(object.ID) == 0 || len(object.ContentConnector.String) == 0 {
+		return nil
+	}
+	var released []bool
+	err := tx.Select(&released, `
+    select releasedDate is not null from content_dedup where contentConnector = ? for update`,
+		object.ContentConnector.String)
+	if err != nil {
+		return err
+	}
+	if len(released) == 0 {
+		if sharesContent {
+			return ErrContentReleased
+		}
+		return nil
+	}
+	if released[0] {
+		return ErrContentReleased
+	}
+	_, err = tx.Exec(`
+    insert ignore into content_reference (objectId, contentConnector) values (?, ?)`,
+		object.ID, object.ContentConnector.String)
+	return err
+}
+
+// releaseContentReferencesInTransaction removes the references of an expunged
+// object, and marks content no longer referred to by any object as released.
+// The content is locked before its references are counted, so that a
+// reference added concurrently is either seen or refused.
+func releaseContentReferencesInTransaction(tx *sqlx.Tx, object models.ODObject) error {
+	var contentConnectors []string
+	if err := tx.Select(&contentConnectors, `select contentConnector from content_reference where objectId = ?`, object.ID); err != nil {
+		return err
+	}
+	if len(contentConnectors) == 0 {
+		return nil
+	}
+	for _, contentConnector := range contentConnectors {
+		var locked []int
+		if err := tx.Select(&locked, `select 1 from content_dedup where contentConnector = ? for update`, contentConnector); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`delete from content_reference where objectId = ?`, object.ID); err != nil {
+		return err
+	}
+	for _, contentConnector := range contentConnectors {
+		var references int
+		err := tx.Get(&references, `select count(*) from content_reference where contentConnector = ? lock in share mode`, contentConnector)
+		if err != nil {
+			return err
+		}
+		if references > 0 {
+			continue
+		}
+		_, err = tx.Exec(`
+    update content_dedup set releasedDate = current_timestamp(6) 
+    where contentConnector = ? 
+        and releasedDate is null`,
+			contentConnector)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package dao
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// AddFavoriteToObject marks the object as a favorite of the user. If the user
+// already has the object as a current favorite, the existing favorite is
+// returned and no new record is created.
+func (dao *DataAccessLayer) AddFavoriteToObject(user models.ODUser, object models.ODObject) (models.ODUserObjectFavorite, error) {
+	defer util.Time("AddFavoriteToObject")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ODUserObjectFavorite{}, err
+	}
+	dbFavorite, err := addFavoriteToObjectInTransaction(tx, user, object)
+	if err != nil {
+		dao.GetLogger().Error("Error in AddFavoriteToObject", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbFavorite, err
+}
+
+func addFavoriteToObjectInTransaction(tx *sqlx.Tx, user models.ODUser, object models.ODObject) (models.ODUserObjectFavorite, error) {
+	var dbFavorite models.ODUserObjectFavorite
+
+	if len(object.ID) == 0 {
+		return dbFavorite, ErrMissingID
+	}
+	if len(user.DistinguishedName) == 0 {
+		return dbFavorite, ErrMissingUser
+	}
+
+	// Favorites are unique by object per user, so reuse an existing one
+	dbFavorite, err := getFavoriteForObjectInTransaction(tx, user, object)
+	if err == nil {
+		return dbFavorite, nil
+	}
+	if err != sql.ErrNoRows {
+		return dbFavorite, err
+	}
+
+	addFavoriteStatement, err := tx.Preparex(`insert user_object_favorite set 
+        createdby = ?
+        ,objectid = ?
+    `)
+	if err != nil {
+		return dbFavorite, err
+	}
+	defer addFavoriteStatement.Close()
+	result, err := addFavoriteStatement.Exec(user.DistinguishedName, object.ID)
+	if err != nil {
+		return dbFavorite, err
+	}
+	// Cannot use result.LastInsertId() as our identifier is not an autoincremented int
+	rowCount, err := result.RowsAffected()
+	if rowCount < 1 {
+		return dbFavorite, errors.New("No rows added from inserting user_object_favorite")
+	}
+	return getFavoriteForObjectInTransaction(tx, user, object)
+}
+
+func getFavoriteForObjectInTransaction(tx *sqlx.Tx, user models.ODUser, object models.ODObject) (models.ODUserObjectFavorite, error) {
+	var dbFavorite models.ODUserObjectFavorite
+	getFavoriteStatement := `
+    select
+        id
+        ,createdDate
+        ,createdBy
+        ,modifiedDate
+        ,modifiedBy
+        ,isDeleted
+        ,deletedDate
+        ,deletedBy
+        ,objectId
+    from user_object_favorite
+    where 
+        isdeleted = 0 
+        and createdby = ? 
+        and objectid = ? 
+    order by createddate desc limit 1`
+	err := tx.Get(&dbFavorite, getFavoriteStatement, user.DistinguishedName, object.ID)
+	return dbFavorite, err
+}
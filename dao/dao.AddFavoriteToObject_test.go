@@ -0,0 +1,99 @@
+package dao_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestDAOAddFavoriteToObject(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	guid, _ := util.NewGUID()
+	timeSuffix := strconv.FormatInt(time.Now().UTC().Unix(), 10) + guid
+
+	// create object
+	obj := setupObjectForDAOSearchObjectsTest("Favorite Object " + timeSuffix)
+	objectType, err := d.GetObjectTypeByName(obj.TypeName.String, true, obj.CreatedBy)
+	if err != nil {
+		t.Error(err)
+	} else {
+		obj.TypeID = objectType.ID
+	}
+	dbObject, err := d.CreateObject(&obj)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	// favorite it twice, expecting a single favorite
+	first, err := d.AddFavoriteToObject(users[1], dbObject)
+	if err != nil {
+		t.Error(err)
+	}
+	second, err := d.AddFavoriteToObject(users[1], dbObject)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(first.ID) != string(second.ID) {
+		t.Error("expected adding an existing favorite to return the existing favorite")
+	}
+
+	// list favorites
+	pagingRequest := dao.PagingRequest{}
+	filterName := dao.FilterSetting{}
+	filterName.FilterField = "name"
+	filterName.Condition = "contains"
+	filterName.Expression = timeSuffix
+	pagingRequest.FilterSettings = append(pagingRequest.FilterSettings, filterName)
+	favorites, err := d.GetFavoriteObjectsByUser(users[1], pagingRequest)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if favorites.TotalRows != 1 {
+		t.Errorf("expected 1 favorite, got %d for %s", favorites.TotalRows, timeSuffix)
+	}
+
+	// favorites are per user
+	marked, err := d.GetFavoritesForObjects(users[1], []models.ODObject{dbObject})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(marked) != 1 {
+		t.Errorf("expected object to be marked as favorite, got %d", len(marked))
+	}
+	marked, err = d.GetFavoritesForObjects(users[2], []models.ODObject{dbObject})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(marked) != 0 {
+		t.Errorf("expected object not to be a favorite of another user, got %d", len(marked))
+	}
+
+	// remove favorite
+	err = d.RemoveFavorite(users[1], dbObject)
+	if err != nil {
+		t.Error(err)
+	}
+	favorites, err = d.GetFavoriteObjectsByUser(users[1], pagingRequest)
+	if err != nil {
+		t.Error(err)
+	}
+	if favorites.TotalRows != 0 {
+		t.Errorf("expected no favorites after removal, got %d", favorites.TotalRows)
+	}
+
+	// delete the object
+	err = d.DeleteObject(users[1], dbObject, true)
+	if err != nil {
+		t.Error(err)
+	}
+}
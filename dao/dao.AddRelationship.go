@@ -0,0 +1,77 @@
+package dao
+
+import (
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// AddRelationship creates a relationship of the given type from the source
+// object to the target object on behalf of the user.
+func (dao *DataAccessLayer) AddRelationship(user models.ODUser, relationship models.ODRelationship) (models.ODRelationship, error) {
+	defer util.Time("AddRelationship")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ODRelationship{}, err
+	}
+	dbRelationship, err := addRelationshipInTransaction(tx, user, relationship)
+	if err != nil {
+		dao.GetLogger().Error("Error in AddRelationship", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbRelationship, err
+}
+
+func addRelationshipInTransaction(tx *sqlx.Tx, user models.ODUser, relationship models.ODRelationship) (models.ODRelationship, error) {
+	var dbRelationship models.ODRelationship
+
+	if len(relationship.SourceID) == 0 || len(relationship.TargetID) == 0 {
+		return dbRelationship, ErrMissingID
+	}
+	if len(relationship.RelationshipType) == 0 {
+		return dbRelationship, ErrMissingRelType
+	}
+	if len(user.DistinguishedName) == 0 {
+		return dbRelationship, ErrMissingUser
+	}
+
+	addRelationshipStatement, err := tx.Preparex(`insert relationship set 
+        createdby = ?
+        ,sourceid = ?
+        ,targetid = ?
+        ,relationshiptype = ?
+        ,description = ?
+        ,classificationpm = ?
+    `)
+	if err != nil {
+		return dbRelationship, err
+	}
+	defer addRelationshipStatement.Close()
+	result, err := addRelationshipStatement.Exec(user.DistinguishedName, relationship.SourceID, relationship.TargetID,
+		relationship.RelationshipType, relationship.Description, relationship.ClassificationPM)
+	if err != nil {
+		return dbRelationship, err
+	}
+	// Cannot use result.LastInsertId() as our identifier is not an autoincremented int
+	rowCount, err := result.RowsAffected()
+	if rowCount < 1 {
+		return dbRelationship, errors.New("No rows added from inserting relationship")
+	}
+	getRelationshipStatement := `
+    select ` + relationshipColumns + `
+    from relationship
+    where 
+        isdeleted = 0 
+        and sourceid = ? 
+        and targetid = ? 
+        and relationshiptype = ?`
+	err = tx.Get(&dbRelationship, getRelationshipStatement, relationship.SourceID, relationship.TargetID, relationship.RelationshipType)
+	return dbRelationship, err
+}
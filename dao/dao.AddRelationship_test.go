@@ -0,0 +1,100 @@
+package dao_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestDAOAddRelationship(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	guid, _ := util.NewGUID()
+	timeSuffix := strconv.FormatInt(time.Now().UTC().Unix(), 10) + guid
+
+	// create a report and its source
+	report := setupObjectForDAOSearchObjectsTest("Relationship Report " + timeSuffix)
+	objectType, err := d.GetObjectTypeByName(report.TypeName.String, true, report.CreatedBy)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	report.TypeID = objectType.ID
+	dbReport, err := d.CreateObject(&report)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	source := setupObjectForDAOSearchObjectsTest("Relationship Source " + timeSuffix)
+	source.TypeID = objectType.ID
+	dbSource, err := d.CreateObject(&source)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	// relate the report to its source
+	relationship := models.ODRelationship{SourceID: dbReport.ID, TargetID: dbSource.ID, RelationshipType: "derivedFrom"}
+	dbRelationship, err := d.AddRelationship(users[1], relationship)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if dbRelationship.CreatedBy != users[1].DistinguishedName || dbRelationship.RelationshipType != "derivedFrom" {
+		t.Errorf("relationship was not stored as requested")
+	}
+
+	// outgoing from the report, incoming to the source
+	relationships, err := d.GetRelationshipsForObject(dbReport, dao.RelationshipsOut)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(relationships) != 1 {
+		t.Errorf("expected 1 outgoing relationship for report, got %d", len(relationships))
+	}
+	relationships, err = d.GetRelationshipsForObject(dbReport, dao.RelationshipsIn)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(relationships) != 0 {
+		t.Errorf("expected no incoming relationships for report, got %d", len(relationships))
+	}
+	relationships, err = d.GetRelationshipsForObject(dbSource, dao.RelationshipsAll)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(relationships) != 1 {
+		t.Errorf("expected 1 relationship for source, got %d", len(relationships))
+	}
+
+	// delete the relationship
+	dbRelationship.ModifiedBy = users[1].DistinguishedName
+	err = d.DeleteRelationship(dbRelationship)
+	if err != nil {
+		t.Error(err)
+	}
+	relationships, err = d.GetRelationshipsForObject(dbSource, dao.RelationshipsAll)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(relationships) != 0 {
+		t.Errorf("expected no relationships after deletion, got %d", len(relationships))
+	}
+
+	// delete the objects
+	err = d.DeleteObject(users[1], dbReport, true)
+	if err != nil {
+		t.Error(err)
+	}
+	err = d.DeleteObject(users[1], dbSource, true)
+	if err != nil {
+		t.Error(err)
+	}
+}
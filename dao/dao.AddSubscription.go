@@ -0,0 +1,79 @@
+package dao
+
+import (
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// AddSubscription creates a subscription for the user to be notified of
+// changes to the object referenced by the subscription.
+func (dao *DataAccessLayer) AddSubscription(user models.ODUser, subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error) {
+	defer util.Time("AddSubscription")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ODUserObjectSubscription{}, err
+	}
+	dbSubscription, err := addSubscriptionInTransaction(tx, user, subscription)
+	if err != nil {
+		dao.GetLogger().Error("Error in AddSubscription", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbSubscription, err
+}
+
+func addSubscriptionInTransaction(tx *sqlx.Tx, user models.ODUser, subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error) {
+	var dbSubscription models.ODUserObjectSubscription
+
+	if len(subscription.ObjectID) == 0 {
+		return dbSubscription, ErrMissingID
+	}
+	if len(user.DistinguishedName) == 0 {
+		return dbSubscription, ErrMissingUser
+	}
+	if len(subscription.CallbackURL) == 0 {
+		return dbSubscription, ErrMissingCallbackURL
+	}
+
+	addSubscriptionStatement, err := tx.Preparex(`insert user_object_subscription set 
+        createdby = ?
+        ,objectid = ?
+        ,oncreate = ?
+        ,onupdate = ?
+        ,ondelete = ?
+        ,recursive = ?
+        ,callbackurl = ?
+    `)
+	if err != nil {
+		return dbSubscription, err
+	}
+	defer addSubscriptionStatement.Close()
+	result, err := addSubscriptionStatement.Exec(user.DistinguishedName, subscription.ObjectID,
+		subscription.OnCreate, subscription.OnUpdate, subscription.OnDelete, subscription.Recursive,
+		subscription.CallbackURL)
+	if err != nil {
+		return dbSubscription, err
+	}
+	// Cannot use result.LastInsertId() as our identifier is not an autoincremented int
+	rowCount, err := result.RowsAffected()
+	if rowCount < 1 {
+		return dbSubscription, errors.New("No rows added from inserting user_object_subscription")
+	}
+	getSubscriptionStatement := `
+    select ` + subscriptionColumns + `
+    from user_object_subscription
+    where 
+        isdeleted = 0 
+        and createdby = ? 
+        and objectid = ? 
+    order by createddate desc limit 1`
+	err = tx.Get(&dbSubscription, getSubscriptionStatement, user.DistinguishedName, subscription.ObjectID)
+	return dbSubscription, err
+}
@@ -0,0 +1,118 @@
+package dao_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestDAOAddSubscription(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	guid, _ := util.NewGUID()
+	timeSuffix := strconv.FormatInt(time.Now().UTC().Unix(), 10) + guid
+
+	// create a parent and child object
+	parent := setupObjectForDAOSearchObjectsTest("Subscription Parent " + timeSuffix)
+	objectType, err := d.GetObjectTypeByName(parent.TypeName.String, true, parent.CreatedBy)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	parent.TypeID = objectType.ID
+	dbParent, err := d.CreateObject(&parent)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	child := setupObjectForDAOSearchObjectsTest("Subscription Child " + timeSuffix)
+	child.TypeID = objectType.ID
+	child.ParentID = dbParent.ID
+	dbChild, err := d.CreateObject(&child)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	// subscribe to the parent, not recursively
+	subscription := models.ODUserObjectSubscription{ObjectID: dbParent.ID, OnUpdate: true, CallbackURL: "https://localhost/callback"}
+	dbSubscription, err := d.AddSubscription(users[1], subscription)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if dbSubscription.CreatedBy != users[1].DistinguishedName || !dbSubscription.OnUpdate || dbSubscription.OnDelete {
+		t.Errorf("subscription was not stored as requested")
+	}
+
+	// applies to the parent, but not the child
+	subscriptions, err := d.GetSubscriptionsForObject(dbParent)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(subscriptions) != 1 {
+		t.Errorf("expected 1 subscription for parent, got %d", len(subscriptions))
+	}
+	subscriptions, err = d.GetSubscriptionsForObject(dbChild)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(subscriptions) != 0 {
+		t.Errorf("expected no subscriptions for child, got %d", len(subscriptions))
+	}
+
+	// make it recursive, and it then applies to the child
+	dbSubscription.Recursive = true
+	dbSubscription.ModifiedBy = users[1].DistinguishedName
+	dbSubscription, err = d.UpdateSubscription(dbSubscription)
+	if err != nil {
+		t.Error(err)
+	}
+	subscriptions, err = d.GetSubscriptionsForObject(dbChild)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(subscriptions) != 1 {
+		t.Errorf("expected 1 recursive subscription for child, got %d", len(subscriptions))
+	}
+
+	// listed for the user
+	subscriptions, err = d.GetSubscriptionsByUser(users[1])
+	if err != nil {
+		t.Error(err)
+	}
+	found := false
+	for _, s := range subscriptions {
+		if string(s.ID) == string(dbSubscription.ID) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected subscription to be listed for user")
+	}
+
+	// delete the subscription
+	err = d.DeleteSubscription(dbSubscription)
+	if err != nil {
+		t.Error(err)
+	}
+	subscriptions, err = d.GetSubscriptionsForObject(dbChild)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(subscriptions) != 0 {
+		t.Errorf("expected no subscriptions after deletion, got %d", len(subscriptions))
+	}
+
+	// delete the objects
+	err = d.DeleteObject(users[1], dbParent, true)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,107 @@
+package dao
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// AddTagToObject associates a tag with the provided name to the Object
+// indicated by ObjectID. If the object already has a current tag by that name,
+// the existing tag is returned and no new record is created.
+func (dao *DataAccessLayer) AddTagToObject(object models.ODObject, tag *models.ODObjectTag) (models.ODObjectTag, error) {
+	defer util.Time("AddTagToObject")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("could not begin transaction", zap.Error(err))
+		return models.ODObjectTag{}, err
+	}
+	dbTag, err := addTagToObjectInTransaction(tx, object, tag)
+	if err != nil {
+		dao.GetLogger().Error("error in addtagtoobject", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbTag, err
+}
+
+func addTagToObjectInTransaction(tx *sqlx.Tx, object models.ODObject, tag *models.ODObjectTag) (models.ODObjectTag, error) {
+	var dbTag models.ODObjectTag
+
+	if len(object.ID) == 0 {
+		return dbTag, ErrMissingID
+	}
+	if len(tag.Name) == 0 {
+		return dbTag, ErrMissingTagName
+	}
+
+	// Tags are unique by name per object, so reuse an existing one
+	dbTag, err := getTagForObjectByNameInTransaction(tx, object, tag.Name)
+	if err == nil {
+		*tag = dbTag
+		return dbTag, nil
+	}
+	if err != sql.ErrNoRows {
+		return dbTag, err
+	}
+
+	// Setup the statement
+	addTagStatement, err := tx.Preparex(`insert object_tag set 
+        createdby = ?
+        ,objectid = ?
+        ,name = ?
+    `)
+	if err != nil {
+		return dbTag, err
+	}
+	defer addTagStatement.Close()
+	// Add it
+	result, err := addTagStatement.Exec(tag.CreatedBy, object.ID, tag.Name)
+	if err != nil {
+		return dbTag, err
+	}
+	// Cannot use result.LastInsertId() as our identifier is not an autoincremented int
+	rowCount, err := result.RowsAffected()
+	if rowCount < 1 {
+		return dbTag, errors.New("No rows added from inserting object_tag")
+	}
+	// Retrieve back into tag
+	dbTag, err = getTagForObjectByNameInTransaction(tx, object, tag.Name)
+	if err != nil {
+		return dbTag, err
+	}
+	*tag = dbTag
+	return dbTag, nil
+}
+
+func getTagForObjectByNameInTransaction(tx *sqlx.Tx, object models.ODObject, name string) (models.ODObjectTag, error) {
+	var dbTag models.ODObjectTag
+	getTagStatement := `
+    select
+        id
+        ,createdDate
+        ,createdBy
+        ,modifiedDate
+        ,modifiedBy
+        ,isDeleted
+        ,deletedDate
+        ,deletedBy
+        ,changeCount
+        ,changeToken
+        ,objectId
+        ,name
+    from object_tag
+    where 
+        isdeleted = 0 
+        and objectid = ? 
+        and name = ? 
+    order by createddate desc limit 1`
+	err := tx.Get(&dbTag, getTagStatement, object.ID, name)
+	return dbTag, err
+}
@@ -0,0 +1,95 @@
+package dao_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestDAOAddTagToObject(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	guid, _ := util.NewGUID()
+	timeSuffix := strconv.FormatInt(time.Now().UTC().Unix(), 10) + guid
+
+	// create object
+	obj := setupObjectForDAOSearchObjectsTest("Tag Object " + timeSuffix)
+	objectType, err := d.GetObjectTypeByName(obj.TypeName.String, true, obj.CreatedBy)
+	if err != nil {
+		t.Error(err)
+	} else {
+		obj.TypeID = objectType.ID
+	}
+	dbObject, err := d.CreateObject(&obj)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	// add the same tag twice, expecting a single association
+	tag := models.ODObjectTag{CreatedBy: obj.CreatedBy, Name: "tag" + timeSuffix}
+	first, err := d.AddTagToObject(dbObject, &tag)
+	if err != nil {
+		t.Error(err)
+	}
+	second, err := d.AddTagToObject(dbObject, &tag)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(first.ID) != string(second.ID) {
+		t.Error("expected adding an existing tag to return the existing association")
+	}
+
+	// get object with tags
+	objectWithTags, err := d.GetObject(dbObject, true)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(objectWithTags.Tags) != 1 {
+		t.Errorf("expected one tag on the object, got %d", len(objectWithTags.Tags))
+	} else if objectWithTags.Tags[0].Name != tag.Name {
+		t.Errorf("expected tag name %s, got %s", tag.Name, objectWithTags.Tags[0].Name)
+	}
+
+	// search using the tag as a filter
+	pagingRequest := dao.PagingRequest{}
+	filterTag := dao.FilterSetting{}
+	filterTag.FilterField = "tag"
+	filterTag.Condition = "equals"
+	filterTag.Expression = tag.Name
+	pagingRequest.FilterSettings = append(pagingRequest.FilterSettings, filterTag)
+	searchResults, err := d.SearchObjectsByNameOrDescription(users[1], pagingRequest, false)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if searchResults.TotalRows != 1 {
+		t.Errorf("expected 1 result filtering on tag, got %d for %s", searchResults.TotalRows, timeSuffix)
+	}
+
+	// remove the tag
+	err = d.RemoveTag(models.ODObjectTag{ObjectID: dbObject.ID, Name: tag.Name, ModifiedBy: obj.CreatedBy})
+	if err != nil {
+		t.Error(err)
+	}
+	tags, err := d.GetTagsForObject(dbObject)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("expected no tags after removal, got %d", len(tags))
+	}
+
+	// delete the object
+	err = d.DeleteObject(users[1], objectWithTags, true)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,46 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// CancelJob marks a job that is queued or running as cancelled, and returns
+// it. The instance running the job stops when it next records progress. A job
+// that has already finished is returned unchanged.
+func (dao *DataAccessLayer) CancelJob(job models.ODJob) (models.ODJob, error) {
+	defer util.Time("CancelJob")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ODJob{}, err
+	}
+	dbJob, err := cancelJobInTransaction(tx, job)
+	if err != nil {
+		dao.GetLogger().Error("Error in CancelJob", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbJob, err
+}
+
+func cancelJobInTransaction(tx *sqlx.Tx, job models.ODJob) (models.ODJob, error) {
+	if len(job.ID) == 0 {
+		return models.ODJob{}, ErrMissingID
+	}
+	_, err := tx.Exec(`
+    update job set
+        state = ?
+        ,modifiedDate = current_timestamp(6)
+        ,finishedDate = current_timestamp(6)
+    where id = ? and state in (?, ?)`,
+		models.JobStateCancelled, job.ID, models.JobStateQueued, models.JobStateRunning)
+	if err != nil {
+		return models.ODJob{}, err
+	}
+	return getJobInTransaction(tx, job)
+}
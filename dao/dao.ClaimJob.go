@@ -0,0 +1,74 @@
+package dao
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// ClaimJob claims the oldest job that is queued, or running on an instance
+// that has not renewed its claim within the lease, recording claimedBy as the
+// claim. As progress is only recorded for the claim a job was last claimed
+// with, claimedBy should be unique to each claim. Jobs with the excluded IDs
+// are not claimed. It returns sql.ErrNoRows when there
+// is no job to claim.
+func (dao *DataAccessLayer) ClaimJob(claimedBy string, leaseSeconds int64, excluded [][]byte) (models.ODJob, error) {
+	defer util.Time("ClaimJob")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ODJob{}, err
+	}
+	dbJob, err := claimJobInTransaction(tx, claimedBy, leaseSeconds, excluded)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			dao.GetLogger().Error("Error in ClaimJob", zap.Error(err))
+		}
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbJob, err
+}
+
+func claimJobInTransaction(tx *sqlx.Tx, claimedBy string, leaseSeconds int64, excluded [][]byte) (models.ODJob, error) {
+	query := `
+    select id from job
+    where (state = ?
+        or (state = ? and (heartbeatDate is null or heartbeatDate < date_sub(current_timestamp(6), interval ? second))))`
+	args := []interface{}{models.JobStateQueued, models.JobStateRunning, leaseSeconds}
+	if len(excluded) > 0 {
+		placeholders := make([]string, len(excluded))
+		for i, id := range excluded {
+			placeholders[i] = `?`
+			args = append(args, id)
+		}
+		query += ` and id not in (` + strings.Join(placeholders, `,`) + `)`
+	}
+	query += `
+    order by createdDate
+    limit 1
+    for update`
+	var id []byte
+	err := tx.Get(&id, query, args...)
+	if err != nil {
+		return models.ODJob{}, err
+	}
+	_, err = tx.Exec(`
+    update job set
+        state = ?
+        ,claimedBy = ?
+        ,heartbeatDate = current_timestamp(6)
+        ,modifiedDate = current_timestamp(6)
+    where id = ?`,
+		models.JobStateRunning, claimedBy, id)
+	if err != nil {
+		return models.ODJob{}, err
+	}
+	return getJobInTransaction(tx, models.ODJob{ID: id})
+}
@@ -0,0 +1,146 @@
+package dao_test
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"strconv"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestDAOContentDedup(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	guid, _ := util.NewGUID()
+	timeSuffix := strconv.FormatInt(time.Now().UTC().Unix(), 10) + guid
+	zone := "S3_DEFAULT"
+	hash := sha256.Sum256([]byte("TestDAOContentDedup " + timeSuffix))
+
+	// store the content with the first object
+	first := setupObjectForDAOSearchObjectsTest("Dedup First " + timeSuffix)
+	objectType, err := d.GetObjectTypeByName(first.TypeName.String, true, first.CreatedBy)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	first.TypeID = objectType.ID
+	first.ContentConnector = models.ToNullString(ciphertext.CreateRandomName())
+	first.ContentHash = hash[:]
+	first.ContentSize = models.NullInt64{NullInt64: sql.NullInt64{Int64: 42, Valid: true}}
+	first.EncryptIV = crypto.CreateIV()
+	contents, err := d.FindContentDedup(first, zone)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(contents) != 0 {
+		t.Errorf("expected no content before it is stored, got %d", len(contents))
+	}
+	if err = d.AddContentDedup(first, zone); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	dbFirst, err := d.CreateObject(&first)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	// a second object with the same content and ACM finds it
+	second := setupObjectForDAOSearchObjectsTest("Dedup Second " + timeSuffix)
+	second.TypeID = objectType.ID
+	second.ContentHash = hash[:]
+	second.ContentSize = models.NullInt64{NullInt64: sql.NullInt64{Int64: 42, Valid: true}}
+	contents, err = d.FindContentDedup(second, zone)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(contents) != 1 || contents[0].ContentConnector != first.ContentConnector.String {
+		t.Fatalf("expected content of first object to be found, got %v", contents)
+	}
+	second.ContentConnector = models.ToNullString(contents[0].ContentConnector)
+	second.EncryptIV = contents[0].EncryptIV
+	second.SharesContent = true
+	dbSecond, err := d.CreateObject(&second)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	stats, err := d.GetContentDedupStats()
+	if err != nil {
+		t.Error(err)
+	}
+	if stats.BytesSaved < 42 {
+		t.Errorf("expected at least 42 bytes saved, got %d", stats.BytesSaved)
+	}
+
+	// different content is not found
+	other := second
+	other.ContentSize = models.NullInt64{NullInt64: sql.NullInt64{Int64: 43, Valid: true}}
+	if contents, err = d.FindContentDedup(other, zone); err != nil || len(contents) != 0 {
+		t.Errorf("expected no content of a different size, got %v %v", contents, err)
+	}
+
+	// the content is released once both objects are expunged
+	if err = d.ExpungeObject(users[1], dbFirst, true); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if isReleased(t, first.ContentConnector.String) {
+		t.Errorf("expected content to be kept while an object refers to it")
+	}
+	if err = d.ExpungeObject(users[1], dbSecond, true); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !isReleased(t, first.ContentConnector.String) {
+		t.Fatalf("expected content to be released once no object refers to it")
+	}
+	if contents, err = d.FindContentDedup(second, zone); err != nil || len(contents) != 0 {
+		t.Errorf("expected released content not to be found, got %v %v", contents, err)
+	}
+
+	// an object found the content before it was released, and must not refer to it
+	late := setupObjectForDAOSearchObjectsTest("Dedup Late " + timeSuffix)
+	late.TypeID = objectType.ID
+	late.ContentHash = hash[:]
+	late.ContentSize = second.ContentSize
+	late.ContentConnector = first.ContentConnector
+	late.EncryptIV = first.EncryptIV
+	late.SharesContent = true
+	if _, err = d.CreateObject(&late); err != dao.ErrContentReleased {
+		t.Errorf("expected released content not to be shared, got %v", err)
+	}
+	if err = d.DeleteContentDedup(models.ODContentDedup{ContentConnector: first.ContentConnector.String}); err != nil {
+		t.Error(err)
+	}
+	if isReleased(t, first.ContentConnector.String) {
+		t.Errorf("expected released content to be deleted")
+	}
+	if _, err = d.CreateObject(&late); err != dao.ErrContentReleased {
+		t.Errorf("expected deleted content not to be shared, got %v", err)
+	}
+}
+
+func isReleased(t *testing.T, contentConnector string) bool {
+	contents, err := d.GetReleasedContentDedup(1000)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, content := range contents {
+		if content.ContentConnector == contentConnector {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// CreateJob records a new job as queued, to be claimed by a server instance.
+// If the job is given an ID, the job is recorded only once, and the job
+// already recorded with that ID is returned thereafter.
+func (dao *DataAccessLayer) CreateJob(job models.ODJob) (models.ODJob, error) {
+	defer util.Time("CreateJob")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ODJob{}, err
+	}
+	dbJob, err := createJobInTransaction(tx, job)
+	if err != nil {
+		dao.GetLogger().Error("Error in CreateJob", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbJob, err
+}
+
+func createJobInTransaction(tx *sqlx.Tx, job models.ODJob) (models.ODJob, error) {
+	if len(job.CreatedBy) == 0 {
+		return models.ODJob{}, ErrMissingUser
+	}
+	if len(job.JobType) == 0 {
+		return models.ODJob{}, ErrMissingJobType
+	}
+	id := job.ID
+	if len(id) == 0 {
+		newID, err := util.NewGUIDBytes()
+		if err != nil {
+			return models.ODJob{}, err
+		}
+		id = newID
+	}
+	_, err := tx.Exec(`
+    insert job set
+        id = ?
+        ,createdBy = ?
+        ,jobType = ?
+        ,state = ?
+        ,request = ?
+        ,context = ?
+        ,total = ?
+    on duplicate key update id = id`,
+		id, job.CreatedBy, job.JobType, models.JobStateQueued, job.Request, job.Context, job.Total)
+	if err != nil {
+		return models.ODJob{}, err
+	}
+	return getJobInTransaction(tx, models.ODJob{ID: id})
+}
@@ -0,0 +1,105 @@
+package dao_test
+
+import (
+	"bytes"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestDAOCreateJob(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	// a job requires a type
+	_, err := d.CreateJob(models.ODJob{CreatedBy: users[1].DistinguishedName})
+	if err != dao.ErrMissingJobType {
+		t.Errorf("expected ErrMissingJobType, got %v", err)
+	}
+
+	job := models.ODJob{
+		CreatedBy: users[1].DistinguishedName,
+		JobType:   "test",
+		Request:   "{}",
+		Context:   "{}",
+		Total:     3,
+	}
+	dbJob, err := d.CreateJob(job)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(dbJob.ID) == 0 || dbJob.State != models.JobStateQueued || dbJob.Total != 3 || dbJob.FinishedDate.Valid {
+		t.Errorf("job was not queued as requested")
+	}
+
+	dbJob, err = d.GetJob(models.ODJob{ID: dbJob.ID})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if dbJob.CreatedBy != users[1].DistinguishedName || dbJob.JobType != "test" {
+		t.Errorf("job was not retrieved as stored")
+	}
+
+	// progress is only recorded by the instance that claimed the job
+	dbJob.ClaimedBy = models.ToNullString("not-an-instance")
+	dbJob.State = models.JobStateRunning
+	dbJob.Processed = 1
+	err = d.UpdateJobProgress(dbJob, nil)
+	if err != dao.ErrJobNotRunning {
+		t.Errorf("expected ErrJobNotRunning, got %v", err)
+	}
+
+	// only the instance that claimed a job may release it
+	err = d.ReleaseJob(dbJob)
+	if err != dao.ErrJobNotRunning {
+		t.Errorf("expected ErrJobNotRunning releasing the job, got %v", err)
+	}
+
+	dbJob, err = d.CancelJob(dbJob)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if dbJob.State != models.JobStateCancelled || !dbJob.FinishedDate.Valid || dbJob.Processed != 0 {
+		t.Errorf("job was not cancelled, state %s", dbJob.State)
+	}
+}
+
+func TestDAOCreateJobWithID(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	id, _ := util.NewGUIDBytes()
+	job := models.ODJob{
+		ID:        id,
+		CreatedBy: users[1].DistinguishedName,
+		JobType:   "test",
+		Request:   "{}",
+		Context:   "{}",
+		Total:     1,
+	}
+	first, err := d.CreateJob(job)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	// the job is recorded only once for the same id
+	job.Total = 2
+	second, err := d.CreateJob(job)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !bytes.Equal(first.ID, id) || !bytes.Equal(second.ID, id) || second.Total != 1 {
+		t.Errorf("job with the same id was recorded twice")
+	}
+}
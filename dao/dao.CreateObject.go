@@ -182,6 +182,7 @@ func createObjectInTransaction(tx *sqlx.Tx, dao *DataAccessLayer, object *models
         ,contentSize = ?
         ,contentHash = ?
         ,encryptIV = ?
+        ,cipherFormat = ?
         ,containsUSPersonsData = ?
         ,exemptFromFOIA = ?
         ,ownedBy = ?
@@ -197,7 +198,7 @@ func createObjectInTransaction(tx *sqlx.Tx, dao *DataAccessLayer, object *models
 		object.Name, object.Description.String, object.ParentID,
 		object.ContentConnector.String, object.RawAcm.String,
 		object.ContentType.String, object.ContentSize.Int64, object.ContentHash,
-		object.EncryptIV, object.ContainsUSPersonsData, object.ExemptFromFOIA, object.OwnedBy.String,
+		object.EncryptIV, object.CipherFormat, object.ContainsUSPersonsData, object.ExemptFromFOIA, object.OwnedBy.String,
 		object.ACMID)
 	dao.GetLogger().Debug("dao checking response from insert to object")
 	if err != nil {
@@ -242,6 +243,7 @@ func createObjectInTransaction(tx *sqlx.Tx, dao *DataAccessLayer, object *models
         ,o.contentSize
         ,o.contentHash
         ,o.encryptIV
+        ,o.cipherFormat
         ,o.containsUSPersonsData
         ,o.exemptFromFOIA
         ,ot.name typeName
@@ -260,6 +262,12 @@ func createObjectInTransaction(tx *sqlx.Tx, dao *DataAccessLayer, object *models
 	if err != nil {
 		return dbObject, acmCreated, fmt.Errorf("CreateObject Error retrieving object, %s", err.Error())
 	}
+	if err = addContentReferenceInTransaction(tx, dbObject, object.SharesContent); err != nil {
+		if err == ErrContentReleased {
+			return dbObject, acmCreated, err
+		}
+		return dbObject, acmCreated, fmt.Errorf("CreateObject Error adding content reference, %s", err.Error())
+	}
 
 	// Add properties of object.Properties []models.ODObjectPropertyEx
 	for i, property := range object.Properties {
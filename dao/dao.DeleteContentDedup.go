@@ -0,0 +1,33 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// DeleteContentDedup removes the record of released content once it has been
+// removed from storage. Content that is no longer released is kept.
+func (dao *DataAccessLayer) DeleteContentDedup(content models.ODContentDedup) error {
+	defer util.Time("DeleteContentDedup")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return err
+	}
+	err = deleteContentDedupInTransaction(tx, content)
+	if err != nil {
+		dao.GetLogger().Error("Error in DeleteContentDedup", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return err
+}
+
+func deleteContentDedupInTransaction(tx *sqlx.Tx, content models.ODContentDedup) error {
+	_, err := tx.Exec(`delete from content_dedup where contentConnector = ? and releasedDate is not null`, content.ContentConnector)
+	return err
+}
@@ -0,0 +1,54 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// DeleteRelationship marks the relationship as deleted. The ModifiedBy and
+// ChangeToken fields of the relationship must be set.
+func (dao *DataAccessLayer) DeleteRelationship(relationship models.ODRelationship) error {
+	defer util.Time("DeleteRelationship")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return err
+	}
+	err = deleteRelationshipInTransaction(tx, relationship)
+	if err != nil {
+		dao.GetLogger().Error("Error in DeleteRelationship", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return err
+}
+
+func deleteRelationshipInTransaction(tx *sqlx.Tx, relationship models.ODRelationship) error {
+	if len(relationship.ID) == 0 {
+		return ErrMissingID
+	}
+	if len(relationship.ModifiedBy) == 0 {
+		return ErrMissingModifiedBy
+	}
+	if len(relationship.ChangeToken) == 0 {
+		return ErrMissingChangeToken
+	}
+	deleteRelationshipStatement, err := tx.Preparex(`
+    update relationship set 
+        modifiedby = ?
+        ,isdeleted = 1 
+    where 
+        id = ? 
+        and changetoken = ?
+        and isdeleted = 0`)
+	if err != nil {
+		return err
+	}
+	defer deleteRelationshipStatement.Close()
+	_, err = deleteRelationshipStatement.Exec(relationship.ModifiedBy, relationship.ID, relationship.ChangeToken)
+	return err
+}
@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// DeleteSubscription marks the subscription as deleted so that no further
+// notifications are sent for it. The ModifiedBy field of the subscription
+// must be set.
+func (dao *DataAccessLayer) DeleteSubscription(subscription models.ODUserObjectSubscription) error {
+	defer util.Time("DeleteSubscription")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return err
+	}
+	err = deleteSubscriptionInTransaction(tx, subscription)
+	if err != nil {
+		dao.GetLogger().Error("Error in DeleteSubscription", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return err
+}
+
+func deleteSubscriptionInTransaction(tx *sqlx.Tx, subscription models.ODUserObjectSubscription) error {
+	if len(subscription.ID) == 0 {
+		return ErrMissingID
+	}
+	if len(subscription.ModifiedBy) == 0 {
+		return ErrMissingModifiedBy
+	}
+	deleteSubscriptionStatement, err := tx.Preparex(`
+    update user_object_subscription set 
+        modifiedby = ?
+        ,deletedby = ?
+        ,isdeleted = 1 
+    where 
+        id = ? 
+        and isdeleted = 0`)
+	if err != nil {
+		return err
+	}
+	defer deleteSubscriptionStatement.Close()
+	_, err = deleteSubscriptionStatement.Exec(subscription.ModifiedBy, subscription.ModifiedBy, subscription.ID)
+	return err
+}
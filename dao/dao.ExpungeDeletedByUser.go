@@ -93,8 +93,9 @@ func (dao *DataAccessLayer) expungeDeletedByUserInTransactionMore(tx *sqlx.Tx, u
 	query += `'`
 	query += ` where o.isdeleted = 1 and o.isExpunged = 0 and o.isAncestorDeleted = 0 `
 	query += buildFilterRequireObjectsIOrMyGroupsOwn(tx, user)
-	query += buildFilterSortAndLimit(pagingRequest)
-	err = tx.Select(&response.Objects, query)
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	err = tx.Select(&response.Objects, query, filterArgs...)
 	dao.GetLogger().Info("expungeDeletedByUserInTransactionMore", zap.Any("user", user), zap.Any("pagingRequest", pagingRequest), zap.Int("rows", len(response.Objects)))
 	return response, err
 }
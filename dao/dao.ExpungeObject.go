@@ -111,6 +111,10 @@ func expungeObjectInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, user models.O
 	if err != nil {
 		return err
 	}
+	// Content no longer referred to by any object may be removed from storage
+	if err = releaseContentReferencesInTransaction(tx, dbObject); err != nil {
+		return err
+	}
 
 	// Process children
 	hasUndeletedChildren := true
@@ -0,0 +1,77 @@
+package dao
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// FindContentDedup returns content already stored in the zone having the same
+// ContentHash, ContentSize and ACM as the object, and that is still referred
+// to by an object. Each is returned with the encrypted key of a permission of
+// an object referring to it. No content is returned if none match.
+func (dao *DataAccessLayer) FindContentDedup(object models.ODObject, zone string) ([]models.ODContentDedup, error) {
+	defer util.Time("FindContentDedup")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return nil, err
+	}
+	contents, err := findContentDedupInTransaction(tx, dao, object, zone)
+	if err != nil {
+		dao.GetLogger().Error("Error in FindContentDedup", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return contents, err
+}
+
+func findContentDedupInTransaction(tx *sqlx.Tx, dao *DataAccessLayer, object models.ODObject, zone string) ([]models.ODContentDedup, error) {
+	var contents []models.ODContentDedup
+	if len(object.ContentHash) == 0 || !object.ContentSize.Valid {
+		return contents, nil
+	}
+	acmID, err := getAcm2IDForRawAcmInTransaction(tx, dao, object.RawAcm.String, false)
+	if err == sql.ErrNoRows {
+		// No object has been given this ACM before, so nothing can match
+		return contents, nil
+	}
+	if err != nil {
+		return contents, err
+	}
+	query := `
+    select 
+        d.contentConnector
+        ,d.createdDate
+        ,d.zone
+        ,d.acmId
+        ,d.contentHash
+        ,d.contentSize
+        ,d.encryptIV
+        ,d.cipherFormat
+        ,d.releasedDate
+        ,p.permissionIV
+        ,p.encryptKey
+    from content_dedup d
+        inner join object_permission p on p.id = (
+            select p2.id 
+            from content_reference r 
+                inner join object_permission p2 on p2.objectId = r.objectId and p2.isDeleted = 0 
+            where r.contentConnector = d.contentConnector 
+            limit 1)
+    where 
+        d.contentHash = ? 
+        and d.contentSize = ? 
+        and d.acmId = ? 
+        and d.zone = ? 
+        and d.releasedDate is null
+    order by d.createdDate
+    limit 10`
+	err = tx.Select(&contents, query, object.ContentHash, object.ContentSize.Int64, acmID, zone)
+	return contents, err
+}
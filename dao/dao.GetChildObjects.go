@@ -37,13 +37,16 @@ func getChildObjectsInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, pagingReque
     from object o 
         inner join object_type ot on o.typeid = ot.id 
     where o.isdeleted = 0 and o.parentid = ?`
-	query += buildFilterSortAndLimit(pagingRequest)
-	err := tx.Select(&response.Objects, query, object.ID)
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	args := append([]interface{}{object.ID}, filterArgs...)
+	err := tx.Select(&response.Objects, query, args...)
 	if err != nil {
 		return response, err
 	}
 	// Paging stats guidance
-	err = tx.Get(&response.TotalRows, queryRowCount(query), object.ID)
+	countQuery, countArgs := queryRowCountWithoutCursor(query, args, pagingRequest)
+	err = tx.Get(&response.TotalRows, countQuery, countArgs...)
 	if err != nil {
 		return response, err
 	}
@@ -59,6 +62,7 @@ func getChildObjectsInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, pagingReque
 		}
 		response.Objects[i] = obj
 	}
+	setNextCursor(&response, pagingRequest)
 	if loadProperties {
 		response = postProcessingFilterOnCustomProperties(response, pagingRequest)
 	}
@@ -42,13 +42,16 @@ func getChildObjectsByUserInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, user
         inner join object_type ot on o.typeid = ot.id `
 	query += buildJoinUserToACM(tx, user)
 	query += ` where o.isdeleted = 0 and o.parentid = ? `
-	query += buildFilterSortAndLimit(pagingRequest)
-	err := tx.Select(&response.Objects, query, object.ID)
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	args := append([]interface{}{object.ID}, filterArgs...)
+	err := tx.Select(&response.Objects, query, args...)
 	if err != nil {
 		return response, err
 	}
 	// Paging stats guidance
-	err = tx.Get(&response.TotalRows, queryRowCount(query), object.ID)
+	countQuery, countArgs := queryRowCountWithoutCursor(query, args, pagingRequest)
+	err = tx.Get(&response.TotalRows, countQuery, countArgs...)
 	if err != nil {
 		return response, err
 	}
@@ -64,6 +67,7 @@ func getChildObjectsByUserInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, user
 		}
 		response.Objects[i] = obj
 	}
+	setNextCursor(&response, pagingRequest)
 	if loadProperties {
 		response = postProcessingFilterOnCustomProperties(response, pagingRequest)
 	}
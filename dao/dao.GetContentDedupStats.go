@@ -0,0 +1,45 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetContentDedupStats returns the storage saved by sharing deduplicated
+// content between objects
+func (dao *DataAccessLayer) GetContentDedupStats() (models.ContentDedupStats, error) {
+	defer util.Time("GetContentDedupStats")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ContentDedupStats{}, err
+	}
+	stats, err := getContentDedupStatsInTransaction(tx)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetContentDedupStats", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return stats, err
+}
+
+func getContentDedupStatsInTransaction(tx *sqlx.Tx) (models.ContentDedupStats, error) {
+	var stats models.ContentDedupStats
+	query := `
+    select 
+        count(1) as contentCount
+        ,ifnull(sum(r.refs),0) as referenceCount
+        ,ifnull(sum(d.contentSize * (r.refs - 1)),0) as bytesSaved
+    from content_dedup d
+        inner join (
+            select contentConnector, count(1) as refs 
+            from content_reference 
+            group by contentConnector) r on r.contentConnector = d.contentConnector
+    where d.releasedDate is null`
+	err := tx.Get(&stats, query)
+	return stats, err
+}
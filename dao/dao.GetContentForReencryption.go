@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetContentForReencryption returns the next batch of content stored in a
+// zone that is still encrypted with AES-CTR, one revision for each content
+// connector, ordered by connector and starting after afterConnector. An empty
+// zone selects the default zone. Content of expunged objects is not returned.
+func (dao *DataAccessLayer) GetContentForReencryption(zone string, afterConnector string, limit int) ([]models.ODObject, error) {
+	defer util.Time("GetContentForReencryption")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return nil, err
+	}
+	response, err := getContentForReencryptionInTransaction(tx, zone, afterConnector, limit)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetContentForReencryption", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return response, err
+}
+
+func getContentForReencryptionInTransaction(tx *sqlx.Tx, zone string, afterConnector string, limit int) ([]models.ODObject, error) {
+	response := []models.ODObject{}
+	query := `
+    select 
+        min(ao.id) id
+        ,ao.contentConnector
+        ,min(ao.contentSize) contentSize
+        ,min(ao.contentHash) contentHash
+        ,min(ao.encryptIV) encryptIV
+        ,min(ao.cipherFormat) cipherFormat
+    from 
+        a_object ao
+    where 
+        ao.cipherFormat = 0
+        and ao.contentConnector > ?
+        and ao.contentSize > 0
+        and ao.encryptIV is not null
+        and not exists (select null from object o where o.id = ao.id and o.isExpunged = 1)`
+	args := []interface{}{afterConnector}
+	if len(zone) == 0 {
+		query += `
+        and locate(':', ao.contentConnector) = 0`
+	} else {
+		query += `
+        and left(ao.contentConnector, char_length(?) + 1) = concat(?, ':')`
+		args = append(args, zone, zone)
+	}
+	query += `
+    group by ao.contentConnector
+    order by ao.contentConnector
+    limit ?`
+	args = append(args, limit)
+	err := tx.Select(&response, query, args...)
+	return response, err
+}
@@ -0,0 +1,55 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetContentForScrub returns the next batch of stored content in any zone,
+// one revision for each content connector, ordered by connector and starting
+// after afterConnector. Content of expunged objects, and content stored within
+// the last hour that may not yet be in permanent storage, is not returned.
+func (dao *DataAccessLayer) GetContentForScrub(afterConnector string, limit int) ([]models.ODObject, error) {
+	defer util.Time("GetContentForScrub")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return nil, err
+	}
+	response, err := getContentForScrubInTransaction(tx, afterConnector, limit)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetContentForScrub", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return response, err
+}
+
+func getContentForScrubInTransaction(tx *sqlx.Tx, afterConnector string, limit int) ([]models.ODObject, error) {
+	response := []models.ODObject{}
+	query := `
+    select 
+        min(ao.id) id
+        ,ao.contentConnector
+        ,min(ao.contentSize) contentSize
+        ,min(ao.contentHash) contentHash
+        ,min(ao.encryptIV) encryptIV
+        ,min(ao.cipherFormat) cipherFormat
+    from 
+        a_object ao
+    where 
+        ao.contentConnector > ?
+        and ao.contentSize > 0
+        and ao.encryptIV is not null
+        and ao.modifiedDate < date_sub(current_timestamp(6), interval 1 hour)
+        and not exists (select null from object o where o.id = ao.id and o.isExpunged = 1)
+    group by ao.contentConnector
+    order by ao.contentConnector
+    limit ?`
+	err := tx.Select(&response, query, afterConnector, limit)
+	return response, err
+}
@@ -0,0 +1,78 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetFavoriteObjectsByUser retrieves a list of Objects that the user has
+// marked as a favorite and is still permitted to read per their cached
+// authorization to object ACMs.
+func (dao *DataAccessLayer) GetFavoriteObjectsByUser(user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error) {
+	defer util.Time("GetFavoriteObjectsByUser")()
+	loadProperties := true
+	loadPermissions := true
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ODObjectResultset{}, err
+	}
+	response, err := getFavoriteObjectsByUserInTransaction(dao, tx, user, pagingRequest, loadPermissions, loadProperties)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetFavoriteObjectsByUser", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return response, err
+}
+
+func getFavoriteObjectsByUserInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, user models.ODUser, pagingRequest PagingRequest, loadPermissions bool, loadProperties bool) (models.ODObjectResultset, error) {
+	response := models.ODObjectResultset{}
+
+	if len(user.DistinguishedName) == 0 {
+		return response, ErrMissingUser
+	}
+
+	query := `
+    select
+        o.id    
+    from object o
+        inner join object_type ot on o.typeid = ot.id `
+	query += buildJoinUserToACM(tx, user)
+	query += ` where o.isdeleted = 0 `
+	query += ` and o.id in (select f.objectid from user_object_favorite f where f.isdeleted = 0 and f.createdby = ?)`
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	args := append([]interface{}{user.DistinguishedName}, filterArgs...)
+	err := tx.Select(&response.Objects, query, args...)
+	if err != nil {
+		return response, err
+	}
+	// Paging stats guidance
+	countQuery, countArgs := queryRowCountWithoutCursor(query, args, pagingRequest)
+	err = tx.Get(&response.TotalRows, countQuery, countArgs...)
+	if err != nil {
+		return response, err
+	}
+	response.PageNumber = GetSanitizedPageNumber(pagingRequest.PageNumber)
+	response.PageSize = GetSanitizedPageSize(pagingRequest.PageSize)
+	response.PageRows = len(response.Objects)
+	response.PageCount = GetPageCount(response.TotalRows, response.PageSize)
+	// Load full meta, properties, and permissions
+	for i := 0; i < len(response.Objects); i++ {
+		obj, err := getObjectInTransaction(dao, tx, response.Objects[i], loadPermissions, loadProperties)
+		if err != nil {
+			return response, err
+		}
+		response.Objects[i] = obj
+	}
+	setNextCursor(&response, pagingRequest)
+	if loadProperties {
+		response = postProcessingFilterOnCustomProperties(response, pagingRequest)
+	}
+	return response, err
+}
@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetFavoritesForObjects retrieves the user's current favorites among the
+// objects provided. Objects that are not a favorite of the user are omitted.
+func (dao *DataAccessLayer) GetFavoritesForObjects(user models.ODUser, objects []models.ODObject) ([]models.ODUserObjectFavorite, error) {
+	defer util.Time("GetFavoritesForObjects")()
+	if len(objects) == 0 {
+		return []models.ODUserObjectFavorite{}, nil
+	}
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return []models.ODUserObjectFavorite{}, err
+	}
+	response, err := getFavoritesForObjectsInTransaction(tx, user, objects)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetFavoritesForObjects", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return response, err
+}
+
+func getFavoritesForObjectsInTransaction(tx *sqlx.Tx, user models.ODUser, objects []models.ODObject) ([]models.ODUserObjectFavorite, error) {
+	response := []models.ODUserObjectFavorite{}
+	if len(user.DistinguishedName) == 0 {
+		return response, ErrMissingUser
+	}
+	objectIDs := make([]string, len(objects))
+	for i, object := range objects {
+		if len(object.ID) == 0 {
+			return response, ErrMissingID
+		}
+		objectIDs[i] = "unhex('" + hex.EncodeToString(object.ID) + "')"
+	}
+	query := `
+    select
+        id
+        ,createdDate
+        ,createdBy
+        ,modifiedDate
+        ,modifiedBy
+        ,isDeleted
+        ,deletedDate
+        ,deletedBy
+        ,objectId
+    from user_object_favorite
+    where 
+        isdeleted = 0 
+        and createdby = ? 
+        and objectid in (` + strings.Join(objectIDs, ",") + `)`
+	err := tx.Select(&response, query, user.DistinguishedName)
+	return response, err
+}
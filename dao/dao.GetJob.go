@@ -0,0 +1,76 @@
+package dao
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// jobColumns is the list of columns selected when retrieving jobs.
+const jobColumns = `
+        id
+        ,createdDate
+        ,createdBy
+        ,modifiedDate
+        ,jobType
+        ,state
+        ,request
+        ,context
+        ,total
+        ,processed
+        ,succeeded
+        ,failed
+        ,error
+        ,claimedBy
+        ,heartbeatDate
+        ,finishedDate`
+
+// JobErrorLimit is the most errors retrieved with a job. Errors beyond it are
+// only counted.
+const JobErrorLimit = 1000
+
+// GetJob retrieves a job by its identifier, with the first of its errors.
+func (dao *DataAccessLayer) GetJob(job models.ODJob) (models.ODJob, error) {
+	defer util.Time("GetJob")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ODJob{}, err
+	}
+	dbJob, err := getJobInTransaction(tx, job)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			dao.GetLogger().Error("Error in GetJob", zap.Error(err))
+		}
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbJob, err
+}
+
+func getJobInTransaction(tx *sqlx.Tx, job models.ODJob) (models.ODJob, error) {
+	var dbJob models.ODJob
+	if len(job.ID) == 0 {
+		return dbJob, ErrMissingID
+	}
+	getJobStatement := `
+    select ` + jobColumns + `
+    from job
+    where id = ?`
+	if err := tx.Get(&dbJob, getJobStatement, job.ID); err != nil {
+		return dbJob, err
+	}
+	getJobErrorsStatement := `
+    select jobId, objectId, code, error, msg
+    from job_error
+    where jobId = ?
+    order by id
+    limit ?`
+	err := tx.Select(&dbJob.Errors, getJobErrorsStatement, job.ID, JobErrorLimit)
+	return dbJob, err
+}
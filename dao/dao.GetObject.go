@@ -69,6 +69,7 @@ func getObjectInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, object models.ODO
         ,o.contentSize
         ,o.contentHash
         ,o.encryptIV
+        ,o.cipherFormat
         ,o.containsUSPersonsData
         ,o.exemptFromFOIA
         ,ot.name typeName
@@ -99,6 +100,12 @@ func getObjectInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, object models.ODO
 			err = dbPropErr
 			return dbObject, err
 		}
+		dbTags, dbTagErr := getTagsForObjectInTransaction(tx, object)
+		dbObject.Tags = dbTags
+		if dbTagErr != nil {
+			err = dbTagErr
+			return dbObject, err
+		}
 	}
 
 	// Done
@@ -58,6 +58,7 @@ func getObjectRevisionInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, object mo
         ,ao.contentSize
         ,ao.contentHash
         ,ao.encryptIV
+        ,ao.cipherFormat
         ,ao.containsUSPersonsData
         ,ao.exemptFromFOIA
         ,ot.name typeName
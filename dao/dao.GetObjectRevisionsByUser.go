@@ -31,6 +31,11 @@ func (dao *DataAccessLayer) GetObjectRevisionsByUser(
 
 func getObjectRevisionsByUserInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, user models.ODUser, pagingRequest PagingRequest, object models.ODObject, loadProperties bool) (models.ODObjectResultset, error) {
 	response := models.ODObjectResultset{}
+	// Revisions share the ID of their object, which cursors rely on to order
+	// objects with equal sort values, so they are only listed by page number
+	if pagingRequest.Cursor != nil {
+		return response, ErrInvalidCursor
+	}
 	query := `
     select 
         ao.id
@@ -58,6 +63,7 @@ func getObjectRevisionsByUserInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, us
         ,ao.contentSize
         ,ao.contentHash
         ,ao.encryptIV
+        ,ao.cipherFormat
         ,ao.containsUSPersonsData
         ,ao.exemptFromFOIA
         ,ot.name typeName
@@ -66,15 +72,17 @@ func getObjectRevisionsByUserInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, us
         inner join object_type ot on ao.typeid = ot.id `
 	query += buildJoinUserToACM(tx, user)
 	query += ` where ao.isdeleted = 0 and ao.id = ? `
-	query += buildFilterSortAndLimitArchive(pagingRequest)
+	filter, filterArgs := buildFilterSortAndLimitArchive(pagingRequest)
+	query += filter
+	args := append([]interface{}{object.ID}, filterArgs...)
 	query = strings.Replace(query, "a_object ao", "a_object o", -1)
 	query = strings.Replace(query, "ao.", "o.", -1)
-	err := tx.Select(&response.Objects, query, object.ID)
+	err := tx.Select(&response.Objects, query, args...)
 	if err != nil {
 		return response, err
 	}
 	// Paging stats guidance
-	err = tx.Get(&response.TotalRows, queryRowCount(query), object.ID)
+	err = tx.Get(&response.TotalRows, queryRowCount(query), args...)
 	if err != nil {
 		return response, err
 	}
@@ -117,6 +117,12 @@ func TestDAOGetObjectRevisionsByUser(t *testing.T) {
 		t.Errorf("Expected revision to be named %s, but got %s", "Test Object Revision", resultset.Objects[2].Name)
 	}
 
+	// Revisions cannot be listed from a cursor
+	pagingRequest.Cursor = &dao.Cursor{Keys: []string{"o.changecount"}, Values: []*string{nil}, ID: hex.EncodeToString(object.ID)}
+	if _, err := d.GetObjectRevisionsByUser(user, pagingRequest, object, false); err != dao.ErrInvalidCursor {
+		t.Errorf("Expected revisions listed from a cursor to be refused, got %v", err)
+	}
+
 	// Cleanup
 	err = d.DeleteObject(user, object, true)
 	if err != nil {
@@ -0,0 +1,94 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetObjectTypeProperties retrieves the current property definitions declared
+// for the object type, ordered by property name.
+func (dao *DataAccessLayer) GetObjectTypeProperties(objectType models.ODObjectType) ([]models.ODObjectTypeProperty, error) {
+	defer util.Time("GetObjectTypeProperties")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return nil, err
+	}
+	definitions, err := getObjectTypePropertiesInTransaction(tx, objectType)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetObjectTypeProperties", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return definitions, err
+}
+
+// objectTypePropertyRow is the flattened result of joining object_type_property
+// with its property.
+type objectTypePropertyRow struct {
+	ID               []byte            `db:"id"`
+	CreatedDate      models.NullTime   `db:"createdDate"`
+	CreatedBy        string            `db:"createdBy"`
+	TypeID           []byte            `db:"typeId"`
+	PropertyID       []byte            `db:"propertyId"`
+	ValueType        string            `db:"valueType"`
+	IsRequired       bool              `db:"isRequired"`
+	AllowedValues    models.NullString `db:"allowedValues"`
+	Name             string            `db:"name"`
+	Value            models.NullString `db:"propertyValue"`
+	ClassificationPM models.NullString `db:"classificationPM"`
+}
+
+func getObjectTypePropertiesInTransaction(tx *sqlx.Tx, objectType models.ODObjectType) ([]models.ODObjectTypeProperty, error) {
+	var definitions []models.ODObjectTypeProperty
+	if len(objectType.ID) == 0 {
+		return definitions, ErrMissingID
+	}
+	query := `
+    select 
+        otp.id
+        ,otp.createdDate
+        ,otp.createdBy
+        ,otp.typeId
+        ,otp.propertyId
+        ,otp.valueType
+        ,otp.isRequired
+        ,otp.allowedValues
+        ,p.name
+        ,p.propertyValue
+        ,p.classificationPM
+    from object_type_property otp
+        inner join property p on otp.propertyid = p.id
+    where 
+        otp.isdeleted = 0 
+        and p.isdeleted = 0 
+        and otp.typeid = ?
+    order by p.name asc`
+	var rows []objectTypePropertyRow
+	if err := tx.Select(&rows, query, objectType.ID); err != nil {
+		return definitions, err
+	}
+	for _, row := range rows {
+		definitions = append(definitions, models.ODObjectTypeProperty{
+			ID:            row.ID,
+			CreatedDate:   row.CreatedDate.Time,
+			CreatedBy:     row.CreatedBy,
+			TypeID:        row.TypeID,
+			PropertyID:    row.PropertyID,
+			ValueType:     row.ValueType,
+			IsRequired:    row.IsRequired,
+			AllowedValues: row.AllowedValues,
+			Property: models.ODProperty{
+				ID:               row.PropertyID,
+				Name:             row.Name,
+				Value:            row.Value,
+				ClassificationPM: row.ClassificationPM,
+			},
+		})
+	}
+	return definitions, nil
+}
@@ -46,13 +46,15 @@ func getObjectsIHaveSharedInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, user
 	query += ` where o.isdeleted = 0 `
 	usergranteeid := strconv.FormatInt(getACMValueFor(tx, models.AACFlatten(user.DistinguishedName)), 10)
 	query += fmt.Sprintf(` and op.createdbyid = %s and op.granteeid <> %s `, usergranteeid, usergranteeid)
-	query += buildFilterSortAndLimit(pagingRequest)
-	err := tx.Select(&response.Objects, query)
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	err := tx.Select(&response.Objects, query, filterArgs...)
 	if err != nil {
 		return response, err
 	}
 	// Paging stats guidance
-	err = tx.Get(&response.TotalRows, queryRowCount(query))
+	countQuery, countArgs := queryRowCountWithoutCursor(query, filterArgs, pagingRequest)
+	err = tx.Get(&response.TotalRows, countQuery, countArgs...)
 	if err != nil {
 		return response, err
 	}
@@ -68,6 +70,7 @@ func getObjectsIHaveSharedInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, user
 		}
 		response.Objects[i] = obj
 	}
+	setNextCursor(&response, pagingRequest)
 	if loadProperties {
 		response = postProcessingFilterOnCustomProperties(response, pagingRequest)
 	}
@@ -40,13 +40,15 @@ func getObjectsSharedToEveryoneInTransaction(dao *DataAccessLayer, tx *sqlx.Tx,
 	query += buildJoinUserToACM(tx, user)
 	query += ` where o.isdeleted = 0 `
 	query += " and (acm2.flattenedacm like '%f_share=' or acm2.flattenedacm like '%f_share=;%')"
-	query += buildFilterSortAndLimit(pagingRequest)
-	err := tx.Select(&response.Objects, query)
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	err := tx.Select(&response.Objects, query, filterArgs...)
 	if err != nil {
 		return response, err
 	}
 	// Paging stats guidance
-	err = tx.Get(&response.TotalRows, queryRowCount(query))
+	countQuery, countArgs := queryRowCountWithoutCursor(query, filterArgs, pagingRequest)
+	err = tx.Get(&response.TotalRows, countQuery, countArgs...)
 	if err != nil {
 		return response, err
 	}
@@ -62,6 +64,7 @@ func getObjectsSharedToEveryoneInTransaction(dao *DataAccessLayer, tx *sqlx.Tx,
 		}
 		response.Objects[i] = obj
 	}
+	setNextCursor(&response, pagingRequest)
 	if loadProperties {
 		response = postProcessingFilterOnCustomProperties(response, pagingRequest)
 	}
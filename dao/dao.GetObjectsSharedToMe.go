@@ -46,13 +46,15 @@ func getObjectsSharedToMeInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, user m
 	query += buildFilterExcludeObjectsIOrMyGroupsOwn(tx, user)
 	// exclude those shared to everyone. for shared to me either explicit to me, or to a group im a member of
 	query += " and (acm2.flattenedacm like '%f_share=%' and acm2.flattenedacm not like '%f_share=;%' and acm2.flattenedacm not like '%f_share=')"
-	query += buildFilterSortAndLimit(pagingRequest)
-	err := tx.Select(&response.Objects, query)
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	err := tx.Select(&response.Objects, query, filterArgs...)
 	if err != nil {
 		return response, err
 	}
 	// Paging stats guidance
-	err = tx.Get(&response.TotalRows, queryRowCount(query))
+	countQuery, countArgs := queryRowCountWithoutCursor(query, filterArgs, pagingRequest)
+	err = tx.Get(&response.TotalRows, countQuery, countArgs...)
 	if err != nil {
 		return response, err
 	}
@@ -68,6 +70,7 @@ func getObjectsSharedToMeInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, user m
 		}
 		response.Objects[i] = obj
 	}
+	setNextCursor(&response, pagingRequest)
 	if loadProperties {
 		response = postProcessingFilterOnCustomProperties(response, pagingRequest)
 	}
@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetPermissionsForKeyRotation returns the next batch of grants, including
+// deleted grants, on objects whose content is stored in a zone, ordered by
+// id and starting after afterID. An empty zone selects the default zone.
+func (dao *DataAccessLayer) GetPermissionsForKeyRotation(zone string, afterID []byte, limit int) ([]models.ODObjectPermission, error) {
+	defer util.Time("GetPermissionsForKeyRotation")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return nil, err
+	}
+	response, err := getPermissionsForKeyRotationInTransaction(tx, zone, afterID, limit)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetPermissionsForKeyRotation", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return response, err
+}
+
+func getPermissionsForKeyRotationInTransaction(tx *sqlx.Tx, zone string, afterID []byte, limit int) ([]models.ODObjectPermission, error) {
+	response := []models.ODObjectPermission{}
+	if afterID == nil {
+		afterID = []byte{}
+	}
+	query := `
+    select 
+        op.id
+        ,op.createdDate
+        ,op.createdBy
+        ,op.modifiedDate
+        ,op.modifiedBy
+        ,op.isDeleted
+        ,op.deletedDate
+        ,op.deletedBy
+        ,op.changeCount
+        ,op.changeToken
+        ,op.objectId
+        ,op.grantee
+        ,op.acmShare
+        ,op.allowCreate
+        ,op.allowRead
+        ,op.allowUpdate
+        ,op.allowDelete
+        ,op.allowShare
+        ,op.explicitShare
+        ,op.encryptKey
+        ,op.permissionIV
+        ,op.permissionMAC
+    from 
+        object_permission op
+        inner join object o on op.objectId = o.id
+    where 
+        op.id > ?`
+	args := []interface{}{afterID}
+	if len(zone) == 0 {
+		query += `
+        and (o.contentConnector is null or locate(':', o.contentConnector) = 0)`
+	} else {
+		query += `
+        and left(o.contentConnector, char_length(?) + 1) = concat(?, ':')`
+		args = append(args, zone, zone)
+	}
+	query += `
+    order by op.id
+    limit ?`
+	args = append(args, limit)
+	err := tx.Select(&response, query, args...)
+	return response, err
+}
@@ -0,0 +1,68 @@
+package dao
+
+import (
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetReferencedContentConnectors returns those of the content connectors given
+// that an object, any revision of an object, or deduplicated content refers to
+func (dao *DataAccessLayer) GetReferencedContentConnectors(contentConnectors []string) ([]string, error) {
+	defer util.Time("GetReferencedContentConnectors")()
+	if len(contentConnectors) == 0 {
+		return nil, nil
+	}
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return nil, err
+	}
+	response, err := getReferencedContentConnectorsInTransaction(tx, contentConnectors)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetReferencedContentConnectors", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return response, err
+}
+
+func getReferencedContentConnectorsInTransaction(tx *sqlx.Tx, contentConnectors []string) ([]string, error) {
+	response := []string{}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(contentConnectors)), ",")
+	var args []interface{}
+	for i := 0; i < 3; i++ {
+		for _, contentConnector := range contentConnectors {
+			args = append(args, contentConnector)
+		}
+	}
+	query := `
+    select contentConnector from object where contentConnector in (` + placeholders + `)
+    union
+    select contentConnector from a_object where contentConnector in (` + placeholders + `)
+    union
+    select contentConnector from content_dedup where contentConnector in (` + placeholders + `)`
+	err := tx.Select(&response, query, args...)
+	return response, err
+}
+
+// ReferencedContentConnectors looks up which content connectors are referred
+// to in the database, for collecting orphaned files
+func ReferencedContentConnectors(d DAO) ciphertext.ReferencedFunc {
+	return func(contentConnectors []string) (map[string]bool, error) {
+		refs, err := d.GetReferencedContentConnectors(contentConnectors)
+		if err != nil {
+			return nil, err
+		}
+		referenced := make(map[string]bool, len(refs))
+		for _, ref := range refs {
+			referenced[ref] = true
+		}
+		return referenced, nil
+	}
+}
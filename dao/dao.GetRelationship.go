@@ -0,0 +1,63 @@
+package dao
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// relationshipColumns is the list of columns selected when retrieving
+// relationships.
+const relationshipColumns = `
+        id
+        ,createdDate
+        ,createdBy
+        ,modifiedDate
+        ,modifiedBy
+        ,isDeleted
+        ,deletedDate
+        ,deletedBy
+        ,changeCount
+        ,changeToken
+        ,sourceId
+        ,targetId
+        ,relationshipType
+        ,description
+        ,classificationPM`
+
+// GetRelationship retrieves a relationship by its identifier.
+func (dao *DataAccessLayer) GetRelationship(relationship models.ODRelationship) (models.ODRelationship, error) {
+	defer util.Time("GetRelationship")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ODRelationship{}, err
+	}
+	dbRelationship, err := getRelationshipInTransaction(tx, relationship)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			dao.GetLogger().Error("Error in GetRelationship", zap.Error(err))
+		}
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbRelationship, err
+}
+
+func getRelationshipInTransaction(tx *sqlx.Tx, relationship models.ODRelationship) (models.ODRelationship, error) {
+	var dbRelationship models.ODRelationship
+	if len(relationship.ID) == 0 {
+		return dbRelationship, ErrMissingID
+	}
+	getRelationshipStatement := `
+    select ` + relationshipColumns + `
+    from relationship
+    where id = ?`
+	err := tx.Get(&dbRelationship, getRelationshipStatement, relationship.ID)
+	return dbRelationship, err
+}
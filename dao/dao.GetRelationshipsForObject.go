@@ -0,0 +1,73 @@
+package dao
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// Directions of relationships relative to an object.
+const (
+	// RelationshipsIn are those for which the object is the target.
+	RelationshipsIn = "in"
+	// RelationshipsOut are those for which the object is the source.
+	RelationshipsOut = "out"
+	// RelationshipsAll are those for which the object is either the source
+	// or the target.
+	RelationshipsAll = ""
+)
+
+// GetRelationshipsForObject retrieves the current relationships of the object
+// in the given direction, most recent first. Access to the object at the
+// other end of each relationship is not checked.
+func (dao *DataAccessLayer) GetRelationshipsForObject(object models.ODObject, direction string) ([]models.ODRelationship, error) {
+	defer util.Time("GetRelationshipsForObject")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return nil, err
+	}
+	relationships, err := getRelationshipsForObjectInTransaction(tx, object, direction)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetRelationshipsForObject", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return relationships, err
+}
+
+func getRelationshipsForObjectInTransaction(tx *sqlx.Tx, object models.ODObject, direction string) ([]models.ODRelationship, error) {
+	var relationships []models.ODRelationship
+	if len(object.ID) == 0 {
+		return relationships, ErrMissingID
+	}
+	var args []interface{}
+	var where string
+	switch direction {
+	case RelationshipsIn:
+		where = "targetid = ?"
+		args = append(args, object.ID)
+	case RelationshipsOut:
+		where = "sourceid = ?"
+		args = append(args, object.ID)
+	case RelationshipsAll:
+		where = "(sourceid = ? or targetid = ?)"
+		args = append(args, object.ID, object.ID)
+	default:
+		return relationships, fmt.Errorf("unsupported relationship direction %s", direction)
+	}
+	query := `
+    select ` + relationshipColumns + `
+    from relationship
+    where 
+        isdeleted = 0 
+        and ` + where + `
+    order by createddate desc`
+	err := tx.Select(&relationships, query, args...)
+	return relationships, err
+}
@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetReleasedContentDedup returns deduplicated content that no object refers
+// to any longer, oldest first, so that it may be removed from storage
+func (dao *DataAccessLayer) GetReleasedContentDedup(limit int) ([]models.ODContentDedup, error) {
+	defer util.Time("GetReleasedContentDedup")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return nil, err
+	}
+	contents, err := getReleasedContentDedupInTransaction(tx, limit)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetReleasedContentDedup", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return contents, err
+}
+
+func getReleasedContentDedupInTransaction(tx *sqlx.Tx, limit int) ([]models.ODContentDedup, error) {
+	var contents []models.ODContentDedup
+	query := `
+    select 
+        contentConnector
+        ,createdDate
+        ,zone
+        ,acmId
+        ,contentHash
+        ,contentSize
+        ,encryptIV
+        ,cipherFormat
+        ,releasedDate
+    from content_dedup
+    where releasedDate is not null
+    order by releasedDate
+    limit ?`
+	err := tx.Select(&contents, query, limit)
+	return contents, err
+}
@@ -37,13 +37,15 @@ func getRootObjectsInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, pagingReques
     from object o 
         inner join object_type ot on o.typeid = ot.id
     where o.isdeleted = 0 and o.parentid is null`
-	query += buildFilterSortAndLimit(pagingRequest)
-	err := tx.Select(&response.Objects, query)
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	err := tx.Select(&response.Objects, query, filterArgs...)
 	if err != nil {
 		return response, err
 	}
 	// Paging stats guidance
-	err = tx.Get(&response.TotalRows, queryRowCount(query))
+	countQuery, countArgs := queryRowCountWithoutCursor(query, filterArgs, pagingRequest)
+	err = tx.Get(&response.TotalRows, countQuery, countArgs...)
 	if err != nil {
 		return response, err
 	}
@@ -59,6 +61,7 @@ func getRootObjectsInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, pagingReques
 		}
 		response.Objects[i] = obj
 	}
+	setNextCursor(&response, pagingRequest)
 	if loadProperties {
 		response = postProcessingFilterOnCustomProperties(response, pagingRequest)
 	}
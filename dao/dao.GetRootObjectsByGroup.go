@@ -45,13 +45,15 @@ func getRootObjectsByGroupInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, group
 	query += `'`
 	query += ` where o.isdeleted = 0 and o.parentid is null `
 	query += buildFilterRequireObjectsGroupOwns(dao, tx, groupGranteeName)
-	query += buildFilterSortAndLimit(pagingRequest)
-	err := tx.Select(&response.Objects, query)
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	err := tx.Select(&response.Objects, query, filterArgs...)
 	if err != nil {
 		return response, err
 	}
 	// Paging stats guidance
-	err = tx.Get(&response.TotalRows, queryRowCount(query))
+	countQuery, countArgs := queryRowCountWithoutCursor(query, filterArgs, pagingRequest)
+	err = tx.Get(&response.TotalRows, countQuery, countArgs...)
 	if err != nil {
 		return response, err
 	}
@@ -67,6 +69,7 @@ func getRootObjectsByGroupInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, group
 		}
 		response.Objects[i] = obj
 	}
+	setNextCursor(&response, pagingRequest)
 	if loadProperties {
 		response = postProcessingFilterOnCustomProperties(response, pagingRequest)
 	}
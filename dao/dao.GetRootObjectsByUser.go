@@ -43,13 +43,15 @@ func getRootObjectsByUserInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, user m
 	query += buildJoinUserToACM(tx, user)
 	query += ` where o.isdeleted = 0 and o.parentid is null `
 	query += buildFilterRequireObjectsIOwn(tx, user)
-	query += buildFilterSortAndLimit(pagingRequest)
-	err := tx.Select(&response.Objects, query)
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	err := tx.Select(&response.Objects, query, filterArgs...)
 	if err != nil {
 		return response, err
 	}
 	// Paging stats guidance
-	err = tx.Get(&response.TotalRows, queryRowCount(query))
+	countQuery, countArgs := queryRowCountWithoutCursor(query, filterArgs, pagingRequest)
+	err = tx.Get(&response.TotalRows, countQuery, countArgs...)
 	if err != nil {
 		return response, err
 	}
@@ -65,6 +67,7 @@ func getRootObjectsByUserInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, user m
 		}
 		response.Objects[i] = obj
 	}
+	setNextCursor(&response, pagingRequest)
 	if loadProperties {
 		response = postProcessingFilterOnCustomProperties(response, pagingRequest)
 	}
@@ -0,0 +1,62 @@
+package dao
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// subscriptionColumns is the list of columns selected when retrieving
+// subscriptions.
+const subscriptionColumns = `
+        id
+        ,createdDate
+        ,createdBy
+        ,modifiedDate
+        ,modifiedBy
+        ,isDeleted
+        ,deletedDate
+        ,deletedBy
+        ,objectId
+        ,onCreate
+        ,onUpdate
+        ,onDelete
+        ,recursive
+        ,callbackUrl`
+
+// GetSubscription retrieves a subscription by its identifier.
+func (dao *DataAccessLayer) GetSubscription(subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error) {
+	defer util.Time("GetSubscription")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ODUserObjectSubscription{}, err
+	}
+	dbSubscription, err := getSubscriptionInTransaction(tx, subscription)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			dao.GetLogger().Error("Error in GetSubscription", zap.Error(err))
+		}
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbSubscription, err
+}
+
+func getSubscriptionInTransaction(tx *sqlx.Tx, subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error) {
+	var dbSubscription models.ODUserObjectSubscription
+	if len(subscription.ID) == 0 {
+		return dbSubscription, ErrMissingID
+	}
+	getSubscriptionStatement := `
+    select ` + subscriptionColumns + `
+    from user_object_subscription
+    where id = ?`
+	err := tx.Get(&dbSubscription, getSubscriptionStatement, subscription.ID)
+	return dbSubscription, err
+}
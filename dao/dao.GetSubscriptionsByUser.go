@@ -0,0 +1,44 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetSubscriptionsByUser retrieves the current subscriptions created by the
+// user, most recent first.
+func (dao *DataAccessLayer) GetSubscriptionsByUser(user models.ODUser) ([]models.ODUserObjectSubscription, error) {
+	defer util.Time("GetSubscriptionsByUser")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return nil, err
+	}
+	subscriptions, err := getSubscriptionsByUserInTransaction(tx, user)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetSubscriptionsByUser", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return subscriptions, err
+}
+
+func getSubscriptionsByUserInTransaction(tx *sqlx.Tx, user models.ODUser) ([]models.ODUserObjectSubscription, error) {
+	var subscriptions []models.ODUserObjectSubscription
+	if len(user.DistinguishedName) == 0 {
+		return subscriptions, ErrMissingUser
+	}
+	query := `
+    select ` + subscriptionColumns + `
+    from user_object_subscription
+    where 
+        isdeleted = 0 
+        and createdby = ?
+    order by createddate desc`
+	err := tx.Select(&subscriptions, query, user.DistinguishedName)
+	return subscriptions, err
+}
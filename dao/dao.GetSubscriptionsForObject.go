@@ -0,0 +1,67 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetSubscriptionsForObject retrieves the current subscriptions that apply to
+// the object. This includes those made directly on the object, as well as
+// recursive subscriptions made on any of its ancestors.
+func (dao *DataAccessLayer) GetSubscriptionsForObject(object models.ODObject) ([]models.ODUserObjectSubscription, error) {
+	defer util.Time("GetSubscriptionsForObject")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return nil, err
+	}
+	subscriptions, err := getSubscriptionsForObjectInTransaction(tx, object)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetSubscriptionsForObject", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return subscriptions, err
+}
+
+func getSubscriptionsForObjectInTransaction(tx *sqlx.Tx, object models.ODObject) ([]models.ODUserObjectSubscription, error) {
+	var subscriptions []models.ODUserObjectSubscription
+	if len(object.ID) == 0 {
+		return subscriptions, ErrMissingID
+	}
+	query := `
+    select ` + subscriptionColumns + `
+    from user_object_subscription
+    where 
+        isdeleted = 0 
+        and objectid = ?`
+	if err := tx.Select(&subscriptions, query, object.ID); err != nil {
+		return subscriptions, err
+	}
+
+	// Walk up the parent chain collecting recursive subscriptions. Visited
+	// identifiers are tracked to guard against a cycle in the hierarchy.
+	visited := map[string]bool{string(object.ID): true}
+	parentID := object.ParentID
+	for len(parentID) > 0 && !visited[string(parentID)] {
+		visited[string(parentID)] = true
+		var ancestorSubscriptions []models.ODUserObjectSubscription
+		if err := tx.Select(&ancestorSubscriptions, query+` and recursive = 1`, parentID); err != nil {
+			return subscriptions, err
+		}
+		subscriptions = append(subscriptions, ancestorSubscriptions...)
+		var parents [][]byte
+		if err := tx.Select(&parents, `select parentid from object where id = ? and parentid is not null`, parentID); err != nil {
+			return subscriptions, err
+		}
+		parentID = nil
+		if len(parents) > 0 {
+			parentID = parents[0]
+		}
+	}
+	return subscriptions, nil
+}
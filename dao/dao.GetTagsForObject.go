@@ -0,0 +1,54 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// GetTagsForObject retrieves the current tags for a given object.
+func (dao *DataAccessLayer) GetTagsForObject(object models.ODObject) ([]models.ODObjectTag, error) {
+	defer util.Time("GetTagsForObject")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return []models.ODObjectTag{}, err
+	}
+	response, err := getTagsForObjectInTransaction(tx, object)
+	if err != nil {
+		dao.GetLogger().Error("Error in GetTagsForObject", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return response, err
+}
+
+func getTagsForObjectInTransaction(tx *sqlx.Tx, object models.ODObject) ([]models.ODObjectTag, error) {
+	response := []models.ODObjectTag{}
+	query := `
+    select
+        id
+        ,createdDate
+        ,createdBy
+        ,modifiedDate
+        ,modifiedBy
+        ,isDeleted
+        ,deletedDate
+        ,deletedBy
+        ,changeCount
+        ,changeToken
+        ,objectId
+        ,name
+    from object_tag
+    where 
+        isdeleted = 0 
+        and objectid = ?
+    order by
+        name asc
+        `
+	err := tx.Select(&response, query, object.ID)
+	return response, err
+}
@@ -39,13 +39,15 @@ func getTrashedObjectsByUserInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, use
 	query += buildJoinUserToACM(tx, user)
 	query += ` where o.isdeleted = 1 and o.isExpunged = 0 and o.isAncestorDeleted = 0 `
 	query += buildFilterRequireObjectsIOrMyGroupsOwn(tx, user)
-	query += buildFilterSortAndLimit(pagingRequest)
-	err = tx.Select(&response.Objects, query)
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	err = tx.Select(&response.Objects, query, filterArgs...)
 	if err != nil {
 		return response, err
 	}
 	// Paging stats guidance
-	err = tx.Get(&response.TotalRows, queryRowCount(query))
+	countQuery, countArgs := queryRowCountWithoutCursor(query, filterArgs, pagingRequest)
+	err = tx.Get(&response.TotalRows, countQuery, countArgs...)
 	if err != nil {
 		return response, err
 	}
@@ -61,6 +63,7 @@ func getTrashedObjectsByUserInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, use
 		}
 		response.Objects[i] = obj
 	}
+	setNextCursor(&response, pagingRequest)
 	if loadProperties {
 		response = postProcessingFilterOnCustomProperties(response, pagingRequest)
 	}
@@ -142,6 +142,29 @@ func setObjectACM2ForObjectInTransaction(tx *sqlx.Tx, dao *DataAccessLayer, obje
 	return acmCreated, nil
 }
 
+// getAcm2IDForRawAcmInTransaction resolves the identifier of the flattened
+// form of a raw ACM. When addIfMissing is false and the ACM has never been
+// assigned to an object, sql.ErrNoRows is returned.
+func getAcm2IDForRawAcmInTransaction(tx *sqlx.Tx, dao *DataAccessLayer, rawAcm string, addIfMissing bool) (int64, error) {
+	acmInterface, err := utils.UnmarshalStringToInterface(rawAcm)
+	if err != nil {
+		return 0, err
+	}
+	acmMap, ok := acmInterface.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("Unable to convert ACM to map")
+	}
+	flattenedACM := getOverallFlattenedACM(acmMap)
+	if !addIfMissing {
+		// Looked up directly, as getAcm2ByNameInTransaction retries when not found
+		var acmID int64
+		err = tx.Get(&acmID, `select id from acm2 where flattenedacm = ?`, flattenedACM)
+		return acmID, err
+	}
+	acm, _, err := getAcm2ByNameInTransaction(tx, dao, flattenedACM, true)
+	return acm.ID, err
+}
+
 func getAcm2ByNameInTransaction(tx *sqlx.Tx, dao *DataAccessLayer, namedValue string, addIfMissing bool) (models.ODAcm2, bool, error) {
 	created := false
 	var result models.ODAcm2
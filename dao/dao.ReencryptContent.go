@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// ReencryptContent points every object, revision and deduplication record
+// that refers to the content stored under contentConnector at the content as
+// re-encrypted, given by the connector, iv and format of the object. Objects
+// are not revised by the change, and keep their changeToken. The content under
+// the previous connector is left in storage for orphaned content collection.
+func (dao *DataAccessLayer) ReencryptContent(contentConnector string, object models.ODObject) error {
+	defer util.Time("ReencryptContent")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return err
+	}
+	err = reencryptContentInTransaction(tx, contentConnector, object)
+	if err != nil {
+		dao.GetLogger().Error("Error in ReencryptContent", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return err
+}
+
+func reencryptContentInTransaction(tx *sqlx.Tx, contentConnector string, object models.ODObject) error {
+	// Marks the session so that the trigger on object does not record a revision
+	if _, err := tx.Exec(`set @odrive_reencrypting = 1`); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+    update object set contentConnector = ?, encryptIV = ?, cipherFormat = ?
+    where contentConnector = ? and cipherFormat = 0`,
+		object.ContentConnector, object.EncryptIV, object.CipherFormat, contentConnector)
+	// The session returns to the pool, so it is unmarked whether or not the update succeeded
+	if _, resetErr := tx.Exec(`set @odrive_reencrypting = null`); err == nil {
+		err = resetErr
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+    update a_object set contentConnector = ?, encryptIV = ?, cipherFormat = ?
+    where contentConnector = ? and cipherFormat = 0`,
+		object.ContentConnector, object.EncryptIV, object.CipherFormat, contentConnector)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+    update content_dedup set contentConnector = ?, encryptIV = ?, cipherFormat = ?
+    where contentConnector = ?`,
+		object.ContentConnector, object.EncryptIV, object.CipherFormat, contentConnector)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`update content_reference set contentConnector = ? where contentConnector = ?`,
+		object.ContentConnector, contentConnector)
+	return err
+}
@@ -0,0 +1,56 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// ReleaseJob returns a job running under the claim it was last claimed with
+// to the queue, keeping its progress, so that another instance may claim it.
+// It returns ErrJobNotRunning once the job has been cancelled or claimed
+// again.
+func (dao *DataAccessLayer) ReleaseJob(job models.ODJob) error {
+	defer util.Time("ReleaseJob")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return err
+	}
+	err = releaseJobInTransaction(tx, job)
+	if err != nil {
+		if err != ErrJobNotRunning {
+			dao.GetLogger().Error("Error in ReleaseJob", zap.Error(err))
+		}
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return err
+}
+
+func releaseJobInTransaction(tx *sqlx.Tx, job models.ODJob) error {
+	if len(job.ID) == 0 {
+		return ErrMissingID
+	}
+	result, err := tx.Exec(`
+    update job set
+        state = ?
+        ,claimedBy = null
+        ,heartbeatDate = null
+        ,modifiedDate = current_timestamp(6)
+    where id = ? and state = ? and claimedBy = ?`,
+		models.JobStateQueued, job.ID, models.JobStateRunning, job.ClaimedBy)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		if err != nil {
+			return err
+		}
+		return ErrJobNotRunning
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// RemoveFavorite marks the user's current favorite for the object as deleted.
+// If the object is not a favorite of the user, this is a no-op.
+func (dao *DataAccessLayer) RemoveFavorite(user models.ODUser, object models.ODObject) error {
+	defer util.Time("RemoveFavorite")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return err
+	}
+	err = removeFavoriteInTransaction(tx, user, object)
+	if err != nil {
+		dao.GetLogger().Error("Error in RemoveFavorite", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return err
+}
+
+func removeFavoriteInTransaction(tx *sqlx.Tx, user models.ODUser, object models.ODObject) error {
+	if len(object.ID) == 0 {
+		return ErrMissingID
+	}
+	if len(user.DistinguishedName) == 0 {
+		return ErrMissingUser
+	}
+	updateFavoriteStatement, err := tx.Preparex(`
+    update user_object_favorite set 
+        modifiedby = ?
+        ,deletedby = ?
+        ,isdeleted = 1 
+    where 
+        isdeleted = 0 
+        and createdby = ? 
+        and objectid = ?`)
+	if err != nil {
+		return err
+	}
+	defer updateFavoriteStatement.Close()
+	_, err = updateFavoriteStatement.Exec(user.DistinguishedName, user.DistinguishedName, user.DistinguishedName, object.ID)
+	return err
+}
@@ -0,0 +1,58 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// RemoveTag marks the current tag matching the name and object of the tag
+// passed in as deleted. If there is no such tag, this is a no-op.
+//    tag.ObjectID must be set to the object the tag is associated with
+//    tag.Name must be set to the name of the tag to be removed
+//    tag.ModifiedBy must be set to the user performing the operation
+func (dao *DataAccessLayer) RemoveTag(tag models.ODObjectTag) error {
+	defer util.Time("RemoveTag")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return err
+	}
+	err = removeTagInTransaction(tx, tag)
+	if err != nil {
+		dao.GetLogger().Error("Error in RemoveTag", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return err
+}
+
+func removeTagInTransaction(tx *sqlx.Tx, tag models.ODObjectTag) error {
+	if len(tag.ObjectID) == 0 {
+		return ErrMissingID
+	}
+	if len(tag.Name) == 0 {
+		return ErrMissingTagName
+	}
+	if len(tag.ModifiedBy) == 0 {
+		return ErrMissingModifiedBy
+	}
+	updateTagStatement, err := tx.Preparex(`
+    update object_tag set 
+        modifiedby = ?
+        ,deletedby = ?
+        ,isdeleted = 1 
+    where 
+        isdeleted = 0 
+        and objectid = ? 
+        and name = ?`)
+	if err != nil {
+		return err
+	}
+	defer updateTagStatement.Close()
+	_, err = updateTagStatement.Exec(tag.ModifiedBy, tag.ModifiedBy, tag.ObjectID, tag.Name)
+	return err
+}
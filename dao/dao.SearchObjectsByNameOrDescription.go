@@ -48,13 +48,15 @@ func searchObjectsByNameOrDescriptionInTransaction(dao *DataAccessLayer, tx *sql
         inner join object_type ot on o.typeid = ot.id `
 	query += buildJoinUserToACM(tx, user)
 	query += ` where o.isdeleted = 0 and o.isexpunged = 0 and o.isancestordeleted = 0`
-	query += buildFilterSortAndLimit(pagingRequest)
-	err := tx.Select(&response.Objects, query)
+	filter, filterArgs := buildFilterSortAndLimit(pagingRequest)
+	query += filter
+	err := tx.Select(&response.Objects, query, filterArgs...)
 	if err != nil {
 		return response, err
 	}
 	// Paging stats guidance
-	err = tx.Get(&response.TotalRows, queryRowCount(query))
+	countQuery, countArgs := queryRowCountWithoutCursor(query, filterArgs, pagingRequest)
+	err = tx.Get(&response.TotalRows, countQuery, countArgs...)
 	if err != nil {
 		return response, err
 	}
@@ -70,6 +72,7 @@ func searchObjectsByNameOrDescriptionInTransaction(dao *DataAccessLayer, tx *sql
 		}
 		response.Objects[i] = obj
 	}
+	setNextCursor(&response, pagingRequest)
 	if loadProperties {
 		response = postProcessingFilterOnCustomProperties(response, pagingRequest)
 	}
@@ -107,28 +110,16 @@ func queryRowCount(query string) string {
 // archive table, call buildOrderByArchive
 func buildOrderBy(pagingRequest PagingRequest) string {
 	sql := ` order by`
-	useDefaultSort := true
-	if len(pagingRequest.SortSettings) > 0 {
-		for _, sortSetting := range pagingRequest.SortSettings {
-			dbField := getDBFieldFromPagingRequestField(sortSetting.SortField)
-			if len(dbField) == 0 {
-				// skip this unrecognized/unhandled field
-				continue
-			}
-			if !useDefaultSort {
-				sql += ","
-			}
-			useDefaultSort = false
-			sql += ` ` + dbField
-			if sortSetting.SortAscending {
-				sql += ` asc`
-			} else {
-				sql += ` desc`
-			}
+	for i, key := range getSortKeys(pagingRequest) {
+		if i > 0 {
+			sql += ","
+		}
+		sql += ` ` + key.dbField
+		if key.ascending {
+			sql += ` asc`
+		} else {
+			sql += ` desc`
 		}
-	}
-	if useDefaultSort {
-		sql += ` o.modifieddate desc`
 	}
 	return sql
 }
@@ -177,71 +168,195 @@ func getDBFieldFromPagingRequestField(fieldName string) string {
 	return ""
 }
 
-func buildFilter(pagingRequest PagingRequest) string {
+// buildFilter builds the where clause portion matching the filter settings
+// and filter tree of the paging request, returning the arguments for its
+// placeholders. Filter settings on custom properties are left for post
+// processing, whereas those in the filter tree are matched in the query.
+func buildFilter(pagingRequest PagingRequest) (string, []interface{}) {
 	out := ``
+	var args []interface{}
 	if len(pagingRequest.FilterSettings) > 0 {
 		matchType := " or "
 		if pagingRequest.FilterMatchType == "and" {
 			matchType = " and "
 		}
+		var conditions []string
 		for _, filterSetting := range pagingRequest.FilterSettings {
-			dbField := getDBFieldFromPagingRequestField(filterSetting.FilterField)
-			if len(dbField) == 0 {
+			condition, conditionArgs := buildFilterSetting(filterSetting, false)
+			if len(condition) == 0 {
 				// unrecognized/unhandled field
 				continue
 			}
+			conditions = append(conditions, condition)
+			args = append(args, conditionArgs...)
+		}
+		if len(conditions) > 0 {
+			out += ` and (` + strings.Join(conditions, matchType) + `)`
+		}
+	}
+	if pagingRequest.FilterTree != nil {
+		condition, conditionArgs := buildFilterGroup(*pagingRequest.FilterTree)
+		if len(condition) > 0 {
+			out += ` and (` + condition + `)`
+			args = append(args, conditionArgs...)
+		}
+	}
+	return out, args
+}
 
-			out += matchType
-			isid := strings.HasSuffix(dbField, "id")
-			if isid {
-				out += ` hex(` + dbField + `) `
-			} else {
-				out += dbField
-			}
-			switch strings.ToLower(filterSetting.Condition) {
-			case "morethan":
-				out += ` > '` + MySQLSafeString(filterSetting.Expression) + `'`
-			case "lessthan":
-				out += ` < '` + MySQLSafeString(filterSetting.Expression) + `'`
-			case "notbegins":
-				out += ` not like '` + MySQLSafeString(filterSetting.Expression) + `%'`
-			case "begins":
-				out += ` like '` + MySQLSafeString(filterSetting.Expression) + `%'`
-			case "notends":
-				out += ` not like '%` + MySQLSafeString(filterSetting.Expression) + `'`
-			case "ends":
-				out += ` like '%` + MySQLSafeString(filterSetting.Expression) + `'`
-			case "notcontains":
-				out += ` not like '%` + MySQLSafeString(filterSetting.Expression) + `%'`
-			case "contains":
-				out += ` like '%` + MySQLSafeString(filterSetting.Expression) + `%'`
-			case "notequals":
-				out += ` not like '` + MySQLSafeString(filterSetting.Expression) + `'`
-			default: // "equals":
-				out += ` like '` + MySQLSafeString(filterSetting.Expression) + `'`
-			}
+// buildFilterGroup builds the condition matching a group of the filter tree,
+// combining its filter settings and nested groups by its match type
+func buildFilterGroup(filterGroup FilterGroup) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for _, filterSetting := range filterGroup.FilterSettings {
+		condition, conditionArgs := buildFilterSetting(filterSetting, true)
+		conditions = append(conditions, condition)
+		args = append(args, conditionArgs...)
+	}
+	for _, group := range filterGroup.Groups {
+		condition, conditionArgs := buildFilterGroup(group)
+		if len(condition) == 0 {
+			continue
 		}
-		if len(out) > 0 {
-			// Replace only the first condition with the group opener and close out the group
-			out = strings.Replace(out, matchType, ` and (`, 1) + `)`
+		conditions = append(conditions, ` (`+condition+`)`)
+		args = append(args, conditionArgs...)
+	}
+	matchType := " or "
+	if filterGroup.MatchType == "and" {
+		matchType = " and "
+	}
+	return strings.Join(conditions, matchType), args
+}
+
+// buildFilterSetting builds the condition matching a single filter setting.
+// Fields that are not columns, tags or content refer to custom properties,
+// which are only matched when requested, and otherwise yield no condition.
+func buildFilterSetting(filterSetting FilterSetting, withProperties bool) (string, []interface{}) {
+	if isTagFilterField(filterSetting.FilterField) {
+		return buildFilterForTag(filterSetting)
+	}
+	if IsContentFilterField(filterSetting.FilterField) {
+		return buildFilterForContent(filterSetting)
+	}
+	dbField := getDBFieldFromPagingRequestField(filterSetting.FilterField)
+	if len(dbField) == 0 {
+		if withProperties {
+			return buildFilterForProperty(filterSetting)
 		}
+		return "", nil
+	}
+	column := dbField
+	if strings.HasSuffix(dbField, "id") {
+		column = `hex(` + dbField + `)`
 	}
-	return out
+	condition, args, err := buildFilterCondition(column, getFilterKind(dbField), filterSetting.Condition, filterSetting.Expression)
+	if err != nil {
+		// expressions are validated by callers, but an invalid one matches nothing
+		return ` 1 = 0`, nil
+	}
+	return ` ` + condition, args
 }
 
-func buildFilterSortAndLimit(pagingRequest PagingRequest) string {
+// escapeLikePattern escapes the wildcards of a like pattern so that the
+// expression is matched literally
+func escapeLikePattern(expression string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(expression)
+}
+
+// isTagFilterField reports whether the filter field refers to the tags
+// associated with an object rather than a column or custom property
+func isTagFilterField(fieldName string) bool {
+	field := strings.ToLower(strings.TrimSpace(fieldName))
+	return field == "tag" || field == "tags"
+}
+
+// buildFilterForTag matches objects having a current tag satisfying the
+// condition. Negated conditions exclude objects having any matching tag.
+func buildFilterForTag(filterSetting FilterSetting) (string, []interface{}) {
+	condition, negated := splitNegatedCondition(filterSetting.Condition)
+	membership := ` in `
+	if negated {
+		membership = ` not in `
+	}
+	comparison, args, err := buildFilterCondition(`tg.name`, filterKindString, condition, filterSetting.Expression)
+	if err != nil {
+		return ` 1 = 0`, nil
+	}
+	return ` o.id` + membership + `(select tg.objectid from object_tag tg where tg.isdeleted = 0 and ` + comparison + `)`, args
+}
+
+// buildFilterForProperty matches objects having a current custom property of
+// the filter field name whose value satisfies the condition. Negated
+// conditions exclude objects having any such property value, so that objects
+// without the property match, as when post processing.
+func buildFilterForProperty(filterSetting FilterSetting) (string, []interface{}) {
+	condition, negated := splitNegatedCondition(filterSetting.Condition)
+	membership := ` in `
+	if negated {
+		membership = ` not in `
+	}
+	kind := inferPropertyFilterKind(condition, filterSetting.Expression)
+	comparison, args, err := buildFilterCondition(propertyValueColumn(kind), kind, condition, filterSetting.Expression)
+	if err != nil {
+		return ` 1 = 0`, nil
+	}
+	return ` o.id` + membership + `(select op.objectid from object_property op inner join property p on op.propertyid = p.id where op.isdeleted = 0 and p.isdeleted = 0 and p.name = ? and ` + comparison + `)`, append([]interface{}{strings.TrimSpace(filterSetting.FilterField)}, args...)
+}
+
+// IsContentFilterField reports whether the filter field refers to the text
+// of the content stream of an object, which is matched by the full text index
+func IsContentFilterField(fieldName string) bool {
+	return strings.ToLower(strings.TrimSpace(fieldName)) == "content"
+}
+
+// buildFilterForContent matches objects among those whose content was found to
+// contain the expression. Negated conditions exclude those objects.
+func buildFilterForContent(filterSetting FilterSetting) (string, []interface{}) {
+	negated := strings.HasPrefix(strings.ToLower(filterSetting.Condition), "not")
+	if len(filterSetting.ObjectIDs) == 0 {
+		if negated {
+			return ` 1 = 1`, nil
+		}
+		return ` 1 = 0`, nil
+	}
+	placeholders := make([]string, len(filterSetting.ObjectIDs))
+	args := make([]interface{}, len(filterSetting.ObjectIDs))
+	for i, id := range filterSetting.ObjectIDs {
+		placeholders[i] = `?`
+		args[i] = id
+	}
+	membership := ` in `
+	if negated {
+		membership = ` not in `
+	}
+	return ` o.id` + membership + `(` + strings.Join(placeholders, `,`) + `)`, args
+}
+
+// buildFilterSortAndLimit builds the where clause portion for filters, and the
+// order by and limit clauses, returning the arguments for the placeholders of
+// the filters
+func buildFilterSortAndLimit(pagingRequest PagingRequest) (string, []interface{}) {
 	limit := GetLimit(pagingRequest.PageNumber, pagingRequest.PageSize)
 	offset := GetOffset(pagingRequest.PageNumber, pagingRequest.PageSize)
 	sqlStatementSuffix := ``
-	sqlStatementSuffix += buildFilter(pagingRequest)
+	filter, args := buildFilter(pagingRequest)
+	sqlStatementSuffix += filter
+	if pagingRequest.Cursor != nil {
+		// The page starts after the cursor rather than at an offset
+		cursorFilter, cursorArgs := buildFilterForCursor(pagingRequest)
+		sqlStatementSuffix += cursorFilter
+		args = append(args, cursorArgs...)
+		offset = 0
+	}
 	sqlStatementSuffix += buildOrderBy(pagingRequest)
 	sqlStatementSuffix += ` limit ` + strconv.Itoa(limit) + ` offset ` + strconv.Itoa(offset)
-	return sqlStatementSuffix
+	return sqlStatementSuffix, args
 }
-func buildFilterSortAndLimitArchive(pagingRequest PagingRequest) string {
-	a := buildFilterSortAndLimit(pagingRequest)
+func buildFilterSortAndLimitArchive(pagingRequest PagingRequest) (string, []interface{}) {
+	a, args := buildFilterSortAndLimit(pagingRequest)
 	a = strings.Replace(a, " o.", " ao.", -1)
-	return a
+	return a, args
 }
 
 func buildJoinUserToACM(tx *sqlx.Tx, user models.ODUser) string {
@@ -265,6 +380,7 @@ func postProcessingFilterOnCustomProperties(response models.ODObjectResultset, p
 		filteredResponse.PageSize = response.PageSize
 		filteredResponse.PageCount = response.PageCount
 		filteredResponse.TotalRows = response.TotalRows
+		filteredResponse.NextCursor = response.NextCursor
 		// check each response
 		for _, responseObject := range response.Objects {
 			// assume we're matched unless invalidated below
@@ -272,13 +388,12 @@ func postProcessingFilterOnCustomProperties(response models.ODObjectResultset, p
 			// check each filter against the request
 			for _, filterSetting := range pagingRequest.FilterSettings {
 				filterField := getDBFieldFromPagingRequestField(filterSetting.FilterField)
-				if len(filterField) != 0 {
+				if len(filterField) != 0 || isTagFilterField(filterSetting.FilterField) || IsContentFilterField(filterSetting.FilterField) {
 					// field was already handled during query against dataset
 					continue
 				}
 				filterField = strings.ToLower(filterSetting.FilterField)
 				condition := strings.ToLower(filterSetting.Condition)
-				checkValue := strings.ToLower(filterSetting.Expression)
 				// track if the property referenced in filter is found
 				propertyFound := false
 				// examine each property
@@ -287,31 +402,7 @@ func postProcessingFilterOnCustomProperties(response models.ODObjectResultset, p
 					if strings.ToLower(property.Name) == filterField {
 						// found
 						propertyFound = true
-						propertyValue := strings.ToLower(property.Value.String)
-						// check condition of the filter. comparisons below are negation checks
-						// for whether it should be removed from the filtered response
-						switch condition {
-						case "morethan":
-							propertiesMatching = propertiesMatching && (propertyValue > checkValue)
-						case "lessthan":
-							propertiesMatching = propertiesMatching && (propertyValue < checkValue)
-						case "notbegins":
-							propertiesMatching = propertiesMatching && !strings.HasPrefix(propertyValue, checkValue)
-						case "begins":
-							propertiesMatching = propertiesMatching && strings.HasPrefix(propertyValue, checkValue)
-						case "notends":
-							propertiesMatching = propertiesMatching && !strings.HasSuffix(propertyValue, checkValue)
-						case "ends":
-							propertiesMatching = propertiesMatching && strings.HasSuffix(propertyValue, checkValue)
-						case "notcontains":
-							propertiesMatching = propertiesMatching && !strings.Contains(propertyValue, checkValue)
-						case "contains":
-							propertiesMatching = propertiesMatching && strings.Contains(propertyValue, checkValue)
-						case "notequals":
-							propertiesMatching = propertiesMatching && !(propertyValue == checkValue)
-						default: // "equals":
-							propertiesMatching = propertiesMatching && (propertyValue == checkValue)
-						}
+						propertiesMatching = propertiesMatching && matchPropertyFilter(condition, property.Value.String, filterSetting.Expression)
 					}
 					if !propertiesMatching {
 						break
@@ -320,7 +411,7 @@ func postProcessingFilterOnCustomProperties(response models.ODObjectResultset, p
 				// If we didn't even find the property referenced, we can only keep if its a negation filter.
 				// For example, object doens't have the property, and filtering on its value not equal
 				if !propertyFound {
-					if !strings.HasPrefix(condition, "not") {
+					if _, negated := splitNegatedCondition(condition); !negated {
 						propertiesMatching = false
 					}
 				}
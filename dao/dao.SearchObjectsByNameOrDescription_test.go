@@ -1,8 +1,10 @@
 package dao_test
 
 import (
+	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -213,3 +215,275 @@ func setupObjectForDAOSearchObjectsTest(name string) models.ODObject {
 	obj.RawAcm.String = ValidACMUnclassified
 	return obj
 }
+
+func TestDAOSearchObjectsContentFilter(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	guid, _ := util.NewGUID()
+	timeSuffix := strconv.FormatInt(time.Now().UTC().Unix(), 10) + guid
+	baseName := "ContentFilter" + timeSuffix
+
+	obj1 := setupObjectForDAOSearchObjectsTest(baseName + " Object 1")
+	objectType, err := d.GetObjectTypeByName(obj1.TypeName.String, true, obj1.CreatedBy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj1.TypeID = objectType.ID
+	dbObject1, err := d.CreateObject(&obj1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj2 := setupObjectForDAOSearchObjectsTest(baseName + " Object 2")
+	obj2.TypeID = objectType.ID
+	if _, err := d.CreateObject(&obj2); err != nil {
+		t.Fatal(err)
+	}
+
+	// The content filter is intersected with the other filters
+	cases := []struct {
+		condition string
+		objectIDs [][]byte
+		expected  int
+	}{
+		{"equals", [][]byte{dbObject1.ID}, 1},
+		{"equals", nil, 0},
+		{"notequals", [][]byte{dbObject1.ID}, 1},
+		{"notequals", nil, 2},
+	}
+	for _, c := range cases {
+		pagingRequest := dao.PagingRequest{FilterSettings: []dao.FilterSetting{
+			dao.FilterSetting{FilterField: "name", Condition: "contains", Expression: baseName},
+			dao.FilterSetting{FilterField: "content", Condition: c.condition, Expression: "phrase", ObjectIDs: c.objectIDs}},
+			FilterMatchType: "and"}
+		searchResults, err := d.SearchObjectsByNameOrDescription(users[1], pagingRequest, false)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if searchResults.TotalRows != c.expected {
+			t.Errorf("%s %d objects: expected %d results from searching, got %d", c.condition, len(c.objectIDs), c.expected, searchResults.TotalRows)
+		}
+	}
+}
+
+func TestDAOSearchObjectsFilterTree(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	guid, _ := util.NewGUID()
+	timeSuffix := strconv.FormatInt(time.Now().UTC().Unix(), 10) + guid
+	baseName := "FilterTree" + timeSuffix
+
+	// Object 1 is named with the phrase, object 2 described with it, and
+	// object 3 is neither but has the property
+	var ids [][]byte
+	for i, name := range []string{baseName + " Object 1", "Object 2", "Object 3"} {
+		obj := setupObjectForDAOSearchObjectsTest(name)
+		obj.Description = models.ToNullString(strconv.Itoa(i))
+		if i == 1 {
+			obj.Description = models.ToNullString(baseName)
+		}
+		if i == 2 {
+			obj.Properties = []models.ODObjectPropertyEx{{Name: "tree" + timeSuffix, Value: models.ToNullString("50% red_" + timeSuffix), ClassificationPM: models.ToNullString("U")}}
+		}
+		objectType, err := d.GetObjectTypeByName(obj.TypeName.String, true, obj.CreatedBy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		obj.TypeID = objectType.ID
+		dbObject, err := d.CreateObject(&obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, dbObject.ID)
+	}
+
+	cases := []struct {
+		description string
+		filterTree  dao.FilterGroup
+		expected    int
+	}{
+		{"type and (name or description)", dao.FilterGroup{MatchType: "and",
+			FilterSettings: []dao.FilterSetting{{FilterField: "typename", Condition: "equals", Expression: "File"}},
+			Groups: []dao.FilterGroup{{MatchType: "or", FilterSettings: []dao.FilterSetting{
+				{FilterField: "name", Condition: "contains", Expression: baseName},
+				{FilterField: "description", Condition: "contains", Expression: baseName}}}}}, 2},
+		{"property or name", dao.FilterGroup{MatchType: "or", FilterSettings: []dao.FilterSetting{
+			{FilterField: "tree" + timeSuffix, Condition: "equals", Expression: "50% red_" + timeSuffix},
+			{FilterField: "name", Condition: "contains", Expression: baseName}}}, 2},
+		{"wildcards are literal", dao.FilterGroup{MatchType: "or", FilterSettings: []dao.FilterSetting{
+			{FilterField: "tree" + timeSuffix, Condition: "equals", Expression: "50_ red%"}}}, 0},
+		{"negated property and name", dao.FilterGroup{MatchType: "and", FilterSettings: []dao.FilterSetting{
+			{FilterField: "tree" + timeSuffix, Condition: "notbegins", Expression: "50%"},
+			{FilterField: "name", Condition: "contains", Expression: baseName}}}, 1},
+	}
+	for _, c := range cases {
+		filterTree := c.filterTree
+		// The flat filters limit results to the objects created
+		pagingRequest := dao.PagingRequest{PageSize: 10, FilterSettings: []dao.FilterSetting{
+			{FilterField: "id", Condition: "equals", Expression: strings.ToUpper(hex.EncodeToString(ids[0]))},
+			{FilterField: "id", Condition: "equals", Expression: strings.ToUpper(hex.EncodeToString(ids[1]))},
+			{FilterField: "id", Condition: "equals", Expression: strings.ToUpper(hex.EncodeToString(ids[2]))}},
+			FilterTree: &filterTree}
+		searchResults, err := d.SearchObjectsByNameOrDescription(users[1], pagingRequest, false)
+		if err != nil {
+			t.Errorf("%s: %v", c.description, err)
+			continue
+		}
+		if searchResults.TotalRows != c.expected {
+			t.Errorf("%s: expected %d results from searching, got %d", c.description, c.expected, searchResults.TotalRows)
+		}
+	}
+}
+
+func TestDAOSearchObjectsCursor(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	guid, _ := util.NewGUID()
+	baseName := "Cursor" + strconv.FormatInt(time.Now().UTC().Unix(), 10) + guid
+	for i := 0; i < 5; i++ {
+		obj := setupObjectForDAOSearchObjectsTest(baseName + " Object " + strconv.Itoa(i%3))
+		objectType, err := d.GetObjectTypeByName(obj.TypeName.String, true, obj.CreatedBy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		obj.TypeID = objectType.ID
+		if _, err := d.CreateObject(&obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pagingRequest := dao.PagingRequest{PageSize: 2,
+		FilterSettings: []dao.FilterSetting{{FilterField: "name", Condition: "begins", Expression: baseName}},
+		SortSettings:   []dao.SortSetting{{SortField: "name", SortAscending: true}}}
+	seen := make(map[string]bool)
+	var pages []int
+	for {
+		searchResults, err := d.SearchObjectsByNameOrDescription(users[1], pagingRequest, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, len(searchResults.Objects))
+		// Totals are of the whole listing, including objects before the cursor
+		if searchResults.TotalRows != 5 || searchResults.PageCount != 3 {
+			t.Errorf("page %d: expected 5 rows over 3 pages, got %d over %d", len(pages), searchResults.TotalRows, searchResults.PageCount)
+		}
+		for _, object := range searchResults.Objects {
+			id := hex.EncodeToString(object.ID)
+			if seen[id] {
+				t.Errorf("object %s returned on more than one page", id)
+			}
+			seen[id] = true
+		}
+		if len(searchResults.NextCursor) == 0 || len(pages) > 5 {
+			break
+		}
+		cursor, err := dao.DecodeCursor(searchResults.NextCursor, pagingRequest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pagingRequest.Cursor = cursor
+		t.Logf("next cursor %s", searchResults.NextCursor)
+
+		// The cursor is only valid for listings sorted the same way
+		resorted := pagingRequest
+		resorted.SortSettings = []dao.SortSetting{{SortField: "createddate", SortAscending: true}}
+		if _, err := dao.DecodeCursor(searchResults.NextCursor, resorted); err != dao.ErrInvalidCursor {
+			t.Errorf("expected cursor to be invalid for a different sort, got %v", err)
+		}
+	}
+	if len(seen) != 5 || len(pages) != 3 {
+		t.Errorf("expected 5 objects over 3 pages, got %d over %v", len(seen), pages)
+	}
+	if _, err := dao.DecodeCursor("not a cursor", pagingRequest); err != dao.ErrInvalidCursor {
+		t.Errorf("expected invalid cursor, got %v", err)
+	}
+}
+
+func TestDAOSearchObjectsFilterConditions(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	guid, _ := util.NewGUID()
+	timeSuffix := strconv.FormatInt(time.Now().UTC().Unix(), 10) + guid
+	baseName := "FilterConditions" + timeSuffix
+
+	// Objects have content sizes of 5, 50 and 500, with a numeric property
+	// on all but the last
+	for i, size := range []int64{5, 50, 500} {
+		obj := setupObjectForDAOSearchObjectsTest(baseName + " Object " + strconv.Itoa(i))
+		obj.ContentSize.Int64 = size
+		obj.ContentSize.Valid = true
+		if i < 2 {
+			obj.Properties = []models.ODObjectPropertyEx{{Name: "pages" + timeSuffix, Value: models.ToNullString(strconv.Itoa(9 + i)), ClassificationPM: models.ToNullString("U")}}
+		}
+		objectType, err := d.GetObjectTypeByName(obj.TypeName.String, true, obj.CreatedBy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		obj.TypeID = objectType.ID
+		if _, err := d.CreateObject(&obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	cases := []struct {
+		filterSetting dao.FilterSetting
+		expected      int
+	}{
+		{dao.FilterSetting{FilterField: "contentsize", Condition: "between", Expression: "6|500"}, 2},
+		{dao.FilterSetting{FilterField: "contentsize", Condition: "morethan", Expression: "9"}, 2},
+		{dao.FilterSetting{FilterField: "contentsize", Condition: "in", Expression: "5|500"}, 2},
+		{dao.FilterSetting{FilterField: "typename", Condition: "in", Expression: "Folder|File"}, 3},
+		{dao.FilterSetting{FilterField: "typename", Condition: "notin", Expression: "File"}, 0},
+		{dao.FilterSetting{FilterField: "createddate", Condition: "withinlast", Expression: "1h"}, 3},
+		{dao.FilterSetting{FilterField: "createddate", Condition: "notwithinlast", Expression: "1h"}, 0},
+		{dao.FilterSetting{FilterField: "modifieddate", Condition: "equals", Expression: today}, 3},
+		{dao.FilterSetting{FilterField: "createddate", Condition: "between", Expression: "2019-01-01|" + today}, 3},
+		{dao.FilterSetting{FilterField: "description", Condition: "isnull"}, 0},
+		{dao.FilterSetting{FilterField: "pages" + timeSuffix, Condition: "morethan", Expression: "9"}, 1},
+		{dao.FilterSetting{FilterField: "pages" + timeSuffix, Condition: "null"}, 1},
+		{dao.FilterSetting{FilterField: "pages" + timeSuffix, Condition: "notnull"}, 2},
+	}
+	for _, c := range cases {
+		if err := dao.ValidateFilterSetting(c.filterSetting); err != nil {
+			t.Errorf("%s %s %s: %v", c.filterSetting.FilterField, c.filterSetting.Condition, c.filterSetting.Expression, err)
+			continue
+		}
+		filterTree := dao.FilterGroup{MatchType: "and", FilterSettings: []dao.FilterSetting{c.filterSetting}}
+		pagingRequest := dao.PagingRequest{PageSize: 10, FilterSettings: []dao.FilterSetting{
+			{FilterField: "name", Condition: "contains", Expression: baseName}},
+			FilterTree: &filterTree}
+		searchResults, err := d.SearchObjectsByNameOrDescription(users[1], pagingRequest, false)
+		if err != nil {
+			t.Errorf("%s %s %s: %v", c.filterSetting.FilterField, c.filterSetting.Condition, c.filterSetting.Expression, err)
+			continue
+		}
+		if searchResults.TotalRows != c.expected {
+			t.Errorf("%s %s %s: expected %d results, got %d", c.filterSetting.FilterField, c.filterSetting.Condition, c.filterSetting.Expression, c.expected, searchResults.TotalRows)
+		}
+	}
+
+	invalid := []dao.FilterSetting{
+		{FilterField: "contentsize", Condition: "morethan", Expression: "large"},
+		{FilterField: "createddate", Condition: "between", Expression: "2019-01-01"},
+		{FilterField: "name", Condition: "withinlast", Expression: "7d"},
+		{FilterField: "modifieddate", Condition: "withinlast", Expression: "a week"},
+	}
+	for _, filterSetting := range invalid {
+		if err := dao.ValidateFilterSetting(filterSetting); err == nil {
+			t.Errorf("%s %s %s: expected filter to be invalid", filterSetting.FilterField, filterSetting.Condition, filterSetting.Expression)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package dao
+
+import (
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// SetObjectTypeProperties replaces the property definitions declared for the
+// object type with those provided. The ModifiedBy field of the object type
+// must be set, and is recorded as the creator of the new definitions.
+func (dao *DataAccessLayer) SetObjectTypeProperties(objectType models.ODObjectType, definitions []models.ODObjectTypeProperty) ([]models.ODObjectTypeProperty, error) {
+	defer util.Time("SetObjectTypeProperties")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return nil, err
+	}
+	dbDefinitions, err := setObjectTypePropertiesInTransaction(tx, objectType, definitions)
+	if err != nil {
+		dao.GetLogger().Error("Error in SetObjectTypeProperties", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbDefinitions, err
+}
+
+func setObjectTypePropertiesInTransaction(tx *sqlx.Tx, objectType models.ODObjectType, definitions []models.ODObjectTypeProperty) ([]models.ODObjectTypeProperty, error) {
+	if len(objectType.ID) == 0 {
+		return nil, ErrMissingID
+	}
+	if len(objectType.ModifiedBy) == 0 {
+		return nil, ErrMissingModifiedBy
+	}
+
+	// Remove the existing definitions and their properties
+	existing, err := getObjectTypePropertiesInTransaction(tx, objectType)
+	if err != nil {
+		return nil, err
+	}
+	for _, definition := range existing {
+		if _, err := tx.Exec(`update object_type_property set modifiedby = ?, isdeleted = 1 where id = ?`, objectType.ModifiedBy, definition.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`update property set modifiedby = ?, isdeleted = 1 where id = ?`, objectType.ModifiedBy, definition.PropertyID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Add the new definitions
+	for _, definition := range definitions {
+		if len(definition.Property.Name) == 0 {
+			return nil, errors.New("property definition is missing a name")
+		}
+		result, err := tx.Exec(`insert property set createdby = ?, name = ?, propertyvalue = ?, classificationpm = ?`,
+			objectType.ModifiedBy, definition.Property.Name, definition.Property.Value.String, definition.Property.ClassificationPM.String)
+		if err != nil {
+			return nil, err
+		}
+		// Cannot use result.LastInsertId() as our identifier is not an autoincremented int
+		if rowCount, _ := result.RowsAffected(); rowCount < 1 {
+			return nil, errors.New("No rows added from inserting property")
+		}
+		var propertyID []byte
+		err = tx.Get(&propertyID, `
+    select id 
+    from property 
+    where 
+        createdby = ? 
+        and name = ? 
+        and isdeleted = 0 
+    order by createddate desc limit 1`, objectType.ModifiedBy, definition.Property.Name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`insert object_type_property set 
+        createdby = ?
+        ,typeid = ?
+        ,propertyid = ?
+        ,valuetype = ?
+        ,isrequired = ?
+        ,allowedvalues = ?`,
+			objectType.ModifiedBy, objectType.ID, propertyID, definition.ValueType, definition.IsRequired, definition.AllowedValues)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return getObjectTypePropertiesInTransaction(tx, objectType)
+}
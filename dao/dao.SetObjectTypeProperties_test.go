@@ -0,0 +1,73 @@
+package dao_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestDAOSetObjectTypeProperties(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip()
+	}
+
+	guid, _ := util.NewGUID()
+	typeName := "Type Properties " + strconv.FormatInt(time.Now().UTC().Unix(), 10) + guid
+	objectType, err := d.GetObjectTypeByName(typeName, true, usernames[1])
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	objectType.ModifiedBy = usernames[1]
+
+	var caseNumber models.ODObjectTypeProperty
+	caseNumber.Property.Name = "caseNumber"
+	caseNumber.ValueType = models.PropertyValueTypeInt
+	caseNumber.IsRequired = true
+	var status models.ODObjectTypeProperty
+	status.Property.Name = "status"
+	status.Property.Value = models.ToNullString("open")
+	status.ValueType = models.PropertyValueTypeEnum
+	status.AllowedValues = models.ToNullString(`["open","closed"]`)
+
+	definitions, err := d.SetObjectTypeProperties(objectType, []models.ODObjectTypeProperty{status, caseNumber})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(definitions) != 2 {
+		t.Errorf("expected 2 definitions, got %d", len(definitions))
+		t.FailNow()
+	}
+	// ordered by name
+	if definitions[0].Property.Name != "caseNumber" || !definitions[0].IsRequired || definitions[0].ValueType != models.PropertyValueTypeInt {
+		t.Errorf("caseNumber definition was not stored as requested")
+	}
+	if definitions[1].Property.Value.String != "open" || definitions[1].AllowedValues.String != `["open","closed"]` {
+		t.Errorf("status definition was not stored as requested")
+	}
+
+	// replacing definitions removes those not given
+	_, err = d.SetObjectTypeProperties(objectType, []models.ODObjectTypeProperty{caseNumber})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	definitions, err = d.GetObjectTypeProperties(objectType)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(definitions) != 1 || definitions[0].Property.Name != "caseNumber" {
+		t.Errorf("expected only caseNumber to remain, got %d definitions", len(definitions))
+	}
+
+	// modifiedBy is required
+	objectType.ModifiedBy = ""
+	if _, err := d.SetObjectTypeProperties(objectType, nil); err == nil {
+		t.Errorf("expected error when modifiedBy not set")
+	}
+}
@@ -90,6 +90,7 @@ func undeleteAncestorChildren(dao *DataAccessLayer, tx *sqlx.Tx, object *models.
         ,o.contentSize
         ,o.contentHash
         ,o.encryptIV
+        ,o.cipherFormat
         ,o.containsUSPersonsData
         ,o.exemptFromFOIA        
         ,ot.name typeName     
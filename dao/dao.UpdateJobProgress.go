@@ -0,0 +1,78 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// UpdateJobProgress records the progress of a job running under the claim it
+// was last claimed with, along with errors for items that could not be
+// processed, and renews the claim. Progress is only recorded while the job is
+// running under the claim, returning ErrJobNotRunning once it has been
+// cancelled or claimed again. A job is finished by giving a state other than
+// running.
+func (dao *DataAccessLayer) UpdateJobProgress(job models.ODJob, jobErrors []models.ODJobError) error {
+	defer util.Time("UpdateJobProgress")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return err
+	}
+	err = updateJobProgressInTransaction(tx, job, jobErrors)
+	if err != nil {
+		if err != ErrJobNotRunning {
+			dao.GetLogger().Error("Error in UpdateJobProgress", zap.Error(err))
+		}
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return err
+}
+
+func updateJobProgressInTransaction(tx *sqlx.Tx, job models.ODJob, jobErrors []models.ODJobError) error {
+	if len(job.ID) == 0 {
+		return ErrMissingID
+	}
+	result, err := tx.Exec(`
+    update job set
+        state = ?
+        ,total = ?
+        ,processed = ?
+        ,succeeded = ?
+        ,failed = ?
+        ,error = ?
+        ,heartbeatDate = current_timestamp(6)
+        ,modifiedDate = current_timestamp(6)
+        ,finishedDate = if(? = ?, null, current_timestamp(6))
+    where id = ? and state = ? and claimedBy = ?`,
+		job.State, job.Total, job.Processed, job.Succeeded, job.Failed, job.Error,
+		job.State, models.JobStateRunning,
+		job.ID, models.JobStateRunning, job.ClaimedBy)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		if err != nil {
+			return err
+		}
+		return ErrJobNotRunning
+	}
+	for _, jobError := range jobErrors {
+		_, err := tx.Exec(`
+    insert job_error set
+        jobId = ?
+        ,objectId = ?
+        ,code = ?
+        ,error = ?
+        ,msg = ?`,
+			job.ID, jobError.ObjectID, jobError.Code, jobError.Error, jobError.Msg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -186,6 +186,7 @@ func updateObjectInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, object *models
         ,contentSize = ?
         ,contentHash = ?
         ,encryptIV = ?
+        ,cipherFormat = ?
         ,containsUSPersonsData = ?
         ,exemptFromFOIA = ?
         ,acmId = ?
@@ -200,7 +201,7 @@ func updateObjectInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, object *models
 		object.Name, object.Description.String, object.ParentID,
 		object.ContentConnector.String, object.RawAcm.String,
 		object.ContentType.String, object.ContentSize, object.ContentHash,
-		object.EncryptIV, object.ContainsUSPersonsData, object.ExemptFromFOIA,
+		object.EncryptIV, object.CipherFormat, object.ContainsUSPersonsData, object.ExemptFromFOIA,
 		object.ACMID,
 		object.ID,
 		object.ChangeToken)
@@ -300,6 +301,12 @@ func updateObjectInTransaction(dao *DataAccessLayer, tx *sqlx.Tx, object *models
 	if err != nil {
 		return acmCreated, fmt.Errorf("updateobject error retrieving object %v, %s", object, err.Error())
 	}
+	if err = addContentReferenceInTransaction(tx, dbObject, object.SharesContent); err != nil {
+		if err == ErrContentReleased {
+			return acmCreated, err
+		}
+		return acmCreated, fmt.Errorf("updateobject error adding content reference, %s", err.Error())
+	}
 	*object = dbObject
 	return acmCreated, nil
 }
@@ -0,0 +1,62 @@
+package dao
+
+import (
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// UpdateSubscription changes the events notified for and callback URL of an
+// existing subscription. The ModifiedBy field of the subscription must be set.
+func (dao *DataAccessLayer) UpdateSubscription(subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error) {
+	defer util.Time("UpdateSubscription")()
+	tx, err := dao.MetadataDB.Beginx()
+	if err != nil {
+		dao.GetLogger().Error("Could not begin transaction", zap.Error(err))
+		return models.ODUserObjectSubscription{}, err
+	}
+	dbSubscription, err := updateSubscriptionInTransaction(tx, subscription)
+	if err != nil {
+		dao.GetLogger().Error("Error in UpdateSubscription", zap.Error(err))
+		tx.Rollback()
+	} else {
+		tx.Commit()
+	}
+	return dbSubscription, err
+}
+
+func updateSubscriptionInTransaction(tx *sqlx.Tx, subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error) {
+	if len(subscription.ID) == 0 {
+		return subscription, ErrMissingID
+	}
+	if len(subscription.ModifiedBy) == 0 {
+		return subscription, ErrMissingModifiedBy
+	}
+	if len(subscription.CallbackURL) == 0 {
+		return subscription, ErrMissingCallbackURL
+	}
+	updateSubscriptionStatement, err := tx.Preparex(`
+    update user_object_subscription set 
+        modifiedby = ?
+        ,oncreate = ?
+        ,onupdate = ?
+        ,ondelete = ?
+        ,recursive = ?
+        ,callbackurl = ?
+    where 
+        id = ? 
+        and isdeleted = 0`)
+	if err != nil {
+		return subscription, err
+	}
+	defer updateSubscriptionStatement.Close()
+	_, err = updateSubscriptionStatement.Exec(subscription.ModifiedBy, subscription.OnCreate,
+		subscription.OnUpdate, subscription.OnDelete, subscription.Recursive, subscription.CallbackURL,
+		subscription.ID)
+	if err != nil {
+		return subscription, err
+	}
+	return getSubscriptionInTransaction(tx, subscription)
+}
@@ -17,33 +17,51 @@ import (
 
 // SchemaVersionsSupported marks compatibility with different schema versions of a previously created database.
 // On startup, we should be checking the schema, and raise some alarm if the schema is out of date, or trigger a migration, etc.
-var SchemaVersionsSupported = strings.Split(config.GetEnvOrDefault("OD_DB_SCHEMAVERSIONS", "20170726,20190225"), ",")
+var SchemaVersionsSupported = strings.Split(config.GetEnvOrDefault("OD_DB_SCHEMAVERSIONS", "20191009"), ",")
 var mutexReadOnly sync.Mutex
 
 // DAO defines the contract our app has with the database.
 type DAO interface {
+	AddContentDedup(object models.ODObject, zone string) error
+	AddFavoriteToObject(user models.ODUser, object models.ODObject) (models.ODUserObjectFavorite, error)
 	AddPermissionToObject(object models.ODObject, permission *models.ODObjectPermission) (models.ODObjectPermission, error)
 	AddPropertyToObject(object models.ODObject, property *models.ODProperty) (models.ODProperty, error)
+	AddRelationship(user models.ODUser, relationship models.ODRelationship) (models.ODRelationship, error)
+	AddSubscription(user models.ODUser, subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error)
+	AddTagToObject(object models.ODObject, tag *models.ODObjectTag) (models.ODObjectTag, error)
+	CancelJob(job models.ODJob) (models.ODJob, error)
+	ClaimJob(claimedBy string, leaseSeconds int64, excluded [][]byte) (models.ODJob, error)
 	AssociateUsersToNewACM(object models.ODObject, done chan bool) error
 	CreateAcmGrantee(acmGrantee models.ODAcmGrantee) (models.ODAcmGrantee, error)
+	CreateJob(job models.ODJob) (models.ODJob, error)
 	CreateObject(object *models.ODObject) (models.ODObject, error)
 	CreateObjectType(objectType *models.ODObjectType) (models.ODObjectType, error)
 	CreateUser(models.ODUser) (models.ODUser, error)
+	DeleteContentDedup(content models.ODContentDedup) error
 	DeleteObject(user models.ODUser, object models.ODObject, explicit bool) error
 	DeleteObjectPermission(objectPermission models.ODObjectPermission) (models.ODObjectPermission, error)
 	DeleteObjectProperty(objectProperty models.ODObjectPropertyEx) error
 	DeleteObjectType(objectType models.ODObjectType) error
+	DeleteRelationship(relationship models.ODRelationship) error
+	DeleteSubscription(subscription models.ODUserObjectSubscription) error
 	ExpungeDeletedByUser(user models.ODUser, pageSize int) (models.ODObjectResultset, error)
 	ExpungeObject(user models.ODUser, object models.ODObject, explicit bool) error
+	FindContentDedup(object models.ODObject, zone string) ([]models.ODContentDedup, error)
 	GetAcmGrantee(grantee string) (models.ODAcmGrantee, error)
 	GetAcmGrantees(grantees []string) ([]models.ODAcmGrantee, error)
 	GetChildObjects(pagingRequest PagingRequest, object models.ODObject) (models.ODObjectResultset, error)
 	GetChildObjectsByUser(user models.ODUser, pagingRequest PagingRequest, object models.ODObject) (models.ODObjectResultset, error)
 	GetChildObjectsWithProperties(pagingRequest PagingRequest, object models.ODObject) (models.ODObjectResultset, error)
 	GetChildObjectsWithPropertiesByUser(user models.ODUser, pagingRequest PagingRequest, object models.ODObject) (models.ODObjectResultset, error)
+	GetContentDedupStats() (models.ContentDedupStats, error)
+	GetContentForReencryption(zone string, afterConnector string, limit int) ([]models.ODObject, error)
+	GetContentForScrub(afterConnector string, limit int) ([]models.ODObject, error)
 	GetDatabase() *sqlx.DB
 	GetDBState() (models.DBState, error)
+	GetFavoriteObjectsByUser(user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error)
+	GetFavoritesForObjects(user models.ODUser, objects []models.ODObject) ([]models.ODUserObjectFavorite, error)
 	GetGroupsForUser(user models.ODUser) (models.GroupSpaceResultset, error)
+	GetJob(job models.ODJob) (models.ODJob, error)
 	GetLogger() *zap.Logger
 	GetObject(object models.ODObject, loadProperties bool) (models.ODObject, error)
 	GetObjectPermission(objectPermission models.ODObjectPermission) (models.ODObjectPermission, error)
@@ -55,17 +73,27 @@ type DAO interface {
 	GetObjectsIHaveShared(user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error)
 	GetObjectsSharedToEveryone(user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error)
 	GetObjectsSharedToMe(user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error)
+	GetObjectTypeProperties(objectType models.ODObjectType) ([]models.ODObjectTypeProperty, error)
 	GetOpenConnectionCount() int
 	GetParents(child models.ODObject) ([]models.ODObject, error)
+	GetPermissionsForKeyRotation(zone string, afterID []byte, limit int) ([]models.ODObjectPermission, error)
 	GetPermissionsForObject(object models.ODObject) ([]models.ODObjectPermission, error)
 	GetPropertiesForObject(object models.ODObject) ([]models.ODObjectPropertyEx, error)
 	GetPropertiesForObjectRevision(object models.ODObject) ([]models.ODObjectPropertyEx, error)
+	GetRelationship(relationship models.ODRelationship) (models.ODRelationship, error)
+	GetRelationshipsForObject(object models.ODObject, direction string) ([]models.ODRelationship, error)
+	GetReferencedContentConnectors(contentConnectors []string) ([]string, error)
+	GetReleasedContentDedup(limit int) ([]models.ODContentDedup, error)
 	GetRootObjects(pagingRequest PagingRequest) (models.ODObjectResultset, error)
 	GetRootObjectsByGroup(groupGranteeName string, user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error)
 	GetRootObjectsByUser(user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error)
 	GetRootObjectsWithProperties(pagingRequest PagingRequest) (models.ODObjectResultset, error)
 	GetRootObjectsWithPropertiesByGroup(groupGranteeName string, user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error)
 	GetRootObjectsWithPropertiesByUser(user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error)
+	GetSubscription(subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error)
+	GetSubscriptionsByUser(user models.ODUser) ([]models.ODUserObjectSubscription, error)
+	GetSubscriptionsForObject(object models.ODObject) ([]models.ODUserObjectSubscription, error)
+	GetTagsForObject(object models.ODObject) ([]models.ODObjectTag, error)
 	GetTrashedObjectsByUser(user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error)
 	GetUserAOCacheByDistinguishedName(user models.ODUser) (models.ODUserAOCache, error)
 	GetUserByDistinguishedName(user models.ODUser) (models.ODUser, error)
@@ -73,12 +101,19 @@ type DAO interface {
 	GetUserStats(dn string) (models.UserStats, error)
 	IsParentIDADescendent(id []byte, parentID []byte) (bool, error)
 	IsReadOnly(refresh bool) bool
+	ReencryptContent(contentConnector string, object models.ODObject) error
 	RebuildUserACMCache(useraocache *models.ODUserAOCache, user models.ODUser, done chan bool, mode string) error
+	ReleaseJob(job models.ODJob) error
+	RemoveFavorite(user models.ODUser, object models.ODObject) error
+	RemoveTag(tag models.ODObjectTag) error
 	SearchObjectsByNameOrDescription(user models.ODUser, pagingRequest PagingRequest, loadProperties bool) (models.ODObjectResultset, error)
+	SetObjectTypeProperties(objectType models.ODObjectType, definitions []models.ODObjectTypeProperty) ([]models.ODObjectTypeProperty, error)
 	SetUserAOCacheByDistinguishedName(useraocache *models.ODUserAOCache, user models.ODUser) error
 	UndeleteObject(object *models.ODObject) (models.ODObject, error)
 	UpdateObject(object *models.ODObject) error
+	UpdateJobProgress(job models.ODJob, jobErrors []models.ODJobError) error
 	UpdatePermission(permission models.ODObjectPermission) error
+	UpdateSubscription(subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error)
 }
 
 // DataAccessLayer is a concrete DAO implementation with a true DB connection.
@@ -9,4 +9,11 @@ var (
 	ErrMissingModifiedBy  = errors.New("object modifiedby was not specified for object being updated")
 	ErrNoRows             = errors.New("sql: no rows in result set")
 	ErrMissingTypeID      = errors.New("missing typeid field")
+	ErrMissingTagName     = errors.New("missing tag name")
+	ErrMissingUser        = errors.New("missing user distinguished name")
+	ErrMissingCallbackURL = errors.New("missing callback url")
+	ErrMissingRelType     = errors.New("missing relationship type")
+	ErrMissingJobType     = errors.New("missing job type")
+	ErrJobNotRunning      = errors.New("job is not running on this instance")
+	ErrContentReleased    = errors.New("shared content has been released")
 )
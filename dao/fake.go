@@ -16,25 +16,45 @@ import (
 // responses for each of the methods that FakeDAO will implement. These fake
 // response fields can be explicitly set, or setup functions can be defined.
 type FakeDAO struct {
-	AcmGrantee          models.ODAcmGrantee
-	AcmGrantees         []models.ODAcmGrantee
-	DBState             models.DBState
-	Err                 error
-	GroupSpaceResultSet models.GroupSpaceResultset
-	IsDescendent        bool
-	Object              models.ODObject
-	ObjectPermission    models.ODObjectPermission
-	ObjectPermissions   []models.ODObjectPermission
-	ObjectProperties    []models.ODObjectPropertyEx
-	ObjectPropertyEx    models.ODObjectPropertyEx
-	ObjectType          models.ODObjectType
-	ObjectResultSet     models.ODObjectResultset
-	Parents             []models.ODObject
-	Property            models.ODProperty
-	User                models.ODUser
-	UserAOCache         models.ODUserAOCache
-	Users               []models.ODUser
-	UserStatsData       models.UserStats
+	AcmGrantee           models.ODAcmGrantee
+	AcmGrantees          []models.ODAcmGrantee
+	DBState              models.DBState
+	Err                  error
+	Favorite             models.ODUserObjectFavorite
+	Favorites            []models.ODUserObjectFavorite
+	GroupSpaceResultSet  models.GroupSpaceResultset
+	IsDescendent         bool
+	Job                  models.ODJob
+	Object               models.ODObject
+	ObjectPermission     models.ODObjectPermission
+	ObjectPermissions    []models.ODObjectPermission
+	ObjectProperties     []models.ODObjectPropertyEx
+	ObjectPropertyEx     models.ODObjectPropertyEx
+	ObjectType           models.ODObjectType
+	ObjectTypeProperties []models.ODObjectTypeProperty
+	ObjectResultSet      models.ODObjectResultset
+	Parents              []models.ODObject
+	Property             models.ODProperty
+	Relationship         models.ODRelationship
+	Relationships        []models.ODRelationship
+	Subscription         models.ODUserObjectSubscription
+	Subscriptions        []models.ODUserObjectSubscription
+	Tag                  models.ODObjectTag
+	Tags                 []models.ODObjectTag
+	User                 models.ODUser
+	UserAOCache          models.ODUserAOCache
+	Users                []models.ODUser
+	UserStatsData        models.UserStats
+}
+
+// AddContentDedup for FakeDAO.
+func (fake *FakeDAO) AddContentDedup(object models.ODObject, zone string) error {
+	return fake.Err
+}
+
+// AddFavoriteToObject for FakeDAO.
+func (fake *FakeDAO) AddFavoriteToObject(user models.ODUser, object models.ODObject) (models.ODUserObjectFavorite, error) {
+	return fake.Favorite, fake.Err
 }
 
 // AddPermissionToObject for FakeDAO.
@@ -47,16 +67,46 @@ func (fake *FakeDAO) AddPropertyToObject(object models.ODObject, property *model
 	return fake.Property, fake.Err
 }
 
+// AddRelationship for FakeDAO.
+func (fake *FakeDAO) AddRelationship(user models.ODUser, relationship models.ODRelationship) (models.ODRelationship, error) {
+	return fake.Relationship, fake.Err
+}
+
+// AddSubscription for FakeDAO.
+func (fake *FakeDAO) AddSubscription(user models.ODUser, subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error) {
+	return fake.Subscription, fake.Err
+}
+
+// AddTagToObject for FakeDAO.
+func (fake *FakeDAO) AddTagToObject(object models.ODObject, tag *models.ODObjectTag) (models.ODObjectTag, error) {
+	return fake.Tag, fake.Err
+}
+
 // AssociateUsersToNewACM for FakeDAO.
 func (fake *FakeDAO) AssociateUsersToNewACM(object models.ODObject, done chan bool) error {
 	return fake.Err
 }
 
+// CancelJob for FakeDAO.
+func (fake *FakeDAO) CancelJob(job models.ODJob) (models.ODJob, error) {
+	return fake.Job, fake.Err
+}
+
+// ClaimJob for FakeDAO.
+func (fake *FakeDAO) ClaimJob(claimedBy string, leaseSeconds int64, excluded [][]byte) (models.ODJob, error) {
+	return fake.Job, fake.Err
+}
+
 // CreateAcmGrantee for FakeDAO.
 func (fake *FakeDAO) CreateAcmGrantee(acmGrantee models.ODAcmGrantee) (models.ODAcmGrantee, error) {
 	return fake.AcmGrantee, fake.Err
 }
 
+// CreateJob for FakeDAO.
+func (fake *FakeDAO) CreateJob(job models.ODJob) (models.ODJob, error) {
+	return fake.Job, fake.Err
+}
+
 // CreateObject for FakeDAO.
 func (fake *FakeDAO) CreateObject(object *models.ODObject) (models.ODObject, error) {
 	return fake.Object, fake.Err
@@ -72,6 +122,11 @@ func (fake *FakeDAO) CreateUser(user models.ODUser) (models.ODUser, error) {
 	return fake.User, fake.Err
 }
 
+// DeleteContentDedup for FakeDAO.
+func (fake *FakeDAO) DeleteContentDedup(content models.ODContentDedup) error {
+	return fake.Err
+}
+
 // DeleteObject for FakeDAO.
 func (fake *FakeDAO) DeleteObject(user models.ODUser, object models.ODObject, explicit bool) error {
 	return fake.Err
@@ -92,6 +147,16 @@ func (fake *FakeDAO) DeleteObjectType(objectType models.ODObjectType) error {
 	return fake.Err
 }
 
+// DeleteRelationship for FakeDAO.
+func (fake *FakeDAO) DeleteRelationship(relationship models.ODRelationship) error {
+	return fake.Err
+}
+
+// DeleteSubscription for FakeDAO.
+func (fake *FakeDAO) DeleteSubscription(subscription models.ODUserObjectSubscription) error {
+	return fake.Err
+}
+
 // ExpungeDeletedByUser for FakeDAO.
 func (fake *FakeDAO) ExpungeDeletedByUser(user models.ODUser, pageSize int) (models.ODObjectResultset, error) {
 	return fake.ObjectResultSet, fake.Err
@@ -102,6 +167,11 @@ func (fake *FakeDAO) ExpungeObject(user models.ODUser, object models.ODObject, e
 	return fake.Err
 }
 
+// FindContentDedup for FakeDAO.
+func (fake *FakeDAO) FindContentDedup(object models.ODObject, zone string) ([]models.ODContentDedup, error) {
+	return nil, fake.Err
+}
+
 // GetAcmGrantee for FakeDAO
 func (fake *FakeDAO) GetAcmGrantee(grantee string) (models.ODAcmGrantee, error) {
 	return fake.AcmGrantee, fake.Err
@@ -135,6 +205,21 @@ func (fake *FakeDAO) GetChildObjectsWithPropertiesByUser(
 	return fake.ObjectResultSet, fake.Err
 }
 
+// GetContentDedupStats for FakeDAO.
+func (fake *FakeDAO) GetContentDedupStats() (models.ContentDedupStats, error) {
+	return models.ContentDedupStats{}, fake.Err
+}
+
+// GetContentForReencryption for FakeDAO.
+func (fake *FakeDAO) GetContentForReencryption(zone string, afterConnector string, limit int) ([]models.ODObject, error) {
+	return fake.ObjectResultSet.Objects, fake.Err
+}
+
+// GetContentForScrub for FakeDAO.
+func (fake *FakeDAO) GetContentForScrub(afterConnector string, limit int) ([]models.ODObject, error) {
+	return fake.ObjectResultSet.Objects, fake.Err
+}
+
 // GetDatabase for FakeDAO
 func (fake *FakeDAO) GetDatabase() *sqlx.DB {
 	return nil
@@ -148,11 +233,26 @@ func (fake *FakeDAO) GetDBState() (models.DBState, error) {
 	return fake.DBState, fake.Err
 }
 
+// GetFavoriteObjectsByUser for FakeDAO.
+func (fake *FakeDAO) GetFavoriteObjectsByUser(user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error) {
+	return fake.ObjectResultSet, fake.Err
+}
+
+// GetFavoritesForObjects for FakeDAO.
+func (fake *FakeDAO) GetFavoritesForObjects(user models.ODUser, objects []models.ODObject) ([]models.ODUserObjectFavorite, error) {
+	return fake.Favorites, fake.Err
+}
+
 // GetGroupsForUser for FakeDAO
 func (fake *FakeDAO) GetGroupsForUser(user models.ODUser) (models.GroupSpaceResultset, error) {
 	return fake.GroupSpaceResultSet, fake.Err
 }
 
+// GetJob for FakeDAO.
+func (fake *FakeDAO) GetJob(job models.ODJob) (models.ODJob, error) {
+	return fake.Job, fake.Err
+}
+
 // GetLogger returns a logger for the current session (or any other context - we want correlation across a request)
 func (fake *FakeDAO) GetLogger() *zap.Logger {
 	return config.RootLogger
@@ -208,11 +308,21 @@ func (fake *FakeDAO) GetObjectsSharedToMe(user models.ODUser, pagingRequest Pagi
 	return fake.ObjectResultSet, fake.Err
 }
 
+// GetObjectTypeProperties for FakeDAO.
+func (fake *FakeDAO) GetObjectTypeProperties(objectType models.ODObjectType) ([]models.ODObjectTypeProperty, error) {
+	return fake.ObjectTypeProperties, fake.Err
+}
+
 // GetParents for FakeDAO
 func (fake *FakeDAO) GetParents(child models.ODObject) ([]models.ODObject, error) {
 	return fake.Parents, fake.Err
 }
 
+// GetPermissionsForKeyRotation for FakeDAO.
+func (fake *FakeDAO) GetPermissionsForKeyRotation(zone string, afterID []byte, limit int) ([]models.ODObjectPermission, error) {
+	return fake.ObjectPermissions, fake.Err
+}
+
 // GetPermissionsForObject for FakeDAO.
 func (fake *FakeDAO) GetPermissionsForObject(object models.ODObject) ([]models.ODObjectPermission, error) {
 	return fake.ObjectPermissions, fake.Err
@@ -228,6 +338,26 @@ func (fake *FakeDAO) GetPropertiesForObjectRevision(object models.ODObject) ([]m
 	return fake.ObjectProperties, nil
 }
 
+// GetRelationship for FakeDAO.
+func (fake *FakeDAO) GetRelationship(relationship models.ODRelationship) (models.ODRelationship, error) {
+	return fake.Relationship, fake.Err
+}
+
+// GetRelationshipsForObject for FakeDAO.
+func (fake *FakeDAO) GetRelationshipsForObject(object models.ODObject, direction string) ([]models.ODRelationship, error) {
+	return fake.Relationships, fake.Err
+}
+
+// GetReferencedContentConnectors for FakeDAO. Every content connector is referenced.
+func (fake *FakeDAO) GetReferencedContentConnectors(contentConnectors []string) ([]string, error) {
+	return contentConnectors, fake.Err
+}
+
+// GetReleasedContentDedup for FakeDAO.
+func (fake *FakeDAO) GetReleasedContentDedup(limit int) ([]models.ODContentDedup, error) {
+	return nil, fake.Err
+}
+
 // GetRootObjects for FakeDAO.
 func (fake *FakeDAO) GetRootObjects(pagingRequest PagingRequest) (models.ODObjectResultset, error) {
 	return fake.ObjectResultSet, fake.Err
@@ -263,6 +393,26 @@ func (fake *FakeDAO) GetOpenConnectionCount() int {
 	return 0
 }
 
+// GetSubscription for FakeDAO.
+func (fake *FakeDAO) GetSubscription(subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error) {
+	return fake.Subscription, fake.Err
+}
+
+// GetSubscriptionsByUser for FakeDAO.
+func (fake *FakeDAO) GetSubscriptionsByUser(user models.ODUser) ([]models.ODUserObjectSubscription, error) {
+	return fake.Subscriptions, fake.Err
+}
+
+// GetSubscriptionsForObject for FakeDAO.
+func (fake *FakeDAO) GetSubscriptionsForObject(object models.ODObject) ([]models.ODUserObjectSubscription, error) {
+	return fake.Subscriptions, fake.Err
+}
+
+// GetTagsForObject for FakeDAO.
+func (fake *FakeDAO) GetTagsForObject(object models.ODObject) ([]models.ODObjectTag, error) {
+	return fake.Tags, fake.Err
+}
+
 // GetTrashedObjectsByUser for FakeDAO.
 func (fake *FakeDAO) GetTrashedObjectsByUser(user models.ODUser, pagingRequest PagingRequest) (models.ODObjectResultset, error) {
 	return fake.ObjectResultSet, fake.Err
@@ -309,11 +459,36 @@ func (fake *FakeDAO) RebuildUserACMCache(useraocache *models.ODUserAOCache, user
 	return fake.Err
 }
 
+// ReencryptContent for FakeDAO.
+func (fake *FakeDAO) ReencryptContent(contentConnector string, object models.ODObject) error {
+	return fake.Err
+}
+
+// ReleaseJob for FakeDAO.
+func (fake *FakeDAO) ReleaseJob(job models.ODJob) error {
+	return fake.Err
+}
+
+// RemoveFavorite for FakeDAO.
+func (fake *FakeDAO) RemoveFavorite(user models.ODUser, object models.ODObject) error {
+	return fake.Err
+}
+
+// RemoveTag for FakeDAO.
+func (fake *FakeDAO) RemoveTag(tag models.ODObjectTag) error {
+	return fake.Err
+}
+
 // SearchObjectsByNameOrDescription for FakeDAO
 func (fake *FakeDAO) SearchObjectsByNameOrDescription(user models.ODUser, pagingRequest PagingRequest, loadProperties bool) (models.ODObjectResultset, error) {
 	return fake.ObjectResultSet, fake.Err
 }
 
+// SetObjectTypeProperties for FakeDAO.
+func (fake *FakeDAO) SetObjectTypeProperties(objectType models.ODObjectType, definitions []models.ODObjectTypeProperty) ([]models.ODObjectTypeProperty, error) {
+	return fake.ObjectTypeProperties, fake.Err
+}
+
 // SetUserAOCacheByDistinguishedName for FakeDAO
 func (fake *FakeDAO) SetUserAOCacheByDistinguishedName(useraocache *models.ODUserAOCache, user models.ODUser) error {
 	return fake.Err
@@ -329,6 +504,11 @@ func (fake *FakeDAO) UpdateObject(object *models.ODObject) error {
 	return fake.Err
 }
 
+// UpdateJobProgress for FakeDAO.
+func (fake *FakeDAO) UpdateJobProgress(job models.ODJob, jobErrors []models.ODJobError) error {
+	return fake.Err
+}
+
 // UpdatePermission for FakeDAO.
 func (fake *FakeDAO) UpdatePermission(permission models.ODObjectPermission) error {
 	return fake.Err
@@ -342,3 +522,8 @@ func (fake *FakeDAO) clearError() {
 func fakeCompileCheck() DAO {
 	return &FakeDAO{}
 }
+
+// UpdateSubscription for FakeDAO.
+func (fake *FakeDAO) UpdateSubscription(subscription models.ODUserObjectSubscription) (models.ODUserObjectSubscription, error) {
+	return fake.Subscription, fake.Err
+}
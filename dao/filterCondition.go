@@ -0,0 +1,439 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of values compared by filter conditions
+const (
+	filterKindString = iota
+	filterKindNumber
+	filterKindDate
+)
+
+const (
+	// filterValueDelimiter separates the values of the expression of a
+	// between or in condition
+	filterValueDelimiter = "|"
+	// maxFilterValues bounds the values of the expression of an in condition
+	maxFilterValues = 1000
+)
+
+// filterDateLayouts are the layouts accepted for dates in filter expressions.
+// A date without a time refers to the whole day in UTC.
+var filterDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// relativeDurationPattern matches durations such as 7d for the withinlast
+// condition, in seconds, minutes, hours, days or weeks
+var relativeDurationPattern = regexp.MustCompile(`^(\d+)\s*([smhdw])$`)
+
+// getFilterKind returns the kind of values held in a database field, which
+// determines how expressions compared to it are converted
+func getFilterKind(dbField string) int {
+	switch dbField {
+	case "o.changecount", "o.contentsize":
+		return filterKindNumber
+	case "o.createddate", "o.modifieddate":
+		return filterKindDate
+	}
+	return filterKindString
+}
+
+// normalizeFilterCondition returns the condition in lower case, with aliases
+// replaced by the condition they refer to
+func normalizeFilterCondition(condition string) string {
+	condition = strings.ToLower(strings.TrimSpace(condition))
+	switch condition {
+	case "isnull":
+		return "null"
+	case "isnotnull":
+		return "notnull"
+	}
+	return condition
+}
+
+// splitNegatedCondition returns the condition matching the values whose
+// presence is required, or excluded when negated. This is used for tags and
+// custom properties, where objects without any value match negated
+// conditions. The null condition is the negation of notnull.
+func splitNegatedCondition(condition string) (string, bool) {
+	condition = normalizeFilterCondition(condition)
+	switch condition {
+	case "null":
+		return "notnull", true
+	case "notnull":
+		return "notnull", false
+	}
+	if strings.HasPrefix(condition, "not") {
+		return strings.TrimPrefix(condition, "not"), true
+	}
+	return condition, false
+}
+
+// splitFilterValues returns the values of the expression of a between or in
+// condition
+func splitFilterValues(expression string) []string {
+	values := strings.Split(expression, filterValueDelimiter)
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return values
+}
+
+// parseFilterValue converts a value of an expression to the kind compared,
+// also reporting whether a date has no time.
+func parseFilterValue(kind int, value string) (interface{}, bool, error) {
+	switch kind {
+	case filterKindNumber:
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("%q is not a number", value)
+		}
+		return n, false, nil
+	case filterKindDate:
+		value = strings.TrimSpace(value)
+		for _, layout := range filterDateLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t.UTC(), layout == "2006-01-02", nil
+			}
+		}
+		return nil, false, fmt.Errorf("%q is not a date such as 2019-10-01 or 2019-10-01T12:00:00Z", value)
+	}
+	return value, false, nil
+}
+
+// parseRelativeDuration parses the expression of a withinlast condition, such
+// as 30m, 12h, 7d or 2w
+func parseRelativeDuration(expression string) (time.Duration, error) {
+	match := relativeDurationPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(expression)))
+	if match == nil {
+		return 0, fmt.Errorf("%q is not a duration such as 12h, 7d or 2w", expression)
+	}
+	n, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration such as 12h, 7d or 2w", expression)
+	}
+	unit := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}[match[2]]
+	return time.Duration(n) * unit, nil
+}
+
+// inferPropertyFilterKind returns the kind of values that custom property
+// values are converted to for a condition. Property values are text, but are
+// compared as numbers or dates by ordering conditions whose expression values
+// are all numbers or dates.
+func inferPropertyFilterKind(condition string, expression string) int {
+	positive, _ := splitNegatedCondition(condition)
+	switch positive {
+	case "withinlast":
+		return filterKindDate
+	case "morethan", "lessthan", "between":
+	default:
+		return filterKindString
+	}
+	values := []string{expression}
+	if positive == "between" {
+		values = splitFilterValues(expression)
+	}
+	for _, kind := range []int{filterKindNumber, filterKindDate} {
+		matches := true
+		for _, value := range values {
+			if _, _, err := parseFilterValue(kind, value); err != nil {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return kind
+		}
+	}
+	return filterKindString
+}
+
+// buildFilterCondition builds the comparison of the column to the expression
+// for a condition, returning the arguments for its placeholders. Values of
+// the expression are converted to the kind of the column. Unrecognized
+// conditions compare for equality.
+func buildFilterCondition(column string, kind int, condition string, expression string) (string, []interface{}, error) {
+	switch condition = normalizeFilterCondition(condition); condition {
+	case "null":
+		return column + ` is null`, nil, nil
+	case "notnull":
+		return column + ` is not null`, nil, nil
+	case "withinlast", "notwithinlast":
+		if kind != filterKindDate {
+			return "", nil, errors.New("withinlast may only be used with dates")
+		}
+		duration, err := parseRelativeDuration(expression)
+		if err != nil {
+			return "", nil, err
+		}
+		comparison := ` >= `
+		if condition == "notwithinlast" {
+			comparison = ` < `
+		}
+		return column + comparison + `date_sub(current_timestamp(6), interval ? microsecond)`, []interface{}{int64(duration / time.Microsecond)}, nil
+	case "between", "notbetween":
+		values := splitFilterValues(expression)
+		if len(values) != 2 {
+			return "", nil, fmt.Errorf("between requires two values delimited by %s", filterValueDelimiter)
+		}
+		low, _, err := parseFilterValue(kind, values[0])
+		if err != nil {
+			return "", nil, err
+		}
+		high, dateOnly, err := parseFilterValue(kind, values[1])
+		if err != nil {
+			return "", nil, err
+		}
+		out := `(` + column + ` >= ? and ` + column + ` <= ?)`
+		if dateOnly {
+			// The range includes the whole of the last day
+			out = `(` + column + ` >= ? and ` + column + ` < ?)`
+			high = high.(time.Time).AddDate(0, 0, 1)
+		}
+		if condition == "notbetween" {
+			out = `not ` + out
+		}
+		return out, []interface{}{low, high}, nil
+	case "in", "notin":
+		values := splitFilterValues(expression)
+		if len(values) > maxFilterValues {
+			return "", nil, fmt.Errorf("in may have at most %d values", maxFilterValues)
+		}
+		placeholders := make([]string, len(values))
+		args := make([]interface{}, len(values))
+		for i, value := range values {
+			arg, _, err := parseFilterValue(kind, value)
+			if err != nil {
+				return "", nil, err
+			}
+			placeholders[i] = `?`
+			args[i] = arg
+		}
+		membership := ` in `
+		if condition == "notin" {
+			membership = ` not in `
+		}
+		return column + membership + `(` + strings.Join(placeholders, `,`) + `)`, args, nil
+	case "morethan", "lessthan":
+		comparison := ` > ?`
+		if condition == "lessthan" {
+			comparison = ` < ?`
+		}
+		arg, _, err := parseFilterValue(kind, expression)
+		if err != nil {
+			return "", nil, err
+		}
+		return column + comparison, []interface{}{arg}, nil
+	case "notbegins":
+		return column + ` not like ?`, []interface{}{escapeLikePattern(expression) + `%`}, nil
+	case "begins":
+		return column + ` like ?`, []interface{}{escapeLikePattern(expression) + `%`}, nil
+	case "notends":
+		return column + ` not like ?`, []interface{}{`%` + escapeLikePattern(expression)}, nil
+	case "ends":
+		return column + ` like ?`, []interface{}{`%` + escapeLikePattern(expression)}, nil
+	case "notcontains":
+		return column + ` not like ?`, []interface{}{`%` + escapeLikePattern(expression) + `%`}, nil
+	case "contains":
+		return column + ` like ?`, []interface{}{`%` + escapeLikePattern(expression) + `%`}, nil
+	}
+	// equals and notequals
+	negated := condition == "notequals"
+	if kind == filterKindString {
+		if negated {
+			return column + ` not like ?`, []interface{}{escapeLikePattern(expression)}, nil
+		}
+		return column + ` like ?`, []interface{}{escapeLikePattern(expression)}, nil
+	}
+	arg, dateOnly, err := parseFilterValue(kind, expression)
+	if err != nil {
+		return "", nil, err
+	}
+	if dateOnly {
+		// A date matches the whole day
+		out := `(` + column + ` >= ? and ` + column + ` < ?)`
+		if negated {
+			out = `not ` + out
+		}
+		return out, []interface{}{arg, arg.(time.Time).AddDate(0, 0, 1)}, nil
+	}
+	if negated {
+		return column + ` <> ?`, []interface{}{arg}, nil
+	}
+	return column + ` = ?`, []interface{}{arg}, nil
+}
+
+// propertyValueColumn returns the expression converting custom property
+// values to the kind compared
+func propertyValueColumn(kind int) string {
+	switch kind {
+	case filterKindNumber:
+		return `cast(p.propertyvalue as decimal(65,10))`
+	case filterKindDate:
+		return `cast(p.propertyvalue as datetime(6))`
+	}
+	return `p.propertyvalue`
+}
+
+// ValidateFilterSetting reports whether the expression of a filter setting
+// is valid for its field and condition, such as a date for a date range.
+func ValidateFilterSetting(filterSetting FilterSetting) error {
+	if IsContentFilterField(filterSetting.FilterField) {
+		return nil
+	}
+	kind := filterKindString
+	condition := filterSetting.Condition
+	switch dbField := getDBFieldFromPagingRequestField(filterSetting.FilterField); {
+	case isTagFilterField(filterSetting.FilterField):
+		condition, _ = splitNegatedCondition(condition)
+	case len(dbField) == 0:
+		condition, _ = splitNegatedCondition(condition)
+		kind = inferPropertyFilterKind(condition, filterSetting.Expression)
+	default:
+		kind = getFilterKind(dbField)
+	}
+	if _, _, err := buildFilterCondition("", kind, condition, filterSetting.Expression); err != nil {
+		return fmt.Errorf("filter on %s is not valid: %v", filterSetting.FilterField, err)
+	}
+	return nil
+}
+
+// ValidateFilters validates the filter settings of the paging request,
+// including those of the filter tree
+func ValidateFilters(pagingRequest PagingRequest) error {
+	for _, filterSetting := range pagingRequest.FilterSettings {
+		if err := ValidateFilterSetting(filterSetting); err != nil {
+			return err
+		}
+	}
+	if pagingRequest.FilterTree != nil {
+		return validateFilterGroup(*pagingRequest.FilterTree)
+	}
+	return nil
+}
+
+func validateFilterGroup(filterGroup FilterGroup) error {
+	for _, filterSetting := range filterGroup.FilterSettings {
+		if err := ValidateFilterSetting(filterSetting); err != nil {
+			return err
+		}
+	}
+	for _, group := range filterGroup.Groups {
+		if err := validateFilterGroup(group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchPropertyFilter reports whether a custom property value, which is
+// present, satisfies a condition, comparing as the SQL built for the
+// condition would. Text is compared without regard to case.
+func matchPropertyFilter(condition string, propertyValue string, expression string) bool {
+	condition = normalizeFilterCondition(condition)
+	kind := inferPropertyFilterKind(condition, expression)
+	value := strings.ToLower(propertyValue)
+	checkValue := strings.ToLower(expression)
+	switch condition {
+	case "null":
+		return false
+	case "notnull":
+		return true
+	case "withinlast", "notwithinlast":
+		duration, err := parseRelativeDuration(expression)
+		t, _, perr := parseFilterValue(filterKindDate, propertyValue)
+		if err != nil || perr != nil {
+			return false
+		}
+		within := !t.(time.Time).Before(time.Now().Add(-duration))
+		return within == (condition == "withinlast")
+	case "between", "notbetween":
+		values := splitFilterValues(checkValue)
+		if len(values) != 2 {
+			return false
+		}
+		between := compareFilterValues(kind, value, values[0]) >= 0 && compareFilterValues(kind, value, values[1]) <= 0
+		if high, dateOnly, err := parseFilterValue(kind, values[1]); err == nil && dateOnly {
+			// The range includes the whole of the last day
+			nextDay := high.(time.Time).AddDate(0, 0, 1).Format(time.RFC3339Nano)
+			between = compareFilterValues(kind, value, values[0]) >= 0 && compareFilterValues(kind, value, nextDay) < 0
+		}
+		return between == (condition == "between")
+	case "in", "notin":
+		in := false
+		for _, v := range splitFilterValues(checkValue) {
+			in = in || v == value
+		}
+		return in == (condition == "in")
+	case "morethan":
+		return compareFilterValues(kind, value, checkValue) > 0
+	case "lessthan":
+		return compareFilterValues(kind, value, checkValue) < 0
+	case "notbegins":
+		return !strings.HasPrefix(value, checkValue)
+	case "begins":
+		return strings.HasPrefix(value, checkValue)
+	case "notends":
+		return !strings.HasSuffix(value, checkValue)
+	case "ends":
+		return strings.HasSuffix(value, checkValue)
+	case "notcontains":
+		return !strings.Contains(value, checkValue)
+	case "contains":
+		return strings.Contains(value, checkValue)
+	case "notequals":
+		return value != checkValue
+	}
+	return value == checkValue
+}
+
+// compareFilterValues compares two values of a kind, returning -1, 0 or 1.
+// Values that cannot be converted compare as less than any other.
+func compareFilterValues(kind int, a string, b string) int {
+	switch kind {
+	case filterKindNumber, filterKindDate:
+		av, _, aerr := parseFilterValue(kind, a)
+		bv, _, berr := parseFilterValue(kind, b)
+		switch {
+		case aerr != nil && berr != nil:
+			return 0
+		case aerr != nil:
+			return -1
+		case berr != nil:
+			return 1
+		}
+		if kind == filterKindNumber {
+			return compareFloats(av.(float64), bv.(float64))
+		}
+		at, bt := av.(time.Time), bv.(time.Time)
+		switch {
+		case at.Before(bt):
+			return -1
+		case at.After(bt):
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+func compareFloats(a float64, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -58,6 +58,10 @@ type PagingRequest struct {
 	SortSettings []SortSetting
 	// FilterMatchType indicates the kind of matching performed when multiple filters are provided.
 	FilterMatchType string
+	// FilterTree is an optional group of filters and nested groups that results must also match
+	FilterTree *FilterGroup
+	// Cursor is an optional position from which results are returned, instead of the page number
+	Cursor *Cursor
 }
 
 // FilterSetting denotes a field and a condition to match an expression on which to filter results
@@ -65,6 +69,17 @@ type FilterSetting struct {
 	FilterField string
 	Condition   string
 	Expression  string
+	// ObjectIDs are the objects whose content matches the expression of a
+	// content filter, as resolved by the full text index
+	ObjectIDs [][]byte
+}
+
+// FilterGroup denotes filter settings and nested groups of filters whose
+// matches are combined by the match type, either and or or
+type FilterGroup struct {
+	MatchType      string
+	FilterSettings []FilterSetting
+	Groups         []FilterGroup
 }
 
 // SortSetting denotes a field and a preferred direction on which to sort results.
@@ -0,0 +1,10 @@
+/*
+Package fulltext provides extraction of text from object content streams, and
+an inverted index of that text stored on local disk.
+
+Text is extracted from plain text, HTML, Office Open XML documents, and the
+text layer of PDF documents. The index supports finding objects by words and
+phrases occurring in their content, and holds terms only as an HMAC so that
+the text is not written to disk.
+*/
+package fulltext
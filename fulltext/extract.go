@@ -0,0 +1,243 @@
+package fulltext
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ErrUnsupportedContentType indicates that text cannot be extracted from
+// content of the type given
+var ErrUnsupportedContentType = errors.New("text cannot be extracted from content of this type")
+
+// maxPartSize bounds the uncompressed size of each part of an Office document
+// or PDF stream that is read, to guard against decompression bombs
+const maxPartSize = 64 << 20
+
+// Extract returns the text of content of the given type. If the content type
+// is missing or generic, the extension of the name is used to determine the
+// type. Content is given whole, as Office documents are zip archives whose
+// parts are read out of order.
+func Extract(contentType string, name string, content []byte) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "application/octet-stream" {
+		mediaType = mime.TypeByExtension(strings.ToLower(path.Ext(name)))
+		if mediaType, _, err = mime.ParseMediaType(mediaType); err != nil {
+			mediaType = ""
+		}
+	}
+	if len(mediaType) == 0 {
+		switch strings.ToLower(path.Ext(name)) {
+		case ".docx", ".pptx", ".xlsx":
+			mediaType = "application/vnd.openxmlformats-officedocument"
+		case ".pdf":
+			mediaType = "application/pdf"
+		}
+	}
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return extractHTML(content), nil
+	case mediaType == "text/xml" || mediaType == "application/xml":
+		return extractXML(content), nil
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json":
+		return strings.ToValidUTF8(string(content), " "), nil
+	case strings.HasPrefix(mediaType, "application/vnd.openxmlformats-officedocument"):
+		return extractOfficeXML(content)
+	case mediaType == "application/pdf":
+		return extractPDF(content), nil
+	}
+	return "", ErrUnsupportedContentType
+}
+
+// extractHTML returns the text of an HTML document, excluding scripts and
+// styles. Markup that cannot be parsed ends extraction, returning the text
+// found before it.
+func extractHTML(content []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	var text strings.Builder
+	skipping := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if name := strings.ToLower(t.Name.Local); name == "script" || name == "style" {
+				skipping++
+			}
+		case xml.EndElement:
+			if name := strings.ToLower(t.Name.Local); (name == "script" || name == "style") && skipping > 0 {
+				skipping--
+			}
+			text.WriteString(" ")
+		case xml.CharData:
+			if skipping == 0 {
+				text.Write(t)
+			}
+		}
+	}
+	return strings.ToValidUTF8(text.String(), " ")
+}
+
+// extractXML returns the character data of an XML document
+func extractXML(content []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.EndElement:
+			text.WriteString(" ")
+		case xml.CharData:
+			text.Write(t)
+		}
+	}
+	return strings.ToValidUTF8(text.String(), " ")
+}
+
+// isOfficeTextPart reports whether a part of an Office Open XML document
+// holds text of a Word document, PowerPoint slide or Excel workbook
+func isOfficeTextPart(name string) bool {
+	switch {
+	case name == "word/document.xml", name == "word/footnotes.xml", name == "word/endnotes.xml":
+		return true
+	case strings.HasPrefix(name, "word/header"), strings.HasPrefix(name, "word/footer"):
+		return true
+	case strings.HasPrefix(name, "ppt/slides/slide"), strings.HasPrefix(name, "ppt/notesSlides/notesSlide"):
+		return true
+	case name == "xl/sharedStrings.xml", strings.HasPrefix(name, "xl/worksheets/sheet"):
+		return true
+	}
+	return false
+}
+
+// extractOfficeXML returns the text of Word documents, PowerPoint
+// presentations and Excel workbooks in Office Open XML format. Text runs are
+// held in elements named t in each of these formats.
+func extractOfficeXML(content []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", err
+	}
+	var parts []*zip.File
+	for _, f := range archive.File {
+		if strings.HasSuffix(f.Name, ".xml") && isOfficeTextPart(f.Name) {
+			parts = append(parts, f)
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Name < parts[j].Name })
+	var text strings.Builder
+	for _, part := range parts {
+		r, err := part.Open()
+		if err != nil {
+			return text.String(), err
+		}
+		decoder := xml.NewDecoder(io.LimitReader(r, maxPartSize))
+		inText := false
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				inText = t.Name.Local == "t"
+			case xml.EndElement:
+				// Runs of a paragraph may split words, so only paragraphs, cells
+				// and rows are separated
+				switch t.Name.Local {
+				case "p", "si", "c", "row", "tab", "br":
+					text.WriteString(" ")
+				}
+				inText = false
+			case xml.CharData:
+				if inText {
+					text.Write(t)
+				}
+			}
+		}
+		r.Close()
+		text.WriteString("\n")
+	}
+	return strings.ToValidUTF8(text.String(), " "), nil
+}
+
+// extractPDF returns the text layer of a PDF document, found in the text
+// objects of its uncompressed and Flate compressed content streams. Text in
+// fonts with custom encodings, as is common for CJK scripts, is not
+// recovered.
+func extractPDF(content []byte) string {
+	var text strings.Builder
+	for _, stream := range pdfStreams(content) {
+		extractPDFText(stream, &text)
+	}
+	return text.String()
+}
+
+// pdfStreams returns the decoded content of each stream of a PDF document
+// that may hold text. Streams with filters other than FlateDecode, such as
+// images, are skipped, as are embedded fonts.
+func pdfStreams(content []byte) [][]byte {
+	var streams [][]byte
+	offset := 0
+	for {
+		start := bytes.Index(content[offset:], []byte("stream"))
+		if start < 0 {
+			break
+		}
+		start += offset
+		if start >= 3 && string(content[start-3:start]) == "end" {
+			// This is the endstream of a stream already skipped
+			offset = start + len("stream")
+			continue
+		}
+		// The stream keyword is preceded by the dictionary describing the stream
+		dictStart := bytes.LastIndex(content[offset:start], []byte("obj"))
+		dict := content[offset:start]
+		if dictStart >= 0 {
+			dict = content[offset+dictStart : start]
+		}
+		dataStart := start + len("stream")
+		if dataStart < len(content) && content[dataStart] == '\r' {
+			dataStart++
+		}
+		if dataStart < len(content) && content[dataStart] == '\n' {
+			dataStart++
+		}
+		end := bytes.Index(content[dataStart:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		end += dataStart
+		offset = end + len("endstream")
+		data := content[dataStart:end]
+		if bytes.Contains(dict, []byte("/Length1")) || bytes.Contains(dict, []byte("/Subtype/Image")) || bytes.Contains(dict, []byte("/Subtype /Image")) {
+			continue
+		}
+		if bytes.Contains(dict, []byte("/Filter")) {
+			if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Contains(dict, []byte("/DCTDecode")) {
+				continue
+			}
+			// A stream truncated or corrupt part way still yields its text to that point
+			inflated, _ := ioutil.ReadAll(io.LimitReader(newFlateReader(data), maxPartSize))
+			data = inflated
+		}
+		streams = append(streams, data)
+	}
+	return streams
+}
@@ -0,0 +1,73 @@
+package fulltext_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/fulltext"
+)
+
+func TestExtract(t *testing.T) {
+	html := `<html><head><title>Quarterly report</title><style>p { color: red }</style>
+<script>var hidden = "secret";</script></head>
+<body><p>Revenue &amp; growth<br>exceeded plans</p></body></html>`
+
+	var docx bytes.Buffer
+	zw := zip.NewWriter(&docx)
+	w, _ := zw.Create("word/document.xml")
+	fmt.Fprint(w, `<?xml version="1.0"?><w:document xmlns:w="w"><w:body>`+
+		`<w:p><w:r><w:t>Minutes of the </w:t></w:r><w:r><w:t>board meet</w:t></w:r><w:r><w:t>ing</w:t></w:r></w:p>`+
+		`<w:p><w:r><w:t>Adjourned</w:t></w:r></w:p></w:body></w:document>`)
+	w, _ = zw.Create("word/styles.xml")
+	fmt.Fprint(w, `<w:styles xmlns:w="w"><w:t>not text</w:t></w:styles>`)
+	zw.Close()
+
+	var compressed bytes.Buffer
+	zlw := zlib.NewWriter(&compressed)
+	fmt.Fprint(zlw, "BT /F1 12 Tf 72 712 Td (Flight plan) Tj 0 -14 Td [(al)10(titude ) -300 (restric)5(ted)] TJ ET")
+	zlw.Close()
+	pdf := "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n" +
+		"4 0 obj << /Length 44 >> stream\nBT (Uncompressed \\(plain\\) text) Tj ET\nendstream endobj\n" +
+		fmt.Sprintf("5 0 obj << /Length %d /Filter /FlateDecode >> stream\n", compressed.Len()) + compressed.String() + "\nendstream endobj\n" +
+		"6 0 obj << /Subtype /Image /Length 9 >> stream\nBT (imagedata) Tj ET\nendstream endobj\n%%EOF"
+
+	cases := []struct {
+		contentType string
+		name        string
+		content     []byte
+		contains    []string
+		excludes    []string
+	}{
+		{"text/plain; charset=utf-8", "a.txt", []byte("plain words"), []string{"plain words"}, nil},
+		{"", "notes.md", []byte("markdown words"), []string{"markdown words"}, nil},
+		{"text/html", "a.html", []byte(html), []string{"Quarterly report", "Revenue & growth", "exceeded plans"}, []string{"secret", "color"}},
+		{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "a.docx", docx.Bytes(), []string{"Minutes of the board meeting", "Adjourned"}, []string{"not text"}},
+		{"application/octet-stream", "a.docx", docx.Bytes(), []string{"board meeting"}, nil},
+		{"application/pdf", "a.pdf", []byte(pdf), []string{"Uncompressed (plain) text", "Flight plan", "altitude  restricted"}, []string{"imagedata"}},
+	}
+	for _, c := range cases {
+		text, err := fulltext.Extract(c.contentType, c.name, c.content)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		for _, s := range c.contains {
+			if !strings.Contains(text, s) {
+				t.Errorf("%s: expected text to contain %q, got %q", c.name, s, text)
+			}
+		}
+		for _, s := range c.excludes {
+			if strings.Contains(text, s) {
+				t.Errorf("%s: expected text not to contain %q, got %q", c.name, s, text)
+			}
+		}
+	}
+
+	if _, err := fulltext.Extract("image/png", "a.png", []byte{0x89, 'P', 'N', 'G'}); err != fulltext.ErrUnsupportedContentType {
+		t.Errorf("expected unsupported content type for images, got %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+package fulltext
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	// indexFilePrefix begins the name of the journal of the index within its
+	// directory, which is followed by a fingerprint of the key of the index
+	indexFilePrefix = "fulltext."
+	// termHashSize is the number of bytes of the HMAC of a term kept in place
+	// of the term
+	termHashSize = 16
+	// maxTermsPerDocument bounds the terms indexed for a single document
+	maxTermsPerDocument = 1000000
+	// compactionThreshold is the number of superseded records in the journal
+	// that cause it to be rewritten when the index is opened
+	compactionThreshold = 1000
+)
+
+// indexRecord is a line of the journal, holding the hashes of the terms of a
+// document. A record with no terms removes the document from the index.
+type indexRecord struct {
+	ID    string   `json:"id"`
+	Terms []string `json:"terms,omitempty"`
+}
+
+// Index is an inverted index of the terms of documents, identified by an ID,
+// supporting search for documents containing a phrase. The index is held in
+// memory, and persisted to a journal in its directory so that it survives a
+// restart. Terms are held only as an HMAC under the key of the index, so that
+// the text of documents is not written to disk. It is safe for concurrent use
+// within a process, but the directory must not be shared between processes,
+// as each holds the index in memory and may rewrite the journal when opening
+// it.
+type Index struct {
+	mu  sync.RWMutex
+	key []byte
+	// documents holds the hashed terms of each document in order
+	documents map[string][]string
+	// postings holds the positions of each hashed term within each document
+	postings map[string]map[string][]int
+	journal  *os.File
+	writer   *bufio.Writer
+}
+
+// OpenIndex opens the index stored in the directory under a key derived from
+// the secret, creating the directory and an empty index if they do not exist.
+// An index stored under a different secret is not opened, so documents must be
+// indexed again when the secret changes. The journal is compacted if it holds
+// many superseded records.
+func OpenIndex(dir string, secret []byte) (*Index, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("fulltext index"))
+	idx := &Index{
+		key:       mac.Sum(nil),
+		documents: make(map[string][]string),
+		postings:  make(map[string]map[string][]int),
+	}
+	fingerprint := sha256.Sum256(idx.key)
+	name := filepath.Join(dir, indexFilePrefix+hex.EncodeToString(fingerprint[:8])+".index")
+	superseded, err := idx.replay(name)
+	if err != nil {
+		return nil, err
+	}
+	if superseded > compactionThreshold {
+		if err := idx.compact(name); err != nil {
+			return nil, err
+		}
+	}
+	idx.journal, err = os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	idx.writer = bufio.NewWriter(idx.journal)
+	return idx, nil
+}
+
+// replay loads the journal, returning the number of records superseded by
+// later records. A record left incomplete by a crash ends the replay.
+func (idx *Index) replay(name string) (int, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	superseded := 0
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var record indexRecord
+		if err := decoder.Decode(&record); err != nil {
+			if err != io.EOF {
+				// The incomplete record is dropped when the journal is compacted
+				superseded += compactionThreshold + 1
+			}
+			break
+		}
+		if _, ok := idx.documents[record.ID]; ok || len(record.Terms) == 0 {
+			superseded++
+		}
+		idx.apply(record)
+	}
+	return superseded, nil
+}
+
+// compact rewrites the journal with a single record for each document
+func (idx *Index) compact(name string) error {
+	tmp := name + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, id := range idx.sortedIDs() {
+		if err := encoder.Encode(indexRecord{ID: id, Terms: idx.documents[id]}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+func (idx *Index) sortedIDs() []string {
+	ids := make([]string, 0, len(idx.documents))
+	for id := range idx.documents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// apply updates the index in memory with a record
+func (idx *Index) apply(record indexRecord) {
+	for term := range termPositions(idx.documents[record.ID]) {
+		delete(idx.postings[term], record.ID)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.documents, record.ID)
+	if len(record.Terms) == 0 {
+		return
+	}
+	idx.documents[record.ID] = record.Terms
+	for term, positions := range termPositions(record.Terms) {
+		documents, ok := idx.postings[term]
+		if !ok {
+			documents = make(map[string][]int)
+			idx.postings[term] = documents
+		}
+		documents[record.ID] = positions
+	}
+}
+
+// termPositions returns the positions at which each term occurs
+func termPositions(terms []string) map[string][]int {
+	positions := make(map[string][]int)
+	for i, term := range terms {
+		positions[term] = append(positions[term], i)
+	}
+	return positions
+}
+
+// hashTerms returns the HMAC of each term under the key of the index
+func (idx *Index) hashTerms(terms []string) []string {
+	hashed := make([]string, len(terms))
+	for i, term := range terms {
+		mac := hmac.New(sha256.New, idx.key)
+		mac.Write([]byte(term))
+		hashed[i] = base64.RawStdEncoding.EncodeToString(mac.Sum(nil)[:termHashSize])
+	}
+	return hashed
+}
+
+// write appends a record to the journal and applies it
+func (idx *Index) write(record indexRecord) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if err := json.NewEncoder(idx.writer).Encode(record); err != nil {
+		return err
+	}
+	if err := idx.writer.Flush(); err != nil {
+		return err
+	}
+	idx.apply(record)
+	return nil
+}
+
+// Add indexes the text of a document, replacing any text previously indexed
+// for it. A document with no terms is removed.
+func (idx *Index) Add(id string, text string) error {
+	terms := Tokenize(text)
+	if len(terms) > maxTermsPerDocument {
+		terms = terms[:maxTermsPerDocument]
+	}
+	return idx.write(indexRecord{ID: id, Terms: idx.hashTerms(terms)})
+}
+
+// Remove removes a document from the index
+func (idx *Index) Remove(id string) error {
+	idx.mu.RLock()
+	_, ok := idx.documents[id]
+	idx.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return idx.write(indexRecord{ID: id})
+}
+
+// Search returns the IDs of documents containing the terms of the phrase
+// consecutively, in order of ID. At most limit IDs are returned. No documents
+// match a phrase without terms.
+func (idx *Index) Search(phrase string, limit int) []string {
+	terms := idx.hashTerms(Tokenize(phrase))
+	if len(terms) == 0 {
+		return nil
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	// Candidates are the documents containing the least common term
+	rarest := terms[0]
+	for _, term := range terms[1:] {
+		if len(idx.postings[term]) < len(idx.postings[rarest]) {
+			rarest = term
+		}
+	}
+	var ids []string
+	for id := range idx.postings[rarest] {
+		if idx.containsPhrase(id, terms) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
+
+// containsPhrase reports whether the terms occur consecutively in a document
+func (idx *Index) containsPhrase(id string, terms []string) bool {
+	document := idx.documents[id]
+	for _, start := range idx.postings[terms[0]][id] {
+		if start+len(terms) > len(document) {
+			break
+		}
+		matched := true
+		for i, term := range terms[1:] {
+			if document[start+i+1] != term {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of documents in the index
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.documents)
+}
+
+// Close flushes and closes the journal of the index
+func (idx *Index) Close() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if err := idx.writer.Flush(); err != nil {
+		idx.journal.Close()
+		return err
+	}
+	return idx.journal.Close()
+}
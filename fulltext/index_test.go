@@ -0,0 +1,102 @@
+package fulltext_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/fulltext"
+)
+
+func TestIndexSearch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fulltext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	secret := []byte("testkey")
+	idx, err := fulltext.OpenIndex(dir, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.Add("a", "The quick brown fox jumps over the lazy dog.")
+	idx.Add("b", "A lazy afternoon; the QUICK fox naps.")
+	idx.Add("c", "Nothing to see here")
+
+	cases := []struct {
+		phrase   string
+		expected []string
+	}{
+		{"fox", []string{"a", "b"}},
+		{"quick fox", []string{"b"}},
+		{"Quick Brown", []string{"a"}},
+		{"lazy dog", []string{"a"}},
+		{"dog lazy", nil},
+		{"elephant", nil},
+		{"  ", nil},
+	}
+	for _, c := range cases {
+		ids := idx.Search(c.phrase, 0)
+		if len(ids) != len(c.expected) {
+			t.Errorf("%q: expected %v, got %v", c.phrase, c.expected, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != c.expected[i] {
+				t.Errorf("%q: expected %v, got %v", c.phrase, c.expected, ids)
+			}
+		}
+	}
+	if ids := idx.Search("fox", 1); len(ids) != 1 {
+		t.Errorf("expected search to be limited to 1 document, got %v", ids)
+	}
+
+	// Replacing and removing documents survives reopening the index
+	idx.Add("a", "An entirely different story")
+	idx.Remove("c")
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	idx, err = fulltext.OpenIndex(dir, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	if idx.Len() != 2 {
+		t.Errorf("expected 2 documents after reopening, got %d", idx.Len())
+	}
+	if ids := idx.Search("fox", 0); len(ids) != 1 || ids[0] != "b" {
+		t.Errorf("expected only b to contain fox, got %v", ids)
+	}
+	if ids := idx.Search("different story", 0); len(ids) != 1 || ids[0] != "a" {
+		t.Errorf("expected a to be replaced, got %v", ids)
+	}
+
+	// The text of documents is not written to disk
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		raw, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(strings.ToLower(string(raw)), "fox") {
+			t.Errorf("expected terms to be hashed in %s, got %s", f.Name(), raw)
+		}
+	}
+
+	// An index stored under another secret is not opened
+	other, err := fulltext.OpenIndex(dir, []byte("otherkey"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if other.Len() != 0 {
+		t.Errorf("expected no documents under another secret, got %d", other.Len())
+	}
+}
@@ -0,0 +1,255 @@
+package fulltext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// newFlateReader returns a reader of data compressed with the FlateDecode
+// filter. Data that is not valid yields nothing.
+func newFlateReader(data []byte) io.Reader {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return bytes.NewReader(nil)
+	}
+	return r
+}
+
+// pdfOperand is a string or number operand of an operator in a PDF content
+// stream. Other kinds of operands are not needed to recover text.
+type pdfOperand struct {
+	text     string
+	number   float64
+	isNumber bool
+}
+
+// extractPDFText writes the text shown by the text objects of a PDF content
+// stream. Strings shown by the Tj, TJ, ' and " operators are written, with
+// spaces where text is moved to a new position.
+func extractPDFText(stream []byte, text *strings.Builder) {
+	var operands []pdfOperand
+	inText := false
+	for i := 0; i < len(stream); {
+		c := stream[i]
+		switch {
+		case c == '%':
+			for i < len(stream) && stream[i] != '\n' && stream[i] != '\r' {
+				i++
+			}
+		case isPDFSpace(c) || c == '[' || c == ']' || c == '{' || c == '}':
+			i++
+		case c == '(':
+			s, n := readPDFLiteralString(stream[i:])
+			operands = append(operands, pdfOperand{text: s})
+			i += n
+		case c == '<' && i+1 < len(stream) && stream[i+1] == '<':
+			i += skipPDFDictionary(stream[i:])
+		case c == '<':
+			s, n := readPDFHexString(stream[i:])
+			operands = append(operands, pdfOperand{text: s})
+			i += n
+		case c == '/':
+			i++
+			for i < len(stream) && !isPDFSpace(stream[i]) && !isPDFDelimiter(stream[i]) {
+				i++
+			}
+		case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+			start := i
+			i++
+			for i < len(stream) && (stream[i] == '.' || (stream[i] >= '0' && stream[i] <= '9')) {
+				i++
+			}
+			number, _ := strconv.ParseFloat(string(stream[start:i]), 64)
+			operands = append(operands, pdfOperand{number: number, isNumber: true})
+		default:
+			start := i
+			for i < len(stream) && !isPDFSpace(stream[i]) && !isPDFDelimiter(stream[i]) {
+				i++
+			}
+			if i == start {
+				i++
+				continue
+			}
+			operator := string(stream[start:i])
+			switch operator {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				text.WriteString("\n")
+			case "Td", "TD", "T*", "Tm":
+				if inText {
+					text.WriteString(" ")
+				}
+			case "'", "\"":
+				if inText {
+					text.WriteString(" ")
+				}
+				fallthrough
+			case "Tj", "TJ":
+				if inText {
+					writePDFOperands(operands, text)
+				}
+			case "ID":
+				// Inline image data runs until the EI operator
+				if end := bytes.Index(stream[i:], []byte("EI")); end >= 0 {
+					i += end + 2
+				} else {
+					i = len(stream)
+				}
+			}
+			operands = operands[:0]
+		}
+	}
+}
+
+// writePDFOperands writes the strings shown by a text showing operator. Large
+// negative adjustments between strings of a TJ array separate words.
+func writePDFOperands(operands []pdfOperand, text *strings.Builder) {
+	for _, operand := range operands {
+		if operand.isNumber {
+			if operand.number < -200 {
+				text.WriteString(" ")
+			}
+			continue
+		}
+		text.WriteString(operand.text)
+	}
+}
+
+// readPDFLiteralString reads a string enclosed in balanced parentheses,
+// returning its decoded text and the number of bytes read
+func readPDFLiteralString(b []byte) (string, int) {
+	var s []byte
+	depth := 0
+	i := 0
+	for ; i < len(b); i++ {
+		c := b[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return decodePDFString(s), i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(b) {
+				return decodePDFString(s), i
+			}
+			switch e := b[i]; e {
+			case 'n':
+				s = append(s, '\n')
+			case 'r':
+				s = append(s, '\r')
+			case 't':
+				s = append(s, '\t')
+			case 'b', 'f':
+			case '\r':
+				if i+1 < len(b) && b[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					value := 0
+					j := i
+					for ; j < len(b) && j < i+3 && b[j] >= '0' && b[j] <= '7'; j++ {
+						value = value*8 + int(b[j]-'0')
+					}
+					s = append(s, byte(value))
+					i = j - 1
+				} else {
+					s = append(s, e)
+				}
+			}
+			continue
+		}
+		s = append(s, c)
+	}
+	return decodePDFString(s), i
+}
+
+// readPDFHexString reads a string of hexadecimal digits enclosed in angle
+// brackets, returning its decoded text and the number of bytes read. Strings
+// that do not decode to text, such as glyph identifiers, are returned empty.
+func readPDFHexString(b []byte) (string, int) {
+	var digits []byte
+	i := 1
+	for ; i < len(b) && b[i] != '>'; i++ {
+		if !isPDFSpace(b[i]) {
+			digits = append(digits, b[i])
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	s := make([]byte, 0, len(digits)/2)
+	for j := 0; j+1 < len(digits); j += 2 {
+		value, err := strconv.ParseUint(string(digits[j:j+2]), 16, 8)
+		if err != nil {
+			return "", i + 1
+		}
+		s = append(s, byte(value))
+	}
+	text := decodePDFString(s)
+	for _, r := range text {
+		if r < ' ' && r != '\n' && r != '\t' {
+			return "", i + 1
+		}
+	}
+	return text, i + 1
+}
+
+// decodePDFString decodes a string in UTF-16 if it has a byte order mark, or
+// otherwise treats each byte as a character as in PDFDocEncoding
+func decodePDFString(s []byte) string {
+	if len(s) >= 2 && s[0] == 0xfe && s[1] == 0xff {
+		units := make([]uint16, 0, len(s)/2)
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(s))
+	for i, c := range s {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// skipPDFDictionary returns the number of bytes of a dictionary, including
+// any dictionaries nested within it
+func skipPDFDictionary(b []byte) int {
+	depth := 0
+	for i := 0; i+1 < len(b); i++ {
+		switch {
+		case b[i] == '<' && b[i+1] == '<':
+			depth++
+			i++
+		case b[i] == '>' && b[i+1] == '>':
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(b)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
@@ -0,0 +1,27 @@
+package fulltext
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxTermLength is the longest term indexed. Longer runs of letters and
+// digits are unlikely to be searched for, and are truncated.
+const maxTermLength = 64
+
+// Tokenize splits text into lower case terms of letters and digits, in the
+// order they occur
+func Tokenize(text string) []string {
+	var terms []string
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, field := range fields {
+		term := strings.ToLower(field)
+		if runes := []rune(term); len(runes) > maxTermLength {
+			term = string(runes[:maxTermLength])
+		}
+		terms = append(terms, term)
+	}
+	return terms
+}
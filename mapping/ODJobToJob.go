@@ -0,0 +1,39 @@
+package mapping
+
+import (
+	"encoding/hex"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+)
+
+// MapODJobToJob converts an internal ODJob model to an API exposable protocol
+// Job
+func MapODJobToJob(i *models.ODJob) protocol.Job {
+	o := protocol.Job{}
+	o.ID = hex.EncodeToString(i.ID)
+	o.Type = i.JobType
+	o.State = i.State
+	o.CreatedBy = i.CreatedBy
+	o.CreatedDate = i.CreatedDate
+	o.ModifiedDate = i.ModifiedDate
+	if i.FinishedDate.Valid {
+		finishedDate := i.FinishedDate.Time
+		o.FinishedDate = &finishedDate
+	}
+	o.Total = i.Total
+	o.Processed = i.Processed
+	o.Succeeded = i.Succeeded
+	o.Failed = i.Failed
+	o.Error = i.Error.String
+	o.Errors = make([]protocol.ObjectError, len(i.Errors))
+	for p, q := range i.Errors {
+		o.Errors[p] = protocol.ObjectError{
+			ObjectID: q.ObjectID,
+			Error:    q.Error.String,
+			Msg:      q.Msg.String,
+			Code:     q.Code,
+		}
+	}
+	return o
+}
@@ -0,0 +1,18 @@
+package mapping
+
+import (
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+)
+
+// MapODObjectTagsToTags converts an array of ODObjectTag structs from internal
+// model format to the array of tag names exposed in API protocol format
+func MapODObjectTagsToTags(i *[]models.ODObjectTag) []string {
+	if i == nil || len(*i) == 0 {
+		return nil
+	}
+	o := make([]string, len(*i))
+	for p, q := range *i {
+		o[p] = q.Name
+	}
+	return o
+}
@@ -54,6 +54,7 @@ func MapODObjectToObject(i *models.ODObject) protocol.Object {
 	}
 
 	o.Properties = MapODPropertiesToProperties(&i.Properties)
+	o.Tags = MapODObjectTagsToTags(&i.Tags)
 	o.Permissions = MapODPermissionsToPermissions1_0(&i.Permissions)
 	o.Permission = MapODPermissionsToPermission(&i.Permissions)
 	o.ContainsUSPersonsData = i.ContainsUSPersonsData
@@ -115,6 +116,7 @@ func MapODObjectToDeletedObject(i *models.ODObject) protocol.DeletedObject {
 		o.ContentSize = 0
 	}
 	o.Properties = MapODPropertiesToProperties(&i.Properties)
+	o.Tags = MapODObjectTagsToTags(&i.Tags)
 	o.Permissions = MapODPermissionsToPermissions1_0(&i.Permissions)
 	o.Permission = MapODPermissionsToPermission(&i.Permissions)
 	o.ContainsUSPersonsData = i.ContainsUSPersonsData
@@ -142,6 +144,7 @@ func MapODObjectResultsetToObjectResultset(i *models.ODObjectResultset) protocol
 	o.Resultset.PageSize = i.Resultset.PageSize
 	o.Resultset.PageRows = i.Resultset.PageRows
 	o.Objects = MapODObjectsToObjects(&i.Objects)
+	o.NextCursor = i.NextCursor
 	return o
 }
 
@@ -0,0 +1,89 @@
+package mapping
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+)
+
+// MapODObjectTypeToObjectType converts an internal ODObjectType model, along
+// with its TypeProperties, to an API exposable protocol ObjectType
+func MapODObjectTypeToObjectType(i *models.ODObjectType) protocol.ObjectType {
+	o := protocol.ObjectType{}
+	o.ID = hex.EncodeToString(i.ID)
+	o.Name = i.Name
+	o.Description = i.Description.String
+	o.Properties = MapODObjectTypePropertiesToTypeProperties(&i.TypeProperties)
+	return o
+}
+
+// MapODObjectTypePropertyToTypeProperty converts an internal
+// ODObjectTypeProperty model to an API exposable protocol TypeProperty
+func MapODObjectTypePropertyToTypeProperty(i *models.ODObjectTypeProperty) protocol.TypeProperty {
+	o := protocol.TypeProperty{}
+	o.Name = i.Property.Name
+	o.ValueType = i.ValueType
+	o.Required = i.IsRequired
+	o.Default = i.Property.Value.String
+	o.ClassificationPM = i.Property.ClassificationPM.String
+	if i.AllowedValues.Valid && len(i.AllowedValues.String) > 0 {
+		// Values are encoded on the way in, so a decode failure leaves the
+		// list empty rather than failing the whole type.
+		json.Unmarshal([]byte(i.AllowedValues.String), &o.AllowedValues)
+	}
+	return o
+}
+
+// MapODObjectTypePropertiesToTypeProperties converts an array of internal
+// ODObjectTypeProperty models into an array of API exposable protocol
+// TypeProperties
+func MapODObjectTypePropertiesToTypeProperties(i *[]models.ODObjectTypeProperty) []protocol.TypeProperty {
+	o := make([]protocol.TypeProperty, len(*i))
+	for p, q := range *i {
+		o[p] = MapODObjectTypePropertyToTypeProperty(&q)
+	}
+	return o
+}
+
+// MapTypePropertyToODObjectTypeProperty converts an API exposable protocol
+// TypeProperty into an internally usable ODObjectTypeProperty model
+func MapTypePropertyToODObjectTypeProperty(i *protocol.TypeProperty) (models.ODObjectTypeProperty, error) {
+	o := models.ODObjectTypeProperty{}
+	o.Property.Name = i.Name
+	o.ValueType = i.ValueType
+	if len(o.ValueType) == 0 {
+		o.ValueType = models.PropertyValueTypeString
+	}
+	o.IsRequired = i.Required
+	if len(i.Default) > 0 {
+		o.Property.Value = models.ToNullString(i.Default)
+	}
+	if len(i.ClassificationPM) > 0 {
+		o.Property.ClassificationPM = models.ToNullString(i.ClassificationPM)
+	}
+	if len(i.AllowedValues) > 0 {
+		allowed, err := json.Marshal(i.AllowedValues)
+		if err != nil {
+			return o, err
+		}
+		o.AllowedValues = models.ToNullString(string(allowed))
+	}
+	return o, nil
+}
+
+// MapTypePropertiesToODObjectTypeProperties converts an array of API
+// exposable protocol TypeProperties into an array of internally usable
+// ODObjectTypeProperty models
+func MapTypePropertiesToODObjectTypeProperties(i *[]protocol.TypeProperty) ([]models.ODObjectTypeProperty, error) {
+	o := make([]models.ODObjectTypeProperty, len(*i))
+	for p, q := range *i {
+		mapped, err := MapTypePropertyToODObjectTypeProperty(&q)
+		if err != nil {
+			return o, err
+		}
+		o[p] = mapped
+	}
+	return o, nil
+}
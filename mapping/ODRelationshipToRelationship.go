@@ -0,0 +1,65 @@
+package mapping
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+)
+
+// MapODRelationshipToRelationship converts an internal ODRelationship model
+// to an API exposable protocol Relationship
+func MapODRelationshipToRelationship(i *models.ODRelationship) protocol.Relationship {
+	o := protocol.Relationship{}
+	o.ID = hex.EncodeToString(i.ID)
+	o.CreatedDate = i.CreatedDate
+	o.CreatedBy = i.CreatedBy
+	o.ChangeToken = i.ChangeToken
+	o.SourceID = hex.EncodeToString(i.SourceID)
+	o.TargetID = hex.EncodeToString(i.TargetID)
+	o.Type = i.RelationshipType
+	o.Description = i.Description.String
+	o.ClassificationPM = i.ClassificationPM.String
+	return o
+}
+
+// MapODRelationshipsToRelationships converts an array of internal
+// ODRelationship models into an array of API exposable protocol Relationships
+func MapODRelationshipsToRelationships(i *[]models.ODRelationship) []protocol.Relationship {
+	o := make([]protocol.Relationship, len(*i))
+	for p, q := range *i {
+		o[p] = MapODRelationshipToRelationship(&q)
+	}
+	return o
+}
+
+// MapRelationshipToODRelationship converts an API exposable protocol
+// Relationship into an internally usable ODRelationship model
+func MapRelationshipToODRelationship(i *protocol.Relationship) (models.ODRelationship, error) {
+	var err error
+	o := models.ODRelationship{}
+	if len(i.ID) > 0 {
+		o.ID, err = hex.DecodeString(i.ID)
+		if err != nil {
+			return o, fmt.Errorf("Unable to decode id from %s", i.ID)
+		}
+	}
+	if len(i.SourceID) > 0 {
+		o.SourceID, err = hex.DecodeString(i.SourceID)
+		if err != nil {
+			return o, fmt.Errorf("Unable to decode source id from %s", i.SourceID)
+		}
+	}
+	if len(i.TargetID) > 0 {
+		o.TargetID, err = hex.DecodeString(i.TargetID)
+		if err != nil {
+			return o, fmt.Errorf("Unable to decode target id from %s", i.TargetID)
+		}
+	}
+	o.ChangeToken = i.ChangeToken
+	o.RelationshipType = i.Type
+	o.Description = models.ToNullString(i.Description)
+	o.ClassificationPM = models.ToNullString(i.ClassificationPM)
+	return o, nil
+}
@@ -0,0 +1,62 @@
+package mapping
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+)
+
+// MapODUserObjectSubscriptionToSubscription converts an internal
+// ODUserObjectSubscription model to an API exposable protocol Subscription
+func MapODUserObjectSubscriptionToSubscription(i *models.ODUserObjectSubscription) protocol.Subscription {
+	o := protocol.Subscription{}
+	o.ID = hex.EncodeToString(i.ID)
+	o.CreatedDate = i.CreatedDate
+	o.CreatedBy = i.CreatedBy
+	o.ModifiedDate = i.ModifiedDate
+	o.ObjectID = hex.EncodeToString(i.ObjectID)
+	o.OnCreate = i.OnCreate
+	o.OnUpdate = i.OnUpdate
+	o.OnDelete = i.OnDelete
+	o.Recursive = i.Recursive
+	o.CallbackURL = i.CallbackURL
+	return o
+}
+
+// MapODUserObjectSubscriptionsToSubscriptions converts an array of internal
+// ODUserObjectSubscription models into an array of API exposable protocol
+// Subscriptions
+func MapODUserObjectSubscriptionsToSubscriptions(i *[]models.ODUserObjectSubscription) []protocol.Subscription {
+	o := make([]protocol.Subscription, len(*i))
+	for p, q := range *i {
+		o[p] = MapODUserObjectSubscriptionToSubscription(&q)
+	}
+	return o
+}
+
+// MapSubscriptionToODUserObjectSubscription converts an API exposable protocol
+// Subscription into an internally usable ODUserObjectSubscription model
+func MapSubscriptionToODUserObjectSubscription(i *protocol.Subscription) (models.ODUserObjectSubscription, error) {
+	var err error
+	o := models.ODUserObjectSubscription{}
+	if len(i.ID) > 0 {
+		o.ID, err = hex.DecodeString(i.ID)
+		if err != nil {
+			return o, fmt.Errorf("Unable to decode id from %s", i.ID)
+		}
+	}
+	if len(i.ObjectID) > 0 {
+		o.ObjectID, err = hex.DecodeString(i.ObjectID)
+		if err != nil {
+			return o, fmt.Errorf("Unable to decode object id from %s", i.ObjectID)
+		}
+	}
+	o.OnCreate = i.OnCreate
+	o.OnUpdate = i.OnUpdate
+	o.OnDelete = i.OnDelete
+	o.Recursive = i.Recursive
+	o.CallbackURL = i.CallbackURL
+	return o, nil
+}
@@ -12,6 +12,20 @@ func MapPagingRequestToDAOPagingRequest(i *protocol.PagingRequest) dao.PagingReq
 	o.FilterSettings = mapFilterSettingsToDAOFilterSettings(&i.FilterSettings)
 	o.SortSettings = mapSortSettingsToDAOSortSettings(&i.SortSettings)
 	o.FilterMatchType = i.FilterMatchType
+	if i.FilterTree != nil {
+		filterTree := mapFilterGroupToDAOFilterGroup(i.FilterTree)
+		o.FilterTree = &filterTree
+	}
+	return o
+}
+
+func mapFilterGroupToDAOFilterGroup(i *protocol.FilterGroup) dao.FilterGroup {
+	o := dao.FilterGroup{MatchType: i.MatchType}
+	o.FilterSettings = mapFilterSettingsToDAOFilterSettings(&i.FilterSettings)
+	o.Groups = make([]dao.FilterGroup, len(i.Groups))
+	for p, q := range i.Groups {
+		o.Groups[p] = mapFilterGroupToDAOFilterGroup(&q)
+	}
 	return o
 }
 
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// ODContentDedup is a structure defining content stored once in a ciphertext
+// cache zone that deduplicates, and shared by every object having the same
+// content and ACM.
+type ODContentDedup struct {
+	// ContentConnector is the connector by which the content is known in its
+	// zone
+	ContentConnector string `db:"contentConnector"`
+	// CreatedDate is the timestamp of when the content was first stored
+	CreatedDate time.Time `db:"createdDate"`
+	// Zone is the ciphertext cache zone in which the content is stored
+	Zone string `db:"zone"`
+	// ACMID is the identifier of the flattened ACM of the objects sharing the
+	// content. Content is only shared between objects with the same ACM.
+	ACMID int64 `db:"acmId"`
+	// ContentHash is the SHA-256 hash of the plaintext content
+	ContentHash []byte `db:"contentHash"`
+	// ContentSize is the length of the plaintext content in bytes
+	ContentSize int64 `db:"contentSize"`
+	// EncryptIV is the initialization vector the content was encrypted with
+	EncryptIV []byte `db:"encryptIV"`
+	// CipherFormat is the format the content was encrypted in
+	CipherFormat int `db:"cipherFormat"`
+	// ReleasedDate is the timestamp of when the last object referring to the
+	// content was expunged, or null if it is still referred to
+	ReleasedDate NullTime `db:"releasedDate"`
+	// PermissionIV is the initialization vector of a permission of an object
+	// referring to the content, used with the master key to obtain the key the
+	// content was encrypted with. It is not stored with the content.
+	PermissionIV []byte `db:"permissionIV"`
+	// EncryptKey is the encrypted key of a permission of an object referring
+	// to the content. It is not stored with the content.
+	EncryptKey []byte `db:"encryptKey"`
+}
+
+// ContentDedupStats summarizes the storage saved by deduplicating content
+type ContentDedupStats struct {
+	// ContentCount is the number of distinct contents stored once
+	ContentCount int64 `db:"contentCount"`
+	// ReferenceCount is the number of objects referring to deduplicated content
+	ReferenceCount int64 `db:"referenceCount"`
+	// BytesSaved is the size of the content that would otherwise have been
+	// stored again for each additional object referring to it
+	BytesSaved int64 `db:"bytesSaved"`
+}
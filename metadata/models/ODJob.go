@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// States of a job. A job is queued until claimed by a server instance, and
+// then running until it completes, fails or is cancelled.
+const (
+	JobStateQueued    = "queued"
+	JobStateRunning   = "running"
+	JobStateComplete  = "complete"
+	JobStateCancelled = "cancelled"
+	JobStateFailed    = "failed"
+)
+
+// ODJob is a structure defining a long running operation performed in the
+// background, and its progress.
+type ODJob struct {
+	// ID is the unique identifier of the job
+	ID []byte `db:"id"`
+	// CreatedDate is the timestamp of when the job was requested
+	CreatedDate time.Time `db:"createdDate"`
+	// CreatedBy is the distinguished name of the user that requested the job
+	CreatedBy string `db:"createdBy"`
+	// ModifiedDate is the timestamp of when progress was last recorded
+	ModifiedDate time.Time `db:"modifiedDate"`
+	// JobType identifies the operation performed
+	JobType string `db:"jobType"`
+	// State is one of queued, running, complete, cancelled or failed
+	State string `db:"state"`
+	// Request is the JSON encoded request of the operation
+	Request string `db:"request"`
+	// Context is the JSON encoded caller and event of the request, from which
+	// the job is performed on behalf of the user
+	Context string `db:"context"`
+	// Total is the number of items to be processed, if known
+	Total int64 `db:"total"`
+	// Processed is the number of items processed, from which a job resumes
+	Processed int64 `db:"processed"`
+	// Succeeded is the number of items processed successfully
+	Succeeded int64 `db:"succeeded"`
+	// Failed is the number of items that could not be processed
+	Failed int64 `db:"failed"`
+	// Error is the reason a job failed as a whole
+	Error NullString `db:"error"`
+	// ClaimedBy identifies the claim of the server instance running the job,
+	// being new each time the job is claimed
+	ClaimedBy NullString `db:"claimedBy"`
+	// HeartbeatDate is the timestamp of when the instance running the job
+	// last renewed its claim
+	HeartbeatDate NullTime `db:"heartbeatDate"`
+	// FinishedDate is the timestamp of when the job completed, failed or was
+	// cancelled
+	FinishedDate NullTime `db:"finishedDate"`
+	// Errors are the items that could not be processed. They are not stored
+	// with the job.
+	Errors []ODJobError `db:"-"`
+}
+
+// ODJobError is an item of a job that could not be processed
+type ODJobError struct {
+	// JobID is the unique identifier of the job
+	JobID []byte `db:"jobId"`
+	// ObjectID identifies the object the item refers to
+	ObjectID string `db:"objectId"`
+	// Code is a status code for the failure
+	Code int `db:"code"`
+	// Error is an error string for the failure
+	Error NullString `db:"error"`
+	// Msg is an informative message about the failure
+	Msg NullString `db:"msg"`
+}
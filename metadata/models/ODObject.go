@@ -82,6 +82,13 @@ type ODObject struct {
 	// EncryptIV contains the initialization vector information for encrypting the
 	// content stream for this object at result
 	EncryptIV []byte `db:"encryptIV" json:"-"`
+	// CipherFormat is the format the content stream is encrypted in at rest,
+	// one of the crypto.CipherFormat values
+	CipherFormat int `db:"cipherFormat" json:"-"`
+	// SharesContent indicates that the object is being pointed at deduplicated
+	// content already stored, which must not have been released when the
+	// object is stored
+	SharesContent bool `db:"-" json:"-"`
 	// TypeName reflects the name of the object type associated with TypeID
 	TypeName NullString `db:"typeName"`
 	// Properties is an array of Object Properties associated with this object
@@ -89,6 +96,8 @@ type ODObject struct {
 	Properties []ODObjectPropertyEx `json:"properties"`
 	// Permissions is an array of Object Permissions associated with this object
 	Permissions []ODObjectPermission `json:"permissions"`
+	// Tags is an array of labels associated with this object
+	Tags []ODObjectTag `json:"tags"`
 	// CallerPermissions is a composite permission of what the caller is allowed.
 	CallerPermissions ODCommonPermission `json:"callerPermission"`
 	// ContainsUSPersonsData indicates if this object contains US Persons data (Yes,No,Unknown)
@@ -105,6 +114,9 @@ type ODObject struct {
 type ODObjectResultset struct {
 	Resultset
 	Objects []ODObject
+	// NextCursor is the position following the last object, from which the
+	// next page of results may be requested, or empty if there are no more
+	NextCursor string
 }
 
 // IsCreating is a helper method to indicate if this object is being created
@@ -118,6 +118,20 @@ func SetEncryptKey(passphrase string, dst *ODObjectPermission) {
 	dst.PermissionMAC = CalculatePermissionMAC(passphrase, dst)
 }
 
+// RewrapEncryptKey rewraps a permission whose key was wrapped with the previous
+// passphrase so that it is wrapped with passphrase instead. It reports whether
+// the permission was changed. Permissions already wrapped with passphrase, or
+// whose signature matches neither passphrase, are left as they are.
+func RewrapEncryptKey(previous, passphrase string, dst *ODObjectPermission) bool {
+	if previous == "" || EqualsPermissionMAC(passphrase, dst) || !EqualsPermissionMAC(previous, dst) {
+		return false
+	}
+	k := crypto.ApplyPassphrase(previous, dst.PermissionIV, dst.EncryptKey)
+	dst.EncryptKey = crypto.ApplyPassphrase(passphrase, dst.PermissionIV, k)
+	dst.PermissionMAC = CalculatePermissionMAC(passphrase, dst)
+	return true
+}
+
 // CalculatePermissionMAC - validate that odrive wrote this grant
 func CalculatePermissionMAC(passphrase string, src *ODObjectPermission) []byte {
 	//Sign our permission
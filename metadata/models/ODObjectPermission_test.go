@@ -1,8 +1,11 @@
 package models_test
 
 import (
+	"bytes"
 	"testing"
 
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+
 	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
 )
 
@@ -15,3 +18,26 @@ func TestAACFlatten(t *testing.T) {
 	}
 
 }
+
+func TestRewrapEncryptKey(t *testing.T) {
+
+	permission := models.ODObjectPermission{Grantee: "cntesttester01", AllowRead: true}
+	models.SetEncryptKey("previous", &permission)
+	fileKey := crypto.ApplyPassphrase("previous", permission.PermissionIV, permission.EncryptKey)
+
+	if !models.RewrapEncryptKey("previous", "current", &permission) {
+		t.Fatalf("expected permission to be rewrapped")
+	}
+	if !models.EqualsPermissionMAC("current", &permission) {
+		t.Errorf("rewrapped permission is not signed with the current passphrase")
+	}
+	if !bytes.Equal(fileKey, crypto.ApplyPassphrase("current", permission.PermissionIV, permission.EncryptKey)) {
+		t.Errorf("rewrapped permission does not unwrap to the same file key")
+	}
+	if models.RewrapEncryptKey("previous", "current", &permission) {
+		t.Errorf("permission already wrapped with the current passphrase was rewrapped")
+	}
+	if models.RewrapEncryptKey("other", "another", &permission) {
+		t.Errorf("permission signed with neither passphrase was rewrapped")
+	}
+}
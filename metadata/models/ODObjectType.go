@@ -53,4 +53,8 @@ type ODObjectType struct {
 	// created of this type, the properties defined on the type act as default
 	// initializer
 	Properties []ODObjectPropertyEx
+	// TypeProperties are the definitions of properties declared for objects of
+	// this type, including their value types, whether they are required, and
+	// their defaults.
+	TypeProperties []ODObjectTypeProperty
 }
@@ -34,6 +34,25 @@ type ODObjectTypeProperty struct {
 	// PropertyID refers to the Property for which this linkage between type
 	// and property is associated.
 	PropertyID []byte `db:"propertyId"`
-	// Property references the actual underlying property object.
+	// ValueType is the type that values of the property must conform to. One of
+	// string, int, date, or enum.
+	ValueType string `db:"valueType"`
+	// IsRequired indicates whether objects of the type must have a value for
+	// the property.
+	IsRequired bool `db:"isRequired"`
+	// AllowedValues is a JSON encoded array of the values permitted when the
+	// ValueType is enum.
+	AllowedValues NullString `db:"allowedValues"`
+	// Property references the actual underlying property object. Its name is
+	// the name of the property, and its value, if any, is the default value
+	// given to objects of the type that do not specify one.
 	Property ODProperty
 }
+
+// Value types for properties declared on object types.
+const (
+	PropertyValueTypeString = "string"
+	PropertyValueTypeInt    = "int"
+	PropertyValueTypeDate   = "date"
+	PropertyValueTypeEnum   = "enum"
+)
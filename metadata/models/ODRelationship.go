@@ -44,6 +44,9 @@ type ODRelationship struct {
 	// TargetID is the identifier of the target object for this relationship.
 	// In a hierarchial context, the target refers to the 'child' item
 	TargetID []byte `db:"targetId"`
+	// RelationshipType indicates how the source relates to the target, such as
+	// a report that is derivedFrom its source files.
+	RelationshipType string `db:"relationshipType"`
 	// Description is an indicator of the purpose of the relationship.
 	Description NullString `db:"description"`
 	// ClassificationPM is the portion mark classification for the description of
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// ODUserObjectSubscription is a structure defining a subscription by a user
+// to be notified of changes made to an Object within Object Drive.
+type ODUserObjectSubscription struct {
+	// ID is the unique identifier for an item in Object Drive.
+	ID []byte `db:"id"`
+	// CreatedDate is the timestamp of when an item was created.
+	CreatedDate time.Time `db:"createdDate"`
+	// CreatedBy is the user, identified by distinguished name, that created this
+	// item. This is the user to whom notifications pertain.
+	CreatedBy string `db:"createdBy"`
+	// ModifiedDate is the timestamp of when an item was modified. If an item
+	// has only been created and not subsequently modified, its ModifiedDate
+	// shall equate to the CreatedDate once stored in the repository.
+	ModifiedDate time.Time `db:"modifiedDate"`
+	// ModifiedBy is the user, identified by distinguished name, that last
+	// modified this item
+	ModifiedBy string `db:"modifiedBy"`
+	// IsDeleted indicates whether the item is currently marked as deleted and
+	// subsequently filtered from certain API results
+	IsDeleted bool `db:"isDeleted" json:"-"`
+	// DeletedDate is the timestamp of when an item was deleted, or null if it
+	// currently is not deleted.
+	DeletedDate NullTime `db:"deletedDate" json:"-"`
+	// DeletedBy is the user, identified by distinguished name, that marked the
+	// item as deleted, or null if the item is currently not deleted.
+	DeletedBy NullString `db:"deletedBy" json:"-"`
+	// ObjectID is the identifier of the object subscribed to
+	ObjectID []byte `db:"objectId"`
+	// OnCreate indicates whether notifications should be sent when objects
+	// are created
+	OnCreate bool `db:"onCreate"`
+	// OnUpdate indicates whether notifications should be sent when objects
+	// are updated or undeleted
+	OnUpdate bool `db:"onUpdate"`
+	// OnDelete indicates whether notifications should be sent when objects
+	// are deleted
+	OnDelete bool `db:"onDelete"`
+	// Recursive indicates whether the subscription also applies to all
+	// descendants of the object
+	Recursive bool `db:"recursive"`
+	// CallbackURL is the URL to which notifications are posted
+	CallbackURL string `db:"callbackUrl"`
+}
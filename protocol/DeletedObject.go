@@ -60,6 +60,8 @@ type DeletedObject struct {
 	// Properties is an array of Object Properties associated with this object
 	// structured as key/value with portion marking.
 	Properties []Property `json:"properties,omitempty"`
+	// Tags is an array of tag names associated with this object
+	Tags []string `json:"tags,omitempty"`
 	// CallerPermission is the composite permission the caller has for this object
 	CallerPermission CallerPermission `json:"callerPermission,omitempty"`
 	//Permission Permission `json:"permission,omitempty"`
@@ -0,0 +1,211 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxFilterGroupDepth bounds the nesting of groups in a filter tree
+	maxFilterGroupDepth = 8
+	// maxFilterGroupSettings bounds the filter settings in a filter tree
+	maxFilterGroupSettings = 100
+)
+
+// FilterGroup denotes filter settings and nested groups of filters whose
+// matches are combined by the match type. A filter tree such as
+// "typename equals File and (name contains X or description contains X)"
+// is a group matching all of a filter setting and a nested group matching any
+// of two filter settings.
+type FilterGroup struct {
+	// MatchType indicates whether all (and) or any (or) of the filter settings
+	// and groups must match
+	MatchType string `json:"matchType,omitempty"`
+	// FilterSettings are the filters of the group
+	FilterSettings []FilterSetting `json:"filterSettings,omitempty"`
+	// Groups are the groups nested within the group
+	Groups []FilterGroup `json:"groups,omitempty"`
+}
+
+// Validate normalizes the match type of each group in the tree, and ensures
+// that every group has at least one filter setting or nested group, that
+// filter settings name a field, and that the tree is within the limits on its
+// depth and number of filter settings.
+func (g *FilterGroup) Validate() error {
+	settings := 0
+	return g.validate(1, &settings)
+}
+
+func (g *FilterGroup) validate(depth int, settings *int) error {
+	if depth > maxFilterGroupDepth {
+		return fmt.Errorf("filter groups may be nested at most %d deep", maxFilterGroupDepth)
+	}
+	switch strings.ToLower(strings.TrimSpace(g.MatchType)) {
+	case "all", "and":
+		g.MatchType = "and"
+	case "", "any", "or":
+		g.MatchType = "or"
+	default:
+		return fmt.Errorf("filter group match type %s is not and or or", g.MatchType)
+	}
+	if len(g.FilterSettings) == 0 && len(g.Groups) == 0 {
+		return errors.New("filter groups must have at least one filter or group")
+	}
+	for _, filterSetting := range g.FilterSettings {
+		if len(strings.TrimSpace(filterSetting.FilterField)) == 0 {
+			return errors.New("filters must name a filterField")
+		}
+	}
+	*settings += len(g.FilterSettings)
+	if *settings > maxFilterGroupSettings {
+		return fmt.Errorf("filter groups may have at most %d filters", maxFilterGroupSettings)
+	}
+	for i := range g.Groups {
+		if err := g.Groups[i].validate(depth+1, settings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseFilterGroup parses a filter tree from its compact form, in which a
+// group is its match type followed by a comma delimited list of filters and
+// nested groups in parentheses, and a filter is its field, condition and
+// expression delimited by colons. For example
+//
+//	and(typename:equals:File,or(name:contains:X,description:contains:X))
+//
+// An expression containing commas, parentheses or leading and trailing spaces
+// may be enclosed in double quotes, within which a backslash escapes the next
+// character.
+func ParseFilterGroup(s string) (*FilterGroup, error) {
+	p := &filterGroupParser{s: s}
+	g, err := p.group()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q after filter group", p.s[p.pos:])
+	}
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// filterGroupParser parses the compact form of a filter tree
+type filterGroupParser struct {
+	s     string
+	pos   int
+	depth int
+}
+
+func (p *filterGroupParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("filter at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *filterGroupParser) skipSpace() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// token reads up to, but not including, any of the delimiters
+func (p *filterGroupParser) token(delimiters string) string {
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte(delimiters, p.s[p.pos]) < 0 {
+		p.pos++
+	}
+	return strings.TrimSpace(p.s[start:p.pos])
+}
+
+func (p *filterGroupParser) group() (*FilterGroup, error) {
+	p.depth++
+	if p.depth > maxFilterGroupDepth {
+		return nil, p.errorf("filter groups may be nested at most %d deep", maxFilterGroupDepth)
+	}
+	p.skipSpace()
+	matchType := p.token("(,):")
+	if p.pos >= len(p.s) || p.s[p.pos] != '(' {
+		return nil, p.errorf("expected a group such as and(...) or or(...)")
+	}
+	p.pos++
+	g := &FilterGroup{MatchType: matchType}
+	for {
+		if err := p.term(g); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return nil, p.errorf("expected ) to close the group")
+		}
+		c := p.s[p.pos]
+		p.pos++
+		if c == ')' {
+			break
+		}
+		if c != ',' {
+			return nil, p.errorf("expected , or ) but found %q", c)
+		}
+	}
+	p.depth--
+	return g, nil
+}
+
+// term reads a filter or nested group into the group
+func (p *filterGroupParser) term(g *FilterGroup) error {
+	p.skipSpace()
+	start := p.pos
+	name := p.token("(,):")
+	if p.pos < len(p.s) && p.s[p.pos] == '(' {
+		p.pos = start
+		nested, err := p.group()
+		if err != nil {
+			return err
+		}
+		g.Groups = append(g.Groups, *nested)
+		return nil
+	}
+	if p.pos >= len(p.s) || p.s[p.pos] != ':' || len(name) == 0 {
+		return p.errorf("expected a filter such as name:contains:X")
+	}
+	p.pos++
+	condition := p.token("(,):")
+	if p.pos >= len(p.s) || p.s[p.pos] != ':' || len(condition) == 0 {
+		return p.errorf("expected a condition and expression for filter %s", name)
+	}
+	p.pos++
+	expression, err := p.expression()
+	if err != nil {
+		return err
+	}
+	g.FilterSettings = append(g.FilterSettings, FilterSetting{FilterField: name, Condition: condition, Expression: expression})
+	return nil
+}
+
+func (p *filterGroupParser) expression() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) || p.s[p.pos] != '"' {
+		return p.token(",)"), nil
+	}
+	p.pos++
+	var expression strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return expression.String(), nil
+		case '\\':
+			if p.pos < len(p.s) {
+				expression.WriteByte(p.s[p.pos])
+				p.pos++
+			}
+		default:
+			expression.WriteByte(c)
+		}
+	}
+	return "", p.errorf("expected \" to close the expression")
+}
@@ -0,0 +1,65 @@
+package protocol_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+)
+
+func TestParseFilterGroup(t *testing.T) {
+	cases := []struct {
+		filter   string
+		expected protocol.FilterGroup
+	}{
+		{
+			filter: "and(typename:equals:File,or(name:contains:X,description:contains:X))",
+			expected: protocol.FilterGroup{MatchType: "and",
+				FilterSettings: []protocol.FilterSetting{{FilterField: "typename", Condition: "equals", Expression: "File"}},
+				Groups: []protocol.FilterGroup{{MatchType: "or", FilterSettings: []protocol.FilterSetting{
+					{FilterField: "name", Condition: "contains", Expression: "X"},
+					{FilterField: "description", Condition: "contains", Expression: "X"}}}}},
+		},
+		{
+			filter: ` ANY ( mimetype : begins : image/ , name:equals:" a, (b) \"c\" " ) `,
+			expected: protocol.FilterGroup{MatchType: "or", FilterSettings: []protocol.FilterSetting{
+				{FilterField: "mimetype", Condition: "begins", Expression: "image/"},
+				{FilterField: "name", Condition: "equals", Expression: ` a, (b) "c" `}}},
+		},
+		{
+			filter: "or(and(or(name:equals:a:b)))",
+			expected: protocol.FilterGroup{MatchType: "or", Groups: []protocol.FilterGroup{{MatchType: "and", Groups: []protocol.FilterGroup{{MatchType: "or",
+				FilterSettings: []protocol.FilterSetting{{FilterField: "name", Condition: "equals", Expression: "a:b"}}}}}}},
+		},
+	}
+	for _, c := range cases {
+		g, err := protocol.ParseFilterGroup(c.filter)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.filter, err)
+			continue
+		}
+		if !reflect.DeepEqual(*g, c.expected) {
+			t.Errorf("%s: expected %+v, got %+v", c.filter, c.expected, *g)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"name:equals:x",
+		"and()",
+		"and(name:equals:x",
+		"and(name:equals:x))",
+		"and(name:equals)",
+		"and(:equals:x)",
+		`and(name:equals:"x)`,
+		"xor(name:equals:x)",
+		strings.Repeat("and(", 9) + "name:equals:x" + strings.Repeat(")", 9),
+		"or(" + strings.Repeat("name:equals:x,", 100) + "name:equals:x)",
+	}
+	for _, filter := range invalid {
+		if _, err := protocol.ParseFilterGroup(filter); err == nil {
+			t.Errorf("%s: expected an error", filter)
+		}
+	}
+}
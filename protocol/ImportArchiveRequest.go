@@ -0,0 +1,13 @@
+package protocol
+
+// ImportArchiveRequest is the metadata for importing an archive into a folder. The
+// acm and permission given are applied to every file and folder created from it.
+type ImportArchiveRequest struct {
+	// RawACM is the raw ACM string applied to objects created from the archive
+	RawAcm interface{} `json:"acm"`
+	// Permission is the API 1.0.1.16+ version for providing permissions for users and groups with a resource and capability driven approach
+	Permission Permission `json:"permission,omitempty"`
+	// Format is the format of the archive, being one of tar, tgz or zip. If not given, it is
+	// determined from the content of the archive
+	Format string `json:"format,omitempty"`
+}
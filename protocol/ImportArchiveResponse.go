@@ -0,0 +1,31 @@
+package protocol
+
+// ImportArchiveResponse reports the result of importing each entry of an archive
+type ImportArchiveResponse struct {
+	// Complete indicates whether every entry of the archive was processed. An import
+	// is stopped once the archive exceeds the limits on its size or number of entries
+	Complete bool `json:"complete"`
+	// Created is the number of files and folders created
+	Created int `json:"created"`
+	// Failed is the number of entries that could not be imported
+	Failed int `json:"failed"`
+	// Entries are the results of each entry, in the order read from the archive
+	Entries []ImportArchiveEntry `json:"entries"`
+}
+
+// ImportArchiveEntry is the result of importing a single entry of an archive
+type ImportArchiveEntry struct {
+	// Name is the path of the entry within the archive
+	Name string `json:"name"`
+	// ObjectID is the unique identifier of the object created for the entry, or of the
+	// folder that already existed at its path
+	ObjectID string `json:"objectId,omitempty"`
+	// TypeName is the type of the object for the entry, being File or Folder
+	TypeName string `json:"typeName,omitempty"`
+	// Code is a status code for the entry, being 200 when an object is created or found
+	Code int `json:"code"`
+	// Error is an error string for an entry that could not be imported
+	Error string `json:"error,omitempty"`
+	// Msg is an informative message about the error that transpired
+	Msg string `json:"msg,omitempty"`
+}
@@ -0,0 +1,33 @@
+package protocol
+
+import "time"
+
+// Job reports the progress of a long running operation performed in the background
+type Job struct {
+	// ID is the unique identifier of the job
+	ID string `json:"id"`
+	// Type is the operation performed, being one of delete, move, ownership, expunge or propagation
+	Type string `json:"type"`
+	// State is one of queued, running, complete, cancelled or failed
+	State string `json:"state"`
+	// CreatedBy is the user that requested the job
+	CreatedBy string `json:"createdBy"`
+	// CreatedDate is when the job was requested
+	CreatedDate time.Time `json:"createdDate"`
+	// ModifiedDate is when progress was last recorded
+	ModifiedDate time.Time `json:"modifiedDate"`
+	// FinishedDate is when the job completed, failed or was cancelled
+	FinishedDate *time.Time `json:"finishedDate,omitempty"`
+	// Total is the number of objects to be processed, or zero if not known in advance
+	Total int64 `json:"total"`
+	// Processed is the number of objects processed so far
+	Processed int64 `json:"processed"`
+	// Succeeded is the number of objects processed successfully
+	Succeeded int64 `json:"succeeded"`
+	// Failed is the number of objects that could not be processed
+	Failed int64 `json:"failed"`
+	// Error is the reason the job failed as a whole
+	Error string `json:"error,omitempty"`
+	// Errors are the objects that could not be processed, up to a limit
+	Errors []ObjectError `json:"errors"`
+}
@@ -62,6 +62,8 @@ type Object struct {
 	// Properties is an array of Object Properties associated with this object
 	// structured as key/value with portion marking.
 	Properties []Property `json:"properties,omitempty"`
+	// Tags is an array of tag names associated with this object
+	Tags []string `json:"tags,omitempty"`
 	// CallerPermission is the composite permission the caller has for this object
 	CallerPermission CallerPermission `json:"callerPermission,omitempty"`
 	// Permissions is an array of Object Permissions associated with this object
@@ -77,6 +79,8 @@ type Object struct {
 	// IsPDFAvailable is retained here to maintain backwards compatibility for
 	// integrations that expect this field to exist and will break if it is not present
 	IsPDFAvailable bool `json:"isPDFAvailable"`
+	// IsFavorite indicates whether the caller has marked this object as a favorite
+	IsFavorite bool `json:"isFavorite"`
 }
 
 // WithBreadcrumbs extends the object's Breadcrumbs slice, if it exists. If it does
@@ -104,6 +108,13 @@ func (obj Object) WithCallerPermission(caller Caller) Object {
 	return obj
 }
 
+// WithFavorite sets whether the caller has marked the object as a favorite on a
+// copy of the Object, and returns that copy.
+func (obj Object) WithFavorite(isFavorite bool) Object {
+	obj.IsFavorite = isFavorite
+	return obj
+}
+
 // WithConsolidatedPermissions iterates permissions on the object and combines
 // capabilities allowed for multiple resources removing duplicates
 func (obj Object) WithConsolidatedPermissions() Object {
@@ -16,6 +16,9 @@ type ObjectResultset struct {
 	Objects []Object `json:"objects,omitempty"`
 	// ObjectErrors is a list of errors per object id
 	ObjectErrors []ObjectError `json:"objectErrors,omitempty"`
+	// NextCursor may be given as the cursor of a request for the next page of
+	// results, and is omitted when there are no more
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // NewObjectResultsetFromJSONBody parses an ObjectResultset from a JSON body.
@@ -0,0 +1,34 @@
+package protocol
+
+// ObjectType is the definition of a type of object, including the properties
+// that objects of the type are expected to carry.
+type ObjectType struct {
+	// ID is the unique identifier for this object type in Object Drive.
+	ID string `json:"id,omitempty"`
+	// Name is the given name for the object type. (e.g., Document, Image)
+	Name string `json:"name"`
+	// Description is an abstract of the type such as its purpose.
+	Description string `json:"description,omitempty"`
+	// Properties are the property definitions declared for the type.
+	Properties []TypeProperty `json:"properties"`
+}
+
+// TypeProperty declares a property for objects of an object type.
+type TypeProperty struct {
+	// Name is the name of the property.
+	Name string `json:"name"`
+	// ValueType is the type that values of the property must conform to. One
+	// of string, int, date, or enum. If not specified, string is assumed.
+	ValueType string `json:"valueType"`
+	// Required indicates whether objects of the type must have a value for
+	// the property.
+	Required bool `json:"required"`
+	// Default is the value assigned to objects of the type that do not
+	// specify a value for the property.
+	Default string `json:"default,omitempty"`
+	// ClassificationPM is the portion mark classification for the default
+	// value of the property.
+	ClassificationPM string `json:"classificationPM,omitempty"`
+	// AllowedValues are the values permitted when the value type is enum.
+	AllowedValues []string `json:"allowedValues,omitempty"`
+}
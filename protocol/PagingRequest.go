@@ -27,6 +27,10 @@ type PagingRequest struct {
 	SortSettings []SortSetting `json:"sortSettings,omitempty"`
 	// FilterMatchType indicates the kind of matching performed when multiple filters are provided.
 	FilterMatchType string `json:"filterMatchType,omitempty"`
+	// FilterTree is an optional group of filters and nested groups that results must also match
+	FilterTree *FilterGroup `json:"filterTree,omitempty"`
+	// Cursor is the nextCursor of a previous page of results, from which to continue instead of the page number
+	Cursor string `json:"cursor,omitempty"`
 }
 
 // newPagingRequestFromURLValues creates a new PagingRequest from the following URL params:
@@ -39,6 +43,7 @@ func newPagingRequestFromURLValues(urlValues url.Values) (PagingRequest, error)
 	pagingRequest.PageNumber = GetQueryParamAsPositiveInt(urlValues, []string{"PageNumber", "pageNumber"}, 1)
 	pagingRequest.PageSize = GetQueryParamAsPositiveInt(urlValues, []string{"PageSize", "pageSize"}, 20)
 
+	pagingRequest.Cursor = urlValues.Get("cursor")
 	pagingRequest.FilterSettings = makeFilterSettingsFromQueryParam(urlValues)
 	pagingRequest.SortSettings = makeSortSettingsFromQueryParam(urlValues)
 
@@ -97,6 +102,13 @@ func NewPagingRequest(r *http.Request, captured map[string]string, isObjectIDReq
 		urlValues := r.URL.Query()
 		pr, _ := newPagingRequestFromURLValues(urlValues)
 		pagingRequest = &pr
+		if filter := urlValues.Get("filter"); len(filter) > 0 {
+			filterTree, err := ParseFilterGroup(filter)
+			if err != nil {
+				return nil, err
+			}
+			pagingRequest.FilterTree = filterTree
+		}
 	case "POST":
 		if util.IsApplicationJSON(r.Header.Get("Content-Type")) {
 			pr, err := newPagingRequestFromJSONBody(r.Body)
@@ -104,6 +116,11 @@ func NewPagingRequest(r *http.Request, captured map[string]string, isObjectIDReq
 				return nil, errors.New("Error parsing request from message body")
 			}
 			pagingRequest = &pr
+			if pagingRequest.FilterTree != nil {
+				if err := pagingRequest.FilterTree.Validate(); err != nil {
+					return nil, err
+				}
+			}
 		} else {
 			return nil, errors.New("Unsupported content-type to parse paging information")
 		}
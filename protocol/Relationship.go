@@ -0,0 +1,39 @@
+package protocol
+
+import "time"
+
+// Relationship is a typed link from a source object to a target object, such
+// as a report that is derivedFrom its source files.
+type Relationship struct {
+	// ID is the unique identifier for this relationship in Object Drive.
+	ID string `json:"id,omitempty"`
+	// CreatedDate is the timestamp of when the relationship was created.
+	CreatedDate time.Time `json:"createdDate,omitempty"`
+	// CreatedBy is the user, identified by distinguished name, that created
+	// the relationship.
+	CreatedBy string `json:"createdBy,omitempty"`
+	// ChangeToken is generated value which is assigned at the database. It
+	// must be provided when removing the relationship.
+	ChangeToken string `json:"changeToken,omitempty"`
+	// SourceID is the identifier of the object the relationship is from.
+	SourceID string `json:"sourceId,omitempty"`
+	// TargetID is the identifier of the object the relationship is to.
+	TargetID string `json:"targetId"`
+	// Type indicates how the source relates to the target.
+	Type string `json:"type"`
+	// Description is an optional explanation of the purpose of the
+	// relationship.
+	Description string `json:"description,omitempty"`
+	// ClassificationPM is the portion mark classification for the
+	// description of this relationship.
+	ClassificationPM string `json:"classificationPM,omitempty"`
+}
+
+// RelationshipResultset encapsulates the relationships of an object with
+// resultset metric information.
+type RelationshipResultset struct {
+	// Resultset contains meta information about the resultset
+	Resultset
+	// Relationships contains the list of relationships in this resultset.
+	Relationships []Relationship `json:"relationships"`
+}
@@ -0,0 +1,61 @@
+package protocol
+
+import "time"
+
+// Subscription is a request by a user to be notified at a callback URL when
+// an object, and optionally its descendants, is created, updated or deleted.
+type Subscription struct {
+	// ID is the unique identifier for this subscription in Object Drive.
+	ID string `json:"id,omitempty"`
+	// CreatedDate is the timestamp of when the subscription was created.
+	CreatedDate time.Time `json:"createdDate,omitempty"`
+	// CreatedBy is the user, identified by distinguished name, that created
+	// the subscription and to whom notifications pertain.
+	CreatedBy string `json:"createdBy,omitempty"`
+	// ModifiedDate is the timestamp of when the subscription was last modified.
+	ModifiedDate time.Time `json:"modifiedDate,omitempty"`
+	// ObjectID is the identifier of the object subscribed to.
+	ObjectID string `json:"objectId,omitempty"`
+	// OnCreate indicates whether a notification is sent when the object, or
+	// a descendant if recursive, is created.
+	OnCreate bool `json:"onCreate"`
+	// OnUpdate indicates whether a notification is sent when the object, or
+	// a descendant if recursive, is updated or undeleted.
+	OnUpdate bool `json:"onUpdate"`
+	// OnDelete indicates whether a notification is sent when the object, or
+	// a descendant if recursive, is deleted.
+	OnDelete bool `json:"onDelete"`
+	// Recursive indicates whether the subscription applies to all
+	// descendants of the object in addition to the object itself.
+	Recursive bool `json:"recursive"`
+	// CallbackURL is the http or https URL that notifications are posted to.
+	CallbackURL string `json:"callbackUrl"`
+}
+
+// SubscriptionResultset encapsulates the subscriptions of a user with
+// resultset metric information.
+type SubscriptionResultset struct {
+	// Resultset contains meta information about the resultset
+	Resultset
+	// Subscriptions contains the list of subscriptions in this resultset.
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+// SubscriptionNotification is the body posted to the callback URL of a
+// subscription when a matching change occurs.
+type SubscriptionNotification struct {
+	// SubscriptionID identifies the subscription that matched the change.
+	SubscriptionID string `json:"subscriptionId"`
+	// EventID is the identifier of the event that was published for the
+	// change, suitable for correlating with the event stream.
+	EventID string `json:"eventId"`
+	// Action is the kind of change that occurred. One of create, update,
+	// delete, or undelete.
+	Action string `json:"action"`
+	// Timestamp is the unix time at which the change occurred.
+	Timestamp int64 `json:"timestamp"`
+	// UserDN is the user that made the change.
+	UserDN string `json:"userDn"`
+	// Object is the state of the changed object after the change.
+	Object Object `json:"object"`
+}
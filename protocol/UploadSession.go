@@ -0,0 +1,59 @@
+package protocol
+
+import "time"
+
+// UploadSessionRequest begins a resumable upload of the content stream for a
+// new object, or to replace the content stream of an existing object. Exactly
+// one of Create or Update is given, and its contentSize is required.
+type UploadSessionRequest struct {
+	// Create describes the object that is created when the upload is committed.
+	Create *CreateObjectRequest `json:"create,omitempty"`
+	// Update describes the changes made to an existing object, identified by
+	// its id and changeToken, when the upload is committed.
+	Update *UpdateObjectAndStreamRequest `json:"update,omitempty"`
+}
+
+// UploadSession reports the progress of a resumable upload.
+type UploadSession struct {
+	// ID is the unique identifier for this upload session.
+	ID string `json:"id"`
+	// ObjectID is the identifier of the object whose content stream is
+	// replaced when the upload is committed. Empty when creating an object.
+	ObjectID string `json:"objectId,omitempty"`
+	// CreatedDate is the timestamp of when the upload session was created.
+	CreatedDate time.Time `json:"createdDate"`
+	// ExpiresDate is the timestamp after which the upload session and any
+	// content received will be discarded if not committed. Each chunk received
+	// extends the session.
+	ExpiresDate time.Time `json:"expiresDate"`
+	// ContentSize is the total length of the content stream, in bytes.
+	ContentSize int64 `json:"contentSize"`
+	// ReceivedSize is the number of distinct bytes of the content stream
+	// received so far.
+	ReceivedSize int64 `json:"receivedSize"`
+	// Received lists the ranges of the content stream received so far, in
+	// order and without overlap.
+	Received []ByteRange `json:"received"`
+	// Chunks lists the chunks received so far, ordered by chunk number.
+	Chunks []UploadChunk `json:"chunks"`
+}
+
+// UploadChunk is a portion of the content stream of an upload session.
+type UploadChunk struct {
+	// Number identifies the chunk. Sending a chunk with the same number again
+	// replaces it.
+	Number int `json:"number"`
+	// Offset is the position of the first byte of the chunk within the
+	// content stream.
+	Offset int64 `json:"offset"`
+	// Length is the number of bytes in the chunk.
+	Length int64 `json:"length"`
+}
+
+// ByteRange is an inclusive range of bytes within a content stream.
+type ByteRange struct {
+	// Start is the position of the first byte in the range.
+	Start int64 `json:"start"`
+	// Stop is the position of the last byte in the range.
+	Stop int64 `json:"stop"`
+}
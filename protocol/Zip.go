@@ -1,6 +1,7 @@
 package protocol
 
-// Zip models a request for a list of objects to be zipped into an archive.
+// Zip models a request for a list of objects to be zipped into an archive. Folders
+// are included along with their descendants.
 type Zip struct {
 	// ObjectIds is an array of object identifiers to be compressed in the archive file
 	ObjectIDs []string `json:"objectIds"`
@@ -8,4 +9,8 @@ type Zip struct {
 	FileName string `json:"fileName"`
 	// Disposition indicates whether to return as inline or attachment disposition
 	Disposition string `json:"disposition"`
+	// Format is the archive format to return, being one of tar, tgz or zip. Defaults to zip
+	Format string `json:"format"`
+	// IncludeRevisions indicates whether prior revisions of files are to be included alongside them
+	IncludeRevisions bool `json:"includeRevisions"`
 }
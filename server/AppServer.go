@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
@@ -24,6 +25,7 @@ import (
 	"bitbucket.di2e.net/dime/object-drive-server/config"
 	"bitbucket.di2e.net/dime/object-drive-server/dao"
 	"bitbucket.di2e.net/dime/object-drive-server/events"
+	"bitbucket.di2e.net/dime/object-drive-server/fulltext"
 	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
 	"bitbucket.di2e.net/dime/object-drive-server/metadata/models/acm"
 	"bitbucket.di2e.net/dime/object-drive-server/performance"
@@ -84,6 +86,22 @@ type AppServer struct {
 	EventQueue events.Publisher
 	// EventQueueZK is a pointer to the cluster where we discover Kafka. May be the same as DefaultZK.
 	EventQueueZK *zookeeper.ZKState
+	// ContentIndex is the full text index of object content, or nil if content is not indexed.
+	ContentIndex *fulltext.Index
+	// WebDAV is the configuration of the WebDAV front end, which is served if it has a prefix.
+	WebDAV config.WebDAVConfiguration
+	// webDAVLocks holds the locks taken through the WebDAV front end.
+	webDAVLocks *davLockTable
+	// jobWake signals an idle job worker on this instance that a job was queued.
+	jobWake chan struct{}
+	// releasedJobs holds the jobs this instance is not configured to perform.
+	releasedJobs *releasedJobs
+	// scrub holds the results of verifying stored content on this instance.
+	scrub *scrubStats
+	// Webhook is the configuration of notifications delivered to subscribers.
+	Webhook config.WebhookConfiguration
+	// S3Gateway is the configuration of the S3 gateway, which listens on its own port if set.
+	S3Gateway config.S3GatewayConfiguration
 	// Tracker captures metrics about upload/download throughput.
 	Tracker *performance.JobReporters
 	// TemplateCache holds HTML templates.
@@ -102,6 +120,8 @@ type AppServer struct {
 	TypeLruCache *ccache.Cache
 	// AclWhitelist provides a list of distinguished names allowed to perform impersonation
 	ACLImpersonationWhitelist []string
+	// TypeAdmins provides a list of distinguished names allowed to manage object type property definitions
+	TypeAdmins []string
 	// Version is set at runtime based on compile time flags
 	Version string
 }
@@ -143,7 +163,12 @@ func NewAppServer(conf config.ServerSettingsConfiguration) (*AppServer, error) {
 		UserAOsLruCache:           userAOsLruCache,
 		TypeLruCache:              typeLruCache,
 		ACLImpersonationWhitelist: conf.ACLImpersonationWhitelist,
+		TypeAdmins:                conf.TypeAdmins,
 		Version:                   conf.Version,
+		webDAVLocks:               newDAVLockTable(),
+		jobWake:                   make(chan struct{}, 1),
+		releasedJobs:              newReleasedJobs(),
+		scrub:                     newScrubStats(),
 	}
 
 	app.InitRegex()
@@ -176,7 +201,14 @@ func (h *AppServer) InitRegex() {
 		Object:           route("/objects/(?P<objectId>[0-9a-fA-F]{32})$"),
 		ObjectProperties: route("/objects/(?P<objectId>[0-9a-fA-F]{32})/properties$"),
 		ObjectCopy:       route("/objects/(?P<objectId>[0-9a-fA-F]{32})/copy$"),
+		ObjectImport:     route("/objects/(?P<objectId>[0-9a-fA-F]{32})/import$"),
+		ObjectShare:      route("/objects/(?P<objectId>[0-9a-fA-F]{32})/share$"),
 		ObjectStream:     route("/objects/(?P<objectId>[0-9a-fA-F]{32})/stream(\\.[0-9a-zA-Z]*)?$"),
+		ObjectTag:        route("/objects/(?P<objectId>[0-9a-fA-F]{32})/tags/(?P<tagName>.*)$"),
+		ObjectFavorite:   route("/objects/(?P<objectId>[0-9a-fA-F]{32})/favorite$"),
+		ObjectSubscribe:  route("/objects/(?P<objectId>[0-9a-fA-F]{32})/subscriptions$"),
+		ObjectRelations:  route("/objects/(?P<objectId>[0-9a-fA-F]{32})/relationships$"),
+		ObjectRelation:   route("/objects/(?P<objectId>[0-9a-fA-F]{32})/relationships/(?P<relationshipId>[0-9a-fA-F]{32})$"),
 		// Ciphertext is used for peer-2-peer calls. The value of 'rname' equates to the contentConnector value on the object.
 		// The reason this length can be exactly either 52 or 64 is due to a code change that went in with 1.0.20
 		// Current rname values are created with a length of 26 bytes (52 hexadecimal) while older ones were 32 (64 hexadecimal).
@@ -204,6 +236,20 @@ func (h *AppServer) InitRegex() {
 		Groups:           route("/groups$"),
 		// - search
 		Search: route("/search/(?P<searchPhrase>.*)$"),
+		// - favorites
+		Favorites: route("/favorites$"),
+		// - subscriptions
+		Subscriptions: route("/subscriptions$"),
+		Subscription:  route("/subscriptions/(?P<subscriptionId>[0-9a-fA-F]{32})$"),
+		// - types
+		TypeDefinition: route("/types/(?P<typeName>[^/]+)$"),
+		// - resumable uploads. The uploadId is the contentConnector the content is received under
+		UploadSessions: route("/uploads$"),
+		UploadSession:  route("/uploads/(?P<uploadId>([0-9a-zA-Z_]+:)?[0-9a-fA-F]{52})$"),
+		UploadChunk:    route("/uploads/(?P<uploadId>([0-9a-zA-Z_]+:)?[0-9a-fA-F]{52})/chunks/(?P<chunkNumber>[0-9]{1,9})$"),
+		UploadCommit:   route("/uploads/(?P<uploadId>([0-9a-zA-Z_]+:)?[0-9a-fA-F]{52})/commit$"),
+		// - jobs
+		Job: route("/jobs/(?P<jobId>[0-9a-fA-F]{32})$"),
 		// - trash
 		Trash: route("/trashed$"),
 		Zip:   route("/zip$"),
@@ -298,6 +344,7 @@ func (h AppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	var uri = r.URL.Path
 	var herr *AppError
+	s3Request := isS3GatewayRequest(r)
 
 	// CORS support - if it specifies an origin, then reflect back an access control origin
 	if reqOrigin := r.Header.Get("Origin"); reqOrigin != "" {
@@ -309,13 +356,21 @@ func (h AppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// The following routes can be handled without calls to the database
 	logger.Debug("checking routes that dont require database")
 	withoutDatabase := false
-	switch r.Method {
-	case "OPTIONS":
+	switch {
+	case s3Request:
+		// every request of the S3 gateway is handled with the database below
+	case r.Method == "OPTIONS":
 		// Handle the pre-flight request here
-		herr = h.cors(ctx, w, r)
+		if h.isWebDAVPath(uri) {
+			h.davOptions(w)
+		} else {
+			herr = h.cors(ctx, w, r)
+		}
 		withoutDatabase = true
-	case "GET":
+	case r.Method == "GET":
 		switch {
+		case h.isWebDAVPath(uri):
+			// drop to with database and user below, as paths beneath the prefix may match other routes
 		case h.Routes.Favicon.RX.MatchString(uri):
 			herr = h.favicon(ctx, w, r)
 			withoutDatabase = true
@@ -386,8 +441,14 @@ func (h AppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	logger.Debug("routing to request handler")
-	switch r.Method {
-	case "GET":
+	switch {
+	case s3Request:
+		matched = "S3Gateway"
+		herr = h.serveS3(ctx, w, r)
+	case h.isWebDAVPath(uri):
+		matched = "WebDAV"
+		herr = h.serveWebDAV(ctx, w, r)
+	case r.Method == "GET":
 		switch {
 		// - user profile usage information
 		case h.Routes.UserStats.RX.MatchString(uri):
@@ -444,6 +505,39 @@ func (h AppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		case h.Routes.SharedToEveryone.RX.MatchString(uri):
 			matched = "SharedToEveryone"
 			herr = h.listUserObjectsSharedToEveryone(ctx, w, r)
+		// - list object relationships
+		case h.Routes.ObjectRelations.RX.MatchString(uri):
+			matched = "ObjectRelations"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.ObjectRelations.RX)
+			herr = h.listObjectRelationships(ctx, w, r)
+		// - get progress of job
+		case h.Routes.Job.RX.MatchString(uri):
+			matched = "Job"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.Job.RX)
+			herr = h.getJob(ctx, w, r)
+		// - list my favorite objects
+		case h.Routes.Favorites.RX.MatchString(uri):
+			matched = "Favorites"
+			herr = h.listUserObjectFavorites(ctx, w, r)
+		// - list my subscriptions
+		case h.Routes.Subscriptions.RX.MatchString(uri):
+			matched = "Subscriptions"
+			herr = h.listUserSubscriptions(ctx, w, r)
+		// - get subscription
+		case h.Routes.Subscription.RX.MatchString(uri):
+			matched = "Subscription"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.Subscription.RX)
+			herr = h.getSubscription(ctx, w, r)
+		// - get type definition
+		case h.Routes.TypeDefinition.RX.MatchString(uri):
+			matched = "TypeDefinition"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.TypeDefinition.RX)
+			herr = h.getObjectType(ctx, w, r)
+		// - get upload session
+		case h.Routes.UploadSession.RX.MatchString(uri):
+			matched = "UploadSession"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.UploadSession.RX)
+			herr = h.getUploadSession(ctx, w, r)
 		// - list object revisions (array of get object properties)
 		case h.Routes.Revisions.RX.MatchString(uri):
 			matched = "Revisions"
@@ -473,7 +567,7 @@ func (h AppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			h.publishError(gem, herr)
 		}
 
-	case "POST":
+	case r.Method == "POST":
 		if h.RootDAO.IsReadOnly(false) {
 			msg := "Service Unavailable. Operation not permitted until database schema upgrade completed. Service operating in read-only mode."
 			herr = NewAppError(http.StatusServiceUnavailable, fmt.Errorf(msg), msg)
@@ -505,6 +599,16 @@ func (h AppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			matched = "ObjectCopy"
 			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.ObjectCopy.RX)
 			herr = h.copyObject(ctx, w, r)
+		// - import archive into folder
+		case h.Routes.ObjectImport.RX.MatchString(uri):
+			matched = "ObjectImport"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.ObjectImport.RX)
+			herr = h.importArchive(ctx, w, r)
+		// - create object share, optionally applied to descendants
+		case h.Routes.ObjectShare.RX.MatchString(uri):
+			matched = "ObjectShare"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.ObjectShare.RX)
+			herr = h.addObjectShare(ctx, w, r)
 		// - update object stream
 		case h.Routes.ObjectStream.RX.MatchString(uri):
 			matched = "ObjectStream"
@@ -515,6 +619,45 @@ func (h AppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			matched = "SharedObject"
 			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.SharedObject.RX)
 			herr = h.addObjectShare(ctx, w, r)
+		// - add object tag
+		case h.Routes.ObjectTag.RX.MatchString(uri):
+			matched = "ObjectTag"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.ObjectTag.RX)
+			herr = h.addObjectTag(ctx, w, r)
+		// - add object to favorites
+		case h.Routes.ObjectFavorite.RX.MatchString(uri):
+			matched = "ObjectFavorite"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.ObjectFavorite.RX)
+			herr = h.addObjectFavorite(ctx, w, r)
+		// - subscribe to object changes
+		case h.Routes.ObjectSubscribe.RX.MatchString(uri):
+			matched = "ObjectSubscribe"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.ObjectSubscribe.RX)
+			herr = h.addObjectSubscription(ctx, w, r)
+		// - relate object to another
+		case h.Routes.ObjectRelations.RX.MatchString(uri):
+			matched = "ObjectRelations"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.ObjectRelations.RX)
+			herr = h.addObjectRelationship(ctx, w, r)
+		// - update subscription
+		case h.Routes.Subscription.RX.MatchString(uri):
+			matched = "Subscription"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.Subscription.RX)
+			herr = h.updateSubscription(ctx, w, r)
+		// - update type definition
+		case h.Routes.TypeDefinition.RX.MatchString(uri):
+			matched = "TypeDefinition"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.TypeDefinition.RX)
+			herr = h.updateObjectType(ctx, w, r)
+		// - begin resumable upload
+		case h.Routes.UploadSessions.RX.MatchString(uri):
+			matched = "UploadSessions"
+			herr = h.createUploadSession(ctx, w, r)
+		// - commit resumable upload
+		case h.Routes.UploadCommit.RX.MatchString(uri):
+			matched = "UploadCommit"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.UploadCommit.RX)
+			herr = h.commitUploadSession(ctx, w, r)
 		// - move object
 		case h.Routes.ObjectMove.RX.MatchString(uri):
 			matched = "ObjectMove"
@@ -549,7 +692,7 @@ func (h AppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			h.publishError(gem, herr)
 		}
 
-	case "DELETE":
+	case r.Method == "DELETE":
 		if h.RootDAO.IsReadOnly(false) {
 			msg := "Service Unavailable. Operation not permitted until database schema upgrade completed. Service operating in read-only mode."
 			herr = NewAppError(http.StatusServiceUnavailable, fmt.Errorf(msg), msg)
@@ -566,6 +709,36 @@ func (h AppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			matched = "SharedObject"
 			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.SharedObject.RX)
 			herr = h.removeObjectShare(ctx, w, r)
+		// - remove object tag
+		case h.Routes.ObjectTag.RX.MatchString(uri):
+			matched = "ObjectTag"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.ObjectTag.RX)
+			herr = h.removeObjectTag(ctx, w, r)
+		// - remove object from favorites
+		case h.Routes.ObjectFavorite.RX.MatchString(uri):
+			matched = "ObjectFavorite"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.ObjectFavorite.RX)
+			herr = h.removeObjectFavorite(ctx, w, r)
+		// - remove subscription
+		case h.Routes.Subscription.RX.MatchString(uri):
+			matched = "Subscription"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.Subscription.RX)
+			herr = h.removeSubscription(ctx, w, r)
+		// - remove relationship
+		case h.Routes.ObjectRelation.RX.MatchString(uri):
+			matched = "ObjectRelation"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.ObjectRelation.RX)
+			herr = h.removeObjectRelationship(ctx, w, r)
+		// - cancel job
+		case h.Routes.Job.RX.MatchString(uri):
+			matched = "Job"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.Job.RX)
+			herr = h.cancelJob(ctx, w, r)
+		// - abandon resumable upload
+		case h.Routes.UploadSession.RX.MatchString(uri):
+			matched = "UploadSession"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.UploadSession.RX)
+			herr = h.removeUploadSession(ctx, w, r)
 		// - Empty this user's trash
 		case h.Routes.Trash.RX.MatchString(uri):
 			matched = "Trash"
@@ -577,13 +750,31 @@ func (h AppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			herr = do404(ctx, w, r)
 			h.publishError(gem, herr)
 		}
+	case r.Method == "PUT":
+		if h.RootDAO.IsReadOnly(false) {
+			msg := "Service Unavailable. Operation not permitted until database schema upgrade completed. Service operating in read-only mode."
+			herr = NewAppError(http.StatusServiceUnavailable, errors.New(msg), msg)
+			break
+		}
+		switch {
+		// - receive chunk of resumable upload
+		case h.Routes.UploadChunk.RX.MatchString(uri):
+			matched = "UploadChunk"
+			ctx = parseCaptureGroups(ctx, r.URL.Path, h.Routes.UploadChunk.RX)
+			herr = h.addUploadChunk(ctx, w, r)
+		default:
+			herr = do404(ctx, w, r)
+			h.publishError(gem, herr)
+		}
 	default:
 		herr = do404(ctx, w, r)
 		h.publishError(gem, herr)
 	}
 
 	// TODO: Before returning, finalize any metrics, capturing time/error codes ?
-	if herr != nil {
+	if herr != nil && s3Request {
+		sendS3ErrorResponse(logger, w, r, herr)
+	} else if herr != nil {
 		sendAppErrorResponse(logger, &w, herr)
 	} else {
 		countOKResponse(logger)
@@ -619,8 +810,13 @@ func (h *AppServer) publishError(gem events.GEM, herr *AppError) {
 	h.EventQueue.Publish(gem)
 }
 func (h *AppServer) publishSuccess(gem events.GEM, w http.ResponseWriter) {
+	h.publishSuccessWithStatus(gem, w.Header().Get("Status"))
+}
+
+// publishSuccessWithStatus publishes a successful event for work done outside
+// of a response, such as by a background job
+func (h *AppServer) publishSuccessWithStatus(gem events.GEM, status string) {
 	gem.Payload.Audit = audit.WithActionResult(gem.Payload.Audit, "SUCCESS")
-	status := w.Header().Get("Status")
 	if len(status) == 0 {
 		status = "200"
 	}
@@ -829,7 +1025,14 @@ type StaticRx struct {
 	Object             StaticRxData
 	ObjectProperties   StaticRxData
 	ObjectCopy         StaticRxData
+	ObjectImport       StaticRxData
+	ObjectShare        StaticRxData
 	ObjectStream       StaticRxData
+	ObjectTag          StaticRxData
+	ObjectFavorite     StaticRxData
+	ObjectSubscribe    StaticRxData
+	ObjectRelations    StaticRxData
+	ObjectRelation     StaticRxData
 	Ciphertext         StaticRxData
 	ObjectChangeOwner  StaticRxData
 	ObjectDelete       StaticRxData
@@ -849,6 +1052,15 @@ type StaticRx struct {
 	GroupObjects       StaticRxData
 	Groups             StaticRxData
 	Search             StaticRxData
+	Favorites          StaticRxData
+	Subscriptions      StaticRxData
+	Subscription       StaticRxData
+	TypeDefinition     StaticRxData
+	UploadSessions     StaticRxData
+	UploadSession      StaticRxData
+	UploadChunk        StaticRxData
+	UploadCommit       StaticRxData
+	Job                StaticRxData
 	Trash              StaticRxData
 	Zip                StaticRxData
 	ObjectsMove        StaticRxData
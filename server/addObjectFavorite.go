@@ -0,0 +1,44 @@
+package server
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+func (h AppServer) addObjectFavorite(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	dbObject, herr := h.commonObjectReadPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(dbObject.ID)
+	gem.Payload.ChangeToken = dbObject.ChangeToken
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(dbObject.ID))
+	gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(dbObject))
+
+	user := models.ODUser{DistinguishedName: caller.DistinguishedName}
+	if _, err := dao.AddFavoriteToObject(user, dbObject); err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error adding object to favorites")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	apiResponse := mapping.MapODObjectToObject(&dbObject).WithCallerPermission(protocolCaller(caller)).WithFavorite(true)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
@@ -0,0 +1,155 @@
+package server_test
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestAddAndRemoveObjectFavorite(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	tester10 := 0
+	tester1 := 1
+
+	t.Logf("Create a folder as tester10 that is not shared")
+	folder, err := makeFolderWithACMViaJSON("Test Folder for Favorites "+strconv.FormatInt(time.Now().UTC().UnixNano(), 10), ValidACMUnclassifiedFOUOSharedToTester10, tester10)
+	failNowOnErr(t, err, "Unable to create folder")
+	uriFavorite := mountPoint + "/objects/" + folder.ID + "/favorite"
+	uriFavorites := mountPoint + "/favorites?filterField=name&condition=equals&expression=" + url.QueryEscape(folder.Name)
+
+	t.Logf("Favorite the folder as tester10")
+	req := makeHTTPRequestFromInterface(t, "POST", uriFavorite, nil)
+	res, err := clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when adding favorite")
+	var favoriteObject protocol.Object
+	err = util.FullDecode(res.Body, &favoriteObject)
+	failNowOnErr(t, err, "Error decoding json to Object")
+	if !favoriteObject.IsFavorite {
+		t.Errorf("expected object to be marked as favorite")
+	}
+
+	t.Logf("Favorite the folder as tester1 who cannot read it")
+	req = makeHTTPRequestFromInterface(t, "POST", uriFavorite, nil)
+	res, err = clients[tester1].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 403, res, "Bad status when adding favorite without permission")
+	util.FinishBody(res.Body)
+
+	t.Logf("Get the folder as tester10 and verify it is marked as favorite")
+	req = makeHTTPRequestFromInterface(t, "GET", mountPoint+"/objects/"+folder.ID+"/properties", nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when getting object")
+	var gotObject protocol.Object
+	err = util.FullDecode(res.Body, &gotObject)
+	failNowOnErr(t, err, "Error decoding json to Object")
+	if !gotObject.IsFavorite {
+		t.Errorf("expected retrieved object to be marked as favorite")
+	}
+
+	t.Logf("List favorites as tester10")
+	req = makeHTTPRequestFromInterface(t, "GET", uriFavorites, nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when listing favorites")
+	var resultset protocol.ObjectResultset
+	err = util.FullDecode(res.Body, &resultset)
+	failNowOnErr(t, err, "Error decoding json to ObjectResultset")
+	if resultset.TotalRows != 1 {
+		t.Errorf("expected 1 favorite, got %d", resultset.TotalRows)
+	}
+
+	t.Logf("Remove favorite as tester10")
+	req = makeHTTPRequestFromInterface(t, "DELETE", uriFavorite, nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when removing favorite")
+	var unfavoriteObject protocol.Object
+	err = util.FullDecode(res.Body, &unfavoriteObject)
+	failNowOnErr(t, err, "Error decoding json to Object")
+	if unfavoriteObject.IsFavorite {
+		t.Errorf("expected object to no longer be marked as favorite")
+	}
+
+	t.Logf("List favorites as tester10 after removal")
+	req = makeHTTPRequestFromInterface(t, "GET", uriFavorites, nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when listing favorites")
+	err = util.FullDecode(res.Body, &resultset)
+	failNowOnErr(t, err, "Error decoding json to ObjectResultset")
+	if resultset.TotalRows != 0 {
+		t.Errorf("expected no favorites after removal, got %d", resultset.TotalRows)
+	}
+}
+
+func TestRemoveFavoriteOfDeletedObject(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	tester10 := 0
+
+	t.Logf("Create and favorite a folder as tester10")
+	folder, err := makeFolderWithACMViaJSON("Test Folder for Deleted Favorites "+strconv.FormatInt(time.Now().UTC().UnixNano(), 10), ValidACMUnclassifiedFOUOSharedToTester10, tester10)
+	failNowOnErr(t, err, "Unable to create folder")
+	uriFavorite := mountPoint + "/objects/" + folder.ID + "/favorite"
+	req := makeHTTPRequestFromInterface(t, "POST", uriFavorite, nil)
+	res, err := clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when adding favorite")
+	util.FinishBody(res.Body)
+
+	t.Logf("Delete the folder")
+	req, err = NewDeleteObjectRequest(*folder, "")
+	failNowOnErr(t, err, "Unable to create delete request")
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when deleting folder")
+	util.FinishBody(res.Body)
+
+	t.Logf("Remove favorite of the deleted folder")
+	req = makeHTTPRequestFromInterface(t, "DELETE", uriFavorite, nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when removing favorite of deleted object")
+	var unfavoriteObject protocol.Object
+	err = util.FullDecode(res.Body, &unfavoriteObject)
+	failNowOnErr(t, err, "Error decoding json to Object")
+	if unfavoriteObject.ID != folder.ID || unfavoriteObject.IsFavorite {
+		t.Errorf("expected only the id of the object no longer marked as favorite, got %v", unfavoriteObject)
+	}
+
+	t.Logf("Undelete the folder and verify it is no longer a favorite")
+	req, err = NewGetObjectRequest(folder.ID, "")
+	failNowOnErr(t, err, "Unable to create get request")
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when getting deleted folder")
+	var deletedObject protocol.Object
+	err = util.FullDecode(res.Body, &deletedObject)
+	failNowOnErr(t, err, "Error decoding json to Object")
+	req, err = NewUndeleteObjectDELETERequest(folder.ID, deletedObject.ChangeToken, "")
+	failNowOnErr(t, err, "Unable to create undelete request")
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when undeleting folder")
+	util.FinishBody(res.Body)
+	req, err = NewGetObjectRequest(folder.ID, "")
+	failNowOnErr(t, err, "Unable to create get request")
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when getting undeleted folder")
+	var undeletedObject protocol.Object
+	err = util.FullDecode(res.Body, &undeletedObject)
+	failNowOnErr(t, err, "Error decoding json to Object")
+	if undeletedObject.IsFavorite {
+		t.Errorf("expected undeleted object to no longer be marked as favorite")
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/auth"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// maxRelationshipTypeLength corresponds to the size of the relationshipType
+// column on relationship
+const maxRelationshipTypeLength = 255
+
+func (h AppServer) addObjectRelationship(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	d := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "MODIFY")
+
+	sourceObject, herr := h.commonObjectRelationshipPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(sourceObject.ID)
+	gem.Payload.ChangeToken = sourceObject.ChangeToken
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(sourceObject.ID))
+	gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(sourceObject))
+
+	relationship, err := parseRelationshipRequest(r)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, err.Error())
+		h.publishError(gem, herr)
+		return herr
+	}
+	relationship.SourceID = sourceObject.ID
+	if string(relationship.TargetID) == string(sourceObject.ID) {
+		herr := NewAppError(http.StatusBadRequest, errors.New("targetId must differ from the object"), "An object cannot be related to itself")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// The target must be visible to the caller, otherwise the relationship
+	// would reveal its existence. A target that is not visible is reported
+	// as one that does not exist, so that callers cannot probe for objects.
+	targetObject, err := d.GetObject(models.ODObject{ID: relationship.TargetID}, true)
+	if err != nil {
+		code, msg, err := getObjectDAOError(err)
+		if code == http.StatusNotFound {
+			msg = "The target object was not found"
+		}
+		herr := NewAppError(code, err, msg)
+		h.publishError(gem, herr)
+		return herr
+	}
+	if !h.relationshipEndpointVisible(ctx, &targetObject) {
+		herr := NewAppError(http.StatusNotFound, dao.ErrNoRows, "The target object was not found")
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(targetObject))
+
+	existing, err := d.GetRelationshipsForObject(sourceObject, dao.RelationshipsOut)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error retrieving relationships")
+		h.publishError(gem, herr)
+		return herr
+	}
+	for _, e := range existing {
+		if string(e.TargetID) == string(targetObject.ID) && e.RelationshipType == relationship.RelationshipType {
+			herr := NewAppError(http.StatusConflict, errors.New("relationship exists"), "The relationship already exists")
+			h.publishError(gem, herr)
+			return herr
+		}
+	}
+
+	user := models.ODUser{DistinguishedName: caller.DistinguishedName}
+	dbRelationship, err := d.AddRelationship(user, relationship)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error adding relationship")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	apiResponse := mapping.MapODRelationshipToRelationship(&dbRelationship)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// commonObjectRelationshipPrep retrieves the object referenced in the request
+// URI and verifies the caller is permitted to alter its relationships.
+func (h AppServer) commonObjectRelationshipPrep(ctx context.Context) (models.ODObject, *AppError) {
+	dbObject, herr := h.commonObjectReadPrep(ctx)
+	if herr != nil {
+		return dbObject, herr
+	}
+	if ok := isUserAllowedToUpdate(ctx, &dbObject); !ok {
+		return dbObject, NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User does not have permission to update this object")
+	}
+	return dbObject, nil
+}
+
+// relationshipEndpointVisible indicates whether the caller may learn of an
+// object at either end of a relationship. The object must exist, and the
+// caller must be permitted to read it and be authorized for its ACM.
+func (h AppServer) relationshipEndpointVisible(ctx context.Context, obj *models.ODObject) bool {
+	if obj.IsDeleted || obj.IsExpunged || obj.IsAncestorDeleted {
+		return false
+	}
+	if !isUserAllowedToRead(ctx, obj) {
+		return false
+	}
+	caller, _ := CallerFromContext(ctx)
+	logger := LoggerFromContext(ctx)
+	aacAuth := auth.NewAACAuth(logger, h.AAC)
+	if _, err := aacAuth.IsUserAuthorizedForACM(caller.DistinguishedName, obj.RawAcm.String); err != nil {
+		return false
+	}
+	return true
+}
+
+func parseRelationshipRequest(r *http.Request) (models.ODRelationship, error) {
+	var jsonRelationship protocol.Relationship
+	defer util.FinishBody(r.Body)
+	if err := util.FullDecode(r.Body, &jsonRelationship); err != nil {
+		return models.ODRelationship{}, errors.New("unable to decode relationship from request")
+	}
+	// Identifiers other than the target come from the URI, not the body
+	jsonRelationship.ID = ""
+	jsonRelationship.SourceID = ""
+	jsonRelationship.ChangeToken = ""
+	jsonRelationship.Type = strings.TrimSpace(jsonRelationship.Type)
+	if len(jsonRelationship.TargetID) == 0 {
+		return models.ODRelationship{}, errors.New("targetId must be specified")
+	}
+	if len(jsonRelationship.Type) == 0 {
+		return models.ODRelationship{}, errors.New("type must be specified")
+	}
+	if len(jsonRelationship.Type) > maxRelationshipTypeLength {
+		return models.ODRelationship{}, errors.New("type exceeds maximum length")
+	}
+	relationship, err := mapping.MapRelationshipToODRelationship(&jsonRelationship)
+	if err != nil {
+		return relationship, err
+	}
+	if len(relationship.TargetID) != 16 {
+		return relationship, errors.New("targetId is not a valid object identifier")
+	}
+	return relationship, nil
+}
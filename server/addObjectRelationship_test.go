@@ -0,0 +1,128 @@
+package server_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestAddListAndRemoveObjectRelationship(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	tester10 := 0
+	tester1 := 1
+	suffix := strconv.FormatInt(time.Now().UTC().UnixNano(), 10)
+
+	t.Logf("Create a report folder as tester10 readable by everyone")
+	report, err := makeFolderWithACMViaJSON("Test Report for Relationships "+suffix, ValidACMUnclassified, tester10)
+	failNowOnErr(t, err, "Unable to create report folder")
+	t.Logf("Create a source folder as tester10 that is not shared")
+	source, err := makeFolderWithACMViaJSON("Test Source for Relationships "+suffix, ValidACMUnclassifiedFOUOSharedToTester10, tester10)
+	failNowOnErr(t, err, "Unable to create source folder")
+	uriReportRelationships := mountPoint + "/objects/" + report.ID + "/relationships"
+	request := protocol.Relationship{TargetID: source.ID, Type: "derivedFrom"}
+
+	t.Logf("Relate the report to itself as tester10")
+	req := makeHTTPRequestFromInterface(t, "POST", uriReportRelationships, protocol.Relationship{TargetID: report.ID, Type: "derivedFrom"})
+	res, err := clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 400, res, "Bad status when relating object to itself")
+	util.FinishBody(res.Body)
+
+	t.Logf("Relate the report to the source as tester1 who cannot update the report")
+	req = makeHTTPRequestFromInterface(t, "POST", uriReportRelationships, request)
+	res, err = clients[tester1].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 403, res, "Bad status when relating without permission")
+	util.FinishBody(res.Body)
+
+	t.Logf("Relate a folder of tester1 to the source that tester1 cannot read")
+	own, err := makeFolderWithACMViaJSON("Test Own Folder for Relationships "+suffix, ValidACMUnclassified, tester1)
+	failNowOnErr(t, err, "Unable to create own folder")
+	uriOwnRelationships := mountPoint + "/objects/" + own.ID + "/relationships"
+	req = makeHTTPRequestFromInterface(t, "POST", uriOwnRelationships, request)
+	res, err = clients[tester1].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 404, res, "Bad status when relating to a target that is not visible")
+	util.FinishBody(res.Body)
+
+	t.Logf("Relate a folder of tester1 to a target that does not exist")
+	req = makeHTTPRequestFromInterface(t, "POST", uriOwnRelationships, protocol.Relationship{TargetID: "11e9000000000000000000000000dead", Type: "derivedFrom"})
+	res, err = clients[tester1].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 404, res, "Bad status when relating to a target that does not exist")
+	util.FinishBody(res.Body)
+
+	t.Logf("Relate the report to the source as tester10")
+	req = makeHTTPRequestFromInterface(t, "POST", uriReportRelationships, request)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when relating")
+	var relationship protocol.Relationship
+	err = util.FullDecode(res.Body, &relationship)
+	failNowOnErr(t, err, "Error decoding json to Relationship")
+	if relationship.SourceID != report.ID || relationship.TargetID != source.ID || relationship.Type != "derivedFrom" {
+		t.Errorf("relationship was not created as requested")
+	}
+
+	t.Logf("Relate the report to the source again as tester10")
+	req = makeHTTPRequestFromInterface(t, "POST", uriReportRelationships, request)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 409, res, "Bad status when relating a second time")
+	util.FinishBody(res.Body)
+
+	listRelationships := func(clientid int, uri string) protocol.RelationshipResultset {
+		req := makeHTTPRequestFromInterface(t, "GET", uri, nil)
+		res, err := clients[clientid].Client.Do(req)
+		failNowOnErr(t, err, "Unable to do request")
+		statusMustBe(t, 200, res, "Bad status when listing relationships")
+		var resultset protocol.RelationshipResultset
+		err = util.FullDecode(res.Body, &resultset)
+		failNowOnErr(t, err, "Error decoding json to RelationshipResultset")
+		return resultset
+	}
+
+	t.Logf("List outgoing relationships of the report as tester10")
+	if resultset := listRelationships(tester10, uriReportRelationships+"?direction=out"); len(resultset.Relationships) != 1 {
+		t.Errorf("expected 1 relationship, got %d", len(resultset.Relationships))
+	}
+	t.Logf("List incoming relationships of the source as tester10")
+	if resultset := listRelationships(tester10, mountPoint+"/objects/"+source.ID+"/relationships?direction=in"); len(resultset.Relationships) != 1 {
+		t.Errorf("expected 1 relationship, got %d", len(resultset.Relationships))
+	}
+	t.Logf("List outgoing relationships of the report as tester1 who cannot read the source")
+	if resultset := listRelationships(tester1, uriReportRelationships+"?direction=out"); len(resultset.Relationships) != 0 {
+		t.Errorf("expected relationship to source to be hidden, got %d", len(resultset.Relationships))
+	}
+
+	t.Logf("List relationships with an invalid direction as tester10")
+	req = makeHTTPRequestFromInterface(t, "GET", uriReportRelationships+"?direction=sideways", nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 400, res, "Bad status when listing with invalid direction")
+	util.FinishBody(res.Body)
+
+	t.Logf("Remove the relationship from the source as tester10")
+	req = makeHTTPRequestFromInterface(t, "DELETE", mountPoint+"/objects/"+source.ID+"/relationships/"+relationship.ID, nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 404, res, "Bad status when removing relationship from target")
+	util.FinishBody(res.Body)
+
+	t.Logf("Remove the relationship from the report as tester10")
+	req = makeHTTPRequestFromInterface(t, "DELETE", uriReportRelationships+"/"+relationship.ID, nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when removing relationship")
+	util.FinishBody(res.Body)
+
+	t.Logf("List relationships of the report as tester10 after removal")
+	if resultset := listRelationships(tester10, uriReportRelationships); len(resultset.Relationships) != 0 {
+		t.Errorf("expected no relationships after removal, got %d", len(resultset.Relationships))
+	}
+}
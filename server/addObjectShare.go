@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"golang.org/x/net/context"
@@ -33,82 +34,24 @@ func (h AppServer) addObjectShare(ctx context.Context, w http.ResponseWriter, r
 		return herr
 	}
 
-	dp := ciphertext.FindCiphertextCacheByObject(&dbObject)
-	masterKey := dp.GetMasterKey()
+	// Retained for propagation, as the permissions are reduced against those already granted
+	shared := make([]models.ODObjectPermission, len(permissions))
+	copy(shared, permissions)
 
 	// Only proceed if there were permissions provided
 	if len(permissions) == 0 {
 		logger.Info("No permissions derived from share for adding.")
 	} else {
-		permissionsChanged := false
-
-		// Check if database object has a read permission for everyone
-		dbHasEveryone := hasPermissionsForGrantee(&dbObject, models.EveryoneGroup)
-
-		// Check if removing everyone
-		removingEveryone := false
-		if dbHasEveryone {
-			for _, permission := range permissions {
-				if permission.AllowRead && !isPermissionFor(&permission, models.EveryoneGroup) {
-					removePermissionsForGrantee(&dbObject, models.EveryoneGroup)
-					// since removed everyone, reset the flag so we dont do this check for every permission
-					dbHasEveryone = false
-					removingEveryone = true
-					permissionsChanged = true
-				}
-			}
-		}
-
-		// Force Owner CRUDS
-		if removingEveryone {
-			_, ownerR := models.PermissionForOwner(dbObject.OwnedBy.String)
-			permissions = append(permissions, ownerR)
-		}
-
-		// Iterate the permissions, condensing to only new being added
-		for _, permission := range permissions {
-
-			// Verify that permission settings are allowed for user's rollupPermission
-			if herr := verifyPermissionToShare(rollupPermission, permission, false); herr != nil {
-				return herr
-			}
-
-			// Metadata for this permission to be created
-			permission.ObjectID = dbObject.ID
-			permission.CreatedBy = caller.DistinguishedName
-			permission.ExplicitShare = true
-
-			// If after removing existing grants there are no more permissions, ...
-			if reduceGrantsFromExistingPermissionsLeavesNone(dbObject.Permissions, &permission) {
-				// stop processing this permission
-				continue
-			}
-
-			// Add to list of permissions being added
-			permissionsChanged = true
-			models.CopyEncryptKey(masterKey, &rollupPermission, &permission)
-			dbObject.Permissions = append(dbObject.Permissions, permission)
+		permissionsChanged, herr := h.addSharePermissions(ctx, &dbObject, rollupPermission, permissions)
+		if herr != nil {
+			return herr
 		}
 
 		// If actual changes from removing everyone or adding capabilities...
 		if permissionsChanged {
 			dbObject.ModifiedBy = caller.DistinguishedName
-			// Post modification authorization checks
-			aacAuth := auth.NewAACAuth(logger, h.AAC)
-			modifiedACM := dbObject.RawAcm.String
-			// Rebuild
-			modifiedACM, err = aacAuth.RebuildACMFromPermissions(dbObject.Permissions, modifiedACM)
-			if err != nil {
-				return NewAppError(http.StatusInternalServerError, err, "Error rebuilding ACM from revised permissions")
-			}
-			// Flatten
-			var msgs []string
-			modifiedACM, msgs, err = aacAuth.GetFlattenedACM(modifiedACM)
-			if err != nil {
-				return NewAppError(authHTTPErr(err), err, err.Error()+strings.Join(msgs, "/"))
-			}
-			dbObject.RawAcm = models.ToNullString(modifiedACM)
 			// Check that caller has access
+			aacAuth := auth.NewAACAuth(logger, h.AAC)
 			if _, err := aacAuth.IsUserAuthorizedForACM(caller.DistinguishedName, dbObject.RawAcm.String); err != nil {
 				return NewAppError(authHTTPErr(err), err, err.Error())
 			}
@@ -145,10 +88,95 @@ func (h AppServer) addObjectShare(ctx context.Context, w http.ResponseWriter, r
 	gem.Payload = events.WithEnrichedPayload(gem.Payload, apiResponse)
 	h.EventQueue.Publish(gem)
 
+	// Apply the share to descendants in the background, as a job located by the response
+	if recursive, _ := strconv.ParseBool(r.URL.Query().Get("recursive")); recursive && len(shared) > 0 {
+		if herr := h.queuePropagation(ctx, w, updatedObject, propagationShare, shared); herr != nil {
+			return herr
+		}
+	}
+
 	jsonResponse(w, apiResponse)
 	return nil
 }
 
+// addSharePermissions adds the permissions being shared to an object, removing
+// everyone when sharing with others, and rebuilds the ACM of the object from
+// them. It reports whether the object was changed. The caller is responsible
+// for authorizing the changed ACM and saving the object.
+func (h AppServer) addSharePermissions(ctx context.Context, dbObject *models.ODObject, rollupPermission models.ODObjectPermission, permissions []models.ODObjectPermission) (bool, *AppError) {
+	caller, _ := CallerFromContext(ctx)
+	logger := LoggerFromContext(ctx)
+	masterKey := ciphertext.FindCiphertextCacheByObject(dbObject).GetMasterKey()
+	permissionsChanged := false
+
+	// Check if database object has a read permission for everyone
+	dbHasEveryone := hasPermissionsForGrantee(dbObject, models.EveryoneGroup)
+
+	// Check if removing everyone
+	removingEveryone := false
+	if dbHasEveryone {
+		for _, permission := range permissions {
+			if permission.AllowRead && !isPermissionFor(&permission, models.EveryoneGroup) {
+				removePermissionsForGrantee(dbObject, models.EveryoneGroup)
+				// since removed everyone, reset the flag so we dont do this check for every permission
+				dbHasEveryone = false
+				removingEveryone = true
+				permissionsChanged = true
+			}
+		}
+	}
+
+	// Force Owner CRUDS
+	if removingEveryone {
+		_, ownerR := models.PermissionForOwner(dbObject.OwnedBy.String)
+		permissions = append(permissions, ownerR)
+	}
+
+	// Iterate the permissions, condensing to only new being added
+	for _, permission := range permissions {
+
+		// Verify that permission settings are allowed for user's rollupPermission
+		if herr := verifyPermissionToShare(rollupPermission, permission, false); herr != nil {
+			return false, herr
+		}
+
+		// Metadata for this permission to be created
+		permission.ObjectID = dbObject.ID
+		permission.CreatedBy = caller.DistinguishedName
+		permission.ExplicitShare = true
+
+		// If after removing existing grants there are no more permissions, ...
+		if reduceGrantsFromExistingPermissionsLeavesNone(dbObject.Permissions, &permission) {
+			// stop processing this permission
+			continue
+		}
+
+		// Add to list of permissions being added
+		permissionsChanged = true
+		models.CopyEncryptKey(masterKey, &rollupPermission, &permission)
+		dbObject.Permissions = append(dbObject.Permissions, permission)
+	}
+	if !permissionsChanged {
+		return false, nil
+	}
+
+	// Post modification authorization checks
+	aacAuth := auth.NewAACAuth(logger, h.AAC)
+	// Rebuild
+	modifiedACM, err := aacAuth.RebuildACMFromPermissions(dbObject.Permissions, dbObject.RawAcm.String)
+	if err != nil {
+		return false, NewAppError(http.StatusInternalServerError, err, "Error rebuilding ACM from revised permissions")
+	}
+	// Flatten
+	var msgs []string
+	modifiedACM, msgs, err = aacAuth.GetFlattenedACM(modifiedACM)
+	if err != nil {
+		return false, NewAppError(authHTTPErr(err), err, err.Error()+strings.Join(msgs, "/"))
+	}
+	dbObject.RawAcm = models.ToNullString(modifiedACM)
+	return true, nil
+}
+
 func commonObjectSharePrep(ctx context.Context, r *http.Request) (models.ODObjectPermission, []models.ODObjectPermission, models.ODObject, *AppError) {
 
 	// Get dao value from ctx.
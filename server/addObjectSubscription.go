@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+	"bitbucket.di2e.net/dime/object-drive-server/services/webhook"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// maxCallbackURLLength is the size of the callbackUrl column.
+const maxCallbackURLLength = 2048
+
+func (h AppServer) addObjectSubscription(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	// Subscriptions do not change the object, so these are access events that
+	// are not themselves delivered to subscribers.
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	requestSubscription, err := parseSubscriptionRequest(r, h.Webhook)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Error parsing request")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// The object subscribed to is the one in the URI, and must be readable
+	dbObject, herr := h.commonObjectReadPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(dbObject.ID)
+	gem.Payload.ChangeToken = dbObject.ChangeToken
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(dbObject.ID))
+	gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(dbObject))
+
+	requestSubscription.ObjectID = dbObject.ID
+	user := models.ODUser{DistinguishedName: caller.DistinguishedName}
+	dbSubscription, err := dao.AddSubscription(user, requestSubscription)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error adding subscription")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	apiResponse := mapping.MapODUserObjectSubscriptionToSubscription(&dbSubscription)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// parseSubscriptionRequest decodes and validates the subscription settings
+// provided in the request body.
+func parseSubscriptionRequest(r *http.Request, conf config.WebhookConfiguration) (models.ODUserObjectSubscription, error) {
+	var jsonSubscription protocol.Subscription
+	if err := util.FullDecode(r.Body, &jsonSubscription); err != nil {
+		return models.ODUserObjectSubscription{}, err
+	}
+	// Identifiers come from the URI, not the body
+	jsonSubscription.ID = ""
+	jsonSubscription.ObjectID = ""
+	subscription, err := mapping.MapSubscriptionToODUserObjectSubscription(&jsonSubscription)
+	if err != nil {
+		return subscription, err
+	}
+	if !subscription.OnCreate && !subscription.OnUpdate && !subscription.OnDelete {
+		return subscription, errors.New("at least one of onCreate, onUpdate, or onDelete must be true")
+	}
+	if err := validateCallbackURL(subscription.CallbackURL, conf); err != nil {
+		return subscription, err
+	}
+	return subscription, nil
+}
+
+// validateCallbackURL ensures notifications can be posted to the callback URL,
+// and that it is one of the callbacks allowed by the webhook configuration.
+func validateCallbackURL(callbackURL string, conf config.WebhookConfiguration) error {
+	if len(callbackURL) == 0 {
+		return errors.New("callbackUrl must be specified")
+	}
+	if len(callbackURL) > maxCallbackURLLength {
+		return errors.New("callbackUrl exceeds maximum length")
+	}
+	return webhook.CheckCallbackURL(callbackURL, conf.AllowedCallbacks, conf.AllowPrivate)
+}
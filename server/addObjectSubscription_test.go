@@ -0,0 +1,109 @@
+package server_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestAddUpdateAndRemoveObjectSubscription(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	tester10 := 0
+	tester1 := 1
+
+	t.Logf("Create a folder as tester10 that is not shared")
+	folder, err := makeFolderWithACMViaJSON("Test Folder for Subscriptions "+strconv.FormatInt(time.Now().UTC().UnixNano(), 10), ValidACMUnclassifiedFOUOSharedToTester10, tester10)
+	failNowOnErr(t, err, "Unable to create folder")
+	uriSubscribe := mountPoint + "/objects/" + folder.ID + "/subscriptions"
+	request := protocol.Subscription{OnUpdate: true, OnDelete: true, Recursive: true, CallbackURL: "https://localhost:8443/callback"}
+
+	t.Logf("Subscribe without any events as tester10")
+	req := makeHTTPRequestFromInterface(t, "POST", uriSubscribe, protocol.Subscription{CallbackURL: request.CallbackURL})
+	res, err := clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 400, res, "Bad status when subscribing without events")
+	util.FinishBody(res.Body)
+
+	t.Logf("Subscribe with an invalid callback url as tester10")
+	req = makeHTTPRequestFromInterface(t, "POST", uriSubscribe, protocol.Subscription{OnUpdate: true, CallbackURL: "ftp://localhost/callback"})
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 400, res, "Bad status when subscribing with invalid callback")
+	util.FinishBody(res.Body)
+
+	t.Logf("Subscribe as tester1 who cannot read the folder")
+	req = makeHTTPRequestFromInterface(t, "POST", uriSubscribe, request)
+	res, err = clients[tester1].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 403, res, "Bad status when subscribing without permission")
+	util.FinishBody(res.Body)
+
+	t.Logf("Subscribe as tester10")
+	req = makeHTTPRequestFromInterface(t, "POST", uriSubscribe, request)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when subscribing")
+	var subscription protocol.Subscription
+	err = util.FullDecode(res.Body, &subscription)
+	failNowOnErr(t, err, "Error decoding json to Subscription")
+	if subscription.ObjectID != folder.ID || !subscription.Recursive || subscription.OnCreate {
+		t.Errorf("subscription was not created as requested")
+	}
+	uriSubscription := mountPoint + "/subscriptions/" + subscription.ID
+
+	t.Logf("Get the subscription as tester1 who does not own it")
+	req = makeHTTPRequestFromInterface(t, "GET", uriSubscription, nil)
+	res, err = clients[tester1].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 403, res, "Bad status when getting another user's subscription")
+	util.FinishBody(res.Body)
+
+	t.Logf("Update the subscription as tester10")
+	request.OnCreate = true
+	req = makeHTTPRequestFromInterface(t, "POST", uriSubscription, request)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when updating subscription")
+	err = util.FullDecode(res.Body, &subscription)
+	failNowOnErr(t, err, "Error decoding json to Subscription")
+	if !subscription.OnCreate {
+		t.Errorf("expected subscription to be updated")
+	}
+
+	t.Logf("List subscriptions as tester10")
+	req = makeHTTPRequestFromInterface(t, "GET", mountPoint+"/subscriptions", nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when listing subscriptions")
+	var resultset protocol.SubscriptionResultset
+	err = util.FullDecode(res.Body, &resultset)
+	failNowOnErr(t, err, "Error decoding json to SubscriptionResultset")
+	found := false
+	for _, s := range resultset.Subscriptions {
+		if s.ID == subscription.ID {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected subscription to be listed")
+	}
+
+	t.Logf("Remove the subscription as tester10")
+	req = makeHTTPRequestFromInterface(t, "DELETE", uriSubscription, nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when removing subscription")
+	util.FinishBody(res.Body)
+
+	t.Logf("Get the subscription as tester10 after removal")
+	req = makeHTTPRequestFromInterface(t, "GET", uriSubscription, nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 410, res, "Bad status when getting removed subscription")
+	util.FinishBody(res.Body)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/auth"
+	"bitbucket.di2e.net/dime/object-drive-server/events"
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+// maxTagNameLength corresponds to the size of the name column on object_tag
+const maxTagNameLength = 255
+
+func (h AppServer) addObjectTag(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "update"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "MODIFY")
+
+	dbObject, tagName, herr := h.commonObjectTagPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(dbObject.ID)
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(dbObject.ID))
+	auditOriginal := NewResourceFromObject(dbObject)
+
+	tag := models.ODObjectTag{Name: tagName, CreatedBy: caller.DistinguishedName}
+	if _, err := dao.AddTagToObject(dbObject, &tag); err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error adding tag to object")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	updatedObject, err := dao.GetObject(dbObject, true)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error retrieving object")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	auditModified := NewResourceFromObject(updatedObject)
+	apiResponse := mapping.MapODObjectToObject(&updatedObject).WithCallerPermission(protocolCaller(caller))
+
+	gem.Payload.ChangeToken = apiResponse.ChangeToken
+	gem.Payload.Audit = audit.WithModifiedPairList(gem.Payload.Audit, audit.NewModifiedResourcePair(auditOriginal, auditModified))
+	gem.Payload.StreamUpdate = false
+	gem.Payload = events.WithEnrichedPayload(gem.Payload, apiResponse)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// commonObjectTagPrep parses the object and tag name from the request URI,
+// retrieves the object, and verifies the caller is permitted to alter its tags.
+func (h AppServer) commonObjectTagPrep(ctx context.Context) (models.ODObject, string, *AppError) {
+
+	caller, _ := CallerFromContext(ctx)
+	logger := LoggerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+
+	requestObject, tagName, err := parseObjectTagRequest(ctx)
+	if err != nil {
+		return requestObject, tagName, NewAppError(http.StatusBadRequest, err, err.Error())
+	}
+
+	dbObject, err := dao.GetObject(requestObject, true)
+	if err != nil {
+		return dbObject, tagName, NewAppError(http.StatusInternalServerError, err, "Error retrieving object")
+	}
+
+	if ok, _ := isUserAllowedToUpdateWithPermission(ctx, &dbObject); !ok {
+		return dbObject, tagName, NewAppError(http.StatusForbidden, errors.New("forbidden"), "user does not have permission to update this object")
+	}
+
+	aacAuth := auth.NewAACAuth(logger, h.AAC)
+	if _, err := aacAuth.IsUserAuthorizedForACM(caller.DistinguishedName, dbObject.RawAcm.String); err != nil {
+		return dbObject, tagName, NewAppError(authHTTPErr(err), err, err.Error())
+	}
+
+	if dbObject.IsDeleted {
+		switch {
+		case dbObject.IsExpunged:
+			return dbObject, tagName, NewAppError(http.StatusGone, nil, "The object no longer exists.")
+		default:
+			return dbObject, tagName, NewAppError(http.StatusConflict, nil, "The object is currently in the trash. Use removeObjectFromTrash to restore it")
+		}
+	}
+
+	return dbObject, tagName, nil
+}
+
+func parseObjectTagRequest(ctx context.Context) (models.ODObject, string, error) {
+	requestObject, err := parseGetObjectRequest(ctx)
+	if err != nil {
+		return requestObject, "", err
+	}
+	// Captured from the path after it is unescaped, so a tag name may contain '%'
+	captured, _ := CaptureGroupsFromContext(ctx)
+	tagName := strings.TrimSpace(captured["tagName"])
+	if len(tagName) == 0 {
+		return requestObject, "", errors.New("could not extract tag name from URI")
+	}
+	if len(tagName) > maxTagNameLength {
+		return requestObject, "", errors.New("tag name exceeds maximum length")
+	}
+	return requestObject, tagName, nil
+}
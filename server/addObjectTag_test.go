@@ -0,0 +1,66 @@
+package server_test
+
+import (
+	"net/url"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestAddAndRemoveObjectTag(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	tester10 := 0
+	tester1 := 1
+
+	t.Logf("Create a folder as tester10")
+	folder := makeFolderViaJSON("Test Folder for Tags", tester10, t)
+	tagName := "tagged 100%20 " + folder.ID
+	uriTag := mountPoint + "/objects/" + folder.ID + "/tags/" + url.PathEscape(tagName)
+
+	t.Logf("Add tag as tester10")
+	req := makeHTTPRequestFromInterface(t, "POST", uriTag, nil)
+	res, err := clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when adding tag to object")
+	var taggedObject protocol.Object
+	err = util.FullDecode(res.Body, &taggedObject)
+	failNowOnErr(t, err, "Error decoding json to Object")
+	if len(taggedObject.Tags) != 1 || taggedObject.Tags[0] != tagName {
+		t.Errorf("expected tags to be [%s], got %v", tagName, taggedObject.Tags)
+	}
+
+	t.Logf("Add tag as tester1 who cannot update the folder")
+	req = makeHTTPRequestFromInterface(t, "POST", uriTag, nil)
+	res, err = clients[tester1].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 403, res, "Bad status when adding tag without permission")
+	util.FinishBody(res.Body)
+
+	t.Logf("Search for the folder by tag")
+	uriSearch := mountPoint + "/search/" + url.PathEscape(folder.Name) + "?filterField=tag&condition=equals&expression=" + url.QueryEscape(tagName)
+	req = makeHTTPRequestFromInterface(t, "GET", uriSearch, nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when searching by tag")
+	var resultset protocol.ObjectResultset
+	err = util.FullDecode(res.Body, &resultset)
+	failNowOnErr(t, err, "Error decoding json to ObjectResultset")
+	if resultset.TotalRows != 1 {
+		t.Errorf("expected 1 object filtered by tag, got %d", resultset.TotalRows)
+	}
+
+	t.Logf("Remove tag as tester10")
+	req = makeHTTPRequestFromInterface(t, "DELETE", uriTag, nil)
+	res, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "Unable to do request")
+	statusMustBe(t, 200, res, "Bad status when removing tag from object")
+	var untaggedObject protocol.Object
+	err = util.FullDecode(res.Body, &untaggedObject)
+	failNowOnErr(t, err, "Error decoding json to Object")
+	if len(untaggedObject.Tags) != 0 {
+		t.Errorf("expected no tags after removal, got %v", untaggedObject.Tags)
+	}
+}
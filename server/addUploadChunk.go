@@ -0,0 +1,113 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+// addUploadChunk receives a numbered chunk of a resumable upload as the body of
+// the request, encrypting it into the cache at the offset given. Chunks may be
+// sent in any order, and sent again to replace a chunk that failed.
+func (h AppServer) addUploadChunk(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	logger := LoggerFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	id, herr := uploadSessionIDFromCaptureGroups(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	captured, _ := CaptureGroupsFromContext(ctx)
+	number, err := strconv.Atoi(captured["chunkNumber"])
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "invalid chunk number in URI")
+		h.publishError(gem, herr)
+		return herr
+	}
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		herr := NewAppError(http.StatusBadRequest, errors.New("invalid offset"), "offset must be specified as a non-negative integer")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	uploadSessionLock.Lock()
+	session, herr := loadUploadSession(ctx, id)
+	uploadSessionLock.Unlock()
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	if offset >= session.ContentSize {
+		herr := NewAppError(http.StatusBadRequest, errors.New("offset beyond content"), "offset must be less than the contentSize of the upload")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// Chunks are written outside of the lock, so that they may be received
+	// concurrently.
+	dp := uploadSessionCache(id)
+	fName := dp.Files().Resolve(uploadSessionFileName(id, ciphertext.FileStateUploading))
+	f, err := os.OpenFile(fName, os.O_WRONLY, 0)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "Unable to open file receiving upload")
+		h.publishError(gem, herr)
+		return herr
+	}
+	length, err := h.writeUploadChunk(logger, f, io.LimitReader(r.Body, session.ContentSize-offset), session.fileKey(), session.EncryptIV, offset)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "Unable to write chunk")
+		if err == io.ErrUnexpectedEOF {
+			herr = NewAppError(http.StatusBadRequest, err, "Chunk was not completely received")
+		}
+		h.publishError(gem, herr)
+		return herr
+	}
+	if n, _ := r.Body.Read(make([]byte, 1)); n > 0 {
+		herr := NewAppError(http.StatusBadRequest, errors.New("chunk too long"), "chunk extends beyond the contentSize of the upload")
+		h.publishError(gem, herr)
+		return herr
+	}
+	if length == 0 {
+		herr := NewAppError(http.StatusBadRequest, errors.New("chunk empty"), "chunk must not be empty")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// The session may have received other chunks while this one was written
+	uploadSessionLock.Lock()
+	defer uploadSessionLock.Unlock()
+	session, herr = loadUploadSession(ctx, id)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	session.addChunk(protocol.UploadChunk{Number: number, Offset: offset, Length: length})
+	session.ModifiedDate = time.Now().UTC()
+	if err := saveUploadSession(session); err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "Unable to save upload session")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	jsonResponse(w, session.toProtocol())
+	h.publishSuccess(gem, w)
+	return nil
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// archiveFormat describes an archive format that objects may be exported as
+type archiveFormat struct {
+	ContentType string
+	// Extensions are the file name extensions of the format, the first being
+	// used for default file names
+	Extensions []string
+}
+
+// archiveFormats are the supported export formats, keyed by the name requested
+var archiveFormats = map[string]archiveFormat{
+	"zip": archiveFormat{ContentType: "application/zip", Extensions: []string{".zip"}},
+	"tar": archiveFormat{ContentType: "application/x-tar", Extensions: []string{".tar"}},
+	"tgz": archiveFormat{ContentType: "application/gzip", Extensions: []string{".tgz", ".tar.gz"}},
+}
+
+// hasExtension reports whether the file name ends in one of the extensions of the format
+func (f archiveFormat) hasExtension(name string) bool {
+	for _, ext := range f.Extensions {
+		if strings.HasSuffix(name, ext) && len(name) > len(ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveWriter writes files to an archive as a stream. The size of a file
+// must be known when it is created, as tar records it ahead of the content,
+// and exactly that many bytes written to it.
+type archiveWriter interface {
+	CreateFile(name string, size int64, modTime time.Time) (io.Writer, error)
+	Close() error
+}
+
+// newArchiveWriter returns a writer of the named format onto w
+func newArchiveWriter(format string, w io.Writer) archiveWriter {
+	switch format {
+	case "tar":
+		return &tarArchiveWriter{tw: tar.NewWriter(w)}
+	case "tgz":
+		gw := gzip.NewWriter(w)
+		return &tarArchiveWriter{tw: tar.NewWriter(gw), gw: gw}
+	default:
+		return &zipArchiveWriter{zw: zip.NewWriter(w)}
+	}
+}
+
+// writeArchiveFile writes a file held in memory, such as a manifest, to the archive
+func writeArchiveFile(aw archiveWriter, name string, data []byte, modTime time.Time) error {
+	w, err := aw.CreateFile(name, int64(len(data)), modTime)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchiveWriter) CreateFile(name string, size int64, modTime time.Time) (io.Writer, error) {
+	// Write the file header out, to properly set timestamps and permissions
+	header, err := zip.FileInfoHeader(&zipFileInfo{
+		size:    size,
+		name:    path.Base(name),
+		modTime: modTime,
+		mode:    os.FileMode(0600),
+	})
+	if err != nil {
+		return nil, err
+	}
+	header.Name = path.Clean(name)
+	return a.zw.CreateHeader(header)
+}
+
+func (a *zipArchiveWriter) Close() error {
+	return a.zw.Close()
+}
+
+type tarArchiveWriter struct {
+	tw *tar.Writer
+	// gw is set when the tar is compressed
+	gw *gzip.Writer
+}
+
+func (a *tarArchiveWriter) CreateFile(name string, size int64, modTime time.Time) (io.Writer, error) {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     path.Clean(name),
+		Size:     size,
+		Mode:     0600,
+		ModTime:  modTime,
+	}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	return a.tw, nil
+}
+
+func (a *tarArchiveWriter) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	if a.gw != nil {
+		return a.gw.Close()
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+)
+
+// errRangeNotSatisfiable indicates that none of the ranges requested overlap
+// the content, and should be reported as 416 Range Not Satisfiable
+var errRangeNotSatisfiable = errors.New("requested range not satisfiable")
+
+// ParseByteRanges resolves the Range header of a request against content of the
+// given size, as described in RFC 7233. Each range returned is inclusive and
+// within the content. No ranges are returned when there is no Range header, its
+// unit is not bytes, or the ranges requested total more than the content itself,
+// as the whole content is sent instead. Ranges starting beyond the content are
+// dropped, and errRangeNotSatisfiable returned if no others remain. Any other
+// error means the header is invalid, and should be ignored.
+func ParseByteRanges(spec string, size int64) ([]crypto.ByteRange, error) {
+	if len(spec) == 0 {
+		return nil, nil
+	}
+	eq := strings.Index(spec, "=")
+	if eq < 0 || strings.TrimSpace(spec[:eq]) != "bytes" {
+		return nil, nil
+	}
+	var byteRanges []crypto.ByteRange
+	var requested int
+	var total int64
+	for _, rangeSpec := range strings.Split(spec[eq+1:], ",") {
+		rangeSpec = strings.TrimSpace(rangeSpec)
+		if len(rangeSpec) == 0 {
+			continue
+		}
+		requested++
+		dash := strings.Index(rangeSpec, "-")
+		if dash < 0 {
+			return nil, fmt.Errorf("invalid byte range %q", rangeSpec)
+		}
+		first, last := strings.TrimSpace(rangeSpec[:dash]), strings.TrimSpace(rangeSpec[dash+1:])
+		var byteRange crypto.ByteRange
+		if len(first) == 0 {
+			// A suffix range is the final bytes of the content
+			suffix, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || suffix < 0 {
+				return nil, fmt.Errorf("invalid byte range %q", rangeSpec)
+			}
+			if suffix == 0 || size == 0 {
+				continue
+			}
+			if suffix > size {
+				suffix = size
+			}
+			byteRange = crypto.ByteRange{Start: size - suffix, Stop: size - 1}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, fmt.Errorf("invalid byte range %q", rangeSpec)
+			}
+			stop := size - 1
+			if len(last) > 0 {
+				stop, err = strconv.ParseInt(last, 10, 64)
+				if err != nil || stop < start {
+					return nil, fmt.Errorf("invalid byte range %q", rangeSpec)
+				}
+				if stop > size-1 {
+					stop = size - 1
+				}
+			}
+			if start >= size {
+				continue
+			}
+			byteRange = crypto.ByteRange{Start: start, Stop: stop}
+		}
+		byteRanges = append(byteRanges, byteRange)
+		total += byteRange.Stop - byteRange.Start + 1
+	}
+	if requested == 0 {
+		return nil, fmt.Errorf("invalid byte range %q", spec)
+	}
+	if len(byteRanges) == 0 {
+		return nil, errRangeNotSatisfiable
+	}
+	if total > size {
+		return nil, nil
+	}
+	return byteRanges, nil
+}
+
+// isNotModified evaluates If-None-Match, or failing that If-Modified-Since, to
+// determine whether the client already has the current content
+func isNotModified(r *http.Request, etag string, modified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); len(ifNoneMatch) > 0 {
+		// Weak comparison is used, as the client only needs to know that its copy is current
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ifModifiedSince := r.Header.Get("If-Modified-Since"); len(ifModifiedSince) > 0 && !modified.IsZero() {
+		if t, err := http.ParseTime(ifModifiedSince); err == nil {
+			return !modified.Truncate(time.Second).After(t)
+		}
+	}
+	return false
+}
+
+// isRangeCurrent evaluates If-Range to determine whether the ranges requested
+// should be sent. When the content has changed since the client received part
+// of it, the whole content is sent instead.
+func isRangeCurrent(r *http.Request, etag string, modified time.Time) bool {
+	ifRange := r.Header.Get("If-Range")
+	if len(ifRange) == 0 {
+		return true
+	}
+	// Strong comparison is required, so weak entity tags never match
+	if strings.HasPrefix(ifRange, `"`) {
+		return ifRange == etag
+	}
+	t, err := http.ParseTime(ifRange)
+	if err != nil || modified.IsZero() {
+		return false
+	}
+	return modified.Truncate(time.Second).Equal(t)
+}
+
+// byteRangePartHeader is the header of a part of a multipart/byteranges response
+func byteRangePartHeader(byteRange crypto.ByteRange, size int64, contentType string) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Type":  {contentType},
+		"Content-Range": {fmt.Sprintf("bytes %d-%d/%d", byteRange.Start, byteRange.Stop, size)},
+	}
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter int64
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
+
+// writeMultipartByteRanges sends a 206 Partial Content response of more than one
+// range as multipart/byteranges. The send function writes the bytes of a range
+// of the content. Returns the number of bytes of content sent.
+func writeMultipartByteRanges(w http.ResponseWriter, byteRanges []crypto.ByteRange, size int64, contentType string, send func(io.Writer, crypto.ByteRange) (int64, error)) (int64, error) {
+	mw := multipart.NewWriter(w)
+
+	// The length of the response is known in advance from the part headers
+	var counter countingWriter
+	cw := multipart.NewWriter(&counter)
+	cw.SetBoundary(mw.Boundary())
+	var contentLength int64
+	for _, byteRange := range byteRanges {
+		cw.CreatePart(byteRangePartHeader(byteRange, size, contentType))
+		contentLength += byteRange.Stop - byteRange.Start + 1
+	}
+	cw.Close()
+	contentLength += int64(counter)
+
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	w.Header().Set("Content-Length", strconv.FormatInt(contentLength, 10))
+	w.Header().Del("Content-Range")
+	w.WriteHeader(http.StatusPartialContent)
+
+	var sent int64
+	for _, byteRange := range byteRanges {
+		part, err := mw.CreatePart(byteRangePartHeader(byteRange, size, contentType))
+		if err != nil {
+			return sent, err
+		}
+		n, err := send(part, byteRange)
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, mw.Close()
+}
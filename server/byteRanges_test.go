@@ -0,0 +1,65 @@
+package server_test
+
+import (
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/server"
+)
+
+func TestParseByteRanges(t *testing.T) {
+	size := int64(1000)
+	cases := []struct {
+		spec     string
+		expected []crypto.ByteRange
+		invalid  bool
+	}{
+		{spec: ""},
+		{spec: "items=0-5"},
+		{spec: "bytes=0-499", expected: []crypto.ByteRange{{Start: 0, Stop: 499}}},
+		{spec: "bytes=500-", expected: []crypto.ByteRange{{Start: 500, Stop: 999}}},
+		{spec: "bytes=-200", expected: []crypto.ByteRange{{Start: 800, Stop: 999}}},
+		{spec: "bytes=-2000", expected: []crypto.ByteRange{{Start: 0, Stop: 999}}},
+		{spec: "bytes=900-1200", expected: []crypto.ByteRange{{Start: 900, Stop: 999}}},
+		{spec: "bytes=0-9, 20-29,-10", expected: []crypto.ByteRange{{Start: 0, Stop: 9}, {Start: 20, Stop: 29}, {Start: 990, Stop: 999}}},
+		{spec: "bytes=0-9,1000-1010", expected: []crypto.ByteRange{{Start: 0, Stop: 9}}},
+		// Ranges totalling more than the content are ignored
+		{spec: "bytes=0-999,0-999"},
+		{spec: "bytes=5-1", invalid: true},
+		{spec: "bytes=abc-", invalid: true},
+		{spec: "bytes=", invalid: true},
+		{spec: "bytes=0-4,abc", invalid: true},
+	}
+	for _, c := range cases {
+		byteRanges, err := server.ParseByteRanges(c.spec, size)
+		if c.invalid {
+			// An invalid Range header is ignored, so the whole content is sent
+			if err == nil || err.Error() == "requested range not satisfiable" {
+				t.Errorf("%q: expected invalid byte range, got %v", c.spec, err)
+			}
+			if byteRanges != nil {
+				t.Errorf("%q: expected whole content, got %v", c.spec, byteRanges)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", c.spec, err)
+			continue
+		}
+		if len(byteRanges) != len(c.expected) {
+			t.Errorf("%q: expected %v, got %v", c.spec, c.expected, byteRanges)
+			continue
+		}
+		for i := range c.expected {
+			if byteRanges[i] != c.expected[i] {
+				t.Errorf("%q: expected %v, got %v", c.spec, c.expected, byteRanges)
+			}
+		}
+	}
+
+	for _, spec := range []string{"bytes=1000-", "bytes=-0", "bytes=2000-3000,1000-"} {
+		if _, err := server.ParseByteRanges(spec, size); err == nil {
+			t.Errorf("%q: expected range not satisfiable", spec)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
 	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
 	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
@@ -54,22 +55,8 @@ func (h AppServer) getCiphertext(ctx context.Context, w http.ResponseWriter, r *
 	rName := ciphertext.FileId(captureGroups["rname"])
 	dp := ciphertext.FindCiphertextCache(zone)
 
-	//If there is a byte range, then use it.
-	startAt := int64(0)
-	byteRange, err := extractByteRange(r)
-	if err != nil {
-		herr := NewAppError(http.StatusBadRequest, err, "byte range parse fail")
-		h.publishError(gem, herr)
-		return herr
-	}
-	//We just want to know where to start from, and stream the whole file
-	//until the client stops reading it.
-	if byteRange != nil {
-		startAt = byteRange.Start
-	}
-
-	//Send back the byte range asked for
-	f, length, err := ciphertext.UseLocalFile(logger, dp, rName, startAt)
+	//Find the whole ciphertext, so that the byte ranges asked for can be resolved against it
+	f, length, err := ciphertext.UseLocalFile(logger, dp, rName, 0)
 	if err != nil {
 		//Keep it quiet in the case of not found
 		herr := NewAppError(http.StatusInternalServerError, err, "error looking in p2p cache")
@@ -81,21 +68,59 @@ func (h AppServer) getCiphertext(ctx context.Context, w http.ResponseWriter, r *
 		h.publishSuccess(gem, w)
 		return herr
 	}
+	defer f.Close()
 	if length < 0 {
 		herr := NewAppError(http.StatusInternalServerError, nil, "p2p bad legnth")
 		h.publishError(gem, herr)
 		logger.Error("p2p bad length", zap.Int64("Content-Length", length))
+		return herr
 	}
-	defer f.Close()
 
-	w.Header().Set("Content-Type", "application/octet-stream")
-	//w.Header().Set("Content-Length", fmt.Sprintf("%d", length))
-	byteCount, err := io.Copy(w, f)
+	//If there are byte ranges, then use them.
+	byteRanges, err := ParseByteRanges(r.Header.Get("Range"), length)
+	if err == errRangeNotSatisfiable {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", length))
+		herr := NewAppError(http.StatusRequestedRangeNotSatisfiable, err, "requested range not satisfiable")
+		h.publishError(gem, herr)
+		return herr
+	}
+	if err != nil {
+		// An invalid Range header is ignored, and the whole ciphertext sent
+		logger.Info("ignoring invalid byte range", zap.String("range", r.Header.Get("Range")), zap.Error(err))
+		byteRanges = nil
+	}
+	send := func(out io.Writer, byteRange crypto.ByteRange) (int64, error) {
+		if _, err := f.Seek(byteRange.Start, io.SeekStart); err != nil {
+			return 0, err
+		}
+		return io.CopyN(out, f, byteRange.Stop-byteRange.Start+1)
+	}
+
+	//Send back the byte ranges asked for
+	contentType := "application/octet-stream"
+	w.Header().Set("Content-Type", contentType)
+	var byteCount int64
+	var herr *AppError
+	switch len(byteRanges) {
+	case 0:
+		//w.Header().Set("Content-Length", fmt.Sprintf("%d", length))
+		byteCount, err = io.Copy(w, f)
+	case 1:
+		byteRange := byteRanges[0]
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", byteRange.Stop-byteRange.Start+1))
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", byteRange.Start, byteRange.Stop, length))
+		w.WriteHeader(http.StatusPartialContent)
+		herr = NewAppError(http.StatusPartialContent, nil, "Partial Content")
+		byteCount, err = send(w, byteRange)
+	default:
+		herr = NewAppError(http.StatusPartialContent, nil, "Partial Content")
+		byteCount, err = writeMultipartByteRanges(w, byteRanges, length, contentType, send)
+	}
 
 	//It is perfectly normal for a client to only pull part of the data and cut us off
 	if err != nil && strings.Contains(err.Error(), "write: connection reset by peer") == false {
 		logger.Info("p2p copy failure", zap.Error(err), zap.Int64("bytes", byteCount))
 	}
 	h.publishSuccess(gem, w)
-	return nil
+	return herr
 }
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+// commitUploadSession completes a resumable upload once every chunk has been
+// received, creating the object or updating its stream just as if the content
+// had been sent in a single request. The upload session no longer exists
+// afterward, whether or not the commit succeeds.
+func (h AppServer) commitUploadSession(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	logger := LoggerFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	id, herr := uploadSessionIDFromCaptureGroups(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// Once complete, the session is removed so that no more chunks are accepted
+	uploadSessionLock.Lock()
+	session, herr := loadUploadSession(ctx, id)
+	if herr == nil && !session.isComplete() {
+		herr = NewAppError(http.StatusConflict, errors.New("upload incomplete"), "Every chunk of the upload must be received before it is committed")
+	}
+	if herr == nil {
+		dp := uploadSessionCache(id)
+		if err := dp.Files().Remove(uploadSessionFileName(id, uploadSessionExtension)); err != nil {
+			herr = NewAppError(http.StatusInternalServerError, err, "Unable to remove upload session")
+		}
+	}
+	uploadSessionLock.Unlock()
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	if session.Create != nil {
+		gem.Action = "create"
+		gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventCreate")
+		gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "CREATE")
+	} else {
+		gem.Action = "update"
+		gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
+		gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "MODIFY")
+		gem.Payload.ObjectID = session.Update.ID
+	}
+
+	// The object is validated again, as things may have changed since the
+	// upload began
+	request := protocol.UploadSessionRequest{Create: session.Create, Update: session.Update}
+	obj, grant, pathDelimiter, herr := h.uploadSessionObject(ctx, request)
+	if herr != nil {
+		discardUploadSession(logger, id)
+		h.publishError(gem, herr)
+		return herr
+	}
+	if session.Update != nil {
+		gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(obj.ID))
+	}
+	obj.ContentConnector = models.ToNullString(session.ID)
+	// The content is sealed with its own iv, distinct from that of the chunks
+	obj.EncryptIV = crypto.CreateIV()
+	obj.CipherFormat = h.Conf.EncryptableFunctions.CipherFormat
+	// Seal and checksum the content, and rename it to indicate that it can be
+	// moved to permanent storage
+	dp := uploadSessionCache(id)
+	// The key is wrapped again in case the master key was rotated during the session
+	grant.PermissionIV = session.PermissionIV
+	grant.EncryptKey = crypto.ApplyPassphrase(dp.GetMasterKey(), session.PermissionIV, session.fileKey())
+	fileID := ciphertext.ContentConnectorFileId(id)
+	uploading := uploadSessionFileName(id, ciphertext.FileStateUploading)
+	f, err := dp.Files().Open(uploading)
+	if err != nil {
+		discardUploadSession(logger, id)
+		herr := NewAppError(http.StatusInternalServerError, err, "Unable to open uploaded file")
+		h.publishError(gem, herr)
+		return herr
+	}
+	sealing := uploadSessionFileName(id, uploadSealingExtension)
+	out, err := dp.Files().Create(sealing)
+	if err != nil {
+		f.Close()
+		discardUploadSession(logger, id)
+		herr := NewAppError(http.StatusInternalServerError, err, "Unable to create sealed file")
+		h.publishError(gem, herr)
+		return herr
+	}
+	checksum, err := h.sealUploadedContent(logger, f, out, session.fileKey(), session.EncryptIV, obj.EncryptIV)
+	f.Close()
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		discardUploadSession(logger, id)
+		herr := NewAppError(http.StatusInternalServerError, err, "Unable to checksum uploaded file")
+		h.publishError(gem, herr)
+		return herr
+	}
+	dp.Files().Remove(uploading)
+	if err := dp.Files().Rename(sealing, uploadSessionFileName(id, ciphertext.FileStateUploaded)); err != nil {
+		discardUploadSession(logger, id)
+		herr := NewAppError(http.StatusInternalServerError, err, "Unable to rename uploaded file")
+		h.publishError(gem, herr)
+		return herr
+	}
+	obj.ContentHash = checksum
+	obj.ContentSize.Int64 = session.ContentSize
+	obj.ContentSize.Valid = true
+	drained := obj
+	drainFunc := func() { h.Writeback(&drained, fileID, crypto.CiphertextSize(obj.CipherFormat, session.ContentSize)) }
+
+	if session.Create != nil {
+		return h.storeCreatedObject(ctx, w, gem, obj, grant, pathDelimiter, true, drainFunc)
+	}
+	return h.storeUpdatedObjectStream(ctx, w, gem, obj, grant, session.Update.RecursiveShare, drainFunc)
+}
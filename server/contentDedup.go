@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bytes"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+)
+
+// releasedContentPageSize bounds the released content purged at a time
+const releasedContentPageSize = 100
+
+// dedupedUpload is content uploaded for an object that was pointed at content
+// already stored instead. The upload is kept until the object is stored, so
+// that it can be stored as usual if the content shared is released first.
+type dedupedUpload struct {
+	contentConnector models.NullString
+	encryptIV        []byte
+	cipherFormat     int
+	permissionIV     []byte
+	encryptKey       []byte
+}
+
+// restore points the object back at the content uploaded for it, and its
+// permissions at the key the upload was encrypted with
+func (u *dedupedUpload) restore(masterKey string, obj *models.ODObject, grant *models.ODObjectPermission) {
+	obj.ContentConnector = u.contentConnector
+	obj.EncryptIV = u.encryptIV
+	obj.CipherFormat = u.cipherFormat
+	obj.SharesContent = false
+	grant.PermissionIV = u.permissionIV
+	grant.EncryptKey = u.encryptKey
+	grant.PermissionMAC = models.CalculatePermissionMAC(masterKey, grant)
+	for idx := range obj.Permissions {
+		models.CopyEncryptKey(masterKey, grant, &obj.Permissions[idx])
+	}
+}
+
+// discard removes the upload from the cache, once the object is stored with
+// the content it shares or is not stored at all
+func (u *dedupedUpload) discard(logger *zap.Logger, dp ciphertext.CiphertextCache) {
+	removeOrphanedFile(logger, dp, u.contentConnector.String)
+}
+
+// deduplicateContent looks for content already stored in the zone of an
+// object that has just been uploaded, having the same hash, size and ACM. If
+// found, the object is pointed at the existing content, and the upload is
+// returned so that it may be discarded once the object is stored, or stored
+// instead should the existing content be released first. Otherwise the
+// uploaded content is recorded so that later uploads may share it, and nil is
+// returned.
+//
+// When creating, the key of the existing content is given to the grant. An
+// existing object keeps its key across revisions, so an update only shares
+// content that was encrypted with the same key.
+func deduplicateContent(ctx context.Context, obj *models.ODObject, grant *models.ODObjectPermission, asCreate bool) *dedupedUpload {
+	logger := LoggerFromContext(ctx)
+	d := DAOFromContext(ctx)
+	dp := ciphertext.FindCiphertextCacheByObject(obj)
+	// Content is not deduplicated while the master key is being rotated, as the
+	// grants it would share a key with may be wrapped with either key
+	if dp == nil || !dp.Deduplicates() || dp.GetPreviousMasterKey() != "" {
+		return nil
+	}
+	zone := string(ciphertext.ContentConnectorZone(obj.ContentConnector.String))
+	// Failing to deduplicate is not fatal, as the content uploaded is stored as usual
+	contents, err := d.FindContentDedup(*obj, zone)
+	if err != nil {
+		logger.Error("unable to find deduplicated content", zap.Error(err))
+		return nil
+	}
+	masterKey := dp.GetMasterKey()
+	fileKey := crypto.ApplyPassphrase(masterKey, grant.PermissionIV, grant.EncryptKey)
+	for _, content := range contents {
+		contentKey := crypto.ApplyPassphrase(masterKey, content.PermissionIV, content.EncryptKey)
+		if !asCreate && !bytes.Equal(contentKey, fileKey) {
+			continue
+		}
+		logger.Info("deduplicated content", zap.String("uploaded", obj.ContentConnector.String), zap.String("contentConnector", content.ContentConnector))
+		upload := &dedupedUpload{
+			contentConnector: obj.ContentConnector,
+			encryptIV:        obj.EncryptIV,
+			cipherFormat:     obj.CipherFormat,
+			permissionIV:     grant.PermissionIV,
+			encryptKey:       grant.EncryptKey,
+		}
+		obj.ContentConnector = models.ToNullString(content.ContentConnector)
+		obj.EncryptIV = content.EncryptIV
+		obj.CipherFormat = content.CipherFormat
+		obj.SharesContent = true
+		if asCreate {
+			grant.PermissionIV = content.PermissionIV
+			grant.EncryptKey = content.EncryptKey
+			grant.PermissionMAC = models.CalculatePermissionMAC(masterKey, grant)
+		}
+		return upload
+	}
+	recordContentDedup(ctx, *obj, zone)
+	return nil
+}
+
+// storeDedupedUpload is called when the content an object was to share was
+// released before the object was stored. The object is pointed back at its
+// upload, which is recorded so that later uploads may share it.
+func storeDedupedUpload(ctx context.Context, u *dedupedUpload, masterKey string, obj *models.ODObject, grant *models.ODObjectPermission) {
+	logger := LoggerFromContext(ctx)
+	logger.Info("deduplicated content was released, storing upload instead", zap.String("contentConnector", obj.ContentConnector.String), zap.String("uploaded", u.contentConnector.String))
+	u.restore(masterKey, obj, grant)
+	recordContentDedup(ctx, *obj, string(ciphertext.ContentConnectorZone(obj.ContentConnector.String)))
+}
+
+// isContentReleased reports whether an object could not be stored because
+// the content it was to share was released first
+func isContentReleased(err error) bool {
+	return err == dao.ErrContentReleased
+}
+
+// recordContentDedup records content newly uploaded so that later uploads
+// may share it
+func recordContentDedup(ctx context.Context, obj models.ODObject, zone string) {
+	logger := LoggerFromContext(ctx)
+	d := DAOFromContext(ctx)
+	if err := d.AddContentDedup(obj, zone); err != nil {
+		logger.Error("unable to record content for deduplication", zap.Error(err))
+	}
+}
+
+// purgeReleasedContent removes deduplicated content that no object refers to
+// any longer from the cache and permanent storage of its zone. This is called
+// in the background after objects are expunged.
+func purgeReleasedContent(logger *zap.Logger, d dao.DAO) {
+	for {
+		contents, err := d.GetReleasedContentDedup(releasedContentPageSize)
+		if err != nil {
+			logger.Error("unable to get released content", zap.Error(err))
+			return
+		}
+		for _, content := range contents {
+			dp := ciphertext.FindCiphertextCache(ciphertext.CiphertextCacheZone(content.Zone))
+			if dp == nil {
+				logger.Error("released content is in a zone that is not configured", zap.String("zone", content.Zone))
+				return
+			}
+			if err := dp.Purge(ciphertext.ContentConnectorFileId(content.ContentConnector)); err != nil {
+				logger.Error("unable to purge released content", zap.String("contentConnector", content.ContentConnector), zap.Error(err))
+				return
+			}
+			if err := d.DeleteContentDedup(content); err != nil {
+				logger.Error("unable to delete released content", zap.String("contentConnector", content.ContentConnector), zap.Error(err))
+				return
+			}
+		}
+		if len(contents) < releasedContentPageSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+)
+
+// maxContentFilterMatches bounds the objects matched by a content filter when
+// listing objects. The caller's visibility of the objects is checked by the
+// DAO after they are matched, so a filter matching more objects is refused
+// rather than truncated, which would drop visible matches and, for
+// notcontains, include objects that do contain the phrase.
+const maxContentFilterMatches = 10000
+
+// mapPagingRequest maps the paging request to the DAO, decoding its cursor,
+// validating the expressions of its filters, and resolving the objects whose
+// content matches each content filter, including those of the filter tree,
+// from the full text index. Content filters are rejected when content is not
+// indexed.
+func (h AppServer) mapPagingRequest(pagingRequest *protocol.PagingRequest) (dao.PagingRequest, *AppError) {
+	daoPagingRequest := mapping.MapPagingRequestToDAOPagingRequest(pagingRequest)
+	if len(pagingRequest.Cursor) > 0 {
+		cursor, err := dao.DecodeCursor(pagingRequest.Cursor, daoPagingRequest)
+		if err != nil {
+			return daoPagingRequest, NewAppError(http.StatusBadRequest, err, "The cursor given is not valid for this request")
+		}
+		daoPagingRequest.Cursor = cursor
+	}
+	if err := dao.ValidateFilters(daoPagingRequest); err != nil {
+		return daoPagingRequest, NewAppError(http.StatusBadRequest, err, err.Error())
+	}
+	if herr := h.resolveContentFilters(daoPagingRequest.FilterSettings); herr != nil {
+		return daoPagingRequest, herr
+	}
+	if daoPagingRequest.FilterTree != nil {
+		if herr := h.resolveContentFilterGroup(daoPagingRequest.FilterTree); herr != nil {
+			return daoPagingRequest, herr
+		}
+	}
+	return daoPagingRequest, nil
+}
+
+func (h AppServer) resolveContentFilterGroup(filterGroup *dao.FilterGroup) *AppError {
+	if herr := h.resolveContentFilters(filterGroup.FilterSettings); herr != nil {
+		return herr
+	}
+	for i := range filterGroup.Groups {
+		if herr := h.resolveContentFilterGroup(&filterGroup.Groups[i]); herr != nil {
+			return herr
+		}
+	}
+	return nil
+}
+
+func (h AppServer) resolveContentFilters(filterSettings []dao.FilterSetting) *AppError {
+	for i, filterSetting := range filterSettings {
+		if !dao.IsContentFilterField(filterSetting.FilterField) {
+			continue
+		}
+		if h.ContentIndex == nil {
+			return NewAppError(http.StatusBadRequest, errors.New("content is not indexed"), "Filtering on content is not supported by this server")
+		}
+		ids := h.ContentIndex.Search(filterSetting.Expression, maxContentFilterMatches+1)
+		if len(ids) > maxContentFilterMatches {
+			return NewAppError(http.StatusBadRequest, errors.New("content filter matches too many objects"), fmt.Sprintf("The content filter matches more than %d objects. Use a more specific phrase.", maxContentFilterMatches))
+		}
+		objectIDs := make([][]byte, 0, len(ids))
+		for _, id := range ids {
+			if objectID, err := hex.DecodeString(id); err == nil {
+				objectIDs = append(objectIDs, objectID)
+			}
+		}
+		filterSettings[i].ObjectIDs = objectIDs
+	}
+	return nil
+}
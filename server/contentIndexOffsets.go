@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// contentIndexOffsetInterval is how often the offsets of the events indexed
+// are recorded
+const contentIndexOffsetInterval = 10 * time.Second
+
+// contentIndexOffsets tracks, for each partition of the topic consumed by the
+// content indexer, the offset from which to resume so that no event is
+// missed. Events are indexed concurrently, so this is the oldest event still
+// being indexed, or the event after the last one read when none are.
+type contentIndexOffsets struct {
+	mu   sync.Mutex
+	path string
+	// next is the offset after the last event read from each partition
+	next map[int32]int64
+	// indexing holds the offsets of the events of each partition being
+	// indexed
+	indexing map[int32]map[int64]bool
+}
+
+// openContentIndexOffsets loads the offsets recorded at the path, if any
+func openContentIndexOffsets(path string) (*contentIndexOffsets, error) {
+	o := &contentIndexOffsets{
+		path:     path,
+		next:     make(map[int32]int64),
+		indexing: make(map[int32]map[int64]bool),
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &o.next); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// received notes that an event was read and is being indexed
+func (o *contentIndexOffsets) received(partition int32, offset int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.next[partition] = offset + 1
+	if o.indexing[partition] == nil {
+		o.indexing[partition] = make(map[int64]bool)
+	}
+	o.indexing[partition][offset] = true
+}
+
+// done notes that an event has been indexed
+func (o *contentIndexOffsets) done(partition int32, offset int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.indexing[partition], offset)
+}
+
+// committed returns the offset from which to resume each partition
+func (o *contentIndexOffsets) committed() map[int32]int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	committed := make(map[int32]int64)
+	for partition, next := range o.next {
+		for offset := range o.indexing[partition] {
+			if offset < next {
+				next = offset
+			}
+		}
+		committed[partition] = next
+	}
+	return committed
+}
+
+// save records the offsets from which to resume, replacing those recorded
+// before only once written in full
+func (o *contentIndexOffsets) save() error {
+	data, err := json.Marshal(o.committed())
+	if err != nil {
+		return err
+	}
+	tmp := o.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, o.path)
+}
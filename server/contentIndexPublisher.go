@@ -0,0 +1,247 @@
+package server
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/events"
+	"bitbucket.di2e.net/dime/object-drive-server/fulltext"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/services/kafka"
+)
+
+// contentIndexer maintains the full text index of object content held by
+// this instance. Successful events that change the content stream of an
+// object cause its text to be extracted and indexed in the background, and
+// deleted objects are removed from the index.
+//
+// Where events are published to Kafka, every instance consumes the events of
+// the topic, so that its index holds the content changed through any
+// instance. Otherwise only the events published by this instance are indexed.
+// The index directory is for this instance alone, and must not be shared.
+type contentIndexer struct {
+	app     *AppServer
+	index   *fulltext.Index
+	maxSize int64
+	pending chan contentIndexEvent
+	offsets *contentIndexOffsets
+}
+
+// contentIndexEvent is an event waiting to be indexed, along with where it
+// was read from the topic when consumed
+type contentIndexEvent struct {
+	gem       events.GEM
+	consumed  bool
+	partition int32
+	offset    int64
+}
+
+// newContentIndexer starts the workers maintaining the index per the full
+// text configuration.
+func newContentIndexer(app *AppServer, index *fulltext.Index, conf config.FullTextConfiguration) *contentIndexer {
+	ix := &contentIndexer{
+		app:     app,
+		index:   index,
+		maxSize: conf.MaxSize,
+		pending: make(chan contentIndexEvent, conf.QueueSize),
+	}
+	for i := int64(0); i < conf.Workers; i++ {
+		go ix.start()
+	}
+	return ix
+}
+
+// isContentIndexEvent reports whether an event changes what is indexed for an
+// object
+func isContentIndexEvent(gem events.GEM) bool {
+	if !gem.IsSuccessful() || len(gem.Payload.ObjectID) == 0 {
+		return false
+	}
+	switch gem.Action {
+	case "create", "update", "undelete":
+		return gem.Payload.StreamUpdate
+	case "delete":
+		return true
+	}
+	return false
+}
+
+// consume indexes the events of the topic, starting from the offsets last
+// recorded in the directory of the index. Events are waited on rather than
+// dropped when the queue is full, and offsets are only recorded once the
+// events before them are indexed, so that events are indexed again rather
+// than missed after a restart.
+func (ix *contentIndexer) consume(brokers []string, topic string, dir string) error {
+	offsets, err := openContentIndexOffsets(filepath.Join(dir, "offsets."+topic+".json"))
+	if err != nil {
+		return err
+	}
+	ix.offsets = offsets
+	_, err = kafka.NewConsumer(brokers, topic, offsets.committed(), func(partition int32, offset int64, value []byte) {
+		offsets.received(partition, offset)
+		var gem events.GEM
+		if err := json.Unmarshal(value, &gem); err != nil || !isContentIndexEvent(gem) {
+			offsets.done(partition, offset)
+			return
+		}
+		ix.pending <- contentIndexEvent{gem: gem, consumed: true, partition: partition, offset: offset}
+	}, logger)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for range time.Tick(contentIndexOffsetInterval) {
+			if err := offsets.save(); err != nil {
+				logger.Error("unable to record content index offsets", zap.Error(err))
+			}
+		}
+	}()
+	return nil
+}
+
+func (ix *contentIndexer) start() {
+	for e := range ix.pending {
+		ix.update(e.gem)
+		if e.consumed {
+			ix.offsets.done(e.partition, e.offset)
+		}
+	}
+}
+
+// contentIndexPublisher is an events.Publisher that passes every event on to
+// the wrapped Publisher, and additionally queues the events that change
+// content for indexing. It is used when events are not published to Kafka.
+type contentIndexPublisher struct {
+	events.Publisher
+	indexer *contentIndexer
+}
+
+// newContentIndexPublisher wraps the publisher to index the events published
+// by this instance.
+func newContentIndexPublisher(publisher events.Publisher, indexer *contentIndexer) *contentIndexPublisher {
+	return &contentIndexPublisher{Publisher: publisher, indexer: indexer}
+}
+
+// withPublisher returns a contentIndexPublisher sharing this one's indexer,
+// but passing events on to a different Publisher. This is used when the event
+// queue is reconnected.
+func (p *contentIndexPublisher) withPublisher(publisher events.Publisher) *contentIndexPublisher {
+	return newContentIndexPublisher(publisher, p.indexer)
+}
+
+// Publish implements the events.Publisher interface.
+func (p *contentIndexPublisher) Publish(e events.Event) {
+	p.Publisher.Publish(e)
+	gem, ok := e.(events.GEM)
+	if !ok || !isContentIndexEvent(gem) {
+		return
+	}
+	select {
+	case p.indexer.pending <- contentIndexEvent{gem: gem}:
+	default:
+		logger.Warn("content index queue is full, object not indexed", zap.String("eventId", gem.ID), zap.String("objectId", gem.Payload.ObjectID))
+	}
+}
+
+// update indexes the text of the content of the object referenced by the
+// event, or removes the object from the index if it is deleted or its text
+// cannot be extracted.
+func (ix *contentIndexer) update(gem events.GEM) {
+	objectID, err := hex.DecodeString(gem.Payload.ObjectID)
+	if err != nil {
+		return
+	}
+	id := hex.EncodeToString(objectID)
+	if gem.Action == "delete" {
+		if err := ix.index.Remove(id); err != nil {
+			logger.Error("unable to remove object from content index", zap.String("objectId", id), zap.Error(err))
+		}
+		return
+	}
+	d := ix.app.RootDAO
+	if d == nil {
+		return
+	}
+	object, err := d.GetObject(models.ODObject{ID: objectID}, true)
+	if err != nil {
+		logger.Warn("unable to retrieve object for content index", zap.String("objectId", id), zap.Error(err))
+		return
+	}
+	if object.IsDeleted || object.IsExpunged || object.ContentSize.Int64 <= 0 {
+		ix.index.Remove(id)
+		return
+	}
+	content, err := ix.readContent(&object)
+	if err != nil {
+		logger.Warn("unable to read content for content index", zap.String("objectId", id), zap.Error(err))
+		return
+	}
+	text, err := fulltext.Extract(object.ContentType.String, object.Name, content)
+	if err == fulltext.ErrUnsupportedContentType {
+		// A revision may have changed the type of content held
+		ix.index.Remove(id)
+		return
+	}
+	if err != nil {
+		logger.Warn("unable to extract text for content index", zap.String("objectId", id), zap.Error(err))
+	}
+	if err := ix.index.Add(id, text); err != nil {
+		logger.Error("unable to add object to content index", zap.String("objectId", id), zap.Error(err))
+	}
+}
+
+// readContent decrypts up to the maximum size of the content of an object,
+// using the key held by any of its permissions.
+func (ix *contentIndexer) readContent(object *models.ODObject) ([]byte, error) {
+	dp := ciphertext.FindCiphertextCacheByObject(object)
+	if dp == nil {
+		return nil, errors.New("object is in a zone that is not configured")
+	}
+	rewrapPermissions(object)
+	var key []byte
+	for _, permission := range object.Permissions {
+		if len(permission.EncryptKey) > 0 {
+			key = crypto.ApplyPassphrase(dp.GetMasterKey(), permission.PermissionIV, permission.EncryptKey)
+			break
+		}
+	}
+	if len(key) == 0 {
+		return nil, errors.New("object has no permission from which to derive the file key")
+	}
+	size := object.ContentSize.Int64
+	if ix.maxSize > 0 && size > ix.maxSize {
+		size = ix.maxSize
+	}
+	byteRange := &crypto.ByteRange{Start: 0, Stop: size - 1}
+	cipherReader, iv, err := ix.app.pullObjectRange(logger, dp, object, byteRange)
+	if cipherReader != nil {
+		defer cipherReader.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var content bytes.Buffer
+	_, _, err = ix.app.Conf.EncryptableFunctions.DecipherFor(object.CipherFormat)(logger, cipherReader, &content, key, iv, "indexing content", byteRange)
+	return content.Bytes(), err
+}
+
+// reconnectedPublisher returns the event queue to use when the underlying
+// Publisher is reconnected, keeping the wrappers of the current event queue.
+func reconnectedPublisher(current events.Publisher, publisher events.Publisher) events.Publisher {
+	switch p := current.(type) {
+	case *subscriptionPublisher:
+		return p.withPublisher(reconnectedPublisher(p.Publisher, publisher))
+	case *contentIndexPublisher:
+		return p.withPublisher(reconnectedPublisher(p.Publisher, publisher))
+	}
+	return publisher
+}
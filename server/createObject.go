@@ -31,7 +31,6 @@ func (h AppServer) createObject(ctx context.Context, w http.ResponseWriter, r *h
 
 	logger := LoggerFromContext(ctx)
 	caller, _ := CallerFromContext(ctx)
-	dao := DAOFromContext(ctx)
 	gem, _ := GEMFromContext(ctx)
 
 	gem.Action = "create"
@@ -39,9 +38,7 @@ func (h AppServer) createObject(ctx context.Context, w http.ResponseWriter, r *h
 	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "CREATE")
 
 	var obj models.ODObject
-	var createdObject models.ODObject
 	var pathDelimiter string
-	var err error
 	var herr *AppError
 	var drainFunc func()
 
@@ -97,6 +94,24 @@ func (h AppServer) createObject(ctx context.Context, w http.ResponseWriter, r *h
 			return herr
 		}
 	}
+	return h.storeCreatedObject(ctx, w, gem, obj, ownerPermission, pathDelimiter, isMultipart, drainFunc)
+}
+
+// storeCreatedObject completes the creation of an object once its metadata,
+// and content stream if any, have been accepted. If a stream was provided,
+// drainFunc moves it from the cache to permanent storage once the object is
+// stored, and it is removed from the cache should creation fail. The gem is
+// published on completion.
+func (h AppServer) storeCreatedObject(ctx context.Context, w http.ResponseWriter, gem events.GEM, obj models.ODObject,
+	ownerPermission models.ODObjectPermission, pathDelimiter string, isMultipart bool, drainFunc func()) *AppError {
+
+	logger := LoggerFromContext(ctx)
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	var createdObject models.ODObject
+	var err error
+	var herr *AppError
+
 	obj.CreatedBy = caller.DistinguishedName
 	dp := ciphertext.FindCiphertextCacheByObject(&obj)
 	masterKey := dp.GetMasterKey()
@@ -124,6 +139,24 @@ func (h AppServer) createObject(ctx context.Context, w http.ResponseWriter, r *h
 		return abortUploadObject(logger, dp, &obj, isMultipart, herr)
 	}
 
+	// Share content already stored with the same ACM rather than storing it
+	// again. The upload is kept until the object is stored.
+	uploaded := isMultipart
+	uploadedDrainFunc := drainFunc
+	var deduped *dedupedUpload
+	if isMultipart {
+		deduped = deduplicateContent(ctx, &obj, &ownerPermission, true)
+	}
+	if deduped != nil {
+		uploaded = false
+		drainFunc = nil
+		defer func() {
+			if deduped != nil {
+				deduped.discard(logger, dp)
+			}
+		}()
+	}
+
 	// recalculate permission mac for owner permission
 	ownerPermission.PermissionMAC = models.CalculatePermissionMAC(masterKey, &ownerPermission)
 	consolidateChangingPermissions(&obj)
@@ -139,20 +172,31 @@ func (h AppServer) createObject(ctx context.Context, w http.ResponseWriter, r *h
 	if err = handleIntermediateFoldersDuringCreation(ctx, h, user, dp.GetMasterKey(), &obj, pathDelimiter); err != nil {
 		herr = NewAppError(http.StatusInternalServerError, err, "error processing intermediate folders")
 		h.publishError(gem, herr)
-		return abortUploadObject(logger, dp, &obj, isMultipart, herr)
+		return abortUploadObject(logger, dp, &obj, uploaded, herr)
 	}
 
 	if err := handleTypeName(ctx, h, &obj); err != nil {
 		herr = NewAppError(http.StatusInternalServerError, err, "error setting type for object")
 		h.publishError(gem, herr)
-		return abortUploadObject(logger, dp, &obj, isMultipart, herr)
+		return abortUploadObject(logger, dp, &obj, uploaded, herr)
+	}
+	if herr = enforceTypeProperties(ctx, h, &obj); herr != nil {
+		h.publishError(gem, herr)
+		return abortUploadObject(logger, dp, &obj, uploaded, herr)
 	}
 
 	createdObject, err = dao.CreateObject(&obj)
+	if deduped != nil && isContentReleased(err) {
+		storeDedupedUpload(ctx, deduped, masterKey, &obj, &ownerPermission)
+		deduped = nil
+		uploaded = true
+		drainFunc = uploadedDrainFunc
+		createdObject, err = dao.CreateObject(&obj)
+	}
 	if err != nil {
 		herr = NewAppError(http.StatusInternalServerError, err, "error storing object")
 		h.publishError(gem, herr)
-		return abortUploadObject(logger, dp, &obj, isMultipart, herr)
+		return abortUploadObject(logger, dp, &obj, uploaded, herr)
 	}
 	parents, err := dao.GetParents(createdObject)
 	if err != nil {
@@ -327,6 +371,9 @@ func newFolderBasedOnObject(obj *models.ODObject) models.ODObject {
 		ParentID:    obj.ParentID,
 		RawAcm:      obj.RawAcm,
 		Permissions: []models.ODObjectPermission{},
+		// Folders share the zone of the object, as their permissions are
+		// keyed with the same master key
+		ContentConnector: models.ToNullString(ciphertext.NewContentConnector(ciphertext.ContentConnectorZone(obj.ContentConnector.String))),
 	}
 	return theFolder
 }
@@ -466,6 +513,14 @@ func handleCreatePrerequisites(ctx context.Context, h AppServer, requestObject *
 	ownerCUDS := models.PermissionWithoutRead(ownerCRUDS)
 	requestObject.Permissions = append(requestObject.Permissions, ownerCUDS)
 
+	// Select the zone the content is stored in now that the owner is known.
+	// The zone is kept in the content connector for the life of the object.
+	zone, err := ciphertext.FindCiphertextCacheZoneForObject(requestObject)
+	if err != nil {
+		return NewAppError(http.StatusBadRequest, err, err.Error())
+	}
+	requestObject.ContentConnector = models.ToNullString(ciphertext.NewContentConnector(zone))
+
 	return nil
 }
 
@@ -507,7 +562,7 @@ func validateCreateObjectHeaders(r *http.Request) *AppError {
 }
 
 func removeOrphanedFile(logger *zap.Logger, d ciphertext.CiphertextCache, contentConnector string) {
-	fileID := ciphertext.FileId(contentConnector)
+	fileID := ciphertext.ContentConnectorFileId(contentConnector)
 	uploadedName := ciphertext.NewFileName(fileID, ciphertext.FileStateUploaded)
 	orphanedName := ciphertext.NewFileName(fileID, ciphertext.FileStateOrphaned)
 	var err error
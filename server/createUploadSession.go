@@ -0,0 +1,92 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// createUploadSession begins a resumable upload. The object described is
+// validated now, and again when the upload is committed.
+func (h AppServer) createUploadSession(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	// Upload sessions do not change any object until committed
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	var request protocol.UploadSessionRequest
+	if err := util.FullDecode(r.Body, &request); err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Could not parse json object as a protocol.UploadSessionRequest")
+		h.publishError(gem, herr)
+		return herr
+	}
+	contentSize := uploadSessionContentSize(request)
+	if contentSize <= 0 {
+		herr := NewAppError(http.StatusBadRequest, errors.New("contentSize required"), "contentSize must be specified")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	obj, grant, _, herr := h.uploadSessionObject(ctx, request)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	dp := ciphertext.FindCiphertextCacheByObject(&obj)
+	if request.Create != nil {
+		// The content of a new object is encrypted with a new key and iv
+		obj.EncryptIV = crypto.CreateIV()
+		models.SetEncryptKey(dp.GetMasterKey(), &grant)
+	} else {
+		gem.Payload.ObjectID = request.Update.ID
+		gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(obj.ID))
+	}
+
+	now := time.Now().UTC()
+	session := uploadSession{
+		ID:           obj.ContentConnector.String,
+		CreatedBy:    caller.DistinguishedName,
+		CreatedDate:  now,
+		ModifiedDate: now,
+		ContentSize:  contentSize,
+		EncryptIV:    obj.EncryptIV,
+		PermissionIV: grant.PermissionIV,
+		EncryptKey:   grant.EncryptKey,
+		KeyCheck:     stagedKeyCheck(dp.GetMasterKey(), grant.PermissionIV),
+		Create:       request.Create,
+		Update:       request.Update,
+	}
+
+	f, err := dp.Files().Create(uploadSessionFileName(session.ID, ciphertext.FileStateUploading))
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "Unable to create file to receive upload")
+		h.publishError(gem, herr)
+		return herr
+	}
+	f.Close()
+	uploadSessionLock.Lock()
+	err = saveUploadSession(&session)
+	uploadSessionLock.Unlock()
+	if err != nil {
+		discardUploadSession(LoggerFromContext(ctx), session.ID)
+		herr := NewAppError(http.StatusInternalServerError, err, "Unable to save upload session")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	jsonResponse(w, session.toProtocol())
+	h.publishSuccess(gem, w)
+	return nil
+}
@@ -84,6 +84,7 @@ func (h AppServer) deleteObjectForever(ctx context.Context, w http.ResponseWrite
 		h.publishError(gem, herr)
 		return herr
 	}
+	go purgeReleasedContent(LoggerFromContext(ctx), dao)
 
 	apiResponse := mapping.MapODObjectToExpungedObjectResponse(&dbObject).WithCallerPermission(protocolCaller(caller))
 	jsonResponse(w, apiResponse)
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	"encoding/hex"
 
@@ -18,10 +19,7 @@ import (
 
 func (h AppServer) doBulkDelete(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
 
-	dao := DAOFromContext(ctx)
-	user, _ := UserFromContext(ctx)
-	caller, ok := CallerFromContext(ctx)
-	if !ok {
+	if _, ok := CallerFromContext(ctx); !ok {
 		return NewAppError(http.StatusInternalServerError, errors.New("Could not get caller from context"), "Invalid caller.")
 	}
 
@@ -43,6 +41,12 @@ func (h AppServer) doBulkDelete(ctx context.Context, w http.ResponseWriter, r *h
 		h.publishError(gem, herr)
 		return herr
 	}
+
+	if async, _ := strconv.ParseBool(r.URL.Query().Get("async")); async {
+		request := jobRequest{Objects: objects}
+		return h.queueJob(ctx, w, gem, jobTypeDelete, request, int64(len(objects)))
+	}
+
 	// Limit bulk delete operation to 1000 items
 	if len(objects) > 1000 {
 		herr := NewAppError(http.StatusBadRequest, err, "Cannot delete more then 1000 objects at a time")
@@ -52,116 +56,94 @@ func (h AppServer) doBulkDelete(ctx context.Context, w http.ResponseWriter, r *h
 
 	var bulkResponse []protocol.ObjectError
 	for _, o := range objects {
-		gem = ResetBulkItem(gem)
-		id, err := hex.DecodeString(o.ObjectID)
-		if err != nil {
-			herr := NewAppError(http.StatusBadRequest, err, "Cannot decode object id")
-			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ObjectID,
-					Error:    herr.Error.Error(),
-					Msg:      herr.Msg,
-					Code:     herr.Code,
-				},
-			)
-			continue
-		}
+		bulkResponse = append(bulkResponse, h.bulkDeleteObject(ctx, ResetBulkItem(gem), o))
+	}
+	jsonResponse(w, bulkResponse)
+	return nil
+}
 
-		requestObject := models.ODObject{
-			ID:          id,
-			ChangeToken: o.ChangeToken,
-		}
-		gem.Payload.ObjectID = hex.EncodeToString(requestObject.ID)
-		gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(requestObject.ID))
-		dbObject, err := dao.GetObject(requestObject, true)
-		if err != nil {
-			herr := NewAppError(http.StatusInternalServerError, err, "Error retrieving object")
+// bulkDeleteObject moves a single object of a bulk delete to the trash,
+// publishing the outcome and returning it for the response
+func (h AppServer) bulkDeleteObject(ctx context.Context, gem events.GEM, o protocol.ObjectVersioned) protocol.ObjectError {
+	dao := DAOFromContext(ctx)
+	user, _ := UserFromContext(ctx)
+	caller, _ := CallerFromContext(ctx)
+
+	id, err := hex.DecodeString(o.ObjectID)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Cannot decode object id")
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ObjectID, herr)
+	}
+
+	requestObject := models.ODObject{
+		ID:          id,
+		ChangeToken: o.ChangeToken,
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(requestObject.ID)
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(requestObject.ID))
+	dbObject, err := dao.GetObject(requestObject, true)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "Error retrieving object")
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ObjectID, herr)
+	}
+	gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(dbObject))
+	gem.Payload.ChangeToken = dbObject.ChangeToken
+
+	// Auth check
+	if ok := isUserAllowedToDelete(ctx, &dbObject); !ok {
+		herr := NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User does not have permission to delete this object")
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ObjectID, herr)
+	}
+
+	// State check
+	if dbObject.IsDeleted {
+		// Deleted already
+		switch {
+		case dbObject.IsExpunged:
+			herr := NewAppError(http.StatusGone, err, "The referenced object no longer exists.")
 			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ObjectID,
-					Error:    herr.Error.Error(),
-					Msg:      herr.Msg,
-					Code:     herr.Code,
-				},
-			)
-			continue
+			return bulkObjectError(o.ObjectID, herr)
+		default:
+			// Just ignore files already deleted.
 		}
-		gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(dbObject))
-		gem.Payload.ChangeToken = dbObject.ChangeToken
-
-		// Auth check
-		if ok := isUserAllowedToDelete(ctx, &dbObject); !ok {
-			herr := NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User does not have permission to delete this object")
+	} else {
+		// ok to change
+		dbObject.ModifiedBy = caller.DistinguishedName
+		dbObject.ChangeToken = requestObject.ChangeToken
+		err = dao.DeleteObject(user, dbObject, true)
+		if err != nil {
+			herr := NewAppError(http.StatusInternalServerError, err, "DAO Error deleting object")
 			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ObjectID,
-					Error:    herr.Error.Error(),
-					Msg:      herr.Msg,
-					Code:     herr.Code,
-				},
-			)
-			continue
+			return bulkObjectError(o.ObjectID, herr)
 		}
+	}
 
-		// State check
-		if dbObject.IsDeleted {
-			// Deleted already
-			switch {
-			case dbObject.IsExpunged:
-				herr := NewAppError(http.StatusGone, err, "The referenced object no longer exists.")
-				h.publishError(gem, herr)
-				bulkResponse = append(bulkResponse,
-					protocol.ObjectError{
-						ObjectID: o.ObjectID,
-						Error:    herr.Error.Error(),
-						Msg:      herr.Msg,
-						Code:     herr.Code,
-					},
-				)
-				continue
-			default:
-				// Just ignore files already deleted.
-			}
-		} else {
-			// ok to change
-			dbObject.ModifiedBy = caller.DistinguishedName
-			dbObject.ChangeToken = requestObject.ChangeToken
-			err = dao.DeleteObject(user, dbObject, true)
-			if err != nil {
-				herr := NewAppError(http.StatusInternalServerError, err, "DAO Error deleting object")
-				h.publishError(gem, herr)
-				bulkResponse = append(bulkResponse,
-					protocol.ObjectError{
-						ObjectID: o.ObjectID,
-						Error:    herr.Error.Error(),
-						Msg:      herr.Msg,
-						Code:     herr.Code,
-					},
-				)
-				continue
-			}
-		}
+	// reget the object so that changetoken and deleteddate are correct
+	dbObject, err = dao.GetObject(requestObject, false)
+	gem.Payload.ChangeToken = dbObject.ChangeToken
+	gem.Payload = events.WithEnrichedPayload(gem.Payload, mapping.MapODObjectToObject(&dbObject))
+	h.publishSuccessWithStatus(gem, "200")
 
-		bulkResponse = append(
-			bulkResponse,
-			protocol.ObjectError{
-				ObjectID: o.ObjectID,
-				Error:    "",
-				Msg:      "",
-				Code:     http.StatusOK,
-			},
-		)
-
-		// reget the object so that changetoken and deleteddate are correct
-		dbObject, err = dao.GetObject(requestObject, false)
-		gem.Payload.ChangeToken = dbObject.ChangeToken
-		gem.Payload = events.WithEnrichedPayload(gem.Payload, mapping.MapODObjectToObject(&dbObject))
-		h.publishSuccess(gem, w)
+	return protocol.ObjectError{
+		ObjectID: o.ObjectID,
+		Error:    "",
+		Msg:      "",
+		Code:     http.StatusOK,
+	}
+}
 
+// bulkObjectError reports an object of a bulk operation that could not be processed
+func bulkObjectError(objectID string, herr *AppError) protocol.ObjectError {
+	objectError := protocol.ObjectError{
+		ObjectID: objectID,
+		Msg:      herr.Msg,
+		Code:     herr.Code,
 	}
-	jsonResponse(w, bulkResponse)
-	return nil
+	if herr.Error != nil {
+		objectError.Error = herr.Error.Error()
+	}
+	return objectError
 }
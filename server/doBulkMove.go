@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	"encoding/hex"
 
@@ -18,10 +19,6 @@ import (
 
 func (h AppServer) doBulkMove(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
 
-	dao := DAOFromContext(ctx)
-	caller, _ := CallerFromContext(ctx)
-	logger := LoggerFromContext(ctx)
-	aacAuth := auth.NewAACAuth(logger, h.AAC)
 	gem, _ := GEMFromContext(ctx)
 	gem.Action = "update"
 	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
@@ -41,106 +38,85 @@ func (h AppServer) doBulkMove(ctx context.Context, w http.ResponseWriter, r *htt
 		return herr
 	}
 
+	if async, _ := strconv.ParseBool(r.URL.Query().Get("async")); async {
+		request := jobRequest{Moves: objects}
+		return h.queueJob(ctx, w, gem, jobTypeMove, request, int64(len(objects)))
+	}
+
 	var bulkResponse []protocol.ObjectError
-	w.Header().Set("Status", (string)(http.StatusOK))
+	w.Header().Set("Status", strconv.Itoa(http.StatusOK))
 	for _, o := range objects {
-		gem = ResetBulkItem(gem)
-		id, err := hex.DecodeString(o.ID)
-		if err != nil {
-			herr := NewAppError(http.StatusBadRequest, err, "Cannot decode object id")
-			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ID,
-					Error:    herr.Error.Error(),
-					Msg:      herr.Msg,
-					Code:     herr.Code,
-				},
-			)
-			continue
-		}
-		parentid, err := hex.DecodeString(o.ParentID)
-		if err != nil {
-			herr := NewAppError(http.StatusBadRequest, err, "Cannot decode object parent id")
-			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ID,
-					Error:    herr.Error.Error(),
-					Msg:      herr.Msg,
-					Code:     herr.Code,
-				},
-			)
-			continue
-		}
-
-		requestObject := models.ODObject{
-			ID:          id,
-			ChangeToken: o.ChangeToken,
-			ParentID:    parentid,
-		}
-		gem.Payload.ObjectID = hex.EncodeToString(requestObject.ID)
-		gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(requestObject.ID))
-		dbObject, err := dao.GetObject(requestObject, true)
-		if err != nil {
-			herr := NewAppError(http.StatusBadRequest, err, "Error retrieving object")
-			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ID,
-					Error:    herr.Error.Error(),
-					Msg:      herr.Msg,
-					Code:     herr.Code,
-				},
-			)
-			continue
-		}
-		auditOriginal := NewResourceFromObject(dbObject)
-
-		code, msg, errCause := moveObjectRaw(
-			ctx,
-			dao,
-			caller,
-			getKnownResourceStringsFromUserGroups(ctx),
-			aacAuth,
-			requestObject,
-			&dbObject,
-		)
-
-		if errCause != nil {
-			herr := NewAppError(code, errCause, msg)
-			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ID,
-					Error:    herr.Error.Error(),
-					Msg:      herr.Msg,
-					Code:     herr.Code,
-				},
-			)
-			continue
-		}
-
-		auditModified := NewResourceFromObject(dbObject)
-
-		bulkResponse = append(
-			bulkResponse,
-			protocol.ObjectError{
-				ObjectID: o.ID,
-				Error:    "",
-				Msg:      "",
-				Code:     http.StatusOK,
-			},
-		)
-
-		apiResponse := mapping.MapODObjectToObject(&dbObject).WithCallerPermission(protocolCaller(caller))
-
-		gem.Payload.ChangeToken = apiResponse.ChangeToken
-		gem.Payload.Audit = audit.WithModifiedPairList(gem.Payload.Audit, audit.NewModifiedResourcePair(auditOriginal, auditModified))
-		gem.Payload = events.WithEnrichedPayload(gem.Payload, apiResponse)
-		h.publishSuccess(gem, w)
-
+		bulkResponse = append(bulkResponse, h.bulkMoveObject(ctx, ResetBulkItem(gem), o))
 	}
 	jsonResponse(w, bulkResponse)
 	return nil
 }
+
+// bulkMoveObject moves a single object of a bulk move to its new parent,
+// publishing the outcome and returning it for the response
+func (h AppServer) bulkMoveObject(ctx context.Context, gem events.GEM, o protocol.MoveObjectRequest) protocol.ObjectError {
+	dao := DAOFromContext(ctx)
+	caller, _ := CallerFromContext(ctx)
+	logger := LoggerFromContext(ctx)
+	aacAuth := auth.NewAACAuth(logger, h.AAC)
+
+	id, err := hex.DecodeString(o.ID)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Cannot decode object id")
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ID, herr)
+	}
+	parentid, err := hex.DecodeString(o.ParentID)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Cannot decode object parent id")
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ID, herr)
+	}
+
+	requestObject := models.ODObject{
+		ID:          id,
+		ChangeToken: o.ChangeToken,
+		ParentID:    parentid,
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(requestObject.ID)
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(requestObject.ID))
+	dbObject, err := dao.GetObject(requestObject, true)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Error retrieving object")
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ID, herr)
+	}
+	auditOriginal := NewResourceFromObject(dbObject)
+
+	code, msg, errCause := moveObjectRaw(
+		ctx,
+		dao,
+		caller,
+		getKnownResourceStringsFromUserGroups(ctx),
+		aacAuth,
+		requestObject,
+		&dbObject,
+	)
+
+	if errCause != nil {
+		herr := NewAppError(code, errCause, msg)
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ID, herr)
+	}
+
+	auditModified := NewResourceFromObject(dbObject)
+
+	apiResponse := mapping.MapODObjectToObject(&dbObject).WithCallerPermission(protocolCaller(caller))
+
+	gem.Payload.ChangeToken = apiResponse.ChangeToken
+	gem.Payload.Audit = audit.WithModifiedPairList(gem.Payload.Audit, audit.NewModifiedResourcePair(auditOriginal, auditModified))
+	gem.Payload = events.WithEnrichedPayload(gem.Payload, apiResponse)
+	h.publishSuccessWithStatus(gem, "200")
+
+	return protocol.ObjectError{
+		ObjectID: o.ID,
+		Error:    "",
+		Msg:      "",
+		Code:     http.StatusOK,
+	}
+}
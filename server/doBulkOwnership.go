@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	"go.uber.org/zap"
 
@@ -20,10 +21,6 @@ import (
 
 func (h AppServer) doBulkOwnership(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
 
-	dao := DAOFromContext(ctx)
-	caller, _ := CallerFromContext(ctx)
-	logger := LoggerFromContext(ctx)
-	aacAuth := auth.NewAACAuth(logger, h.AAC)
 	gem, _ := GEMFromContext(ctx)
 	gem.Action = "update"
 	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
@@ -56,123 +53,85 @@ func (h AppServer) doBulkOwnership(ctx context.Context, w http.ResponseWriter, r
 		return herr
 	}
 
+	if async, _ := strconv.ParseBool(r.URL.Query().Get("async")); async {
+		request := jobRequest{Objects: objects, NewOwner: newOwner}
+		return h.queueJob(ctx, w, gem, jobTypeOwnership, request, int64(len(objects)))
+	}
+
 	var bulkResponse []protocol.ObjectError
 	w.Header().Set("Status", "200") // should not be necessary
 	for _, o := range objects {
-		gem = ResetBulkItem(gem)
-
-		changeRequest := protocol.ChangeOwnerRequest{
-			ID:          o.ObjectID,
-			ChangeToken: o.ChangeToken,
-			NewOwner:    newOwner,
-		}
-		requestObject, err := mapping.MapChangeOwnerRequestToODObject(&changeRequest)
-
-		if err != nil {
-			herr := NewAppError(http.StatusBadRequest, err, "Error parsing JSON")
-			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ObjectID,
-					Error:    herr.Error.Error(),
-					Msg:      herr.Msg,
-					Code:     herr.Code,
-				},
-			)
-			continue
-		}
-		gem.Payload.ObjectID = hex.EncodeToString(requestObject.ID)
-		gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(requestObject.ID))
-
-		requestObject.ChangeToken = o.ChangeToken
-		dbObject, err := dao.GetObject(requestObject, true)
-		if err != nil {
-			herr := NewAppError(http.StatusBadRequest, err, "Error retrieving object")
-			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ObjectID,
-					Error:    herr.Error.Error(),
-					Msg:      herr.Msg,
-					Code:     herr.Code,
-				},
-			)
-			continue
-		}
-		auditOriginal := NewResourceFromObject(dbObject)
-
-		// Auth check
-		okToUpdate, updatePermission := isUserAllowedToUpdateWithPermission(ctx, &dbObject)
-		if !okToUpdate {
-			herr := NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User does not have permission to update this object")
-			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ObjectID,
-					Error:    herr.Error.Error(),
-					Msg:      herr.Msg,
-					Code:     herr.Code,
-				},
-			)
-			continue
-		}
-		if !aacAuth.IsUserOwner(caller.DistinguishedName, getKnownResourceStringsFromUserGroups(ctx), dbObject.OwnedBy.String) {
-			herr := NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User must be an object owner to transfer ownership of the object")
-			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ObjectID,
-					Error:    herr.Error.Error(),
-					Msg:      herr.Msg,
-					Code:     herr.Code,
-				},
-			)
-			continue
-		}
-
-		var code int
-		var msg string
-		var errCause error
-
-		apiResponse, herr := changeOwnerRaw(
-			&requestObject, &dbObject,
-			&updatePermission,
-			aacAuth,
-			caller,
-			dao,
-		)
-		if herr != nil {
-			errCause = herr.Error
-			code = herr.Code
-			msg = herr.Msg
-			errMsg := errCause.Error()
-			h.publishError(gem, herr)
-			bulkResponse = append(bulkResponse,
-				protocol.ObjectError{
-					ObjectID: o.ObjectID,
-					Error:    errMsg,
-					Msg:      msg,
-					Code:     code,
-				},
-			)
-			continue
-		}
-		auditModified := NewResourceFromObject(dbObject)
-
-		bulkResponse = append(
-			bulkResponse,
-			protocol.ObjectError{
-				ObjectID: o.ObjectID,
-				Error:    "",
-				Msg:      "",
-				Code:     http.StatusOK,
-			},
-		)
-
-		gem.Payload.Audit = audit.WithModifiedPairList(gem.Payload.Audit, audit.NewModifiedResourcePair(auditOriginal, auditModified))
-		gem.Payload = events.WithEnrichedPayload(gem.Payload, *apiResponse)
-		h.publishSuccess(gem, w)
+		bulkResponse = append(bulkResponse, h.bulkChangeOwner(ctx, ResetBulkItem(gem), o, newOwner))
 	}
 	jsonResponse(w, bulkResponse)
 	return nil
 }
+
+// bulkChangeOwner transfers ownership of a single object of a bulk ownership
+// change, publishing the outcome and returning it for the response
+func (h AppServer) bulkChangeOwner(ctx context.Context, gem events.GEM, o protocol.ObjectVersioned, newOwner string) protocol.ObjectError {
+	dao := DAOFromContext(ctx)
+	caller, _ := CallerFromContext(ctx)
+	logger := LoggerFromContext(ctx)
+	aacAuth := auth.NewAACAuth(logger, h.AAC)
+
+	changeRequest := protocol.ChangeOwnerRequest{
+		ID:          o.ObjectID,
+		ChangeToken: o.ChangeToken,
+		NewOwner:    newOwner,
+	}
+	requestObject, err := mapping.MapChangeOwnerRequestToODObject(&changeRequest)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Error parsing JSON")
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ObjectID, herr)
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(requestObject.ID)
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(requestObject.ID))
+
+	requestObject.ChangeToken = o.ChangeToken
+	dbObject, err := dao.GetObject(requestObject, true)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Error retrieving object")
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ObjectID, herr)
+	}
+	auditOriginal := NewResourceFromObject(dbObject)
+
+	// Auth check
+	okToUpdate, updatePermission := isUserAllowedToUpdateWithPermission(ctx, &dbObject)
+	if !okToUpdate {
+		herr := NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User does not have permission to update this object")
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ObjectID, herr)
+	}
+	if !aacAuth.IsUserOwner(caller.DistinguishedName, getKnownResourceStringsFromUserGroups(ctx), dbObject.OwnedBy.String) {
+		herr := NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User must be an object owner to transfer ownership of the object")
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ObjectID, herr)
+	}
+
+	apiResponse, herr := changeOwnerRaw(
+		&requestObject, &dbObject,
+		&updatePermission,
+		aacAuth,
+		caller,
+		dao,
+	)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return bulkObjectError(o.ObjectID, herr)
+	}
+	auditModified := NewResourceFromObject(dbObject)
+
+	gem.Payload.Audit = audit.WithModifiedPairList(gem.Payload.Audit, audit.NewModifiedResourcePair(auditOriginal, auditModified))
+	gem.Payload = events.WithEnrichedPayload(gem.Payload, *apiResponse)
+	h.publishSuccessWithStatus(gem, "200")
+
+	return protocol.ObjectError{
+		ObjectID: o.ObjectID,
+		Error:    "",
+		Msg:      "",
+		Code:     http.StatusOK,
+	}
+}
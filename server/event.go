@@ -33,6 +33,32 @@ func globalEventFromRequest(r *http.Request) events.GEM {
 	return e
 }
 
+// globalEventForSystem sets up the global event model for work initiated by
+// this instance in the background, rather than by a request
+func globalEventForSystem() events.GEM {
+	node := "node/" + config.NodeID
+	e := events.GEM{
+		ID:               newGUID(),
+		SchemaVersion:    "1.0",
+		EventType:        "object-drive-event",
+		SystemIP:         util.GetIP(config.RootLogger),
+		Timestamp:        time.Now().UTC().Unix(),
+		Action:           "unknown",
+		OriginatorTokens: []string{node},
+	}
+	var a events_thrift.AuditEvent
+	a = audit.WithID(a, "guid", e.ID)
+	a = audit.WithType(a, "EventUnknown")
+	a = audit.WithAction(a, "ACCESS")
+	a = audit.WithActionMode(a, "SYSTEM_INITIATED")
+	a = audit.WithActionResult(a, "FAILURE")
+	a = audit.WithActionInitiator(a, "SYSTEM", node)
+	a = audit.WithCreator(a, "APPLICATION", "Object Drive")
+	a = audit.WithCreatedOn(a, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	e.Payload.Audit = a
+	return e
+}
+
 func defaultAudit(r *http.Request) events_thrift.AuditEvent {
 
 	var e events_thrift.AuditEvent
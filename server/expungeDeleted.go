@@ -22,8 +22,6 @@ type ExpungedStats struct {
 // We take a paging request to put a bound on the time until a response
 func (h AppServer) expungeDeleted(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
 
-	// Get user from context
-	user, _ := UserFromContext(ctx)
 	dao := DAOFromContext(ctx)
 	gem, _ := GEMFromContext(ctx)
 	gem.Action = "delete"
@@ -43,13 +41,32 @@ func (h AppServer) expungeDeleted(ctx context.Context, w http.ResponseWriter, r
 	if pageSize > int(h.Conf.MaxPageSize) {
 		pageSize = int(h.Conf.MaxPageSize)
 	}
-	expungedObjects, err := dao.ExpungeDeletedByUser(user, pageSize)
-	if err != nil {
-		herr := NewAppError(http.StatusInternalServerError, err, "Unable to expunge deleted objects for user")
+	if async, _ := strconv.ParseBool(r.URL.Query().Get("async")); async {
+		request := jobRequest{PageSize: pageSize}
+		return h.queueJob(ctx, w, gem, jobTypeExpunge, request, 0)
+	}
+	w.Header().Set("Status", "200")
+	expunged, herr := h.expungeDeletedPage(ctx, gem, pageSize)
+	if herr != nil {
 		h.publishError(gem, herr)
 		return herr
 	}
-	w.Header().Set("Status", "200")
+	go purgeReleasedContent(LoggerFromContext(ctx), dao)
+	expungedStats := ExpungedStats{ExpungedCount: expunged}
+	jsonResponse(w, expungedStats)
+
+	return nil
+}
+
+// expungeDeletedPage expunges up to a page of the objects in the trash of the
+// user, publishing an event for each, and returns the number expunged
+func (h AppServer) expungeDeletedPage(ctx context.Context, gem events.GEM, pageSize int) (int, *AppError) {
+	user, _ := UserFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	expungedObjects, err := dao.ExpungeDeletedByUser(user, pageSize)
+	if err != nil {
+		return 0, NewAppError(http.StatusInternalServerError, err, "Unable to expunge deleted objects for user")
+	}
 	for _, o := range expungedObjects.Objects {
 		gem = ResetBulkItem(gem)
 		gem.Payload.ObjectID = hex.EncodeToString(o.ID)
@@ -57,10 +74,7 @@ func (h AppServer) expungeDeleted(ctx context.Context, w http.ResponseWriter, r
 		gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(o))
 		gem.Payload.ChangeToken = o.ChangeToken
 		gem.Payload = events.WithEnrichedPayload(gem.Payload, mapping.MapODObjectToObject(&o))
-		h.publishSuccess(gem, w)
+		h.publishSuccessWithStatus(gem, "200")
 	}
-	expungedStats := ExpungedStats{ExpungedCount: expungedObjects.TotalRows}
-	jsonResponse(w, expungedStats)
-
-	return nil
+	return expungedObjects.TotalRows, nil
 }
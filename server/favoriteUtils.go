@@ -0,0 +1,34 @@
+package server
+
+import (
+	"encoding/hex"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+)
+
+// favoritesForObjects returns the set of hex encoded identifiers of the objects
+// provided that the caller has marked as a favorite. Failure to look up
+// favorites is logged and treated as having none, since it should not prevent
+// the objects themselves from being returned.
+func favoritesForObjects(ctx context.Context, objects []models.ODObject) map[string]bool {
+	favorites := make(map[string]bool)
+	if len(objects) == 0 {
+		return favorites
+	}
+	caller, _ := CallerFromContext(ctx)
+	logger := LoggerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	user := models.ODUser{DistinguishedName: caller.DistinguishedName}
+	dbFavorites, err := dao.GetFavoritesForObjects(user, objects)
+	if err != nil {
+		logger.Warn("unable to retrieve favorites for objects", zap.Error(err))
+		return favorites
+	}
+	for _, favorite := range dbFavorites {
+		favorites[hex.EncodeToString(favorite.ObjectID)] = true
+	}
+	return favorites
+}
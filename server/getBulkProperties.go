@@ -181,6 +181,23 @@ func (h AppServer) getBulkProperties(ctx context.Context, w http.ResponseWriter,
 			continue
 		}
 
+		definitions, err := getTypeProperties(ctx, h, dbObject.TypeID)
+		if err != nil {
+			herr := NewAppError(http.StatusInternalServerError, err, "error retrieving properties for type")
+			h.publishError(gem, herr)
+			bulkResponse.ObjectErrors = append(bulkResponse.ObjectErrors,
+				protocol.ObjectError{
+					ObjectID: requestObjectID,
+					Error:    herr.Error.Error(),
+					Msg:      herr.Msg,
+					Code:     herr.Code,
+				},
+			)
+			continue
+		}
+		// Report defaults for declared properties the object does not have.
+		dbObject.Properties = ApplyTypePropertyDefaults(definitions, dbObject.Properties)
+
 		crumbs := breadcrumbsFromParents(filtered)
 		apiResponse := mapping.MapODObjectToObject(&dbObject).
 			WithCallerPermission(protocolCaller(caller)).
@@ -90,9 +90,11 @@ func (h AppServer) getObject(ctx context.Context, w http.ResponseWriter, r *http
 	}
 	crumbs := breadcrumbsFromParents(filtered)
 
+	favorites := favoritesForObjects(ctx, []models.ODObject{dbObject})
 	apiResponse := mapping.MapODObjectToObject(&dbObject).
 		WithCallerPermission(protocolCaller(caller)).
 		WithBreadcrumbs(crumbs)
+	apiResponse = apiResponse.WithFavorite(favorites[apiResponse.ID])
 	jsonResponse(w, apiResponse)
 
 	h.publishSuccess(gem, w)
@@ -127,6 +129,43 @@ func getObjectDAOError(err error) (int, string, error) {
 	}
 }
 
+// commonObjectReadPrep retrieves the object referenced in the request URI and
+// verifies the caller is permitted to read it. It is shared by the requests
+// that act on an object the caller need only be able to read, such as
+// favoriting or subscribing to it.
+func (h AppServer) commonObjectReadPrep(ctx context.Context) (models.ODObject, *AppError) {
+
+	caller, _ := CallerFromContext(ctx)
+	logger := LoggerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+
+	requestObject, err := parseGetObjectRequest(ctx)
+	if err != nil {
+		return requestObject, NewAppError(http.StatusBadRequest, err, "Error parsing URI")
+	}
+
+	dbObject, err := dao.GetObject(requestObject, true)
+	if err != nil {
+		code, msg, err := getObjectDAOError(err)
+		return dbObject, NewAppError(code, err, msg)
+	}
+
+	if ok := isUserAllowedToRead(ctx, &dbObject); !ok {
+		return dbObject, NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User does not have permission to read/view this object")
+	}
+
+	aacAuth := auth.NewAACAuth(logger, h.AAC)
+	if _, err := aacAuth.IsUserAuthorizedForACM(caller.DistinguishedName, dbObject.RawAcm.String); err != nil {
+		return dbObject, NewAppError(authHTTPErr(err), err, err.Error())
+	}
+
+	if ok, code, err := isDeletedErr(dbObject); !ok {
+		return dbObject, NewAppError(code, err, "deleted object")
+	}
+
+	return dbObject, nil
+}
+
 // redactParents checks authorization to read and AAC call; if caller is authorized, add to
 // filtered slice for return.
 func redactParents(ctx context.Context, auth auth.Authorization, parents []models.ODObject) []models.ODObject {
@@ -7,7 +7,6 @@ import (
 	"io"
 	"net/http"
 	"path"
-	"strconv"
 	"strings"
 
 	"go.uber.org/zap"
@@ -27,33 +26,6 @@ import (
 	"bitbucket.di2e.net/dime/object-drive-server/performance"
 )
 
-func extractByteRange(r *http.Request) (*crypto.ByteRange, error) {
-	var err error
-	byteRangeSpec := r.Header.Get("Range")
-	if len(byteRangeSpec) == 0 {
-		// If there is no byte range requested, then don't return one.
-		return nil, nil
-	}
-	parsedByteRange := crypto.NewByteRange()
-	typeOfRange := strings.Split(byteRangeSpec, "=")
-	if typeOfRange[0] == "bytes" {
-		startStop := strings.Split(typeOfRange[1], "-")
-		parsedByteRange.Start, err = strconv.ParseInt(startStop[0], 10, 64)
-		if err != nil {
-			return nil, err
-		}
-		if len(startStop[1]) > 0 {
-			parsedByteRange.Stop, err = strconv.ParseInt(startStop[1], 10, 64)
-			if err != nil {
-				return nil, err
-			}
-		}
-	} else {
-		return nil, fmt.Errorf("could not understand range type")
-	}
-	return parsedByteRange, err
-}
-
 // getObjectStream gets object data stored in object-drive
 func (h AppServer) getObjectStream(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
 	var err error
@@ -298,19 +270,35 @@ func (h AppServer) getAndStreamFile(ctx context.Context, object *models.ODObject
 	gem, _ := GEMFromContext(ctx)
 	var finalStatus *AppError
 	logger := LoggerFromContext(ctx)
-
-	//Prepare for range requesting
-	byteRange, err := extractByteRange(r)
-	if err != nil {
-		herr := NewAppError(http.StatusBadRequest, err, "Unable to parse byte range")
-		h.publishError(gem, herr)
+	fullLength := object.ContentSize.Int64
+
+	//This contentHash is a sha256 of the full plaintext.
+	etag := fmt.Sprintf("\"%s\"", hex.EncodeToString(object.ContentHash))
+	w.Header().Set("ETag", etag)
+	lastModified := object.ModifiedDate.UTC()
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+	if isNotModified(r, etag, lastModified) {
+		herr := NewAppError(http.StatusNotModified, nil, "Not Modified")
 		return NoBytesReturned, herr
 	}
-	var blocksSkipped int64
-	var cipherStartAt int64
-	if byteRange != nil {
-		blocksSkipped = (byteRange.Start / aes.BlockSize)
-		cipherStartAt = blocksSkipped * aes.BlockSize
+
+	//Prepare for range requesting, unless what the client has of the content is out of date
+	var byteRanges []crypto.ByteRange
+	if isRangeCurrent(r, etag, lastModified) {
+		byteRanges, err = ParseByteRanges(r.Header.Get("Range"), fullLength)
+		if err == errRangeNotSatisfiable {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", fullLength))
+			herr := NewAppError(http.StatusRequestedRangeNotSatisfiable, err, "Requested range not satisfiable")
+			h.publishError(gem, herr)
+			return NoBytesReturned, herr
+		}
+		if err != nil {
+			// An invalid Range header is ignored, and the whole content sent
+			logger.Info("ignoring invalid byte range", zap.String("range", r.Header.Get("Range")), zap.Error(err))
+			byteRanges = nil
+		}
 	}
 
 	//We should have classification banner with the content, as
@@ -336,114 +324,85 @@ func (h AppServer) getAndStreamFile(ctx context.Context, object *models.ODObject
 		}
 	}
 
-	//When setting headers, take measures to handle byte range requesting
-	w.Header().Set("Content-Type", sanitizeAgainstCRLFInHeader(object.ContentType.String))
-	var start = int64(0)
-	var stop = object.ContentSize.Int64 - 1
-	var fullLength = object.ContentSize.Int64
-	clientEtag := r.Header.Get("If-None-Match")
-	if object.ContentSize.Valid {
-		var reportedContentLength = fullLength
+	contentType := sanitizeAgainstCRLFInHeader(object.ContentType.String)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Accept-Ranges", "bytes")
+	//typical disposition values: inline, attachment - RFC2183.
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`%s; filename="%s"`, sanitizeDisposition(disposition), sanitizeFilename(object.Name)))
+	//RFC2183 talks about Content-Description.  We should set this.
+	if object.Description.Valid && len(object.Description.String) > 0 {
+		w.Header().Set("Content-Description", sanitizeAgainstCRLFInHeader(object.Description.String))
+	}
 
-		w.Header().Set("Accept-Ranges", "bytes")
+	logger.Debug("cipher file being resolved", zap.String("id", hex.EncodeToString(object.ID)), zap.String("contentConnector", object.ContentConnector.String))
+	d := ciphertext.FindCiphertextCacheByObject(object)
+	rName := ciphertext.ContentConnectorFileId(object.ContentConnector.String)
+	cipherFilePathCached := d.Resolve(ciphertext.NewFileName(rName, ciphertext.FileStateCached))
 
-		if byteRange != nil {
-			start = byteRange.Start
-			if byteRange.Stop != -1 {
-				stop = byteRange.Stop
+	decipher := h.Conf.EncryptableFunctions.DecipherFor(object.CipherFormat)
+	var actualLength int64
+	if len(byteRanges) > 1 {
+		//Each range is pulled and decrypted in turn as a part of the response
+		send := func(out io.Writer, byteRange crypto.ByteRange) (int64, error) {
+			cipherReader, iv, err := h.pullObjectRange(logger, d, object, &byteRange)
+			if cipherReader != nil {
+				defer cipherReader.Close()
 			}
-			reportedContentLength = stop + 1 - start
+			if err != nil {
+				return 0, err
+			}
+			_, length, err := decipher(logger, cipherReader, out, encryptKey, iv, "client downloading", &byteRange)
+			return length, err
 		}
-
-		w.Header().Set("Content-Length", fmt.Sprintf("%d", reportedContentLength))
-		//typical disposition values: inline, attachment - RFC2183.
-		w.Header().Set("Content-Disposition", fmt.Sprintf(`%s; filename="%s"`, sanitizeDisposition(disposition), sanitizeFilename(object.Name)))
-		//RFC2183 talks about Content-Description.  We should set this.
-		if object.Description.Valid && len(object.Description.String) > 0 {
-			w.Header().Set("Content-Description", sanitizeAgainstCRLFInHeader(object.Description.String))
+		actualLength, err = writeMultipartByteRanges(w, byteRanges, fullLength, contentType, send)
+		finalStatus = NewAppError(http.StatusPartialContent, nil, "Partial Content")
+	} else {
+		var byteRange *crypto.ByteRange
+		if len(byteRanges) == 1 {
+			byteRange = &byteRanges[0]
+		}
+		//Pull the file from the cache
+		logger.Debug("cipher file being pulled", zap.String("contentConnector", object.ContentConnector.String))
+		cipherReader, iv, err := h.pullObjectRange(logger, d, object, byteRange)
+		// Ensure reader is closed even for errors as part of fix for DIMEODS-1262
+		if cipherReader != nil {
+			defer cipherReader.Close()
 		}
-		//This contentHash is a sha256 of the full plaintext.
-		contentHash := hex.EncodeToString(object.ContentHash)
+		if err != nil {
+			herr := NewAppError(http.StatusInternalServerError, err, err.Error())
+			h.publishError(gem, herr)
+			return NoBytesReturned, herr
+		}
+
+		//When setting headers, take measures to handle byte range requesting
 		if byteRange != nil {
-			rangeResponse := fmt.Sprintf("bytes %d-%d/%d", start, stop, fullLength)
-			w.Header().Set("Content-Range", rangeResponse)
-			etag := fmt.Sprintf("\"%s\"", contentHash)
-			w.Header().Set("ETag", etag)
-			if clientEtag == etag {
-				w.Header().Del("Content-Length")
-				herr := NewAppError(http.StatusNotModified, nil, "Not Modified")
-				return NoBytesReturned, herr
-			}
+			start, stop := byteRanges[0].Start, byteRanges[0].Stop
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", stop+1-start))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, stop, fullLength))
 			//Note that if we return a nil error, the stats collector will think we got a 200
 			//Begin writing a 206... one of the rare codes that still returns content
 			w.WriteHeader(http.StatusPartialContent)
 			//We cant return yet because we need to send bytes back, but we should return 206 in the end.
 			finalStatus = NewAppError(http.StatusPartialContent, nil, "Partial Content")
 		} else {
-			etag := fmt.Sprintf("\"%s\"", contentHash)
-			w.Header().Set("ETag", etag)
-			if clientEtag == etag {
-				w.Header().Del("Content-Length")
-				herr := NewAppError(http.StatusNotModified, nil, "Not Modified")
-				return NoBytesReturned, herr
-			}
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", fullLength))
 			//Begin writing back a normal 200
 			w.WriteHeader(http.StatusOK)
 		}
-	}
 
-	logger.Debug("cipher file being resolved", zap.String("id", hex.EncodeToString(object.ID)), zap.String("contentConnector", object.ContentConnector.String))
-	d := ciphertext.FindCiphertextCacheByObject(object)
-	rName := ciphertext.FileId(object.ContentConnector.String)
-	cipherFilePathCached := d.Resolve(ciphertext.NewFileName(rName, ciphertext.FileStateCached))
-	totalLength := object.ContentSize.Int64
-	isLocalPuller := false
-	var cipherReader io.ReadCloser
-
-	//Pull the file from the cache
-	logger.Debug("cipher file being pulled", zap.String("contentConnector", object.ContentConnector.String))
-	cipherReader, isLocalPuller, err = d.NewPuller(logger, rName, totalLength, cipherStartAt, -1)
-	// Ensure reader is closed even for errors as part of fix for DIMEODS-1262
-	if cipherReader != nil {
-		defer cipherReader.Close()
-	}
-	if err != nil {
-		herr := NewAppError(http.StatusInternalServerError, err, err.Error())
-		h.publishError(gem, herr)
-		return NoBytesReturned, herr
-	}
-	//If it didn't come from the files in the cache, then make sure we ReCache it for future use
-	//TODO: it might be desirable to only do this probabilistically
-	if !isLocalPuller {
-		logger.Debug("cipher file was not local will do background recache")
-		go func() {
-			trackingEnabled := false
-			var beganAt performance.BeganJob
-			if trackingEnabled {
-				beganAt = h.Tracker.BeginTime(performance.S3DrainFrom)
-			}
-			d.BackgroundRecache(rName, object.ContentSize.Int64)
-			if trackingEnabled {
-				h.Tracker.EndTime(performance.S3DrainFrom, beganAt, performance.SizeJob(fullLength))
-			}
-		}()
+		//Actually send back the cipherFile
+		_, actualLength, err = decipher(
+			logger,
+			cipherReader,
+			w,
+			encryptKey,
+			iv,
+			"client downloading",
+			byteRange,
+		)
 	}
 
-	//Skip over blocks we won't use, and adjust byteRange to match it
-	iv := adjustIV(object.EncryptIV, byteRange)
-	//Actually send back the cipherFile
-	var actualLength int64
-	_, actualLength, err = h.Conf.EncryptableFunctions.DoCipherByReaderWriter(
-		logger,
-		cipherReader,
-		w,
-		encryptKey,
-		iv,
-		"client downloading",
-		byteRange,
-	)
-
-	logger.Debug("sent content stream to client", zap.Int64("bytes", actualLength), zap.Any("byterange", byteRange))
+	logger.Debug("sent content stream to client", zap.Int64("bytes", actualLength), zap.Any("byteranges", byteRanges))
 	if err != nil {
 		//Error here isn't a constant, but it's indicative of client disconnecting and
 		//not bothering to eat all the bytes we sent back (as promised).  So be quiet
@@ -462,3 +421,46 @@ func (h AppServer) getAndStreamFile(ctx context.Context, object *models.ODObject
 	//the finalStatus is not necessarily an error, but requires a status code, because nil implies 200.
 	return actualLength, finalStatus
 }
+
+// pullObjectRange begins pulling the ciphertext of the object from the block,
+// or chunk for content sealed in chunks, containing the start of the byte
+// range, or from the beginning if there is no range. The byte range is
+// adjusted to be relative to that block, and the iv to decrypt from it is
+// returned. It is the caller's responsibility to close the reader, even if
+// there is an error.
+func (h AppServer) pullObjectRange(logger *zap.Logger, d ciphertext.CiphertextCache, object *models.ODObject, byteRange *crypto.ByteRange) (io.ReadCloser, []byte, error) {
+	rName := ciphertext.ContentConnectorFileId(object.ContentConnector.String)
+	cipherLength := crypto.CiphertextSize(object.CipherFormat, object.ContentSize.Int64)
+	var cipherStartAt int64
+	var iv []byte
+	if object.CipherFormat == crypto.CipherFormatGCM {
+		cipherStartAt, iv = crypto.AdjustSealedIV(object.EncryptIV, byteRange)
+	} else {
+		if byteRange != nil {
+			cipherStartAt = (byteRange.Start / aes.BlockSize) * aes.BlockSize
+		}
+		//Skip over blocks we won't use, and adjust byteRange to match it
+		iv = adjustIV(object.EncryptIV, byteRange)
+	}
+	cipherReader, isLocalPuller, err := d.NewPuller(logger, rName, cipherLength, cipherStartAt, -1)
+	if err != nil {
+		return cipherReader, nil, err
+	}
+	//If it didn't come from the files in the cache, then make sure we ReCache it for future use
+	//TODO: it might be desirable to only do this probabilistically
+	if !isLocalPuller {
+		logger.Debug("cipher file was not local will do background recache")
+		go func() {
+			trackingEnabled := false
+			var beganAt performance.BeganJob
+			if trackingEnabled {
+				beganAt = h.Tracker.BeginTime(performance.S3DrainFrom)
+			}
+			d.BackgroundRecache(rName, cipherLength)
+			if trackingEnabled {
+				h.Tracker.EndTime(performance.S3DrainFrom, beganAt, performance.SizeJob(cipherLength))
+			}
+		}()
+	}
+	return cipherReader, iv, nil
+}
@@ -3,7 +3,10 @@ package server_test
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httputil"
 	"strings"
@@ -137,6 +140,98 @@ func TestUploadAndGetByteRange(t *testing.T) {
 	}
 }
 
+func TestGetObjectStreamMultipleByteRanges(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	clientID := 5
+	b := []byte(`abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890!@`)
+
+	file, cleanup := GenerateTempFileFromBytes(b, t)
+	defer cleanup()
+	req, err := NewCreateObjectPOSTRequest("", file)
+	failNowOnErr(t, err, "Failure from NewCreateObjectPOSTRequest")
+	responseObject := doCreateObjectRequest(t, clientID, req, 200)
+
+	// Ranges that are not block aligned, including a suffix range
+	req, err = NewGetObjectStreamRequest(responseObject.ID, "")
+	failNowOnErr(t, err, "Could not create GetObjectStreamRequest")
+	req.Header.Set("Range", "bytes=3-9, 40-41, -5")
+	res := doGetObjectRequest(t, clientID, req, http.StatusPartialContent, nil, nil)
+	defer util.FinishBody(res.Body)
+	mediaType, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	failNowOnErr(t, err, "Could not parse Content-Type")
+	if mediaType != "multipart/byteranges" {
+		t.Fatalf("expected multipart/byteranges, got %s", mediaType)
+	}
+	expected := []struct {
+		contentRange string
+		content      []byte
+	}{
+		{"bytes 3-9/64", b[3:10]},
+		{"bytes 40-41/64", b[40:42]},
+		{"bytes 59-63/64", b[59:]},
+	}
+	mr := multipart.NewReader(res.Body, params["boundary"])
+	for _, e := range expected {
+		part, err := mr.NextPart()
+		failNowOnErr(t, err, "Could not read part")
+		if part.Header.Get("Content-Range") != e.contentRange {
+			t.Errorf("expected Content-Range %s, got %s", e.contentRange, part.Header.Get("Content-Range"))
+		}
+		content, err := ioutil.ReadAll(part)
+		failNowOnErr(t, err, "Could not read part content")
+		if !bytes.Equal(content, e.content) {
+			t.Errorf("expected %q, got %q", e.content, content)
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("expected only %d parts", len(expected))
+	}
+
+	// A range beyond the content cannot be satisfied
+	req, err = NewGetObjectStreamRequest(responseObject.ID, "")
+	failNowOnErr(t, err, "Could not create GetObjectStreamRequest")
+	req.Header.Set("Range", "bytes=100-")
+	res = doGetObjectRequest(t, clientID, req, http.StatusRequestedRangeNotSatisfiable, nil, nil)
+	util.FinishBody(res.Body)
+	if res.Header.Get("Content-Range") != "bytes */64" {
+		t.Errorf("expected unsatisfied Content-Range, got %s", res.Header.Get("Content-Range"))
+	}
+
+	// The whole content is sent when the Range header is invalid
+	req, err = NewGetObjectStreamRequest(responseObject.ID, "")
+	failNowOnErr(t, err, "Could not create GetObjectStreamRequest")
+	req.Header.Set("Range", "bytes=abc-")
+	res = doGetObjectRequest(t, clientID, req, http.StatusOK, nil, nil)
+	content, err := ioutil.ReadAll(res.Body)
+	util.FinishBody(res.Body)
+	failNowOnErr(t, err, "Could not read content")
+	if !bytes.Equal(content, b) {
+		t.Errorf("expected whole content, got %q", content)
+	}
+
+	// The whole content is sent when If-Range does not match
+	req, err = NewGetObjectStreamRequest(responseObject.ID, "")
+	failNowOnErr(t, err, "Could not create GetObjectStreamRequest")
+	req.Header.Set("Range", "bytes=0-4")
+	req.Header.Set("If-Range", `"9a29ea29e29eac3457b"`)
+	res = doGetObjectRequest(t, clientID, req, http.StatusOK, nil, nil)
+	content, err = ioutil.ReadAll(res.Body)
+	util.FinishBody(res.Body)
+	failNowOnErr(t, err, "Could not read content")
+	if !bytes.Equal(content, b) {
+		t.Errorf("expected whole content, got %q", content)
+	}
+
+	// Not modified since the content was last modified
+	req, err = NewGetObjectStreamRequest(responseObject.ID, "")
+	failNowOnErr(t, err, "Could not create GetObjectStreamRequest")
+	req.Header.Set("If-Modified-Since", res.Header.Get("Last-Modified"))
+	res = doGetObjectRequest(t, clientID, req, http.StatusNotModified, nil, nil)
+	util.FinishBody(res.Body)
+}
+
 func isResponseError(res *http.Response, t *testing.T) {
 	if res == nil {
 		t.Errorf("Response was nil")
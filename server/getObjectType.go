@@ -0,0 +1,72 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+// maxTypeNameLength is the size of the name column of object_type
+const maxTypeNameLength = 255
+
+func (h AppServer) getObjectType(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	d := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	typeName, err := parseTypeNameRequest(ctx)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, err.Error())
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	objectType, err := d.GetObjectTypeByName(typeName, false, "")
+	if err != nil || objectType.IsDeleted {
+		if err == nil || err == sql.ErrNoRows {
+			herr := NewAppError(http.StatusNotFound, errors.New("type not found"), "Type not found")
+			h.publishError(gem, herr)
+			return herr
+		}
+		herr := NewAppError(http.StatusInternalServerError, err, "Error retrieving type")
+		h.publishError(gem, herr)
+		return herr
+	}
+	objectType.TypeProperties, err = d.GetObjectTypeProperties(objectType)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "Error retrieving properties for type")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	apiResponse := mapping.MapODObjectTypeToObjectType(&objectType)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+func parseTypeNameRequest(ctx context.Context) (string, error) {
+	captured, _ := CaptureGroupsFromContext(ctx)
+	typeName, err := url.PathUnescape(captured["typeName"])
+	if err != nil {
+		return "", errors.New("invalid type name in URI")
+	}
+	typeName = strings.TrimSpace(typeName)
+	if len(typeName) == 0 {
+		return "", errors.New("could not extract type name from URI")
+	}
+	if len(typeName) > maxTypeNameLength {
+		return "", errors.New("type name exceeds maximum length")
+	}
+	return typeName, nil
+}
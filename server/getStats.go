@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"os"
@@ -11,7 +12,10 @@ import (
 
 	metrics "github.com/rcrowley/go-metrics"
 
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
 	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
 	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
 	"golang.org/x/net/context"
 
@@ -40,6 +44,10 @@ func (h AppServer) getStats(ctx context.Context, w http.ResponseWriter, r *http.
 	fmt.Fprintf(w, "\t\"usersLruCacheCount\": %d,\n", h.UsersLruCache.ItemCount())
 	fmt.Fprintf(w, "\t\"userAOsLruCacheCount\": %d,\n", h.UserAOsLruCache.ItemCount())
 	fmt.Fprintf(w, "\t\"typesLruCacheCount\": %d,\n", h.TypeLruCache.ItemCount())
+	renderContentDedupStats(w, h.RootDAO)
+	renderKeyRotationStats(w, h.RootDAO)
+	renderReencryptStats(w, h.RootDAO)
+	renderScrubStats(w, h.Conf.ScrubRate, h.scrub)
 	renderErrorCounters(w)
 	renderMetricsForTrackedFunctions(w)
 
@@ -50,6 +58,119 @@ func (h AppServer) getStats(ctx context.Context, w http.ResponseWriter, r *http.
 	return nil
 }
 
+// Write out the storage saved by deduplicating content, if it can be determined
+func renderContentDedupStats(w http.ResponseWriter, d dao.DAO) {
+	stats, err := d.GetContentDedupStats()
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "\t\"contentDeduplication\": {\n")
+	fmt.Fprintf(w, "\t\t\"contentCount\": %d,\n", stats.ContentCount)
+	fmt.Fprintf(w, "\t\t\"referenceCount\": %d,\n", stats.ReferenceCount)
+	fmt.Fprintf(w, "\t\t\"bytesSaved\": %d\n", stats.BytesSaved)
+	fmt.Fprintf(w, "\t},\n")
+}
+
+// Write out the progress of rewrapping grants for zones whose master key is
+// being rotated on this instance
+func renderKeyRotationStats(w http.ResponseWriter, d dao.DAO) {
+	rotating := rotatingCiphertextCaches()
+	if len(rotating) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\t\"keyRotation\": [\n")
+	for i, dp := range rotating {
+		job, err := d.GetJob(models.ODJob{ID: keyRotationJobID(dp)})
+		if err != nil {
+			job.State = "unknown"
+		}
+		fmt.Fprintf(w, "\t\t{\n")
+		fmt.Fprintf(w, "\t\t\t\"zone\": \"%s\",\n", dp.GetCiphertextCacheZone())
+		fmt.Fprintf(w, "\t\t\t\"jobId\": \"%s\",\n", hex.EncodeToString(keyRotationJobID(dp)))
+		fmt.Fprintf(w, "\t\t\t\"state\": \"%s\",\n", job.State)
+		fmt.Fprintf(w, "\t\t\t\"processed\": %d,\n", job.Processed)
+		fmt.Fprintf(w, "\t\t\t\"rewrapped\": %d,\n", job.Succeeded)
+		fmt.Fprintf(w, "\t\t\t\"failed\": %d\n", job.Failed)
+		if i < len(rotating)-1 {
+			fmt.Fprintf(w, "\t\t},\n")
+		} else {
+			fmt.Fprintf(w, "\t\t}\n")
+		}
+	}
+	fmt.Fprintf(w, "\t],\n")
+}
+
+// Write out the progress of re-encrypting content for zones that have been
+// queued for it
+func renderReencryptStats(w http.ResponseWriter, d dao.DAO) {
+	var jobs []models.ODJob
+	var zones []ciphertext.CiphertextCacheZone
+	for _, dp := range ciphertext.FindCiphertextCacheList() {
+		job, err := d.GetJob(models.ODJob{ID: reencryptJobID(dp)})
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+		zones = append(zones, dp.GetCiphertextCacheZone())
+	}
+	if len(jobs) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\t\"reencryption\": [\n")
+	for i, job := range jobs {
+		fmt.Fprintf(w, "\t\t{\n")
+		fmt.Fprintf(w, "\t\t\t\"zone\": \"%s\",\n", zones[i])
+		fmt.Fprintf(w, "\t\t\t\"jobId\": \"%s\",\n", hex.EncodeToString(job.ID))
+		fmt.Fprintf(w, "\t\t\t\"state\": \"%s\",\n", job.State)
+		fmt.Fprintf(w, "\t\t\t\"processed\": %d,\n", job.Processed)
+		fmt.Fprintf(w, "\t\t\t\"reencrypted\": %d,\n", job.Succeeded)
+		fmt.Fprintf(w, "\t\t\t\"failed\": %d\n", job.Failed)
+		if i < len(jobs)-1 {
+			fmt.Fprintf(w, "\t\t},\n")
+		} else {
+			fmt.Fprintf(w, "\t\t}\n")
+		}
+	}
+	fmt.Fprintf(w, "\t],\n")
+}
+
+// Write out the results of verifying stored content on this instance
+func renderScrubStats(w http.ResponseWriter, rate int64, s *scrubStats) {
+	if rate <= 0 || s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	fmt.Fprintf(w, "\t\"contentVerification\": {\n")
+	fmt.Fprintf(w, "\t\t\"ratePerMinute\": %d,\n", rate)
+	fmt.Fprintf(w, "\t\t\"passes\": %d,\n", s.Passes)
+	if !s.LastPass.IsZero() {
+		fmt.Fprintf(w, "\t\t\"lastPassDate\": \"%s\",\n", s.LastPass.Format(time.RFC3339Nano))
+	}
+	fmt.Fprintf(w, "\t\t\"verified\": %d,\n", s.Verified)
+	fmt.Fprintf(w, "\t\t\"pending\": %d,\n", s.Pending)
+	fmt.Fprintf(w, "\t\t\"mismatched\": %d,\n", s.Mismatched)
+	fmt.Fprintf(w, "\t\t\"missing\": %d,\n", s.Missing)
+	fmt.Fprintf(w, "\t\t\"missingKey\": %d,\n", s.MissingKey)
+	fmt.Fprintf(w, "\t\t\"errors\": %d,\n", s.Errors)
+	fmt.Fprintf(w, "\t\t\"recentIssues\": [\n")
+	for i, p := range s.RecentIssues {
+		fmt.Fprintf(w, "\t\t\t{\n")
+		fmt.Fprintf(w, "\t\t\t\t\"objectId\": \"%s\",\n", p.ObjectID)
+		fmt.Fprintf(w, "\t\t\t\t\"contentConnector\": %q,\n", p.ContentConnector)
+		fmt.Fprintf(w, "\t\t\t\t\"problem\": \"%s\",\n", p.Problem)
+		fmt.Fprintf(w, "\t\t\t\t\"msg\": %q,\n", p.Msg)
+		fmt.Fprintf(w, "\t\t\t\t\"foundDate\": \"%s\"\n", p.Found.Format(time.RFC3339Nano))
+		if i < len(s.RecentIssues)-1 {
+			fmt.Fprintf(w, "\t\t\t},\n")
+		} else {
+			fmt.Fprintf(w, "\t\t\t}\n")
+		}
+	}
+	fmt.Fprintf(w, "\t\t]\n")
+	fmt.Fprintf(w, "\t},\n")
+}
+
 // Write the counters out.  Make sure we are in the thread of the datastructure when we do this
 func renderErrorCounters(w http.ResponseWriter) {
 	// Count the total number of events per endpoint, and report for each line
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+func (h AppServer) getSubscription(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	dbSubscription, herr := commonSubscriptionPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(dbSubscription.ObjectID)
+
+	apiResponse := mapping.MapODUserObjectSubscriptionToSubscription(&dbSubscription)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// commonSubscriptionPrep retrieves the subscription referenced in the request
+// URI and verifies that it belongs to the caller.
+func commonSubscriptionPrep(ctx context.Context) (models.ODUserObjectSubscription, *AppError) {
+
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+
+	captured, ok := CaptureGroupsFromContext(ctx)
+	if !ok {
+		return models.ODUserObjectSubscription{}, NewAppError(http.StatusBadRequest, errors.New("could not get capture groups"), "Error parsing URI")
+	}
+	subscriptionID, err := hex.DecodeString(captured["subscriptionId"])
+	if err != nil || len(subscriptionID) == 0 {
+		return models.ODUserObjectSubscription{}, NewAppError(http.StatusBadRequest, errors.New("invalid subscriptionId in URI"), "Error parsing URI")
+	}
+
+	dbSubscription, err := dao.GetSubscription(models.ODUserObjectSubscription{ID: subscriptionID})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return dbSubscription, NewAppError(http.StatusNotFound, err, "Subscription not found")
+		}
+		return dbSubscription, NewAppError(http.StatusInternalServerError, err, "Error retrieving subscription")
+	}
+	if dbSubscription.IsDeleted {
+		return dbSubscription, NewAppError(http.StatusGone, errors.New("subscription is deleted"), "Subscription is deleted")
+	}
+	if models.AACFlatten(dbSubscription.CreatedBy) != models.AACFlatten(caller.DistinguishedName) {
+		return dbSubscription, NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - Subscription belongs to another user")
+	}
+	return dbSubscription, nil
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+// getUploadSession reports the chunks received for a resumable upload, so that
+// a client may resume by sending only those that are missing.
+func (h AppServer) getUploadSession(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	id, herr := uploadSessionIDFromCaptureGroups(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	uploadSessionLock.Lock()
+	session, herr := loadUploadSession(ctx, id)
+	uploadSessionLock.Unlock()
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	jsonResponse(w, session.toProtocol())
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// uploadSessionIDFromCaptureGroups returns the uploadId in the request URI
+func uploadSessionIDFromCaptureGroups(ctx context.Context) (string, *AppError) {
+	captured, ok := CaptureGroupsFromContext(ctx)
+	if !ok || len(captured["uploadId"]) == 0 {
+		return "", NewAppError(http.StatusBadRequest, errors.New("could not get capture groups"), "Error parsing URI")
+	}
+	return captured["uploadId"], nil
+}
@@ -1,7 +1,8 @@
 package server
 
 import (
-	"archive/zip"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +10,8 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"sort"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -18,6 +21,7 @@ import (
 	"bitbucket.di2e.net/dime/object-drive-server/auth"
 	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
 	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
 	"bitbucket.di2e.net/dime/object-drive-server/protocol"
 
 	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
@@ -58,8 +62,21 @@ func newFileAcmInfo(fqPath string, rawAcm string, dt time.Time) *zipFileInfo {
 
 // This is an item in the manifest
 type zipManifestItem struct {
-	Portion string
-	Name    string
+	Portion string `json:"portion"`
+	Name    string `json:"name"`
+	// ObjectID and ChangeCount identify the revision of the object included
+	ObjectID    string `json:"objectId"`
+	ChangeCount int    `json:"changeCount"`
+	// ACM is the full acm of the revision, as only the portion is written to
+	// the text manifest
+	ACM json.RawMessage `json:"acm,omitempty"`
+}
+
+// A relationship between two files in the manifest
+type zipManifestRelationship struct {
+	Source string `json:"source"`
+	Type   string `json:"type"`
+	Target string `json:"target"`
 }
 
 // All the state we need to write a manifest
@@ -67,6 +84,17 @@ type zipManifestItem struct {
 type zipManifest struct {
 	Files []zipManifestItem
 	ACMs  map[string]bool
+	// Names of the files included, keyed by hex encoded object id
+	Names         map[string]string
+	Relationships []zipManifestRelationship
+}
+
+// zipManifestJSON is the manifest written as a JSON sidecar, for consumers
+// that need to process the classifications of the files
+type zipManifestJSON struct {
+	Banner        string                    `json:"banner"`
+	Files         []zipManifestItem         `json:"files"`
+	Relationships []zipManifestRelationship `json:"relationships"`
 }
 
 // All the state we need to deconflict names in a directory
@@ -74,9 +102,22 @@ type zipUsedNames struct {
 	UsedNames map[string]bool
 }
 
+// zipExport is the state of an archive being written, as it is passed
+// down through folders
+type zipExport struct {
+	Archive   archiveWriter
+	Manifest  *zipManifest
+	UsedNames *zipUsedNames
+	// Folders are the hex encoded ids of folders included, so that a folder
+	// selected along with its ancestor is only included once
+	Folders          map[string]bool
+	IncludeRevisions bool
+	User             models.ODUser
+}
+
 // We need to retain classifications of files, so we need to write something
 // to re-associate them, short of doing file renaming to retain classification
-func zipWriteManifest(ctx context.Context, aacClient aac.AacService, zw *zip.Writer, manifest *zipManifest) *AppError {
+func zipWriteManifest(ctx context.Context, aacClient aac.AacService, aw archiveWriter, manifest *zipManifest) *AppError {
 	user, ok := UserFromContext(ctx)
 	if !ok {
 		return NewAppError(http.StatusInternalServerError, nil, "unable to get user from context")
@@ -107,25 +148,40 @@ func zipWriteManifest(ctx context.Context, aacClient aac.AacService, zw *zip.Wri
 	}
 
 	// Begin writing the manifest (associate portion with individual files)
-	header, err := zip.FileInfoHeader(newManifestInfo("classification_manifest.txt", time.Now().UTC()))
-	if err != nil {
-		return NewAppError(http.StatusInternalServerError, err, "Unable to create file acm info header")
-	}
-	w, err := zw.CreateHeader(header)
-	if err != nil {
-		return NewAppError(http.StatusInternalServerError, err, "unable to create manifest")
-	}
-
 	// Make sure that the first line of the manifest is the rollup portion,
 	// so that it's obvious what the overall classification is.
 	// And write the portion and name of each individual file
-	w.Write([]byte(fmt.Sprintf("%s\n\n", banner)))
+	var w bytes.Buffer
+	w.WriteString(fmt.Sprintf("%s\n\n", banner))
 	for _, m := range manifest.Files {
-		w.Write([]byte(fmt.Sprintf("(%s) %s\n", m.Portion, path.Clean(m.Name))))
+		w.WriteString(fmt.Sprintf("(%s) %s\n", m.Portion, path.Clean(m.Name)))
+	}
+	// Relationships are only listed between files in the archive
+	if len(manifest.Relationships) > 0 {
+		w.WriteString("\nRelationships\n")
+		for _, rel := range manifest.Relationships {
+			w.WriteString(fmt.Sprintf("%s -[%s]-> %s\n", path.Clean(rel.Source), rel.Type, path.Clean(rel.Target)))
+		}
 	}
 	// And write the portion and name of each individual file
-	w.Write([]byte(fmt.Sprintf("\n%s\n", banner)))
+	w.WriteString(fmt.Sprintf("\n%s\n", banner))
+	now := time.Now().UTC()
+	if err := writeArchiveFile(aw, "classification_manifest.txt", w.Bytes(), now); err != nil {
+		return NewAppError(http.StatusInternalServerError, err, "unable to create manifest")
+	}
 
+	// The same manifest as JSON, with the full acm of each file
+	sidecar := zipManifestJSON{Banner: banner, Files: manifest.Files, Relationships: manifest.Relationships}
+	if sidecar.Relationships == nil {
+		sidecar.Relationships = []zipManifestRelationship{}
+	}
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return NewAppError(http.StatusInternalServerError, err, "unable to marshal manifest")
+	}
+	if err := writeArchiveFile(aw, "classification_manifest.json", data, now); err != nil {
+		return NewAppError(http.StatusInternalServerError, err, "unable to create manifest")
+	}
 	return nil
 }
 
@@ -136,44 +192,23 @@ func zipReadCloser(dp ciphertext.CiphertextCache, logger *zap.Logger, rName ciph
 	return f, err
 }
 
-// newFileInfo is everything that a zip needs to know about the file
-func newFileInfo(obj models.ODObject, fqPath string) *zipFileInfo {
-	return &zipFileInfo{
-		size:    obj.ContentSize.Int64,
-		name:    path.Clean(fqPath),
-		modTime: obj.ModifiedDate,
-		mode:    os.FileMode(0600),
-		isDir:   false,
-	}
-}
-
-// newManifestInfo is everything that a zip needs to know about the file
-func newManifestInfo(fqPath string, dt time.Time) *zipFileInfo {
-	return &zipFileInfo{
-		name:    path.Clean(fqPath),
-		modTime: dt,
-		mode:    os.FileMode(0600),
-		isDir:   false,
-	}
-}
-
-// zipWriteFile writes filestreams to the archive. Security checks should already have taken place.
-// Note that we do NOT support file paths now.  We only produce flat zips of individual files, with
-// no original directory hierarchy.
+// zipWriteFile writes filestreams to the archive under the name given, which
+// may include the path of the folders it was found in. Security checks should
+// already have taken place.
 func zipWriteFile(
 	ctx context.Context,
 	h AppServer,
 	obj models.ODObject,
-	zw *zip.Writer,
+	name string,
+	aw archiveWriter,
 	userPermission models.ODObjectPermission,
-	manifest *zipManifest,
 ) *AppError {
+	logger := LoggerFromContext(ctx)
 	totalLength := obj.ContentSize.Int64
 	if totalLength <= 0 {
 		logger.Debug("skipping object have no content length")
 		return nil
 	}
-	logger := LoggerFromContext(ctx)
 	dp := ciphertext.FindCiphertextCacheByObject(&obj)
 	// Using captured permission, derive filekey
 	var fileKey []byte
@@ -183,8 +218,8 @@ func zipWriteFile(
 	}
 
 	// Get the ciphertext for this file
-	rName := ciphertext.FileId(obj.ContentConnector.String)
-	cipherReader, err := zipReadCloser(dp, logger, rName, totalLength)
+	rName := ciphertext.ContentConnectorFileId(obj.ContentConnector.String)
+	cipherReader, err := zipReadCloser(dp, logger, rName, crypto.CiphertextSize(obj.CipherFormat, totalLength))
 	if cipherReader != nil {
 		defer cipherReader.Close()
 	}
@@ -192,22 +227,17 @@ func zipWriteFile(
 		logger.Error("unable to create puller for PermanentStorage", zap.Error(err))
 		return NewAppError(http.StatusInternalServerError, err, "Unable to create pullet to read files")
 	}
-	logger.Debug("permanentstorage pull for zip begin", zap.String("fname", obj.Name), zap.Int64("bytes", totalLength))
+	logger.Debug("permanentstorage pull for zip begin", zap.String("fname", name), zap.Int64("bytes", totalLength))
 
-	// Write the file header out, to properly set timestamps and permissions
-	header, err := zip.FileInfoHeader(newFileInfo(obj, obj.Name))
-	if err != nil {
-		return NewAppError(http.StatusInternalServerError, err, "unable to create file info header")
-	}
-	w, err := zw.CreateHeader(header)
+	w, err := aw.CreateFile(name, totalLength, obj.ModifiedDate)
 	if err != nil {
 		return NewAppError(http.StatusInternalServerError, err, "unable to write zip file")
 	}
 
-	// Actually send back the cipherFile to zip stream - decrypted
+	// Actually send back the cipherFile to archive stream - decrypted
 	byteRange := &crypto.ByteRange{Start: 0, Stop: -1}
 	var actualLength int64
-	_, actualLength, err = h.Conf.EncryptableFunctions.DoCipherByReaderWriter(
+	_, actualLength, err = h.Conf.EncryptableFunctions.DecipherFor(obj.CipherFormat)(
 		logger,
 		cipherReader,
 		w,
@@ -216,7 +246,10 @@ func zipWriteFile(
 		"zip for client",
 		byteRange,
 	)
-	logger.Debug("file pull for zip end", zap.String("fname", obj.Name), zap.Int64("bytes", actualLength))
+	logger.Debug("file pull for zip end", zap.String("fname", name), zap.Int64("bytes", actualLength))
+	if err != nil {
+		return NewAppError(http.StatusInternalServerError, err, "unable to write file to archive")
+	}
 	return nil
 }
 
@@ -236,46 +269,181 @@ func zipHasAccess(ctx context.Context, h AppServer, dbObject *models.ODObject) (
 	return isUserAllowedToReadWithPermission(ctx, dbObject)
 }
 
-// zipIncludeFile puts a single file into the zipArchive.
+// zipIncludeFile puts a single revision of a file into the archive under the
+// name given, reporting whether the caller could read it.
 func zipIncludeFile(
 	ctx context.Context,
 	h AppServer,
 	obj models.ODObject,
-	path string,
-	zw *zip.Writer,
+	name string,
+	aw archiveWriter,
 	manifest *zipManifest,
-	usedNames *zipUsedNames,
-) *AppError {
+) (bool, *AppError) {
 	hasAccess, userPermission := zipHasAccess(ctx, h, &obj)
-	if hasAccess {
-		if obj.RawAcm.Valid {
-			portion, err := acmExtractItem("portion", obj.RawAcm.String)
-			if err != nil {
-				return NewAppError(http.StatusInternalServerError, err, "Could not get portion from object")
+	if !hasAccess {
+		return false, nil
+	}
+	if !obj.RawAcm.Valid {
+		return false, NewAppError(http.StatusInternalServerError, nil, "No portion on object")
+	}
+	portion, err := acmExtractItem("portion", obj.RawAcm.String)
+	if err != nil {
+		return false, NewAppError(http.StatusInternalServerError, err, "Could not get portion from object")
+	}
+	manifest.ACMs[obj.RawAcm.String] = true
+	thisFile := zipManifestItem{
+		Portion:     portion,
+		Name:        name,
+		ObjectID:    hex.EncodeToString(obj.ID),
+		ChangeCount: obj.ChangeCount,
+	}
+	if json.Valid([]byte(obj.RawAcm.String)) {
+		thisFile.ACM = json.RawMessage(obj.RawAcm.String)
+	}
+	manifest.Files = append(manifest.Files, thisFile)
+	return true, zipWriteFile(ctx, h, obj, name, aw, userPermission)
+}
+
+// zipIncludeObject puts an object into the archive within the directory
+// given. Objects with content are included as files, along with their prior
+// revisions if requested, while those without are included as folders of
+// their descendants.
+func zipIncludeObject(ctx context.Context, h AppServer, export *zipExport, obj models.ODObject, dir string) *AppError {
+	logger := LoggerFromContext(ctx)
+	id := hex.EncodeToString(obj.ID)
+	if !obj.ContentSize.Valid || obj.ContentSize.Int64 <= 0 {
+		if export.Folders[id] {
+			return nil
+		}
+		export.Folders[id] = true
+		return zipIncludeFolder(ctx, h, export, obj, zipSuggestName(export.UsedNames, dir, obj.Name))
+	}
+	// A file selected along with its folder is only included once
+	if _, ok := export.Manifest.Names[id]; ok {
+		return nil
+	}
+	// Make sure that we don't have name collisions
+	name := zipSuggestName(export.UsedNames, dir, obj.Name)
+	included, herr := zipIncludeFile(ctx, h, obj, name, export.Archive, export.Manifest)
+	if herr != nil {
+		return herr
+	}
+	if !included {
+		logger.Info("zip not including file", zap.String("id", id))
+		return nil
+	}
+	export.Manifest.Names[id] = name
+	if export.IncludeRevisions {
+		return zipIncludeRevisions(ctx, h, export, obj, dir)
+	}
+	return nil
+}
+
+// zipIncludeFolder puts the descendants of a folder that the caller may read
+// into the directory given
+func zipIncludeFolder(ctx context.Context, h AppServer, export *zipExport, folder models.ODObject, dir string) *AppError {
+	if hasAccess, _ := zipHasAccess(ctx, h, &folder); !hasAccess {
+		return nil
+	}
+	d := DAOFromContext(ctx)
+	pagingRequest := dao.PagingRequest{
+		PageNumber:   1,
+		PageSize:     int(h.Conf.MaxPageSize),
+		SortSettings: []dao.SortSetting{dao.SortSetting{SortField: "name", SortAscending: true}},
+	}
+	for {
+		resultset, err := d.GetChildObjectsByUser(export.User, pagingRequest, folder)
+		if err != nil {
+			return NewAppError(http.StatusInternalServerError, err, "could not list folder for zip")
+		}
+		for _, child := range resultset.Objects {
+			if herr := zipIncludeObject(ctx, h, export, child, dir); herr != nil {
+				return herr
 			}
-			manifest.ACMs[obj.RawAcm.String] = true
-			thisFile := zipManifestItem{
-				Portion: portion,
-				Name:    obj.Name,
+		}
+		if pagingRequest.PageNumber >= resultset.PageCount {
+			return nil
+		}
+		pagingRequest.PageNumber++
+	}
+}
+
+// zipIncludeRevisions puts the prior revisions of a file with content into
+// the directory given, named for their change count
+func zipIncludeRevisions(ctx context.Context, h AppServer, export *zipExport, obj models.ODObject, dir string) *AppError {
+	d := DAOFromContext(ctx)
+	pagingRequest := dao.PagingRequest{
+		PageNumber:   1,
+		PageSize:     int(h.Conf.MaxPageSize),
+		SortSettings: []dao.SortSetting{dao.SortSetting{SortField: "changecount", SortAscending: true}},
+	}
+	for {
+		resultset, err := d.GetObjectRevisionsByUser(export.User, pagingRequest, obj, false)
+		if err != nil {
+			return NewAppError(http.StatusInternalServerError, err, "could not list revisions for zip")
+		}
+		for _, revision := range resultset.Objects {
+			if revision.ChangeCount == obj.ChangeCount || !revision.ContentSize.Valid || revision.ContentSize.Int64 <= 0 {
+				continue
 			}
-			manifest.Files = append(manifest.Files, thisFile)
-			herr := zipWriteFile(ctx, h, obj, zw, userPermission, manifest)
-			if herr != nil {
+			name := zipSuggestName(export.UsedNames, dir, zipRevisionName(revision.Name, revision.ChangeCount))
+			if _, herr := zipIncludeFile(ctx, h, revision, name, export.Archive, export.Manifest); herr != nil {
 				return herr
 			}
-		} else {
-			return NewAppError(http.StatusInternalServerError, nil, "No portion on object")
 		}
+		if pagingRequest.PageNumber >= resultset.PageCount {
+			return nil
+		}
+		pagingRequest.PageNumber++
 	}
-	return nil
+}
+
+// zipRevisionName is the name of a prior revision of a file, such as
+// "report(revision 2).txt"
+func zipRevisionName(name string, changeCount int) string {
+	name = path.Base(name)
+	ext := path.Ext(name)
+	return fmt.Sprintf("%s(revision %d)%s", name[0:len(name)-len(ext)], changeCount, ext)
 }
 
 func newManifest(zipSpec *protocol.Zip) *zipManifest {
 	m := zipManifest{}
 	m.ACMs = make(map[string]bool)
+	m.Names = make(map[string]string)
 	return &m
 }
 
+// zipIncludeRelationships adds the relationships between files in the archive
+// to the manifest. Since both ends are in the archive, the caller has already
+// been verified as able to read them.
+func zipIncludeRelationships(ctx context.Context, manifest *zipManifest) *AppError {
+	d := DAOFromContext(ctx)
+	var ids []string
+	for id := range manifest.Names {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		objectID, _ := hex.DecodeString(id)
+		relationships, err := d.GetRelationshipsForObject(models.ODObject{ID: objectID}, dao.RelationshipsOut)
+		if err != nil {
+			return NewAppError(http.StatusInternalServerError, err, "could not retrieve relationships for zip manifest")
+		}
+		for _, relationship := range relationships {
+			target, ok := manifest.Names[hex.EncodeToString(relationship.TargetID)]
+			if !ok {
+				continue
+			}
+			manifest.Relationships = append(manifest.Relationships, zipManifestRelationship{
+				Source: manifest.Names[id],
+				Type:   relationship.RelationshipType,
+				Target: target,
+			})
+		}
+	}
+	return nil
+}
+
 func newUsedNames() *zipUsedNames {
 	u := zipUsedNames{}
 	u.UsedNames = make(map[string]bool)
@@ -286,17 +454,23 @@ func newUsedNames() *zipUsedNames {
 // Notice that we can't just pass around a map[string]bool due to being modified during
 // recursive searching.  So we opt to just have a pointer to a struct to modify, that might have
 // more state later.
-func zipSuggestName(u *zipUsedNames, name string) string {
-	// No more directories, so use the base unconditionally
+// The name suggested is within the directory given, which is empty for the root of
+// the archive, and names are deconflicted within each directory.
+func zipSuggestName(u *zipUsedNames, dir string, name string) string {
+	// Object names may hold path separators, so use the base unconditionally,
+	// and never let a name step outside of its directory
 	name = path.Base(name)
-	if u.UsedNames[name] {
+	if name == "." || name == ".." || name == "/" {
+		name = "_"
+	}
+	if u.UsedNames[path.Join(dir, name)] {
 		// Break up the file name to prepare for re-name
 		ext := path.Ext(name)
 		fname := name[0 : len(name)-len(ext)]
 		// Search for a non-conflicting file name
 		i := 1
 		for {
-			suggestedName := fmt.Sprintf("%s(%d)%s", fname, i, ext)
+			suggestedName := path.Join(dir, fmt.Sprintf("%s(%d)%s", fname, i, ext))
 			if u.UsedNames[suggestedName] {
 				i++
 			} else {
@@ -305,6 +479,7 @@ func zipSuggestName(u *zipUsedNames, name string) string {
 			}
 		}
 	} else {
+		name = path.Join(dir, name)
 		u.UsedNames[name] = true
 		return name
 	}
@@ -318,18 +493,27 @@ type ZipSpecification struct {
 }
 
 // zipRequestValidation applies validatin rules and sets fields on protocol.Zip request.
-func zipRequestValidation(zipSpec *protocol.Zip) {
+func zipRequestValidation(zipSpec *protocol.Zip) error {
 	if zipSpec.Disposition != "attachment" {
 		zipSpec.Disposition = "inline"
 	}
-	// Make sure that the file is a proper file name without a path specifier, and .zip extension
-	if len(zipSpec.FileName) > 0 && path.Ext(zipSpec.FileName) == ".zip" {
+	zipSpec.Format = strings.ToLower(zipSpec.Format)
+	if len(zipSpec.Format) == 0 {
+		zipSpec.Format = "zip"
+	}
+	format, ok := archiveFormats[zipSpec.Format]
+	if !ok {
+		return fmt.Errorf("format %s is not one of tar, tgz or zip", zipSpec.Format)
+	}
+	// Make sure that the file is a proper file name without a path specifier, and an extension of the format
+	if len(zipSpec.FileName) > 0 && format.hasExtension(zipSpec.FileName) {
 		// Make sure that we have a valid file base name, without being too opinionated about what it can be
 		zipSpec.FileName = path.Clean(path.Base(zipSpec.FileName))
 	} else {
 		// Otherwise, just give it a default name
-		zipSpec.FileName = "drive.zip"
+		zipSpec.FileName = "drive" + format.Extensions[0]
 	}
+	return nil
 }
 
 func (h AppServer) postZip(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
@@ -338,8 +522,6 @@ func (h AppServer) postZip(ctx context.Context, w http.ResponseWriter, r *http.R
 	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventExport")
 	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "EXPORT")
 
-	// Just give the zip file a standardized name for now.
-	w.Header().Set("Content-Type", "application/zip")
 	defer util.FinishBody(r.Body)
 	var zipSpec protocol.Zip
 
@@ -350,26 +532,49 @@ func (h AppServer) postZip(ctx context.Context, w http.ResponseWriter, r *http.R
 		return herr
 	}
 	// This cleans up to make the input safe to go into the headers and sets defaults
-	zipRequestValidation(&zipSpec)
+	if err := zipRequestValidation(&zipSpec); err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "unsupported archive format")
+		h.publishError(gem, herr)
+		return herr
+	}
+	w.Header().Set("Content-Type", archiveFormats[zipSpec.Format].ContentType)
 	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=%s", zipSpec.Disposition, url.QueryEscape(zipSpec.FileName)))
 
 	// Get started using the existing scheme from ShoeboxAPI
 	// Using actual parameters allows for multi-select
 	dao := DAOFromContext(ctx)
-	logger := LoggerFromContext(ctx)
-
-	// Start writing a zip file now
-	manifest := newManifest(&zipSpec)
-	zw := zip.NewWriter(w)
-	usedNames := newUsedNames()
-	zipSuggestName(usedNames, "classification_manifest.txt")
+	user, _ := UserFromContext(ctx)
+	snippetFields, ok := SnippetsFromContext(ctx)
+	if !ok {
+		herr := NewAppError(http.StatusBadGateway, errors.New("Error retrieving user permissions"), "Error communicating with upstream")
+		h.publishError(gem, herr)
+		return herr
+	}
+	user.Snippets = snippetFields
+
+	// Start writing an archive now. Files are streamed into it as they are
+	// decrypted, and the manifests written last.
+	export := &zipExport{
+		Archive:          newArchiveWriter(zipSpec.Format, w),
+		Manifest:         newManifest(&zipSpec),
+		UsedNames:        newUsedNames(),
+		Folders:          make(map[string]bool),
+		IncludeRevisions: zipSpec.IncludeRevisions,
+		User:             user,
+	}
+	zipSuggestName(export.UsedNames, "", "classification_manifest.txt")
+	zipSuggestName(export.UsedNames, "", "classification_manifest.json")
 
 	// Remove duplicated object ids.
 	uniqueIDs := make(map[string]bool)
+	var ids []string
 	for _, v := range zipSpec.ObjectIDs {
-		uniqueIDs[v] = true
+		if !uniqueIDs[v] {
+			uniqueIDs[v] = true
+			ids = append(ids, v)
+		}
 	}
-	for id := range uniqueIDs {
+	for _, id := range ids {
 		// Get the root objects we need to zip into our file
 		var err error
 		var requestObject models.ODObject
@@ -387,27 +592,24 @@ func (h AppServer) postZip(ctx context.Context, w http.ResponseWriter, r *http.R
 			return herr
 		}
 		gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(obj))
-		// Make sure that we don't have name collisions
-		obj.Name = zipSuggestName(usedNames, obj.Name)
-		if obj.ContentSize.Valid && obj.ContentSize.Int64 > 0 {
-			// Go ahead and actually include this root object in the archive
-			var herr *AppError
-			herr = zipIncludeFile(ctx, h, obj, ".", zw, manifest, usedNames)
-			if herr != nil {
-				h.publishError(gem, herr)
-				return herr
-			}
-		} else {
-			logger.Info("zip not including file", zap.String("id", id))
+		// Go ahead and actually include this root object in the archive
+		if herr := zipIncludeObject(ctx, h, export, obj, ""); herr != nil {
+			h.publishError(gem, herr)
+			return herr
 		}
 	}
+	herr := zipIncludeRelationships(ctx, export.Manifest)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
 	// We accumulated a lot of data in the manifest.  Write it out now.
-	herr := zipWriteManifest(ctx, h.AAC, zw, manifest)
+	herr = zipWriteManifest(ctx, h.AAC, export.Archive, export.Manifest)
 	if herr != nil {
 		h.publishError(gem, herr)
 		return herr
 	}
-	if err = zw.Close(); err != nil {
+	if err = export.Archive.Close(); err != nil {
 		herr := NewAppError(http.StatusInternalServerError, err, "could not properly close zip file")
 		h.publishError(gem, herr)
 		return herr
@@ -1,8 +1,10 @@
 package server_test
 
 import (
+	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -169,13 +171,13 @@ func doTestZip(t *testing.T, objs []protocol.Object, someDataString string, dupl
 			t.Logf("cannot open file for zip: %v", err)
 			t.FailNow()
 		}
-		t.Logf("Check the contents for a manifest plus file count we inserted")
-		returnedFileCount := len(objs) + 1 - duplicates
+		t.Logf("Check the contents for the manifests plus file count we inserted")
+		returnedFileCount := len(objs) + 2 - duplicates
 		filesRemaining := returnedFileCount
 		for _, f := range r.File {
 			t.Logf("%s\n", f.Name)
 			filesRemaining--
-			if f.Name == "classification_manifest.txt" {
+			if f.Name == "classification_manifest.txt" || f.Name == "classification_manifest.json" {
 				cf, err := f.Open()
 				defer func() {
 					cf.Close()
@@ -204,6 +206,89 @@ func doTestZip(t *testing.T, objs []protocol.Object, someDataString string, dupl
 	}
 }
 
+// A tgz of a folder holds its descendants at their relative paths, along with
+// the prior revisions of files
+func TestZipTarFolderWithRevisions(t *testing.T) {
+	tester10 := 0
+	client := clients[tester10].Client
+	someDataString := "lat=5,long=6"
+	folder, err := makeFolderWithACMWithParentViaJSON("tarmaps", "", ValidAcmCreateObjectSimple, tester10)
+	if err != nil {
+		t.Logf("failed to make folder: %v", err)
+		t.FailNow()
+	}
+	subfolder, err := makeFolderWithACMWithParentViaJSON("dat", folder.ID, ValidAcmCreateObjectSimple, tester10)
+	if err != nil {
+		t.Logf("failed to make folder: %v", err)
+		t.FailNow()
+	}
+	testZipMakeFile(t, tester10, folder.ID, "mapdata.txt", someDataString)
+	revised := testZipMakeFile(t, tester10, subfolder.ID, "data.txt", someDataString)
+	res, err := client.Do(NewUpdateObjectStreamPOSTRequest(t, revised))
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Logf("unable to update file: %v", err)
+		t.FailNow()
+	}
+	util.FinishBody(res.Body)
+
+	zipSpec := protocol.Zip{
+		ObjectIDs:        []string{folder.ID},
+		Format:           "tgz",
+		IncludeRevisions: true,
+	}
+	jsonBytes, _ := json.Marshal(&zipSpec)
+	req, err := http.NewRequest("POST", mountPoint+"/zip", bytes.NewBuffer(jsonBytes))
+	if err != nil {
+		t.Logf("unable to make request for zip: %v", err)
+		t.FailNow()
+	}
+	res, err = client.Do(req)
+	if err != nil {
+		t.Logf("cannot get tgz: %v", err)
+		t.FailNow()
+	}
+	defer util.FinishBody(res.Body)
+	if res.StatusCode != http.StatusOK {
+		t.Logf("wrong status code: %d", res.StatusCode)
+		t.FailNow()
+	}
+	if res.Header.Get("Content-Type") != "application/gzip" {
+		t.Logf("wrong content type: %s", res.Header.Get("Content-Type"))
+		t.Fail()
+	}
+	gr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Logf("response is not gzipped: %v", err)
+		t.FailNow()
+	}
+	found := make(map[string]bool)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Logf("unable to read tar: %v", err)
+			t.FailNow()
+		}
+		t.Logf("%s", header.Name)
+		found[header.Name] = true
+	}
+	for _, name := range []string{
+		"tarmaps/mapdata.txt",
+		"tarmaps/dat/data.txt",
+		"tarmaps/dat/data(revision 0).txt",
+		"classification_manifest.txt",
+		"classification_manifest.json",
+	} {
+		if !found[name] {
+			t.Logf("tgz is missing %s", name)
+			t.Fail()
+		}
+	}
+}
+
 // Add a file into the zip file
 func testZipMakeFile(t *testing.T, clientID int, parentID string, name string, data string) protocol.Object {
 	return testZipMakeFileWithACM(t, clientID, parentID, name, data, ValidAcmCreateObjectSimple)
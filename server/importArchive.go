@@ -0,0 +1,450 @@
+package server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+	"golang.org/x/net/context"
+)
+
+// importSpoolExtension is the extension of a zip archive held in the cache
+// while it is imported, as its entries are found at the end of the archive
+const importSpoolExtension = ".import"
+
+// errImportTooLarge is returned by reads beyond the size allowed for an import
+var errImportTooLarge = errors.New("archive exceeds the size allowed for import")
+
+// importEntry is a file or folder read from an archive being imported
+type importEntry struct {
+	Name  string
+	IsDir bool
+	// Content is the content of a file, and nil for a folder or for entries
+	// of any other type, such as links
+	Content io.Reader
+}
+
+// importReader reads the entries of an archive in turn, returning io.EOF
+// after the last
+type importReader interface {
+	Next() (importEntry, error)
+}
+
+type tarImportReader struct {
+	tr *tar.Reader
+}
+
+func (t *tarImportReader) Next() (importEntry, error) {
+	for {
+		header, err := t.tr.Next()
+		if err != nil {
+			return importEntry{}, err
+		}
+		switch header.Typeflag {
+		case tar.TypeXGlobalHeader:
+			// Global headers describe the entries, and are not entries themselves
+			continue
+		case tar.TypeDir:
+			return importEntry{Name: header.Name, IsDir: true}, nil
+		case tar.TypeReg, tar.TypeRegA:
+			return importEntry{Name: header.Name, Content: t.tr}, nil
+		}
+		return importEntry{Name: header.Name}, nil
+	}
+}
+
+type zipImportReader struct {
+	files   []*zip.File
+	current io.ReadCloser
+	// limit is applied to the content of each file, as the sizes recorded
+	// in the archive need not be true
+	limit func(io.Reader) io.Reader
+}
+
+func (z *zipImportReader) Next() (importEntry, error) {
+	z.Close()
+	if len(z.files) == 0 {
+		return importEntry{}, io.EOF
+	}
+	f := z.files[0]
+	z.files = z.files[1:]
+	switch {
+	case f.FileInfo().IsDir():
+		return importEntry{Name: f.Name, IsDir: true}, nil
+	case !f.Mode().IsRegular():
+		return importEntry{Name: f.Name}, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return importEntry{}, err
+	}
+	z.current = rc
+	return importEntry{Name: f.Name, Content: z.limit(rc)}, nil
+}
+
+// Close closes the content of the last file read
+func (z *zipImportReader) Close() {
+	if z.current != nil {
+		z.current.Close()
+		z.current = nil
+	}
+}
+
+// importLimiter fails reads once more bytes are read than remain of those
+// allowed for an import. Counting what is actually read, rather than the
+// sizes an archive claims, guards against archives that expand beyond reason.
+type importLimiter struct {
+	r         io.Reader
+	remaining *int64
+}
+
+func (l *importLimiter) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	*l.remaining -= int64(n)
+	if *l.remaining < 0 {
+		return n, errImportTooLarge
+	}
+	return n, err
+}
+
+// importEntryName cleans the path of an entry, reporting whether it is safe
+// to create beneath the folder imported into. Paths that would step outside
+// of the folder are refused.
+func importEntryName(name string) (string, bool) {
+	var segments []string
+	for _, segment := range strings.Split(name, "/") {
+		switch segment {
+		case "", ".":
+			continue
+		case "..":
+			return "", false
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == 0 {
+		return "", false
+	}
+	return strings.Join(segments, "/"), true
+}
+
+// importArchiveFormat returns the format of an archive, determined from its
+// first bytes when not given
+func importArchiveFormat(format string, br *bufio.Reader) (string, error) {
+	format = strings.ToLower(format)
+	if len(format) > 0 {
+		if _, ok := archiveFormats[format]; !ok {
+			return "", fmt.Errorf("format %s is not one of tar, tgz or zip", format)
+		}
+		return format, nil
+	}
+	magic, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		return "zip", nil
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return "tgz", nil
+	}
+	return "tar", nil
+}
+
+// importArchive expands a zip or tar archive into a folder, creating a file
+// or folder for each of its entries in turn. Folders leading to each entry
+// are found or created as they are when creating an object with a path in its
+// name. The request is a multipart of the ObjectMetadata, giving the acm and
+// permission applied to every object created, followed by the archive.
+func (h AppServer) importArchive(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	logger := LoggerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	gem.Action = "create"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventCreate")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "CREATE")
+	defer util.FinishBody(r.Body)
+
+	captured, _ := CaptureGroupsFromContext(ctx)
+	var requestObject models.ODObject
+	var err error
+	requestObject.ID, err = hex.DecodeString(captured["objectId"])
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Object Identifier in Request URI is not a hex string")
+		h.publishError(gem, herr)
+		return herr
+	}
+	folder, err := dao.GetObject(requestObject, false)
+	if err != nil {
+		code, msg, err := getObjectDAOError(err)
+		herr := NewAppError(code, err, msg)
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(folder))
+	if folder.IsDeleted {
+		herr := NewAppError(http.StatusConflict, errors.New("folder is deleted"), "cannot import into a deleted folder")
+		h.publishError(gem, herr)
+		return herr
+	}
+	if ok := isUserAllowedToCreate(ctx, &folder); !ok {
+		herr := NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User does not have permission to create children under this object")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// The metadata precedes the archive
+	if !contentTypeIsMultipartFormData(r) {
+		herr := NewAppError(http.StatusBadRequest, errors.New("not multipart"), "An archive must be imported as multipart/form-data")
+		h.publishError(gem, herr)
+		return herr
+	}
+	mpr, err := r.MultipartReader()
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Unable to get mime multipart")
+		h.publishError(gem, herr)
+		return herr
+	}
+	part, err := mpr.NextPart()
+	if err != nil || !strings.EqualFold(part.FormName(), "ObjectMetadata") {
+		herr := NewAppError(http.StatusBadRequest, err, "the first part name for an import must be ObjectMetadata")
+		h.publishError(gem, herr)
+		return herr
+	}
+	var request protocol.ImportArchiveRequest
+	metadata, err := ioutil.ReadAll(io.LimitReader(part, 5<<(10*2)))
+	if err == nil {
+		err = json.Unmarshal(metadata, &request)
+	}
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Could not decode ObjectMetadata")
+		h.publishError(gem, herr)
+		return herr
+	}
+	if request.RawAcm == nil {
+		herr := NewAppError(http.StatusBadRequest, errors.New("no acm"), "An ACM must be specified")
+		h.publishError(gem, herr)
+		return herr
+	}
+	part, err = mpr.NextPart()
+	if err != nil || !strings.EqualFold(part.FormName(), "filestream") {
+		herr := NewAppError(http.StatusBadRequest, err, "error getting archive part")
+		h.publishError(gem, herr)
+		return herr
+	}
+	br := bufio.NewReader(part)
+	format, err := importArchiveFormat(request.Format, br)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "unsupported archive format")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// Reads of the archive, as expanded, count against the size allowed
+	remaining := h.Conf.ImportMaxSize
+	limit := func(r io.Reader) io.Reader { return &importLimiter{r: r, remaining: &remaining} }
+	var entries importReader
+	switch format {
+	case "zip":
+		zr, cleanup, herr := h.importSpoolZip(ctx, br)
+		if herr != nil {
+			h.publishError(gem, herr)
+			return herr
+		}
+		defer cleanup()
+		// What the archive claims is checked before anything is created,
+		// and what is actually read is limited as it is read
+		var claimed uint64
+		for _, f := range zr.File {
+			claimed += f.UncompressedSize64
+		}
+		if int64(len(zr.File)) > h.Conf.ImportMaxEntries || claimed > uint64(h.Conf.ImportMaxSize) {
+			herr := NewAppError(http.StatusRequestEntityTooLarge, errImportTooLarge, fmt.Sprintf("archives are limited to %d entries and %d bytes", h.Conf.ImportMaxEntries, h.Conf.ImportMaxSize))
+			h.publishError(gem, herr)
+			return herr
+		}
+		zipEntries := &zipImportReader{files: zr.File, limit: limit}
+		defer zipEntries.Close()
+		entries = zipEntries
+	case "tgz":
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			herr := NewAppError(http.StatusBadRequest, err, "archive is not gzip compressed")
+			h.publishError(gem, herr)
+			return herr
+		}
+		entries = &tarImportReader{tr: tar.NewReader(limit(gr))}
+	default:
+		entries = &tarImportReader{tr: tar.NewReader(limit(br))}
+	}
+
+	response := h.importEntries(ctx, r, folder, request, entries, &remaining)
+	logger.Info("archive imported", zap.String("format", format), zap.Int("created", response.Created), zap.Int("failed", response.Failed), zap.Bool("complete", response.Complete))
+	jsonResponse(w, response)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// importSpoolZip holds a zip archive in the cache so that its entries may be
+// read. It is encrypted with a key of its own while held, and removed by the
+// cleanup returned.
+func (h AppServer) importSpoolZip(ctx context.Context, content io.Reader) (*zip.Reader, func(), *AppError) {
+	logger := LoggerFromContext(ctx)
+	id := newGUID()
+	dp := uploadSessionCache(id)
+	name := uploadSessionFileName(id, importSpoolExtension)
+	f, err := dp.Files().Create(name)
+	if err != nil {
+		return nil, nil, NewAppError(http.StatusInternalServerError, err, "unable to hold archive for import")
+	}
+	cleanup := func() {
+		f.Close()
+		if err := dp.Files().Remove(name); err != nil && !os.IsNotExist(err) {
+			logger.Warn("unable to remove archive held for import", zap.Error(err))
+		}
+	}
+	key := crypto.CreateKey()
+	iv := crypto.CreateIV()
+	_, size, err := h.Conf.EncryptableFunctions.DoCipherByReaderWriter(logger, io.LimitReader(content, h.Conf.ImportMaxSize+1), f, key, iv, "import spool", nil)
+	if err != nil {
+		cleanup()
+		return nil, nil, NewAppError(http.StatusInternalServerError, err, "unable to hold archive for import")
+	}
+	if size > h.Conf.ImportMaxSize {
+		cleanup()
+		return nil, nil, NewAppError(http.StatusRequestEntityTooLarge, errImportTooLarge, fmt.Sprintf("archives are limited to %d bytes", h.Conf.ImportMaxSize))
+	}
+	var ra io.ReaderAt = f
+	if h.Conf.EncryptEnabled {
+		if ra, err = crypto.NewCipherReaderAt(f, key, iv); err != nil {
+			cleanup()
+			return nil, nil, NewAppError(http.StatusInternalServerError, err, "unable to read archive for import")
+		}
+	}
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		cleanup()
+		return nil, nil, NewAppError(http.StatusBadRequest, err, "archive is not a valid zip")
+	}
+	return zr, cleanup, nil
+}
+
+// importEntries creates an object for each entry of an archive in turn,
+// stopping once the limits on the archive are exceeded
+func (h AppServer) importEntries(ctx context.Context, r *http.Request, folder models.ODObject,
+	request protocol.ImportArchiveRequest, entries importReader, remaining *int64) protocol.ImportArchiveResponse {
+
+	response := protocol.ImportArchiveResponse{Entries: []protocol.ImportArchiveEntry{}}
+	root := davResource{Path: "/", Object: folder}
+	// Folders already found or created, by path, as archives typically hold
+	// an entry for each folder as well as for the files within it
+	folders := make(map[string]string)
+	fail := func(result protocol.ImportArchiveEntry, herr *AppError) {
+		result.Code = herr.Code
+		result.Msg = herr.Msg
+		if herr.Error != nil {
+			result.Error = herr.Error.Error()
+		}
+		response.Failed++
+		response.Entries = append(response.Entries, result)
+	}
+	var count int64
+	for {
+		entry, err := entries.Next()
+		if err == io.EOF {
+			response.Complete = true
+			return response
+		}
+		result := protocol.ImportArchiveEntry{Name: entry.Name}
+		if err != nil {
+			code := http.StatusBadRequest
+			if *remaining < 0 {
+				code = http.StatusRequestEntityTooLarge
+			}
+			fail(result, NewAppError(code, err, "unable to read archive"))
+			return response
+		}
+		if count++; count > h.Conf.ImportMaxEntries {
+			fail(result, NewAppError(http.StatusRequestEntityTooLarge, errImportTooLarge, fmt.Sprintf("archives are limited to %d entries", h.Conf.ImportMaxEntries)))
+			return response
+		}
+		name, ok := importEntryName(entry.Name)
+		if !ok {
+			fail(result, NewAppError(http.StatusBadRequest, errors.New("unsafe path"), "entry path is empty or outside of the folder"))
+			continue
+		}
+		if !entry.IsDir && entry.Content == nil {
+			fail(result, NewAppError(http.StatusUnsupportedMediaType, errors.New("unsupported entry"), "only files and folders may be imported"))
+			continue
+		}
+		result.TypeName = "File"
+		if entry.IsDir {
+			result.TypeName = "Folder"
+			if id, ok := folders[name]; ok {
+				result.ObjectID = id
+				result.Code = http.StatusOK
+				response.Entries = append(response.Entries, result)
+				continue
+			}
+			// A folder that already exists is used rather than created again
+			resolution, herr := h.resolveDAVPath(ctx, root, strings.Split(name, "/"))
+			if herr != nil {
+				fail(result, herr)
+				continue
+			}
+			if resolution.Resource != nil {
+				if !resolution.Resource.isCollection() {
+					fail(result, NewAppError(http.StatusConflict, errors.New("type mismatch"), "a file already exists at the path of the folder"))
+					continue
+				}
+				result.ObjectID = hex.EncodeToString(resolution.Resource.Object.ID)
+				result.Code = http.StatusOK
+				folders[name] = result.ObjectID
+				response.Entries = append(response.Entries, result)
+				continue
+			}
+		}
+		createRequest := protocol.CreateObjectRequest{
+			TypeName:          result.TypeName,
+			Name:              name,
+			NamePathDelimiter: "/",
+			ParentID:          hex.EncodeToString(folder.ID),
+			RawAcm:            request.RawAcm,
+			Permission:        request.Permission,
+		}
+		var content io.Reader
+		if !entry.IsDir {
+			content = entry.Content
+		}
+		created, herr := h.davDelegateCreate(ctx, r, createRequest, content)
+		if *remaining < 0 {
+			fail(result, NewAppError(http.StatusRequestEntityTooLarge, errImportTooLarge, fmt.Sprintf("archives are limited to %d bytes", h.Conf.ImportMaxSize)))
+			return response
+		}
+		if herr != nil {
+			fail(result, herr)
+			continue
+		}
+		if entry.IsDir {
+			folders[name] = created.ID
+		}
+		result.ObjectID = created.ID
+		result.Code = http.StatusOK
+		response.Created++
+		response.Entries = append(response.Entries, result)
+	}
+}
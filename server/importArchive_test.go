@@ -0,0 +1,79 @@
+package server_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestImportArchive(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	tester10 := 0
+	folder := makeFolderViaJSON("TestImportArchive", tester10, t)
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	writeEntry := func(name string, content string, typeflag byte) {
+		header := &tar.Header{Name: name, Typeflag: typeflag, Mode: 0600, Size: int64(len(content))}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("unable to write tar header: %v", err)
+		}
+		tw.Write([]byte(content))
+	}
+	writeEntry("reports/", "", tar.TypeDir)
+	writeEntry("reports/summary.txt", "summary", tar.TypeReg)
+	writeEntry("reports/2019/detail.txt", "detail", tar.TypeReg)
+	writeEntry("../escaped.txt", "escaped", tar.TypeReg)
+	writeEntry("reports/link", "", tar.TypeSymlink)
+	tw.Close()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	metadata, _ := json.Marshal(protocol.ImportArchiveRequest{RawAcm: ValidACMUnclassified})
+	writePartField(mw, "ObjectMetadata", string(metadata), "application/json")
+	fw, _ := mw.CreateFormFile("filestream", "reports.tar")
+	fw.Write(archive.Bytes())
+	mw.Close()
+
+	req, err := http.NewRequest("POST", mountPoint+"/objects/"+folder.ID+"/import", &body)
+	failNowOnErr(t, err, "unable to create request")
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	defer util.FinishBody(resp.Body)
+	statusMustBe(t, 200, resp, "expected archive to be imported")
+
+	var response protocol.ImportArchiveResponse
+	if err := util.FullDecode(resp.Body, &response); err != nil {
+		t.Fatalf("unable to decode response: %v", err)
+	}
+	if !response.Complete || response.Created != 3 || response.Failed != 2 || len(response.Entries) != 5 {
+		t.Fatalf("unexpected import result: %+v", response)
+	}
+	codes := map[string]int{
+		"reports/":                200,
+		"reports/summary.txt":     200,
+		"reports/2019/detail.txt": 200,
+		"../escaped.txt":          400,
+		"reports/link":            415,
+	}
+	for _, entry := range response.Entries {
+		if entry.Code != codes[entry.Name] {
+			t.Errorf("entry %s had code %d", entry.Name, entry.Code)
+		}
+	}
+	// Files beneath a folder entry are created within that folder
+	reports := response.Entries[0].ObjectID
+	summary := getObject(response.Entries[1].ObjectID, tester10, t)
+	if summary.ParentID != reports {
+		t.Errorf("expected summary in the reports folder %s, found in %s", reports, summary.ParentID)
+	}
+}
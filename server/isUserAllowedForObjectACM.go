@@ -87,7 +87,8 @@ func isUserAllowedTo(ctx context.Context, obj *models.ODObject, requiredPermissi
 	var userPermission models.ODObjectPermission
 	var granteeMatch bool
 
-	masterKey := ciphertext.FindCiphertextCacheByObject(nil).GetMasterKey()
+	rewrapPermissions(obj)
+	masterKey := ciphertext.FindCiphertextCacheByObject(obj).GetMasterKey()
 
 	for _, permission := range obj.Permissions {
 		// Skip if permission is deleted
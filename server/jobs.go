@@ -0,0 +1,436 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/events"
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+// Types of jobs, each being a bulk operation requested with async=true, or a
+// change applied to the descendants of an object with recursive=true
+const (
+	jobTypeDelete      = "delete"
+	jobTypeMove        = "move"
+	jobTypeOwnership   = "ownership"
+	jobTypeExpunge     = "expunge"
+	jobTypePropagation = "propagation"
+)
+
+// jobPollInterval is how often an idle worker checks for jobs queued through
+// other instances, or left behind by an instance that stopped
+const jobPollInterval = 10 * time.Second
+
+// jobReleaseInterval is how long an instance leaves a job it released before
+// claiming it again
+const jobReleaseInterval = time.Hour
+
+// releasedJobs are the jobs this instance released because it is not
+// configured to perform them, so that it leaves them for instances that are
+type releasedJobs struct {
+	sync.Mutex
+	released map[string]time.Time
+}
+
+func newReleasedJobs() *releasedJobs {
+	return &releasedJobs{released: make(map[string]time.Time)}
+}
+
+func (r *releasedJobs) add(id []byte) {
+	r.Lock()
+	defer r.Unlock()
+	r.released[string(id)] = time.Now()
+}
+
+// excluded returns the IDs of the jobs released within the release interval,
+// forgetting the others
+func (r *releasedJobs) excluded() [][]byte {
+	r.Lock()
+	defer r.Unlock()
+	var ids [][]byte
+	for id, released := range r.released {
+		if time.Since(released) > jobReleaseInterval {
+			delete(r.released, id)
+			continue
+		}
+		ids = append(ids, []byte(id))
+	}
+	return ids
+}
+
+// jobRequest holds the items of a bulk operation performed as a job. Only the
+// fields used by the type of job are set.
+type jobRequest struct {
+	Objects    []protocol.ObjectVersioned   `json:"objects,omitempty"`
+	Moves      []protocol.MoveObjectRequest `json:"moves,omitempty"`
+	NewOwner   string                       `json:"newOwner,omitempty"`
+	PageSize   int                          `json:"pageSize,omitempty"`
+	Zone       string                       `json:"zone,omitempty"`
+	GraceHours int64                        `json:"graceHours,omitempty"`
+	DryRun     bool                         `json:"dryRun,omitempty"`
+	// ObjectID, Operation and Permission are the change propagated to the
+	// descendants of an object
+	ObjectID   string               `json:"objectId,omitempty"`
+	Operation  string               `json:"operation,omitempty"`
+	Permission *protocol.Permission `json:"permission,omitempty"`
+}
+
+// jobContext is the caller and event of the request a job was queued from,
+// kept with the job so that any instance can perform it on behalf of the user
+type jobContext struct {
+	Caller Caller     `json:"caller"`
+	GEM    events.GEM `json:"gem"`
+}
+
+// queueJob records a bulk operation to be performed in the background, and
+// responds with the job accepted
+func (h AppServer) queueJob(ctx context.Context, w http.ResponseWriter, gem events.GEM, jobType string, request jobRequest, total int64) *AppError {
+	dbJob, herr := h.createJob(ctx, gem, jobType, request, total)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	apiResponse := mapping.MapODJobToJob(&dbJob)
+	w.Header().Set("Status", "202")
+	w.Header().Set("Location", "/jobs/"+apiResponse.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	jsonResponse(w, apiResponse)
+	return nil
+}
+
+// createJob records an operation to be performed in the background on behalf
+// of the caller
+func (h AppServer) createJob(ctx context.Context, gem events.GEM, jobType string, request jobRequest, total int64) (models.ODJob, *AppError) {
+	d := DAOFromContext(ctx)
+	caller, _ := CallerFromContext(ctx)
+
+	requestJSON, err := json.Marshal(request)
+	if err != nil {
+		return models.ODJob{}, NewAppError(http.StatusInternalServerError, err, "Error encoding job request")
+	}
+	contextJSON, err := json.Marshal(jobContext{Caller: caller, GEM: gem})
+	if err != nil {
+		return models.ODJob{}, NewAppError(http.StatusInternalServerError, err, "Error encoding job context")
+	}
+	dbJob, err := d.CreateJob(models.ODJob{
+		CreatedBy: caller.DistinguishedName,
+		JobType:   jobType,
+		Request:   string(requestJSON),
+		Context:   string(contextJSON),
+		Total:     total,
+	})
+	if err != nil {
+		return models.ODJob{}, NewAppError(http.StatusInternalServerError, err, "Error creating job")
+	}
+	h.notifyJobWorkers()
+	return dbJob, nil
+}
+
+// notifyJobWorkers wakes an idle worker on this instance to claim a job just
+// queued, rather than waiting for it to poll
+func (h AppServer) notifyJobWorkers() {
+	select {
+	case h.jobWake <- struct{}{}:
+	default:
+	}
+}
+
+// startJobWorkers starts the workers that claim and perform jobs on this
+// instance. A job is claimed from the database, so that a job queued through
+// any instance, or left running by an instance that stopped renewing its
+// claim within the lease, is performed by whichever worker claims it first.
+func (h *AppServer) startJobWorkers(workers int64, leaseSeconds int64) {
+	instanceID := config.RandomID()
+	for i := int64(0); i < workers; i++ {
+		go h.claimJobs(instanceID, leaseSeconds)
+	}
+	logger.Info("job workers started", zap.String("instance", instanceID), zap.Int64("workers", workers), zap.Int64("lease", leaseSeconds))
+}
+
+// claimJobs performs jobs as they are claimed, waiting while there are none.
+// Each claim is made with a token of its own, so that once a job has been
+// claimed again, even by another worker on this instance, progress is no
+// longer recorded for the claim that lapsed and it stops.
+func (h *AppServer) claimJobs(instanceID string, leaseSeconds int64) {
+	for {
+		job, err := h.RootDAO.ClaimJob(instanceID+"-"+config.RandomID(), leaseSeconds, h.releasedJobs.excluded())
+		if err == nil {
+			h.runJob(job)
+			continue
+		}
+		if err != sql.ErrNoRows {
+			logger.Error("unable to claim job", zap.Error(err))
+		}
+		select {
+		case <-h.jobWake:
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+// runJob performs a claimed job from where it was last recorded, until it is
+// finished or is no longer running on this instance
+func (h AppServer) runJob(job models.ODJob) {
+	jobLogger := logger.With(zap.String("jobId", hex.EncodeToString(job.ID)), zap.String("jobType", job.JobType))
+	jobLogger.Info("job running", zap.Int64("processed", job.Processed))
+
+	var request jobRequest
+	if err := json.Unmarshal([]byte(job.Request), &request); err != nil {
+		h.failJob(jobLogger, job, err)
+		return
+	}
+	// Key rotation, re-encryption and orphan collection are not performed on
+	// behalf of a user
+	if job.JobType == jobTypeKeyRotation {
+		h.runKeyRotationJob(jobLogger, job, request.Zone)
+		return
+	}
+	if job.JobType == jobTypeReencrypt {
+		h.runReencryptJob(jobLogger, job, request.Zone)
+		return
+	}
+	if job.JobType == jobTypeOrphans {
+		h.runOrphanJob(jobLogger, job, request)
+		return
+	}
+	ctx, gem, err := h.jobContext(jobLogger, job)
+	if err != nil {
+		h.failJob(jobLogger, job, err)
+		return
+	}
+
+	switch job.JobType {
+	case jobTypeDelete:
+		h.runBulkJob(jobLogger, job, len(request.Objects), func(i int) protocol.ObjectError {
+			return h.bulkDeleteObject(ctx, ResetBulkItem(gem), request.Objects[i])
+		})
+	case jobTypeMove:
+		h.runBulkJob(jobLogger, job, len(request.Moves), func(i int) protocol.ObjectError {
+			return h.bulkMoveObject(ctx, ResetBulkItem(gem), request.Moves[i])
+		})
+	case jobTypeOwnership:
+		h.runBulkJob(jobLogger, job, len(request.Objects), func(i int) protocol.ObjectError {
+			return h.bulkChangeOwner(ctx, ResetBulkItem(gem), request.Objects[i], request.NewOwner)
+		})
+	case jobTypeExpunge:
+		h.runExpungeJob(ctx, jobLogger, job, gem, request.PageSize)
+	case jobTypePropagation:
+		h.runPropagationJob(ctx, jobLogger, job, request)
+	default:
+		h.failJob(jobLogger, job, errors.New("unknown job type "+job.JobType))
+	}
+}
+
+// jobContext rebuilds the context of the request a job was queued from. The
+// groups of the user are retrieved again, so that the job is authorized as
+// the user is when it runs.
+func (h AppServer) jobContext(jobLogger *zap.Logger, job models.ODJob) (context.Context, events.GEM, error) {
+	var stored jobContext
+	if err := json.Unmarshal([]byte(job.Context), &stored); err != nil {
+		return nil, events.GEM{}, err
+	}
+	ctx := context.Background()
+	ctx = ContextWithLogger(ctx, jobLogger)
+	ctx = ContextWithCaller(ctx, stored.Caller)
+	ctx = ContextWithDAO(ctx, h.RootDAO)
+	ctx = ContextWithGEM(ctx, stored.GEM)
+	user, err := h.FetchUser(ctx)
+	if err != nil {
+		return nil, events.GEM{}, err
+	}
+	ctx = ContextWithUser(ctx, *user)
+	groups, snippets, err := h.GetUserGroupsAndSnippets(ctx)
+	if err != nil {
+		return nil, events.GEM{}, err
+	}
+	caller := stored.Caller
+	caller.Groups = groups
+	ctx = ContextWithCaller(ctx, caller)
+	ctx = ContextWithSnippets(ctx, snippets)
+	ctx = ContextWithGroups(ctx, groups)
+	return ctx, stored.GEM, nil
+}
+
+// runBulkJob processes the items of a bulk operation that have not yet been
+// recorded, recording each in turn. An item being processed when an instance
+// stops is processed again by the instance that resumes the job.
+func (h AppServer) runBulkJob(jobLogger *zap.Logger, job models.ODJob, count int, process func(i int) protocol.ObjectError) {
+	for i := int(job.Processed); i < count; i++ {
+		result := process(i)
+		var jobErrors []models.ODJobError
+		job.Processed++
+		if result.Code == http.StatusOK {
+			job.Succeeded++
+		} else {
+			job.Failed++
+			// Failures beyond the limit reported are only counted
+			if job.Failed <= dao.JobErrorLimit {
+				jobErrors = append(jobErrors, models.ODJobError{
+					ObjectID: result.ObjectID,
+					Code:     result.Code,
+					Error:    models.ToNullString(result.Error),
+					Msg:      models.ToNullString(result.Msg),
+				})
+			}
+		}
+		if !h.recordJobProgress(jobLogger, job, jobErrors) {
+			return
+		}
+	}
+	job.State = models.JobStateComplete
+	h.recordJobProgress(jobLogger, job, nil)
+}
+
+// runExpungeJob expunges the trash of the user a page at a time, until it is
+// empty
+func (h AppServer) runExpungeJob(ctx context.Context, jobLogger *zap.Logger, job models.ODJob, gem events.GEM, pageSize int) {
+	for {
+		expunged, herr := h.expungeDeletedPage(ctx, gem, pageSize)
+		if herr != nil {
+			h.publishError(gem, herr)
+			h.failJob(jobLogger, job, herr.Error)
+			return
+		}
+		if expunged == 0 {
+			break
+		}
+		job.Processed += int64(expunged)
+		job.Succeeded += int64(expunged)
+		if !h.recordJobProgress(jobLogger, job, nil) {
+			return
+		}
+	}
+	purgeReleasedContent(jobLogger, h.RootDAO)
+	job.State = models.JobStateComplete
+	h.recordJobProgress(jobLogger, job, nil)
+}
+
+// releaseJob returns a job this instance is not configured to perform to the
+// queue, so that an instance that is can claim it rather than waiting for the
+// claim to lapse. This instance leaves it for the release interval.
+func (h AppServer) releaseJob(jobLogger *zap.Logger, job models.ODJob) {
+	h.releasedJobs.add(job.ID)
+	err := h.RootDAO.ReleaseJob(job)
+	if err != nil && err != dao.ErrJobNotRunning {
+		// Left to be claimed by another instance once the claim on it lapses
+		jobLogger.Error("unable to release job", zap.Error(err))
+		return
+	}
+	jobLogger.Info("job released")
+}
+
+// failJob finishes a job that could not be performed
+func (h AppServer) failJob(jobLogger *zap.Logger, job models.ODJob, err error) {
+	jobLogger.Error("job failed", zap.Error(err))
+	job.State = models.JobStateFailed
+	job.Error = models.ToNullString(err.Error())
+	h.recordJobProgress(jobLogger, job, nil)
+}
+
+// recordJobProgress records the progress of a job, reporting whether it is
+// still running on this instance
+func (h AppServer) recordJobProgress(jobLogger *zap.Logger, job models.ODJob, jobErrors []models.ODJobError) bool {
+	err := h.RootDAO.UpdateJobProgress(job, jobErrors)
+	switch {
+	case err == dao.ErrJobNotRunning:
+		jobLogger.Info("job no longer running on this instance", zap.Int64("processed", job.Processed))
+		return false
+	case err != nil:
+		// Left to be resumed once the claim on it lapses
+		jobLogger.Error("unable to record job progress", zap.Error(err))
+		return false
+	case job.State != models.JobStateRunning:
+		jobLogger.Info("job finished", zap.String("state", job.State), zap.Int64("processed", job.Processed), zap.Int64("failed", job.Failed))
+	}
+	return true
+}
+
+// commonJobPrep retrieves the job referenced in the request URI and verifies
+// that it was requested by the caller
+func commonJobPrep(ctx context.Context) (models.ODJob, *AppError) {
+
+	caller, _ := CallerFromContext(ctx)
+	d := DAOFromContext(ctx)
+
+	captured, ok := CaptureGroupsFromContext(ctx)
+	if !ok {
+		return models.ODJob{}, NewAppError(http.StatusBadRequest, errors.New("could not get capture groups"), "Error parsing URI")
+	}
+	jobID, err := hex.DecodeString(captured["jobId"])
+	if err != nil || len(jobID) == 0 {
+		return models.ODJob{}, NewAppError(http.StatusBadRequest, errors.New("invalid jobId in URI"), "Error parsing URI")
+	}
+
+	dbJob, err := d.GetJob(models.ODJob{ID: jobID})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return dbJob, NewAppError(http.StatusNotFound, err, "Job not found")
+		}
+		return dbJob, NewAppError(http.StatusInternalServerError, err, "Error retrieving job")
+	}
+	if models.AACFlatten(dbJob.CreatedBy) != models.AACFlatten(caller.DistinguishedName) {
+		return dbJob, NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - Job belongs to another user")
+	}
+	return dbJob, nil
+}
+
+func (h AppServer) getJob(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	dbJob, herr := commonJobPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	apiResponse := mapping.MapODJobToJob(&dbJob)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+func (h AppServer) cancelJob(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	d := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "update"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "MODIFY")
+
+	dbJob, herr := commonJobPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	dbJob, err := d.CancelJob(dbJob)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "Error cancelling job")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	apiResponse := mapping.MapODJobToJob(&dbJob)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
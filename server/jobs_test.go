@@ -0,0 +1,84 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestBulkDeleteAsyncJob(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	tester10 := 0
+	tester1 := 1
+
+	t.Logf("* Creating two folders as tester10")
+	first, err := makeFolderWithACMWithParentViaJSON("TestBulkDeleteAsyncJob first", "", ValidACMUnclassifiedFOUOSharedToTester10, tester10)
+	failNowOnErr(t, err, "unable to create first folder")
+	second, err := makeFolderWithACMWithParentViaJSON("TestBulkDeleteAsyncJob second", "", ValidACMUnclassifiedFOUOSharedToTester10, tester10)
+	failNowOnErr(t, err, "unable to create second folder")
+
+	t.Logf("* Deleting them in the background, along with an object that does not exist")
+	objects := []protocol.ObjectVersioned{
+		{ObjectID: first.ID, ChangeToken: first.ChangeToken},
+		{ObjectID: "00000000000000000000000000000000", ChangeToken: first.ChangeToken},
+		{ObjectID: second.ID, ChangeToken: second.ChangeToken},
+	}
+	jsonBody, _ := json.Marshal(objects)
+	req, err := http.NewRequest("DELETE", mountPoint+"/objects?async=true", bytes.NewBuffer(jsonBody))
+	failNowOnErr(t, err, "unable to create request")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 202, resp, "expected delete to be accepted")
+	var job protocol.Job
+	err = util.FullDecode(resp.Body, &job)
+	failNowOnErr(t, err, "unable to decode job")
+	if job.Type != "delete" || job.Total != 3 {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+
+	t.Logf("* Waiting for the job to complete")
+	for attempt := 0; attempt < 100; attempt++ {
+		req, _ = http.NewRequest("GET", mountPoint+"/jobs/"+job.ID, nil)
+		resp, err = clients[tester10].Client.Do(req)
+		failNowOnErr(t, err, "unable to do request")
+		statusMustBe(t, 200, resp, "expected job to be reported")
+		err = util.FullDecode(resp.Body, &job)
+		failNowOnErr(t, err, "unable to decode job")
+		if job.State == "complete" || job.State == "failed" || job.State == "cancelled" {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	if job.State != "complete" || job.Processed != 3 || job.Succeeded != 2 || job.Failed != 1 || job.FinishedDate == nil {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+	if len(job.Errors) != 1 || job.Errors[0].ObjectID != objects[1].ObjectID {
+		t.Errorf("expected the missing object to be reported, got %+v", job.Errors)
+	}
+
+	t.Logf("* Jobs are not reported to other users")
+	req, _ = http.NewRequest("GET", mountPoint+"/jobs/"+job.ID, nil)
+	resp, err = clients[tester1].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 403, resp, "expected tester1 to be forbidden")
+	util.FinishBody(resp.Body)
+
+	t.Logf("* Cancelling a completed job leaves it complete")
+	req, _ = http.NewRequest("DELETE", mountPoint+"/jobs/"+job.ID, nil)
+	resp, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 200, resp, "expected cancel to succeed")
+	err = util.FullDecode(resp.Body, &job)
+	failNowOnErr(t, err, "unable to decode job")
+	if job.State != "complete" {
+		t.Errorf("expected job to remain complete, got %s", job.State)
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+)
+
+// jobTypeKeyRotation rewraps the grants of objects in a zone whose master key
+// is being rotated. It is queued by instances started with a previous master
+// key, rather than requested by a user.
+const jobTypeKeyRotation = "keyrotation"
+
+// keyRotationBatchSize is the number of grants read and rewrapped at a time
+const keyRotationBatchSize = 500
+
+// rotatingCiphertextCaches are the caches configured with a previous master
+// key, whose grants are being rewrapped with the current master key
+func rotatingCiphertextCaches() []ciphertext.CiphertextCache {
+	var rotating []ciphertext.CiphertextCache
+	for _, dp := range ciphertext.FindCiphertextCacheList() {
+		if dp.GetPreviousMasterKey() != "" {
+			rotating = append(rotating, dp)
+		}
+	}
+	return rotating
+}
+
+// keyRotationJobID identifies the rotation of a zone from its previous to its
+// current master key, so that every instance configured for the same rotation
+// queues the same job
+func keyRotationJobID(dp ciphertext.CiphertextCache) []byte {
+	previous := sha256.Sum256([]byte(dp.GetPreviousMasterKey()))
+	current := sha256.Sum256([]byte(dp.GetMasterKey()))
+	h := sha256.New()
+	h.Write([]byte(dp.GetCiphertextCacheZone()))
+	h.Write(previous[:])
+	h.Write(current[:])
+	return h.Sum(nil)[:16]
+}
+
+// queueKeyRotations queues a job to rewrap the grants of each zone whose
+// master key is being rotated. A rotation already queued by another instance
+// is left as it is.
+func (h AppServer) queueKeyRotations() {
+	for _, dp := range rotatingCiphertextCaches() {
+		zone := string(dp.GetCiphertextCacheZone())
+		requestJSON, _ := json.Marshal(jobRequest{Zone: zone})
+		dbJob, err := h.RootDAO.CreateJob(models.ODJob{
+			ID:        keyRotationJobID(dp),
+			CreatedBy: "node/" + config.NodeID,
+			JobType:   jobTypeKeyRotation,
+			Request:   string(requestJSON),
+			Context:   "{}",
+		})
+		if err != nil {
+			logger.Error("unable to queue master key rotation", zap.String("zone", zone), zap.Error(err))
+			continue
+		}
+		logger.Info("master key rotation queued", zap.String("zone", zone), zap.String("jobId", hex.EncodeToString(dbJob.ID)), zap.String("state", dbJob.State))
+	}
+	h.notifyJobWorkers()
+}
+
+// runKeyRotationJob rewraps the grants of a zone that are still wrapped with
+// its previous master key, a batch at a time. Instances that have not yet been
+// restarted with the current master key may continue to write grants with the
+// previous one, so the grants are checked again until none are rewrapped, at
+// which point the canary is set to the current master key.
+func (h AppServer) runKeyRotationJob(jobLogger *zap.Logger, job models.ODJob, zone string) {
+	dp := ciphertext.FindCiphertextCache(ciphertext.CiphertextCacheZone(zone))
+	if dp == nil || dp.GetPreviousMasterKey() == "" {
+		// Claimed by an instance that is not rotating the zone, so leave it to
+		// one that is
+		jobLogger.Info("master key rotation not configured on this instance", zap.String("zone", zone))
+		h.releaseJob(jobLogger, job)
+		return
+	}
+	previous, current := dp.GetPreviousMasterKey(), dp.GetMasterKey()
+	connectorZone := zone
+	if dp.GetCiphertextCacheZone() == ciphertext.S3_DEFAULT_CIPHERTEXT_CACHE {
+		connectorZone = ""
+	}
+
+	for pass := 1; ; pass++ {
+		rewrapped := 0
+		var afterID []byte
+		for {
+			permissions, err := h.RootDAO.GetPermissionsForKeyRotation(connectorZone, afterID, keyRotationBatchSize)
+			if err != nil {
+				h.failJob(jobLogger, job, err)
+				return
+			}
+			if len(permissions) == 0 {
+				break
+			}
+			var jobErrors []models.ODJobError
+			for _, permission := range permissions {
+				afterID = permission.ID
+				job.Processed++
+				if models.EqualsPermissionMAC(current, &permission) {
+					continue
+				}
+				if !models.RewrapEncryptKey(previous, current, &permission) {
+					// Only counted once, as later passes see the same grants
+					if pass == 1 {
+						job.Failed++
+						jobErrors = appendKeyRotationError(jobErrors, job, permission, http.StatusConflict, "grant is signed with neither master key")
+					}
+					continue
+				}
+				if err := h.RootDAO.UpdatePermission(permission); err != nil {
+					job.Failed++
+					jobErrors = appendKeyRotationError(jobErrors, job, permission, http.StatusInternalServerError, err.Error())
+					continue
+				}
+				rewrapped++
+				job.Succeeded++
+			}
+			if !h.recordJobProgress(jobLogger, job, jobErrors) {
+				return
+			}
+		}
+		jobLogger.Info("master key rotation pass complete", zap.String("zone", zone), zap.Int("pass", pass), zap.Int("rewrapped", rewrapped))
+		if rewrapped == 0 {
+			break
+		}
+	}
+
+	if loggable := dp.CompleteKeyRotation(); loggable != nil {
+		h.failJob(jobLogger, job, loggable)
+		return
+	}
+	job.State = models.JobStateComplete
+	h.recordJobProgress(jobLogger, job, nil)
+}
+
+// appendKeyRotationError reports a grant that could not be rewrapped, up to
+// the limit of errors reported for a job
+func appendKeyRotationError(jobErrors []models.ODJobError, job models.ODJob, permission models.ODObjectPermission, code int, msg string) []models.ODJobError {
+	if job.Failed > dao.JobErrorLimit {
+		return jobErrors
+	}
+	return append(jobErrors, models.ODJobError{
+		ObjectID: hex.EncodeToString(permission.ObjectID),
+		Code:     code,
+		Error:    models.ToNullString("grant " + hex.EncodeToString(permission.ID)),
+		Msg:      models.ToNullString(msg),
+	})
+}
+
+// rewrapPermissions rewraps, in memory, the grants of an object that are still
+// wrapped with the previous master key of its zone, so that keys are copied and
+// unwrapped with the current master key while the rotation job catches up
+func rewrapPermissions(obj *models.ODObject) {
+	dp := ciphertext.FindCiphertextCacheByObject(obj)
+	if dp == nil || dp.GetPreviousMasterKey() == "" {
+		return
+	}
+	for i := range obj.Permissions {
+		models.RewrapEncryptKey(dp.GetPreviousMasterKey(), dp.GetMasterKey(), &obj.Permissions[i])
+	}
+}
+
+// stagedKeyCheck identifies the master key that the key of staged content was
+// wrapped with, so that it can be unwrapped after the master key is rotated
+func stagedKeyCheck(masterKey string, iv []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(masterKey))
+	mac.Write(iv)
+	return mac.Sum(nil)
+}
+
+// stagedMasterKey is the master key that the key of staged content was wrapped
+// with. Content staged before its check was recorded uses the current key.
+func stagedMasterKey(dp ciphertext.CiphertextCache, iv, check []byte) string {
+	if previous := dp.GetPreviousMasterKey(); previous != "" && hmac.Equal(check, stagedKeyCheck(previous, iv)) {
+		return previous
+	}
+	return dp.GetMasterKey()
+}
@@ -54,9 +54,15 @@ func (h AppServer) listGroupObjects(ctx context.Context, w http.ResponseWriter,
 	}
 	groupName = strings.ToLower(groupName)
 
+	daoPagingRequest, herr := h.mapPagingRequest(pagingRequest)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
 	// Fetch the matching objects
 	var results models.ODObjectResultset
-	results, err = dao.GetRootObjectsWithPropertiesByGroup(groupName, user, mapping.MapPagingRequestToDAOPagingRequest(pagingRequest))
+	results, err = dao.GetRootObjectsWithPropertiesByGroup(groupName, user, daoPagingRequest)
 	if err != nil {
 		code, msg := listObjectsDAOErr(err)
 		herr := NewAppError(code, err, msg)
@@ -67,9 +73,10 @@ func (h AppServer) listGroupObjects(ctx context.Context, w http.ResponseWriter,
 	// Response in requested format
 	apiResponse := mapping.MapODObjectResultsetToObjectResultset(&results)
 
-	// Caller permissions
+	// Caller permissions and favorites
+	favorites := favoritesForObjects(ctx, results.Objects)
 	for objectIndex, object := range apiResponse.Objects {
-		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller))
+		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller)).WithFavorite(favorites[object.ID])
 	}
 
 	gem.Payload.Audit = WithResourcesFromResultset(gem.Payload.Audit, results)
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+func (h AppServer) listObjectRelationships(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	d := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+	gem.Payload.Audit = audit.WithQueryString(gem.Payload.Audit, r.URL.String())
+
+	dbObject, herr := h.commonObjectReadPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(dbObject.ID)
+	gem.Payload.ChangeToken = dbObject.ChangeToken
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(dbObject.ID))
+	gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(dbObject))
+
+	direction := r.URL.Query().Get("direction")
+	switch direction {
+	case dao.RelationshipsIn, dao.RelationshipsOut, dao.RelationshipsAll:
+	default:
+		herr := NewAppError(http.StatusBadRequest, errors.New("invalid direction"), "direction must be one of in or out")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	relationships, err := d.GetRelationshipsForObject(dbObject, direction)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "GetRelationshipsForObject query failed")
+		h.publishError(gem, herr)
+		return herr
+	}
+	results := h.visibleRelationships(ctx, dbObject, relationships)
+
+	// All relationships are returned as a single page
+	apiResponse := protocol.RelationshipResultset{}
+	apiResponse.Relationships = mapping.MapODRelationshipsToRelationships(&results)
+	apiResponse.TotalRows = len(results)
+	apiResponse.PageRows = len(results)
+	apiResponse.PageSize = len(results)
+	apiResponse.PageNumber = 1
+	apiResponse.PageCount = 1
+
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// visibleRelationships filters relationships of the object down to those for
+// which the caller may see the object at the other end.
+func (h AppServer) visibleRelationships(ctx context.Context, object models.ODObject, relationships []models.ODRelationship) []models.ODRelationship {
+	d := DAOFromContext(ctx)
+	visible := make(map[string]bool)
+	results := []models.ODRelationship{}
+	for _, relationship := range relationships {
+		otherID := relationship.TargetID
+		if string(otherID) == string(object.ID) {
+			otherID = relationship.SourceID
+		}
+		key := string(otherID)
+		ok, checked := visible[key]
+		if !checked {
+			other, err := d.GetObject(models.ODObject{ID: otherID}, true)
+			ok = err == nil && h.relationshipEndpointVisible(ctx, &other)
+			visible[key] = ok
+		}
+		if ok {
+			results = append(results, relationship)
+		}
+	}
+	return results
+}
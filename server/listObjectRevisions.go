@@ -37,6 +37,12 @@ func (h AppServer) listObjectRevisions(ctx context.Context, w http.ResponseWrite
 		h.publishError(gem, herr)
 		return herr
 	}
+	// Revisions share the ID of their object, so cannot be listed from a cursor
+	if len(pagingRequest.Cursor) > 0 {
+		herr := NewAppError(http.StatusBadRequest, errors.New("cursor not supported"), "Revisions may only be listed by page number")
+		h.publishError(gem, herr)
+		return herr
+	}
 
 	// Fetch matching object
 	obj := models.ODObject{}
@@ -86,8 +92,14 @@ func (h AppServer) listObjectRevisions(ctx context.Context, w http.ResponseWrite
 	}
 	user.Snippets = snippetFields
 
+	daoPagingRequest, herr := h.mapPagingRequest(pagingRequest)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
 	// Get the revision information for this objects
-	response, err := dao.GetObjectRevisionsByUser(user, mapping.MapPagingRequestToDAOPagingRequest(pagingRequest), dbObject, true)
+	response, err := dao.GetObjectRevisionsByUser(user, daoPagingRequest, dbObject, true)
 	if err != nil {
 		herr := NewAppError(http.StatusInternalServerError, err, "General error")
 		h.publishError(gem, herr)
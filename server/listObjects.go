@@ -62,11 +62,17 @@ func (h AppServer) listObjects(ctx context.Context, w http.ResponseWriter, r *ht
 	}
 	user.Snippets = snippetFields
 
+	daoPagingRequest, herr := h.mapPagingRequest(pagingRequest)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
 	// Fetch the matching objects
 	var results models.ODObjectResultset
 	if parentObject.ID == nil {
 		// Requesting root
-		results, err = dao.GetRootObjectsWithPropertiesByUser(user, mapping.MapPagingRequestToDAOPagingRequest(pagingRequest))
+		results, err = dao.GetRootObjectsWithPropertiesByUser(user, daoPagingRequest)
 	} else {
 		// Requesting children of an object. Load parent first.
 		dbObject, err := dao.GetObject(parentObject, false)
@@ -93,7 +99,7 @@ func (h AppServer) listObjects(ctx context.Context, w http.ResponseWriter, r *ht
 		}
 
 		// Get the objects
-		results, err = dao.GetChildObjectsWithPropertiesByUser(user, mapping.MapPagingRequestToDAOPagingRequest(pagingRequest), parentObject)
+		results, err = dao.GetChildObjectsWithPropertiesByUser(user, daoPagingRequest, parentObject)
 
 	}
 	if err != nil {
@@ -106,9 +112,10 @@ func (h AppServer) listObjects(ctx context.Context, w http.ResponseWriter, r *ht
 	// Response in requested format
 	apiResponse := mapping.MapODObjectResultsetToObjectResultset(&results)
 
-	// Caller permissions
+	// Caller permissions and favorites
+	favorites := favoritesForObjects(ctx, results.Objects)
 	for objectIndex, object := range apiResponse.Objects {
-		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller))
+		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller)).WithFavorite(favorites[object.ID])
 	}
 
 	gem.Payload.Audit = WithResourcesFromResultset(gem.Payload.Audit, results)
@@ -41,8 +41,14 @@ func (h AppServer) listObjectsTrashed(ctx context.Context, w http.ResponseWriter
 	}
 	user.Snippets = snippetFields
 
+	daoPagingRequest, herr := h.mapPagingRequest(pagingRequest)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
 	// Get trash for this user
-	results, err := dao.GetTrashedObjectsByUser(user, mapping.MapPagingRequestToDAOPagingRequest(pagingRequest))
+	results, err := dao.GetTrashedObjectsByUser(user, daoPagingRequest)
 	if err != nil {
 		herr := NewAppError(http.StatusInternalServerError, errors.New("Database call failed: "), err.Error())
 		h.publishError(gem, herr)
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+func (h AppServer) listUserObjectFavorites(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	// Get user from context
+	caller, _ := CallerFromContext(ctx)
+	user, _ := UserFromContext(ctx)
+	dao := DAOFromContext(ctx)
+
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "list"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventSearchQry")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "PARAMETER_SEARCH")
+	gem.Payload.Audit = audit.WithQueryString(gem.Payload.Audit, r.URL.String())
+
+	// Parse Request
+	pagingRequest, err := protocol.NewPagingRequest(r, nil, false)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Error parsing request")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// Snippets
+	snippetFields, ok := SnippetsFromContext(ctx)
+	if !ok {
+		herr := NewAppError(http.StatusBadGateway, errors.New("Error retrieving user permissions"), "Error communicating with upstream")
+		h.publishError(gem, herr)
+		return herr
+	}
+	user.Snippets = snippetFields
+
+	daoPagingRequest, herr := h.mapPagingRequest(pagingRequest)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// Fetch objects for requested page
+	results, err := dao.GetFavoriteObjectsByUser(user, daoPagingRequest)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "GetFavoriteObjectsByUser query failed")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// Response in requested format
+	apiResponse := mapping.MapODObjectResultsetToObjectResultset(&results)
+
+	// Caller permissions, and every object listed is a favorite
+	for objectIndex, object := range apiResponse.Objects {
+		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller)).WithFavorite(true)
+	}
+
+	gem.Payload.Audit = WithResourcesFromResultset(gem.Payload.Audit, results)
+
+	// Output as JSON
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
@@ -41,8 +41,14 @@ func (h AppServer) listUserObjectShares(ctx context.Context, w http.ResponseWrit
 	}
 	user.Snippets = snippetFields
 
+	daoPagingRequest, herr := h.mapPagingRequest(pagingRequest)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
 	// Fetch objects for requested page
-	results, err := dao.GetObjectsSharedToMe(user, mapping.MapPagingRequestToDAOPagingRequest(pagingRequest))
+	results, err := dao.GetObjectsSharedToMe(user, daoPagingRequest)
 	if err != nil {
 		herr := NewAppError(http.StatusInternalServerError, err, "GetObjectsSharedToMe query failed")
 		h.publishError(gem, herr)
@@ -52,9 +58,10 @@ func (h AppServer) listUserObjectShares(ctx context.Context, w http.ResponseWrit
 	// Response in requested format
 	apiResponse := mapping.MapODObjectResultsetToObjectResultset(&results)
 
-	// Caller permissions
+	// Caller permissions and favorites
+	favorites := favoritesForObjects(ctx, results.Objects)
 	for objectIndex, object := range apiResponse.Objects {
-		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller))
+		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller)).WithFavorite(favorites[object.ID])
 	}
 
 	gem.Payload.Audit = WithResourcesFromResultset(gem.Payload.Audit, results)
@@ -40,8 +40,14 @@ func (h AppServer) listUserObjectsShared(ctx context.Context, w http.ResponseWri
 	}
 	user.Snippets = snippetFields
 
+	daoPagingRequest, herr := h.mapPagingRequest(pagingRequest)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
 	// Fetch matching objects
-	results, err := dao.GetObjectsIHaveShared(user, mapping.MapPagingRequestToDAOPagingRequest(pagingRequest))
+	results, err := dao.GetObjectsIHaveShared(user, daoPagingRequest)
 	if err != nil {
 		herr := NewAppError(http.StatusInternalServerError, err, "GetObjectsIHaveShared query failed")
 		h.publishError(gem, herr)
@@ -51,9 +57,10 @@ func (h AppServer) listUserObjectsShared(ctx context.Context, w http.ResponseWri
 	// Response in requested format
 	apiResponse := mapping.MapODObjectResultsetToObjectResultset(&results)
 
-	// Caller permissions
+	// Caller permissions and favorites
+	favorites := favoritesForObjects(ctx, results.Objects)
 	for objectIndex, object := range apiResponse.Objects {
-		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller))
+		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller)).WithFavorite(favorites[object.ID])
 	}
 
 	gem.Payload.Audit = WithResourcesFromResultset(gem.Payload.Audit, results)
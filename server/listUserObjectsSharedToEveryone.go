@@ -32,8 +32,14 @@ func (h AppServer) listUserObjectsSharedToEveryone(ctx context.Context, w http.R
 		return herr
 	}
 
+	daoPagingRequest, herr := h.mapPagingRequest(pagingRequest)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
 	// Fetch matching objects
-	results, err := dao.GetObjectsSharedToEveryone(user, mapping.MapPagingRequestToDAOPagingRequest(pagingRequest))
+	results, err := dao.GetObjectsSharedToEveryone(user, daoPagingRequest)
 	if err != nil {
 		herr := NewAppError(http.StatusInternalServerError, err, "GetObjectsSharedToEveryone query failed")
 		h.publishError(gem, herr)
@@ -43,9 +49,10 @@ func (h AppServer) listUserObjectsSharedToEveryone(ctx context.Context, w http.R
 	// Response in requested format
 	apiResponse := mapping.MapODObjectResultsetToObjectResultset(&results)
 
-	// Caller permissions
+	// Caller permissions and favorites
+	favorites := favoritesForObjects(ctx, results.Objects)
 	for objectIndex, object := range apiResponse.Objects {
-		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller))
+		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller)).WithFavorite(favorites[object.ID])
 	}
 
 	gem.Payload.Audit = WithResourcesFromResultset(gem.Payload.Audit, results)
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+func (h AppServer) listUserSubscriptions(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "list"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventSearchQry")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "PARAMETER_SEARCH")
+	gem.Payload.Audit = audit.WithQueryString(gem.Payload.Audit, r.URL.String())
+
+	user := models.ODUser{DistinguishedName: caller.DistinguishedName}
+	results, err := dao.GetSubscriptionsByUser(user)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "GetSubscriptionsByUser query failed")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// All subscriptions are returned as a single page
+	apiResponse := protocol.SubscriptionResultset{}
+	apiResponse.Subscriptions = mapping.MapODUserObjectSubscriptionsToSubscriptions(&results)
+	apiResponse.TotalRows = len(results)
+	apiResponse.PageRows = len(results)
+	apiResponse.PageSize = len(results)
+	apiResponse.PageNumber = 1
+	apiResponse.PageCount = 1
+
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+)
+
+// jobTypeOrphans deletes the files in the permanent storage of a zone that no
+// object or revision refers to. It is queued daily by instances started with
+// OD_CACHE_ORPHAN_COLLECT, rather than requested by a user.
+const jobTypeOrphans = "orphans"
+
+// errOrphanJobStopped stops a collection whose progress could not be recorded
+var errOrphanJobStopped = errors.New("orphan collection stopped")
+
+// orphanJobID identifies the collection of a zone on a day, so that every
+// instance configured to collect orphans queues the same job
+func orphanJobID(dp ciphertext.CiphertextCache, day string) []byte {
+	h := sha256.New()
+	h.Write([]byte(jobTypeOrphans))
+	h.Write([]byte(dp.GetCiphertextCacheZone()))
+	h.Write([]byte(day))
+	return h.Sum(nil)[:16]
+}
+
+// startOrphanCollection queues a job to collect the orphaned files of each
+// zone once a day, checking hourly so that a day is not missed
+func (h AppServer) startOrphanCollection(graceHours int64, dryRun bool) {
+	go func() {
+		for {
+			h.queueOrphanCollections(graceHours, dryRun)
+			time.Sleep(time.Hour)
+		}
+	}()
+}
+
+// queueOrphanCollections queues a job to collect the orphaned files of each
+// zone with permanent storage. A collection already queued today by another
+// instance is left as it is.
+func (h AppServer) queueOrphanCollections(graceHours int64, dryRun bool) {
+	day := time.Now().UTC().Format("20060102")
+	for _, dp := range ciphertext.FindCiphertextCacheList() {
+		if dp.GetPermanentStorage() == nil {
+			continue
+		}
+		zone := string(dp.GetCiphertextCacheZone())
+		requestJSON, _ := json.Marshal(jobRequest{Zone: zone, GraceHours: graceHours, DryRun: dryRun})
+		dbJob, err := h.RootDAO.CreateJob(models.ODJob{
+			ID:        orphanJobID(dp, day),
+			CreatedBy: "node/" + config.NodeID,
+			JobType:   jobTypeOrphans,
+			Request:   string(requestJSON),
+			Context:   "{}",
+		})
+		if err != nil {
+			logger.Error("unable to queue orphan collection", zap.String("zone", zone), zap.Error(err))
+			continue
+		}
+		logger.Debug("orphan collection queued", zap.String("zone", zone), zap.String("jobId", hex.EncodeToString(dbJob.ID)), zap.String("state", dbJob.State))
+	}
+	h.notifyJobWorkers()
+}
+
+// runOrphanJob collects the orphaned files of a zone. Files are counted as
+// processed as they are listed, and as succeeded as they are found orphaned.
+// A collection that is resumed starts over, as listing cannot be resumed.
+func (h AppServer) runOrphanJob(jobLogger *zap.Logger, job models.ODJob, request jobRequest) {
+	dp := ciphertext.FindCiphertextCache(ciphertext.CiphertextCacheZone(request.Zone))
+	if dp == nil {
+		// Claimed by an instance without the zone, so leave it to one with it
+		jobLogger.Info("zone not configured on this instance", zap.String("zone", request.Zone))
+		h.releaseJob(jobLogger, job)
+		return
+	}
+	opts := ciphertext.OrphanOpts{
+		GracePeriod: time.Duration(request.GraceHours) * time.Hour,
+		DryRun:      request.DryRun,
+		Progress: func(report ciphertext.OrphanReport) error {
+			job.Processed = report.Listed
+			job.Succeeded = report.Orphaned
+			if !h.recordJobProgress(jobLogger, job, nil) {
+				return errOrphanJobStopped
+			}
+			return nil
+		},
+	}
+	found := func(orphan ciphertext.Orphan) {
+		jobLogger.Info("orphaned content found",
+			zap.String("key", orphan.Key),
+			zap.String("contentConnector", orphan.ContentConnector),
+			zap.Time("modified", orphan.Modified),
+			zap.Bool("dryRun", request.DryRun))
+	}
+	report, err := ciphertext.CollectOrphans(ciphertext.CacheOrphanZone(dp), opts, dao.ReferencedContentConnectors(h.RootDAO), found)
+	if err == errOrphanJobStopped {
+		return
+	}
+	if err != nil {
+		h.failJob(jobLogger, job, err)
+		return
+	}
+	jobLogger.Info("orphan collection complete",
+		zap.Int64("listed", report.Listed),
+		zap.Int64("recent", report.Recent),
+		zap.Int64("orphaned", report.Orphaned),
+		zap.Int64("marked", report.Marked),
+		zap.Int64("deleted", report.Deleted))
+	job.Processed = report.Listed
+	job.Succeeded = report.Orphaned
+	job.State = models.JobStateComplete
+	h.recordJobProgress(jobLogger, job, nil)
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/auth"
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/events"
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+// The changes propagated to the descendants of an object
+const (
+	// propagationShare adds the permissions shared on an object
+	propagationShare = "share"
+	// propagationACM applies the permissions of an object whose ACM was updated
+	propagationACM = "acm"
+)
+
+// propagationApplier changes a single descendant, reporting whether it was
+// changed. Descendants are saved by the applier.
+type propagationApplier func(ctx context.Context, child *models.ODObject) (bool, *AppError)
+
+// queuePropagation queues a job applying a change made to the root object to
+// all of its descendants, and sets the location of the job on the response.
+// The permissions are those shared, for a share.
+func (h AppServer) queuePropagation(ctx context.Context, w http.ResponseWriter, root models.ODObject, operation string, permissions []models.ODObjectPermission) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	request := jobRequest{
+		ObjectID:  hex.EncodeToString(root.ID),
+		Operation: operation,
+	}
+	if len(permissions) > 0 {
+		permission := mapping.MapODPermissionsToPermission(&permissions)
+		request.Permission = &permission
+	}
+	dbJob, herr := h.createJob(ctx, gem, jobTypePropagation, request, 0)
+	if herr != nil {
+		return herr
+	}
+	w.Header().Set("Location", "/jobs/"+hex.EncodeToString(dbJob.ID))
+	return nil
+}
+
+// runPropagationJob applies a change made to an object to its descendants.
+// The ACM of the object is applied as it is when the job runs, so that a
+// later update supersedes any propagation of an earlier one.
+func (h AppServer) runPropagationJob(ctx context.Context, jobLogger *zap.Logger, job models.ODJob, request jobRequest) {
+	d := DAOFromContext(ctx)
+	rootID, err := hex.DecodeString(request.ObjectID)
+	if err != nil {
+		h.failJob(jobLogger, job, err)
+		return
+	}
+	root, err := d.GetObject(models.ODObject{ID: rootID}, false)
+	if err != nil {
+		h.failJob(jobLogger, job, err)
+		return
+	}
+	switch request.Operation {
+	case propagationShare:
+		if request.Permission == nil {
+			h.failJob(jobLogger, job, errors.New("no permissions to share"))
+			return
+		}
+		permissions, err := mapping.MapPermissionToODPermissions(request.Permission)
+		if err != nil {
+			h.failJob(jobLogger, job, err)
+			return
+		}
+		h.propagate(ctx, jobLogger, job, root, "PERMISSION_MODIFY", h.shareApplier(permissions))
+	case propagationACM:
+		h.propagate(ctx, jobLogger, job, root, "SHARE_MODIFY", h.acmApplier(root))
+	default:
+		h.failJob(jobLogger, job, errors.New("unknown propagation "+request.Operation))
+	}
+}
+
+// propagate walks the descendants of the root breadth first, applying the
+// change to each in turn and recording it, until all have been visited or the
+// job is no longer running on this instance. Deleted descendants, and those
+// beneath a descendant that could not be changed, are not visited. A job
+// resumed by another instance walks through the descendants already recorded
+// without changing them again, as children are visited in order of their ID.
+func (h AppServer) propagate(ctx context.Context, jobLogger *zap.Logger, job models.ODJob, root models.ODObject, auditAction string, apply propagationApplier) {
+	d := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "update"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, auditAction)
+
+	recorded := job.Processed
+	var visited int64
+	pending := [][]byte{root.ID}
+	for len(pending) > 0 {
+		parent := models.ODObject{ID: pending[0]}
+		pending = pending[1:]
+		// Children are paged from a cursor by ID, which changing them does not
+		// affect, so that none are skipped or visited twice as they are saved
+		pr := dao.PagingRequest{
+			PageNumber:   1,
+			PageSize:     int(h.Conf.MaxPageSize),
+			SortSettings: []dao.SortSetting{dao.SortSetting{SortField: "id", SortAscending: true}},
+		}
+		for {
+			children, err := d.GetChildObjectsWithProperties(pr, parent)
+			if err != nil {
+				h.failJob(jobLogger, job, err)
+				return
+			}
+			for _, child := range children.Objects {
+				if child.IsDeleted {
+					continue
+				}
+				visited++
+				if visited <= recorded {
+					pending = append(pending, child.ID)
+					continue
+				}
+				childGEM := gem
+				childGEM.Payload.ObjectID = hex.EncodeToString(child.ID)
+				childGEM.Payload.Audit = audit.WithActionTarget(childGEM.Payload.Audit, NewAuditTargetForID(child.ID))
+				auditOriginal := NewResourceFromObject(child)
+
+				updated, herr := apply(ctx, &child)
+				job.Processed++
+				var jobErrors []models.ODJobError
+				switch {
+				case herr != nil:
+					job.Failed++
+					if job.Failed <= dao.JobErrorLimit {
+						jobErrors = append(jobErrors, propagationError(child, herr))
+					}
+					h.publishError(childGEM, herr)
+				case updated:
+					job.Succeeded++
+					pending = append(pending, child.ID)
+					auditModified := NewResourceFromObject(child)
+					childGEM.Payload.Audit = audit.WithModifiedPairList(
+						childGEM.Payload.Audit, audit.NewModifiedResourcePair(auditOriginal, auditModified))
+					apiResponse := mapping.MapODObjectToObject(&child)
+					childGEM.Payload = events.WithEnrichedPayload(childGEM.Payload, apiResponse)
+					h.EventQueue.Publish(childGEM)
+				default:
+					job.Succeeded++
+					pending = append(pending, child.ID)
+				}
+				if !h.recordJobProgress(jobLogger, job, jobErrors) {
+					return
+				}
+			}
+			if len(children.NextCursor) == 0 {
+				break
+			}
+			cursor, err := dao.DecodeCursor(children.NextCursor, pr)
+			if err != nil {
+				h.failJob(jobLogger, job, err)
+				return
+			}
+			pr.Cursor = cursor
+		}
+	}
+	job.State = models.JobStateComplete
+	h.recordJobProgress(jobLogger, job, nil)
+}
+
+// propagationError reports a descendant that a change could not be applied to
+func propagationError(child models.ODObject, herr *AppError) models.ODJobError {
+	jobError := models.ODJobError{
+		ObjectID: hex.EncodeToString(child.ID),
+		Code:     herr.Code,
+		Msg:      models.ToNullString(herr.Msg),
+	}
+	if herr.Error != nil {
+		jobError.Error = models.ToNullString(herr.Error.Error())
+	}
+	return jobError
+}
+
+// shareApplier adds the permissions shared on a folder to each descendant the
+// caller is allowed to share
+func (h AppServer) shareApplier(permissions []models.ODObjectPermission) propagationApplier {
+	return func(ctx context.Context, child *models.ODObject) (bool, *AppError) {
+		caller, _ := CallerFromContext(ctx)
+		logger := LoggerFromContext(ctx)
+		d := DAOFromContext(ctx)
+		aacAuth := auth.NewAACAuth(logger, h.AAC)
+
+		ok, rollupPermission := isUserAllowedToShareWithPermission(ctx, child)
+		if !ok {
+			return false, NewAppError(http.StatusForbidden, errors.New("unauthorized to share"), "Forbidden - User does not have permission to modify shares for an object")
+		}
+		shared := make([]models.ODObjectPermission, len(permissions))
+		copy(shared, permissions)
+		changed, herr := h.addSharePermissions(ctx, child, rollupPermission, shared)
+		if herr != nil || !changed {
+			return false, herr
+		}
+		modifiedPermissions, modifiedACM, err := aacAuth.NormalizePermissionsFromACM(child.OwnedBy.String, child.Permissions, child.RawAcm.String, false)
+		if err != nil {
+			return false, NewAppError(authHTTPErr(err), err, err.Error())
+		}
+		child.RawAcm = models.ToNullString(modifiedACM)
+		child.Permissions = modifiedPermissions
+		return true, h.saveDescendant(ctx, d, aacAuth, caller, child, rollupPermission)
+	}
+}
+
+// acmApplier applies the permissions of a folder whose ACM was updated to
+// each descendant the caller is allowed to share
+func (h AppServer) acmApplier(applyable models.ODObject) propagationApplier {
+	return func(ctx context.Context, child *models.ODObject) (bool, *AppError) {
+		caller, _ := CallerFromContext(ctx)
+		logger := LoggerFromContext(ctx)
+		d := DAOFromContext(ctx)
+		aacAuth := auth.NewAACAuth(logger, h.AAC)
+
+		ok, updatePermission := isUserAllowedToShareWithPermission(ctx, child)
+		if !ok {
+			return false, NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User does not have permission to update this object")
+		}
+		if updatePermission.AcmGrantee.Grantee == "" {
+			return false, NewAppError(http.StatusInternalServerError, errors.New("grantee cannot be empty string"), "Error determining permission to update this object")
+		}
+		newACM, err := aacAuth.InjectPermissionsIntoACM(applyable.Permissions, child.RawAcm.String)
+		if err != nil {
+			return false, NewAppError(authHTTPErr(err), err, "Error injecting permissions into ACM")
+		}
+		newPerms, newACM, err := aacAuth.NormalizePermissionsFromACM(child.OwnedBy.String, applyable.Permissions, newACM, false)
+		if err != nil {
+			return false, NewAppError(authHTTPErr(err), err, err.Error())
+		}
+		child.Permissions = newPerms
+		newACM, err = aacAuth.RebuildACMFromPermissions(child.Permissions, newACM)
+		if err != nil {
+			return false, NewAppError(http.StatusInternalServerError, err, "Error rebuilding ACM from revised permissions")
+		}
+		child.RawAcm = models.ToNullString(newACM)
+		return true, h.saveDescendant(ctx, d, aacAuth, caller, child, updatePermission)
+	}
+}
+
+// saveDescendant checks that the caller is authorized for the changed ACM of a
+// descendant and saves it, keeping the encryption key granted to the caller
+func (h AppServer) saveDescendant(ctx context.Context, d dao.DAO, aacAuth *auth.AACAuth, caller Caller, child *models.ODObject, grant models.ODObjectPermission) *AppError {
+	if _, err := aacAuth.IsUserAuthorizedForACM(caller.DistinguishedName, child.RawAcm.String); err != nil {
+		return NewAppError(authHTTPErr(err), err, err.Error())
+	}
+	consolidateChangingPermissions(child)
+	// Get around: Invalid MAC on permission
+	masterKey := ciphertext.FindCiphertextCacheByObject(child).GetMasterKey()
+	for i, p := range child.Permissions {
+		models.CopyEncryptKey(masterKey, &grant, &p)
+		models.CopyEncryptKey(masterKey, &grant, &child.Permissions[i])
+	}
+	child.ModifiedBy = caller.DistinguishedName
+	if err := d.UpdateObject(child); err != nil {
+		return NewAppError(http.StatusInternalServerError, err, "Error updating object")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestShareRecursivePropagation(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	tester10 := 0
+	tester1 := 1
+
+	t.Logf("* Creating a private hierarchy root/child/grandchild as tester10")
+	root, err := makeFolderWithACMWithParentViaJSON("TestShareRecursivePropagation", "", ValidACMUnclassifiedFOUOSharedToTester10, tester10)
+	failNowOnErr(t, err, "unable to create root")
+	child, err := makeFolderWithACMWithParentViaJSON("child", root.ID, ValidACMUnclassifiedFOUOSharedToTester10, tester10)
+	failNowOnErr(t, err, "unable to create child")
+	grandchild, err := makeFolderWithACMWithParentViaJSON("grandchild", child.ID, ValidACMUnclassifiedFOUOSharedToTester10, tester10)
+	failNowOnErr(t, err, "unable to create grandchild")
+	shouldNotHaveReadForObjectID(t, grandchild.ID, tester1)
+
+	t.Logf("* Sharing root with tester1 recursively")
+	share := protocol.ObjectShare{Share: makeUserShare(fakeDN1), AllowRead: true}
+	jsonBody, _ := json.Marshal(share)
+	req, err := http.NewRequest("POST", mountPoint+"/objects/"+root.ID+"/share?recursive=true", bytes.NewBuffer(jsonBody))
+	failNowOnErr(t, err, "unable to create request")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 200, resp, "expected share to succeed")
+	location := resp.Header.Get("Location")
+	util.FinishBody(resp.Body)
+	if !strings.HasPrefix(location, "/jobs/") {
+		t.Fatalf("expected the propagation job to be located, got %q", location)
+	}
+
+	t.Logf("* Waiting for the propagation to complete")
+	var job protocol.Job
+	for attempt := 0; attempt < 50; attempt++ {
+		req, _ = http.NewRequest("GET", mountPoint+location, nil)
+		resp, err = clients[tester10].Client.Do(req)
+		failNowOnErr(t, err, "unable to do request")
+		statusMustBe(t, 200, resp, "expected propagation job to be reported")
+		err = util.FullDecode(resp.Body, &job)
+		failNowOnErr(t, err, "unable to decode job")
+		if job.State == "queued" || job.State == "running" {
+			time.Sleep(200 * time.Millisecond)
+			continue
+		}
+		break
+	}
+	if job.State != "complete" || job.Type != "propagation" || job.Processed != 2 || job.Succeeded != 2 || job.Failed != 0 {
+		t.Fatalf("unexpected propagation job: %+v", job)
+	}
+	shouldHaveReadForObjectID(t, child.ID, tester1)
+	shouldHaveReadForObjectID(t, grandchild.ID, tester1)
+
+	t.Logf("* Propagations are not reported to other users")
+	req, _ = http.NewRequest("GET", mountPoint+location, nil)
+	resp, err = clients[tester1].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 403, resp, "expected tester1 to be forbidden")
+	util.FinishBody(resp.Body)
+
+	t.Logf("* Cancelling a completed propagation leaves it complete")
+	req, _ = http.NewRequest("DELETE", mountPoint+location, nil)
+	resp, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 200, resp, "expected cancel to succeed")
+	err = util.FullDecode(resp.Body, &job)
+	failNowOnErr(t, err, "unable to decode job")
+	if job.State != "complete" {
+		t.Errorf("expected propagation to remain complete, got %s", job.State)
+	}
+}
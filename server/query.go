@@ -54,8 +54,14 @@ func (h AppServer) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 	}
 	user.Snippets = snippetFields
 
+	daoPagingRequest, herr := h.mapPagingRequest(pagingRequest)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
 	// Perform the basic search
-	results, err := dao.SearchObjectsByNameOrDescription(user, mapping.MapPagingRequestToDAOPagingRequest(pagingRequest), true)
+	results, err := dao.SearchObjectsByNameOrDescription(user, daoPagingRequest, true)
 	if err != nil {
 		herr := NewAppError(http.StatusInternalServerError, errors.New("Database call failed: "), err.Error())
 		h.publishError(gem, herr)
@@ -65,9 +71,10 @@ func (h AppServer) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 	// Response in requested format
 	apiResponse := mapping.MapODObjectResultsetToObjectResultset(&results)
 
-	// Caller permissions
+	// Caller permissions and favorites
+	favorites := favoritesForObjects(ctx, results.Objects)
 	for objectIndex, object := range apiResponse.Objects {
-		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller))
+		apiResponse.Objects[objectIndex] = object.WithCallerPermission(protocolCaller(caller)).WithFavorite(favorites[object.ID])
 	}
 
 	gem.Payload.Audit = WithResourcesFromResultset(gem.Payload.Audit, results)
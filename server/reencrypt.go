@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+)
+
+// jobTypeReencrypt re-encrypts the content of a zone stored with AES-CTR into
+// the authenticated format. It is queued by instances started with
+// OD_ENCRYPT_REENCRYPT, rather than requested by a user.
+const jobTypeReencrypt = "reencrypt"
+
+// reencryptBatchSize is the number of content streams read at a time
+const reencryptBatchSize = 100
+
+// reencryptJobID identifies the re-encryption of a zone, so that every
+// instance configured to re-encrypt queues the same job
+func reencryptJobID(dp ciphertext.CiphertextCache) []byte {
+	h := sha256.New()
+	h.Write([]byte(jobTypeReencrypt))
+	h.Write([]byte(dp.GetCiphertextCacheZone()))
+	return h.Sum(nil)[:16]
+}
+
+// queueReencryptions queues a job to re-encrypt the content of each zone. A
+// re-encryption already queued by another instance is left as it is.
+func (h AppServer) queueReencryptions() {
+	for _, dp := range ciphertext.FindCiphertextCacheList() {
+		zone := string(dp.GetCiphertextCacheZone())
+		requestJSON, _ := json.Marshal(jobRequest{Zone: zone})
+		dbJob, err := h.RootDAO.CreateJob(models.ODJob{
+			ID:        reencryptJobID(dp),
+			CreatedBy: "node/" + config.NodeID,
+			JobType:   jobTypeReencrypt,
+			Request:   string(requestJSON),
+			Context:   "{}",
+		})
+		if err != nil {
+			logger.Error("unable to queue re-encryption", zap.String("zone", zone), zap.Error(err))
+			continue
+		}
+		logger.Info("re-encryption queued", zap.String("zone", zone), zap.String("jobId", hex.EncodeToString(dbJob.ID)), zap.String("state", dbJob.State))
+	}
+	h.notifyJobWorkers()
+}
+
+// runReencryptJob re-encrypts the content of a zone stored with AES-CTR,
+// selecting a batch at a time. Each content stream is sealed under a new
+// connector and persisted before the objects referring to it are pointed at
+// it, so that a failure leaves the content as it was. Content is counted as
+// processed once, however many revisions or objects refer to it.
+func (h AppServer) runReencryptJob(jobLogger *zap.Logger, job models.ODJob, zone string) {
+	dp := ciphertext.FindCiphertextCache(ciphertext.CiphertextCacheZone(zone))
+	if dp == nil {
+		// Claimed by an instance without the zone, so leave it to one with it
+		jobLogger.Info("zone not configured on this instance", zap.String("zone", zone))
+		h.releaseJob(jobLogger, job)
+		return
+	}
+	connectorZone := zone
+	if dp.GetCiphertextCacheZone() == ciphertext.S3_DEFAULT_CIPHERTEXT_CACHE {
+		connectorZone = ""
+	}
+
+	// Content that fails is not returned to, as it would be selected again
+	afterConnector := ""
+	for {
+		contents, err := h.RootDAO.GetContentForReencryption(connectorZone, afterConnector, reencryptBatchSize)
+		if err != nil {
+			h.failJob(jobLogger, job, err)
+			return
+		}
+		if len(contents) == 0 {
+			break
+		}
+		for _, content := range contents {
+			afterConnector = content.ContentConnector.String
+			job.Processed++
+			var jobErrors []models.ODJobError
+			if err := h.reencryptContent(jobLogger, dp, content); err != nil {
+				job.Failed++
+				jobErrors = appendReencryptError(jobErrors, job, content, err)
+			} else {
+				job.Succeeded++
+			}
+			// Recorded for each content stream, as each is read and written in
+			// full, so that the claim is renewed well within the lease
+			if !h.recordJobProgress(jobLogger, job, jobErrors) {
+				return
+			}
+		}
+	}
+
+	job.State = models.JobStateComplete
+	h.recordJobProgress(jobLogger, job, nil)
+}
+
+// reencryptContent seals a content stream stored with AES-CTR under a new
+// connector, verifying that the plaintext matches the hash recorded for it,
+// and points every record of it at the sealed content
+func (h AppServer) reencryptContent(jobLogger *zap.Logger, dp ciphertext.CiphertextCache, content models.ODObject) error {
+	permissions, err := h.RootDAO.GetPermissionsForObject(content)
+	if err != nil {
+		return err
+	}
+	content.Permissions = permissions
+	rewrapPermissions(&content)
+	var key []byte
+	for _, permission := range content.Permissions {
+		if len(permission.EncryptKey) > 0 {
+			key = crypto.ApplyPassphrase(dp.GetMasterKey(), permission.PermissionIV, permission.EncryptKey)
+			break
+		}
+	}
+	if len(key) == 0 {
+		return errors.New("object has no permission from which to derive the file key")
+	}
+
+	size := content.ContentSize.Int64
+	cipherReader, _, err := dp.NewPuller(jobLogger, ciphertext.ContentConnectorFileId(content.ContentConnector.String), size, 0, -1)
+	if err != nil {
+		return err
+	}
+	defer cipherReader.Close()
+
+	reencrypted := content
+	reencrypted.ContentConnector = models.ToNullString(ciphertext.NewContentConnector(ciphertext.ContentConnectorZone(content.ContentConnector.String)))
+	reencrypted.EncryptIV = crypto.CreateIV()
+	reencrypted.CipherFormat = crypto.CipherFormatGCM
+	fileID := ciphertext.ContentConnectorFileId(reencrypted.ContentConnector.String)
+	uploading := dp.Resolve(ciphertext.NewFileName(fileID, ciphertext.FileStateUploading))
+	outFile, err := dp.Files().Create(uploading)
+	if err != nil {
+		return err
+	}
+	checksum, err := h.sealUploadedContent(jobLogger, cipherReader, outFile, key, content.EncryptIV, reencrypted.EncryptIV)
+	outFile.Close()
+	if err == nil && !bytes.Equal(checksum, content.ContentHash) {
+		err = fmt.Errorf("content does not match its hash %s", hex.EncodeToString(content.ContentHash))
+	}
+	if err == nil {
+		err = dp.Files().Rename(uploading, dp.Resolve(ciphertext.NewFileName(fileID, ciphertext.FileStateUploaded)))
+	}
+	if err != nil {
+		dp.Files().Remove(uploading)
+		return err
+	}
+	if err := h.Writeback(&reencrypted, fileID, crypto.CiphertextSize(reencrypted.CipherFormat, size)); err != nil {
+		return err
+	}
+	return h.RootDAO.ReencryptContent(content.ContentConnector.String, reencrypted)
+}
+
+// appendReencryptError reports content that could not be re-encrypted, up to
+// the limit of errors reported for a job
+func appendReencryptError(jobErrors []models.ODJobError, job models.ODJob, content models.ODObject, err error) []models.ODJobError {
+	if job.Failed > dao.JobErrorLimit {
+		return jobErrors
+	}
+	return append(jobErrors, models.ODJobError{
+		ObjectID: hex.EncodeToString(content.ID),
+		Code:     http.StatusInternalServerError,
+		Error:    models.ToNullString("content " + content.ContentConnector.String),
+		Msg:      models.ToNullString(err.Error()),
+	})
+}
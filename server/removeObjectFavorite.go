@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+func (h AppServer) removeObjectFavorite(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	// The favorite is removed even if the object has since been deleted, or the
+	// caller may no longer read it, as only the caller's own favorite is touched.
+	objectID, err := getObjectIDFromContext(ctx)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Error parsing URI")
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(objectID)
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(objectID))
+
+	user := models.ODUser{DistinguishedName: caller.DistinguishedName}
+	if err := dao.RemoveFavorite(user, models.ODObject{ID: objectID}); err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error removing object from favorites")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// Only describe the object to a caller who may still read it
+	dbObject, herr := h.commonObjectReadPrep(ctx)
+	if herr != nil {
+		jsonResponse(w, protocol.Object{ID: hex.EncodeToString(objectID)})
+		h.publishSuccess(gem, w)
+		return nil
+	}
+	gem.Payload.ChangeToken = dbObject.ChangeToken
+	gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(dbObject))
+
+	apiResponse := mapping.MapODObjectToObject(&dbObject).WithCallerPermission(protocolCaller(caller)).WithFavorite(false)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
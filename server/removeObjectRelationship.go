@@ -0,0 +1,73 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+func (h AppServer) removeObjectRelationship(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	d := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "MODIFY")
+
+	sourceObject, herr := h.commonObjectRelationshipPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(sourceObject.ID)
+	gem.Payload.ChangeToken = sourceObject.ChangeToken
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(sourceObject.ID))
+	gem.Payload.Audit = audit.WithResources(gem.Payload.Audit, NewResourceFromObject(sourceObject))
+
+	captured, _ := CaptureGroupsFromContext(ctx)
+	relationshipID, err := hex.DecodeString(captured["relationshipId"])
+	if err != nil || len(relationshipID) == 0 {
+		herr := NewAppError(http.StatusBadRequest, errors.New("invalid relationshipId in URI"), "Error parsing URI")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	// Relationships are only removed from their source object, and are not
+	// found at all if they belong to a different object.
+	dbRelationship, err := d.GetRelationship(models.ODRelationship{ID: relationshipID})
+	if err == sql.ErrNoRows || (err == nil && string(dbRelationship.SourceID) != string(sourceObject.ID)) {
+		herr := NewAppError(http.StatusNotFound, errors.New("relationship not found"), "Relationship not found")
+		h.publishError(gem, herr)
+		return herr
+	}
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "Error retrieving relationship")
+		h.publishError(gem, herr)
+		return herr
+	}
+	if dbRelationship.IsDeleted {
+		herr := NewAppError(http.StatusGone, errors.New("relationship is deleted"), "Relationship is deleted")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	dbRelationship.ModifiedBy = caller.DistinguishedName
+	if err := d.DeleteRelationship(dbRelationship); err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error removing relationship")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	apiResponse := mapping.MapODRelationshipToRelationship(&dbRelationship)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
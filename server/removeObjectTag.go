@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/events"
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+func (h AppServer) removeObjectTag(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "update"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "MODIFY")
+
+	dbObject, tagName, herr := h.commonObjectTagPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(dbObject.ID)
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(dbObject.ID))
+	auditOriginal := NewResourceFromObject(dbObject)
+
+	tag := models.ODObjectTag{ObjectID: dbObject.ID, Name: tagName, ModifiedBy: caller.DistinguishedName}
+	if err := dao.RemoveTag(tag); err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error removing tag from object")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	updatedObject, err := dao.GetObject(dbObject, true)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error retrieving object")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	auditModified := NewResourceFromObject(updatedObject)
+	apiResponse := mapping.MapODObjectToObject(&updatedObject).WithCallerPermission(protocolCaller(caller))
+
+	gem.Payload.ChangeToken = apiResponse.ChangeToken
+	gem.Payload.Audit = audit.WithModifiedPairList(gem.Payload.Audit, audit.NewModifiedResourcePair(auditOriginal, auditModified))
+	gem.Payload.StreamUpdate = false
+	gem.Payload = events.WithEnrichedPayload(gem.Payload, apiResponse)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
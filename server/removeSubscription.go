@@ -0,0 +1,40 @@
+package server
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+func (h AppServer) removeSubscription(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	dbSubscription, herr := commonSubscriptionPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(dbSubscription.ObjectID)
+
+	dbSubscription.ModifiedBy = caller.DistinguishedName
+	if err := dao.DeleteSubscription(dbSubscription); err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error removing subscription")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	apiResponse := mapping.MapODUserObjectSubscriptionToSubscription(&dbSubscription)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
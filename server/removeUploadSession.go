@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+// removeUploadSession abandons a resumable upload, discarding any content
+// received.
+func (h AppServer) removeUploadSession(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	id, herr := uploadSessionIDFromCaptureGroups(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	uploadSessionLock.Lock()
+	defer uploadSessionLock.Unlock()
+	session, herr := loadUploadSession(ctx, id)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	discardUploadSession(LoggerFromContext(ctx), session.ID)
+
+	jsonResponse(w, session.toProtocol())
+	h.publishSuccess(gem, w)
+	return nil
+}
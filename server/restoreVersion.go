@@ -159,6 +159,7 @@ func (h AppServer) restoreVersion(ctx context.Context, w http.ResponseWriter, r
 		dbObject.ContentHash = dbObjectRevision.ContentHash
 		dbObject.ContentSize = dbObjectRevision.ContentSize
 		dbObject.EncryptIV = dbObjectRevision.EncryptIV
+		dbObject.CipherFormat = dbObjectRevision.CipherFormat
 		// - properties: delete current by clearing value before saving
 		for cpi := range dbObject.Properties {
 			dbObject.Properties[cpi].Value = models.ToNullString("")
@@ -171,7 +172,7 @@ func (h AppServer) restoreVersion(ctx context.Context, w http.ResponseWriter, r
 		}
 		// - acm & permissions remain the same
 		// - copy grant.EncryptKey to all permissions:
-		masterKey := ciphertext.FindCiphertextCacheByObject(nil).GetMasterKey()
+		masterKey := ciphertext.FindCiphertextCacheByObject(&dbObject).GetMasterKey()
 		for idx, permission := range dbObject.Permissions {
 			models.CopyEncryptKey(masterKey, &grant, &permission)
 			models.CopyEncryptKey(masterKey, &grant, &dbObject.Permissions[idx])
@@ -215,7 +216,7 @@ func (h AppServer) restoreVersion(ctx context.Context, w http.ResponseWriter, r
 	apiResponse := mapping.MapODObjectToObject(&dbObject).WithCallerPermission(protocolCaller(caller)).WithBreadcrumbs(crumbs)
 	gem.Payload.ChangeToken = apiResponse.ChangeToken
 	gem.Payload.Audit = audit.WithModifiedPairList(gem.Payload.Audit, audit.NewModifiedResourcePair(auditOriginal, auditModified))
-	gem.Payload.StreamUpdate = false
+	gem.Payload.StreamUpdate = dbObject.ContentSize.Int64 > 0
 	gem.Payload = events.WithEnrichedPayload(gem.Payload, apiResponse)
 	jsonResponse(w, apiResponse)
 	h.publishSuccess(gem, w)
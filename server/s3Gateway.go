@@ -0,0 +1,698 @@
+package server
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+const (
+	// s3MaxKeys is the most keys returned when listing a bucket
+	s3MaxKeys = 1000
+	// s3TimeFormat is the format of timestamps in S3 responses
+	s3TimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+type s3GatewayRequestKey struct{}
+
+// S3GatewayHandler serves the listener of the S3 gateway. Requests are marked
+// as coming through the gateway and then handled by ServeHTTP, so that the
+// caller is authenticated just as for the API.
+func (h *AppServer) S3GatewayHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), s3GatewayRequestKey{}, true))
+		h.ServeHTTP(w, r)
+	})
+}
+
+// isS3GatewayRequest reports whether the request came through the listener of
+// the S3 gateway
+func isS3GatewayRequest(r *http.Request) bool {
+	s3, _ := r.Context().Value(s3GatewayRequestKey{}).(bool)
+	return s3
+}
+
+// s3Error is the body of an error response
+type s3Error struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string
+	Message  string
+	Resource string
+}
+
+type s3Bucket struct {
+	Name         string
+	CreationDate string
+}
+
+type s3ListAllMyBucketsResult struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListAllMyBucketsResult"`
+	Owner   struct {
+		ID          string
+		DisplayName string
+	}
+	Buckets []s3Bucket `xml:"Buckets>Bucket"`
+}
+
+type s3Contents struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int64
+	StorageClass string
+}
+
+type s3CommonPrefix struct {
+	Prefix string
+}
+
+type s3ListBucketResult struct {
+	XMLName               xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name                  string
+	Prefix                string
+	Delimiter             string `xml:",omitempty"`
+	StartAfter            string `xml:",omitempty"`
+	ContinuationToken     string `xml:",omitempty"`
+	NextContinuationToken string `xml:",omitempty"`
+	KeyCount              int
+	MaxKeys               int
+	IsTruncated           bool
+	Contents              []s3Contents
+	CommonPrefixes        []s3CommonPrefix
+}
+
+// s3Entry is a key found when listing a bucket, being either an object or,
+// when listing with a delimiter, a common prefix
+type s3Entry struct {
+	Key    string
+	Prefix bool
+	Object models.ODObject
+}
+
+// s3Location is the result of resolving a key within a bucket. Keys are paths
+// as with /files, beneath the root of the bucket.
+type s3Location struct {
+	Bucket string
+	Key    string
+	// Parent is the deepest collection that exists on the path to the key
+	Parent davResource
+	// Missing are the names of the folders beneath Parent leading to the key
+	// that do not exist
+	Missing []string
+	// Name is the final segment of the key
+	Name string
+	// Resource is the resource at the key, or nil if there is none
+	Resource *davResource
+}
+
+// s3Codes are the codes of S3 errors for each status
+var s3Codes = map[int]string{
+	http.StatusBadRequest:                   "InvalidRequest",
+	http.StatusForbidden:                    "AccessDenied",
+	http.StatusNotFound:                     "NoSuchKey",
+	http.StatusMethodNotAllowed:             "MethodNotAllowed",
+	http.StatusConflict:                     "OperationAborted",
+	http.StatusPreconditionFailed:           "PreconditionFailed",
+	http.StatusRequestedRangeNotSatisfiable: "InvalidRange",
+	http.StatusNotImplemented:               "NotImplemented",
+	http.StatusServiceUnavailable:           "ServiceUnavailable",
+}
+
+// s3SpecificCodes are the codes of S3 errors that are not implied by the
+// status of the response
+var s3SpecificCodes = []string{"InvalidPart", "InvalidPartOrder", "NoSuchBucket", "NoSuchKey", "NoSuchUpload"}
+
+// sendS3ErrorResponse logs an error as with sendAppErrorResponse, and sends
+// it to the client as the body of an S3 error response
+func sendS3ErrorResponse(logger *zap.Logger, w http.ResponseWriter, r *http.Request, herr *AppError) {
+	sendErrorResponseRaw(logger, nil, herr)
+	if alreadySent(herr.Code) {
+		return
+	}
+	if herr.Code < http.StatusBadRequest {
+		w.WriteHeader(herr.Code)
+		return
+	}
+	code, ok := s3Codes[herr.Code]
+	if !ok {
+		code = "InternalError"
+	}
+	// Errors specific to S3 are noted at the start of their message
+	for _, specific := range s3SpecificCodes {
+		if strings.HasPrefix(herr.Msg, specific+":") {
+			code = specific
+		}
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(herr.Code)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(s3Error{Code: code, Message: herr.Msg, Resource: r.URL.Path})
+}
+
+// writeS3Response writes the XML body of a successful response
+func writeS3Response(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(body)
+}
+
+// s3ETag returns the ETag reported for an object given the hex encoded hash of
+// its content, which is also the ETag when retrieving the content, so that
+// conditional requests match whichever way the ETag was obtained
+func s3ETag(contentHash string) string {
+	return strconv.Quote(contentHash)
+}
+
+// serveS3 serves the S3 gateway with path style requests of the form
+// /bucket/key. The bucket configured for the user holds the objects owned by
+// the caller, and any other bucket is the group of that name. Keys are paths as
+// with /files, and changes are delegated to the handlers of the API, so that
+// they are authorized and audited just as when made through it. Signatures of
+// requests are not verified, as callers are authenticated by their
+// certificate.
+func (h AppServer) serveS3(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+	ctx = ContextWithGEM(ctx, gem)
+
+	bucket, key, ok := s3BucketAndKey(r.URL.EscapedPath())
+	if !ok {
+		return h.davFail(ctx, http.StatusBadRequest, errors.New("invalid path"), "Invalid key")
+	}
+	switch r.Method {
+	case "GET", "HEAD":
+	default:
+		if h.RootDAO.IsReadOnly(false) {
+			msg := "Service Unavailable. Operation not permitted until database schema upgrade completed. Service operating in read-only mode."
+			return h.davFail(ctx, http.StatusServiceUnavailable, errors.New(msg), msg)
+		}
+	}
+	if len(bucket) == 0 {
+		if r.Method != "GET" {
+			return h.davFail(ctx, http.StatusMethodNotAllowed, errors.New("method not allowed"), "Method not supported for the service")
+		}
+		return h.s3ListBuckets(ctx, w)
+	}
+	root, ok := h.s3Bucket(ctx, bucket)
+	if !ok {
+		return h.davFail(ctx, http.StatusNotFound, errors.New("bucket not found"), "NoSuchBucket: The specified bucket does not exist")
+	}
+	query := r.URL.Query()
+	if len(key) == 0 {
+		switch {
+		case r.Method == "HEAD":
+			h.publishSuccess(gem, w)
+			return nil
+		case r.Method == "GET" && hasQuery(query, "location"):
+			writeS3Response(w, struct {
+				XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LocationConstraint"`
+			}{})
+			h.publishSuccess(gem, w)
+			return nil
+		case r.Method == "GET" && query.Get("list-type") == "2":
+			return h.s3ListObjects(ctx, w, r, bucket, root)
+		}
+		return h.davFail(ctx, http.StatusNotImplemented, errors.New("not implemented"), "Only ListObjectsV2 is supported for buckets")
+	}
+	loc, herr := h.s3Resolve(ctx, bucket, root, key)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	switch {
+	case r.Method == "GET":
+		return h.s3GetObject(ctx, w, r, loc)
+	case r.Method == "HEAD":
+		return h.s3HeadObject(ctx, w, loc)
+	case r.Method == "PUT" && hasQuery(query, "uploadId"):
+		return h.s3UploadPart(ctx, w, r, loc)
+	case r.Method == "PUT":
+		return h.s3PutObject(ctx, w, r, loc)
+	case r.Method == "DELETE" && hasQuery(query, "uploadId"):
+		return h.s3AbortMultipartUpload(ctx, w, r, loc)
+	case r.Method == "DELETE":
+		return h.s3DeleteObject(ctx, w, r, loc)
+	case r.Method == "POST" && hasQuery(query, "uploads"):
+		return h.s3CreateMultipartUpload(ctx, w, r, loc)
+	case r.Method == "POST" && hasQuery(query, "uploadId"):
+		return h.s3CompleteMultipartUpload(ctx, w, r, loc)
+	}
+	return h.davFail(ctx, http.StatusNotImplemented, errors.New("not implemented"), "Operation not supported for objects")
+}
+
+func hasQuery(query url.Values, name string) bool {
+	_, ok := query[name]
+	return ok
+}
+
+// s3BucketAndKey splits the escaped path of a request into the bucket and key
+func s3BucketAndKey(escapedPath string) (string, string, bool) {
+	escapedPath = strings.TrimPrefix(escapedPath, "/")
+	escapedBucket, escapedKey := escapedPath, ""
+	if i := strings.Index(escapedPath, "/"); i >= 0 {
+		escapedBucket, escapedKey = escapedPath[:i], escapedPath[i+1:]
+	}
+	bucket, err := url.PathUnescape(escapedBucket)
+	if err != nil {
+		return "", "", false
+	}
+	key, err := url.PathUnescape(escapedKey)
+	if err != nil {
+		return "", "", false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "." || segment == ".." {
+			return "", "", false
+		}
+	}
+	return bucket, key, true
+}
+
+// s3Bucket returns the root of the bucket
+func (h AppServer) s3Bucket(ctx context.Context, bucket string) (davResource, bool) {
+	if bucket == h.S3Gateway.UserBucket {
+		return davResource{Path: "/"}, true
+	}
+	for _, group := range davGroups(ctx) {
+		if strings.EqualFold(group, bucket) {
+			return davResource{Path: davJoin("/", group), Group: group}, true
+		}
+	}
+	return davResource{}, false
+}
+
+// s3Resolve resolves a key beneath the root of a bucket
+func (h AppServer) s3Resolve(ctx context.Context, bucket string, root davResource, key string) (s3Location, *AppError) {
+	loc := s3Location{Bucket: bucket, Key: key, Parent: root}
+	var segments []string
+	for _, segment := range strings.Split(key, "/") {
+		if len(segment) > 0 {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		return loc, NewAppError(http.StatusBadRequest, errors.New("empty key"), "Invalid key")
+	}
+	loc.Name = segments[len(segments)-1]
+	for i, name := range segments {
+		child, found, herr := h.davChild(ctx, loc.Parent, name)
+		if herr != nil {
+			return loc, herr
+		}
+		if !found {
+			loc.Missing = segments[i : len(segments)-1]
+			return loc, nil
+		}
+		if i == len(segments)-1 {
+			loc.Resource = &child
+			return loc, nil
+		}
+		if !child.isCollection() {
+			return loc, NewAppError(http.StatusConflict, errors.New("not a folder"), "A key leading to the key is not a folder")
+		}
+		loc.Parent = child
+	}
+	return loc, nil
+}
+
+// s3ListBuckets lists the bucket of the caller and those of their groups
+func (h AppServer) s3ListBuckets(ctx context.Context, w http.ResponseWriter) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	caller, _ := CallerFromContext(ctx)
+	user, _ := UserFromContext(ctx)
+	var result s3ListAllMyBucketsResult
+	result.Owner.ID = caller.DistinguishedName
+	result.Owner.DisplayName = caller.CommonName
+	created := user.CreatedDate.UTC().Format(s3TimeFormat)
+	result.Buckets = append(result.Buckets, s3Bucket{Name: h.S3Gateway.UserBucket, CreationDate: created})
+	for _, group := range davGroups(ctx) {
+		result.Buckets = append(result.Buckets, s3Bucket{Name: group, CreationDate: created})
+	}
+	writeS3Response(w, result)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// s3ListObjects lists the keys of a bucket with ListObjectsV2. Only / is
+// supported as a delimiter. Without a delimiter, the folders leading to the
+// prefix are walked.
+func (h AppServer) s3ListObjects(ctx context.Context, w http.ResponseWriter, r *http.Request, bucket string, root davResource) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	query := r.URL.Query()
+	result := s3ListBucketResult{
+		Name:              bucket,
+		Prefix:            query.Get("prefix"),
+		Delimiter:         query.Get("delimiter"),
+		StartAfter:        query.Get("start-after"),
+		ContinuationToken: query.Get("continuation-token"),
+		MaxKeys:           s3MaxKeys,
+	}
+	if len(result.Delimiter) > 0 && result.Delimiter != "/" {
+		return h.davFail(ctx, http.StatusNotImplemented, errors.New("delimiter not supported"), "Only / is supported as a delimiter")
+	}
+	if maxKeys := query.Get("max-keys"); len(maxKeys) > 0 {
+		n, err := strconv.Atoi(maxKeys)
+		if err != nil || n < 0 {
+			return h.davFail(ctx, http.StatusBadRequest, err, "Invalid max-keys")
+		}
+		if n < s3MaxKeys {
+			result.MaxKeys = n
+		}
+	}
+	after := result.StartAfter
+	if len(result.ContinuationToken) > 0 {
+		token, err := base64.RawURLEncoding.DecodeString(result.ContinuationToken)
+		if err != nil {
+			return h.davFail(ctx, http.StatusBadRequest, err, "Invalid continuation-token")
+		}
+		after = string(token)
+	}
+
+	// The folder holding the keys is found from the prefix up to its last /
+	folder := root
+	dir := ""
+	if i := strings.LastIndex(result.Prefix, "/"); i >= 0 {
+		dir = result.Prefix[:i+1]
+		loc, herr := h.s3Resolve(ctx, bucket, root, dir)
+		if herr != nil && herr.Code != http.StatusConflict {
+			h.publishError(gem, herr)
+			return herr
+		}
+		if herr != nil || loc.Resource == nil || !loc.Resource.isCollection() {
+			writeS3Response(w, result)
+			h.publishSuccess(gem, w)
+			return nil
+		}
+		folder = *loc.Resource
+	}
+	walker := s3Walker{prefix: result.Prefix, delimited: result.Delimiter == "/", after: after, limit: result.MaxKeys}
+	if herr := h.s3Walk(ctx, folder, dir, &walker); herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	for _, entry := range walker.entries {
+		if result.KeyCount == result.MaxKeys {
+			result.IsTruncated = true
+			break
+		}
+		result.KeyCount++
+		after = entry.Key
+		if entry.Prefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, s3CommonPrefix{Prefix: entry.Key})
+			continue
+		}
+		result.Contents = append(result.Contents, s3Contents{
+			Key:          entry.Key,
+			LastModified: entry.Object.ModifiedDate.UTC().Format(s3TimeFormat),
+			ETag:         s3ETag(hex.EncodeToString(entry.Object.ContentHash)),
+			Size:         entry.Object.ContentSize.Int64,
+			StorageClass: "STANDARD",
+		})
+	}
+	if result.IsTruncated {
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(after))
+	}
+	writeS3Response(w, result)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// s3Walker collects the keys of a listing in key order, starting after a key
+// and stopping once one more than the limit is found, so that a page does not
+// read more of the bucket than it lists
+type s3Walker struct {
+	prefix    string
+	delimited bool
+	after     string
+	limit     int
+	entries   []s3Entry
+}
+
+// full reports whether enough keys have been found to tell that the page is
+// truncated
+func (walker *s3Walker) full() bool {
+	return len(walker.entries) > walker.limit
+}
+
+func (walker *s3Walker) add(entry s3Entry) {
+	if entry.Key > walker.after {
+		walker.entries = append(walker.entries, entry)
+	}
+}
+
+// s3Walk collects the keys beneath a folder that match the prefix. Folders are
+// reported as common prefixes when listing with a delimiter, and otherwise as
+// keys ending in / followed by their members. Names cannot contain /, so the
+// keys beneath a folder directly follow its own, and walking the children in
+// key order finds the keys in order.
+func (h AppServer) s3Walk(ctx context.Context, folder davResource, dir string, walker *s3Walker) *AppError {
+	children, herr := h.davChildren(ctx, folder)
+	if herr != nil {
+		return herr
+	}
+	type keyedChild struct {
+		key   string
+		child davResource
+	}
+	var keyed []keyedChild
+	for _, child := range children {
+		if child.Groups {
+			continue
+		}
+		key := dir + child.Object.Name
+		if child.isCollection() {
+			key += "/"
+		}
+		// Members of a folder may match a prefix that the folder does not
+		if !strings.HasPrefix(key, walker.prefix) && !strings.HasPrefix(walker.prefix, key) {
+			continue
+		}
+		keyed = append(keyed, keyedChild{key: key, child: child})
+	}
+	sort.SliceStable(keyed, func(i, j int) bool { return keyed[i].key < keyed[j].key })
+	for _, k := range keyed {
+		if walker.full() {
+			return nil
+		}
+		key, child := k.key, k.child
+		switch {
+		case child.isCollection() && walker.delimited:
+			walker.add(s3Entry{Key: key, Prefix: true})
+		case child.isCollection():
+			if strings.HasPrefix(key, walker.prefix) {
+				walker.add(s3Entry{Key: key, Object: child.Object})
+			}
+			// A folder before the key listed from holds no keys after it,
+			// unless that key is beneath the folder
+			if key <= walker.after && !strings.HasPrefix(walker.after, key) {
+				continue
+			}
+			if herr := h.s3Walk(ctx, child, key, walker); herr != nil {
+				return herr
+			}
+		case strings.HasPrefix(key, walker.prefix):
+			walker.add(s3Entry{Key: key, Object: child.Object})
+		}
+	}
+	return nil
+}
+
+// s3Object returns the object at the key. Folders are only found by keys
+// ending in /.
+func (h AppServer) s3Object(ctx context.Context, loc s3Location) (davResource, *AppError) {
+	if !loc.exists() {
+		return davResource{}, h.davFail(ctx, http.StatusNotFound, errors.New("not found"), "NoSuchKey: The specified key does not exist")
+	}
+	return *loc.Resource, nil
+}
+
+// exists reports whether there is an object at the key
+func (loc s3Location) exists() bool {
+	return loc.Resource != nil && loc.Resource.isObject() && loc.Resource.isCollection() == strings.HasSuffix(loc.Key, "/")
+}
+
+// s3GetObject retrieves the content at a key, honoring any Range
+func (h AppServer) s3GetObject(ctx context.Context, w http.ResponseWriter, r *http.Request, loc s3Location) *AppError {
+	res, herr := h.s3Object(ctx, loc)
+	if herr != nil {
+		return herr
+	}
+	if res.isCollection() {
+		return h.s3HeadObject(ctx, w, loc)
+	}
+	captured := map[string]string{"objectId": hex.EncodeToString(res.Object.ID)}
+	return h.getObjectStream(context.WithValue(ctx, CaptureGroupsVal, captured), w, r)
+}
+
+// s3HeadObject reports the metadata of the object at a key. The object was
+// found by listing objects the caller may read.
+func (h AppServer) s3HeadObject(ctx context.Context, w http.ResponseWriter, loc s3Location) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	res, herr := h.s3Object(ctx, loc)
+	if herr != nil {
+		return herr
+	}
+	object := res.Object
+	contentType := object.ContentType.String
+	if len(contentType) == 0 || res.isCollection() {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(object.ContentSize.Int64, 10))
+	w.Header().Set("ETag", s3ETag(hex.EncodeToString(object.ContentHash)))
+	w.Header().Set("Last-Modified", object.ModifiedDate.UTC().Format(http.TimeFormat))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.WriteHeader(http.StatusOK)
+	gem.Payload.ObjectID = hex.EncodeToString(object.ID)
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(object.ID))
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// s3PutObject creates the object at a key, along with any folders leading to
+// it, or replaces its content. Keys ending in / create folders.
+func (h AppServer) s3PutObject(ctx context.Context, w http.ResponseWriter, r *http.Request, loc s3Location) *AppError {
+	if len(r.Header.Get("x-amz-copy-source")) > 0 {
+		return h.davFail(ctx, http.StatusNotImplemented, errors.New("not implemented"), "Copying objects is not supported")
+	}
+	object, herr := h.s3Store(ctx, r, loc, r.Header.Get("Content-Type"), s3Body(r))
+	if herr != nil {
+		return herr
+	}
+	w.Header().Set("ETag", s3ETag(object.ContentHash))
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// s3Store creates the object at a key or replaces its content
+func (h AppServer) s3Store(ctx context.Context, r *http.Request, loc s3Location, contentType string, content io.Reader) (protocol.Object, *AppError) {
+	folder := strings.HasSuffix(loc.Key, "/")
+	if loc.Resource != nil {
+		res := *loc.Resource
+		if folder && res.isCollection() {
+			return mapping.MapODObjectToObject(&res.Object), nil
+		}
+		if folder || res.isCollection() || !res.isObject() {
+			return protocol.Object{}, h.davFail(ctx, http.StatusConflict, errors.New("type mismatch"), "The key is held by a folder or a file")
+		}
+		id := hex.EncodeToString(res.Object.ID)
+		metadata := protocol.UpdateObjectAndStreamRequest{ID: id, ChangeToken: res.Object.ChangeToken, ContentType: contentType}
+		return h.davDelegateStream(ctx, r, h.updateObjectStream, map[string]string{"objectId": id}, metadata, res.Object.Name, content)
+	}
+	typeName := "File"
+	if folder {
+		typeName = "Folder"
+	}
+	name := strings.Join(append(append([]string{}, loc.Missing...), loc.Name), "/")
+	request, herr := h.davCreateRequest(ctx, loc.Parent, name, typeName, h.S3Gateway.ACM)
+	if herr != nil {
+		return protocol.Object{}, herr
+	}
+	request.NamePathDelimiter = "/"
+	if folder {
+		return h.davDelegateCreate(ctx, r, request, nil)
+	}
+	request.ContentType = contentType
+	return h.davDelegateCreate(ctx, r, request, content)
+}
+
+// s3DeleteObject moves the object at a key to the trash. As with S3, deleting
+// a key that does not exist succeeds. Folders are only deleted once empty.
+func (h AppServer) s3DeleteObject(ctx context.Context, w http.ResponseWriter, r *http.Request, loc s3Location) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	if !loc.exists() {
+		w.WriteHeader(http.StatusNoContent)
+		h.publishSuccess(gem, w)
+		return nil
+	}
+	res := *loc.Resource
+	if res.isCollection() {
+		children, herr := h.davChildren(ctx, res)
+		if herr != nil {
+			h.publishError(gem, herr)
+			return herr
+		}
+		if len(children) > 0 {
+			return h.davFail(ctx, http.StatusConflict, fmt.Errorf("folder has %d members", len(children)), "Folders may only be deleted once empty")
+		}
+	}
+	if herr := h.davDelete(ctx, r, res.Object); herr != nil {
+		return herr
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// s3Body returns the content of a request, removing the signatures of chunks
+// from content sent with aws-chunked encoding
+func s3Body(r *http.Request) io.Reader {
+	if strings.HasPrefix(r.Header.Get("x-amz-content-sha256"), "STREAMING-") {
+		return &s3ChunkedReader{r: bufio.NewReader(r.Body)}
+	}
+	return r.Body
+}
+
+// s3ChunkedReader reads content sent with aws-chunked encoding, in which each
+// chunk is preceded by a line of its size in hexadecimal and its signature,
+// and followed by a line break, with a chunk of size zero ending the content.
+type s3ChunkedReader struct {
+	r         *bufio.Reader
+	remaining int64
+	done      bool
+}
+
+func (c *s3ChunkedReader) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		size := strings.TrimSpace(line)
+		if i := strings.Index(size, ";"); i >= 0 {
+			size = size[:i]
+		}
+		if c.remaining, err = strconv.ParseInt(size, 16, 64); err != nil || c.remaining < 0 {
+			return 0, fmt.Errorf("invalid chunk size %q", size)
+		}
+		if c.remaining == 0 {
+			c.done = true
+		}
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if c.remaining == 0 && err == nil {
+		// Each chunk is followed by a line break
+		if _, err := c.r.ReadString('\n'); err != nil {
+			return n, io.ErrUnexpectedEOF
+		}
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
@@ -0,0 +1,319 @@
+package server
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+)
+
+const (
+	// s3UploadExtension is appended to the ID of a multipart upload for the
+	// file in the cache that records the upload
+	s3UploadExtension = ".s3upload"
+	// s3MaxPartNumber is the highest number of a part of a multipart upload
+	s3MaxPartNumber = 10000
+)
+
+// s3MultipartUpload is the state of an S3 multipart upload. Parts are
+// encrypted into the cache as they are received, each with its own iv, and
+// are decrypted in order to create or update the object when the upload is
+// completed. As with upload sessions, requests for an upload must be made to
+// the same instance.
+type s3MultipartUpload struct {
+	ID           string    `json:"id"`
+	CreatedBy    string    `json:"createdBy"`
+	ModifiedDate time.Time `json:"modifiedDate"`
+	Bucket       string    `json:"bucket"`
+	Key          string    `json:"key"`
+	ContentType  string    `json:"contentType"`
+	// PermissionIV and EncryptKey are the key that parts are encrypted with,
+	// which is itself encrypted with the master key. KeyCheck identifies the
+	// master key, in case it is rotated during the upload.
+	PermissionIV []byte         `json:"permissionIV"`
+	EncryptKey   []byte         `json:"encryptKey"`
+	KeyCheck     []byte         `json:"keyCheck,omitempty"`
+	Parts        map[int]s3Part `json:"parts"`
+}
+
+// s3Part is a part received for a multipart upload
+type s3Part struct {
+	Size      int64  `json:"size"`
+	ETag      string `json:"etag"`
+	EncryptIV []byte `json:"encryptIV"`
+}
+
+type s3InitiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ InitiateMultipartUploadResult"`
+	Bucket   string
+	Key      string
+	UploadID string `xml:"UploadId"`
+}
+
+type s3CompleteMultipartUpload struct {
+	Parts []struct {
+		PartNumber int
+		ETag       string
+	} `xml:"Part"`
+}
+
+type s3CompleteMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUploadResult"`
+	Location string
+	Bucket   string
+	Key      string
+	ETag     string
+}
+
+// s3UploadIDRX matches the IDs given to multipart uploads, which name files in
+// the cache
+var s3UploadIDRX = regexp.MustCompile("^[0-9a-f]{32}$")
+
+func s3PartExtension(number int) string {
+	return fmt.Sprintf(".s3part%d", number)
+}
+
+// loadS3MultipartUpload retrieves the upload of the key belonging to the
+// caller. Uploads belonging to others or of other keys are reported as not
+// found, and expired uploads are discarded.
+func loadS3MultipartUpload(ctx context.Context, id string, loc s3Location) (*s3MultipartUpload, *AppError) {
+	caller, _ := CallerFromContext(ctx)
+	notFound := "NoSuchUpload: The specified multipart upload does not exist"
+	if !s3UploadIDRX.MatchString(id) {
+		return nil, NewAppError(http.StatusNotFound, errors.New("invalid upload id"), notFound)
+	}
+	dp := uploadSessionCache(id)
+	f, err := dp.Files().Open(uploadSessionFileName(id, s3UploadExtension))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, NewAppError(http.StatusNotFound, err, notFound)
+		}
+		return nil, NewAppError(http.StatusInternalServerError, err, "error retrieving multipart upload")
+	}
+	defer f.Close()
+	var upload s3MultipartUpload
+	if err := json.NewDecoder(f).Decode(&upload); err != nil {
+		return nil, NewAppError(http.StatusInternalServerError, err, "error reading multipart upload")
+	}
+	if upload.CreatedBy != caller.DistinguishedName || upload.Bucket != loc.Bucket || upload.Key != loc.Key {
+		return nil, NewAppError(http.StatusNotFound, errors.New("multipart upload belongs to another user or key"), notFound)
+	}
+	if time.Now().After(upload.ModifiedDate.Add(uploadSessionLifetime)) {
+		discardS3MultipartUpload(LoggerFromContext(ctx), &upload)
+		return nil, NewAppError(http.StatusNotFound, errors.New("multipart upload expired"), notFound)
+	}
+	return &upload, nil
+}
+
+// discardS3MultipartUpload removes the upload and any parts received
+func discardS3MultipartUpload(logger *zap.Logger, upload *s3MultipartUpload) {
+	dp := uploadSessionCache(upload.ID)
+	exts := []string{s3UploadExtension}
+	for number := range upload.Parts {
+		exts = append(exts, s3PartExtension(number))
+	}
+	for _, ext := range exts {
+		if err := dp.Files().Remove(uploadSessionFileName(upload.ID, ext)); err != nil && !os.IsNotExist(err) {
+			logger.Warn("unable to remove multipart upload file", zap.String("id", upload.ID), zap.String("ext", ext), zap.Error(err))
+		}
+	}
+}
+
+// fileKey decrypts the key that the parts of the upload are encrypted with
+func (upload *s3MultipartUpload) fileKey() []byte {
+	masterKey := stagedMasterKey(uploadSessionCache(upload.ID), upload.PermissionIV, upload.KeyCheck)
+	return crypto.ApplyPassphrase(masterKey, upload.PermissionIV, upload.EncryptKey)
+}
+
+// s3CreateMultipartUpload begins a multipart upload of a key
+func (h AppServer) s3CreateMultipartUpload(ctx context.Context, w http.ResponseWriter, r *http.Request, loc s3Location) *AppError {
+	caller, _ := CallerFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	if strings.HasSuffix(loc.Key, "/") || (loc.Resource != nil && loc.Resource.isCollection()) {
+		return h.davFail(ctx, http.StatusConflict, errors.New("folder"), "Folders cannot be uploaded in parts")
+	}
+	upload := s3MultipartUpload{
+		ID:           newGUID(),
+		CreatedBy:    caller.DistinguishedName,
+		ModifiedDate: time.Now(),
+		Bucket:       loc.Bucket,
+		Key:          loc.Key,
+		ContentType:  r.Header.Get("Content-Type"),
+		PermissionIV: crypto.CreatePermissionIV(),
+		Parts:        map[int]s3Part{},
+	}
+	masterKey := uploadSessionCache(upload.ID).GetMasterKey()
+	upload.EncryptKey = crypto.ApplyPassphrase(masterKey, upload.PermissionIV, crypto.CreateKey())
+	upload.KeyCheck = stagedKeyCheck(masterKey, upload.PermissionIV)
+	if err := saveUploadState(upload.ID, s3UploadExtension, &upload); err != nil {
+		return h.davFail(ctx, http.StatusInternalServerError, err, "Unable to save multipart upload")
+	}
+	writeS3Response(w, s3InitiateMultipartUploadResult{Bucket: loc.Bucket, Key: loc.Key, UploadID: upload.ID})
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// s3UploadPart receives a part of a multipart upload, replacing any part of
+// the same number
+func (h AppServer) s3UploadPart(ctx context.Context, w http.ResponseWriter, r *http.Request, loc s3Location) *AppError {
+	logger := LoggerFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	query := r.URL.Query()
+	id := query.Get("uploadId")
+	number, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil || number < 1 || number > s3MaxPartNumber {
+		return h.davFail(ctx, http.StatusBadRequest, err, fmt.Sprintf("partNumber must be from 1 to %d", s3MaxPartNumber))
+	}
+	upload, herr := loadS3MultipartUpload(ctx, id, loc)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	dp := uploadSessionCache(id)
+	f, err := dp.Files().Create(uploadSessionFileName(id, s3PartExtension(number)))
+	if err != nil {
+		return h.davFail(ctx, http.StatusInternalServerError, err, "Unable to store part")
+	}
+	part := s3Part{EncryptIV: crypto.CreateIV()}
+	hasher := md5.New()
+	_, part.Size, err = h.Conf.EncryptableFunctions.DoCipherByReaderWriter(logger, io.TeeReader(s3Body(r), hasher), f, upload.fileKey(), part.EncryptIV, "uploading part", crypto.NewByteRange())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return h.davFail(ctx, http.StatusInternalServerError, err, "Unable to store part")
+	}
+	part.ETag = hex.EncodeToString(hasher.Sum(nil))
+
+	uploadSessionLock.Lock()
+	upload, herr = loadS3MultipartUpload(ctx, id, loc)
+	if herr == nil {
+		upload.Parts[number] = part
+		upload.ModifiedDate = time.Now()
+		if err := saveUploadState(id, s3UploadExtension, upload); err != nil {
+			herr = NewAppError(http.StatusInternalServerError, err, "Unable to save multipart upload")
+		}
+	}
+	uploadSessionLock.Unlock()
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	w.Header().Set("ETag", strconv.Quote(part.ETag))
+	w.WriteHeader(http.StatusOK)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// s3CompleteMultipartUpload creates or updates the object at the key with the
+// listed parts in order. The upload no longer exists afterward, whether or not
+// this succeeds.
+func (h AppServer) s3CompleteMultipartUpload(ctx context.Context, w http.ResponseWriter, r *http.Request, loc s3Location) *AppError {
+	logger := LoggerFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	id := r.URL.Query().Get("uploadId")
+	var request s3CompleteMultipartUpload
+	if err := xml.NewDecoder(io.LimitReader(r.Body, davMaxRequestSize)).Decode(&request); err != nil {
+		return h.davFail(ctx, http.StatusBadRequest, err, "Error parsing CompleteMultipartUpload")
+	}
+
+	// Once completing, the upload is removed so that no more parts are accepted
+	uploadSessionLock.Lock()
+	upload, herr := loadS3MultipartUpload(ctx, id, loc)
+	if herr == nil {
+		if err := uploadSessionCache(id).Files().Remove(uploadSessionFileName(id, s3UploadExtension)); err != nil {
+			herr = NewAppError(http.StatusInternalServerError, err, "Unable to remove multipart upload")
+		}
+	}
+	uploadSessionLock.Unlock()
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	defer discardS3MultipartUpload(logger, upload)
+
+	if len(request.Parts) == 0 {
+		return h.davFail(ctx, http.StatusBadRequest, errors.New("no parts"), "InvalidPart: At least one part must be listed")
+	}
+	var numbers []int
+	for i, listed := range request.Parts {
+		if i > 0 && listed.PartNumber <= numbers[i-1] {
+			return h.davFail(ctx, http.StatusBadRequest, errors.New("parts out of order"), "InvalidPartOrder: Parts must be listed in ascending order")
+		}
+		part, ok := upload.Parts[listed.PartNumber]
+		if !ok || strings.Trim(listed.ETag, `"`) != part.ETag {
+			return h.davFail(ctx, http.StatusBadRequest, fmt.Errorf("part %d not found", listed.PartNumber), "InvalidPart: A listed part was not found or its ETag does not match")
+		}
+		numbers = append(numbers, listed.PartNumber)
+	}
+	sort.Ints(numbers)
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		pw.CloseWithError(h.readS3Parts(logger, upload, numbers, pw))
+	}()
+	object, herr := h.s3Store(ctx, r, loc, upload.ContentType, pr)
+	if herr != nil {
+		return herr
+	}
+	writeS3Response(w, s3CompleteMultipartUploadResult{
+		Location: r.URL.Path,
+		Bucket:   loc.Bucket,
+		Key:      loc.Key,
+		ETag:     s3ETag(object.ContentHash),
+	})
+	return nil
+}
+
+// readS3Parts decrypts the parts of an upload in order
+func (h AppServer) readS3Parts(logger *zap.Logger, upload *s3MultipartUpload, numbers []int, w io.Writer) error {
+	dp := uploadSessionCache(upload.ID)
+	key := upload.fileKey()
+	for _, number := range numbers {
+		f, err := dp.Files().Open(uploadSessionFileName(upload.ID, s3PartExtension(number)))
+		if err != nil {
+			return err
+		}
+		_, _, err = h.Conf.EncryptableFunctions.DoCipherByReaderWriter(logger, f, w, key, upload.Parts[number].EncryptIV, "reading part", crypto.NewByteRange())
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// s3AbortMultipartUpload discards a multipart upload and its parts
+func (h AppServer) s3AbortMultipartUpload(ctx context.Context, w http.ResponseWriter, r *http.Request, loc s3Location) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	uploadSessionLock.Lock()
+	upload, herr := loadS3MultipartUpload(ctx, r.URL.Query().Get("uploadId"), loc)
+	if herr == nil {
+		discardS3MultipartUpload(LoggerFromContext(ctx), upload)
+	}
+	uploadSessionLock.Unlock()
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	w.WriteHeader(http.StatusNoContent)
+	h.publishSuccess(gem, w)
+	return nil
+}
@@ -0,0 +1,244 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// s3GatewayEndpoint is the address of the S3 gateway of the server under test
+var s3GatewayEndpoint = os.Getenv("OD_S3GATEWAY_ENDPOINT")
+
+func doS3Request(t *testing.T, clientID int, method string, path string, body io.Reader) *http.Response {
+	req, err := http.NewRequest(method, s3GatewayEndpoint+path, body)
+	failNowOnErr(t, err, "unable to create request")
+	resp, err := clients[clientID].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	return resp
+}
+
+func TestS3GatewayObjects(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	if len(s3GatewayEndpoint) == 0 {
+		t.Logf("OD_S3GATEWAY_ENDPOINT is not set. The S3 gateway will not be tested.")
+		t.SkipNow()
+	}
+	tester10 := 0
+	dir := "/user/TestS3GatewayObjects" + newGUID(t)
+
+	resp := doS3Request(t, tester10, "PUT", dir+"/nested/a.txt", strings.NewReader("abcdefghij"))
+	statusMustBe(t, 200, resp, "expected object and the folders leading to it to be created")
+	util.FinishBody(resp.Body)
+
+	resp = doS3Request(t, tester10, "GET", "/user?list-type=2&delimiter=/&prefix="+strings.TrimPrefix(dir, "/user/")+"/", nil)
+	statusMustBe(t, 200, resp, "expected listing")
+	var listing struct {
+		CommonPrefixes []struct{ Prefix string }
+	}
+	err := xml.NewDecoder(resp.Body).Decode(&listing)
+	resp.Body.Close()
+	failNowOnErr(t, err, "unable to decode listing")
+	if len(listing.CommonPrefixes) != 1 || !strings.HasSuffix(listing.CommonPrefixes[0].Prefix, "/nested/") {
+		t.Errorf("expected nested folder as a common prefix, got %v", listing.CommonPrefixes)
+	}
+
+	req, err := http.NewRequest("GET", s3GatewayEndpoint+dir+"/nested/a.txt", nil)
+	failNowOnErr(t, err, "unable to create request")
+	req.Header.Set("Range", "bytes=2-4")
+	resp, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 206, resp, "expected partial content")
+	content, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(content) != "cde" {
+		t.Errorf("expected range of content, got %q", content)
+	}
+
+	resp = doS3Request(t, tester10, "HEAD", dir+"/nested/missing.txt", nil)
+	statusMustBe(t, 404, resp, "expected missing key")
+	util.FinishBody(resp.Body)
+
+	resp = doS3Request(t, tester10, "DELETE", dir+"/nested/a.txt", nil)
+	statusMustBe(t, 204, resp, "expected object to be deleted")
+	util.FinishBody(resp.Body)
+}
+
+func TestS3GatewayETags(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	if len(s3GatewayEndpoint) == 0 {
+		t.Logf("OD_S3GATEWAY_ENDPOINT is not set. The S3 gateway will not be tested.")
+		t.SkipNow()
+	}
+	tester10 := 0
+	prefix := "TestS3GatewayETags" + newGUID(t) + "/"
+	key := "/user/" + prefix + "a.txt"
+
+	resp := doS3Request(t, tester10, "PUT", key, strings.NewReader("abcdefghij"))
+	statusMustBe(t, 200, resp, "expected object to be created")
+	util.FinishBody(resp.Body)
+	etag := resp.Header.Get("ETag")
+	// The ETag is the hash of the content rather than an MD5, which clients
+	// would otherwise check the content against
+	if len(strings.Trim(etag, `"`)) != 64 {
+		t.Fatalf("expected ETag of the sha256 of the content, got %q", etag)
+	}
+
+	resp = doS3Request(t, tester10, "HEAD", key, nil)
+	statusMustBe(t, 200, resp, "expected object")
+	util.FinishBody(resp.Body)
+	if resp.Header.Get("ETag") != etag {
+		t.Errorf("expected HEAD ETag %s, got %s", etag, resp.Header.Get("ETag"))
+	}
+
+	resp = doS3Request(t, tester10, "GET", "/user?list-type=2&prefix="+url.QueryEscape(prefix), nil)
+	statusMustBe(t, 200, resp, "expected listing")
+	var listing struct {
+		Contents []struct{ Key, ETag string }
+	}
+	err := xml.NewDecoder(resp.Body).Decode(&listing)
+	resp.Body.Close()
+	failNowOnErr(t, err, "unable to decode listing")
+	if len(listing.Contents) != 1 || listing.Contents[0].ETag != etag {
+		t.Errorf("expected listed ETag %s, got %v", etag, listing.Contents)
+	}
+
+	resp = doS3Request(t, tester10, "GET", key, nil)
+	statusMustBe(t, 200, resp, "expected object")
+	util.FinishBody(resp.Body)
+	if resp.Header.Get("ETag") != etag {
+		t.Errorf("expected GET ETag %s, got %s", etag, resp.Header.Get("ETag"))
+	}
+
+	// The ETag from HEAD is good for conditional requests on the content
+	req, err := http.NewRequest("GET", s3GatewayEndpoint+key, nil)
+	failNowOnErr(t, err, "unable to create request")
+	req.Header.Set("If-None-Match", etag)
+	resp, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 304, resp, "expected content to be unchanged")
+	util.FinishBody(resp.Body)
+
+	resp = doS3Request(t, tester10, "DELETE", key, nil)
+	statusMustBe(t, 204, resp, "expected object to be deleted")
+	util.FinishBody(resp.Body)
+}
+
+func TestS3GatewayListPaging(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	if len(s3GatewayEndpoint) == 0 {
+		t.Logf("OD_S3GATEWAY_ENDPOINT is not set. The S3 gateway will not be tested.")
+		t.SkipNow()
+	}
+	tester10 := 0
+	prefix := "TestS3GatewayListPaging" + newGUID(t) + "/"
+	keys := []string{"a-b.txt", "a/1.txt", "a/2.txt", "b.txt"}
+	for _, key := range keys {
+		resp := doS3Request(t, tester10, "PUT", "/user/"+prefix+key, strings.NewReader(key))
+		statusMustBe(t, 200, resp, "expected object to be created")
+		util.FinishBody(resp.Body)
+	}
+
+	// Keys are listed in order across folders, two at a time
+	expected := []string{"a-b.txt", "a/", "a/1.txt", "a/2.txt", "b.txt"}
+	var listed []string
+	token := ""
+	for page := 0; page < len(expected); page++ {
+		path := "/user?list-type=2&max-keys=2&prefix=" + url.QueryEscape(prefix)
+		if len(token) > 0 {
+			path += "&continuation-token=" + url.QueryEscape(token)
+		}
+		resp := doS3Request(t, tester10, "GET", path, nil)
+		statusMustBe(t, 200, resp, "expected listing")
+		var listing struct {
+			Contents              []struct{ Key string }
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err := xml.NewDecoder(resp.Body).Decode(&listing)
+		resp.Body.Close()
+		failNowOnErr(t, err, "unable to decode listing")
+		for _, contents := range listing.Contents {
+			listed = append(listed, strings.TrimPrefix(contents.Key, prefix))
+		}
+		if !listing.IsTruncated {
+			break
+		}
+		token = listing.NextContinuationToken
+	}
+	if strings.Join(listed, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected keys %v, got %v", expected, listed)
+	}
+
+	for _, key := range keys {
+		resp := doS3Request(t, tester10, "DELETE", "/user/"+prefix+key, nil)
+		statusMustBe(t, 204, resp, "expected object to be deleted")
+		util.FinishBody(resp.Body)
+	}
+}
+
+func TestS3GatewayMultipartUpload(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	if len(s3GatewayEndpoint) == 0 {
+		t.Logf("OD_S3GATEWAY_ENDPOINT is not set. The S3 gateway will not be tested.")
+		t.SkipNow()
+	}
+	tester10 := 0
+	key := "/user/TestS3GatewayMultipartUpload" + newGUID(t) + ".txt"
+
+	resp := doS3Request(t, tester10, "POST", key+"?uploads", nil)
+	statusMustBe(t, 200, resp, "expected multipart upload to be created")
+	var initiated struct{ UploadId string }
+	err := xml.NewDecoder(resp.Body).Decode(&initiated)
+	resp.Body.Close()
+	failNowOnErr(t, err, "unable to decode multipart upload")
+
+	parts := []string{"first part ", "second part"}
+	var complete bytes.Buffer
+	complete.WriteString("<CompleteMultipartUpload>")
+	// Parts are sent out of order
+	for i := len(parts) - 1; i >= 0; i-- {
+		number := string(rune('1' + i))
+		resp = doS3Request(t, tester10, "PUT", key+"?partNumber="+number+"&uploadId="+initiated.UploadId, strings.NewReader(parts[i]))
+		statusMustBe(t, 200, resp, "expected part to be received")
+		util.FinishBody(resp.Body)
+		parts[i] = "<Part><PartNumber>" + number + "</PartNumber><ETag>" + resp.Header.Get("ETag") + "</ETag></Part>"
+	}
+	complete.WriteString(strings.Join(parts, ""))
+	complete.WriteString("</CompleteMultipartUpload>")
+
+	resp = doS3Request(t, tester10, "POST", key+"?uploadId="+initiated.UploadId, &complete)
+	statusMustBe(t, 200, resp, "expected multipart upload to be completed")
+	util.FinishBody(resp.Body)
+
+	resp = doS3Request(t, tester10, "GET", key, nil)
+	statusMustBe(t, 200, resp, "expected object")
+	content, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(content) != "first part second part" {
+		t.Errorf("expected parts in order, got %q", content)
+	}
+
+	resp = doS3Request(t, tester10, "PUT", key+"?partNumber=1&uploadId="+initiated.UploadId, strings.NewReader("late"))
+	statusMustBe(t, 404, resp, "expected completed upload to no longer exist")
+	util.FinishBody(resp.Body)
+
+	resp = doS3Request(t, tester10, "DELETE", key, nil)
+	statusMustBe(t, 204, resp, "expected object to be deleted")
+	util.FinishBody(resp.Body)
+}
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+// scrubBatchSize is the number of content streams read at a time
+const scrubBatchSize = 100
+
+// scrubReportLimit is the number of the most recent problems found that are
+// reported by /stats
+const scrubReportLimit = 100
+
+// Problems found with stored content
+const (
+	scrubMismatch   = "mismatch"
+	scrubMissing    = "missing"
+	scrubMissingKey = "missingKey"
+	scrubError      = "error"
+	// scrubPending is content not yet in permanent storage that is waiting to
+	// be uploaded from a cache, which is not a problem and is checked again on
+	// the next pass
+	scrubPending = "pending"
+)
+
+// scrubProblem is content found to have a problem
+type scrubProblem struct {
+	ObjectID         string
+	ContentConnector string
+	Problem          string
+	Msg              string
+	Found            time.Time
+}
+
+// scrubStats holds the results of verifying stored content on this instance
+type scrubStats struct {
+	sync.Mutex
+	Passes       int64
+	LastPass     time.Time
+	Verified     int64
+	Pending      int64
+	Mismatched   int64
+	Missing      int64
+	MissingKey   int64
+	Errors       int64
+	RecentIssues []scrubProblem
+}
+
+func newScrubStats() *scrubStats {
+	return &scrubStats{}
+}
+
+// record counts content verified, or a problem found with it
+func (s *scrubStats) record(p *scrubProblem) {
+	s.Lock()
+	defer s.Unlock()
+	if p == nil {
+		s.Verified++
+		return
+	}
+	switch p.Problem {
+	case scrubPending:
+		s.Pending++
+		return
+	case scrubMismatch:
+		s.Mismatched++
+	case scrubMissing:
+		s.Missing++
+	case scrubMissingKey:
+		s.MissingKey++
+	default:
+		s.Errors++
+	}
+	s.RecentIssues = append(s.RecentIssues, *p)
+	if len(s.RecentIssues) > scrubReportLimit {
+		s.RecentIssues = s.RecentIssues[len(s.RecentIssues)-scrubReportLimit:]
+	}
+}
+
+// finishPass counts a pass over all stored content
+func (s *scrubStats) finishPass() {
+	s.Lock()
+	defer s.Unlock()
+	s.Passes++
+	s.LastPass = time.Now().UTC()
+}
+
+// startScrubber verifies stored content in the background at a rate of
+// content streams per minute, passing over all of it repeatedly
+func (h AppServer) startScrubber(rate int64) {
+	if rate <= 0 {
+		return
+	}
+	interval := time.Minute / time.Duration(rate)
+	go func() {
+		for {
+			if h.scrubPass(interval) == 0 {
+				// Nothing stored yet
+				time.Sleep(time.Minute)
+			}
+		}
+	}()
+}
+
+// scrubPass verifies each content stream stored, returning how many were read
+func (h AppServer) scrubPass(interval time.Duration) int {
+	scrubLogger := logger.With(zap.String("session", "scrubber"))
+	count := 0
+	afterConnector := ""
+	for {
+		contents, err := h.RootDAO.GetContentForScrub(afterConnector, scrubBatchSize)
+		if err != nil {
+			scrubLogger.Error("unable to list content to verify", zap.Error(err))
+			time.Sleep(time.Minute)
+			continue
+		}
+		if len(contents) == 0 {
+			break
+		}
+		for _, content := range contents {
+			afterConnector = content.ContentConnector.String
+			count++
+			problem := h.scrubContent(scrubLogger, content)
+			h.scrub.record(problem)
+			if problem != nil && problem.Problem != scrubPending {
+				h.publishScrubProblem(scrubLogger, content, problem)
+			}
+			time.Sleep(interval)
+		}
+	}
+	h.scrub.finishPass()
+	scrubLogger.Info("content verification pass complete", zap.Int("contents", count))
+	return count
+}
+
+// ScrubContent verifies a content stream as the scrubber does, returning the
+// problem found with it, or an empty string if it was verified
+func (h AppServer) ScrubContent(scrubLogger *zap.Logger, content models.ODObject) string {
+	if problem := h.scrubContent(scrubLogger, content); problem != nil {
+		return problem.Problem
+	}
+	return ""
+}
+
+// scrubContent reads content back from storage and decrypts it, returning the
+// problem found if the plaintext does not match the hash recorded for it
+func (h AppServer) scrubContent(scrubLogger *zap.Logger, content models.ODObject) *scrubProblem {
+	problem := func(kind string, err error) *scrubProblem {
+		return &scrubProblem{
+			ObjectID:         hex.EncodeToString(content.ID),
+			ContentConnector: content.ContentConnector.String,
+			Problem:          kind,
+			Msg:              err.Error(),
+			Found:            time.Now().UTC(),
+		}
+	}
+	dp := ciphertext.FindCiphertextCacheByObject(&content)
+	if dp == nil {
+		return problem(scrubMissingKey, errors.New("object is in a zone that is not configured"))
+	}
+	var key []byte
+	if h.Conf.EncryptEnabled {
+		permissions, err := h.RootDAO.GetPermissionsForObject(content)
+		if err != nil {
+			return problem(scrubError, err)
+		}
+		content.Permissions = permissions
+		rewrapPermissions(&content)
+		for _, permission := range content.Permissions {
+			if len(permission.EncryptKey) > 0 {
+				key = crypto.ApplyPassphrase(dp.GetMasterKey(), permission.PermissionIV, permission.EncryptKey)
+				break
+			}
+		}
+		if len(key) == 0 {
+			return problem(scrubMissingKey, errors.New("object has no permission from which to derive the file key"))
+		}
+	}
+
+	rName := ciphertext.ContentConnectorFileId(content.ContentConnector.String)
+	var cipherReader io.ReadCloser
+	if ps := dp.GetPermanentStorage(); ps != nil {
+		// Read from permanent storage rather than through the cache, which
+		// serves any copy cached on this instance, so that the stored copy is
+		// what is verified. Content still waiting to be uploaded from this or
+		// a peer's cache is left for a later pass.
+		storageKey := string(dp.Resolve(ciphertext.NewFileName(rName, "")))
+		stream, err := ps.GetStream(&storageKey, 0, -1)
+		switch {
+		case err != nil && err.Error() == ciphertext.PermanentStorageNotFoundErrorString:
+			if ciphertext.IsAwaitingUpload(scrubLogger, dp, rName) {
+				return problem(scrubPending, err)
+			}
+			return problem(scrubMissing, err)
+		case err != nil && err.Error() == ciphertext.PermanentStorageChecksumErrorString:
+			return problem(scrubMismatch, err)
+		case err != nil:
+			return problem(scrubError, err)
+		case stream == nil:
+			return problem(scrubError, errors.New("no content returned from permanent storage"))
+		}
+		cipherReader = stream
+	} else {
+		// Without permanent storage, content is only held by the cache
+		stream, _, err := dp.NewPuller(scrubLogger, rName, crypto.CiphertextSize(content.CipherFormat, content.ContentSize.Int64), 0, -1)
+		if err != nil {
+			if err.Error() == ciphertext.PermanentStorageNotFoundErrorString {
+				return problem(scrubMissing, err)
+			}
+			return problem(scrubError, err)
+		}
+		cipherReader = stream
+	}
+	defer cipherReader.Close()
+
+	// The plaintext written is hashed, as AES-CTR returns the hash of what it
+	// reads, being the ciphertext
+	decipher := h.Conf.EncryptableFunctions.DecipherFor(content.CipherFormat)
+	hasher := sha256.New()
+	_, _, err := decipher(scrubLogger, cipherReader, hasher, key, content.EncryptIV, "verifying content", nil)
+	if err == crypto.ErrCiphertextTampered {
+		return problem(scrubMismatch, err)
+	}
+	if err != nil {
+		return problem(scrubError, err)
+	}
+	if !bytes.Equal(hasher.Sum(nil), content.ContentHash) {
+		return problem(scrubMismatch, fmt.Errorf("content does not match its hash %s", hex.EncodeToString(content.ContentHash)))
+	}
+	return nil
+}
+
+// publishScrubProblem reports a problem found with stored content as a failed
+// event for the object
+func (h AppServer) publishScrubProblem(scrubLogger *zap.Logger, content models.ODObject, p *scrubProblem) {
+	scrubLogger.Error("stored content failed verification",
+		zap.String("objectId", p.ObjectID),
+		zap.String("contentConnector", p.ContentConnector),
+		zap.String("problem", p.Problem),
+		zap.String("msg", p.Msg))
+	code := http.StatusInternalServerError
+	if p.Problem == scrubMissing {
+		code = http.StatusNotFound
+	}
+	gem := globalEventForSystem()
+	gem.Action = "access"
+	gem.Payload.ObjectID = p.ObjectID
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(content.ID))
+	h.publishError(gem, NewAppError(code, errors.New(p.Msg), "Stored content failed verification: "+p.Problem))
+}
@@ -0,0 +1,149 @@
+package server_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/server"
+)
+
+func TestScrubContent(t *testing.T) {
+	logger := config.RootLogger
+	testRoot, err := ioutil.TempDir("", "scrub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testRoot)
+	zone := ciphertext.CiphertextCacheZone("scrubtest")
+	conf := config.DiskCacheOpts{
+		Root:                 testRoot,
+		Partition:            "partition0",
+		LowThresholdPercent:  .50,
+		HighThresholdPercent: .75,
+		EvictAge:             300,
+		WalkSleep:            30,
+		MasterKey:            "testkey",
+	}
+	dp, loggableErr := ciphertext.NewLocalCiphertextCache(logger, zone, conf, "dbID0")
+	if loggableErr != nil {
+		t.Fatalf("unable to create cache: %s", loggableErr.Msg)
+	}
+	ciphertext.SetCiphertextCache(zone, dp)
+
+	plaintext := []byte("the content that was stored")
+	hash := sha256.Sum256(plaintext)
+	newContent := func(format int) (models.ODObject, ciphertext.FileId) {
+		connector := ciphertext.NewContentConnector(zone)
+		content := models.ODObject{
+			ContentConnector: models.ToNullString(connector),
+			ContentSize:      models.NullInt64{NullInt64: sql.NullInt64{Int64: int64(len(plaintext)), Valid: true}},
+			ContentHash:      hash[:],
+			EncryptIV:        crypto.CreateIV(),
+			CipherFormat:     format,
+		}
+		return content, ciphertext.ContentConnectorFileId(connector)
+	}
+	store := func(rName ciphertext.FileId, data []byte) {
+		key := string(dp.Resolve(ciphertext.NewFileName(rName, "")))
+		if err := dp.GetPermanentStorage().Upload(bytes.NewReader(data), &key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cache := func(rName ciphertext.FileId, state string, data []byte) {
+		name := dp.Files().Resolve(dp.Resolve(ciphertext.NewFileName(rName, state)))
+		if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := func(h server.AppServer, name string, content models.ODObject, expected string) {
+		if problem := h.ScrubContent(logger, content); problem != expected {
+			t.Errorf("%s: expected problem %q, got %q", name, expected, problem)
+		}
+	}
+
+	// Content is stored as is when encryption is disabled
+	plain := server.AppServer{Conf: config.ServerSettingsConfiguration{EncryptableFunctions: config.NewEncryptableFunctions(false)}}
+
+	verified, rName := newContent(crypto.CipherFormatCTR)
+	store(rName, plaintext)
+	expect(plain, "verified", verified, "")
+
+	mismatched, rName := newContent(crypto.CipherFormatCTR)
+	store(rName, []byte("the content that was changed"))
+	expect(plain, "mismatch", mismatched, "mismatch")
+
+	// The stored copy is verified rather than a copy cached on this instance
+	cachedMismatch, rName := newContent(crypto.CipherFormatCTR)
+	store(rName, []byte("the content that was changed"))
+	cache(rName, ciphertext.FileStateCached, plaintext)
+	expect(plain, "cached mismatch", cachedMismatch, "mismatch")
+
+	missing, _ := newContent(crypto.CipherFormatCTR)
+	expect(plain, "missing", missing, "missing")
+
+	pending, rName := newContent(crypto.CipherFormatCTR)
+	cache(rName, ciphertext.FileStateUploaded, plaintext)
+	expect(plain, "pending", pending, "pending")
+
+	// Encrypted content is decrypted with the key granted to the object
+	var grant models.ODObjectPermission
+	models.SetEncryptKey(dp.GetMasterKey(), &grant)
+	key := crypto.ApplyPassphrase(dp.GetMasterKey(), grant.PermissionIV, grant.EncryptKey)
+	encrypted := server.AppServer{
+		Conf: config.ServerSettingsConfiguration{
+			EncryptEnabled:       true,
+			EncryptableFunctions: config.NewEncryptableFunctions(true),
+		},
+		RootDAO: &dao.FakeDAO{ObjectPermissions: []models.ODObjectPermission{grant}},
+	}
+	encrypt := func(content models.ODObject, data []byte) []byte {
+		var sealed bytes.Buffer
+		cipher := crypto.DoCipherByReaderWriter
+		if content.CipherFormat == crypto.CipherFormatGCM {
+			cipher = crypto.DoSealByReaderWriter
+		}
+		if _, _, err := cipher(logger, bytes.NewReader(data), &sealed, key, content.EncryptIV, "test", nil); err != nil {
+			t.Fatal(err)
+		}
+		return sealed.Bytes()
+	}
+
+	for _, format := range []struct {
+		name   string
+		format int
+	}{{"ctr", crypto.CipherFormatCTR}, {"gcm", crypto.CipherFormatGCM}} {
+		verified, rName := newContent(format.format)
+		store(rName, encrypt(verified, plaintext))
+		expect(encrypted, format.name+" verified", verified, "")
+
+		mismatched, rName := newContent(format.format)
+		store(rName, encrypt(mismatched, []byte("the content that was changed")))
+		expect(encrypted, format.name+" mismatch", mismatched, "mismatch")
+
+		// Content that cannot be decrypted with the key does not match
+		wrongIV, rName := newContent(format.format)
+		store(rName, encrypt(wrongIV, plaintext))
+		wrongIV.EncryptIV = crypto.CreateIV()
+		expect(encrypted, format.name+" wrong iv", wrongIV, "mismatch")
+
+		// Without a grant there is no key to decrypt the content with
+		unkeyed := encrypted
+		unkeyed.RootDAO = &dao.FakeDAO{}
+		missingKey, rName := newContent(format.format)
+		store(rName, encrypt(missingKey, plaintext))
+		expect(unkeyed, format.name+" missing key", missingKey, "missingKey")
+	}
+}
@@ -15,8 +15,10 @@ import (
 	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
 	"bitbucket.di2e.net/dime/object-drive-server/config"
 	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/fulltext"
 	"bitbucket.di2e.net/dime/object-drive-server/services/aac"
 	"bitbucket.di2e.net/dime/object-drive-server/services/kafka"
+	"bitbucket.di2e.net/dime/object-drive-server/services/kms"
 	"bitbucket.di2e.net/dime/object-drive-server/services/zookeeper"
 	"bitbucket.di2e.net/dime/object-drive-server/util"
 	"github.com/samuel/go-zookeeper/zk"
@@ -57,14 +59,50 @@ func Start(conf config.AppConfiguration) error {
 	app.RootDAO = d
 	go daoReadOnlyCheck(app, conf.DatabaseConnection)
 
+	keyProvider, err := kms.NewKeyProvider(conf.CacheSettings.KeyProvider)
+	if err != nil {
+		logger.Fatal("unable to configure key provider", zap.String("type", conf.CacheSettings.KeyProvider.Type), zap.Error(err))
+	}
+	ciphertext.SetKeyProvider(keyProvider)
+
 	zone := ciphertext.S3_DEFAULT_CIPHERTEXT_CACHE
 	cache, loggableErr := ciphertext.NewDiskCache(zone, conf.CacheSettings, dbID)
 	if loggableErr != nil {
 		loggableErr.ToFatal(logger)
 	}
 	ciphertext.SetCiphertextCache(zone, cache)
+	for _, zoneConf := range conf.CacheSettings.Zones {
+		zone := ciphertext.CiphertextCacheZone(zoneConf.Name)
+		zoneCache, loggableErr := ciphertext.NewDiskCache(zone, conf.CacheSettings.ForZone(zoneConf), dbID)
+		if loggableErr != nil {
+			loggableErr.ToFatal(logger)
+		}
+		ciphertext.SetCiphertextCache(zone, zoneCache)
+	}
+	ciphertext.SetCiphertextCacheRoutes(ciphertext.NewCiphertextCacheRoutes(conf.CacheSettings.Zones))
 
 	configureEventQueue(app, conf.EventQueue, conf.ZK.Timeout)
+	app.EventQueue = newSubscriptionPublisher(app, app.EventQueue, conf.WebhookSettings)
+	if len(conf.FullTextSettings.Root) > 0 {
+		index, err := fulltext.OpenIndex(conf.FullTextSettings.Root, []byte(cache.GetMasterKey()))
+		if err != nil {
+			logger.Fatal("unable to open full text index", zap.String("root", conf.FullTextSettings.Root), zap.Error(err))
+		}
+		app.ContentIndex = index
+		indexer := newContentIndexer(app, index, conf.FullTextSettings)
+		if brokers := eventQueueBrokers(conf.EventQueue, conf.ZK.Timeout); len(brokers) > 0 {
+			warnUnpublishedContentIndexActions(conf.EventQueue)
+			if err := indexer.consume(brokers, conf.EventQueue.Topic, conf.FullTextSettings.Root); err != nil {
+				logger.Fatal("unable to consume events for full text index", zap.String("topic", conf.EventQueue.Topic), zap.Error(err))
+			}
+		} else {
+			app.EventQueue = newContentIndexPublisher(app.EventQueue, indexer)
+		}
+	}
+
+	app.WebDAV = conf.WebDAVSettings
+	app.S3Gateway = conf.S3GatewaySettings
+	app.Webhook = conf.WebhookSettings
 
 	err = connectWithZookeeper(app, conf.ZK.AnnouncementPoint, conf.ZK.Address, conf.ZK.Timeout, conf.ZK.RetryDelay)
 	if err != nil {
@@ -88,6 +126,23 @@ func Start(conf config.AppConfiguration) error {
 		exitChan <- httpServer.ListenAndServeTLS(
 			conf.ServerSettings.ServerCertChain, conf.ServerSettings.ServerKey)
 	}()
+	if len(conf.S3GatewaySettings.ListenPort) > 0 {
+		s3Server := &http.Server{
+			Addr:              app.Bind + ":" + conf.S3GatewaySettings.ListenPort,
+			Handler:           app.S3GatewayHandler(),
+			IdleTimeout:       httpServer.IdleTimeout,
+			ReadTimeout:       httpServer.ReadTimeout,
+			ReadHeaderTimeout: httpServer.ReadHeaderTimeout,
+			WriteTimeout:      httpServer.WriteTimeout,
+			MaxHeaderBytes:    httpServer.MaxHeaderBytes,
+			TLSConfig:         &tlsConfig,
+		}
+		go func() {
+			exitChan <- s3Server.ListenAndServeTLS(
+				conf.ServerSettings.ServerCertChain, conf.ServerSettings.ServerKey)
+		}()
+		logger.Info("starting s3 gateway", zap.String("addr", s3Server.Addr))
+	}
 
 	zkTracking(app, conf)
 	logger.Info("starting server", zap.String("addr", app.Addr))
@@ -106,6 +161,15 @@ func Start(conf config.AppConfiguration) error {
 			}
 		}
 	}()
+	app.queueKeyRotations()
+	if conf.CacheSettings.Reencrypt && conf.ServerSettings.EncryptEnabled {
+		app.queueReencryptions()
+	}
+	app.startJobWorkers(conf.ServerSettings.JobWorkers, conf.ServerSettings.JobLease)
+	app.startScrubber(conf.ServerSettings.ScrubRate)
+	if conf.CacheSettings.OrphanCollect {
+		app.startOrphanCollection(conf.CacheSettings.OrphanGrace, conf.CacheSettings.OrphanDryRun)
+	}
 
 	// Announce our new service in ZK.
 	err = zookeeper.ServiceAnnouncement(app.DefaultZK, "https", "ALIVE", conf.ZK.IP, conf.ZK.Port)
@@ -158,7 +222,8 @@ func configureEventQueue(app *AppServer, conf config.EventQueueConfiguration, zk
 		setter := func(ap *kafka.AsyncProducer) {
 			// Don't just reset the conn because a zk event told you to, do an explicit check.
 			if app.EventQueue.Reconnect() {
-				app.EventQueue = ap
+				// Keep notifying subscribers and indexing content through the new connection
+				app.EventQueue = reconnectedPublisher(app.EventQueue, ap)
 			}
 		}
 		// Allow time for kafka to be available in zookeeper
@@ -190,6 +255,43 @@ func configureEventQueue(app *AppServer, conf config.EventQueueConfiguration, zk
 	logger.Error("no Kafka queue configured")
 }
 
+// eventQueueBrokers returns the addresses of the Kafka brokers events are
+// published to, either as configured or as discovered from ZK, or none if
+// events are not published to Kafka
+func eventQueueBrokers(conf config.EventQueueConfiguration, zkTimeout int64) []string {
+	if len(conf.KafkaAddrs) > 0 {
+		return conf.KafkaAddrs
+	}
+	if len(conf.ZKAddrs) == 0 {
+		return nil
+	}
+	conn, _, err := zk.Connect(conf.ZKAddrs, time.Duration(zkTimeout)*time.Second)
+	if err != nil {
+		logger.Error("err from zk.Connect", zap.Error(err))
+		return nil
+	}
+	defer conn.Close()
+	return kafka.BrokersFromZKPath(conn, "/brokers/ids")
+}
+
+// warnUnpublishedContentIndexActions warns of the successful actions that
+// change content that are not published, as objects changed by them are not
+// indexed by the instances consuming the events
+func warnUnpublishedContentIndexActions(conf config.EventQueueConfiguration) {
+	published := make(map[string]bool)
+	for _, action := range conf.PublishSuccessActions {
+		published[action] = true
+	}
+	if published["*"] {
+		return
+	}
+	for _, action := range []string{"create", "delete", "undelete", "update"} {
+		if !published[action] {
+			logger.Warn("successful action is not published, so objects changed by it are not indexed", zap.String("action", action))
+		}
+	}
+}
+
 func connectWithZookeeperTry(app *AppServer, zkBasePath string, zkAddress string, zkTimeout int64) error {
 	// We need the path to our announcements to exist, but not the ephemeral nodes yet
 	zkState, err := zookeeper.RegisterApplication(zkBasePath, zkAddress, zkTimeout)
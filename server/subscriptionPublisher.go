@@ -0,0 +1,199 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/auth"
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+	"bitbucket.di2e.net/dime/object-drive-server/events"
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/webhook"
+)
+
+// subscriptionPublisher is an events.Publisher that passes every event on to
+// the wrapped Publisher, and additionally notifies users that have subscribed
+// to the objects changed by successful create, update, delete and undelete
+// events. Matching subscriptions and the access checks for each subscriber
+// are performed in the background so that requests are not delayed.
+type subscriptionPublisher struct {
+	events.Publisher
+	app        *AppServer
+	dispatcher *webhook.Dispatcher
+	pending    chan events.GEM
+}
+
+// newSubscriptionPublisher wraps the publisher for delivery of notifications
+// to subscribers per the webhook configuration.
+func newSubscriptionPublisher(app *AppServer, publisher events.Publisher, conf config.WebhookConfiguration) *subscriptionPublisher {
+	dispatcher := webhook.NewDispatcher(
+		webhook.WithAllowedCallbacks(conf.AllowedCallbacks),
+		webhook.WithPrivateNetworks(conf.AllowPrivate),
+		webhook.WithLogger(logger),
+		webhook.WithQueueSize(int(conf.QueueSize)),
+		webhook.WithRetries(int(conf.Retries), time.Duration(conf.RetryDelay)*time.Second),
+		webhook.WithTimeout(time.Duration(conf.Timeout)*time.Second),
+		webhook.WithWorkers(int(conf.Workers)),
+	)
+	p := &subscriptionPublisher{
+		Publisher:  publisher,
+		app:        app,
+		dispatcher: dispatcher,
+		pending:    make(chan events.GEM, conf.QueueSize),
+	}
+	go p.start()
+	return p
+}
+
+// withPublisher returns a subscriptionPublisher sharing this one's delivery,
+// but passing events on to a different Publisher. This is used when the
+// event queue is reconnected.
+func (p *subscriptionPublisher) withPublisher(publisher events.Publisher) *subscriptionPublisher {
+	return &subscriptionPublisher{
+		Publisher:  publisher,
+		app:        p.app,
+		dispatcher: p.dispatcher,
+		pending:    p.pending,
+	}
+}
+
+// Publish implements the events.Publisher interface.
+func (p *subscriptionPublisher) Publish(e events.Event) {
+	p.Publisher.Publish(e)
+	gem, ok := e.(events.GEM)
+	if !ok || !gem.IsSuccessful() || len(gem.Payload.ObjectID) == 0 {
+		return
+	}
+	if len(subscriptionFlagForAction(gem.Action)) == 0 {
+		return
+	}
+	select {
+	case p.pending <- gem:
+	default:
+		logger.Warn("subscription queue is full, notifications not sent", zap.String("eventId", gem.ID), zap.String("objectId", gem.Payload.ObjectID))
+	}
+}
+
+func (p *subscriptionPublisher) start() {
+	for gem := range p.pending {
+		p.notify(gem)
+	}
+}
+
+// subscriptionFlagForAction identifies which subscription setting is required
+// for an event action to be notified, or empty if the action is not notified.
+func subscriptionFlagForAction(action string) string {
+	switch action {
+	case "create":
+		return "onCreate"
+	case "update", "undelete":
+		return "onUpdate"
+	case "delete":
+		return "onDelete"
+	}
+	return ""
+}
+
+func subscriptionWants(subscription models.ODUserObjectSubscription, action string) bool {
+	switch subscriptionFlagForAction(action) {
+	case "onCreate":
+		return subscription.OnCreate
+	case "onUpdate":
+		return subscription.OnUpdate
+	case "onDelete":
+		return subscription.OnDelete
+	}
+	return false
+}
+
+// notify sends a notification to each subscriber of the object referenced by
+// the event that is still permitted to read it.
+func (p *subscriptionPublisher) notify(gem events.GEM) {
+	d := p.app.RootDAO
+	if d == nil {
+		return
+	}
+	objectID, err := hex.DecodeString(gem.Payload.ObjectID)
+	if err != nil {
+		return
+	}
+	object, err := d.GetObject(models.ODObject{ID: objectID}, true)
+	if err != nil {
+		logger.Warn("unable to retrieve object for subscription notification", zap.String("objectId", gem.Payload.ObjectID), zap.Error(err))
+		return
+	}
+	subscriptions, err := d.GetSubscriptionsForObject(object)
+	if err != nil {
+		logger.Warn("unable to retrieve subscriptions for object", zap.String("objectId", gem.Payload.ObjectID), zap.Error(err))
+		return
+	}
+
+	// A subscriber may have several matching subscriptions, but access is
+	// only checked once per subscriber.
+	allowed := make(map[string]bool)
+	for _, subscription := range subscriptions {
+		if !subscriptionWants(subscription, gem.Action) {
+			continue
+		}
+		dn := subscription.CreatedBy
+		ok, checked := allowed[dn]
+		if !checked {
+			ok = p.subscriberCanRead(dn, object)
+			allowed[dn] = ok
+		}
+		if !ok {
+			continue
+		}
+		notification := protocol.SubscriptionNotification{
+			SubscriptionID: hex.EncodeToString(subscription.ID),
+			EventID:        gem.ID,
+			Action:         gem.Action,
+			Timestamp:      gem.Timestamp,
+			UserDN:         gem.Payload.UserDN,
+			Object:         mapping.MapODObjectToObject(&object),
+		}
+		body, err := json.Marshal(notification)
+		if err != nil {
+			logger.Error("unable to marshal subscription notification", zap.Error(err))
+			continue
+		}
+		p.dispatcher.Enqueue(webhook.Notification{
+			SubscriptionID: notification.SubscriptionID,
+			CallbackURL:    subscription.CallbackURL,
+			Body:           body,
+		})
+	}
+}
+
+// subscriberCanRead verifies that the subscriber currently has read access to
+// the object, both through its permissions and the ACM. Any failure to
+// determine access is treated as not permitted.
+func (p *subscriptionPublisher) subscriberCanRead(dn string, object models.ODObject) bool {
+	if p.app.AAC == nil {
+		return false
+	}
+	ctx := context.Background()
+	ctx = ContextWithLogger(ctx, logger)
+	ctx = ContextWithCaller(ctx, Caller{DistinguishedName: dn, UserDistinguishedName: dn})
+	ctx = ContextWithDAO(ctx, p.app.RootDAO)
+	groups, _, err := p.app.GetUserGroupsAndSnippets(ctx)
+	if err != nil {
+		logger.Warn("unable to retrieve groups for subscriber", zap.String("dn", dn), zap.Error(err))
+		return false
+	}
+	ctx = ContextWithGroups(ctx, groups)
+	if !isUserAllowedToRead(ctx, &object) {
+		return false
+	}
+	aacAuth := auth.NewAACAuth(logger, p.app.AAC)
+	if _, err := aacAuth.IsUserAuthorizedForACM(dn, object.RawAcm.String); err != nil {
+		return false
+	}
+	return true
+}
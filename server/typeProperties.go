@@ -0,0 +1,219 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+)
+
+// typePropertiesCachePrefix distinguishes cached property definitions from
+// the object types also held in the TypeLruCache.
+const typePropertiesCachePrefix = "properties:"
+
+// getTypeProperties returns the property definitions declared for the object
+// type, using the TypeLruCache to avoid a database call for every object.
+func getTypeProperties(ctx context.Context, h AppServer, typeID []byte) ([]models.ODObjectTypeProperty, error) {
+	key := typePropertiesCachePrefix + hex.EncodeToString(typeID)
+	if cacheItem := h.TypeLruCache.Get(key); cacheItem != nil && !cacheItem.Expired() {
+		return cacheItem.Value().([]models.ODObjectTypeProperty), nil
+	}
+	dao := DAOFromContext(ctx)
+	definitions, err := dao.GetObjectTypeProperties(models.ODObjectType{ID: typeID})
+	if err != nil {
+		return nil, err
+	}
+	h.TypeLruCache.Set(key, definitions, time.Minute*5)
+	return definitions, nil
+}
+
+// forgetTypeProperties removes the cached type and its property definitions
+// so that changes made by an administrator take effect immediately.
+func forgetTypeProperties(h AppServer, objectType models.ODObjectType) {
+	h.TypeLruCache.Delete(objectType.Name)
+	h.TypeLruCache.Delete(typePropertiesCachePrefix + hex.EncodeToString(objectType.ID))
+}
+
+// enforceTypeProperties assigns defaults for properties declared on the type
+// of the object that it does not have, and then checks the properties of the
+// object against the declared definitions. The TypeID of the object must
+// already be set. A 400 is returned listing each violated property.
+func enforceTypeProperties(ctx context.Context, h AppServer, obj *models.ODObject) *AppError {
+	definitions, err := getTypeProperties(ctx, h, obj.TypeID)
+	if err != nil {
+		return NewAppError(http.StatusInternalServerError, err, "error retrieving properties for type")
+	}
+	if len(definitions) == 0 {
+		return nil
+	}
+	obj.Properties = ApplyTypePropertyDefaults(definitions, obj.Properties)
+	return typePropertyError(obj.TypeName.String, TypePropertyViolations(definitions, obj.Properties))
+}
+
+func typePropertyError(typeName string, violations []string) *AppError {
+	if len(violations) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("properties do not conform to type %s: %s", typeName, strings.Join(violations, "; "))
+	return NewAppError(http.StatusBadRequest, errors.New(msg), msg)
+}
+
+// ApplyTypePropertyDefaults returns the properties with a property appended
+// for each definition that has a default value which is not already present.
+func ApplyTypePropertyDefaults(definitions []models.ODObjectTypeProperty, properties []models.ODObjectPropertyEx) []models.ODObjectPropertyEx {
+	for _, definition := range definitions {
+		if len(definition.Property.Value.String) == 0 {
+			continue
+		}
+		if _, ok := findObjectProperty(properties, definition.Property.Name); ok {
+			continue
+		}
+		properties = append(properties, models.ODObjectPropertyEx{
+			Name:             definition.Property.Name,
+			Value:            definition.Property.Value,
+			ClassificationPM: definition.Property.ClassificationPM,
+		})
+	}
+	return properties
+}
+
+// TypePropertyViolations checks the properties against the definitions
+// declared for a type, returning a description of each violation found.
+// Properties that are not declared by the type are permitted.
+func TypePropertyViolations(definitions []models.ODObjectTypeProperty, properties []models.ODObjectPropertyEx) []string {
+	var violations []string
+	for _, definition := range definitions {
+		name := definition.Property.Name
+		property, ok := findObjectProperty(properties, name)
+		if !ok || len(property.Value.String) == 0 {
+			if definition.IsRequired {
+				violations = append(violations, fmt.Sprintf("%s is required", name))
+			}
+			continue
+		}
+		if violation := checkTypePropertyValue(definition, property.Value.String); len(violation) > 0 {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+// TypeDefinitionViolations checks a set of property definitions for a type
+// prior to saving them, returning a description of each problem found.
+func TypeDefinitionViolations(definitions []models.ODObjectTypeProperty) []string {
+	var violations []string
+	seen := make(map[string]bool)
+	for _, definition := range definitions {
+		name := definition.Property.Name
+		switch {
+		case len(name) == 0:
+			violations = append(violations, "property name is required")
+			continue
+		case len(name) > 255:
+			violations = append(violations, fmt.Sprintf("%s exceeds 255 characters", name))
+			continue
+		case seen[strings.ToLower(name)]:
+			violations = append(violations, fmt.Sprintf("%s is declared more than once", name))
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		switch definition.ValueType {
+		case models.PropertyValueTypeString, models.PropertyValueTypeInt, models.PropertyValueTypeDate:
+		case models.PropertyValueTypeEnum:
+			if len(allowedValues(definition)) == 0 {
+				violations = append(violations, fmt.Sprintf("%s must declare allowedValues for type enum", name))
+				continue
+			}
+		default:
+			violations = append(violations, fmt.Sprintf("%s has unsupported valueType %s", name, definition.ValueType))
+			continue
+		}
+		if len(definition.Property.Value.String) > 0 {
+			if violation := checkTypePropertyValue(definition, definition.Property.Value.String); len(violation) > 0 {
+				violations = append(violations, "default for "+violation)
+			}
+		}
+	}
+	return violations
+}
+
+// checkTypePropertyValue returns a description of why the value does not
+// conform to the definition, or an empty string if it does.
+func checkTypePropertyValue(definition models.ODObjectTypeProperty, value string) string {
+	name := definition.Property.Name
+	switch definition.ValueType {
+	case models.PropertyValueTypeInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Sprintf("%s must be an integer", name)
+		}
+	case models.PropertyValueTypeDate:
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			if _, err := time.Parse("2006-01-02", value); err != nil {
+				return fmt.Sprintf("%s must be a date in the format YYYY-MM-DD or RFC3339", name)
+			}
+		}
+	case models.PropertyValueTypeEnum:
+		allowed := allowedValues(definition)
+		for _, v := range allowed {
+			if v == value {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%s must be one of %s", name, strings.Join(allowed, ", "))
+	}
+	return ""
+}
+
+func allowedValues(definition models.ODObjectTypeProperty) []string {
+	var allowed []string
+	if definition.AllowedValues.Valid && len(definition.AllowedValues.String) > 0 {
+		json.Unmarshal([]byte(definition.AllowedValues.String), &allowed)
+	}
+	return allowed
+}
+
+func findObjectProperty(properties []models.ODObjectPropertyEx, name string) (models.ODObjectPropertyEx, bool) {
+	for _, property := range properties {
+		if property.Name == name {
+			return property, true
+		}
+	}
+	return models.ODObjectPropertyEx{}, false
+}
+
+// enforceTypePropertiesOnUpdate checks the properties the object will have
+// once the requested changes are applied against the definitions declared on
+// its type. Properties in the request replace those already on the object,
+// and a property given an empty value is removed. Defaults for declared
+// properties that would otherwise be absent are added to the request.
+func enforceTypePropertiesOnUpdate(ctx context.Context, h AppServer, requestObject *models.ODObject, dbObject models.ODObject) *AppError {
+	definitions, err := getTypeProperties(ctx, h, requestObject.TypeID)
+	if err != nil {
+		return NewAppError(http.StatusInternalServerError, err, "error retrieving properties for type")
+	}
+	if len(definitions) == 0 {
+		return nil
+	}
+	var merged []models.ODObjectPropertyEx
+	for _, dbProperty := range dbObject.Properties {
+		if _, ok := findObjectProperty(requestObject.Properties, dbProperty.Name); !ok {
+			merged = append(merged, dbProperty)
+		}
+	}
+	for _, property := range requestObject.Properties {
+		if len(property.Value.String) > 0 {
+			merged = append(merged, property)
+		}
+	}
+	withDefaults := ApplyTypePropertyDefaults(definitions, merged)
+	requestObject.Properties = append(requestObject.Properties, withDefaults[len(merged):]...)
+	return typePropertyError(requestObject.TypeName.String, TypePropertyViolations(definitions, withDefaults))
+}
@@ -0,0 +1,110 @@
+package server_test
+
+import (
+	"strings"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/server"
+)
+
+func typePropertyDefinition(name string, valueType string, required bool, defaultValue string, allowed string) models.ODObjectTypeProperty {
+	var definition models.ODObjectTypeProperty
+	definition.Property.Name = name
+	definition.ValueType = valueType
+	definition.IsRequired = required
+	if len(defaultValue) > 0 {
+		definition.Property.Value = models.ToNullString(defaultValue)
+	}
+	if len(allowed) > 0 {
+		definition.AllowedValues = models.ToNullString(allowed)
+	}
+	return definition
+}
+
+func objectProperty(name string, value string) models.ODObjectPropertyEx {
+	return models.ODObjectPropertyEx{Name: name, Value: models.ToNullString(value)}
+}
+
+func TestTypePropertyViolations(t *testing.T) {
+	definitions := []models.ODObjectTypeProperty{
+		typePropertyDefinition("caseNumber", models.PropertyValueTypeInt, true, "", ""),
+		typePropertyDefinition("opened", models.PropertyValueTypeDate, false, "", ""),
+		typePropertyDefinition("status", models.PropertyValueTypeEnum, false, "", `["open","closed"]`),
+		typePropertyDefinition("owner", models.PropertyValueTypeString, true, "", ""),
+	}
+
+	valid := []models.ODObjectPropertyEx{
+		objectProperty("caseNumber", "42"),
+		objectProperty("opened", "2019-10-05"),
+		objectProperty("status", "closed"),
+		objectProperty("owner", "someone"),
+		objectProperty("undeclared", "anything"),
+	}
+	if violations := server.TypePropertyViolations(definitions, valid); len(violations) != 0 {
+		t.Errorf("expected no violations, got %v", violations)
+	}
+
+	invalid := []models.ODObjectPropertyEx{
+		objectProperty("caseNumber", "forty-two"),
+		objectProperty("opened", "yesterday"),
+		objectProperty("status", "pending"),
+	}
+	violations := server.TypePropertyViolations(definitions, invalid)
+	if len(violations) != 4 {
+		t.Errorf("expected 4 violations, got %d: %v", len(violations), violations)
+	}
+	for _, name := range []string{"caseNumber", "opened", "status", "owner is required"} {
+		found := false
+		for _, violation := range violations {
+			if strings.HasPrefix(violation, name) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected a violation for %s", name)
+		}
+	}
+}
+
+func TestApplyTypePropertyDefaults(t *testing.T) {
+	definitions := []models.ODObjectTypeProperty{
+		typePropertyDefinition("status", models.PropertyValueTypeEnum, true, "open", `["open","closed"]`),
+		typePropertyDefinition("priority", models.PropertyValueTypeInt, false, "3", ""),
+		typePropertyDefinition("owner", models.PropertyValueTypeString, false, "", ""),
+	}
+	properties := server.ApplyTypePropertyDefaults(definitions, []models.ODObjectPropertyEx{objectProperty("priority", "1")})
+	if len(properties) != 2 {
+		t.Errorf("expected 2 properties, got %d", len(properties))
+		t.FailNow()
+	}
+	if properties[0].Value.String != "1" {
+		t.Errorf("given value for priority was replaced by default")
+	}
+	if properties[1].Name != "status" || properties[1].Value.String != "open" {
+		t.Errorf("default for status was not applied")
+	}
+	if violations := server.TypePropertyViolations(definitions, properties); len(violations) != 0 {
+		t.Errorf("expected defaults to satisfy required properties, got %v", violations)
+	}
+}
+
+func TestTypeDefinitionViolations(t *testing.T) {
+	definitions := []models.ODObjectTypeProperty{
+		typePropertyDefinition("", models.PropertyValueTypeString, false, "", ""),
+		typePropertyDefinition("size", "float", false, "", ""),
+		typePropertyDefinition("status", models.PropertyValueTypeEnum, false, "", ""),
+		typePropertyDefinition("count", models.PropertyValueTypeInt, false, "many", ""),
+		typePropertyDefinition("Count", models.PropertyValueTypeInt, false, "", ""),
+	}
+	if violations := server.TypeDefinitionViolations(definitions); len(violations) != 5 {
+		t.Errorf("expected 5 violations, got %d: %v", len(violations), violations)
+	}
+	valid := []models.ODObjectTypeProperty{
+		typePropertyDefinition("status", models.PropertyValueTypeEnum, true, "open", `["open","closed"]`),
+		typePropertyDefinition("opened", models.PropertyValueTypeDate, false, "2019-10-05T00:00:00Z", ""),
+	}
+	if violations := server.TypeDefinitionViolations(valid); len(violations) != 0 {
+		t.Errorf("expected no violations, got %v", violations)
+	}
+}
@@ -6,15 +6,12 @@ import (
 	"net/http"
 	"strings"
 
-	"go.uber.org/zap"
-
 	"golang.org/x/net/context"
 
 	"fmt"
 
 	"bitbucket.di2e.net/dime/object-drive-server/auth"
 	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
-	"bitbucket.di2e.net/dime/object-drive-server/dao"
 	"bitbucket.di2e.net/dime/object-drive-server/events"
 	"bitbucket.di2e.net/dime/object-drive-server/mapping"
 	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
@@ -122,6 +119,7 @@ func (h AppServer) updateObject(ctx context.Context, w http.ResponseWriter, r *h
 	requestObject.ContentSize = dbObject.ContentSize
 	requestObject.ContentHash = dbObject.ContentHash
 	requestObject.EncryptIV = dbObject.EncryptIV
+	requestObject.CipherFormat = dbObject.CipherFormat
 
 	// Retain existing ownership
 	requestObject.OwnedBy = models.ToNullString(dbObject.OwnedBy.String)
@@ -176,7 +174,7 @@ func (h AppServer) updateObject(ctx context.Context, w http.ResponseWriter, r *h
 	}
 	consolidateChangingPermissions(&requestObject)
 
-	masterKey := ciphertext.FindCiphertextCacheByObject(nil).GetMasterKey()
+	masterKey := ciphertext.FindCiphertextCacheByObject(&requestObject).GetMasterKey()
 	// copy grant.EncryptKey to all existing permissions:
 	for idx, permission := range requestObject.Permissions {
 		models.CopyEncryptKey(masterKey, &grant, &permission)
@@ -237,6 +235,10 @@ func (h AppServer) updateObject(ctx context.Context, w http.ResponseWriter, r *h
 		h.publishError(gem, herr)
 		return herr
 	}
+	if herr := enforceTypePropertiesOnUpdate(ctx, h, &requestObject, dbObject); herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
 	err = dao.UpdateObject(&requestObject)
 	if err != nil {
 		herr := NewAppError(http.StatusInternalServerError, err, "DAO Error updating object")
@@ -270,124 +272,17 @@ func (h AppServer) updateObject(ctx context.Context, w http.ResponseWriter, r *h
 	gem.Payload.Audit = audit.WithModifiedPairList(gem.Payload.Audit, audit.NewModifiedResourcePair(auditOriginal, auditModified))
 	gem.Payload.StreamUpdate = false
 	gem.Payload = events.WithEnrichedPayload(gem.Payload, apiResponse)
-	jsonResponse(w, apiResponse)
-	h.publishSuccess(gem, w)
 
+	// Apply the permissions to descendants in the background, as a job located by the response
 	if recursive {
-
-		applyable := dbObject
-		go h.updateObjectRecursive(ctx, applyable)
-
-	}
-	return nil
-}
-
-func (h AppServer) updateObjectRecursive(ctx context.Context, applyable models.ODObject) {
-	d := DAOFromContext(ctx)
-	caller, _ := CallerFromContext(ctx)
-	logger := LoggerFromContext(ctx)
-	aacAuth := auth.NewAACAuth(logger, h.AAC)
-	gem, _ := GEMFromContext(ctx)
-	gem.Action = "update"
-	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
-	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "SHARE_MODIFY")
-
-	page := 1
-	pr := dao.PagingRequest{PageNumber: page, PageSize: int(h.Conf.MaxPageSize)}
-
-	children, err := d.GetChildObjectsWithProperties(pr, applyable)
-	if err != nil {
-		logger.Error("error calling GetChildObjectsWithProperties", zap.Error(err))
-		return
-	}
-
-	for {
-		for _, child := range children.Objects {
-
-			gem.Payload.ObjectID = hex.EncodeToString(child.ID)
-			gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(child.ID))
-			auditOriginal := NewResourceFromObject(child)
-
-			if child.IsDeleted {
-				continue
-			}
-
-			ok, updatePermission := isUserAllowedToShareWithPermission(ctx, &child)
-			if !ok {
-				herr := NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User does not have permission to update this object")
-				h.publishError(gem, herr)
-				continue
-			}
-
-			if updatePermission.AcmGrantee.Grantee == "" {
-				logger.Error("grantee cannot be empty string", zap.Error(err))
-				gem.Payload.Audit = audit.WithActionResult(gem.Payload.Audit, "FAILURE")
-				h.EventQueue.Publish(gem)
-				continue
-			}
-
-			// newACM, err := aacAuth.InjectPermissionsIntoACM(applyable.Permissions, child.RawAcm.String)
-			newACM, err := aacAuth.InjectPermissionsIntoACM(applyable.Permissions, child.RawAcm.String)
-			if err != nil {
-				gem.Payload.Audit = audit.WithActionResult(gem.Payload.Audit, "FAILURE")
-				h.EventQueue.Publish(gem)
-				continue
-			}
-			// newPerms, newACM2, err := aacAuth.NormalizePermissionsFromACM(child.OwnedBy.String, child.Permissions, newACM, false)
-			newPerms, newACM2, err := aacAuth.NormalizePermissionsFromACM(child.OwnedBy.String, applyable.Permissions, newACM, false)
-			if err != nil {
-				gem.Payload.Audit = audit.WithActionResult(gem.Payload.Audit, "FAILURE")
-				h.EventQueue.Publish(gem)
-				continue
-			}
-			child.Permissions = newPerms
-
-			newerACM, err := aacAuth.RebuildACMFromPermissions(child.Permissions, newACM2)
-			if err != nil {
-				gem.Payload.Audit = audit.WithActionResult(gem.Payload.Audit, "FAILURE")
-				h.EventQueue.Publish(gem)
-				continue
-			}
-			child.RawAcm = models.ToNullString(newerACM)
-
-			if _, err := aacAuth.IsUserAuthorizedForACM(caller.DistinguishedName, child.RawAcm.String); err != nil {
-				logger.Error("error calling IsUserAuthorizedForACM", zap.Error(err))
-				continue
-			}
-			consolidateChangingPermissions(&child)
-			// Get around: Invalid MAC on permission
-			masterKey := ciphertext.FindCiphertextCacheByObject(nil).GetMasterKey()
-			for i, p := range child.Permissions {
-				models.CopyEncryptKey(masterKey, &updatePermission, &p)
-				models.CopyEncryptKey(masterKey, &updatePermission, &child.Permissions[i])
-			}
-			child.ModifiedBy = caller.DistinguishedName
-			err = d.UpdateObject(&child)
-			if err != nil {
-				logger.Error("error updating child object with new permissions", zap.Error(err))
-				continue
-			}
-
-			auditModified := NewResourceFromObject(child)
-			gem.Payload.Audit = audit.WithModifiedPairList(
-				gem.Payload.Audit, audit.NewModifiedResourcePair(auditOriginal, auditModified))
-			apiResponse := mapping.MapODObjectToObject(&child)
-			gem.Payload = events.WithEnrichedPayload(gem.Payload, apiResponse)
-			h.EventQueue.Publish(gem)
-			h.updateObjectRecursive(ctx, child)
-		}
-		page++
-		if page > children.PageCount {
-			break
-		}
-		pr.PageNumber = page
-		var err error
-		children, err = d.GetChildObjectsWithProperties(pr, applyable)
-		if err != nil {
-			logger.Error("error calling GetChildObjectsWithProperties", zap.Error(err))
-			return
+		if herr := h.queuePropagation(ctx, w, dbObject, propagationACM, nil); herr != nil {
+			h.publishError(gem, herr)
+			return herr
 		}
 	}
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
 }
 
 // parseUpdateObjectRequestAsJSON parses a request into our models object.
@@ -17,17 +17,12 @@ import (
 )
 
 func (h AppServer) updateObjectStream(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
-	var drainFunc func()
 	var requestObject models.ODObject
 	var err error
-	var recursive bool
 
 	logger := LoggerFromContext(ctx)
-	caller, _ := CallerFromContext(ctx)
-	dao := DAOFromContext(ctx)
 	captured, _ := CaptureGroupsFromContext(ctx)
 	gem, _ := GEMFromContext(ctx)
-	aacAuth := auth.NewAACAuth(logger, h.AAC)
 
 	gem.Action = "update"
 	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
@@ -46,70 +41,91 @@ func (h AppServer) updateObjectStream(ctx context.Context, w http.ResponseWriter
 	gem.Payload.ObjectID = captured["objectId"]
 	gem.Payload.Audit = audit.WithActionTarget(gem.Payload.Audit, NewAuditTargetForID(requestObject.ID))
 
+	dbObject, grant, herr := h.objectForStreamUpdate(ctx, requestObject)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	multipartReader, err := r.MultipartReader()
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "unable to open multipart reader")
+		h.publishError(gem, herr)
+		return herr
+	}
+	drainFunc, _, recursive, herr := h.acceptObjectUpload(ctx, multipartReader, &dbObject, &grant, false, nil)
+	dp := ciphertext.FindCiphertextCacheByObject(&dbObject)
+	if herr != nil {
+		herr := abortUploadObject(logger, dp, &dbObject, true, herr)
+		h.publishError(gem, herr)
+		return herr
+	}
+	return h.storeUpdatedObjectStream(ctx, w, gem, dbObject, grant, recursive, drainFunc)
+}
+
+// objectForStreamUpdate retrieves the object whose stream is being replaced,
+// assigning it a name and iv for the new content, and verifies that the caller
+// may update it. The permission granting update is returned to encrypt the
+// new content with.
+func (h AppServer) objectForStreamUpdate(ctx context.Context, requestObject models.ODObject) (models.ODObject, models.ODObjectPermission, *AppError) {
+	logger := LoggerFromContext(ctx)
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	aacAuth := auth.NewAACAuth(logger, h.AAC)
+	var grant models.ODObjectPermission
+
 	// Retrieve existing object from the data store
 	dbObject, err := dao.GetObject(requestObject, true)
 	if err != nil {
 		if err.Error() == db.ErrNoRows.Error() {
-			herr := NewAppError(http.StatusNotFound, err, "Not found")
-			h.publishError(gem, herr)
-			return herr
+			return dbObject, grant, NewAppError(http.StatusNotFound, err, "Not found")
 		}
-		herr := NewAppError(http.StatusInternalServerError, err, "Error retrieving object")
-		h.publishError(gem, herr)
-		return herr
+		return dbObject, grant, NewAppError(http.StatusInternalServerError, err, "Error retrieving object")
 	}
-	auditOriginal := NewResourceFromObject(dbObject)
 
 	if dbObject.IsDeleted {
 		switch {
 		case dbObject.IsExpunged:
-			herr := NewAppError(http.StatusGone, err, "The object no longer exists.")
-			h.publishError(gem, herr)
-			return herr
+			return dbObject, grant, NewAppError(http.StatusGone, err, "The object no longer exists.")
 		case dbObject.IsAncestorDeleted:
-			herr := NewAppError(http.StatusConflict, err, "The object cannot be modified because an ancestor is deleted.")
-			h.publishError(gem, herr)
-			return herr
+			return dbObject, grant, NewAppError(http.StatusConflict, err, "The object cannot be modified because an ancestor is deleted.")
 		default:
-			herr := NewAppError(http.StatusConflict, err, "The object is currently in the trash. Use removeObjectFromtrash to restore it before updating it.")
-			h.publishError(gem, herr)
-			return herr
+			return dbObject, grant, NewAppError(http.StatusConflict, err, "The object is currently in the trash. Use removeObjectFromtrash to restore it before updating it.")
 		}
 	}
 
-	// We need a name for the new text, and a new iv
-	dbObject.ContentConnector.String = ciphertext.CreateRandomName()
+	// We need a name for the new text, and a new iv. The zone of the object
+	// does not change, as its permissions are keyed to the zone.
+	dbObject.ContentConnector.String = ciphertext.NewContentConnector(ciphertext.ContentConnectorZone(dbObject.ContentConnector.String))
 	dbObject.EncryptIV = crypto.CreateIV()
 	// Check if the user has permissions to update the ODObject
-	var grant models.ODObjectPermission
 	var ok bool
 	if ok, grant = isUserAllowedToUpdateWithPermission(ctx, &dbObject); !ok {
-		herr := NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User does not have permission to update this object")
-		h.publishError(gem, herr)
-		return herr
+		return dbObject, grant, NewAppError(http.StatusForbidden, errors.New("Forbidden"), "Forbidden - User does not have permission to update this object")
 	}
 
 	// ACM check for whether user has permission to read this object
 	// from a clearance perspective
 	if _, err := aacAuth.IsUserAuthorizedForACM(caller.DistinguishedName, dbObject.RawAcm.String); err != nil {
-		herr := NewAppError(authHTTPErr(err), err, err.Error())
-		h.publishError(gem, herr)
-		return herr
+		return dbObject, grant, NewAppError(authHTTPErr(err), err, err.Error())
 	}
+	return dbObject, grant, nil
+}
+
+// storeUpdatedObjectStream completes the update of an object once its new
+// content stream has been accepted into the cache. The stream is drained to
+// permanent storage once the object is stored, and removed from the cache
+// should the update fail. The gem is published on completion.
+func (h AppServer) storeUpdatedObjectStream(ctx context.Context, w http.ResponseWriter, gem events.GEM, dbObject models.ODObject,
+	grant models.ODObjectPermission, recursive bool, drainFunc func()) *AppError {
+
+	logger := LoggerFromContext(ctx)
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	aacAuth := auth.NewAACAuth(logger, h.AAC)
+	var herr *AppError
 
-	multipartReader, err := r.MultipartReader()
-	if err != nil {
-		herr := NewAppError(http.StatusBadRequest, err, "unable to open multipart reader")
-		h.publishError(gem, herr)
-		return herr
-	}
-	drainFunc, _, recursive, herr := h.acceptObjectUpload(ctx, multipartReader, &dbObject, &grant, false, nil)
 	dp := ciphertext.FindCiphertextCacheByObject(&dbObject)
-	if herr != nil {
-		herr := abortUploadObject(logger, dp, &dbObject, true, herr)
-		h.publishError(gem, herr)
-		return herr
-	}
 	masterKey := dp.GetMasterKey()
 	modifiedPermissions, modifiedACM, err := aacAuth.NormalizePermissionsFromACM(dbObject.OwnedBy.String, dbObject.Permissions, dbObject.RawAcm.String, dbObject.IsCreating())
 	if err != nil {
@@ -127,12 +143,13 @@ func (h AppServer) updateObjectStream(ctx context.Context, w http.ResponseWriter
 	}
 
 	// Verify user has access to change the share if the ACMs are different
-	unchangedDbObject, err := dao.GetObject(requestObject, true)
+	unchangedDbObject, err := dao.GetObject(models.ODObject{ID: dbObject.ID}, true)
 	if err != nil {
 		herr = NewAppError(http.StatusInternalServerError, err, err.Error())
 		h.publishError(gem, herr)
 		return herr
 	}
+	auditOriginal := NewResourceFromObject(unchangedDbObject)
 	// If the "share" or "f_share" parts have changed, then check that the
 	// caller also has permission to share.
 	if diff, herr := isAcmShareDifferent(dbObject.RawAcm.String, unchangedDbObject.RawAcm.String); herr != nil || diff {
@@ -147,6 +164,21 @@ func (h AppServer) updateObjectStream(ctx context.Context, w http.ResponseWriter
 		}
 	}
 
+	// Share content already stored for this object rather than storing it
+	// again. The upload is kept until the object is stored.
+	uploaded := true
+	uploadedDrainFunc := drainFunc
+	deduped := deduplicateContent(ctx, &dbObject, &grant, false)
+	if deduped != nil {
+		uploaded = false
+		drainFunc = nil
+		defer func() {
+			if deduped != nil {
+				deduped.discard(logger, dp)
+			}
+		}()
+	}
+
 	consolidateChangingPermissions(&dbObject)
 	// copy grant.EncryptKey to all existing permissions:
 	for idx, permission := range dbObject.Permissions {
@@ -158,13 +190,20 @@ func (h AppServer) updateObjectStream(ctx context.Context, w http.ResponseWriter
 	if err := handleTypeName(ctx, h, &dbObject); err != nil {
 		herr := NewAppError(http.StatusInternalServerError, err, "error setting type for object")
 		h.publishError(gem, herr)
-		return abortUploadObject(logger, dp, &dbObject, true, herr)
+		return abortUploadObject(logger, dp, &dbObject, uploaded, herr)
 	}
 	err = dao.UpdateObject(&dbObject)
+	if deduped != nil && isContentReleased(err) {
+		storeDedupedUpload(ctx, deduped, masterKey, &dbObject, &grant)
+		deduped = nil
+		uploaded = true
+		drainFunc = uploadedDrainFunc
+		err = dao.UpdateObject(&dbObject)
+	}
 	if err != nil {
 		herr = NewAppError(http.StatusInternalServerError, err, "error storing object")
 		h.publishError(gem, herr)
-		return abortUploadObject(logger, dp, &dbObject, true, herr)
+		return abortUploadObject(logger, dp, &dbObject, uploaded, herr)
 	}
 	parents, err := dao.GetParents(dbObject)
 	if err != nil {
@@ -182,19 +221,25 @@ func (h AppServer) updateObjectStream(ctx context.Context, w http.ResponseWriter
 
 	auditModified := NewResourceFromObject(dbObject)
 	// Only start to upload into S3 after we have a database record
-	go drainFunc()
+	if drainFunc != nil {
+		go drainFunc()
+	}
 
 	apiResponse := mapping.MapODObjectToObject(&dbObject).WithCallerPermission(protocolCaller(caller)).WithBreadcrumbs(crumbs)
 
 	gem.Payload.ChangeToken = apiResponse.ChangeToken
-	gem.Payload.StreamUpdate = false
+	gem.Payload.StreamUpdate = true
 	gem.Payload.Audit = audit.WithModifiedPairList(gem.Payload.Audit, audit.NewModifiedResourcePair(auditOriginal, auditModified))
 	gem.Payload = events.WithEnrichedPayload(gem.Payload, apiResponse)
-	jsonResponse(w, apiResponse)
-	h.publishSuccess(gem, w)
 
+	// Apply the permissions to descendants in the background, as a job located by the response
 	if recursive {
-		go h.updateObjectRecursive(ctx, dbObject)
+		if herr := h.queuePropagation(ctx, w, dbObject, propagationACM, nil); herr != nil {
+			h.publishError(gem, herr)
+			return herr
+		}
 	}
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
 	return nil
 }
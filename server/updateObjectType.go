@@ -0,0 +1,80 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+// updateObjectType replaces the property definitions declared for a type.
+// Only callers configured as type administrators may do so.
+func (h AppServer) updateObjectType(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	d := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventModify")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "MODIFY")
+
+	if !whitelistContains(h.TypeAdmins, caller.DistinguishedName) {
+		herr := NewAppError(http.StatusForbidden, errors.New("forbidden"), "Forbidden - User is not permitted to manage types")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	typeName, err := parseTypeNameRequest(ctx)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, err.Error())
+		h.publishError(gem, herr)
+		return herr
+	}
+	definitions, err := parseObjectTypeRequest(r)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, err.Error())
+		h.publishError(gem, herr)
+		return herr
+	}
+	if violations := TypeDefinitionViolations(definitions); len(violations) > 0 {
+		msg := "invalid property definitions: " + strings.Join(violations, "; ")
+		herr := NewAppError(http.StatusBadRequest, errors.New(msg), msg)
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	objectType, err := d.GetObjectTypeByName(typeName, true, caller.DistinguishedName)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "Error retrieving type")
+		h.publishError(gem, herr)
+		return herr
+	}
+	objectType.ModifiedBy = caller.DistinguishedName
+	objectType.TypeProperties, err = d.SetObjectTypeProperties(objectType, definitions)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "Error saving properties for type")
+		h.publishError(gem, herr)
+		return herr
+	}
+	forgetTypeProperties(h, objectType)
+
+	apiResponse := mapping.MapODObjectTypeToObjectType(&objectType)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+func parseObjectTypeRequest(r *http.Request) ([]models.ODObjectTypeProperty, error) {
+	var jsonObjectType protocol.ObjectType
+	if err := util.FullDecode(r.Body, &jsonObjectType); err != nil {
+		return nil, err
+	}
+	return mapping.MapTypePropertiesToODObjectTypeProperties(&jsonObjectType.Properties)
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+func (h AppServer) updateSubscription(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+
+	caller, _ := CallerFromContext(ctx)
+	dao := DAOFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+
+	dbSubscription, herr := commonSubscriptionPrep(ctx)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	gem.Payload.ObjectID = hex.EncodeToString(dbSubscription.ObjectID)
+
+	requestSubscription, err := parseSubscriptionRequest(r, h.Webhook)
+	if err != nil {
+		herr := NewAppError(http.StatusBadRequest, err, "Error parsing request")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	dbSubscription.OnCreate = requestSubscription.OnCreate
+	dbSubscription.OnUpdate = requestSubscription.OnUpdate
+	dbSubscription.OnDelete = requestSubscription.OnDelete
+	dbSubscription.Recursive = requestSubscription.Recursive
+	dbSubscription.CallbackURL = requestSubscription.CallbackURL
+	dbSubscription.ModifiedBy = caller.DistinguishedName
+	dbSubscription, err = dao.UpdateSubscription(dbSubscription)
+	if err != nil {
+		herr := NewAppError(http.StatusInternalServerError, err, "error updating subscription")
+		h.publishError(gem, herr)
+		return herr
+	}
+
+	apiResponse := mapping.MapODUserObjectSubscriptionToSubscription(&dbSubscription)
+	jsonResponse(w, apiResponse)
+	h.publishSuccess(gem, w)
+	return nil
+}
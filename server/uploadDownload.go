@@ -250,7 +250,7 @@ func (h AppServer) beginUploadTimed(ctx context.Context, caller Caller, part *mu
 	logger.Debug("within timed upload")
 	dp := ciphertext.FindCiphertextCacheByObject(obj)
 	masterKey := dp.GetMasterKey()
-	fileID := ciphertext.FileId(obj.ContentConnector.String)
+	fileID := ciphertext.ContentConnectorFileId(obj.ContentConnector.String)
 	iv := obj.EncryptIV
 	// TODO this is where we actually use grant.
 	fileKey := crypto.ApplyPassphrase(masterKey, grant.PermissionIV, grant.EncryptKey)
@@ -270,7 +270,7 @@ func (h AppServer) beginUploadTimed(ctx context.Context, caller Caller, part *mu
 
 	// Write the encrypted data to the filesystem
 	byteRange := crypto.NewByteRange()
-	checksum, length, err := h.Conf.EncryptableFunctions.DoCipherByReaderWriter(logger, part, outFile, fileKey, iv, "uploading from browser", byteRange)
+	checksum, length, err := h.Conf.EncryptableFunctions.DoSealByReaderWriter(logger, part, outFile, fileKey, iv, "uploading from browser", byteRange)
 	if err != nil {
 		// It could be the client's fault.  Check the message.
 		msg := fmt.Sprintf("Unable to write ciphertext %s", outFileUploading)
@@ -298,10 +298,12 @@ func (h AppServer) beginUploadTimed(ctx context.Context, caller Caller, part *mu
 	// Record metadata
 	obj.ContentHash = checksum
 	obj.ContentSize.Int64 = length
+	obj.CipherFormat = h.Conf.EncryptableFunctions.CipherFormat
+	cipherLength := crypto.CiphertextSize(obj.CipherFormat, length)
 
 	// At successful conclusion of this writeback, the provider will have stored in S3
 	// and renamed the file to reflect the FileStateCached
-	return func() { h.Writeback(obj, fileID, length) }, nil, err
+	return func() { h.Writeback(obj, fileID, cipherLength) }, nil, err
 }
 
 // Writeback wraps the drain provider Writeback with performance tracking
@@ -0,0 +1,301 @@
+package server
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/ciphertext"
+	"bitbucket.di2e.net/dime/object-drive-server/crypto"
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+)
+
+const (
+	// uploadSessionExtension is appended to the name of the content being
+	// received into the cache for the file that records the upload session
+	uploadSessionExtension = ".session"
+	// uploadSealingExtension is appended to the name of the content being
+	// received for the file it is sealed into when the session is committed
+	uploadSealingExtension = ".sealing"
+	// uploadSessionLifetime is how long an upload session is kept after the
+	// last chunk was received before it is discarded
+	uploadSessionLifetime = 24 * time.Hour
+)
+
+// uploadSession is the state of a resumable upload. It is kept in the cache
+// beside the content as it is received, so requests for a session must be
+// made to the same instance.
+type uploadSession struct {
+	// ID is the content connector the content is received under
+	ID           string    `json:"id"`
+	CreatedBy    string    `json:"createdBy"`
+	CreatedDate  time.Time `json:"createdDate"`
+	ModifiedDate time.Time `json:"modifiedDate"`
+	ContentSize  int64     `json:"contentSize"`
+	EncryptIV    []byte    `json:"encryptIV"`
+	// PermissionIV and EncryptKey are from the permission that the content is
+	// encrypted with, which is itself encrypted with the master key. KeyCheck
+	// identifies the master key, in case it is rotated during the session.
+	PermissionIV []byte                                 `json:"permissionIV"`
+	EncryptKey   []byte                                 `json:"encryptKey"`
+	KeyCheck     []byte                                 `json:"keyCheck,omitempty"`
+	Create       *protocol.CreateObjectRequest          `json:"create,omitempty"`
+	Update       *protocol.UpdateObjectAndStreamRequest `json:"update,omitempty"`
+	Chunks       []protocol.UploadChunk                 `json:"chunks"`
+}
+
+// uploadSessionLock serializes changes to the recorded state of upload
+// sessions. Content is written outside of the lock.
+var uploadSessionLock sync.Mutex
+
+// uploadSessionCache returns the cache that the session is received into
+func uploadSessionCache(id string) ciphertext.CiphertextCache {
+	return ciphertext.FindCiphertextCacheByObject(&models.ODObject{ContentConnector: models.ToNullString(id)})
+}
+
+func uploadSessionFileName(id string, ext string) ciphertext.FileNameCached {
+	dp := uploadSessionCache(id)
+	return dp.Resolve(ciphertext.NewFileName(ciphertext.ContentConnectorFileId(id), ext))
+}
+
+// saveUploadSession records the state of the session, replacing any prior state
+func saveUploadSession(session *uploadSession) error {
+	return saveUploadState(session.ID, uploadSessionExtension, session)
+}
+
+// saveUploadState records state kept beside content being received into the
+// cache, replacing any prior state
+func saveUploadState(id string, ext string, state interface{}) error {
+	dp := uploadSessionCache(id)
+	name := uploadSessionFileName(id, ext)
+	saving := uploadSessionFileName(id, ext+ciphertext.FileStateUploading)
+	f, err := dp.Files().Create(saving)
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(f).Encode(state)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		dp.Files().Remove(saving)
+		return err
+	}
+	return dp.Files().Rename(saving, name)
+}
+
+// loadUploadSession retrieves the session belonging to the caller. Sessions
+// belonging to others are reported as not found, and expired sessions are
+// discarded.
+func loadUploadSession(ctx context.Context, id string) (*uploadSession, *AppError) {
+	caller, _ := CallerFromContext(ctx)
+	dp := uploadSessionCache(id)
+	f, err := dp.Files().Open(uploadSessionFileName(id, uploadSessionExtension))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, NewAppError(http.StatusNotFound, err, "upload session not found")
+		}
+		return nil, NewAppError(http.StatusInternalServerError, err, "error retrieving upload session")
+	}
+	defer f.Close()
+	var session uploadSession
+	if err := json.NewDecoder(f).Decode(&session); err != nil {
+		return nil, NewAppError(http.StatusInternalServerError, err, "error reading upload session")
+	}
+	if session.CreatedBy != caller.DistinguishedName {
+		return nil, NewAppError(http.StatusNotFound, errors.New("upload session belongs to another user"), "upload session not found")
+	}
+	if time.Now().After(session.ModifiedDate.Add(uploadSessionLifetime)) {
+		discardUploadSession(LoggerFromContext(ctx), id)
+		return nil, NewAppError(http.StatusNotFound, errors.New("upload session expired"), "upload session has expired")
+	}
+	return &session, nil
+}
+
+// discardUploadSession removes the session and any content received
+func discardUploadSession(logger *zap.Logger, id string) {
+	dp := uploadSessionCache(id)
+	for _, ext := range []string{uploadSessionExtension, ciphertext.FileStateUploading, uploadSealingExtension} {
+		if err := dp.Files().Remove(uploadSessionFileName(id, ext)); err != nil && !os.IsNotExist(err) {
+			logger.Warn("unable to remove upload session file", zap.String("id", id), zap.String("ext", ext), zap.Error(err))
+		}
+	}
+}
+
+// fileKey decrypts the key that the content of the session is encrypted with
+func (session *uploadSession) fileKey() []byte {
+	masterKey := stagedMasterKey(uploadSessionCache(session.ID), session.PermissionIV, session.KeyCheck)
+	return crypto.ApplyPassphrase(masterKey, session.PermissionIV, session.EncryptKey)
+}
+
+// addChunk records a chunk as received, replacing any chunk of the same number
+func (session *uploadSession) addChunk(chunk protocol.UploadChunk) {
+	for i := range session.Chunks {
+		if session.Chunks[i].Number == chunk.Number {
+			session.Chunks[i] = chunk
+			return
+		}
+	}
+	session.Chunks = append(session.Chunks, chunk)
+	sort.Slice(session.Chunks, func(i, j int) bool { return session.Chunks[i].Number < session.Chunks[j].Number })
+}
+
+// isComplete indicates whether every byte of the content has been received
+func (session *uploadSession) isComplete() bool {
+	received := UploadReceivedRanges(session.Chunks)
+	return len(received) == 1 && received[0].Start == 0 && received[0].Stop == session.ContentSize-1
+}
+
+func (session *uploadSession) toProtocol() protocol.UploadSession {
+	received := UploadReceivedRanges(session.Chunks)
+	var receivedSize int64
+	for _, byteRange := range received {
+		receivedSize += byteRange.Stop - byteRange.Start + 1
+	}
+	apiResponse := protocol.UploadSession{
+		ID:           session.ID,
+		CreatedDate:  session.CreatedDate,
+		ExpiresDate:  session.ModifiedDate.Add(uploadSessionLifetime),
+		ContentSize:  session.ContentSize,
+		ReceivedSize: receivedSize,
+		Received:     received,
+		Chunks:       session.Chunks,
+	}
+	if session.Update != nil {
+		apiResponse.ObjectID = session.Update.ID
+	}
+	if apiResponse.Chunks == nil {
+		apiResponse.Chunks = []protocol.UploadChunk{}
+	}
+	return apiResponse
+}
+
+// UploadReceivedRanges merges the chunks of an upload into the ranges of the
+// content received, in order and without overlap.
+func UploadReceivedRanges(chunks []protocol.UploadChunk) []protocol.ByteRange {
+	var ranges []protocol.ByteRange
+	for _, chunk := range chunks {
+		if chunk.Length > 0 {
+			ranges = append(ranges, protocol.ByteRange{Start: chunk.Offset, Stop: chunk.Offset + chunk.Length - 1})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	merged := []protocol.ByteRange{}
+	for _, byteRange := range ranges {
+		last := len(merged) - 1
+		if last >= 0 && byteRange.Start <= merged[last].Stop+1 {
+			if byteRange.Stop > merged[last].Stop {
+				merged[last].Stop = byteRange.Stop
+			}
+			continue
+		}
+		merged = append(merged, byteRange)
+	}
+	return merged
+}
+
+// writeUploadChunk encrypts the chunk into the file at its offset within the
+// content. As the content is encrypted in CTR mode, the iv is advanced to the
+// block containing the offset, and the keystream is discarded up to the
+// offset within that block. Returns the length of the chunk.
+func (h AppServer) writeUploadChunk(logger *zap.Logger, f *os.File, chunk io.Reader, key []byte, iv []byte, offset int64) (int64, error) {
+	byteRange := &crypto.ByteRange{Start: offset, Stop: -1}
+	chunkIV := adjustIV(iv, byteRange)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	// The cipher is applied to padding up to the offset, which is then
+	// discarded, so that only the chunk is written.
+	padded := io.MultiReader(bytes.NewReader(make([]byte, byteRange.Start)), chunk)
+	_, length, err := h.Conf.EncryptableFunctions.DoCipherByReaderWriter(logger, padded, f, key, chunkIV, "uploading chunk", byteRange)
+	return length - byteRange.Start, err
+}
+
+// sealUploadedContent decrypts the received content, which is encrypted in
+// CTR mode so that chunks may be written at any offset, and seals it with a
+// new iv as content received in a single request would be. Returns the
+// checksum of the plaintext, which cannot be taken as chunks may arrive in
+// any order.
+func (h AppServer) sealUploadedContent(logger *zap.Logger, in io.Reader, out io.Writer, key []byte, chunkIV []byte, iv []byte) ([]byte, error) {
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		_, _, err := h.Conf.EncryptableFunctions.DoCipherByReaderWriter(logger, in, pw, key, chunkIV, "reading upload", crypto.NewByteRange())
+		pw.CloseWithError(err)
+	}()
+	checksum, _, err := h.Conf.EncryptableFunctions.DoSealByReaderWriter(logger, pr, out, key, iv, "sealing upload", crypto.NewByteRange())
+	return checksum, err
+}
+
+// uploadSessionObject maps the request of an upload session to the object it
+// creates or updates, verifying that the caller may do so. The permission the
+// content is encrypted with is also returned, along with the path delimiter
+// for intermediate folders when creating.
+func (h AppServer) uploadSessionObject(ctx context.Context, request protocol.UploadSessionRequest) (models.ODObject, models.ODObjectPermission, string, *AppError) {
+	caller, _ := CallerFromContext(ctx)
+	var obj models.ODObject
+	var grant models.ODObjectPermission
+	var pathDelimiter string
+	switch {
+	case request.Create != nil && request.Update == nil:
+		if err := mapping.OverwriteODObjectWithCreateObjectRequest(&obj, request.Create); err != nil {
+			return obj, grant, "", NewAppError(http.StatusBadRequest, err, fmt.Sprintf("Error creating object with data from request: %s", err.Error()))
+		}
+		if herr := handleCreatePrerequisites(ctx, h, &obj); herr != nil {
+			return obj, grant, "", herr
+		}
+		if len(obj.ContentType.String) == 0 {
+			obj.ContentType = models.ToNullString(GetContentTypeFromFilename(obj.Name))
+		}
+		grant = permissionWithOwnerDefaults(caller)
+		pathDelimiter = request.Create.NamePathDelimiter
+	case request.Update != nil && request.Create == nil:
+		id, err := hex.DecodeString(request.Update.ID)
+		if err != nil || len(id) == 0 {
+			return obj, grant, "", NewAppError(http.StatusBadRequest, errors.New("invalid id"), "An object id must be specified to update")
+		}
+		var herr *AppError
+		obj, grant, herr = h.objectForStreamUpdate(ctx, models.ODObject{ID: id})
+		if herr != nil {
+			return obj, grant, "", herr
+		}
+		if obj.ChangeToken != request.Update.ChangeToken {
+			return obj, grant, "", NewAppError(http.StatusConflict, nil, "Changetoken must be up to date")
+		}
+		if err := mapping.OverwriteODObjectWithUpdateObjectAndStreamRequest(&obj, request.Update); err != nil {
+			return obj, grant, "", NewAppError(http.StatusBadRequest, err, fmt.Sprintf("Could not extract data from json response: %s", err.Error()))
+		}
+	default:
+		return obj, grant, "", NewAppError(http.StatusBadRequest, errors.New("create or update required"), "Exactly one of create or update must be specified")
+	}
+	// Whether creating or updating, the ACM must have a value
+	if len(obj.RawAcm.String) == 0 {
+		return obj, grant, "", NewAppError(http.StatusBadRequest, nil, "An ACM must be specified")
+	}
+	return obj, grant, pathDelimiter, nil
+}
+
+// uploadSessionContentSize is the size of the content given in the request of
+// an upload session
+func uploadSessionContentSize(request protocol.UploadSessionRequest) int64 {
+	if request.Create != nil {
+		return request.Create.ContentSize
+	}
+	if request.Update != nil {
+		return request.Update.ContentSize
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package server_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/server"
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func TestUploadReceivedRanges(t *testing.T) {
+	if received := server.UploadReceivedRanges(nil); received == nil || len(received) != 0 {
+		t.Errorf("expected empty ranges for no chunks, got %v", received)
+	}
+	chunks := []protocol.UploadChunk{
+		{Number: 3, Offset: 40, Length: 10},
+		{Number: 1, Offset: 0, Length: 10},
+		{Number: 2, Offset: 10, Length: 5},
+		{Number: 4, Offset: 45, Length: 20},
+		{Number: 5, Offset: 100, Length: 0},
+	}
+	received := server.UploadReceivedRanges(chunks)
+	expected := []protocol.ByteRange{{Start: 0, Stop: 14}, {Start: 40, Stop: 64}}
+	if len(received) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, received)
+	}
+	for i := range expected {
+		if received[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, received)
+		}
+	}
+}
+
+func TestUploadSessionOutOfOrder(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	tester10 := 0
+	content := []byte("The quick brown fox jumps over the lazy dog, resumably and out of order.")
+
+	create := protocol.CreateObjectRequest{
+		Name:        "TestUploadSessionOutOfOrder.txt",
+		TypeName:    "File",
+		RawAcm:      ValidACMUnclassified,
+		ContentType: "text/plain",
+		ContentSize: int64(len(content)),
+	}
+	req := makeHTTPRequestFromInterface(t, "POST", mountPoint+"/uploads", protocol.UploadSessionRequest{Create: &create})
+	resp, err := clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 200, resp, "expected upload session to be created")
+	var session protocol.UploadSession
+	err = util.FullDecode(resp.Body, &session)
+	failNowOnErr(t, err, "unable to decode upload session")
+
+	// Chunks that are not aligned to the cipher block size, sent last to first
+	chunks := []protocol.UploadChunk{{Number: 1, Offset: 0, Length: 7}, {Number: 2, Offset: 7, Length: 30}, {Number: 3, Offset: 37}}
+	chunks[2].Length = int64(len(content)) - chunks[2].Offset
+	for i := len(chunks) - 1; i >= 0; i-- {
+		chunk := chunks[i]
+		uri := fmt.Sprintf("%s/uploads/%s/chunks/%d?offset=%d", mountPoint, session.ID, chunk.Number, chunk.Offset)
+		req, err := http.NewRequest("PUT", uri, bytes.NewReader(content[chunk.Offset:chunk.Offset+chunk.Length]))
+		failNowOnErr(t, err, "unable to create request")
+		resp, err := clients[tester10].Client.Do(req)
+		failNowOnErr(t, err, "unable to do request")
+		statusMustBe(t, 200, resp, "expected chunk to be received")
+		util.FinishBody(resp.Body)
+	}
+
+	req = makeHTTPRequestFromInterface(t, "GET", mountPoint+"/uploads/"+session.ID, nil)
+	resp, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 200, resp, "expected upload session to be found")
+	err = util.FullDecode(resp.Body, &session)
+	failNowOnErr(t, err, "unable to decode upload session")
+	if session.ReceivedSize != int64(len(content)) || len(session.Received) != 1 || len(session.Chunks) != len(chunks) {
+		t.Fatalf("expected all content received, got %+v", session)
+	}
+
+	req = makeHTTPRequestFromInterface(t, "POST", mountPoint+"/uploads/"+session.ID+"/commit", nil)
+	resp, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 200, resp, "expected upload to be committed")
+	var created protocol.Object
+	err = util.FullDecode(resp.Body, &created)
+	failNowOnErr(t, err, "unable to decode object")
+	if created.ContentSize != int64(len(content)) {
+		t.Errorf("expected contentSize %d, got %d", len(content), created.ContentSize)
+	}
+
+	req, err = NewGetObjectStreamRequest(created.ID, "")
+	failNowOnErr(t, err, "unable to create request")
+	resp, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 200, resp, "expected stream of committed object")
+	defer util.FinishBody(resp.Body)
+	stream, err := ioutil.ReadAll(resp.Body)
+	failNowOnErr(t, err, "unable to read stream")
+	if !bytes.Equal(stream, content) {
+		t.Errorf("expected stream %q, got %q", content, stream)
+	}
+
+	// The session no longer exists once committed
+	req = makeHTTPRequestFromInterface(t, "GET", mountPoint+"/uploads/"+session.ID, nil)
+	resp, err = clients[tester10].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	statusMustBe(t, 404, resp, "expected committed upload session to be gone")
+	util.FinishBody(resp.Body)
+}
@@ -0,0 +1,802 @@
+package server
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"bitbucket.di2e.net/dime/object-drive-server/dao"
+	"bitbucket.di2e.net/dime/object-drive-server/mapping"
+	"bitbucket.di2e.net/dime/object-drive-server/metadata/models"
+	"bitbucket.di2e.net/dime/object-drive-server/protocol"
+	"bitbucket.di2e.net/dime/object-drive-server/services/audit"
+)
+
+const (
+	// davGroupsName is the name of the collection at the root of the WebDAV
+	// namespace holding a collection for each group of the caller, as with
+	// /files/groupobjects
+	davGroupsName = "groupobjects"
+	// davMethods are the methods supported under the WebDAV prefix
+	davMethods = "OPTIONS, PROPFIND, PROPPATCH, GET, HEAD, PUT, MKCOL, MOVE, COPY, DELETE, LOCK, UNLOCK"
+	// davMaxRequestSize bounds the XML body of WebDAV requests
+	davMaxRequestSize = 1 << 20
+)
+
+// davResource is a resource in the WebDAV namespace. This is the root of the
+// objects owned by the caller, the collection of groups, the root of the
+// objects owned by a group, or an object.
+type davResource struct {
+	// Path is the escaped path of the resource beneath the prefix, beginning
+	// with /
+	Path string
+	// Groups is set for the collection of groups
+	Groups bool
+	// Group is the grantee of the group whose root this is
+	Group string
+	// Object is the object at the path, having no ID for the roots
+	Object models.ODObject
+}
+
+func (res davResource) isObject() bool {
+	return len(res.Object.ID) > 0
+}
+
+// isCollection reports whether the resource may have members. Objects are
+// collections if they are folders, or have no content and are not files.
+func (res davResource) isCollection() bool {
+	if !res.isObject() {
+		return true
+	}
+	typeName := res.Object.TypeName.String
+	if strings.EqualFold(typeName, "Folder") {
+		return true
+	}
+	return res.Object.ContentSize.Int64 <= 0 && !strings.EqualFold(typeName, "File")
+}
+
+// name returns the unescaped final segment of the path of the resource
+func (res davResource) name() string {
+	if res.isObject() {
+		return res.Object.Name
+	}
+	if len(res.Group) > 0 {
+		return res.Group
+	}
+	if res.Groups {
+		return davGroupsName
+	}
+	return ""
+}
+
+// davResolution is the result of resolving a WebDAV path: the resource at the
+// path, if there is one, and the collection it is or would be a member of.
+type davResolution struct {
+	// Path is the escaped path beneath the prefix, beginning with /
+	Path string
+	// Name is the unescaped final segment of the path
+	Name string
+	// Parent is the collection holding the path, or nil for the root or if
+	// some collection leading to the path does not exist
+	Parent *davResource
+	// Resource is the resource at the path, or nil if there is none
+	Resource *davResource
+}
+
+// isWebDAVPath reports whether the path of a request is served by the WebDAV
+// front end
+func (h AppServer) isWebDAVPath(uri string) bool {
+	prefix := h.WebDAV.Prefix
+	return len(prefix) > 0 && (uri == prefix || strings.HasPrefix(uri, prefix+"/"))
+}
+
+// davSegments splits the escaped path of a request beneath the prefix into
+// its unescaped segments
+func davSegments(prefix string, escapedPath string) ([]string, bool) {
+	if escapedPath != prefix && !strings.HasPrefix(escapedPath, prefix+"/") {
+		return nil, false
+	}
+	var segments []string
+	for _, segment := range strings.Split(strings.TrimPrefix(escapedPath, prefix), "/") {
+		switch segment {
+		case "", ".":
+			continue
+		case "..":
+			return nil, false
+		}
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, false
+		}
+		segments = append(segments, unescaped)
+	}
+	return segments, true
+}
+
+// davJoin appends an unescaped name to an escaped path
+func davJoin(path string, name string) string {
+	return strings.TrimSuffix(path, "/") + "/" + url.PathEscape(name)
+}
+
+// serveWebDAV serves the WebDAV front end, through which objects may be
+// accessed by their path, such as when mapped as a network drive. Paths
+// resolve as with /files, and changes are delegated to the handlers of the
+// API, so that they are authorized and audited just as when made through it.
+func (h AppServer) serveWebDAV(ctx context.Context, w http.ResponseWriter, r *http.Request) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	gem.Action = "access"
+	gem.Payload.Audit = audit.WithType(gem.Payload.Audit, "EventAccess")
+	gem.Payload.Audit = audit.WithAction(gem.Payload.Audit, "ACCESS")
+	ctx = ContextWithGEM(ctx, gem)
+
+	segments, ok := davSegments(h.WebDAV.Prefix, r.URL.EscapedPath())
+	if !ok {
+		return h.davFail(ctx, http.StatusNotFound, errors.New("invalid path"), "Resource not found")
+	}
+	switch r.Method {
+	case "OPTIONS":
+		h.davOptions(w)
+		return nil
+	case "GET", "HEAD", "PROPFIND":
+	default:
+		if h.RootDAO.IsReadOnly(false) {
+			msg := "Service Unavailable. Operation not permitted until database schema upgrade completed. Service operating in read-only mode."
+			return h.davFail(ctx, http.StatusServiceUnavailable, errors.New(msg), msg)
+		}
+	}
+	res, herr := h.resolveDAVPath(ctx, davResource{Path: "/"}, segments)
+	if herr != nil {
+		h.publishError(gem, herr)
+		return herr
+	}
+	switch r.Method {
+	case "PROPFIND":
+		return h.davPropfind(ctx, w, r, res)
+	case "PROPPATCH":
+		return h.davProppatch(ctx, w, r, res)
+	case "GET", "HEAD":
+		return h.davGet(ctx, w, r, res)
+	case "PUT":
+		return h.davPut(ctx, w, r, res)
+	case "MKCOL":
+		return h.davMkcol(ctx, w, r, res)
+	case "DELETE":
+		return h.davDeleteResource(ctx, w, r, res)
+	case "COPY":
+		return h.davCopyOrMove(ctx, w, r, res, false)
+	case "MOVE":
+		return h.davCopyOrMove(ctx, w, r, res, true)
+	case "LOCK":
+		return h.davLock(ctx, w, r, res)
+	case "UNLOCK":
+		return h.davUnlock(ctx, w, r, res)
+	}
+	w.Header().Set("Allow", davMethods)
+	return h.davFail(ctx, http.StatusMethodNotAllowed, errors.New("method not allowed"), "Method not supported for WebDAV")
+}
+
+// davFail publishes the failure of a WebDAV request. Failures of the handlers
+// that requests are delegated to are published by those handlers.
+func (h AppServer) davFail(ctx context.Context, code int, err error, msg string) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	herr := NewAppError(code, err, msg)
+	h.publishError(gem, herr)
+	return herr
+}
+
+// davOptions advertises WebDAV support, including locking
+func (h AppServer) davOptions(w http.ResponseWriter) {
+	w.Header().Set("DAV", "1, 2")
+	w.Header().Set("MS-Author-Via", "DAV")
+	w.Header().Set("Allow", davMethods)
+}
+
+// resolveDAVPath resolves the segments of a path beneath the collection to the
+// resource there and the collection holding it
+func (h AppServer) resolveDAVPath(ctx context.Context, current davResource, segments []string) (davResolution, *AppError) {
+	if len(segments) == 0 {
+		return davResolution{Path: current.Path, Resource: &current}, nil
+	}
+	res := davResolution{}
+	for i, name := range segments {
+		child, found, herr := h.davChild(ctx, current, name)
+		if herr != nil {
+			return res, herr
+		}
+		res.Path = davJoin(current.Path, name)
+		res.Name = name
+		if i == len(segments)-1 {
+			parent := current
+			res.Parent = &parent
+			if found {
+				res.Resource = &child
+			}
+			return res, nil
+		}
+		if !found || !child.isCollection() {
+			// Neither the path nor its parent exist
+			res.Path = davJoin(res.Path, strings.Join(segments[i+1:], "/"))
+			res.Name = segments[len(segments)-1]
+			return res, nil
+		}
+		current = child
+	}
+	return res, nil
+}
+
+// davGroups returns the groups of the caller that may own objects
+func davGroups(ctx context.Context) []string {
+	groups, _ := GroupsFromContext(ctx)
+	everyone := models.AACFlatten("-Everyone")
+	var names []string
+	for _, group := range groups {
+		if models.AACFlatten(group) != everyone {
+			names = append(names, group)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// davChild finds the member of a collection with the name
+func (h AppServer) davChild(ctx context.Context, parent davResource, name string) (davResource, bool, *AppError) {
+	user, _ := UserFromContext(ctx)
+	d := DAOFromContext(ctx)
+	path := davJoin(parent.Path, name)
+	switch {
+	case parent.Groups:
+		for _, group := range davGroups(ctx) {
+			if strings.EqualFold(group, name) {
+				return davResource{Path: path, Group: group}, true, nil
+			}
+		}
+		return davResource{}, false, nil
+	case !parent.isObject() && len(parent.Group) == 0 && strings.EqualFold(name, davGroupsName):
+		// NOTE: As with /files, an object named groupobjects at the root is hidden by the groups
+		return davResource{Path: path, Groups: true}, true, nil
+	}
+	// Names are matched exactly among the objects with a similar name
+	pagingRequest := dao.PagingRequest{
+		PageSize:       int(h.Conf.MaxPageSize),
+		FilterSettings: []dao.FilterSetting{dao.FilterSetting{FilterField: "name", Condition: "equals", Expression: name}},
+	}
+	var resultset models.ODObjectResultset
+	var err error
+	switch {
+	case parent.isObject():
+		resultset, err = d.GetChildObjectsByUser(user, pagingRequest, parent.Object)
+	case len(parent.Group) > 0:
+		resultset, err = d.GetRootObjectsByGroup(parent.Group, user, pagingRequest)
+	default:
+		resultset, err = d.GetRootObjectsByUser(user, pagingRequest)
+	}
+	if err != nil {
+		return davResource{}, false, NewAppError(http.StatusInternalServerError, err, "Error finding object by name")
+	}
+	for _, object := range resultset.Objects {
+		if object.Name == name {
+			return davResource{Path: path, Object: object}, true, nil
+		}
+	}
+	return davResource{}, false, nil
+}
+
+// davChildren returns the members of a collection, in order of name
+func (h AppServer) davChildren(ctx context.Context, parent davResource) ([]davResource, *AppError) {
+	user, _ := UserFromContext(ctx)
+	d := DAOFromContext(ctx)
+	var children []davResource
+	if parent.Groups {
+		for _, group := range davGroups(ctx) {
+			children = append(children, davResource{Path: davJoin(parent.Path, group), Group: group})
+		}
+		return children, nil
+	}
+	if !parent.isObject() && len(parent.Group) == 0 {
+		children = append(children, davResource{Path: davJoin(parent.Path, davGroupsName), Groups: true})
+	}
+	pagingRequest := dao.PagingRequest{
+		PageNumber:   1,
+		PageSize:     int(h.Conf.MaxPageSize),
+		SortSettings: []dao.SortSetting{dao.SortSetting{SortField: "name", SortAscending: true}},
+	}
+	for {
+		var resultset models.ODObjectResultset
+		var err error
+		switch {
+		case parent.isObject():
+			resultset, err = d.GetChildObjectsByUser(user, pagingRequest, parent.Object)
+		case len(parent.Group) > 0:
+			resultset, err = d.GetRootObjectsByGroup(parent.Group, user, pagingRequest)
+		default:
+			resultset, err = d.GetRootObjectsByUser(user, pagingRequest)
+		}
+		if err != nil {
+			return nil, NewAppError(http.StatusInternalServerError, err, "Error listing objects")
+		}
+		for _, object := range resultset.Objects {
+			children = append(children, davResource{Path: davJoin(parent.Path, object.Name), Object: object})
+		}
+		if pagingRequest.PageNumber >= resultset.PageCount {
+			return children, nil
+		}
+		pagingRequest.PageNumber++
+	}
+}
+
+// davOwnsRoot reports whether an object with the owner may be placed at a
+// root, as the objects at the root of the caller or a group are those owned
+// by it
+func davOwnsRoot(ctx context.Context, root davResource, ownedBy string) bool {
+	owner := models.NewODAcmGranteeFromResourceName(ownedBy)
+	if len(root.Group) > 0 {
+		return owner.Grantee == models.AACFlatten(root.Group)
+	}
+	caller, _ := CallerFromContext(ctx)
+	return owner.Grantee == models.AACFlatten(caller.DistinguishedName)
+}
+
+// davResponse collects the response of an API handler that a WebDAV request
+// is delegated to, as the WebDAV client is sent a response of its own.
+type davResponse struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (d *davResponse) Header() http.Header {
+	return d.header
+}
+
+func (d *davResponse) Write(p []byte) (int, error) {
+	return d.body.Write(p)
+}
+
+func (d *davResponse) WriteHeader(code int) {}
+
+// davDelegate invokes an API handler on behalf of a WebDAV request, with the
+// capture groups and body the handler expects, returning the object in its
+// response. Each delegated request is audited as an event of its own.
+func (h AppServer) davDelegate(ctx context.Context, r *http.Request, handler func(context.Context, http.ResponseWriter, *http.Request) *AppError,
+	captured map[string]string, contentType string, body io.Reader) (protocol.Object, *AppError) {
+
+	var object protocol.Object
+	req, err := http.NewRequest(r.Method, r.URL.String(), body)
+	if err != nil {
+		return object, h.davFail(ctx, http.StatusInternalServerError, err, "Error preparing request")
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", r.UserAgent())
+	req.RemoteAddr = r.RemoteAddr
+	gem, _ := GEMFromContext(ctx)
+	ctx = ContextWithGEM(ctx, ResetBulkItem(gem))
+	ctx = context.WithValue(ctx, CaptureGroupsVal, captured)
+
+	w := &davResponse{header: make(http.Header)}
+	if herr := handler(ctx, w, req); herr != nil {
+		return object, herr
+	}
+	if err := json.Unmarshal(w.body.Bytes(), &object); err != nil {
+		return object, NewAppError(http.StatusInternalServerError, err, "Error reading response")
+	}
+	return object, nil
+}
+
+// davDelegateJSON invokes an API handler with a JSON request
+func (h AppServer) davDelegateJSON(ctx context.Context, r *http.Request, handler func(context.Context, http.ResponseWriter, *http.Request) *AppError,
+	captured map[string]string, request interface{}) (protocol.Object, *AppError) {
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return protocol.Object{}, h.davFail(ctx, http.StatusInternalServerError, err, "Error preparing request")
+	}
+	return h.davDelegate(ctx, r, handler, captured, "application/json", bytes.NewReader(body))
+}
+
+// davDelegateStream invokes an API handler with a multipart request of the
+// metadata followed by the content, streamed as it is read by the handler
+func (h AppServer) davDelegateStream(ctx context.Context, r *http.Request, handler func(context.Context, http.ResponseWriter, *http.Request) *AppError,
+	captured map[string]string, metadata interface{}, name string, content io.Reader) (protocol.Object, *AppError) {
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	done := make(chan struct{})
+	go func() {
+		pw.CloseWithError(writeDAVMultipart(mw, metadata, name, content))
+		close(done)
+	}()
+	object, herr := h.davDelegate(ctx, r, handler, captured, mw.FormDataContentType(), pr)
+	// Should the handler fail before reading the content, closing the pipe
+	// stops the writer. It is waited for, so that the content is no longer
+	// being read once this returns.
+	pr.Close()
+	<-done
+	return object, herr
+}
+
+func writeDAVMultipart(mw *multipart.Writer, metadata interface{}, name string, content io.Reader) error {
+	part, err := mw.CreateFormField("ObjectMetadata")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(part).Encode(metadata); err != nil {
+		return err
+	}
+	part, err = mw.CreateFormFile("filestream", name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return err
+	}
+	return mw.Close()
+}
+
+// davConfirmLocks fails unless the caller may change the path, and the paths
+// beneath it if descendants are changed too, despite any locks on them
+func (h AppServer) davConfirmLocks(ctx context.Context, r *http.Request, path string, descendants bool) *AppError {
+	caller, _ := CallerFromContext(ctx)
+	tokens := davSubmittedTokens(r.Header.Get("If"), "")
+	if h.webDAVLocks.Confirm(path, descendants, caller.DistinguishedName, tokens) {
+		return nil
+	}
+	return h.davFail(ctx, http.StatusLocked, errors.New("locked"), "The resource is locked")
+}
+
+// davCreate creates a file or folder in a collection, with the ACM configured
+// for WebDAV
+func (h AppServer) davCreate(ctx context.Context, r *http.Request, parent davResource, name string, typeName string, content io.Reader) (protocol.Object, *AppError) {
+	request, herr := h.davCreateRequest(ctx, parent, name, typeName, h.WebDAV.ACM)
+	if herr != nil {
+		return protocol.Object{}, herr
+	}
+	return h.davDelegateCreate(ctx, r, request, content)
+}
+
+// davCreateRequest prepares the request to create an object in a collection.
+// Objects created at the root of a group are owned by the group.
+func (h AppServer) davCreateRequest(ctx context.Context, parent davResource, name string, typeName string, acm string) (protocol.CreateObjectRequest, *AppError) {
+	request := protocol.CreateObjectRequest{TypeName: typeName, Name: name, RawAcm: acm}
+	if parent.Groups {
+		return request, h.davFail(ctx, http.StatusForbidden, errors.New("forbidden"), "Objects cannot be created in the collection of groups")
+	}
+	if parent.isObject() {
+		request.ParentID = hex.EncodeToString(parent.Object.ID)
+	}
+	if len(parent.Group) > 0 {
+		grantee, err := DAOFromContext(ctx).GetAcmGrantee(parent.Group)
+		if err != nil {
+			return request, h.davFail(ctx, http.StatusInternalServerError, err, "Error retrieving group")
+		}
+		request.OwnedBy = grantee.ResourceName()
+	}
+	return request, nil
+}
+
+// davDelegateCreate creates an object, with content unless it is nil
+func (h AppServer) davDelegateCreate(ctx context.Context, r *http.Request, request protocol.CreateObjectRequest, content io.Reader) (protocol.Object, *AppError) {
+	name := request.Name
+	if len(request.NamePathDelimiter) > 0 {
+		name = name[strings.LastIndex(name, request.NamePathDelimiter)+1:]
+	}
+	if content == nil {
+		return h.davDelegateJSON(ctx, r, h.createObject, map[string]string{}, request)
+	}
+	return h.davDelegateStream(ctx, r, h.createObject, map[string]string{}, request, name, content)
+}
+
+// davDelete moves an object to the trash
+func (h AppServer) davDelete(ctx context.Context, r *http.Request, object models.ODObject) *AppError {
+	id := hex.EncodeToString(object.ID)
+	request := protocol.DeleteObjectRequest{ID: id, ChangeToken: object.ChangeToken}
+	_, herr := h.davDelegateJSON(ctx, r, h.deleteObject, map[string]string{"objectId": id}, request)
+	return herr
+}
+
+// davPlace moves an object into the collection and renames it, as needed
+func (h AppServer) davPlace(ctx context.Context, r *http.Request, object protocol.Object, parent davResource, name string) (protocol.Object, *AppError) {
+	var herr *AppError
+	if parent.Groups {
+		return object, h.davFail(ctx, http.StatusForbidden, errors.New("forbidden"), "Objects cannot be placed in the collection of groups")
+	}
+	if !parent.isObject() && !davOwnsRoot(ctx, parent, object.OwnedBy) {
+		return object, h.davFail(ctx, http.StatusForbidden, errors.New("forbidden"), "Objects at a root must be owned by the user or group of the root")
+	}
+	parentID := ""
+	if parent.isObject() {
+		parentID = hex.EncodeToString(parent.Object.ID)
+	}
+	if object.ParentID != parentID {
+		request := protocol.MoveObjectRequest{ID: object.ID, ChangeToken: object.ChangeToken, ParentID: parentID}
+		captured := map[string]string{"objectId": object.ID, "folderId": parentID}
+		if object, herr = h.davDelegateJSON(ctx, r, h.moveObject, captured, request); herr != nil {
+			return object, herr
+		}
+	}
+	if object.Name != name {
+		request := protocol.UpdateObjectRequest{
+			ID:                    object.ID,
+			ChangeToken:           object.ChangeToken,
+			TypeName:              object.TypeName,
+			Name:                  name,
+			Description:           object.Description,
+			ContentType:           object.ContentType,
+			ContainsUSPersonsData: object.ContainsUSPersonsData,
+			ExemptFromFOIA:        object.ExemptFromFOIA,
+		}
+		if object, herr = h.davDelegateJSON(ctx, r, h.updateObject, map[string]string{"objectId": object.ID}, request); herr != nil {
+			return object, herr
+		}
+	}
+	return object, nil
+}
+
+// davCopy copies an object into the collection with the name, and the
+// members of a collection beneath it if infinite
+func (h AppServer) davCopy(ctx context.Context, r *http.Request, source davResource, parent davResource, name string, infinite bool) *AppError {
+	id := hex.EncodeToString(source.Object.ID)
+	copied, herr := h.davDelegate(ctx, r, h.copyObject, map[string]string{"objectId": id}, "", nil)
+	if herr != nil {
+		return herr
+	}
+	if copied, herr = h.davPlace(ctx, r, copied, parent, name); herr != nil {
+		return herr
+	}
+	if !infinite || !source.isCollection() {
+		return nil
+	}
+	copiedID, err := hex.DecodeString(copied.ID)
+	if err != nil {
+		return h.davFail(ctx, http.StatusInternalServerError, err, "Error reading copied object")
+	}
+	children, herr := h.davChildren(ctx, source)
+	if herr != nil {
+		return herr
+	}
+	folder := davResource{Object: models.ODObject{ID: copiedID}}
+	for _, child := range children {
+		if herr := h.davCopy(ctx, r, child, folder, child.Object.Name, true); herr != nil {
+			return herr
+		}
+	}
+	return nil
+}
+
+// davGet retrieves the content of a file, or lists a collection as with
+// /files
+func (h AppServer) davGet(ctx context.Context, w http.ResponseWriter, r *http.Request, res davResolution) *AppError {
+	if res.Resource == nil {
+		return h.davFail(ctx, http.StatusNotFound, errors.New("not found"), "Resource not found")
+	}
+	target := *res.Resource
+	captured := map[string]string{}
+	switch {
+	case target.Groups:
+		return h.listMyGroupsWithObjects(ctx, w, r)
+	case len(target.Group) > 0:
+		captured["groupName"] = target.Group
+		return h.listGroupObjects(context.WithValue(ctx, CaptureGroupsVal, captured), w, r)
+	case !target.isObject():
+		return h.listObjects(context.WithValue(ctx, CaptureGroupsVal, captured), w, r)
+	}
+	captured["objectId"] = hex.EncodeToString(target.Object.ID)
+	if target.isCollection() {
+		return h.listObjects(context.WithValue(ctx, CaptureGroupsVal, captured), w, r)
+	}
+	return h.getObjectStream(context.WithValue(ctx, CaptureGroupsVal, captured), w, r)
+}
+
+// davPut creates a file, or replaces the content of an existing one
+func (h AppServer) davPut(ctx context.Context, w http.ResponseWriter, r *http.Request, res davResolution) *AppError {
+	if res.Parent == nil {
+		return h.davFail(ctx, http.StatusConflict, errors.New("no parent"), "The collection to hold the resource does not exist")
+	}
+	if res.Resource != nil && res.Resource.isCollection() {
+		return h.davFail(ctx, http.StatusMethodNotAllowed, errors.New("collection"), "The content of a collection cannot be replaced")
+	}
+	if herr := h.davConfirmLocks(ctx, r, res.Path, false); herr != nil {
+		return herr
+	}
+	if res.Resource != nil {
+		object := res.Resource.Object
+		id := hex.EncodeToString(object.ID)
+		metadata := protocol.UpdateObjectAndStreamRequest{ID: id, ChangeToken: object.ChangeToken}
+		if _, herr := h.davDelegateStream(ctx, r, h.updateObjectStream, map[string]string{"objectId": id}, metadata, object.Name, r.Body); herr != nil {
+			return herr
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	if _, herr := h.davCreate(ctx, r, *res.Parent, res.Name, "File", r.Body); herr != nil {
+		return herr
+	}
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// davMkcol creates a folder
+func (h AppServer) davMkcol(ctx context.Context, w http.ResponseWriter, r *http.Request, res davResolution) *AppError {
+	if r.ContentLength > 0 {
+		return h.davFail(ctx, http.StatusUnsupportedMediaType, errors.New("body not supported"), "A body is not supported when creating a collection")
+	}
+	if res.Resource != nil {
+		return h.davFail(ctx, http.StatusMethodNotAllowed, errors.New("exists"), "The resource already exists")
+	}
+	if res.Parent == nil {
+		return h.davFail(ctx, http.StatusConflict, errors.New("no parent"), "The collection to hold the resource does not exist")
+	}
+	if herr := h.davConfirmLocks(ctx, r, res.Path, false); herr != nil {
+		return herr
+	}
+	if _, herr := h.davCreate(ctx, r, *res.Parent, res.Name, "Folder", nil); herr != nil {
+		return herr
+	}
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// davDeleteResource moves an object, and so its members, to the trash
+func (h AppServer) davDeleteResource(ctx context.Context, w http.ResponseWriter, r *http.Request, res davResolution) *AppError {
+	if res.Resource == nil {
+		return h.davFail(ctx, http.StatusNotFound, errors.New("not found"), "Resource not found")
+	}
+	if !res.Resource.isObject() {
+		return h.davFail(ctx, http.StatusForbidden, errors.New("forbidden"), "Roots cannot be deleted")
+	}
+	if herr := h.davConfirmLocks(ctx, r, res.Path, true); herr != nil {
+		return herr
+	}
+	if herr := h.davDelete(ctx, r, res.Resource.Object); herr != nil {
+		return herr
+	}
+	h.webDAVLocks.Release(res.Path)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// davCopyOrMove copies or moves an object to the destination, replacing any
+// object there unless the request forbids overwriting it
+func (h AppServer) davCopyOrMove(ctx context.Context, w http.ResponseWriter, r *http.Request, res davResolution, move bool) *AppError {
+	if res.Resource == nil {
+		return h.davFail(ctx, http.StatusNotFound, errors.New("not found"), "Resource not found")
+	}
+	if !res.Resource.isObject() {
+		return h.davFail(ctx, http.StatusForbidden, errors.New("forbidden"), "Roots cannot be copied or moved")
+	}
+	destination, err := url.Parse(r.Header.Get("Destination"))
+	if err != nil || len(r.Header.Get("Destination")) == 0 {
+		return h.davFail(ctx, http.StatusBadRequest, err, "A valid Destination must be specified")
+	}
+	if len(destination.Host) > 0 && destination.Host != r.Host {
+		return h.davFail(ctx, http.StatusBadGateway, errors.New("other server"), "The Destination must be on this server")
+	}
+	segments, ok := davSegments(h.WebDAV.Prefix, destination.EscapedPath())
+	if !ok {
+		return h.davFail(ctx, http.StatusBadGateway, errors.New("outside prefix"), "The Destination must be beneath "+h.WebDAV.Prefix)
+	}
+	dest, herr := h.resolveDAVPath(ctx, davResource{Path: "/"}, segments)
+	if herr != nil {
+		gem, _ := GEMFromContext(ctx)
+		h.publishError(gem, herr)
+		return herr
+	}
+	if dest.Parent == nil {
+		return h.davFail(ctx, http.StatusConflict, errors.New("no parent"), "The collection to hold the destination does not exist")
+	}
+	// Overlapping paths are refused before anything is changed, since copying
+	// a collection beneath itself would not end, and overwriting an ancestor
+	// would delete the source
+	if dest.Path == res.Path || (dest.Resource != nil && bytes.Equal(dest.Resource.Object.ID, res.Resource.Object.ID)) {
+		return h.davFail(ctx, http.StatusForbidden, errors.New("same resource"), "The source and destination are the same")
+	}
+	if davPathCovers(res.Path, true, dest.Path) {
+		return h.davFail(ctx, http.StatusConflict, errors.New("destination within source"), "The destination cannot be beneath the source")
+	}
+	if davPathCovers(dest.Path, true, res.Path) {
+		return h.davFail(ctx, http.StatusForbidden, errors.New("source within destination"), "The destination cannot contain the source")
+	}
+	if move {
+		if herr := h.davConfirmLocks(ctx, r, res.Path, true); herr != nil {
+			return herr
+		}
+	}
+	if herr := h.davConfirmLocks(ctx, r, dest.Path, true); herr != nil {
+		return herr
+	}
+	status := http.StatusCreated
+	if dest.Resource != nil {
+		if strings.EqualFold(r.Header.Get("Overwrite"), "F") {
+			return h.davFail(ctx, http.StatusPreconditionFailed, errors.New("destination exists"), "The destination exists and may not be overwritten")
+		}
+		if !dest.Resource.isObject() {
+			return h.davFail(ctx, http.StatusForbidden, errors.New("forbidden"), "Roots cannot be overwritten")
+		}
+		if herr := h.davDelete(ctx, r, dest.Resource.Object); herr != nil {
+			return herr
+		}
+		h.webDAVLocks.Release(dest.Path)
+		status = http.StatusNoContent
+	}
+	if move {
+		if _, herr := h.davPlace(ctx, r, mapping.MapODObjectToObject(&res.Resource.Object), *dest.Parent, dest.Name); herr != nil {
+			return herr
+		}
+		h.webDAVLocks.Release(res.Path)
+	} else {
+		infinite := r.Header.Get("Depth") != "0"
+		if herr := h.davCopy(ctx, r, *res.Resource, *dest.Parent, dest.Name, infinite); herr != nil {
+			return herr
+		}
+	}
+	w.WriteHeader(status)
+	return nil
+}
+
+// davLock takes or refreshes a lock. Locking a path with no resource creates
+// an empty file, which the client then writes.
+func (h AppServer) davLock(ctx context.Context, w http.ResponseWriter, r *http.Request, res davResolution) *AppError {
+	caller, _ := CallerFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	timeout := davLockTimeout(r.Header.Get("Timeout"), time.Duration(h.WebDAV.LockTimeout)*time.Second)
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, davMaxRequestSize))
+	if err != nil {
+		return h.davFail(ctx, http.StatusBadRequest, err, "Error reading request")
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		// A request without a body refreshes a lock
+		for _, token := range davSubmittedTokens(r.Header.Get("If"), "") {
+			if lock, ok := h.webDAVLocks.Refresh(token, res.Path, caller.DistinguishedName, timeout); ok {
+				h.writeDAVLock(w, http.StatusOK, lock)
+				h.publishSuccess(gem, w)
+				return nil
+			}
+		}
+		return h.davFail(ctx, http.StatusPreconditionFailed, errors.New("no lock"), "No lock held by the caller was submitted to refresh")
+	}
+	info, err := parseDAVLockInfo(body)
+	if err != nil {
+		return h.davFail(ctx, http.StatusBadRequest, err, "Error parsing lockinfo")
+	}
+	if res.Resource == nil && res.Parent == nil {
+		return h.davFail(ctx, http.StatusConflict, errors.New("no parent"), "The collection to hold the resource does not exist")
+	}
+	lock, ok := h.webDAVLocks.Lock(res.Path, r.Header.Get("Depth") != "0", caller.DistinguishedName, info.Owner.InnerXML, timeout)
+	if !ok {
+		return h.davFail(ctx, http.StatusLocked, errors.New("locked"), "The resource is locked")
+	}
+	status := http.StatusOK
+	if res.Resource == nil {
+		if _, herr := h.davCreate(ctx, r, *res.Parent, res.Name, "File", strings.NewReader("")); herr != nil {
+			h.webDAVLocks.Unlock(lock.Token, res.Path, caller.DistinguishedName)
+			return herr
+		}
+		status = http.StatusCreated
+	}
+	w.Header().Set("Lock-Token", "<"+lock.Token+">")
+	h.writeDAVLock(w, status, lock)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// davUnlock removes a lock held by the caller
+func (h AppServer) davUnlock(ctx context.Context, w http.ResponseWriter, r *http.Request, res davResolution) *AppError {
+	caller, _ := CallerFromContext(ctx)
+	gem, _ := GEMFromContext(ctx)
+	tokens := davSubmittedTokens("", r.Header.Get("Lock-Token"))
+	if len(tokens) == 0 || !h.webDAVLocks.Unlock(tokens[0], res.Path, caller.DistinguishedName) {
+		return h.davFail(ctx, http.StatusConflict, errors.New("not locked"), "The lock token does not match a lock held by the caller on the resource")
+	}
+	w.WriteHeader(http.StatusNoContent)
+	h.publishSuccess(gem, w)
+	return nil
+}
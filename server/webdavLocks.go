@@ -0,0 +1,196 @@
+package server
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// davLockTokenRX finds the lock tokens submitted in the If header of a WebDAV
+// request. The conditions of the header are not otherwise evaluated.
+var davLockTokenRX = regexp.MustCompile(`<(opaquelocktoken:[^>]+)>`)
+
+// davLock is an exclusive write lock on a WebDAV path, and the paths beneath
+// it if it has infinite depth.
+type davLock struct {
+	Token    string
+	Path     string
+	Infinite bool
+	// Holder is the distinguished name of the user holding the lock
+	Holder string
+	// Owner is the XML describing the owner given when the lock was taken,
+	// which is reported back to clients
+	Owner   string
+	Timeout time.Duration
+	Expires time.Time
+}
+
+// davLockTable holds the WebDAV locks taken on this instance. Locks are held
+// in memory, and so only prevent conflicting changes made through the same
+// instance, and do not survive a restart.
+type davLockTable struct {
+	mu    sync.Mutex
+	locks map[string]*davLock
+}
+
+func newDAVLockTable() *davLockTable {
+	return &davLockTable{locks: make(map[string]*davLock)}
+}
+
+// davPathCovers reports whether a lock on the path, of the given depth,
+// applies to the target path
+func davPathCovers(path string, infinite bool, target string) bool {
+	if path == target {
+		return true
+	}
+	return infinite && strings.HasPrefix(target, strings.TrimSuffix(path, "/")+"/")
+}
+
+// expire removes locks that have not been refreshed in time. The table must
+// be locked.
+func (t *davLockTable) expire(now time.Time) {
+	for token, lock := range t.locks {
+		if now.After(lock.Expires) {
+			delete(t.locks, token)
+		}
+	}
+}
+
+// Lock takes a lock on the path, failing if it conflicts with any lock
+// already held on the path, its ancestors or, for a lock of infinite depth,
+// its descendants.
+func (t *davLockTable) Lock(path string, infinite bool, holder string, owner string, timeout time.Duration) (davLock, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.expire(now)
+	for _, lock := range t.locks {
+		if davPathCovers(lock.Path, lock.Infinite, path) || davPathCovers(path, infinite, lock.Path) {
+			return *lock, false
+		}
+	}
+	lock := &davLock{
+		Token:    "opaquelocktoken:" + newGUID(),
+		Path:     path,
+		Infinite: infinite,
+		Holder:   holder,
+		Owner:    owner,
+		Timeout:  timeout,
+		Expires:  now.Add(timeout),
+	}
+	t.locks[lock.Token] = lock
+	return *lock, true
+}
+
+// Refresh extends a lock held by the holder on the path
+func (t *davLockTable) Refresh(token string, path string, holder string, timeout time.Duration) (davLock, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.expire(now)
+	lock, ok := t.locks[token]
+	if !ok || lock.Holder != holder || !davPathCovers(lock.Path, lock.Infinite, path) {
+		return davLock{}, false
+	}
+	lock.Timeout = timeout
+	lock.Expires = now.Add(timeout)
+	return *lock, true
+}
+
+// Unlock removes a lock held by the holder on the path
+func (t *davLockTable) Unlock(token string, path string, holder string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(time.Now())
+	lock, ok := t.locks[token]
+	if !ok || lock.Holder != holder || !davPathCovers(lock.Path, lock.Infinite, path) {
+		return false
+	}
+	delete(t.locks, token)
+	return true
+}
+
+// Confirm reports whether the holder may change the path, as every lock
+// applying to it, or to any path beneath it when descendants are changed
+// too, is held by the holder and its token was submitted.
+func (t *davLockTable) Confirm(path string, descendants bool, holder string, tokens []string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(time.Now())
+	for _, lock := range t.locks {
+		if !davPathCovers(lock.Path, lock.Infinite, path) && !(descendants && davPathCovers(path, true, lock.Path)) {
+			continue
+		}
+		if lock.Holder != holder || !davContainsToken(tokens, lock.Token) {
+			return false
+		}
+	}
+	return true
+}
+
+// Release removes the locks on the path and the paths beneath it, once the
+// resource there is moved or deleted
+func (t *davLockTable) Release(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for token, lock := range t.locks {
+		if davPathCovers(path, true, lock.Path) {
+			delete(t.locks, token)
+		}
+	}
+}
+
+// Discover returns the locks applying to the path
+func (t *davLockTable) Discover(path string) []davLock {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(time.Now())
+	var locks []davLock
+	for _, lock := range t.locks {
+		if davPathCovers(lock.Path, lock.Infinite, path) {
+			locks = append(locks, *lock)
+		}
+	}
+	return locks
+}
+
+func davContainsToken(tokens []string, token string) bool {
+	for _, t := range tokens {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
+
+// davSubmittedTokens returns the lock tokens submitted in the If and
+// Lock-Token headers of a request
+func davSubmittedTokens(ifHeader string, lockToken string) []string {
+	var tokens []string
+	for _, match := range davLockTokenRX.FindAllStringSubmatch(ifHeader, -1) {
+		tokens = append(tokens, match[1])
+	}
+	if lockToken = strings.Trim(strings.TrimSpace(lockToken), "<>"); len(lockToken) > 0 {
+		tokens = append(tokens, lockToken)
+	}
+	return tokens
+}
+
+// davLockTimeout parses the Timeout header of a LOCK request, such as
+// "Second-600" or "Infinite", bounding it by the maximum timeout
+func davLockTimeout(header string, max time.Duration) time.Duration {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "Second-") {
+			if seconds, err := strconv.ParseInt(strings.TrimPrefix(value, "Second-"), 10, 64); err == nil && seconds > 0 {
+				if timeout := time.Duration(seconds) * time.Second; timeout < max {
+					return timeout
+				}
+			}
+			break
+		}
+	}
+	return max
+}
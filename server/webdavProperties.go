@@ -0,0 +1,275 @@
+package server
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// davPropfindRequest is the body of a PROPFIND request. A request without a
+// body is for all properties.
+type davPropfindRequest struct {
+	XMLName  xml.Name      `xml:"DAV: propfind"`
+	AllProp  *struct{}     `xml:"DAV: allprop"`
+	PropName *struct{}     `xml:"DAV: propname"`
+	Prop     *davPropNames `xml:"DAV: prop"`
+}
+
+// davPropNames are the properties named within a prop element
+type davPropNames struct {
+	Names []davPropName `xml:",any"`
+}
+
+type davPropName struct {
+	XMLName xml.Name
+}
+
+// davPropertyUpdate is the body of a PROPPATCH request
+type davPropertyUpdate struct {
+	XMLName xml.Name       `xml:"DAV: propertyupdate"`
+	Set     []davPropNames `xml:"DAV: set>prop"`
+	Remove  []davPropNames `xml:"DAV: remove>prop"`
+}
+
+// davLockInfo is the body of a LOCK request taking a lock
+type davLockInfo struct {
+	XMLName   xml.Name  `xml:"DAV: lockinfo"`
+	Exclusive *struct{} `xml:"DAV: lockscope>exclusive"`
+	Shared    *struct{} `xml:"DAV: lockscope>shared"`
+	Owner     struct {
+		InnerXML string `xml:",innerxml"`
+	} `xml:"DAV: owner"`
+}
+
+// davLiveProperties are the properties reported for all properties, in order
+var davLiveProperties = []string{
+	"displayname", "resourcetype", "creationdate", "getlastmodified", "getetag",
+	"getcontentlength", "getcontenttype", "supportedlock", "lockdiscovery",
+}
+
+func parseDAVLockInfo(body []byte) (davLockInfo, error) {
+	var info davLockInfo
+	if err := xml.Unmarshal(body, &info); err != nil {
+		return info, err
+	}
+	if info.Shared != nil || info.Exclusive == nil {
+		return info, errors.New("only exclusive locks are supported")
+	}
+	return info, nil
+}
+
+// davEscape escapes text for inclusion in XML
+func davEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// davHref returns the escaped href of a resource, with collections ending in /
+func (h AppServer) davHref(res davResource) string {
+	href := h.WebDAV.Prefix + res.Path
+	if res.isCollection() && href[len(href)-1] != '/' {
+		href += "/"
+	}
+	return href
+}
+
+// davProperty returns the value of a DAV: property of a resource as XML, and
+// whether the resource has it
+func (h AppServer) davProperty(res davResource, name string) (string, bool) {
+	switch name {
+	case "displayname":
+		return davEscape(res.name()), true
+	case "resourcetype":
+		if res.isCollection() {
+			return "<D:collection/>", true
+		}
+		return "", true
+	case "supportedlock":
+		return "<D:lockentry><D:lockscope><D:exclusive/></D:lockscope><D:locktype><D:write/></D:locktype></D:lockentry>", true
+	case "lockdiscovery":
+		return h.davLockDiscovery(h.webDAVLocks.Discover(res.Path)), true
+	}
+	if !res.isObject() {
+		return "", false
+	}
+	object := res.Object
+	switch name {
+	case "creationdate":
+		return object.CreatedDate.UTC().Format(time.RFC3339), true
+	case "getlastmodified":
+		return object.ModifiedDate.UTC().Format(http.TimeFormat), true
+	case "getetag":
+		return davEscape(strconv.Quote(object.ChangeToken)), true
+	}
+	if res.isCollection() {
+		return "", false
+	}
+	switch name {
+	case "getcontentlength":
+		return strconv.FormatInt(object.ContentSize.Int64, 10), true
+	case "getcontenttype":
+		if len(object.ContentType.String) == 0 {
+			return "", false
+		}
+		return davEscape(object.ContentType.String), true
+	}
+	return "", false
+}
+
+// davLockDiscovery describes the locks on a resource as XML
+func (h AppServer) davLockDiscovery(locks []davLock) string {
+	var b bytes.Buffer
+	for _, lock := range locks {
+		depth := "0"
+		if lock.Infinite {
+			depth = "infinity"
+		}
+		b.WriteString("<D:activelock><D:locktype><D:write/></D:locktype><D:lockscope><D:exclusive/></D:lockscope>")
+		fmt.Fprintf(&b, "<D:depth>%s</D:depth>", depth)
+		if len(lock.Owner) > 0 {
+			fmt.Fprintf(&b, "<D:owner>%s</D:owner>", lock.Owner)
+		}
+		fmt.Fprintf(&b, "<D:timeout>Second-%d</D:timeout>", int64(lock.Timeout/time.Second))
+		fmt.Fprintf(&b, "<D:locktoken><D:href>%s</D:href></D:locktoken>", davEscape(lock.Token))
+		fmt.Fprintf(&b, "<D:lockroot><D:href>%s</D:href></D:lockroot>", davEscape(h.WebDAV.Prefix+lock.Path))
+		b.WriteString("</D:activelock>")
+	}
+	return b.String()
+}
+
+// writeDAVLock writes the response to a LOCK request
+func (h AppServer) writeDAVLock(w http.ResponseWriter, code int, lock davLock) {
+	w.Header().Set("Content-Type", `application/xml; charset="utf-8"`)
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><D:prop xmlns:D="DAV:"><D:lockdiscovery>%s</D:lockdiscovery></D:prop>`, h.davLockDiscovery([]davLock{lock}))
+}
+
+// writeDAVPropstat writes the properties of a resource in a multistatus
+// response, with those requested that the resource lacks reported as not
+// found
+func (h AppServer) writeDAVPropstat(b *bytes.Buffer, res davResource, names []xml.Name, namesOnly bool) {
+	var found, missing bytes.Buffer
+	for _, name := range names {
+		if name.Space != "DAV:" {
+			fmt.Fprintf(&missing, `<%s xmlns="%s"/>`, name.Local, davEscape(name.Space))
+			continue
+		}
+		value, ok := h.davProperty(res, name.Local)
+		switch {
+		case !ok:
+			fmt.Fprintf(&missing, "<D:%s/>", name.Local)
+		case namesOnly || len(value) == 0:
+			fmt.Fprintf(&found, "<D:%s/>", name.Local)
+		default:
+			fmt.Fprintf(&found, "<D:%s>%s</D:%s>", name.Local, value, name.Local)
+		}
+	}
+	fmt.Fprintf(b, "<D:response><D:href>%s</D:href>", davEscape(h.davHref(res)))
+	if found.Len() > 0 {
+		fmt.Fprintf(b, "<D:propstat><D:prop>%s</D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat>", found.String())
+	}
+	if missing.Len() > 0 {
+		fmt.Fprintf(b, "<D:propstat><D:prop>%s</D:prop><D:status>HTTP/1.1 404 Not Found</D:status></D:propstat>", missing.String())
+	}
+	b.WriteString("</D:response>")
+}
+
+// writeDAVMultistatus writes a multistatus response
+func writeDAVMultistatus(w http.ResponseWriter, responses *bytes.Buffer) {
+	w.Header().Set("Content-Type", `application/xml; charset="utf-8"`)
+	w.WriteHeader(http.StatusMultiStatus)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><D:multistatus xmlns:D="DAV:">%s</D:multistatus>`, responses.String())
+}
+
+// davPropfind reports the properties of a resource, and of its members for a
+// depth of 1. As listing an entire tree is costly, a depth of infinity is
+// refused.
+func (h AppServer) davPropfind(ctx context.Context, w http.ResponseWriter, r *http.Request, res davResolution) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	if res.Resource == nil {
+		return h.davFail(ctx, http.StatusNotFound, errors.New("not found"), "Resource not found")
+	}
+	depth := r.Header.Get("Depth")
+	if depth != "0" && depth != "1" {
+		return h.davFail(ctx, http.StatusForbidden, errors.New("depth not supported"), "A Depth of 0 or 1 must be specified")
+	}
+	var request davPropfindRequest
+	if err := xml.NewDecoder(io.LimitReader(r.Body, davMaxRequestSize)).Decode(&request); err != nil && err != io.EOF {
+		return h.davFail(ctx, http.StatusBadRequest, err, "Error parsing propfind")
+	}
+	var names []xml.Name
+	if request.Prop != nil {
+		for _, name := range request.Prop.Names {
+			names = append(names, name.XMLName)
+		}
+	} else {
+		for _, name := range davLiveProperties {
+			names = append(names, xml.Name{Space: "DAV:", Local: name})
+		}
+	}
+	resources := []davResource{*res.Resource}
+	if depth == "1" && res.Resource.isCollection() {
+		children, herr := h.davChildren(ctx, *res.Resource)
+		if herr != nil {
+			h.publishError(gem, herr)
+			return herr
+		}
+		resources = append(resources, children...)
+	}
+	var responses bytes.Buffer
+	for _, resource := range resources {
+		if request.Prop != nil {
+			h.writeDAVPropstat(&responses, resource, names, false)
+			continue
+		}
+		// Only the properties the resource has are reported for allprop and
+		// propname
+		var present []xml.Name
+		for _, name := range names {
+			if _, ok := h.davProperty(resource, name.Local); ok {
+				present = append(present, name)
+			}
+		}
+		h.writeDAVPropstat(&responses, resource, present, request.PropName != nil)
+	}
+	writeDAVMultistatus(w, &responses)
+	h.publishSuccess(gem, w)
+	return nil
+}
+
+// davProppatch accepts changes to the properties of a resource so that clients
+// which set them on upload, such as for timestamps, may proceed. The
+// properties are not retained, as objects have no place to hold them.
+func (h AppServer) davProppatch(ctx context.Context, w http.ResponseWriter, r *http.Request, res davResolution) *AppError {
+	gem, _ := GEMFromContext(ctx)
+	if res.Resource == nil {
+		return h.davFail(ctx, http.StatusNotFound, errors.New("not found"), "Resource not found")
+	}
+	if herr := h.davConfirmLocks(ctx, r, res.Path, false); herr != nil {
+		return herr
+	}
+	var update davPropertyUpdate
+	if err := xml.NewDecoder(io.LimitReader(r.Body, davMaxRequestSize)).Decode(&update); err != nil {
+		return h.davFail(ctx, http.StatusBadRequest, err, "Error parsing propertyupdate")
+	}
+	var props bytes.Buffer
+	for _, list := range append(update.Set, update.Remove...) {
+		for _, name := range list.Names {
+			fmt.Fprintf(&props, `<%s xmlns="%s"/>`, name.XMLName.Local, davEscape(name.XMLName.Space))
+		}
+	}
+	var responses bytes.Buffer
+	fmt.Fprintf(&responses, "<D:response><D:href>%s</D:href>", davEscape(h.davHref(*res.Resource)))
+	fmt.Fprintf(&responses, "<D:propstat><D:prop>%s</D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>", props.String())
+	writeDAVMultistatus(w, &responses)
+	h.publishSuccess(gem, w)
+	return nil
+}
@@ -0,0 +1,134 @@
+package server_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/util"
+)
+
+func doWebDAVRequest(t *testing.T, clientID int, method string, path string, body string, headers map[string]string) *http.Response {
+	req, err := http.NewRequest(method, mountPoint+"/dav"+path, strings.NewReader(body))
+	failNowOnErr(t, err, "unable to create request")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := clients[clientID].Client.Do(req)
+	failNowOnErr(t, err, "unable to do request")
+	return resp
+}
+
+func TestWebDAVLifecycle(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	tester10 := 0
+	folder := "/TestWebDAVLifecycle" + newGUID(t)
+
+	resp := doWebDAVRequest(t, tester10, "OPTIONS", "/", "", nil)
+	statusMustBe(t, 200, resp, "expected options")
+	util.FinishBody(resp.Body)
+	if !strings.Contains(resp.Header.Get("DAV"), "2") {
+		t.Errorf("expected locking to be advertised, got DAV: %s", resp.Header.Get("DAV"))
+	}
+
+	resp = doWebDAVRequest(t, tester10, "MKCOL", folder, "", nil)
+	statusMustBe(t, 201, resp, "expected folder to be created")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "PUT", folder+"/missing/a.txt", "content", nil)
+	statusMustBe(t, 409, resp, "expected conflict when parent does not exist")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "PUT", folder+"/a%20file.txt", "first content", nil)
+	statusMustBe(t, 201, resp, "expected file to be created")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "PUT", folder+"/a%20file.txt", "second content", nil)
+	statusMustBe(t, 204, resp, "expected file to be replaced")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "PROPFIND", folder, "", map[string]string{"Depth": "1"})
+	statusMustBe(t, 207, resp, "expected multistatus")
+	listing, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !bytes.Contains(listing, []byte("a%20file.txt")) || !bytes.Contains(listing, []byte("<D:collection/>")) {
+		t.Errorf("expected folder and file in listing, got %s", listing)
+	}
+
+	resp = doWebDAVRequest(t, tester10, "MOVE", folder+"/a%20file.txt", "", map[string]string{"Destination": mountPoint + "/dav" + folder + "/b.txt"})
+	statusMustBe(t, 201, resp, "expected file to be renamed")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "GET", folder+"/b.txt", "", nil)
+	statusMustBe(t, 200, resp, "expected renamed file")
+	content, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(content) != "second content" {
+		t.Errorf("expected replaced content, got %q", content)
+	}
+
+	resp = doWebDAVRequest(t, tester10, "COPY", folder+"/b.txt", "", map[string]string{"Destination": mountPoint + "/dav" + folder + "/c.txt"})
+	statusMustBe(t, 201, resp, "expected file to be copied")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "COPY", folder+"/b.txt", "", map[string]string{"Destination": mountPoint + "/dav" + folder + "/c.txt", "Overwrite": "F"})
+	statusMustBe(t, 412, resp, "expected copy not to overwrite")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "COPY", folder, "", map[string]string{"Destination": mountPoint + "/dav" + folder + "/copy"})
+	statusMustBe(t, 409, resp, "expected copy beneath the source to be refused")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "MOVE", folder+"/b.txt", "", map[string]string{"Destination": mountPoint + "/dav" + folder})
+	statusMustBe(t, 403, resp, "expected move over an ancestor of the source to be refused")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "GET", folder+"/b.txt", "", nil)
+	statusMustBe(t, 200, resp, "expected source to remain")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "DELETE", folder, "", nil)
+	statusMustBe(t, 204, resp, "expected folder to be deleted")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "PROPFIND", folder, "", map[string]string{"Depth": "0"})
+	statusMustBe(t, 404, resp, "expected folder to be gone")
+	util.FinishBody(resp.Body)
+}
+
+func TestWebDAVLocking(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	tester10 := 0
+	path := "/TestWebDAVLocking" + newGUID(t) + ".txt"
+	lockinfo := `<?xml version="1.0" encoding="utf-8"?><D:lockinfo xmlns:D="DAV:"><D:lockscope><D:exclusive/></D:lockscope><D:locktype><D:write/></D:locktype><D:owner>tester10</D:owner></D:lockinfo>`
+
+	resp := doWebDAVRequest(t, tester10, "LOCK", path, lockinfo, map[string]string{"Depth": "0", "Timeout": "Second-60"})
+	statusMustBe(t, 201, resp, "expected lock to create an empty file")
+	util.FinishBody(resp.Body)
+	token := resp.Header.Get("Lock-Token")
+	if len(token) == 0 {
+		t.Fatalf("expected a lock token")
+	}
+
+	resp = doWebDAVRequest(t, tester10, "PUT", path, "unlocked", nil)
+	statusMustBe(t, 423, resp, "expected write without the token to fail")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "PUT", path, "locked", map[string]string{"If": "(" + token + ")"})
+	statusMustBe(t, 204, resp, "expected write with the token to succeed")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "UNLOCK", path, "", map[string]string{"Lock-Token": token})
+	statusMustBe(t, 204, resp, "expected unlock")
+	util.FinishBody(resp.Body)
+
+	resp = doWebDAVRequest(t, tester10, "DELETE", path, "", nil)
+	statusMustBe(t, 204, resp, "expected file to be deleted once unlocked")
+	util.FinishBody(resp.Body)
+}
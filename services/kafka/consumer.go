@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+)
+
+// Consumer reads every partition of a topic. Unlike a member of a consumer
+// group, each Consumer reads the whole topic, so that every instance of the
+// service sees every event, as is needed for state that each instance keeps
+// for itself.
+type Consumer struct {
+	consumer sarama.Consumer
+}
+
+// NewConsumer starts reading each partition of the topic from the offset given
+// for it, calling handle for each message of a partition in turn. Partitions
+// without an offset, or whose offset is no longer retained, are read from the
+// oldest message retained. Partitions added to the topic later are not read.
+func NewConsumer(brokerList []string, topic string, offsets map[int32]int64, handle func(partition int32, offset int64, value []byte), logger *zap.Logger) (*Consumer, error) {
+	consumer, err := sarama.NewConsumer(brokerList, nil)
+	if err != nil {
+		return nil, err
+	}
+	partitions, err := consumer.Partitions(topic)
+	if err != nil {
+		consumer.Close()
+		return nil, err
+	}
+	c := &Consumer{consumer: consumer}
+	for _, partition := range partitions {
+		offset, ok := offsets[partition]
+		if !ok {
+			offset = sarama.OffsetOldest
+		}
+		pc, err := consumer.ConsumePartition(topic, partition, offset)
+		if err == sarama.ErrOffsetOutOfRange {
+			logger.Warn("kafka offset no longer retained, reading from the oldest retained", zap.String("topic", topic), zap.Int32("partition", partition), zap.Int64("offset", offset))
+			pc, err = consumer.ConsumePartition(topic, partition, sarama.OffsetOldest)
+		}
+		if err != nil {
+			consumer.Close()
+			return nil, err
+		}
+		go func(pc sarama.PartitionConsumer) {
+			for msg := range pc.Messages() {
+				handle(msg.Partition, msg.Offset, msg.Value)
+			}
+		}(pc)
+	}
+	logger.Info("consuming kafka topic", zap.String("topic", topic), zap.Int("partitions", len(partitions)))
+	return c, nil
+}
+
+// Close stops reading the topic
+func (c *Consumer) Close() error {
+	return c.consumer.Close()
+}
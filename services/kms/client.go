@@ -0,0 +1,118 @@
+package kms
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client is a KeyProvider that asks a key management service to wrap and
+// unwrap data keys over HTTP, so that the keys themselves never leave it.
+// The service is expected to implement the API served by NewHandler:
+//
+//   POST {url}/keys/{keyId}/wrap    {"plaintext": "base64"}  => {"ciphertext": "base64"}
+//   POST {url}/keys/{keyId}/unwrap  {"ciphertext": "base64"} => {"plaintext": "base64"}
+//   POST {url}/keys/{keyId}/rotate                           => 204
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+// keyRequest is the body of requests to wrap and unwrap, and of the responses
+type keyRequest struct {
+	Plaintext  []byte `json:"plaintext,omitempty"`
+	Ciphertext []byte `json:"ciphertext,omitempty"`
+}
+
+// ClientOpt sets an option on a Client.
+type ClientOpt func(*Client)
+
+// WithTLSConfig sets the TLS configuration used to connect to the service.
+func WithTLSConfig(tlsConfig *tls.Config) ClientOpt {
+	return func(c *Client) {
+		c.client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+}
+
+// WithTimeout sets the maximum duration of a request to the service.
+func WithTimeout(timeout time.Duration) ClientOpt {
+	return func(c *Client) {
+		c.client.Timeout = timeout
+	}
+}
+
+// NewClient constructs a Client for the service at a URL.
+func NewClient(url string, opts ...ClientOpt) *Client {
+	c := &Client{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Wrap asks the service to encrypt a data key with the current version of a key
+func (c *Client) Wrap(keyID string, dataKey []byte) ([]byte, error) {
+	var response keyRequest
+	if err := c.post(keyID, "wrap", keyRequest{Plaintext: dataKey}, &response); err != nil {
+		return nil, err
+	}
+	return response.Ciphertext, nil
+}
+
+// Unwrap asks the service to decrypt a data key wrapped with any version of a key
+func (c *Client) Unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	var response keyRequest
+	if err := c.post(keyID, "unwrap", keyRequest{Ciphertext: wrapped}, &response); err != nil {
+		return nil, err
+	}
+	return response.Plaintext, nil
+}
+
+// Rotate asks the service to make a new version of a key current
+func (c *Client) Rotate(keyID string) error {
+	return c.post(keyID, "rotate", nil, nil)
+}
+
+func (c *Client) post(keyID, operation string, request interface{}, response interface{}) error {
+	if err := ValidKeyID(keyID); err != nil {
+		return err
+	}
+	var body []byte
+	if request != nil {
+		var err error
+		if body, err = json.Marshal(request); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest("POST", c.url+"/keys/"+keyID+"/"+operation, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrKeyNotFound
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("key service responded to %s with %d: %s", operation, resp.StatusCode, strings.TrimSpace(string(content)))
+	case response == nil:
+		return nil
+	}
+	return json.Unmarshal(content, response)
+}
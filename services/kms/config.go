@@ -0,0 +1,56 @@
+package kms
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+)
+
+// NewKeyProvider constructs the provider selected by the options, or nil if
+// master keys are configured directly
+func NewKeyProvider(opts config.KeyProviderOpts) (KeyProvider, error) {
+	switch opts.Type {
+	case "":
+		return nil, nil
+	case config.KeyProviderKeyring:
+		return NewKeyring(opts.Keyring)
+	case config.KeyProviderKMS:
+		var clientOpts []ClientOpt
+		if opts.Trust != "" || opts.Cert != "" {
+			tlsConfig, err := newTLSConfig(opts)
+			if err != nil {
+				return nil, err
+			}
+			clientOpts = append(clientOpts, WithTLSConfig(tlsConfig))
+		}
+		return NewClient(opts.URL, clientOpts...), nil
+	}
+	return nil, fmt.Errorf("unknown key provider %s", opts.Type)
+}
+
+// newTLSConfig trusts the CA given for the key management service, and
+// presents the client certificate given, if any
+func newTLSConfig(opts config.KeyProviderOpts) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if opts.Trust != "" {
+		trustBytes, err := ioutil.ReadFile(opts.Trust)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing CA trust %s: %v", opts.Trust, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(trustBytes) {
+			return nil, fmt.Errorf("Error adding CA trust %s to pool", opts.Trust)
+		}
+	}
+	if opts.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(opts.Cert, opts.Key)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing cert: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,61 @@
+package kms
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// NewHandler serves the API expected by Client from a KeyProvider, such as a
+// Keyring, so that a local stand-in for a key management service may be run
+// where none is available.
+func NewHandler(p KeyProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) != 3 || parts[0] != "keys" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		keyID, operation := parts[1], parts[2]
+		if err := ValidKeyID(keyID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var request, response keyRequest
+		if operation != "rotate" {
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		var err error
+		switch operation {
+		case "wrap":
+			response.Ciphertext, err = p.Wrap(keyID, request.Plaintext)
+		case "unwrap":
+			response.Plaintext, err = p.Unwrap(keyID, request.Ciphertext)
+		case "rotate":
+			err = p.Rotate(keyID)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case err == ErrKeyNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case operation == "rotate":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(response)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package kms
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Keyring is a KeyProvider holding its keys in a local file. The file must be
+// kept safe, and distributed to every instance that uses it, as a private key
+// would be. Keys are created when first used to wrap a data key.
+type Keyring struct {
+	path string
+	mu   sync.Mutex
+	keys map[string][]keyringVersion
+}
+
+// keyringFile is the content of a keyring file
+type keyringFile struct {
+	Keys map[string][]keyringVersion `json:"keys"`
+}
+
+// keyringVersion is a version of a key in a keyring. The last version of a
+// key is current.
+type keyringVersion struct {
+	Version     uint32    `json:"version"`
+	CreatedDate time.Time `json:"createdDate"`
+	Key         []byte    `json:"key"`
+}
+
+// wrappedHeaderSize is the size of the version that prefixes wrapped keys
+const wrappedHeaderSize = 4
+
+// NewKeyring opens the keyring in a file, creating the file if it does not
+// exist
+func NewKeyring(path string) (*Keyring, error) {
+	k := &Keyring{path: path, keys: make(map[string][]keyringVersion)}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return k, k.save()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file keyringFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+	if file.Keys != nil {
+		k.keys = file.Keys
+	}
+	return k, nil
+}
+
+// Wrap encrypts a data key with the current version of a key
+func (k *Keyring) Wrap(keyID string, dataKey []byte) ([]byte, error) {
+	if err := ValidKeyID(keyID); err != nil {
+		return nil, err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.keys[keyID]) == 0 {
+		if err := k.addVersion(keyID); err != nil {
+			return nil, err
+		}
+	}
+	versions := k.keys[keyID]
+	current := versions[len(versions)-1]
+	aead, err := newAEAD(current.Key)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := make([]byte, wrappedHeaderSize+aead.NonceSize())
+	binary.BigEndian.PutUint32(wrapped, current.Version)
+	if _, err := rand.Read(wrapped[wrappedHeaderSize:]); err != nil {
+		return nil, err
+	}
+	return aead.Seal(wrapped, wrapped[wrappedHeaderSize:], dataKey, []byte(keyID)), nil
+}
+
+// Unwrap decrypts a data key wrapped with any version of a key
+func (k *Keyring) Unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	if err := ValidKeyID(keyID); err != nil {
+		return nil, err
+	}
+	if len(wrapped) < wrappedHeaderSize {
+		return nil, errors.New("wrapped key is too short")
+	}
+	version := binary.BigEndian.Uint32(wrapped)
+	k.mu.Lock()
+	var key []byte
+	for _, v := range k.keys[keyID] {
+		if v.Version == version {
+			key = v.Key
+		}
+	}
+	k.mu.Unlock()
+	if key == nil {
+		return nil, ErrKeyNotFound
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < wrappedHeaderSize+aead.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+	nonce := wrapped[wrappedHeaderSize : wrappedHeaderSize+aead.NonceSize()]
+	return aead.Open(nil, nonce, wrapped[wrappedHeaderSize+aead.NonceSize():], []byte(keyID))
+}
+
+// Rotate makes a new version of a key current
+func (k *Keyring) Rotate(keyID string) error {
+	if err := ValidKeyID(keyID); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.addVersion(keyID)
+}
+
+// addVersion creates a new current version of a key, and saves the keyring.
+// The caller must hold the lock.
+func (k *Keyring) addVersion(keyID string) error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	versions := k.keys[keyID]
+	version := uint32(1)
+	if len(versions) > 0 {
+		version = versions[len(versions)-1].Version + 1
+	}
+	k.keys[keyID] = append(versions, keyringVersion{Version: version, CreatedDate: time.Now().UTC(), Key: key})
+	if err := k.save(); err != nil {
+		k.keys[keyID] = versions
+		return err
+	}
+	return nil
+}
+
+// save writes the keyring to a temporary file readable only by its owner, and
+// renames it into place
+func (k *Keyring) save() error {
+	content, err := json.MarshalIndent(keyringFile{Keys: k.keys}, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(k.path), filepath.Base(k.path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), k.path)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,64 @@
+package kms
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// KeyProvider wraps and unwraps data keys with key encryption keys that it
+// holds, so that the data keys are never stored in the clear. Key encryption
+// keys are identified by a key id, and may have several versions.
+type KeyProvider interface {
+	// Wrap encrypts a data key with the current version of a key. A key that
+	// does not yet exist may be created.
+	Wrap(keyID string, dataKey []byte) ([]byte, error)
+	// Unwrap decrypts a data key wrapped with any version of a key.
+	Unwrap(keyID string, wrapped []byte) ([]byte, error)
+	// Rotate makes a new version of a key current. Data keys wrapped with
+	// earlier versions may still be unwrapped.
+	Rotate(keyID string) error
+}
+
+// ErrKeyNotFound is returned when a key id is not known to a provider
+var ErrKeyNotFound = errors.New("key not found")
+
+// ErrInvalidKeyID is returned for key ids that are not letters, digits,
+// periods, underscores and dashes
+var ErrInvalidKeyID = errors.New("key id may contain only letters, digits, periods, underscores and dashes")
+
+var keyIDRx = regexp.MustCompile("^[0-9a-zA-Z_.-]+$")
+
+// ValidKeyID checks that a key id may be used in a keyring or URL
+func ValidKeyID(keyID string) error {
+	if !keyIDRx.MatchString(keyID) {
+		return ErrInvalidKeyID
+	}
+	return nil
+}
+
+// NewDataKey creates a random data key, hex encoded so that it may be used as
+// a passphrase
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return []byte(hex.EncodeToString(key)), nil
+}
+
+// Rewrap unwraps a data key and wraps it again with the current version of
+// its key
+func Rewrap(p KeyProvider, keyID string, wrapped []byte) ([]byte, []byte, error) {
+	dataKey, err := p.Unwrap(keyID, wrapped)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to unwrap data key: %v", err)
+	}
+	rewrapped, err := p.Wrap(keyID, dataKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to wrap data key: %v", err)
+	}
+	return dataKey, rewrapped, nil
+}
@@ -0,0 +1,99 @@
+package kms_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bitbucket.di2e.net/dime/object-drive-server/services/kms"
+)
+
+func newTestKeyring(t *testing.T) (*kms.Keyring, string, func()) {
+	dir, err := ioutil.TempDir("", "keyring")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	path := filepath.Join(dir, "keyring.json")
+	k, err := kms.NewKeyring(path)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("unable to create keyring: %v", err)
+	}
+	return k, path, func() { os.RemoveAll(dir) }
+}
+
+func testProvider(t *testing.T, p kms.KeyProvider) {
+	dataKey, err := kms.NewDataKey()
+	if err != nil {
+		t.Fatalf("unable to create data key: %v", err)
+	}
+	wrapped, err := p.Wrap("zone1", dataKey)
+	if err != nil {
+		t.Fatalf("unable to wrap: %v", err)
+	}
+	if bytes.Contains(wrapped, dataKey) {
+		t.Fatalf("wrapped key contains the data key")
+	}
+	if err := p.Rotate("zone1"); err != nil {
+		t.Fatalf("unable to rotate: %v", err)
+	}
+	unwrapped, rewrapped, err := kms.Rewrap(p, "zone1", wrapped)
+	if err != nil {
+		t.Fatalf("unable to unwrap with a previous version: %v", err)
+	}
+	if !bytes.Equal(unwrapped, dataKey) {
+		t.Fatalf("unwrapped key does not match")
+	}
+	if bytes.Equal(rewrapped, wrapped) {
+		t.Fatalf("rewrapped key is unchanged")
+	}
+	if _, err := p.Unwrap("zone2", wrapped); err == nil {
+		t.Fatalf("key wrapped for one key id unwrapped with another")
+	}
+	if _, err := p.Wrap("../zone", dataKey); err == nil {
+		t.Fatalf("invalid key id accepted")
+	}
+}
+
+func TestKeyring(t *testing.T) {
+	k, path, cleanup := newTestKeyring(t)
+	defer cleanup()
+	testProvider(t, k)
+
+	// Keys survive reopening the keyring
+	wrapped, err := k.Wrap("zone1", []byte("datakey"))
+	if err != nil {
+		t.Fatalf("unable to wrap: %v", err)
+	}
+	reopened, err := kms.NewKeyring(path)
+	if err != nil {
+		t.Fatalf("unable to reopen keyring: %v", err)
+	}
+	unwrapped, err := reopened.Unwrap("zone1", wrapped)
+	if err != nil || string(unwrapped) != "datakey" {
+		t.Fatalf("unable to unwrap with reopened keyring: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unable to stat keyring: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("keyring is readable by others: %v", info.Mode())
+	}
+}
+
+func TestClient(t *testing.T) {
+	k, _, cleanup := newTestKeyring(t)
+	defer cleanup()
+	server := httptest.NewServer(kms.NewHandler(k))
+	defer server.Close()
+	c := kms.NewClient(server.URL)
+	testProvider(t, c)
+
+	if _, err := c.Unwrap("missing", []byte("0000wrapped")); err != kms.ErrKeyNotFound {
+		t.Fatalf("expected key not found, got %v", err)
+	}
+}
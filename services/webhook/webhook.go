@@ -0,0 +1,314 @@
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"bitbucket.di2e.net/dime/object-drive-server/config"
+
+	"go.uber.org/zap"
+)
+
+// Notification is a message to be delivered by HTTP POST to a callback URL.
+type Notification struct {
+	// SubscriptionID identifies the subscription this notification is for,
+	// and is included when logging delivery failures.
+	SubscriptionID string
+	// CallbackURL is the URL the notification is posted to.
+	CallbackURL string
+	// Body is the JSON encoded content of the notification.
+	Body []byte
+}
+
+// Dispatcher delivers notifications asynchronously. Each notification is
+// attempted until the callback URL responds with a 2xx status code, or the
+// retries are exhausted, at which point it is written to the dead letter log.
+type Dispatcher struct {
+	allowed      []string
+	allowPrivate bool
+	client       *http.Client
+	logger       *zap.Logger
+	queue        chan Notification
+	queueSize    int
+	retries      int
+	retryDelay   time.Duration
+	workers      int
+}
+
+// ErrPrivateAddress is returned when a callback resolves to a loopback,
+// private or link-local address, and private networks are not allowed.
+var ErrPrivateAddress = errors.New("callback address is not public")
+
+// privateNetworks are the address ranges refused unless private networks are
+// allowed, including the loopback, link-local and unique local ranges
+var privateNetworks = parseCIDRs(
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+	"172.16.0.0/12", "192.168.0.0/16", "::/128", "::1/128", "fc00::/7", "fe80::/10",
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// isPrivate reports whether an address is not reachable on the public internet
+func isPrivate(ip net.IP) bool {
+	if ip.IsMulticast() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCallbackURL returns an error if notifications may not be posted to a
+// callback URL. Each allowed entry is either a host name, or a URL prefix
+// that includes the scheme. If none are given, any host is allowed. A host
+// given as a literal address must be public unless allowPrivate is set.
+// Host names are checked once resolved, when notifications are delivered.
+func CheckCallbackURL(callbackURL string, allowed []string, allowPrivate bool) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return errors.New("callbackUrl is not a valid url")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("callbackUrl must use http or https")
+	}
+	if len(u.Hostname()) == 0 {
+		return errors.New("callbackUrl must specify a host")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !allowPrivate && isPrivate(ip) {
+		return errors.New("callbackUrl must not be a loopback, private or link-local address")
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, entry := range allowed {
+		if strings.Contains(entry, "://") {
+			if matchesCallbackPrefix(u, entry) {
+				return nil
+			}
+		} else if strings.EqualFold(u.Hostname(), entry) {
+			return nil
+		}
+	}
+	return errors.New("callbackUrl is not an allowed callback")
+}
+
+// matchesCallbackPrefix reports whether a callback URL is beneath an allowed
+// URL prefix. The scheme and host, including any port, must be the same, and
+// the cleaned path of the callback must be the path of the prefix or beneath
+// it, so that neither a longer host name nor user info in the callback can
+// pass for the allowed host.
+func matchesCallbackPrefix(u *url.URL, entry string) bool {
+	prefix, err := url.Parse(entry)
+	if err != nil || len(prefix.Host) == 0 {
+		return false
+	}
+	if !strings.EqualFold(u.Scheme, prefix.Scheme) || !strings.EqualFold(u.Host, prefix.Host) {
+		return false
+	}
+	allowedPath := strings.TrimSuffix(path.Clean("/"+prefix.Path), "/")
+	if len(allowedPath) == 0 {
+		return true
+	}
+	callbackPath := path.Clean("/" + u.Path)
+	return callbackPath == allowedPath || strings.HasPrefix(callbackPath, allowedPath+"/")
+}
+
+// Opt sets an option on a Dispatcher.
+type Opt func(*Dispatcher)
+
+// WithAllowedCallbacks restricts the callback URLs notifications are posted
+// to, as described by CheckCallbackURL.
+func WithAllowedCallbacks(allowed []string) Opt {
+	return func(d *Dispatcher) {
+		d.allowed = allowed
+	}
+}
+
+// WithPrivateNetworks permits posting notifications to callbacks that resolve
+// to loopback, private or link-local addresses.
+func WithPrivateNetworks(allow bool) Opt {
+	return func(d *Dispatcher) {
+		d.allowPrivate = allow
+	}
+}
+
+// WithLogger sets a custom logger on a Dispatcher.
+func WithLogger(logger *zap.Logger) Opt {
+	return func(d *Dispatcher) {
+		d.logger = logger
+	}
+}
+
+// WithQueueSize sets the number of notifications that may be waiting for delivery.
+func WithQueueSize(size int) Opt {
+	return func(d *Dispatcher) {
+		d.queueSize = size
+	}
+}
+
+// WithRetries sets the number of additional delivery attempts, and the initial
+// delay between them. The delay doubles after each failed attempt.
+func WithRetries(retries int, delay time.Duration) Opt {
+	return func(d *Dispatcher) {
+		d.retries = retries
+		d.retryDelay = delay
+	}
+}
+
+// WithTimeout sets the maximum duration of a single delivery attempt.
+func WithTimeout(timeout time.Duration) Opt {
+	return func(d *Dispatcher) {
+		d.client.Timeout = timeout
+	}
+}
+
+// WithWorkers sets the number of notifications that may be delivered concurrently.
+func WithWorkers(workers int) Opt {
+	return func(d *Dispatcher) {
+		d.workers = workers
+	}
+}
+
+// NewDispatcher constructs a Dispatcher with internal defaults and supplied
+// options, and starts its delivery workers.
+func NewDispatcher(opts ...Opt) *Dispatcher {
+	d := Dispatcher{}
+	defaults(&d)
+	for _, opt := range opts {
+		opt(&d)
+	}
+	d.queue = make(chan Notification, d.queueSize)
+	d.start()
+	return &d
+}
+
+func defaults(d *Dispatcher) {
+	// Addresses are checked as connections are made, after the host is
+	// resolved, so that a name cannot be pointed at an internal address once
+	// it has been subscribed. Redirects are not followed for the same reason.
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || (!d.allowPrivate && isPrivate(ip)) {
+				return ErrPrivateAddress
+			}
+			return nil
+		},
+	}
+	d.client = &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	d.logger = config.RootLogger
+	d.queueSize = 1000
+	d.retries = 3
+	d.retryDelay = 5 * time.Second
+	d.workers = 4
+}
+
+// Enqueue schedules a notification for delivery without blocking. If the
+// queue is full, the notification is dead lettered immediately.
+func (d *Dispatcher) Enqueue(n Notification) {
+	select {
+	case d.queue <- n:
+	default:
+		d.deadLetter(n, 0, fmt.Errorf("delivery queue is full"))
+	}
+}
+
+func (d *Dispatcher) start() {
+	for i := 0; i < d.workers; i++ {
+		go func() {
+			for n := range d.queue {
+				d.deliver(n)
+			}
+		}()
+	}
+}
+
+// deliver posts the notification, retrying with exponential backoff.
+func (d *Dispatcher) deliver(n Notification) {
+	// The callbacks allowed may have changed since the subscription was made
+	if err := CheckCallbackURL(n.CallbackURL, d.allowed, d.allowPrivate); err != nil {
+		d.deadLetter(n, 0, err)
+		return
+	}
+	delay := d.retryDelay
+	var err error
+	attempts := 0
+	for attempts <= d.retries {
+		if attempts > 0 {
+			time.Sleep(delay)
+			delay = delay * 2
+		}
+		attempts++
+		if err = d.post(n); err == nil {
+			return
+		}
+		d.logger.Warn("webhook delivery attempt failed",
+			zap.String("subscriptionId", n.SubscriptionID),
+			zap.String("callbackUrl", n.CallbackURL),
+			zap.Int("attempt", attempts),
+			zap.Error(err))
+	}
+	d.deadLetter(n, attempts, err)
+}
+
+func (d *Dispatcher) post(n Notification) error {
+	req, err := http.NewRequest("POST", n.CallbackURL, bytes.NewReader(n.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// deadLetter records a notification that could not be delivered so that it
+// may be reviewed, and if necessary replayed, by operators.
+func (d *Dispatcher) deadLetter(n Notification, attempts int, err error) {
+	d.logger.Error("webhook dead letter",
+		zap.String("subscriptionId", n.SubscriptionID),
+		zap.String("callbackUrl", n.CallbackURL),
+		zap.Int("attempts", attempts),
+		zap.String("body", string(n.Body)),
+		zap.Error(err))
+}
@@ -0,0 +1,134 @@
+package webhook_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"bitbucket.di2e.net/dime/object-drive-server/services/webhook"
+)
+
+func TestDispatcherRetriesUntilDelivered(t *testing.T) {
+	var attempts int32
+	delivered := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		delivered <- string(body)
+	}))
+	defer server.Close()
+
+	d := webhook.NewDispatcher(webhook.WithLogger(zap.NewNop()), webhook.WithRetries(3, time.Millisecond), webhook.WithPrivateNetworks(true))
+	d.Enqueue(webhook.Notification{SubscriptionID: "1", CallbackURL: server.URL, Body: []byte(`{"a":1}`)})
+
+	select {
+	case body := <-delivered:
+		if body != `{"a":1}` {
+			t.Errorf("unexpected body delivered: %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("notification was not delivered")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestDispatcherStopsAfterRetries(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	d := webhook.NewDispatcher(webhook.WithLogger(zap.NewNop()), webhook.WithRetries(2, time.Millisecond), webhook.WithPrivateNetworks(true))
+	d.Enqueue(webhook.Notification{SubscriptionID: "2", CallbackURL: server.URL, Body: []byte(`{}`)})
+
+	// Allow time for the initial attempt, both retries, and a would-be fourth
+	time.Sleep(250 * time.Millisecond)
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestDispatcherRefusesPrivateAddresses(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+	}))
+	defer server.Close()
+
+	// A name is refused once resolved, as well as a literal address
+	d := webhook.NewDispatcher(webhook.WithLogger(zap.NewNop()), webhook.WithRetries(0, time.Millisecond))
+	d.Enqueue(webhook.Notification{SubscriptionID: "3", CallbackURL: server.URL, Body: []byte(`{}`)})
+	d.Enqueue(webhook.Notification{SubscriptionID: "4", CallbackURL: strings.Replace(server.URL, "127.0.0.1", "localhost", 1), Body: []byte(`{}`)})
+
+	time.Sleep(250 * time.Millisecond)
+	if got := atomic.LoadInt32(&attempts); got != 0 {
+		t.Errorf("expected no deliveries to a loopback address, got %d", got)
+	}
+}
+
+func TestDispatcherDoesNotFollowRedirects(t *testing.T) {
+	var redirected int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&redirected, 1)
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	d := webhook.NewDispatcher(webhook.WithLogger(zap.NewNop()), webhook.WithRetries(0, time.Millisecond), webhook.WithPrivateNetworks(true))
+	d.Enqueue(webhook.Notification{SubscriptionID: "5", CallbackURL: server.URL, Body: []byte(`{}`)})
+
+	time.Sleep(250 * time.Millisecond)
+	if got := atomic.LoadInt32(&redirected); got != 0 {
+		t.Errorf("expected redirect not to be followed, got %d deliveries", got)
+	}
+}
+
+func TestCheckCallbackURL(t *testing.T) {
+	allowed := []string{"hooks.example.com", "https://partner.example.org/odrive/", "https://notify.example.com"}
+	cases := []struct {
+		callbackURL  string
+		allowed      []string
+		allowPrivate bool
+		ok           bool
+	}{
+		{"https://anywhere.example.net/callback", nil, false, true},
+		{"ftp://hooks.example.com/callback", nil, false, false},
+		{"https:///callback", nil, false, false},
+		{"http://127.0.0.1:8080/callback", nil, false, false},
+		{"http://169.254.169.254/latest/meta-data", nil, false, false},
+		{"http://10.1.2.3/callback", nil, false, false},
+		{"http://[::1]/callback", nil, false, false},
+		{"http://10.1.2.3/callback", nil, true, true},
+		{"https://HOOKS.example.com/any/path", allowed, false, true},
+		{"https://partner.example.org/odrive/callback", allowed, false, true},
+		{"https://partner.example.org/other", allowed, false, false},
+		{"https://anywhere.example.net/callback", allowed, false, false},
+		{"https://partner.example.org/odrive/../admin", allowed, false, false},
+		{"https://partner.example.org/odriveadmin", allowed, false, false},
+		{"https://notify.example.com/callback", allowed, false, true},
+		{"http://notify.example.com/callback", allowed, false, false},
+		{"https://notify.example.com.attacker.net/", allowed, false, false},
+		{"https://notify.example.com@attacker.net/", allowed, false, false},
+		{"https://notify.example.com:8443/callback", allowed, false, false},
+	}
+	for _, c := range cases {
+		err := webhook.CheckCallbackURL(c.callbackURL, c.allowed, c.allowPrivate)
+		if (err == nil) != c.ok {
+			t.Errorf("%s allowed %v private %v: expected ok %v, got %v", c.callbackURL, c.allowed, c.allowPrivate, c.ok, err)
+		}
+	}
+}